@@ -0,0 +1,67 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"os"
+
+	v1 "github.com/bhojpur/erp/pkg/api/v1"
+	"github.com/spf13/cobra"
+)
+
+//connection flags shared by all commands which call the API, the environment variables are used as defaults
+var (
+	apiClientCode string
+	apiUserName   string
+	apiPassword   string
+	apiSessionKey string
+	apiURL        string
+)
+
+func newAPIClient() (*v1.Client, error) {
+	if apiClientCode == "" {
+		return nil, errors.New("client code is required, use --client-code or ERP_CLIENT_CODE")
+	}
+
+	if apiSessionKey != "" {
+		return v1.NewClientWithURL(apiSessionKey, apiClientCode, "", apiURL, nil, nil)
+	}
+
+	if apiUserName == "" || apiPassword == "" {
+		return nil, errors.New("either session key or user name and password are required")
+	}
+
+	return v1.ClientBuilder{
+		UserName:   apiUserName,
+		Password:   apiPassword,
+		ClientCode: apiClientCode,
+		URL:        apiURL,
+	}.Build(), nil
+}
+
+func addAPIConnectionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&apiClientCode, "client-code", os.Getenv("ERP_CLIENT_CODE"), "Bhojpur ERP client code")
+	cmd.Flags().StringVar(&apiUserName, "username", os.Getenv("ERP_USERNAME"), "API user name")
+	cmd.Flags().StringVar(&apiPassword, "password", os.Getenv("ERP_PASSWORD"), "API user password")
+	cmd.Flags().StringVar(&apiSessionKey, "session-key", os.Getenv("ERP_SESSION_KEY"), "existing session key, the user name and password are not needed then")
+	cmd.Flags().StringVar(&apiURL, "api-url", os.Getenv("ERP_API_URL"), "custom API url")
+}
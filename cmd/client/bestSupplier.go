@@ -0,0 +1,193 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/purchasing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bestSupplierProductIDs     []int
+	bestSupplierQuantity       float64
+	bestSupplierDate           string
+	bestSupplierExpiringWithin time.Duration
+	bestSupplierBaseCurrency   string
+	bestSupplierRates          []string
+	bestSupplierOutput         string
+)
+
+var bestSupplierHeader = []string{
+	"product_id", "quantity", "supplier_id", "supplier_name", "supplier_price_list_id", "supplier_code", "currency",
+	"unit_price", "unit_cost", "order_quantity", "total_cost", "valid_to", "expiring", "other_suppliers",
+}
+
+// bestSupplierCmd represents the best-supplier command
+var bestSupplierCmd = &cobra.Command{
+	Use:   "best-supplier",
+	Short: "Recommends the cheapest supplier of the products from the active supplier price lists",
+	Long: "Loads all active supplier price lists and ranks the suppliers of every product by the net unit cost " +
+		"in the base currency for the quantity, the price lists which are about to expire are reported as warnings",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if bestSupplierQuantity <= 0 {
+			return fmt.Errorf("quantity must be positive, got %v", bestSupplierQuantity)
+		}
+
+		rates, err := parseRates(bestSupplierRates)
+		if err != nil {
+			return err
+		}
+
+		selector := purchasing.NewSelector(purchasing.FixedRates{BaseCurrency: bestSupplierBaseCurrency, Rates: rates})
+		selector.ExpiryWarning = bestSupplierExpiringWithin
+		if bestSupplierDate != "" {
+			selector.Date, err = time.Parse(sharedCommon.DateLayout, bestSupplierDate)
+			if err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", bestSupplierDate)
+			}
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		if err := selector.LoadPriceLists(ctx, cli.PricesManager, sharedCommon.ListingSettings{}); err != nil {
+			return err
+		}
+		if err := selector.LoadSupplierCurrencies(ctx, cli.CustomerManager, sharedCommon.ListingSettings{}); err != nil {
+			return err
+		}
+
+		for _, priceList := range selector.ExpiringPriceLists() {
+			log.Warnf("supplier price list %d %q of supplier %d %q expires on %s, %d days left",
+				priceList.SupplierPriceListID, priceList.Name, priceList.SupplierID, priceList.SupplierName, priceList.ValidTo, priceList.DaysLeft)
+		}
+
+		productIDs := bestSupplierProductIDs
+		if len(productIDs) == 0 {
+			productIDs = selector.ProductIDs()
+		}
+		recommendations, err := selector.Recommend(productIDs, bestSupplierQuantity)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if bestSupplierOutput != "" && bestSupplierOutput != "-" {
+			file, err := os.Create(bestSupplierOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return writeRecommendations(out, recommendations)
+	},
+}
+
+func writeRecommendations(out io.Writer, recommendations []purchasing.Recommendation) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(bestSupplierHeader); err != nil {
+		return err
+	}
+
+	for _, recommendation := range recommendations {
+		best := recommendation.Recommended
+		if best == nil {
+			log.Warnf("no supplier sells product %d", recommendation.ProductID)
+			continue
+		}
+		err := w.Write([]string{
+			strconv.Itoa(recommendation.ProductID),
+			formatFloat(recommendation.Quantity),
+			strconv.Itoa(best.SupplierID),
+			best.SupplierName,
+			strconv.Itoa(best.SupplierPriceListID),
+			best.SupplierCode,
+			best.Currency,
+			formatFloat(best.UnitPrice),
+			strconv.FormatFloat(best.UnitCost, 'f', 4, 64),
+			formatFloat(best.OrderQuantity),
+			strconv.FormatFloat(best.TotalCost, 'f', 2, 64),
+			best.ValidTo,
+			strconv.FormatBool(best.Expiring),
+			strconv.Itoa(len(recommendation.Candidates) - 1),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+//parseRates converts the CODE=rate pairs to the exchange rates table
+func parseRates(pairs []string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid rate %q, expected CODE=rate", pair)
+		}
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate %q, expected a positive number", pair)
+		}
+		rates[strings.ToUpper(parts[0])] = rate
+	}
+
+	return rates, nil
+}
+
+func init() {
+	bestSupplierCmd.Flags().IntSliceVar(&bestSupplierProductIDs, "product", nil, "IDs of the products, all products of the price lists if empty")
+	bestSupplierCmd.Flags().Float64VarP(&bestSupplierQuantity, "quantity", "q", 1, "quantity to buy")
+	bestSupplierCmd.Flags().StringVar(&bestSupplierDate, "date", "", "date of the purchase as YYYY-MM-DD, today if empty")
+	bestSupplierCmd.Flags().DurationVar(&bestSupplierExpiringWithin, "expiring-within", purchasing.DefaultExpiryWarning, "report the price lists which end within this period")
+	bestSupplierCmd.Flags().StringVar(&bestSupplierBaseCurrency, "base-currency", "", "currency of the costs, the suppliers without a currency use it")
+	bestSupplierCmd.Flags().StringArrayVar(&bestSupplierRates, "rate", nil, "exchange rate to the base currency as CODE=rate, can be repeated")
+	bestSupplierCmd.Flags().StringVarP(&bestSupplierOutput, "output", "o", "-", "output file, - for stdout")
+	addAPIConnectionFlags(bestSupplierCmd)
+	rootCmd.AddCommand(bestSupplierCmd)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/erp/pkg/api/v1"
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/currency"
+	"github.com/spf13/cobra"
+)
+
+var (
+	currencyRatesFile string
+	currencyECBFiles  []string
+	currencyFrom      string
+	currencyTo        string
+	currencyOutput    string
+)
+
+// currencyCmd represents the currency command
+var currencyCmd = &cobra.Command{
+	Use:   "currency",
+	Short: "Keeps the dated exchange rates and reports the realised exchange differences",
+}
+
+var currencyRatesCmd = &cobra.Command{
+	Use:   "rates",
+	Short: "Writes the exchange rates of the account currencies and the ECB reference rates as CSV",
+	Long: "Writes the dated exchange rates against the default currency of the account as CSV, the current rates " +
+		"of the account currencies are merged with the rates file and the ECB reference rates XML files",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		table, err := loadRateTable(ctx, cli, currencyRatesFile)
+		if err != nil {
+			return err
+		}
+		for _, path := range currencyECBFiles {
+			if err := readRatesFile(table, path, true); err != nil {
+				return err
+			}
+		}
+
+		return writeCurrencyOutput(table.WriteCSV)
+	},
+}
+
+var currencyFXCmd = &cobra.Command{
+	Use:   "fx",
+	Short: "Writes the realised exchange gains and losses of the payments of the period as CSV",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if currencyFrom == "" {
+			return errors.New("start of the period is required, use --from")
+		}
+		if currencyTo == "" {
+			return errors.New("end of the period is required, use --to")
+		}
+		from, err := time.Parse(sharedCommon.DateLayout, currencyFrom)
+		if err != nil {
+			return fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", currencyFrom)
+		}
+		to, err := time.Parse(sharedCommon.DateLayout, currencyTo)
+		if err != nil {
+			return fmt.Errorf("invalid end date %q, expected YYYY-MM-DD", currencyTo)
+		}
+		if to.Before(from) {
+			return errors.New("end of the period is before the start")
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		table, err := loadRateTable(ctx, cli, currencyRatesFile)
+		if err != nil {
+			return err
+		}
+		if table.TimeZone, err = company.GetTimeZone(ctx, cli.CompanyManager); err != nil {
+			return err
+		}
+		differences, err := table.LoadDifferences(ctx, cli.SalesManager, sharedCommon.ListingSettings{}, from, to)
+		if err != nil {
+			return err
+		}
+
+		return writeCurrencyOutput(func(out io.Writer) error {
+			return currency.WriteDifferencesCSV(out, table.Base, differences)
+		})
+	},
+}
+
+//loadRateTable creates the rate table against the default currency of the account with the current rates
+//of the account currencies and the rates of the file
+func loadRateTable(ctx context.Context, cli *v1.Client, path string) (*currency.Table, error) {
+	currencies, err := cli.GetCurrencies(ctx, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	base := ""
+	for _, c := range currencies {
+		if c.Default == "1" {
+			base = c.Code
+		}
+	}
+	if base == "" {
+		return nil, errors.New("the account has no default currency")
+	}
+
+	table := currency.NewTable(base)
+	if err := table.ImportCurrencies(currencies, time.Now()); err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := readRatesFile(table, path, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return table, nil
+}
+
+//readRatesFile reads the ECB reference rates XML or the CSV written by the rates command, the extension .xml
+//is taken as ECB XML too
+func readRatesFile(table *currency.Table, path string, ecb bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if ecb || strings.EqualFold(filepath.Ext(path), ".xml") {
+		err = table.ReadECB(file)
+	} else {
+		err = table.ReadCSV(file)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func writeCurrencyOutput(write func(out io.Writer) error) error {
+	if currencyOutput == "" || currencyOutput == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(currencyOutput)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{currencyRatesCmd, currencyFXCmd} {
+		cmd.Flags().StringVar(&currencyRatesFile, "rates", "", "exchange rates CSV written by the rates command or ECB reference rates XML")
+		cmd.Flags().StringVarP(&currencyOutput, "output", "o", "-", "output file, - for stdout")
+		addAPIConnectionFlags(cmd)
+	}
+	currencyRatesCmd.Flags().StringArrayVar(&currencyECBFiles, "ecb", nil, "ECB euro foreign exchange reference rates XML, daily or history, can be repeated")
+	currencyFXCmd.Flags().StringVar(&currencyFrom, "from", "", "first day of the period as YYYY-MM-DD")
+	currencyFXCmd.Flags().StringVar(&currencyTo, "to", "", "last day of the period as YYYY-MM-DD")
+
+	currencyCmd.AddCommand(currencyRatesCmd, currencyFXCmd)
+	rootCmd.AddCommand(currencyCmd)
+}
@@ -0,0 +1,184 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/einvoice"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	einvoiceOutput      string
+	einvoiceZeroRated   string
+	einvoiceExemption   string
+	einvoiceUnitCode    string
+	einvoiceSupplierID  int
+	einvoiceWarehouseID int
+	einvoiceDryRun      bool
+)
+
+// einvoiceCmd represents the einvoice command
+var einvoiceCmd = &cobra.Command{
+	Use:   "einvoice",
+	Short: "Exports the sales invoices as Peppol BIS Billing 3.0 UBL and imports the UBL purchase invoices",
+}
+
+var einvoiceExportCmd = &cobra.Command{
+	Use:   "export <documentID>",
+	Short: "Renders a sales invoice or a credit invoice as a UBL 2.1 invoice or credit note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		documentID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid document ID %q", args[0])
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		doc, err := einvoice.LoadDocument(ctx, cli.SalesManager, cli.CompanyManager, cli.CustomerManager, documentID)
+		if err != nil {
+			return err
+		}
+		doc.ZeroRatedCategory = einvoiceZeroRated
+		doc.ExemptionReason = einvoiceExemption
+		doc.UnitCode = einvoiceUnitCode
+
+		var out io.Writer = os.Stdout
+		if einvoiceOutput != "" && einvoiceOutput != "-" {
+			file, err := os.Create(einvoiceOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return doc.Write(out)
+	},
+}
+
+var einvoiceImportCmd = &cobra.Command{
+	Use:   "import <invoice.xml>",
+	Short: "Creates an unconfirmed purchase invoice or return from a UBL invoice or credit note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if einvoiceWarehouseID == 0 {
+			return errors.New("warehouse is required, use --warehouse")
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		invoice, err := einvoice.ParseInvoice(file)
+		if err != nil {
+			return err
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		supplierID := einvoiceSupplierID
+		if supplierID == 0 {
+			supplier, err := invoice.LoadSupplier(ctx, cli.CustomerManager, sharedCommon.ListingSettings{})
+			if err != nil {
+				return err
+			}
+			supplierID = int(supplier.SupplierId)
+		}
+
+		resolver := einvoice.NewProductResolver()
+		if err := resolver.Load(ctx, cli.ProductManager, cli.PricesManager, sharedCommon.ListingSettings{}, supplierID); err != nil {
+			return err
+		}
+		vatRates, err := cli.SalesManager.GetVatRates(ctx, map[string]string{})
+		if err != nil {
+			return err
+		}
+
+		filters, unresolved, err := invoice.PurchaseDocumentFilters(resolver, vatRates, einvoice.ImportOptions{
+			SupplierID:  supplierID,
+			WarehouseID: einvoiceWarehouseID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, line := range unresolved {
+			log.Warnf("no product found for line %s %q, it's added without a product", line.ID, line.Name)
+		}
+
+		if einvoiceDryRun {
+			log.Infof("would create a %s of %d lines for invoice %s of supplier %d",
+				filters["type"], len(invoice.Lines), invoice.Number, supplierID)
+			return nil
+		}
+
+		reports, err := cli.SalesManager.SavePurchaseDocument(ctx, filters)
+		if err != nil {
+			return err
+		}
+		if len(reports) > 0 {
+			log.Infof("created %s %d with number %s for invoice %s", filters["type"],
+				reports[0].InvoiceID, reports[0].InvoiceNo, invoice.Number)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	einvoiceExportCmd.Flags().StringVarP(&einvoiceOutput, "output", "o", "-", "output file, - for stdout")
+	einvoiceExportCmd.Flags().StringVar(&einvoiceZeroRated, "zero-rated-category", einvoice.ZeroRated, "VAT category of the rows with a zero rate")
+	einvoiceExportCmd.Flags().StringVar(&einvoiceExemption, "exemption-reason", "", "VAT exemption reason of the exempt category")
+	einvoiceExportCmd.Flags().StringVar(&einvoiceUnitCode, "unit-code", einvoice.DefaultUnitCode, "UN/ECE rec 20 unit of the quantities")
+	addAPIConnectionFlags(einvoiceExportCmd)
+
+	einvoiceImportCmd.Flags().IntVar(&einvoiceSupplierID, "supplier", 0, "supplier ID, found by the VAT number, the GLN or the registration code if empty")
+	einvoiceImportCmd.Flags().IntVar(&einvoiceWarehouseID, "warehouse", 0, "warehouse ID of the purchase document")
+	einvoiceImportCmd.Flags().BoolVar(&einvoiceDryRun, "dry-run", false, "only resolve the supplier and the products")
+	addAPIConnectionFlags(einvoiceImportCmd)
+
+	einvoiceCmd.AddCommand(einvoiceExportCmd, einvoiceImportCmd)
+	rootCmd.AddCommand(einvoiceCmd)
+}
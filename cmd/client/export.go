@@ -0,0 +1,137 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/export"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat          string
+	exportOutput          string
+	exportColumns         []string
+	exportFilters         []string
+	exportFetchers        int
+	exportRequestsPerSec  int
+	exportItemsPerRequest int
+	exportOrdered         bool
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:       "export <entity>",
+	Short:     "Streams all records of an entity to a CSV, NDJSON or Parquet file",
+	Long:      "Streams all records of an entity to a CSV, NDJSON or Parquet file. Entities: " + strings.Join(export.EntityNames(), ", "),
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: export.EntityNames(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		newDataProvider, ok := export.Entities[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown entity %q, supported entities are %s", args[0], strings.Join(export.EntityNames(), ", "))
+		}
+
+		format, err := export.ParseFormat(exportFormat)
+		if err != nil {
+			return err
+		}
+
+		filters, err := parseExportFilters(exportFilters)
+		if err != nil {
+			return err
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if exportOutput != "" && exportOutput != "-" {
+			file, err := os.Create(exportOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+		bufOut := bufio.NewWriter(out)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		lister := sharedCommon.NewLister(
+			sharedCommon.ListingSettings{
+				MaxRequestsCountPerSecond: exportRequestsPerSec,
+				MaxFetchersCount:          exportFetchers,
+				MaxItemsPerRequest:        exportItemsPerRequest,
+				Ordered:                   exportOrdered,
+			},
+			newDataProvider(cli),
+			nil,
+		)
+
+		count, err := export.Export(ctx, lister.Get(ctx, filters), format, bufOut, exportColumns)
+		if flushErr := bufOut.Flush(); err == nil {
+			err = flushErr
+		}
+		log.Debugf("exported %d records of %s", count, args[0])
+
+		return err
+	},
+}
+
+//parseExportFilters converts the key=value pairs to the listing filters
+func parseExportFilters(pairs []string) (map[string]interface{}, error) {
+	filters := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid filter %q, expected key=value", pair)
+		}
+		filters[parts[0]] = parts[1]
+	}
+
+	return filters, nil
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", string(export.FormatCSV), "output format: csv, ndjson or parquet")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "-", "output file, - for stdout")
+	exportCmd.Flags().StringSliceVarP(&exportColumns, "columns", "c", nil, "columns to export, nested fields are joined with a dot e.g. attributes.color, attributes.* selects all attributes; all columns of the first record if empty")
+	exportCmd.Flags().StringArrayVar(&exportFilters, "filter", nil, "API filter as key=value, can be repeated")
+	exportCmd.Flags().IntVar(&exportFetchers, "fetchers", 1, "count of parallel bulk requests")
+	exportCmd.Flags().IntVar(&exportRequestsPerSec, "rps", 0, "max count of requests per second, 0 means unlimited")
+	exportCmd.Flags().IntVar(&exportItemsPerRequest, "items-per-request", 0, "max count of records fetched by one bulk request, 0 means the API maximum")
+	exportCmd.Flags().BoolVar(&exportOrdered, "ordered", false, "keep the API order of the records")
+	addAPIConnectionFlags(exportCmd)
+	rootCmd.AddCommand(exportCmd)
+}
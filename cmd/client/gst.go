@@ -0,0 +1,393 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/gst"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gstUnitCode    string
+	gstWithPayment bool
+	gstPeriod      string
+	gstGSTIN       string
+	gstB2CLLimit   float64
+	gstOutput      string
+
+	gstTransportMode     string
+	gstVehicle           string
+	gstDistance          int
+	gstTransporterID     string
+	gstTransporterName   string
+	gstTransportDoc      string
+	gstTransportDocDate  string
+	gstEWayBillThreshold float64
+	gstForce             bool
+	gstEWayBillDate      string
+)
+
+// gstCmd represents the gst command
+var gstCmd = &cobra.Command{
+	Use:   "gst",
+	Short: "Renders the Indian GST e-invoices and returns and validates the GST data",
+}
+
+var gstIRNCmd = &cobra.Command{
+	Use:   "irn <documentID>",
+	Short: "Renders a sales document as the e-invoice JSON of the invoice registration portal",
+	Long: "Renders a sales document as the e-invoice JSON of the invoice registration portal, CGST and SGST or " +
+		"IGST is picked from the VAT rate components by the states of the warehouse and the ship-to address",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		documentID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid document ID %q", args[0])
+		}
+
+		loader, err := newGSTLoader()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		doc, err := loader.LoadDocument(ctx, documentID)
+		if err != nil {
+			return err
+		}
+		inv, err := doc.IRN()
+		if err != nil {
+			return err
+		}
+
+		return writeGSTOutput(inv.WriteJSON)
+	},
+}
+
+var gstGSTR1Cmd = &cobra.Command{
+	Use:   "gstr1",
+	Short: "Summarizes the outward supplies of a month as GSTR-1 JSON",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gstPeriod == "" {
+			return errors.New("period is required, use --period")
+		}
+		month, err := time.Parse("2006-01", gstPeriod)
+		if err != nil {
+			return fmt.Errorf("invalid period %q, expected YYYY-MM", gstPeriod)
+		}
+
+		loader, err := newGSTLoader()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		gstin := gstGSTIN
+		if gstin == "" {
+			info, err := loader.Companies.GetCompanyInfo(ctx)
+			if err != nil {
+				return err
+			}
+			gstin = info.VAT
+		}
+		if err := gst.ValidateGSTIN(gstin); err != nil {
+			return err
+		}
+
+		builder := gst.NewGSTR1Builder(gstin, month)
+		builder.B2CLLimit = gstB2CLLimit
+		if builder.TimeZone, err = company.GetTimeZone(ctx, loader.Companies); err != nil {
+			return err
+		}
+		if err := builder.Load(ctx, loader, sharedCommon.ListingSettings{}); err != nil {
+			return err
+		}
+
+		return writeGSTOutput(builder.GSTR1().WriteJSON)
+	},
+}
+
+var gstValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Lists the products with a missing or invalid HSN/SAC code and the customers with an invalid GSTIN",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		return writeGSTOutput(func(out io.Writer) error {
+			problems := 0
+			products := sharedCommon.NewLister(sharedCommon.ListingSettings{}, product.NewListingDataProvider(cli.ProductManager), nil)
+			for item := range products.Get(ctx, map[string]interface{}{"active": 1}) {
+				if item.Err != nil {
+					return item.Err
+				}
+				p := item.Payload.(product.Product)
+				if _, err := gst.ProductHSN(p); err != nil {
+					problems++
+					fmt.Fprintf(out, "product\t%d\t%s\t%v\n", p.ProductID, p.Code, err)
+				}
+			}
+
+			customers := sharedCommon.NewLister(sharedCommon.ListingSettings{}, customer.NewCustomerListingDataProvider(cli.CustomerManager), nil)
+			for item := range customers.Get(ctx, map[string]interface{}{}) {
+				if item.Err != nil {
+					return item.Err
+				}
+				c := item.Payload.(customer.Customer)
+				if c.VatNumber == "" {
+					continue
+				}
+				if err := gst.ValidateGSTIN(c.VatNumber); err != nil {
+					problems++
+					fmt.Fprintf(out, "customer\t%d\t%s\t%v\n", c.ID, c.Code, err)
+				}
+			}
+
+			if problems > 0 {
+				return fmt.Errorf("%d problems found", problems)
+			}
+			return nil
+		})
+	},
+}
+
+var gstEWayBillCmd = &cobra.Command{
+	Use:   "ewaybill",
+	Short: "Renders the e-way bills of the waybill sales and the inventory transfers and tracks their numbers",
+}
+
+var gstEWayBillSaleCmd = &cobra.Command{
+	Use:   "sale <documentID>",
+	Short: "Renders a waybill sales document as the e-way bill JSON",
+	Long: "Renders a waybill sales document as the e-way bill JSON, the transport type of the document is the transport mode " +
+		"unless --transport-mode is given, the documents not above the threshold are refused unless --force is given",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		documentID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid document ID %q", args[0])
+		}
+		opts, err := gstEWayBillOptions()
+		if err != nil {
+			return err
+		}
+
+		loader, err := newGSTLoader()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		doc, err := loader.LoadDocument(ctx, documentID)
+		if err != nil {
+			return err
+		}
+		bill, err := doc.EWayBill(opts)
+		if err != nil {
+			return err
+		}
+
+		return writeGSTOutput(bill.WriteJSON)
+	},
+}
+
+var gstEWayBillTransferCmd = &cobra.Command{
+	Use:   "transfer <inventoryTransferID>",
+	Short: "Renders an inventory transfer as the delivery challan e-way bill JSON",
+	Long: "Renders an inventory transfer as the delivery challan e-way bill JSON, the transfers between the warehouses " +
+		"of different GSTINs are stock transfers with IGST by the VAT rates of the products",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		transferID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid inventory transfer ID %q", args[0])
+		}
+		opts, err := gstEWayBillOptions()
+		if err != nil {
+			return err
+		}
+
+		loader, err := newGSTLoader()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		transfer, err := loader.LoadTransfer(ctx, transferID)
+		if err != nil {
+			return err
+		}
+		bill, err := transfer.EWayBill(opts)
+		if err != nil {
+			return err
+		}
+
+		return writeGSTOutput(bill.WriteJSON)
+	},
+}
+
+var gstEWayBillTrackCmd = &cobra.Command{
+	Use:   "track <sale|transfer> <id> <ewayBillNumber>",
+	Short: "Saves the e-way bill number generated by the portal to the sales document or the inventory transfer",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid ID %q", args[1])
+		}
+		date := time.Now()
+		if gstEWayBillDate != "" {
+			if date, err = time.Parse(sharedCommon.DateLayout, gstEWayBillDate); err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", gstEWayBillDate)
+			}
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		switch args[0] {
+		case "sale":
+			return gst.SaveSaleEWayBill(ctx, cli.SalesManager, id, args[2], date)
+		case "transfer":
+			return gst.SaveTransferEWayBill(ctx, cli.WarehouseManager, id, args[2], date)
+		default:
+			return fmt.Errorf("unknown document kind %q, expected sale or transfer", args[0])
+		}
+	},
+}
+
+func gstEWayBillOptions() (gst.EWayBillOptions, error) {
+	opts := gst.EWayBillOptions{
+		Transport: gst.Transport{
+			Mode:            gstTransportMode,
+			VehicleNumber:   gstVehicle,
+			Distance:        gstDistance,
+			TransporterID:   gstTransporterID,
+			TransporterName: gstTransporterName,
+			DocumentNumber:  gstTransportDoc,
+		},
+		Threshold: gstEWayBillThreshold,
+		Force:     gstForce,
+	}
+	if gstTransportDocDate != "" {
+		date, err := time.Parse(sharedCommon.DateLayout, gstTransportDocDate)
+		if err != nil {
+			return opts, fmt.Errorf("invalid transport document date %q, expected YYYY-MM-DD", gstTransportDocDate)
+		}
+		opts.Transport.DocumentDate = date
+	}
+	return opts, nil
+}
+
+func newGSTLoader() (*gst.Loader, error) {
+	cli, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &gst.Loader{
+		Sales:       cli.SalesManager,
+		Companies:   cli.CompanyManager,
+		Customers:   cli.CustomerManager,
+		Warehouses:  cli.WarehouseManager,
+		Products:    cli.ProductManager,
+		UnitCode:    gstUnitCode,
+		WithPayment: gstWithPayment,
+	}, nil
+}
+
+func writeGSTOutput(write func(out io.Writer) error) error {
+	if gstOutput == "" || gstOutput == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(gstOutput)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{gstIRNCmd, gstGSTR1Cmd, gstEWayBillSaleCmd, gstEWayBillTransferCmd} {
+		cmd.Flags().StringVar(&gstUnitCode, "unit-code", "", "unique quantity code of the products with an unknown unit, OTH if empty")
+		cmd.Flags().BoolVar(&gstWithPayment, "with-payment", false, "IGST is paid on the exports and the SEZ supplies instead of a bond or LUT")
+	}
+	for _, cmd := range []*cobra.Command{gstIRNCmd, gstGSTR1Cmd, gstValidateCmd, gstEWayBillSaleCmd, gstEWayBillTransferCmd} {
+		cmd.Flags().StringVarP(&gstOutput, "output", "o", "-", "output file, - for stdout")
+		addAPIConnectionFlags(cmd)
+	}
+	for _, cmd := range []*cobra.Command{gstEWayBillSaleCmd, gstEWayBillTransferCmd} {
+		cmd.Flags().StringVar(&gstTransportMode, "transport-mode", "", "road, rail, air or ship, the transport type of the sales document if empty")
+		cmd.Flags().StringVar(&gstVehicle, "vehicle", "", "registration number of the vehicle")
+		cmd.Flags().IntVar(&gstDistance, "distance", 0, "approximate distance in kilometres, calculated by the portal from the PIN codes if 0")
+		cmd.Flags().StringVar(&gstTransporterID, "transporter-id", "", "GSTIN or enrolment ID of the transporter")
+		cmd.Flags().StringVar(&gstTransporterName, "transporter-name", "", "name of the transporter")
+		cmd.Flags().StringVar(&gstTransportDoc, "transport-doc", "", "number of the railway receipt, airway bill or bill of lading")
+		cmd.Flags().StringVar(&gstTransportDocDate, "transport-doc-date", "", "date of the transport document as YYYY-MM-DD")
+		cmd.Flags().Float64Var(&gstEWayBillThreshold, "threshold", gst.DefaultEWayBillThreshold, "consignment value above which an e-way bill is needed")
+		cmd.Flags().BoolVar(&gstForce, "force", false, "render the e-way bill of a consignment below the threshold too")
+	}
+	gstEWayBillTrackCmd.Flags().StringVar(&gstEWayBillDate, "date", "", "generation date of the e-way bill as YYYY-MM-DD, today if empty")
+	addAPIConnectionFlags(gstEWayBillTrackCmd)
+	gstGSTR1Cmd.Flags().StringVar(&gstPeriod, "period", "", "month of the return as YYYY-MM")
+	gstGSTR1Cmd.Flags().StringVar(&gstGSTIN, "gstin", "", "GSTIN of the return, the VAT number of the company if empty")
+	gstGSTR1Cmd.Flags().Float64Var(&gstB2CLLimit, "b2cl-limit", gst.DefaultB2CLLimit, "invoice value above which the inter-state supplies to unregistered buyers are reported by invoice")
+
+	gstEWayBillCmd.AddCommand(gstEWayBillSaleCmd, gstEWayBillTransferCmd, gstEWayBillTrackCmd)
+	gstCmd.AddCommand(gstIRNCmd, gstGSTR1Cmd, gstValidateCmd, gstEWayBillCmd)
+	rootCmd.AddCommand(gstCmd)
+}
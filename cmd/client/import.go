@@ -0,0 +1,197 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bhojpur/erp/pkg/importer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFile      string
+	importMapping   string
+	importReport    string
+	importDelimiter string
+	importSheet     string
+	importChunkSize int
+	importDryRun    bool
+	importResume    bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:       "import <entity>",
+	Short:     "Bulk loads records from a CSV or XLSX file",
+	Long:      "Bulk loads records from a CSV or XLSX file and writes the result of every row to a report. Entities: " + strings.Join(importer.EntityNames(), ", "),
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: importer.EntityNames(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entity, ok := importer.Entities[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown entity %q, supported entities are %s", args[0], strings.Join(importer.EntityNames(), ", "))
+		}
+
+		mapping := importer.Mapping{}
+		if importMapping != "" {
+			var err error
+			mapping, err = importer.LoadMappingFile(importMapping)
+			if err != nil {
+				return err
+			}
+		}
+		if importSheet != "" {
+			mapping.Sheet = importSheet
+		}
+
+		src, closeSrc, err := openImportSource(importFile, mapping.Sheet)
+		if err != nil {
+			return err
+		}
+		defer closeSrc()
+
+		reportPath := importReport
+		if reportPath == "" {
+			//the dry run report is kept apart, so it doesn't block the import which follows it
+			reportPath = importFile + ".report.csv"
+			if importDryRun {
+				reportPath = importFile + ".dryrun.csv"
+			}
+		}
+		report, completed, closeReport, err := openImportReport(reportPath, importResume)
+		if err != nil {
+			return err
+		}
+		defer closeReport()
+
+		imp := &importer.Importer{
+			Entity:    entity,
+			Mapping:   mapping,
+			DryRun:    importDryRun,
+			ChunkSize: importChunkSize,
+			Completed: completed,
+		}
+		if !importDryRun {
+			cli, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			imp.Save = entity.NewSaver(cli)
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		summary, err := imp.Run(ctx, src, report)
+		log.Infof(
+			"rows: %d, skipped: %d, invalid: %d, valid: %d, saved: %d, failed: %d, report: %s",
+			summary.Rows, summary.Skipped, summary.Invalid, summary.Valid, summary.Saved, summary.Failed, reportPath,
+		)
+
+		return err
+	},
+}
+
+func openImportSource(path, sheet string) (importer.RowReader, func(), error) {
+	if path == "" {
+		return nil, nil, fmt.Errorf("the source file is required, use --file")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		delimiter, _ := utf8.DecodeRuneInString(importDelimiter)
+		return importer.NewCSVReader(file, delimiter), func() { file.Close() }, nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	reader, sheetCloser, err := importer.NewXLSXReader(file, info.Size(), sheet)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return reader, func() {
+		sheetCloser.Close()
+		file.Close()
+	}, nil
+}
+
+//openImportReport creates a new report or, when resuming, reads the completed rows of the existing one and appends to it
+func openImportReport(path string, resume bool) (*importer.ReportWriter, map[int]bool, func(), error) {
+	completed := map[int]bool{}
+	_, statErr := os.Stat(path)
+	exists := statErr == nil
+	if exists && !resume {
+		return nil, nil, nil, fmt.Errorf("report %s already exists, use --resume to continue the import or remove the report", path)
+	}
+
+	if exists {
+		existing, err := os.Open(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		completed, err = importer.ReadCompletedRows(existing)
+		existing.Close()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	report, err := importer.NewReportWriter(file, !exists)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+
+	return report, completed, func() { file.Close() }, nil
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFile, "file", "", "source CSV or XLSX file")
+	importCmd.Flags().StringVar(&importMapping, "mapping", "", "JSON file mapping the source columns to the API fields, the column names are used as is if it's not set")
+	importCmd.Flags().StringVar(&importReport, "report", "", "report file, <file>.report.csv by default, <file>.dryrun.csv in the dry run")
+	importCmd.Flags().StringVar(&importDelimiter, "delimiter", ",", "CSV delimiter")
+	importCmd.Flags().StringVar(&importSheet, "sheet", "", "XLSX sheet name, overrides the sheet of the mapping")
+	importCmd.Flags().IntVar(&importChunkSize, "chunk-size", 100, "count of rows in one bulk request, at most 100")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only validate the rows")
+	importCmd.Flags().BoolVar(&importResume, "resume", false, "skip the rows which were imported according to the existing report")
+	addAPIConnectionFlags(importCmd)
+	rootCmd.AddCommand(importCmd)
+}
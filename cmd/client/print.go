@@ -0,0 +1,123 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/bhojpur/erp/pkg/printing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	printTemplate string
+	printKind     string
+	printLanguage string
+	printOutput   string
+)
+
+// printCmd represents the print command
+var printCmd = &cobra.Command{
+	Use:   "print <documentID>",
+	Short: "Renders a sales document as a PDF invoice, receipt or delivery note without the hosted printing service",
+	Long: "Renders a sales document as PDF from the document, the company info and the customer. The layout comes " +
+		"from a JSON template or from the built-in template of the document type",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		documentID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid document ID %q", args[0])
+		}
+
+		var tpl *printing.Template
+		if printTemplate != "" {
+			file, err := os.Open(printTemplate)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			read, err := printing.ReadTemplate(file)
+			if err != nil {
+				return err
+			}
+			tpl = &read
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		data, err := printing.LoadData(ctx, cli.SalesManager, cli.CompanyManager, cli.CustomerManager, documentID)
+		if err != nil {
+			return err
+		}
+
+		if tpl == nil {
+			kind := printing.Kind(printKind)
+			if kind == "" {
+				kind = printing.KindOf(data.Document.Type)
+			}
+			defaultTemplate := printing.DefaultTemplate(kind)
+			defaultTemplate.Kind = kind
+			tpl = &defaultTemplate
+		}
+		if printLanguage != "" {
+			tpl.Language = printLanguage
+		}
+
+		renderer, err := printing.NewRenderer(*tpl)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if printOutput != "" && printOutput != "-" {
+			file, err := os.Create(printOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return renderer.Render(out, *data)
+	},
+}
+
+func init() {
+	printCmd.Flags().StringVar(&printTemplate, "template", "", "JSON template of the layout")
+	printCmd.Flags().StringVar(&printKind, "kind", "", "invoice, receipt or deliveryNote when there's no template, by the document type if empty")
+	printCmd.Flags().StringVar(&printLanguage, "language", "", "language of the texts: en, et, fi or de")
+	printCmd.Flags().StringVarP(&printOutput, "output", "o", "-", "PDF file, - for stdout")
+	addAPIConnectionFlags(printCmd)
+
+	rootCmd.AddCommand(printCmd)
+}
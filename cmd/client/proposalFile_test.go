@@ -0,0 +1,140 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/rebalancing"
+	"github.com/bhojpur/erp/pkg/reconciliation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proposal.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("old"), 0644))
+
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "partial"); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "old", string(content))
+
+	assert.NoError(t, writeFileAtomic(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	}))
+	content, _ = ioutil.ReadFile(path)
+	assert.Equal(t, "new", string(content))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	assert.Len(t, files, 1)
+}
+
+type paymentSaverMock struct {
+	calls int
+}
+
+func (m *paymentSaverMock) SavePaymentsBulk(ctx context.Context, bulkFilters []map[string]interface{}, baseFilters map[string]string) (sales.SavePaymentsResponseBulk, error) {
+	m.calls++
+	okStatus := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok"}}
+	errorStatus := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "error", ErrorCode: 1011}}
+	return sales.SavePaymentsResponseBulk{
+		Status: sharedCommon.Status{ResponseStatus: "ok"},
+		BulkItems: []sales.SavePaymentsBulkItem{
+			{Status: okStatus, Records: []sales.SavePaymentID{{PaymentID: 501}}},
+			{Status: errorStatus},
+		}[:len(bulkFilters)],
+	}, nil
+}
+
+func TestApplyProposalSavesCreatedPaymentsOfFailedApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proposal.json")
+	proposal := &reconciliation.Proposal{
+		Matches: []reconciliation.Match{
+			{Transaction: reconciliation.Transaction{ID: "T1"}, DocumentID: 2, Amount: sharedCommon.MustParseDecimal("10"), Confirmed: true},
+			{Transaction: reconciliation.Transaction{ID: "T2"}, DocumentID: 3, Amount: sharedCommon.MustParseDecimal("20"), Confirmed: true},
+		},
+	}
+	assert.NoError(t, saveProposal(path, proposal))
+
+	saver := &paymentSaverMock{}
+	_, err := applyProposal(context.Background(), path, proposal, saver, false)
+	assert.EqualError(t, err, "failed to create 1 of 2 payments")
+
+	saved, err := loadProposal(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 501, saved.Matches[0].PaymentID)
+	assert.Equal(t, 0, saved.Matches[1].PaymentID)
+
+	//the dry run doesn't write the proposal
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0644))
+	_, err = applyProposal(context.Background(), path, saved, saver, true)
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "{}", string(content))
+}
+
+type transferSaverMock struct {
+	calls int
+}
+
+func (m *transferSaverMock) SaveInventoryTransfer(ctx context.Context, filters map[string]string) (int, error) {
+	m.calls++
+	if m.calls > 1 {
+		return 0, errors.New("boom")
+	}
+	return 201, nil
+}
+
+func TestApplyRebalanceProposalSavesTransfersOfFailedApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proposal.json")
+	proposal := &rebalancing.Proposal{
+		Transfers: []rebalancing.Transfer{
+			{FromWarehouseID: 1, ToWarehouseID: 2, Approved: true, Lines: []rebalancing.TransferLine{{ProductID: 11, Quantity: 1}}},
+			{FromWarehouseID: 1, ToWarehouseID: 3, Approved: true, Lines: []rebalancing.TransferLine{{ProductID: 11, Quantity: 2}}},
+		},
+	}
+
+	saver := &transferSaverMock{}
+	_, err := applyRebalanceProposal(context.Background(), path, proposal, saver, false, false)
+	assert.EqualError(t, err, "failed to save 1 of 2 inventory transfers")
+
+	saved, err := loadRebalanceProposal(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, saved.Transfers[0].TransferID)
+	assert.Equal(t, 0, saved.Transfers[1].TransferID)
+}
@@ -0,0 +1,230 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/rebalancing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rebalanceWarehouseIDs []int
+	rebalanceSalesPeriod  time.Duration
+	rebalanceMinCover     time.Duration
+	rebalanceMaxCover     time.Duration
+	rebalanceMinShipment  float64
+	rebalanceTargets      string
+	rebalanceDate         string
+	rebalanceOutput       string
+	rebalanceApproveAll   bool
+	rebalanceConfirm      bool
+	rebalanceDryRun       bool
+)
+
+// rebalanceCmd represents the rebalance command
+var rebalanceCmd = &cobra.Command{
+	Use:   "rebalance",
+	Short: "Proposes stock transfers between the stores and saves the approved ones",
+	Long: "Proposes inventory transfers from the stores which have the products above their max target to the stores " +
+		"below their min target with as few shipments as possible. The plan command only writes the proposal, " +
+		"set \"approved\": true for the transfers in it and save them with the apply command",
+}
+
+var rebalancePlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Matches the overstocked stores to the understocked ones and writes the proposal without changing anything",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		date := time.Now()
+		if rebalanceDate != "" {
+			var err error
+			date, err = time.Parse(sharedCommon.DateLayout, rebalanceDate)
+			if err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", rebalanceDate)
+			}
+		}
+
+		planner := rebalancing.NewPlanner(rebalanceSalesPeriod)
+		planner.MinCover = rebalanceMinCover
+		planner.MaxCover = rebalanceMaxCover
+		planner.MinShipment = rebalanceMinShipment
+		if rebalanceTargets != "" {
+			targets, err := readRebalanceTargets(rebalanceTargets)
+			if err != nil {
+				return fmt.Errorf("%s: %v", rebalanceTargets, err)
+			}
+			planner.AddTargets(targets...)
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		if planner.TimeZone, err = company.GetTimeZone(ctx, cli.CompanyManager); err != nil {
+			return err
+		}
+		if err := planner.LoadWarehouses(ctx, cli.WarehouseManager, rebalanceWarehouseIDs...); err != nil {
+			return err
+		}
+		if err := planner.LoadStock(ctx, cli.ProductManager); err != nil {
+			return err
+		}
+		if err := planner.LoadSales(ctx, cli.SalesManager, date, sharedCommon.ListingSettings{}); err != nil {
+			return err
+		}
+
+		proposal := planner.Propose()
+		for _, need := range proposal.Unmet {
+			log.Warnf("no store can spare product %d, %s units are still needed in warehouse %d",
+				need.ProductID, formatFloat(need.Quantity), need.WarehouseID)
+		}
+		log.Infof("proposed %d transfers between %d stores", len(proposal.Transfers), len(planner.Warehouses))
+
+		var out io.Writer = os.Stdout
+		if rebalanceOutput != "" && rebalanceOutput != "-" {
+			file, err := os.Create(rebalanceOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return proposal.Write(out)
+	},
+}
+
+var rebalanceApplyCmd = &cobra.Command{
+	Use:   "apply <proposal.json>",
+	Short: "Saves the approved transfers of the proposal as inventory transfers",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proposal, err := loadRebalanceProposal(args[0])
+		if err != nil {
+			return err
+		}
+		if rebalanceApproveAll {
+			proposal.ApproveAll()
+		}
+
+		var saver rebalancing.TransferSaver
+		if !rebalanceDryRun {
+			cli, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			saver = cli.WarehouseManager
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		results, err := applyRebalanceProposal(ctx, args[0], proposal, saver, rebalanceConfirm, rebalanceDryRun)
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				log.Errorf("transfer from warehouse %d to %d failed: %v", result.FromWarehouseID, result.ToWarehouseID, result.Err)
+			case rebalanceDryRun:
+				log.Infof("would save a transfer of %d products from warehouse %d to %d", result.Lines, result.FromWarehouseID, result.ToWarehouseID)
+			default:
+				log.Infof("saved inventory transfer %d of %d products from warehouse %d to %d",
+					result.TransferID, result.Lines, result.FromWarehouseID, result.ToWarehouseID)
+			}
+		}
+		if len(results) == 0 {
+			log.Warn("no transfers of the proposal are approved and waiting to be saved")
+		}
+
+		return err
+	},
+}
+
+func loadRebalanceProposal(path string) (*rebalancing.Proposal, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return rebalancing.ReadProposal(file)
+}
+
+//applyRebalanceProposal saves the transfers and writes the proposal back when any transfer was saved, also when
+//the others failed, the transfer IDs are kept in the proposal so applying it again doesn't duplicate the transfers
+func applyRebalanceProposal(ctx context.Context, path string, proposal *rebalancing.Proposal, saver rebalancing.TransferSaver, confirm, dryRun bool) ([]rebalancing.TransferResult, error) {
+	results, err := proposal.Apply(ctx, saver, confirm, dryRun)
+	for _, result := range results {
+		if result.TransferID != 0 {
+			if saveErr := saveRebalanceProposal(path, proposal); saveErr != nil {
+				return results, saveErr
+			}
+			break
+		}
+	}
+	return results, err
+}
+
+func saveRebalanceProposal(path string, proposal *rebalancing.Proposal) error {
+	return writeFileAtomic(path, proposal.Write)
+}
+
+func readRebalanceTargets(path string) ([]rebalancing.Target, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return rebalancing.ReadTargets(file)
+}
+
+func init() {
+	rebalancePlanCmd.Flags().IntSliceVar(&rebalanceWarehouseIDs, "warehouse", nil, "IDs of the stores taking part, all warehouses if empty")
+	rebalancePlanCmd.Flags().DurationVar(&rebalanceSalesPeriod, "sales-period", 28*24*time.Hour, "period of the sales used for the sales velocity")
+	rebalancePlanCmd.Flags().DurationVar(&rebalanceMinCover, "min-cover", rebalancing.DefaultMinCover, "sales period the stock should cover at least, used when the product has no target or reorder point")
+	rebalancePlanCmd.Flags().DurationVar(&rebalanceMaxCover, "max-cover", rebalancing.DefaultMaxCover, "sales period the stock should cover at most, used when the product has no target or restock level")
+	rebalancePlanCmd.Flags().Float64Var(&rebalanceMinShipment, "min-shipment", 0, "least quantity worth a shipment between two stores")
+	rebalancePlanCmd.Flags().StringVar(&rebalanceTargets, "targets", "", "CSV file of the min/max targets with the warehouse_id, product_id, min and max columns")
+	rebalancePlanCmd.Flags().StringVar(&rebalanceDate, "date", "", "last day of the sales period as YYYY-MM-DD, today if empty")
+	rebalancePlanCmd.Flags().StringVarP(&rebalanceOutput, "output", "o", "-", "proposal file, - for stdout")
+	addAPIConnectionFlags(rebalancePlanCmd)
+
+	rebalanceApplyCmd.Flags().BoolVar(&rebalanceApproveAll, "approve-all", false, "approve all transfers of the proposal")
+	rebalanceApplyCmd.Flags().BoolVar(&rebalanceConfirm, "confirm", false, "save the transfers confirmed, otherwise they are left unconfirmed for the stores")
+	rebalanceApplyCmd.Flags().BoolVar(&rebalanceDryRun, "dry-run", false, "only list the transfers which would be saved")
+	addAPIConnectionFlags(rebalanceApplyCmd)
+
+	rebalanceCmd.AddCommand(rebalancePlanCmd, rebalanceApplyCmd)
+	rootCmd.AddCommand(rebalanceCmd)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/currency"
+	"github.com/bhojpur/erp/pkg/receivables"
+	"github.com/spf13/cobra"
+)
+
+var (
+	receivablesDate            string
+	receivablesCustomerID      int
+	receivablesDefaultCurrency string
+	receivablesFrom            string
+	receivablesFormat          string
+	receivablesOutput          string
+	receivablesCurrency        string
+	receivablesRates           string
+)
+
+// receivablesCmd represents the receivables command
+var receivablesCmd = &cobra.Command{
+	Use:   "receivables",
+	Short: "Reports the accounts receivable from the invoices and payments",
+}
+
+var receivablesAgingCmd = &cobra.Command{
+	Use:   "aging",
+	Short: "Writes the open items of the customers bucketed by the days overdue as CSV",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ledger, err := loadLedger()
+		if err != nil {
+			return err
+		}
+
+		report, err := ledger.Aging()
+		if err != nil {
+			return err
+		}
+
+		return writeReceivablesOutput(func(out io.Writer) error {
+			return receivables.WriteAgingCSV(out, report)
+		})
+	},
+}
+
+var receivablesStatementCmd = &cobra.Command{
+	Use:   "statement",
+	Short: "Writes the account statement of the customer as CSV or PDF",
+	Long: "Writes the account statement of the customer with the opening and closing balances per currency, " +
+		"the format is taken from the output file extension when --format is not set",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if receivablesCustomerID == 0 {
+			return errors.New("customer is required, use --customer")
+		}
+		if receivablesFrom == "" {
+			return errors.New("start of the period is required, use --from")
+		}
+
+		format := strings.ToLower(receivablesFormat)
+		if format == "" {
+			format = "csv"
+			if strings.EqualFold(filepath.Ext(receivablesOutput), ".pdf") {
+				format = "pdf"
+			}
+		}
+		if format != "csv" && format != "pdf" {
+			return fmt.Errorf("unknown format %q, expected csv or pdf", receivablesFormat)
+		}
+
+		from, err := time.Parse(sharedCommon.DateLayout, receivablesFrom)
+		if err != nil {
+			return fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", receivablesFrom)
+		}
+
+		ledger, err := loadLedger()
+		if err != nil {
+			return err
+		}
+
+		statement, err := ledger.Statement(receivablesCustomerID, from)
+		if err != nil {
+			return err
+		}
+
+		return writeReceivablesOutput(func(out io.Writer) error {
+			if format == "pdf" {
+				return receivables.WriteStatementPDF(out, statement)
+			}
+			return receivables.WriteStatementCSV(out, statement)
+		})
+	},
+}
+
+func loadLedger() (*receivables.Ledger, error) {
+	asOf := time.Now()
+	if receivablesDate != "" {
+		var err error
+		asOf, err = time.Parse(sharedCommon.DateLayout, receivablesDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", receivablesDate)
+		}
+	}
+
+	cli, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	presentation, err := currency.ParsePresentation(receivablesCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := receivables.NewLedger(asOf)
+	ledger.DefaultCurrency = strings.ToUpper(receivablesDefaultCurrency)
+	ledger.Presentation = presentation
+	if presentation == currency.BaseCurrency {
+		if ledger.Rates, err = loadRateTable(ctx, cli, receivablesRates); err != nil {
+			return nil, err
+		}
+	}
+	if err := ledger.Load(ctx, cli.SalesManager, sharedCommon.ListingSettings{}, receivablesCustomerID); err != nil {
+		return nil, err
+	}
+
+	return ledger, nil
+}
+
+func writeReceivablesOutput(write func(out io.Writer) error) error {
+	if receivablesOutput == "" || receivablesOutput == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(receivablesOutput)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{receivablesAgingCmd, receivablesStatementCmd} {
+		cmd.Flags().StringVar(&receivablesDate, "date", "", "date of the report as YYYY-MM-DD, today if empty")
+		cmd.Flags().StringVar(&receivablesDefaultCurrency, "default-currency", "", "currency of the documents without a currency")
+		cmd.Flags().StringVarP(&receivablesOutput, "output", "o", "-", "output file, - for stdout")
+		cmd.Flags().StringVar(&receivablesCurrency, "currency", "document", "currency of the amounts, document or base")
+		cmd.Flags().StringVar(&receivablesRates, "rates", "", "exchange rates CSV or ECB XML for the amounts in the base currency")
+		addAPIConnectionFlags(cmd)
+	}
+	receivablesAgingCmd.Flags().IntVar(&receivablesCustomerID, "customer", 0, "ID of the customer, all customers if 0")
+	receivablesStatementCmd.Flags().IntVar(&receivablesCustomerID, "customer", 0, "ID of the customer")
+	receivablesStatementCmd.Flags().StringVar(&receivablesFrom, "from", "", "first day of the statement period as YYYY-MM-DD")
+	receivablesStatementCmd.Flags().StringVar(&receivablesFormat, "format", "", "csv or pdf")
+
+	receivablesCmd.AddCommand(receivablesAgingCmd, receivablesStatementCmd)
+	rootCmd.AddCommand(receivablesCmd)
+}
@@ -0,0 +1,231 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/receivables"
+	"github.com/bhojpur/erp/pkg/reconciliation"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reconcileDate            string
+	reconcileDefaultCurrency string
+	reconcileMinConfidence   float64
+	reconcileConfirmAbove    float64
+	reconcileApplyAbove      float64
+	reconcileOutput          string
+	reconcileDryRun          bool
+)
+
+// reconcileCmd represents the reconcile command
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Matches the bank statements to the open invoices and creates the payments of the confirmed matches",
+	Long: "Matches the incoming transfers of the CAMT.053 and MT940 bank statements to the open invoices. The match " +
+		"command only writes the proposal, set \"confirmed\": true for the matches in it and create the payments with the apply command",
+}
+
+var reconcileMatchCmd = &cobra.Command{
+	Use:   "match <statement>...",
+	Short: "Proposes the invoices paid by the bank statement transactions with confidence scores",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		transactions := []reconciliation.Transaction{}
+		for _, path := range args {
+			statements, err := readBankStatements(path)
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			for _, statement := range statements {
+				transactions = append(transactions, statement.Transactions...)
+			}
+		}
+
+		asOf := time.Now()
+		if reconcileDate != "" {
+			var err error
+			asOf, err = time.Parse(sharedCommon.DateLayout, reconcileDate)
+			if err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", reconcileDate)
+			}
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		ledger := receivables.NewLedger(asOf)
+		ledger.DefaultCurrency = strings.ToUpper(reconcileDefaultCurrency)
+		if err := ledger.Load(ctx, cli.SalesManager, sharedCommon.ListingSettings{}, 0); err != nil {
+			return err
+		}
+		items, err := ledger.OpenItems()
+		if err != nil {
+			return err
+		}
+
+		matcher := reconciliation.NewMatcher(items...)
+		matcher.MinConfidence = reconcileMinConfidence
+		matcher.ConfirmConfidence = reconcileConfirmAbove
+		proposal := matcher.Match(transactions)
+
+		confirmed := 0
+		for _, match := range proposal.Matches {
+			if match.Confirmed {
+				confirmed++
+			}
+		}
+		for _, transaction := range proposal.Unmatched {
+			log.Warnf("no invoice matches transaction %s of %s %s from %q", transaction.ID,
+				transaction.Amount.StringFixed(2), transaction.Currency, transaction.PayerName)
+		}
+		log.Infof("proposed %d matches, %d of them are confirmed", len(proposal.Matches), confirmed)
+
+		var out io.Writer = os.Stdout
+		if reconcileOutput != "" && reconcileOutput != "-" {
+			file, err := os.Create(reconcileOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return proposal.Write(out)
+	},
+}
+
+var reconcileApplyCmd = &cobra.Command{
+	Use:   "apply <proposal.json>",
+	Short: "Creates the TRANSFER payments of the confirmed matches of the proposal",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proposal, err := loadProposal(args[0])
+		if err != nil {
+			return err
+		}
+		if reconcileApplyAbove > 0 {
+			proposal.ConfirmAbove(reconcileApplyAbove)
+		}
+
+		var saver reconciliation.PaymentSaver
+		if !reconcileDryRun {
+			cli, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			saver = cli.SalesManager
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		results, err := applyProposal(ctx, args[0], proposal, saver, reconcileDryRun)
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				log.Errorf("payment of %s for invoice %s from transaction %s failed: %v",
+					result.Amount.StringFixed(2), result.DocumentNumber, result.TransactionID, result.Err)
+			case reconcileDryRun:
+				log.Infof("would create a payment of %s for invoice %s from transaction %s",
+					result.Amount.StringFixed(2), result.DocumentNumber, result.TransactionID)
+			default:
+				log.Infof("created payment %d of %s for invoice %s from transaction %s",
+					result.PaymentID, result.Amount.StringFixed(2), result.DocumentNumber, result.TransactionID)
+			}
+		}
+		if len(results) == 0 {
+			log.Warn("no matches of the proposal are confirmed and waiting for a payment")
+		}
+
+		return err
+	},
+}
+
+func loadProposal(path string) (*reconciliation.Proposal, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return reconciliation.ReadProposal(file)
+}
+
+//applyProposal creates the payments and writes the proposal back when any payment was created, also when
+//the others failed, the payment IDs are kept in the proposal so applying it again doesn't duplicate the payments
+func applyProposal(ctx context.Context, path string, proposal *reconciliation.Proposal, saver reconciliation.PaymentSaver, dryRun bool) ([]reconciliation.PaymentResult, error) {
+	results, err := proposal.Apply(ctx, saver, dryRun)
+	for _, result := range results {
+		if result.PaymentID != 0 {
+			if saveErr := saveProposal(path, proposal); saveErr != nil {
+				return results, saveErr
+			}
+			break
+		}
+	}
+	return results, err
+}
+
+func saveProposal(path string, proposal *reconciliation.Proposal) error {
+	return writeFileAtomic(path, proposal.Write)
+}
+
+func readBankStatements(path string) ([]reconciliation.Statement, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return reconciliation.ParseStatements(file)
+}
+
+func init() {
+	reconcileMatchCmd.Flags().StringVar(&reconcileDate, "date", "", "date of the open invoices as YYYY-MM-DD, today if empty")
+	reconcileMatchCmd.Flags().StringVar(&reconcileDefaultCurrency, "default-currency", "", "currency of the invoices without a currency")
+	reconcileMatchCmd.Flags().Float64Var(&reconcileMinConfidence, "min-confidence", reconciliation.DefaultMinConfidence, "lowest confidence of the proposed matches")
+	reconcileMatchCmd.Flags().Float64Var(&reconcileConfirmAbove, "confirm-above", reconciliation.DefaultConfirmConfidence, "confirm the matches with at least this confidence, 0 to review all")
+	reconcileMatchCmd.Flags().StringVarP(&reconcileOutput, "output", "o", "-", "proposal file, - for stdout")
+	addAPIConnectionFlags(reconcileMatchCmd)
+
+	reconcileApplyCmd.Flags().Float64Var(&reconcileApplyAbove, "confirm-above", 0, "also confirm the matches with at least this confidence")
+	reconcileApplyCmd.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "only list the payments which would be created")
+	addAPIConnectionFlags(reconcileApplyCmd)
+
+	reconcileCmd.AddCommand(reconcileMatchCmd, reconcileApplyCmd)
+	rootCmd.AddCommand(reconcileCmd)
+}
@@ -0,0 +1,231 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/purchasing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replenishWarehouseIDs []int
+	replenishSalesPeriod  time.Duration
+	replenishLeadTime     time.Duration
+	replenishSafetyStock  time.Duration
+	replenishCoverage     time.Duration
+	replenishDate         string
+	replenishBaseCurrency string
+	replenishRates        []string
+	replenishOutput       string
+	replenishApproveAll   bool
+	replenishDryRun       bool
+)
+
+// replenishCmd represents the replenish command
+var replenishCmd = &cobra.Command{
+	Use:   "replenish",
+	Short: "Proposes purchase orders from the stock levels and creates the approved ones",
+	Long: "Proposes purchase orders from the stock levels and the sales velocity. The plan command only writes " +
+		"the proposal, set \"approved\": true for the orders in it and create them as drafts with the apply command",
+}
+
+var replenishPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Simulates the replenishment and writes the proposal without changing anything",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(replenishWarehouseIDs) == 0 {
+			return errors.New("at least one warehouse is required, use --warehouse")
+		}
+
+		rates, err := parseRates(replenishRates)
+		if err != nil {
+			return err
+		}
+
+		date := time.Now()
+		if replenishDate != "" {
+			date, err = time.Parse(sharedCommon.DateLayout, replenishDate)
+			if err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", replenishDate)
+			}
+		}
+
+		selector := purchasing.NewSelector(purchasing.FixedRates{BaseCurrency: replenishBaseCurrency, Rates: rates})
+		selector.Date = date
+		planner := purchasing.NewPlanner(selector, replenishSalesPeriod)
+		planner.LeadTime = replenishLeadTime
+		planner.SafetyStock = replenishSafetyStock
+		planner.Coverage = replenishCoverage
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		if planner.TimeZone, err = company.GetTimeZone(ctx, cli.CompanyManager); err != nil {
+			return err
+		}
+		settings := sharedCommon.ListingSettings{}
+		if err := selector.LoadPriceLists(ctx, cli.PricesManager, settings); err != nil {
+			return err
+		}
+		if err := selector.LoadSupplierCurrencies(ctx, cli.CustomerManager, settings); err != nil {
+			return err
+		}
+		if err := planner.LoadStock(ctx, cli.ProductManager, replenishWarehouseIDs...); err != nil {
+			return err
+		}
+		if err := planner.LoadSales(ctx, cli.SalesManager, date, settings); err != nil {
+			return err
+		}
+
+		proposal, err := planner.Propose()
+		if err != nil {
+			return err
+		}
+		for _, suggestion := range proposal.Unassigned {
+			log.Warnf("no supplier sells product %d, %s units are needed in warehouse %d",
+				suggestion.ProductID, formatFloat(suggestion.Quantity), suggestion.WarehouseID)
+		}
+		log.Infof("proposed %d purchase orders", len(proposal.Orders))
+
+		var out io.Writer = os.Stdout
+		if replenishOutput != "" && replenishOutput != "-" {
+			file, err := os.Create(replenishOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return proposal.Write(out)
+	},
+}
+
+var replenishApplyCmd = &cobra.Command{
+	Use:   "apply <proposal.json>",
+	Short: "Creates the approved purchase orders of the proposal as unconfirmed drafts",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proposal, err := loadReplenishProposal(args[0])
+		if err != nil {
+			return err
+		}
+		if replenishApproveAll {
+			proposal.ApproveAll()
+		}
+
+		var saver purchasing.PurchaseDocumentSaver
+		if !replenishDryRun {
+			cli, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			saver = cli.SalesManager
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		results, err := applyReplenishProposal(ctx, args[0], proposal, saver, replenishDryRun)
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				log.Errorf("purchase order of supplier %d for warehouse %d failed: %v", result.SupplierID, result.WarehouseID, result.Err)
+			case replenishDryRun:
+				log.Infof("would create a purchase order of supplier %d for warehouse %d", result.SupplierID, result.WarehouseID)
+			default:
+				log.Infof("created purchase order %s (ID %d) of supplier %d for warehouse %d",
+					result.DocumentNumber, result.DocumentID, result.SupplierID, result.WarehouseID)
+			}
+		}
+		if len(results) == 0 {
+			log.Warn("no orders are approved in the proposal")
+		}
+
+		return err
+	},
+}
+
+func loadReplenishProposal(path string) (*purchasing.Proposal, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return purchasing.ReadProposal(file)
+}
+
+//applyReplenishProposal creates the drafts and writes the proposal back when any draft was created, also when
+//the others failed, the draft IDs are kept in the proposal so applying it again doesn't duplicate the orders
+func applyReplenishProposal(ctx context.Context, path string, proposal *purchasing.Proposal, saver purchasing.PurchaseDocumentSaver, dryRun bool) ([]purchasing.OrderResult, error) {
+	results, err := proposal.Apply(ctx, saver, dryRun)
+	for _, result := range results {
+		if result.DocumentID != 0 {
+			if saveErr := saveReplenishProposal(path, proposal); saveErr != nil {
+				return results, saveErr
+			}
+			break
+		}
+	}
+	return results, err
+}
+
+func saveReplenishProposal(path string, proposal *purchasing.Proposal) error {
+	return writeFileAtomic(path, proposal.Write)
+}
+
+func init() {
+	replenishPlanCmd.Flags().IntSliceVar(&replenishWarehouseIDs, "warehouse", nil, "IDs of the warehouses to restock")
+	replenishPlanCmd.Flags().DurationVar(&replenishSalesPeriod, "sales-period", 90*24*time.Hour, "period of the sales used for the sales velocity")
+	replenishPlanCmd.Flags().DurationVar(&replenishLeadTime, "lead-time", purchasing.DefaultLeadTime, "delivery time of the suppliers, used when the reorder point is not set")
+	replenishPlanCmd.Flags().DurationVar(&replenishSafetyStock, "safety-stock", purchasing.DefaultSafetyStock, "sales period kept as the safety stock, used when the reorder point is not set")
+	replenishPlanCmd.Flags().DurationVar(&replenishCoverage, "coverage", purchasing.DefaultCoverage, "how long the restocked goods should last, used when the restock level is not set")
+	replenishPlanCmd.Flags().StringVar(&replenishDate, "date", "", "date of the plan as YYYY-MM-DD, today if empty")
+	replenishPlanCmd.Flags().StringVar(&replenishBaseCurrency, "base-currency", "", "currency of the costs, the suppliers without a currency use it")
+	replenishPlanCmd.Flags().StringArrayVar(&replenishRates, "rate", nil, "exchange rate to the base currency as CODE=rate, can be repeated")
+	replenishPlanCmd.Flags().StringVarP(&replenishOutput, "output", "o", "-", "proposal file, - for stdout")
+	addAPIConnectionFlags(replenishPlanCmd)
+
+	replenishApplyCmd.Flags().BoolVar(&replenishApproveAll, "approve-all", false, "approve all orders of the proposal")
+	replenishApplyCmd.Flags().BoolVar(&replenishDryRun, "dry-run", false, "only list the orders which would be created")
+	addAPIConnectionFlags(replenishApplyCmd)
+
+	replenishCmd.AddCommand(replenishPlanCmd, replenishApplyCmd)
+	rootCmd.AddCommand(replenishCmd)
+}
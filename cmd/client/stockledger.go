@@ -0,0 +1,138 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/stockledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stockLedgerFrom         string
+	stockLedgerTo           string
+	stockLedgerProductIDs   []int
+	stockLedgerWarehouseIDs []int
+	stockLedgerCompare      bool
+	stockLedgerFormat       string
+	stockLedgerOutput       string
+)
+
+// stockLedgerCmd represents the stockledger command
+var stockLedgerCmd = &cobra.Command{
+	Use:   "stockledger",
+	Short: "Lists the stock movements of the products by warehouse with the running balance",
+	Long: "Merges the rows of the sales and purchase documents, inventory registrations, write-offs and transfers into " +
+		"a chronological list per product and warehouse with the running balance, --compare reports the products " +
+		"which current stock differs from the balance",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := strings.ToLower(stockLedgerFormat)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("unknown format %q, expected csv or json", stockLedgerFormat)
+		}
+
+		filter := stockledger.Filter{ProductIDs: stockLedgerProductIDs, WarehouseIDs: stockLedgerWarehouseIDs}
+		var err error
+		if stockLedgerFrom != "" {
+			if filter.From, err = time.Parse(sharedCommon.DateLayout, stockLedgerFrom); err != nil {
+				return fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", stockLedgerFrom)
+			}
+		}
+		if stockLedgerTo != "" {
+			if stockLedgerCompare {
+				return errors.New("the current stock can be compared only with the ledger until today, remove --to")
+			}
+			if filter.To, err = time.Parse(sharedCommon.DateLayout, stockLedgerTo); err != nil {
+				return fmt.Errorf("invalid end date %q, expected YYYY-MM-DD", stockLedgerTo)
+			}
+			if filter.To.Before(filter.From) {
+				return errors.New("end of the period is before the start")
+			}
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		ledger, err := stockledger.Load(ctx, cli.SalesManager, cli.DocumentsManager, cli.WarehouseManager, sharedCommon.ListingSettings{}, filter)
+		if err != nil {
+			return err
+		}
+
+		var write func(out io.Writer) error
+		if stockLedgerCompare {
+			discrepancies, err := ledger.LoadDiscrepancies(ctx, cli.ProductManager)
+			if err != nil {
+				return err
+			}
+			write = func(out io.Writer) error {
+				if format == "json" {
+					return stockledger.WriteJSON(out, discrepancies)
+				}
+				return stockledger.WriteDiscrepanciesCSV(out, discrepancies)
+			}
+		} else {
+			write = func(out io.Writer) error {
+				if format == "json" {
+					return stockledger.WriteJSON(out, ledger.Entries)
+				}
+				return stockledger.WriteCSV(out, ledger)
+			}
+		}
+
+		if stockLedgerOutput == "" || stockLedgerOutput == "-" {
+			return write(os.Stdout)
+		}
+		file, err := os.Create(stockLedgerOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return write(file)
+	},
+}
+
+func init() {
+	stockLedgerCmd.Flags().StringVar(&stockLedgerFrom, "from", "", "first day of the ledger as YYYY-MM-DD, the earlier movements are summed into the opening balance")
+	stockLedgerCmd.Flags().StringVar(&stockLedgerTo, "to", "", "last day of the ledger as YYYY-MM-DD, today if empty")
+	stockLedgerCmd.Flags().IntSliceVar(&stockLedgerProductIDs, "product", nil, "IDs of the products, all products if empty")
+	stockLedgerCmd.Flags().IntSliceVar(&stockLedgerWarehouseIDs, "warehouse", nil, "IDs of the warehouses, all warehouses if empty")
+	stockLedgerCmd.Flags().BoolVar(&stockLedgerCompare, "compare", false, "write the differences between the current stock and the balances instead of the movements")
+	stockLedgerCmd.Flags().StringVar(&stockLedgerFormat, "format", "csv", "csv or json")
+	stockLedgerCmd.Flags().StringVarP(&stockLedgerOutput, "output", "o", "-", "output file, - for stdout")
+	addAPIConnectionFlags(stockLedgerCmd)
+
+	rootCmd.AddCommand(stockLedgerCmd)
+}
@@ -0,0 +1,268 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/stocktake"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stocktakeWarehouseID     int
+	stocktakeGroupIDs        []int
+	stocktakeUncountedAsZero bool
+	stocktakeCountSheet      string
+	stocktakeBlind           bool
+	stocktakeReasonID        int
+	stocktakeFormat          string
+	stocktakeOutput          string
+	stocktakeDryRun          bool
+)
+
+// stocktakeCmd represents the stocktake command
+var stocktakeCmd = &cobra.Command{
+	Use:   "stocktake",
+	Short: "Runs a physical inventory count of a warehouse",
+	Long: "The sheet command freezes the expected stock of a warehouse or product groups into a stocktake file " +
+		"and writes the count sheet. The count command adds the filled in count sheets and scanner exports to the stocktake, " +
+		"variances lists the differences with their value and post saves the surpluses as inventory registrations " +
+		"and the shortages as write-offs",
+}
+
+var stocktakeSheetCmd = &cobra.Command{
+	Use:   "sheet",
+	Short: "Freezes the expected stock and writes the stocktake file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if stocktakeWarehouseID == 0 {
+			return errors.New("warehouse is required, use --warehouse")
+		}
+		if stocktakeOutput == "" || stocktakeOutput == "-" {
+			return errors.New("the stocktake file is required, use -o")
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		scope := stocktake.Scope{WarehouseID: stocktakeWarehouseID, GroupIDs: stocktakeGroupIDs}
+		sheet, err := stocktake.Freeze(ctx, cli.ProductManager, sharedCommon.ListingSettings{}, nil, scope)
+		if err != nil {
+			return err
+		}
+		sheet.UncountedAsZero = stocktakeUncountedAsZero
+
+		if err := saveStocktake(stocktakeOutput, sheet); err != nil {
+			return err
+		}
+		log.Infof("froze the stock of %d products of warehouse %d", len(sheet.Lines), sheet.WarehouseID)
+
+		if stocktakeCountSheet == "" {
+			return nil
+		}
+		file, err := os.Create(stocktakeCountSheet)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return stocktake.WriteCountSheetCSV(file, sheet, stocktakeBlind)
+	},
+}
+
+var stocktakeCountCmd = &cobra.Command{
+	Use:   "count <stocktake.json> <counts.csv>...",
+	Short: "Adds the counts of the filled in count sheets or scanner exports to the stocktake",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sheet, err := loadStocktake(args[0])
+		if err != nil {
+			return err
+		}
+
+		counts := []stocktake.Count{}
+		for _, path := range args[1:] {
+			fileCounts, err := readCounts(path)
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			counts = append(counts, fileCounts...)
+		}
+		if err := sheet.Apply(counts); err != nil {
+			return err
+		}
+		if err := saveStocktake(args[0], sheet); err != nil {
+			return err
+		}
+
+		log.Infof("added %d counts, %d of %d products are not counted", len(counts), len(sheet.Uncounted()), len(sheet.Lines))
+		return nil
+	},
+}
+
+var stocktakeVariancesCmd = &cobra.Command{
+	Use:   "variances <stocktake.json>",
+	Short: "Lists the differences of the counted and the expected quantities with their value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := strings.ToLower(stocktakeFormat)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("unknown format %q, expected csv or json", stocktakeFormat)
+		}
+
+		sheet, err := loadStocktake(args[0])
+		if err != nil {
+			return err
+		}
+		variances := sheet.Variances()
+		log.Infof("%d variances, total value %s", len(variances), stocktake.VarianceValue(variances).StringFixed(2))
+
+		var out io.Writer = os.Stdout
+		if stocktakeOutput != "" && stocktakeOutput != "-" {
+			file, err := os.Create(stocktakeOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if format == "json" {
+			return stocktake.WriteJSON(out, variances)
+		}
+		return stocktake.WriteVariancesCSV(out, variances)
+	},
+}
+
+var stocktakePostCmd = &cobra.Command{
+	Use:   "post <stocktake.json>",
+	Short: "Saves the surpluses as inventory registrations and the shortages as write-offs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sheet, err := loadStocktake(args[0])
+		if err != nil {
+			return err
+		}
+		if stocktakeReasonID != 0 {
+			sheet.SetDefaultReason(stocktakeReasonID)
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		if stocktakeDryRun {
+			reasons, err := cli.WarehouseManager.GetReasonCodes(ctx, map[string]string{})
+			if err != nil {
+				return err
+			}
+			if err := sheet.Validate(reasons); err != nil {
+				return err
+			}
+			for _, doc := range sheet.Documents() {
+				log.Infof("would save a %s with reason %d, %d rows of value %s", strings.ToLower(doc.Kind), doc.ReasonID,
+					len(doc.Variances), stocktake.VarianceValue(doc.Variances).StringFixed(2))
+			}
+			return nil
+		}
+
+		postErr := sheet.Post(ctx, cli.WarehouseManager)
+		if len(sheet.Postings) > 0 {
+			if err := saveStocktake(args[0], sheet); err != nil {
+				return err
+			}
+		}
+		for _, posting := range sheet.Postings {
+			log.Infof("%s %d with reason %d has %d rows", strings.ToLower(posting.Kind), posting.DocumentID, posting.ReasonID, posting.Rows)
+		}
+
+		return postErr
+	},
+}
+
+func loadStocktake(path string) (*stocktake.Sheet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return stocktake.ReadSheet(file)
+}
+
+func saveStocktake(path string, sheet *stocktake.Sheet) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := stocktake.WriteSheet(file, sheet); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readCounts(path string) ([]stocktake.Count, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return stocktake.ReadCounts(file)
+}
+
+func init() {
+	stocktakeSheetCmd.Flags().IntVar(&stocktakeWarehouseID, "warehouse", 0, "ID of the counted warehouse")
+	stocktakeSheetCmd.Flags().IntSliceVar(&stocktakeGroupIDs, "group", nil, "IDs of the counted product groups, all products if empty")
+	stocktakeSheetCmd.Flags().BoolVar(&stocktakeUncountedAsZero, "uncounted-zero", false, "treat the products which aren't counted as missing")
+	stocktakeSheetCmd.Flags().StringVar(&stocktakeCountSheet, "csv", "", "also write the count sheet to fill in to this file")
+	stocktakeSheetCmd.Flags().BoolVar(&stocktakeBlind, "blind", false, "leave the expected quantities out of the count sheet")
+	stocktakeSheetCmd.Flags().StringVarP(&stocktakeOutput, "output", "o", "", "stocktake file")
+	addAPIConnectionFlags(stocktakeSheetCmd)
+
+	stocktakeVariancesCmd.Flags().StringVar(&stocktakeFormat, "format", "csv", "csv or json")
+	stocktakeVariancesCmd.Flags().StringVarP(&stocktakeOutput, "output", "o", "-", "output file, - for stdout")
+
+	stocktakePostCmd.Flags().IntVar(&stocktakeReasonID, "reason", 0, "reason code of the variances which don't have one")
+	stocktakePostCmd.Flags().BoolVar(&stocktakeDryRun, "dry-run", false, "only validate the reason codes and list the documents which would be saved")
+	addAPIConnectionFlags(stocktakePostCmd)
+
+	stocktakeCmd.AddCommand(stocktakeSheetCmd, stocktakeCountCmd, stocktakeVariancesCmd, stocktakePostCmd)
+	rootCmd.AddCommand(stocktakeCmd)
+}
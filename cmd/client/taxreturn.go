@@ -0,0 +1,143 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/taxreport"
+	"github.com/spf13/cobra"
+)
+
+var (
+	taxReturnFrom   string
+	taxReturnTo     string
+	taxReturnFormat string
+	taxReturnOutput string
+	taxReturnRates  string
+)
+
+// taxReturnCmd represents the taxreturn command
+var taxReturnCmd = &cobra.Command{
+	Use:   "taxreturn",
+	Short: "Aggregates the output and input VAT/GST of the period by tax rate as CSV or JSON",
+	Long: "Aggregates the output tax of the confirmed sales invoices net of the credit invoices and the input tax " +
+		"of the confirmed purchase documents by VAT rate, the tax of the multi-component rates is split by component",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if taxReturnFrom == "" {
+			return errors.New("start of the period is required, use --from")
+		}
+		if taxReturnTo == "" {
+			return errors.New("end of the period is required, use --to")
+		}
+
+		format := strings.ToLower(taxReturnFormat)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("unknown format %q, expected csv or json", taxReturnFormat)
+		}
+
+		from, err := time.Parse(sharedCommon.DateLayout, taxReturnFrom)
+		if err != nil {
+			return fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", taxReturnFrom)
+		}
+		to, err := time.Parse(sharedCommon.DateLayout, taxReturnTo)
+		if err != nil {
+			return fmt.Errorf("invalid end date %q, expected YYYY-MM-DD", taxReturnTo)
+		}
+		if to.Before(from) {
+			return errors.New("end of the period is before the start")
+		}
+
+		cli, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		vatRates, err := cli.SalesManager.GetVatRates(ctx, map[string]string{})
+		if err != nil {
+			return err
+		}
+
+		builder, err := taxreport.NewBuilder(from, to, vatRates)
+		if err != nil {
+			return err
+		}
+		if taxReturnRates != "" {
+			if builder.Rates, err = loadRateTable(ctx, cli, taxReturnRates); err != nil {
+				return err
+			}
+		}
+		if builder.TimeZone, err = company.GetTimeZone(ctx, cli.CompanyManager); err != nil {
+			return err
+		}
+		if err := builder.Load(ctx, cli.SalesManager, cli.DocumentsManager, sharedCommon.ListingSettings{}); err != nil {
+			return err
+		}
+
+		info, err := cli.CompanyManager.GetCompanyInfo(ctx)
+		if err != nil {
+			return err
+		}
+
+		ret := builder.Return()
+		ret.CompanyName = info.Name
+		ret.CompanyVAT = info.VAT
+
+		var out io.Writer = os.Stdout
+		if taxReturnOutput != "" && taxReturnOutput != "-" {
+			file, err := os.Create(taxReturnOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if format == "json" {
+			return taxreport.WriteJSON(out, ret)
+		}
+		return taxreport.WriteCSV(out, ret)
+	},
+}
+
+func init() {
+	taxReturnCmd.Flags().StringVar(&taxReturnFrom, "from", "", "first day of the period as YYYY-MM-DD")
+	taxReturnCmd.Flags().StringVar(&taxReturnTo, "to", "", "last day of the period as YYYY-MM-DD")
+	taxReturnCmd.Flags().StringVar(&taxReturnFormat, "format", "csv", "csv or json")
+	taxReturnCmd.Flags().StringVar(&taxReturnRates, "rates", "", "exchange rates CSV or ECB XML used instead of the currency rates of the documents")
+	taxReturnCmd.Flags().StringVarP(&taxReturnOutput, "output", "o", "-", "output file, - for stdout")
+	addAPIConnectionFlags(taxReturnCmd)
+
+	rootCmd.AddCommand(taxReturnCmd)
+}
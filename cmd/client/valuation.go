@@ -0,0 +1,185 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/valuation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	valuationDate      string
+	valuationFrom      string
+	valuationMethod    string
+	valuationFormat    string
+	valuationRates     string
+	valuationDocuments bool
+	valuationOutput    string
+)
+
+// valuationCmd represents the valuation command
+var valuationCmd = &cobra.Command{
+	Use:   "valuation",
+	Short: "Values the inventory and the cost of the goods sold by FIFO or weighted average cost",
+	Long: "Rebuilds the cost layers of the products in the warehouses from the purchase documents, inventory registrations, " +
+		"write-offs, transfers and sales documents and values the stock at any date and the cost of the sold rows",
+}
+
+var valuationStockCmd = &cobra.Command{
+	Use:   "stock",
+	Short: "Writes the quantity and the value of the stock of the products by warehouse at the end of the day",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := runValuation()
+		if err != nil {
+			return err
+		}
+		report.Costs = []valuation.RowCost{}
+
+		return writeValuationOutput(func(out io.Writer) error {
+			if strings.EqualFold(valuationFormat, "json") {
+				return valuation.WriteJSON(out, report)
+			}
+			return valuation.WriteStockCSV(out, report)
+		})
+	},
+}
+
+var valuationCOGSCmd = &cobra.Command{
+	Use:   "cogs",
+	Short: "Writes the cost of the goods sold by the sales rows of the period",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from := time.Time{}
+		if valuationFrom != "" {
+			var err error
+			if from, err = time.Parse(sharedCommon.DateLayout, valuationFrom); err != nil {
+				return fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", valuationFrom)
+			}
+		}
+
+		report, err := runValuation()
+		if err != nil {
+			return err
+		}
+		to, _ := time.Parse(sharedCommon.DateLayout, report.Date)
+		if to.Before(from) {
+			return errors.New("end of the period is before the start")
+		}
+		report.Costs = report.CostsBetween(from, to)
+
+		return writeValuationOutput(func(out io.Writer) error {
+			switch {
+			case strings.EqualFold(valuationFormat, "json"):
+				return valuation.WriteJSON(out, report)
+			case valuationDocuments:
+				return valuation.WriteDocumentsCSV(out, report.DocumentCosts(from, to))
+			}
+			return valuation.WriteCostsCSV(out, report.Costs)
+		})
+	},
+}
+
+//runValuation loads the stock movements until the date and replays them
+func runValuation() (*valuation.Report, error) {
+	format := strings.ToLower(valuationFormat)
+	if format != "csv" && format != "json" {
+		return nil, fmt.Errorf("unknown format %q, expected csv or json", valuationFormat)
+	}
+	method, err := valuation.ParseMethod(valuationMethod)
+	if err != nil {
+		return nil, err
+	}
+	date := time.Now()
+	if valuationDate != "" {
+		if date, err = time.Parse(sharedCommon.DateLayout, valuationDate); err != nil {
+			return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", valuationDate)
+		}
+	}
+
+	cli, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	valuator, err := valuation.NewValuator(method)
+	if err != nil {
+		return nil, err
+	}
+	if valuationRates != "" {
+		if valuator.Rates, err = loadRateTable(ctx, cli, valuationRates); err != nil {
+			return nil, err
+		}
+	}
+	err = valuator.Load(ctx, cli.SalesManager, cli.DocumentsManager, cli.WarehouseManager, sharedCommon.ListingSettings{}, date)
+	if err != nil {
+		return nil, err
+	}
+
+	report := valuator.Run(date)
+	for _, shortage := range report.Shortages {
+		fmt.Fprintf(os.Stderr, "%s: %s %s issued %s units of product %d more than there were in warehouse %d\n",
+			shortage.Date, shortage.Kind, shortage.Number, shortage.Quantity, shortage.ProductID, shortage.WarehouseID)
+	}
+	return report, nil
+}
+
+func writeValuationOutput(write func(out io.Writer) error) error {
+	if valuationOutput == "" || valuationOutput == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(valuationOutput)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{valuationStockCmd, valuationCOGSCmd} {
+		cmd.Flags().StringVar(&valuationDate, "date", "", "value the stock at the end of the day as YYYY-MM-DD, today by default")
+		cmd.Flags().StringVar(&valuationMethod, "method", "fifo", "costing method, fifo or average")
+		cmd.Flags().StringVar(&valuationFormat, "format", "csv", "csv or json")
+		cmd.Flags().StringVar(&valuationRates, "rates", "", "exchange rates CSV or ECB XML used instead of the currency rates of the documents")
+		cmd.Flags().StringVarP(&valuationOutput, "output", "o", "-", "output file, - for stdout")
+		addAPIConnectionFlags(cmd)
+	}
+	valuationCOGSCmd.Flags().StringVar(&valuationFrom, "from", "", "first day of the period as YYYY-MM-DD, the --date is the last one")
+	valuationCOGSCmd.Flags().BoolVar(&valuationDocuments, "documents", false, "sum the costs by document and compare them with the costs stored in the ERP")
+
+	valuationCmd.AddCommand(valuationStockCmd, valuationCOGSCmd)
+	rootCmd.AddCommand(valuationCmd)
+}
@@ -0,0 +1,151 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/bhojpur/erp/pkg/webhook"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhookURL          string
+	webhookSecret       string
+	webhookSharedSecret string
+	webhookEntity       string
+	webhookAction       string
+	webhookRecords      string
+	webhookClientCode   string
+	webhookRepeat       int
+	webhookAddr         string
+)
+
+// webhookCmd represents the webhook command
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Simulates and receives webhook deliveries locally",
+}
+
+var webhookSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Sends a webhook event with the records from a JSON file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var raw []byte
+		var err error
+		if webhookRecords == "" || webhookRecords == "-" {
+			raw, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			raw, err = ioutil.ReadFile(webhookRecords)
+		}
+		if err != nil {
+			return err
+		}
+
+		records := []json.RawMessage{}
+		if err := json.Unmarshal(raw, &records); err != nil {
+			return fmt.Errorf("records should be a JSON list: %v", err)
+		}
+
+		event, err := webhook.NewEvent(webhookClientCode, webhookEntity, webhookAction, records)
+		if err != nil {
+			return err
+		}
+
+		sender := webhook.Sender{
+			URL:          webhookURL,
+			HMACSecret:   []byte(webhookSecret),
+			SharedSecret: webhookSharedSecret,
+		}
+		for i := 0; i < webhookRepeat; i++ {
+			if err := sender.Send(context.Background(), event); err != nil {
+				return err
+			}
+			log.Infof("delivered %s %s event %s", webhookEntity, webhookAction, event.DeliveryID)
+		}
+
+		return nil
+	},
+}
+
+var webhookListenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Receives webhook deliveries and logs them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if webhookSecret == "" && webhookSharedSecret == "" {
+			return errors.New("either the HMAC secret or the shared secret should be set")
+		}
+		var verifier webhook.Verifier = webhook.HMACVerifier{Secret: []byte(webhookSecret)}
+		if webhookSharedSecret != "" {
+			verifier = webhook.SharedSecretVerifier{Secret: webhookSharedSecret}
+		}
+
+		handler := webhook.NewHandler(verifier, nil)
+		handler.ErrorLog = func(err error) {
+			log.Warn(err)
+		}
+		logEvent := func(ctx context.Context, event webhook.Event) error {
+			log.Infof("received %s %s event %s with %d records", event.Entity, event.Action, event.DeliveryID, len(event.Records))
+			for _, record := range event.Records {
+				log.Debug(string(record))
+			}
+			return nil
+		}
+		for _, entity := range []string{
+			webhook.EntityProducts,
+			webhook.EntitySaleDocuments,
+			webhook.EntityCustomers,
+			webhook.EntityPayments,
+			webhook.EntityInventoryRegistrations,
+			webhook.EntityInventoryWriteOffs,
+			webhook.EntityInventoryTransfers,
+		} {
+			handler.HandleEntity(entity, logEvent)
+		}
+
+		log.Infof("listening for webhooks on %s", webhookAddr)
+		return http.ListenAndServe(webhookAddr, handler)
+	},
+}
+
+func init() {
+	webhookCmd.PersistentFlags().StringVar(&webhookSecret, "secret", os.Getenv("ERP_WEBHOOK_SECRET"), "HMAC secret of the deliveries")
+	webhookCmd.PersistentFlags().StringVar(&webhookSharedSecret, "shared-secret", "", "shared secret sent in the "+webhook.HeaderSecret+" header instead of the signature")
+
+	webhookSendCmd.Flags().StringVar(&webhookURL, "url", "http://localhost:8080/", "webhook receiver url")
+	webhookSendCmd.Flags().StringVar(&webhookEntity, "entity", webhook.EntityProducts, "entity of the event e.g. products, salesDocuments, customers, payments")
+	webhookSendCmd.Flags().StringVar(&webhookAction, "action", webhook.ActionUpdate, "action of the event: insert, update or delete")
+	webhookSendCmd.Flags().StringVar(&webhookRecords, "records", "-", "JSON file with the list of records, - for stdin")
+	webhookSendCmd.Flags().StringVar(&webhookClientCode, "client-code", os.Getenv("ERP_CLIENT_CODE"), "client code of the event")
+	webhookSendCmd.Flags().IntVar(&webhookRepeat, "repeat", 1, "count of deliveries of the same event, to test the deduplication")
+
+	webhookListenCmd.Flags().StringVar(&webhookAddr, "addr", ":8080", "listening address")
+
+	webhookCmd.AddCommand(webhookSendCmd, webhookListenCmd)
+	rootCmd.AddCommand(webhookCmd)
+}
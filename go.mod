@@ -4,10 +4,12 @@ go 1.16
 
 require (
 	github.com/bhojpur/gui v0.0.4
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.4.0
 	github.com/stretchr/testify v1.7.1
+	golang.org/x/sync v0.3.0
 )
 
 require (
@@ -0,0 +1,185 @@
+package common
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//DateLayout is the format of the dates in the API, e.g. the document date or the price list start date
+	DateLayout = "2006-01-02"
+	//TimeLayout is the format of the time of the day in the API, e.g. the document time
+	TimeLayout = "15:04:05"
+	//DateTimeLayout is the date and the time of the day separated by a space
+	DateTimeLayout = DateLayout + " " + TimeLayout
+
+	//emptyDate is sent by the API for the dates which are not set
+	emptyDate = "0000-00-00"
+)
+
+//ErrInvalidDate is returned for the values which are not in the API date and time formats
+var ErrInvalidDate = errors.New("invalid date")
+
+//UTC is the timezone of the calendar days which are compared only with each other, e.g. the days of the reports
+//and the exchange rates
+var UTC = TimeZone{}
+
+//TimeZone converts the API dates and times to time.Time in the account timezone.
+//The dates and the times of the day in the API are local to the account, the unix timestamps are absolute.
+//The zero value is UTC
+type TimeZone struct {
+	loc *time.Location
+}
+
+//NewTimeZone creates the timezone from the location, nil is UTC
+func NewTimeZone(loc *time.Location) TimeZone {
+	return TimeZone{loc: loc}
+}
+
+//LoadTimeZone creates the timezone from the IANA name, e.g. the timezone from the account configuration parameters.
+//An empty name is UTC
+func LoadTimeZone(name string) (TimeZone, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return TimeZone{}, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return TimeZone{}, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return TimeZone{loc: loc}, nil
+}
+
+//Location returns the location of the timezone
+func (tz TimeZone) Location() *time.Location {
+	if tz.loc == nil {
+		return time.UTC
+	}
+	return tz.loc
+}
+
+//String returns the name of the timezone
+func (tz TimeZone) String() string {
+	return tz.Location().String()
+}
+
+//ParseDate parses the date in the API format as the midnight in the timezone.
+//The empty values, "" and "0000-00-00", give the zero time
+func (tz TimeZone) ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == emptyDate {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(DateLayout, value, tz.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q, expected %s", ErrInvalidDate, value, DateLayout)
+	}
+	return t, nil
+}
+
+//ParseDateTime parses the date and the time of the day in the API formats in the timezone.
+//An empty time of the day is the midnight, an empty date gives the zero time
+func (tz TimeZone) ParseDateTime(date, clock string) (time.Time, error) {
+	day, err := tz.ParseDate(date)
+	if err != nil || day.IsZero() {
+		return day, err
+	}
+	clock = strings.TrimSpace(clock)
+	if clock == "" {
+		return day, nil
+	}
+	t, err := time.ParseInLocation(DateTimeLayout, day.Format(DateLayout)+" "+clock, tz.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w time %q, expected %s", ErrInvalidDate, clock, TimeLayout)
+	}
+	return t, nil
+}
+
+//Day returns the midnight in the timezone of the calendar day which t has in its own location,
+//e.g. the day of a date given by the user
+func (tz TimeZone) Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tz.Location())
+}
+
+//Unix converts the unix timestamp to the time in the timezone, 0 is the zero time
+func (tz TimeZone) Unix(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(timestamp, 0).In(tz.Location())
+}
+
+//FormatDate formats the day of the time in the timezone in the API format, the zero time is ""
+func (tz TimeZone) FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(tz.Location()).Format(DateLayout)
+}
+
+//FormatTime formats the time of the day in the timezone in the API format, the zero time is ""
+func (tz TimeZone) FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(tz.Location()).Format(TimeLayout)
+}
+
+//TimeFilters are the typed date filters of the API requests. DateFrom and DateTo are sent as the days in the account
+//timezone, ChangedSince as the unix timestamp. The zero times are not sent
+type TimeFilters struct {
+	DateFrom     time.Time
+	DateTo       time.Time
+	ChangedSince time.Time
+}
+
+//Filters returns the filters in the API formats
+func (f TimeFilters) Filters(tz TimeZone) map[string]string {
+	res := map[string]string{}
+	if !f.DateFrom.IsZero() {
+		res["dateFrom"] = tz.FormatDate(f.DateFrom)
+	}
+	if !f.DateTo.IsZero() {
+		res["dateTo"] = tz.FormatDate(f.DateTo)
+	}
+	if !f.ChangedSince.IsZero() {
+		res["changedSince"] = strconv.FormatInt(f.ChangedSince.Unix(), 10)
+	}
+	return res
+}
+
+//Apply adds the filters to the request filters
+func (f TimeFilters) Apply(tz TimeZone, filters map[string]string) {
+	for key, value := range f.Filters(tz) {
+		filters[key] = value
+	}
+}
+
+//ApplyToListing adds the filters to the lister filters
+func (f TimeFilters) ApplyToListing(tz TimeZone, filters map[string]interface{}) {
+	for key, value := range f.Filters(tz) {
+		filters[key] = value
+	}
+}
@@ -0,0 +1,119 @@
+package common
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTimeZone(t *testing.T) {
+	tz, err := LoadTimeZone("")
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", tz.String())
+
+	tz, err = LoadTimeZone("Asia/Kolkata")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Kolkata", tz.String())
+
+	_, err = LoadTimeZone("Nowhere/Atlantis")
+	assert.Error(t, err)
+}
+
+func TestParseDate(t *testing.T) {
+	tz := NewTimeZone(time.FixedZone("IST", 19800))
+
+	day, err := tz.ParseDate("2022-06-30")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-06-29T18:30:00Z", day.UTC().Format(time.RFC3339))
+
+	for _, value := range []string{"", "0000-00-00", " "} {
+		day, err = tz.ParseDate(value)
+		assert.NoError(t, err)
+		assert.True(t, day.IsZero(), value)
+	}
+
+	_, err = tz.ParseDate("30.06.2022")
+	assert.True(t, errors.Is(err, ErrInvalidDate))
+	assert.EqualError(t, err, `invalid date "30.06.2022", expected 2006-01-02`)
+}
+
+func TestParseDateTime(t *testing.T) {
+	tz := NewTimeZone(time.FixedZone("IST", 19800))
+
+	moment, err := tz.ParseDateTime("2022-06-30", "10:15:30")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-06-30T04:45:30Z", moment.UTC().Format(time.RFC3339))
+
+	moment, err = tz.ParseDateTime("2022-06-30", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-06-30T00:00:00+05:30", moment.Format(time.RFC3339))
+
+	moment, err = tz.ParseDateTime("", "10:15:30")
+	assert.NoError(t, err)
+	assert.True(t, moment.IsZero())
+
+	_, err = tz.ParseDateTime("2022-06-30", "25:00")
+	assert.EqualError(t, err, `invalid date time "25:00", expected 15:04:05`)
+}
+
+func TestUnixAndFormat(t *testing.T) {
+	tz := NewTimeZone(time.FixedZone("IST", 19800))
+
+	assert.True(t, tz.Unix(0).IsZero())
+
+	//2022-06-30 20:00:00 UTC is the next day in the account timezone
+	moment := tz.Unix(1656619200)
+	assert.Equal(t, "2022-07-01", tz.FormatDate(moment))
+	assert.Equal(t, "01:30:00", tz.FormatTime(moment))
+	assert.Equal(t, "2022-06-30", TimeZone{}.FormatDate(moment))
+
+	assert.Equal(t, "", tz.FormatDate(time.Time{}))
+	assert.Equal(t, "", tz.FormatTime(time.Time{}))
+
+	assert.Equal(t, time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), UTC.Day(moment))
+}
+
+func TestTimeFilters(t *testing.T) {
+	tz := NewTimeZone(time.FixedZone("IST", 19800))
+	filters := TimeFilters{
+		DateFrom:     time.Date(2022, 6, 1, 0, 0, 0, 0, tz.Location()),
+		DateTo:       time.Date(2022, 6, 30, 20, 0, 0, 0, time.UTC),
+		ChangedSince: time.Unix(1656619200, 0),
+	}
+	assert.Equal(t, map[string]string{
+		"dateFrom":     "2022-06-01",
+		"dateTo":       "2022-07-01",
+		"changedSince": "1656619200",
+	}, filters.Filters(tz))
+
+	assert.Equal(t, map[string]string{}, TimeFilters{}.Filters(tz))
+
+	request := map[string]string{"type": "INVOICE", "dateFrom": "2020-01-01"}
+	TimeFilters{DateFrom: filters.DateFrom}.Apply(tz, request)
+	assert.Equal(t, map[string]string{"type": "INVOICE", "dateFrom": "2022-06-01"}, request)
+
+	listing := map[string]interface{}{"type": "INVOICE"}
+	TimeFilters{ChangedSince: filters.ChangedSince}.ApplyToListing(tz, listing)
+	assert.Equal(t, map[string]interface{}{"type": "INVOICE", "changedSince": "1656619200"}, listing)
+}
@@ -0,0 +1,334 @@
+package common
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+//maxDecimalExponent limits the exponent of the parsed numbers so that a malformed value can't allocate a huge number
+const maxDecimalExponent = 1000
+
+var (
+	//ErrInvalidDecimal is returned for the values which are not decimal numbers
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	//ErrDivisionByZero is returned by Div for a zero divisor
+	ErrDivisionByZero = errors.New("decimal division by zero")
+)
+
+var bigTen = big.NewInt(10)
+
+//Decimal is an exact decimal number for the money amounts and quantities, the zero value is 0.
+//The API sends the numbers both as JSON numbers and as numeric strings, the decimal accepts both
+type Decimal struct {
+	//coef is the number without the point, nil is 0, it's never modified after the creation
+	coef *big.Int
+	//scale is the count of the digits after the point
+	scale int32
+}
+
+//NewDecimal creates the decimal coef * 10^-scale, e.g. NewDecimal(1999, 2) is 19.99
+func NewDecimal(coef int64, scale int32) Decimal {
+	d := Decimal{coef: big.NewInt(coef), scale: scale}
+	if scale < 0 {
+		d.coef.Mul(d.coef, pow10(-scale))
+		d.scale = 0
+	}
+	return d
+}
+
+//DecimalFromInt creates a decimal of the integer
+func DecimalFromInt(value int64) Decimal {
+	return NewDecimal(value, 0)
+}
+
+//DecimalFromFloat creates the decimal of the shortest representation of the float, so 0.1 stays 0.1,
+//NaN and infinities are 0
+func DecimalFromFloat(value float64) Decimal {
+	d, _ := ParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+	return d
+}
+
+//RoundFloat rounds the shortest representation of the float half away from zero to the places, so 135.045
+//becomes 135.05, it's meant for the amounts which the external formats keep as floats
+func RoundFloat(value float64, places int32) float64 {
+	return DecimalFromFloat(value).Round(places).Float64()
+}
+
+//DecimalFromFloat32 creates the decimal of the shortest representation of the float32, so 9.99 stays 9.99
+func DecimalFromFloat32(value float32) Decimal {
+	d, _ := ParseDecimal(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	return d
+}
+
+//ParseDecimal parses a number like 12, -0.50 or 1.5e-3, the empty string is 0
+func ParseDecimal(value string) (Decimal, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return Decimal{}, nil
+	}
+
+	exponent := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exponent, err = strconv.Atoi(s[i+1:])
+		if err != nil || exponent > maxDecimalExponent || exponent < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("%w %q", ErrInvalidDecimal, value)
+		}
+		s = s[:i]
+	}
+
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+	digits := integer + fraction
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("%w %q", ErrInvalidDecimal, value)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	scale := len(fraction) - exponent
+	if scale < 0 {
+		coef.Mul(coef, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+//MustParseDecimal parses the number and panics if it's invalid, it's meant for the constants
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+//String formats the decimal with all digits of its scale, e.g. 1.50
+func (d Decimal) String() string {
+	digits := d.int().String()
+	if d.scale == 0 {
+		return digits
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+//StringFixed formats the decimal rounded to the places, e.g. 2 for the money amounts
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places).String()
+}
+
+//Float64 returns the nearest float of the decimal
+func (d Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(d.String(), 64)
+	return value
+}
+
+//Scale returns the count of the digits after the point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+//Round rounds the decimal half away from zero to the places after the point, the result has exactly the places
+func (d Decimal) Round(places int32) Decimal {
+	return d.round(places, false)
+}
+
+//RoundHalfEven rounds the decimal to the places after the point with the halves going to the even digit,
+//so 0.125 becomes 0.12 and 0.135 becomes 0.14
+func (d Decimal) RoundHalfEven(places int32) Decimal {
+	return d.round(places, true)
+}
+
+func (d Decimal) round(places int32, halfEven bool) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d.rescale(places)
+	}
+
+	divisor := pow10(d.scale - places)
+	quotient, remainder := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	if remainder.Sign() != 0 {
+		half := new(big.Int).Lsh(new(big.Int).Abs(remainder), 1).Cmp(divisor)
+		if half > 0 || half == 0 && (!halfEven || quotient.Bit(0) == 1) {
+			quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+		}
+	}
+
+	return Decimal{coef: quotient, scale: places}
+}
+
+//Trim removes the trailing zeros after the point, e.g. 1.50 becomes 1.5
+func (d Decimal) Trim() Decimal {
+	coef, scale := d.int(), d.scale
+	for scale > 0 {
+		quotient, remainder := new(big.Int).QuoRem(coef, bigTen, new(big.Int))
+		if remainder.Sign() != 0 {
+			break
+		}
+		coef, scale = quotient, scale-1
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+//Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b := align(d, other)
+	return Decimal{coef: new(big.Int).Add(a.int(), b.int()), scale: a.scale}
+}
+
+//Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b := align(d, other)
+	return Decimal{coef: new(big.Int).Sub(a.int(), b.int()), scale: a.scale}
+}
+
+//Mul returns d * other with the sum of the scales
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), other.int()), scale: d.scale + other.scale}
+}
+
+//Div returns d / other rounded half away from zero to the places
+func (d Decimal) Div(other Decimal, places int32) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	//one more digit than needed is calculated for the rounding
+	numerator := new(big.Int).Mul(d.int(), pow10(places+1+other.scale))
+	denominator := new(big.Int).Mul(other.int(), pow10(d.scale))
+	quotient := new(big.Int).Quo(numerator, denominator)
+
+	return Decimal{coef: quotient, scale: places + 1}.Round(places), nil
+}
+
+//Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+//Abs returns the absolute value of d
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+//Sign returns -1, 0 or 1 by the sign of d
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+//IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+//Cmp compares the values of the decimals regardless of their scales, it returns -1, 0 or 1
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := align(d, other)
+	return a.int().Cmp(b.int())
+}
+
+//Equal reports whether the decimals have the same value, 1.5 equals 1.50
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+//MarshalJSON writes the decimal as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+//UnmarshalJSON reads a JSON number, a numeric string, an empty string or null
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseDecimal(raw)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+func (d Decimal) rescale(scale int32) Decimal {
+	if scale == d.scale {
+		return Decimal{coef: d.int(), scale: scale}
+	}
+	return Decimal{coef: new(big.Int).Mul(d.int(), pow10(scale-d.scale)), scale: scale}
+}
+
+//align returns the decimals with the larger of their scales
+func align(a, b Decimal) (Decimal, Decimal) {
+	if a.scale < b.scale {
+		return a.rescale(b.scale), b
+	}
+	return a, b.rescale(a.scale)
+}
+
+func pow10(exponent int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,164 @@
+package common
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	testCases := map[string]string{
+		"":         "0",
+		" 12 ":     "12",
+		"12.50":    "12.50",
+		"-0.5":     "-0.5",
+		"+.25":     "0.25",
+		"7.":       "7",
+		"0.000123": "0.000123",
+		"1.5e-3":   "0.0015",
+		"2E3":      "2000",
+		"-1.25e1":  "-12.5",
+		"123456789012345678901234567890.123456789": "123456789012345678901234567890.123456789",
+	}
+	for value, expected := range testCases {
+		d, err := ParseDecimal(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, d.String(), value)
+	}
+
+	for _, value := range []string{"abc", "1,5", "-", ".", "1.2.3", "1e", "1e5000", "0x10"} {
+		_, err := ParseDecimal(value)
+		assert.True(t, errors.Is(err, ErrInvalidDecimal), value)
+	}
+	_, err := ParseDecimal("1,5")
+	assert.EqualError(t, err, `invalid decimal "1,5"`)
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	assert.Equal(t, "0.1", DecimalFromFloat(0.1).String())
+	assert.Equal(t, "-1234.56", DecimalFromFloat(-1234.56).String())
+	assert.Equal(t, "9.99", DecimalFromFloat32(9.99).String())
+	assert.Equal(t, "0", DecimalFromFloat(0).String())
+	assert.Equal(t, "19.99", NewDecimal(1999, 2).String())
+	assert.Equal(t, "1500", NewDecimal(15, -2).String())
+	assert.Equal(t, "-0.05", NewDecimal(-5, 2).String())
+	assert.Equal(t, 0.3, DecimalFromFloat(0.1).Add(DecimalFromFloat(0.2)).Float64())
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustParseDecimal
+
+	assert.Equal(t, "0.3", d("0.1").Add(d("0.2")).String())
+	assert.Equal(t, "10.05", d("10").Add(d("0.05")).String())
+	assert.Equal(t, "-0.15", d("0.1").Sub(d("0.25")).String())
+	assert.Equal(t, "40.5000", d("13.50").Mul(d("3.00")).String())
+	assert.Equal(t, "1.5", d("-1.5").Abs().String())
+	assert.Equal(t, "-1.5", d("1.5").Neg().String())
+	assert.Equal(t, "3", Decimal{}.Add(DecimalFromInt(3)).String())
+
+	assert.True(t, d("1.5").Equal(d("1.50")))
+	assert.Equal(t, -1, d("-2").Cmp(d("1.99")))
+	assert.Equal(t, 1, d("0.001").Cmp(Decimal{}))
+	assert.True(t, Decimal{}.IsZero())
+	assert.True(t, d("0.00").IsZero())
+	assert.Equal(t, -1, d("-0.01").Sign())
+
+	roundCases := []struct {
+		value    string
+		places   int32
+		expected string
+	}{
+		{"1.005", 2, "1.01"},
+		{"-1.005", 2, "-1.01"},
+		{"1.0049", 2, "1.00"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"0.004", 2, "0.00"},
+		{"12", 2, "12.00"},
+		{"135.045", 2, "135.05"},
+	}
+	for _, testCase := range roundCases {
+		assert.Equal(t, testCase.expected, d(testCase.value).Round(testCase.places).String(), testCase.value)
+	}
+	halfEvenCases := []struct {
+		value    string
+		places   int32
+		expected string
+	}{
+		{"0.125", 2, "0.12"},
+		{"0.135", 2, "0.14"},
+		{"-0.125", 2, "-0.12"},
+		{"-0.135", 2, "-0.14"},
+		{"0.1251", 2, "0.13"},
+		{"2.5", 0, "2"},
+	}
+	for _, testCase := range halfEvenCases {
+		assert.Equal(t, testCase.expected, d(testCase.value).RoundHalfEven(testCase.places).String(), testCase.value)
+	}
+	assert.Equal(t, "1500.50", d("1500.5").StringFixed(2))
+	assert.Equal(t, 135.05, RoundFloat(135.045, 2))
+	assert.Equal(t, -0.13, RoundFloat(-0.125, 2))
+	assert.Equal(t, "1.5", d("1.5000").Trim().String())
+	assert.Equal(t, "-120", d("-120.00").Trim().String())
+	assert.Equal(t, "0", d("0.000").Trim().String())
+
+	quotient, err := d("2").Div(d("3"), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.6667", quotient.String())
+	quotient, err = d("-100.00").Div(d("0.08"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "-1250.00", quotient.String())
+	quotient, err = d("0.005").Div(d("1"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.01", quotient.String())
+	_, err = d("1").Div(Decimal{}, 2)
+	assert.True(t, errors.Is(err, ErrDivisionByZero))
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var row struct {
+		Amount   Decimal `json:"amount"`
+		Price    Decimal `json:"price"`
+		Discount Decimal `json:"discount"`
+		Total    Decimal `json:"total"`
+		Cost     Decimal `json:"cost"`
+	}
+	err := json.Unmarshal([]byte(`{"amount":"2.000","price":19.99,"discount":"","total":null,"cost":1e2}`), &row)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.000", row.Amount.String())
+	assert.Equal(t, "19.99", row.Price.String())
+	assert.True(t, row.Discount.IsZero())
+	assert.True(t, row.Total.IsZero())
+	assert.Equal(t, "100", row.Cost.String())
+
+	data, err := json.Marshal(row)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":2.000,"price":19.99,"discount":0,"total":0,"cost":100}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"amount":"two"}`), &row)
+	assert.EqualError(t, err, `invalid decimal "two"`)
+	err = json.Unmarshal([]byte(`{"amount":true}`), &row)
+	assert.Error(t, err)
+}
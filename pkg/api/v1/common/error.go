@@ -21,6 +21,7 @@ package common
 // THE SOFTWARE.
 
 import (
+	stdErrors "errors"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -396,3 +397,13 @@ func NewFromError(msg string, err error, code ApiError) *ErpError {
 	}
 	return NewErpError("Error", msg, code)
 }
+
+//IsQuotaError tells if the error was caused by exceeding the API requests quota, such requests can be repeated later
+func IsQuotaError(err error) bool {
+	var erpErr *ErpError
+	if !stdErrors.As(err, &erpErr) {
+		return false
+	}
+
+	return erpErr.Code == HourlyRequestQuota || erpErr.Code == TooManyBulkSubRequests
+}
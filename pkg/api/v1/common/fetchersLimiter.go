@@ -0,0 +1,105 @@
+package common
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//fetchersLimiter limits the count of concurrently running bulk requests, the limit is halved on a quota error,
+//decreased by one on a request slower than the target latency and increased by one otherwise up to the max count
+type fetchersLimiter struct {
+	tokens        chan struct{}
+	lock          sync.Mutex
+	limit         int
+	withheld      int
+	maxCount      int
+	targetLatency time.Duration
+}
+
+func newFetchersLimiter(maxCount int, targetLatency time.Duration) *fetchersLimiter {
+	if maxCount < 1 {
+		maxCount = 1
+	}
+
+	l := &fetchersLimiter{
+		tokens:        make(chan struct{}, maxCount),
+		limit:         maxCount,
+		maxCount:      maxCount,
+		targetLatency: targetLatency,
+	}
+	for i := 0; i < maxCount; i++ {
+		l.tokens <- struct{}{}
+	}
+
+	return l
+}
+
+func (l *fetchersLimiter) acquire(ctx context.Context) error {
+	select {
+	case <-l.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *fetchersLimiter) release(latency time.Duration, isQuotaErr bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	switch {
+	case isQuotaErr:
+		l.limit /= 2
+	case l.targetLatency > 0 && latency > l.targetLatency:
+		l.limit--
+	default:
+		l.limit++
+	}
+
+	if l.limit < 1 {
+		l.limit = 1
+	}
+	if l.limit > l.maxCount {
+		l.limit = l.maxCount
+	}
+
+	//the tokens in circulation are the ones which are not withheld, their count should converge to the limit
+	if l.maxCount-l.withheld > l.limit {
+		l.withheld++
+		return
+	}
+
+	l.tokens <- struct{}{}
+	for l.withheld > 0 && l.maxCount-l.withheld < l.limit {
+		l.withheld--
+		l.tokens <- struct{}{}
+	}
+}
+
+func (l *fetchersLimiter) currentLimit() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.limit
+}
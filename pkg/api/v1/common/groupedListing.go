@@ -0,0 +1,213 @@
+package common
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+//ItemsGroup is a group of items sharing the same key, error items are kept apart from the payload items
+type ItemsGroup struct {
+	Key    string
+	Items  []Item
+	Errors []Item
+}
+
+type ItemsGroupsStream chan ItemsGroup
+
+//GroupKeyFunc gives the key of the group where the payload belongs, e.g. a warehouse ID or a product group ID
+type GroupKeyFunc func(payload interface{}) string
+
+//GetGroupedByKey streams the items in groups of at most groupSize items with the same key. A partial group is sent when
+//the stream ends or when its first item waits longer than ListingSettings.MaxGroupWait. Error items don't belong to any
+//key, so each of them is sent immediately as a group with an empty key, empty Items and the error in Errors
+func (p *Lister) GetGroupedByKey(
+	ctx context.Context,
+	filters map[string]interface{},
+	groupSize int,
+	groupKey GroupKeyFunc,
+) ItemsGroupsStream {
+	itemsStream := p.Get(ctx, filters)
+	groupsChan := make(ItemsGroupsStream, p.listingSettings.MaxFetchersCount)
+	go func() {
+		defer close(groupsChan)
+		p.groupItems(ctx, itemsStream, groupSize, groupKey, true, func(group ItemsGroup) bool {
+			select {
+			case groupsChan <- group:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return groupsChan
+}
+
+type groupBuffer struct {
+	gen   int
+	items []Item
+}
+
+type pendingGroup struct {
+	key      string
+	gen      int
+	deadline time.Time
+}
+
+//groupItems reads itemsStream until it's closed or ctx is cancelled and passes the collected groups to emit,
+//if emit returns false grouping stops. Partial groups are flushed in the order they were started
+func (p *Lister) groupItems(
+	ctx context.Context,
+	itemsStream ItemsStream,
+	groupSize int,
+	groupKey GroupKeyFunc,
+	separateErrors bool,
+	emit func(group ItemsGroup) bool,
+) {
+	if groupSize < 1 {
+		groupSize = 1
+	}
+	maxWait := p.listingSettings.MaxGroupWait
+
+	buffers := map[string]*groupBuffer{}
+	//groups in the order of their first item, the deadlines are increasing, entries of already sent groups are skipped
+	pending := make([]pendingGroup, 0)
+	gen := 0
+
+	flush := func(key string) bool {
+		buf := buffers[key]
+		delete(buffers, key)
+		return emit(ItemsGroup{Key: key, Items: buf.items})
+	}
+
+	timer := time.NewTimer(time.Hour)
+	stopTimer(timer)
+	defer timer.Stop()
+	var timerC <-chan time.Time
+	var armedFor time.Time
+
+	for {
+		pending = dropSentGroups(pending, buffers)
+		if maxWait > 0 && len(pending) > 0 {
+			if deadline := pending[0].deadline; !deadline.Equal(armedFor) {
+				stopTimer(timer)
+				timer.Reset(time.Until(deadline))
+				timerC = timer.C
+				armedFor = deadline
+			}
+		} else if timerC != nil {
+			stopTimer(timer)
+			timerC = nil
+			armedFor = time.Time{}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-timerC:
+			timerC = nil
+			armedFor = time.Time{}
+			for len(pending) > 0 && !pending[0].deadline.After(now) {
+				pg := pending[0]
+				pending = pending[1:]
+				if buf, ok := buffers[pg.key]; ok && buf.gen == pg.gen {
+					if !flush(pg.key) {
+						return
+					}
+				}
+			}
+		case item, ok := <-itemsStream:
+			if !ok {
+				for _, key := range keysInStartOrder(buffers) {
+					if !flush(key) {
+						return
+					}
+				}
+				return
+			}
+
+			if item.Err != nil && separateErrors {
+				if !emit(ItemsGroup{Errors: []Item{item}}) {
+					return
+				}
+				continue
+			}
+
+			key := ""
+			if groupKey != nil && item.Err == nil {
+				key = groupKey(item.Payload)
+			}
+			buf, ok := buffers[key]
+			if !ok {
+				gen++
+				buf = &groupBuffer{gen: gen, items: make([]Item, 0, groupSize)}
+				buffers[key] = buf
+				//without the wait limit nothing drains the pending groups, the partial ones are flushed at the end
+				if maxWait > 0 {
+					pending = append(pending, pendingGroup{key: key, gen: gen, deadline: time.Now().Add(maxWait)})
+				}
+			}
+			buf.items = append(buf.items, item)
+			if len(buf.items) >= groupSize {
+				if !flush(key) {
+					return
+				}
+			}
+		}
+	}
+}
+
+//keysInStartOrder returns the keys of the buffered groups in the order their first items came
+func keysInStartOrder(buffers map[string]*groupBuffer) []string {
+	keys := make([]string, 0, len(buffers))
+	for key := range buffers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return buffers[keys[i]].gen < buffers[keys[j]].gen
+	})
+
+	return keys
+}
+
+func dropSentGroups(pending []pendingGroup, buffers map[string]*groupBuffer) []pendingGroup {
+	for len(pending) > 0 {
+		buf, ok := buffers[pending[0].key]
+		if ok && buf.gen == pending[0].gen {
+			break
+		}
+		pending = pending[1:]
+	}
+
+	return pending
+}
+
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
@@ -23,17 +23,34 @@ package common
 import (
 	"context"
 	"math"
-	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 const DefaultMaxFetchersCount = 1
 const DefaultMaxRequestsCountPerSecond = 0
+const DefaultMaxQuotaRetries = 3
+const DefaultQuotaRetryInterval = time.Second
 
 type ListingSettings struct {
 	MaxRequestsCountPerSecond int
 	StreamBufferLength        int
 	MaxFetchersCount          int
 	MaxItemsPerRequest        int
+	//Ordered makes the Lister emit items in the page order, otherwise items are emitted as soon as any fetcher gets them.
+	//In the ordered mode at most 2*MaxFetchersCount bulk requests are kept in memory while waiting for the previous pages
+	Ordered bool
+	//TargetLatency enables latency based adaptation of the fetchers count: if a bulk request takes longer than this value,
+	//one fetcher less is allowed to run, if it's faster, one more fetcher is allowed up to MaxFetchersCount
+	TargetLatency time.Duration
+	//MaxQuotaRetries is the count of retries of a bulk request which failed with a quota error, a negative value disables retries
+	MaxQuotaRetries int
+	//QuotaRetryInterval is multiplied by the attempt number to get the waiting time before retrying after a quota error
+	QuotaRetryInterval time.Duration
+	//MaxGroupWait is the max time the first item of a partial group waits in GetGrouped and GetGroupedByKey
+	//before the group is sent, zero means that partial groups are sent only at the end of the stream
+	MaxGroupWait time.Duration
 }
 
 type Cursor struct {
@@ -51,19 +68,37 @@ type Item struct {
 	Payload    interface{}
 }
 
+type cursorsBatch struct {
+	seq     int
+	cursors []Cursor
+}
+
+type fetchedBatch struct {
+	seq   int
+	items []Item
+}
+
 func setListingSettingsDefaults(settingsFromInput ListingSettings) ListingSettings {
 	if settingsFromInput.MaxRequestsCountPerSecond == 0 {
 		settingsFromInput.MaxRequestsCountPerSecond = DefaultMaxRequestsCountPerSecond
 	}
 
-	if settingsFromInput.MaxItemsPerRequest == 0 || settingsFromInput.MaxItemsPerRequest > MaxCountPerBulkRequestItem*MaxCountPerBulkRequestItem {
-		settingsFromInput.MaxItemsPerRequest = MaxCountPerBulkRequestItem * MaxCountPerBulkRequestItem
+	if settingsFromInput.MaxItemsPerRequest == 0 || settingsFromInput.MaxItemsPerRequest > MaxCountPerBulkRequestItem*MaxBulkRequestsCount {
+		settingsFromInput.MaxItemsPerRequest = MaxCountPerBulkRequestItem * MaxBulkRequestsCount
 	}
 
 	if settingsFromInput.MaxFetchersCount == 0 {
 		settingsFromInput.MaxFetchersCount = DefaultMaxFetchersCount
 	}
 
+	if settingsFromInput.MaxQuotaRetries == 0 {
+		settingsFromInput.MaxQuotaRetries = DefaultMaxQuotaRetries
+	}
+
+	if settingsFromInput.QuotaRetryInterval == 0 {
+		settingsFromInput.QuotaRetryInterval = DefaultQuotaRetryInterval
+	}
+
 	return settingsFromInput
 }
 
@@ -76,17 +111,25 @@ type Lister struct {
 	listingSettings     ListingSettings
 	reqThrottler        Throttler
 	listingDataProvider DataProvider
+	//sleeper replaces the context aware wait before the quota retries when it's set
+	sleeper Sleeper
 }
 
 func NewLister(settings ListingSettings, dataProvider DataProvider, sl Sleeper) *Lister {
 	settings = setListingSettingsDefaults(settings)
 
-	thrl := NewSleepThrottler(settings.MaxRequestsCountPerSecond, sl)
+	throttlerSleeper := sl
+	if throttlerSleeper == nil {
+		throttlerSleeper = time.Sleep
+	}
+
+	thrl := NewSleepThrottler(settings.MaxRequestsCountPerSecond, throttlerSleeper)
 
 	return &Lister{
 		listingSettings:     settings,
 		reqThrottler:        thrl,
 		listingDataProvider: dataProvider,
+		sleeper:             sl,
 	}
 }
 
@@ -95,48 +138,36 @@ func (p *Lister) SetRequestThrottler(thrl Throttler) {
 	p.reqThrottler = thrl
 }
 
+//GetGrouped streams the items in groups of groupSize items, a partial group is sent when the stream ends
+//or when its first item waits longer than ListingSettings.MaxGroupWait
 func (p *Lister) GetGrouped(ctx context.Context, filters map[string]interface{}, groupSize int) ItemsStreamGrouped {
 	itemsStream := p.Get(ctx, filters)
 	groupedItemsChan := make(ItemsStreamGrouped, p.listingSettings.MaxFetchersCount)
 	go func() {
 		defer close(groupedItemsChan)
-		buf := make([]Item, 0, groupSize)
-		defer func() {
-			if len(buf) == 0 {
-				return
-			}
-			groupedItemsChan <- buf
-		}()
-		for {
+		p.groupItems(ctx, itemsStream, groupSize, nil, false, func(group ItemsGroup) bool {
 			select {
+			case groupedItemsChan <- group.Items:
+				return true
 			case <-ctx.Done():
-				//context is cancelled
-				return
-			case item, ok := <-itemsStream:
-				if !ok {
-					//channel is closed
-					return
-				}
-				buf = append(buf, item)
-				if len(buf) >= groupSize {
-					groupedItemsChan <- buf
-					buf = make([]Item, 0)
-					continue
-				}
+				return false
 			}
-		}
+		})
 	}()
 
 	return groupedItemsChan
 }
 
+//Get streams all items matching the filters, the filters map is not modified. A failed request stops all fetchers,
+//and the error is sent as the last item of the stream. The stream is closed when all items are read or ctx is cancelled
 func (p *Lister) Get(ctx context.Context, filters map[string]interface{}) ItemsStream {
 	p.reqThrottler.Throttle()
 
-	filters["recordsOnPage"] = 1
-	filters["pageNo"] = 1
+	countFilters := copyFilters(filters)
+	countFilters["recordsOnPage"] = 1
+	countFilters["pageNo"] = 1
 
-	totalCount, err := p.listingDataProvider.Count(ctx, filters)
+	totalCount, err := p.listingDataProvider.Count(ctx, countFilters)
 	if err != nil {
 		outputChan := make(ItemsStream, 1)
 		defer close(outputChan)
@@ -149,153 +180,253 @@ func (p *Lister) Get(ctx context.Context, filters map[string]interface{}) ItemsS
 		return outputChan
 	}
 
-	cursorsChan := p.getCursors(ctx, totalCount)
-
-	childChans := make([]ItemsStream, 0, p.listingSettings.MaxFetchersCount)
-	for i := 0; i < p.listingSettings.MaxFetchersCount; i++ {
-		childChan := p.fetchItemsChunk(ctx, cursorsChan, totalCount, filters)
-		childChans = append(childChans, childChan)
-	}
-
-	return p.mergeChannels(ctx, childChans...)
-}
-
-func (p *Lister) fetchItemsChunk(ctx context.Context, cursorChan chan []Cursor, totalCount int, filters map[string]interface{}) ItemsStream {
-	prodStream := make(chan Item, p.listingSettings.StreamBufferLength)
+	listingFilters := copyFilters(filters)
+	outputChan := make(ItemsStream, p.listingSettings.StreamBufferLength)
 	go func() {
-		defer close(prodStream)
-		for cursors := range cursorChan {
-			p.fetchItemsFromAPI(ctx, cursors, totalCount, prodStream, filters)
+		defer close(outputChan)
 
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				continue
-			}
+		err := p.fetchAll(ctx, listingFilters, totalCount, outputChan)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case outputChan <- Item{Err: err, TotalCount: totalCount, Payload: nil}:
+		case <-ctx.Done():
 		}
 	}()
 
-	return prodStream
+	return outputChan
 }
 
-func (p *Lister) getCursors(ctx context.Context, totalCount int) chan []Cursor {
-	out := make(chan []Cursor, p.listingSettings.MaxFetchersCount)
-
-	leftCount := totalCount
+func (p *Lister) fetchAll(ctx context.Context, filters map[string]interface{}, totalCount int, outputChan ItemsStream) error {
+	batches := p.getCursorBatches(totalCount)
+	if len(batches) == 0 {
+		return nil
+	}
 
-	go func() {
-		defer close(out)
+	g, gctx := errgroup.WithContext(ctx)
+	limiter := newFetchersLimiter(p.listingSettings.MaxFetchersCount, p.listingSettings.TargetLatency)
+
+	var window chan struct{}
+	var fetchedChan chan fetchedBatch
+	if p.listingSettings.Ordered {
+		window = make(chan struct{}, 2*p.listingSettings.MaxFetchersCount)
+		fetchedChan = make(chan fetchedBatch, p.listingSettings.MaxFetchersCount)
+		g.Go(func() error {
+			return p.emitInOrder(gctx, len(batches), fetchedChan, window, outputChan)
+		})
+	}
 
-		curPage := 1
-		if p.listingSettings.MaxItemsPerRequest > MaxCountPerBulkRequestItem*MaxBulkRequestsCount {
-			p.listingSettings.MaxItemsPerRequest = MaxCountPerBulkRequestItem * MaxBulkRequestsCount
+	batchesChan := make(chan cursorsBatch)
+	g.Go(func() error {
+		defer close(batchesChan)
+		for _, batch := range batches {
+			if window != nil {
+				select {
+				case window <- struct{}{}:
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			}
+			select {
+			case batchesChan <- batch:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
 		}
+		return nil
+	})
 
-		for leftCount > 0 {
-			countToFetchForBulkRequest := leftCount
-			if leftCount > p.listingSettings.MaxItemsPerRequest {
-				countToFetchForBulkRequest = p.listingSettings.MaxItemsPerRequest
+	for i := 0; i < p.listingSettings.MaxFetchersCount; i++ {
+		g.Go(func() error {
+			for batch := range batchesChan {
+				err := p.fetchBatch(gctx, batch, totalCount, filters, limiter, outputChan, fetchedChan)
+				if err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+	}
 
-			bulkItemsCount := CeilDivisionInt(countToFetchForBulkRequest, MaxCountPerBulkRequestItem)
-			if bulkItemsCount > MaxBulkRequestsCount {
-				bulkItemsCount = MaxBulkRequestsCount
-			}
+	return g.Wait()
+}
 
-			limit := CeilDivisionInt(p.listingSettings.MaxItemsPerRequest, bulkItemsCount)
-			if limit > MaxCountPerBulkRequestItem {
-				limit = MaxCountPerBulkRequestItem
-			}
+func (p *Lister) getCursorBatches(totalCount int) []cursorsBatch {
+	if totalCount <= 0 {
+		return nil
+	}
 
-			cursorsForBulkRequest := make([]Cursor, 0, bulkItemsCount)
-			for i := 0; i < bulkItemsCount; i++ {
-				cursorsForBulkRequest = append(
-					cursorsForBulkRequest,
-					Cursor{
-						Limit:  limit,
-						Offset: curPage,
-					},
-				)
-				curPage++
-				leftCount -= limit
-			}
-			select {
-			case out <- cursorsForBulkRequest:
-				continue
-			case <-ctx.Done():
-				return
-			}
+	maxItemsPerRequest := p.listingSettings.MaxItemsPerRequest
+
+	//the page size should be the same for all pages, otherwise pageNo will point to wrong offsets
+	pagesPerRequest := CeilDivisionInt(maxItemsPerRequest, MaxCountPerBulkRequestItem)
+	if pagesPerRequest > MaxBulkRequestsCount {
+		pagesPerRequest = MaxBulkRequestsCount
+	}
+	limit := CeilDivisionInt(maxItemsPerRequest, pagesPerRequest)
+	if limit > MaxCountPerBulkRequestItem {
+		limit = MaxCountPerBulkRequestItem
+	}
+	pagesPerRequest = CeilDivisionInt(maxItemsPerRequest, limit)
+	if pagesPerRequest > MaxBulkRequestsCount {
+		pagesPerRequest = MaxBulkRequestsCount
+	}
+
+	pagesCount := CeilDivisionInt(totalCount, limit)
+	batches := make([]cursorsBatch, 0, CeilDivisionInt(pagesCount, pagesPerRequest))
+	for page := 1; page <= pagesCount; {
+		cursors := make([]Cursor, 0, pagesPerRequest)
+		for i := 0; i < pagesPerRequest && page <= pagesCount; i++ {
+			cursors = append(cursors, Cursor{
+				Limit:  limit,
+				Offset: page,
+			})
+			page++
 		}
-	}()
+		batches = append(batches, cursorsBatch{
+			seq:     len(batches),
+			cursors: cursors,
+		})
+	}
 
-	return out
+	return batches
 }
 
-func (p *Lister) fetchItemsFromAPI(
+func (p *Lister) fetchBatch(
 	ctx context.Context,
-	cursors []Cursor,
+	batch cursorsBatch,
 	totalCount int,
-	outputChan ItemsStream,
 	filters map[string]interface{},
-) {
-	bulkFilters := make([]map[string]interface{}, 0, len(cursors))
-	for _, cursor := range cursors {
-		bulkFilter := make(map[string]interface{})
-		for filterKey, filterValue := range filters {
-			bulkFilter[filterKey] = filterValue
-		}
+	limiter *fetchersLimiter,
+	outputChan ItemsStream,
+	fetchedChan chan fetchedBatch,
+) error {
+	bulkFilters := make([]map[string]interface{}, 0, len(batch.cursors))
+	for _, cursor := range batch.cursors {
+		bulkFilter := copyFilters(filters)
 		bulkFilter["recordsOnPage"] = cursor.Limit
 		bulkFilter["pageNo"] = cursor.Offset
 		bulkFilters = append(bulkFilters, bulkFilter)
 	}
 
-	p.reqThrottler.Throttle()
+	var items []Item
+	for attempt := 0; ; attempt++ {
+		err := limiter.acquire(ctx)
+		if err != nil {
+			return err
+		}
 
-	err := p.listingDataProvider.Read(ctx, bulkFilters, func(item interface{}) {
-		outputChan <- Item{
-			Err:        nil,
-			TotalCount: totalCount,
-			Payload:    item,
+		p.reqThrottler.Throttle()
+
+		items = nil
+		emittedCount := 0
+		startedAt := time.Now()
+		err = p.listingDataProvider.Read(ctx, bulkFilters, func(payload interface{}) {
+			item := Item{
+				Err:        nil,
+				TotalCount: totalCount,
+				Payload:    payload,
+			}
+			if fetchedChan != nil {
+				items = append(items, item)
+				return
+			}
+			select {
+			case outputChan <- item:
+				emittedCount++
+			case <-ctx.Done():
+			}
+		})
+
+		isQuotaErr := IsQuotaError(err)
+		limiter.release(time.Since(startedAt), isQuotaErr)
+
+		if err == nil {
+			break
 		}
-	})
 
-	if err != nil {
-		outputChan <- Item{
-			Err:        err,
-			TotalCount: totalCount,
-			Payload:    nil,
+		//items which were already sent cannot be taken back, so a retry would duplicate them
+		if !isQuotaErr || emittedCount > 0 || attempt >= p.listingSettings.MaxQuotaRetries {
+			return err
+		}
+
+		if err := p.wait(ctx, p.listingSettings.QuotaRetryInterval*time.Duration(attempt+1)); err != nil {
+			return err
 		}
-		return
+	}
+
+	if fetchedChan == nil {
+		return nil
+	}
+
+	select {
+	case fetchedChan <- fetchedBatch{seq: batch.seq, items: items}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-func (p *Lister) mergeChannels(ctx context.Context, childChans ...ItemsStream) ItemsStream {
-	parentChan := make(ItemsStream, p.listingSettings.StreamBufferLength)
+//wait sleeps before a retry, it returns early with the error of the context when it's cancelled
+func (p *Lister) wait(ctx context.Context, duration time.Duration) error {
+	if p.sleeper != nil {
+		p.sleeper(duration)
+		return ctx.Err()
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(childChans))
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
 
-	for _, childChan := range childChans {
-		go func(productsChildChan <-chan Item) {
-			defer wg.Done()
-			for prod := range productsChildChan {
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Lister) emitInOrder(
+	ctx context.Context,
+	batchesCount int,
+	fetchedChan <-chan fetchedBatch,
+	window <-chan struct{},
+	outputChan ItemsStream,
+) error {
+	pending := make(map[int][]Item)
+	for nextSeq := 0; nextSeq < batchesCount; {
+		select {
+		case batch := <-fetchedChan:
+			pending[batch.seq] = batch.items
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		for items, ok := pending[nextSeq]; ok; items, ok = pending[nextSeq] {
+			delete(pending, nextSeq)
+			for _, item := range items {
 				select {
-				case parentChan <- prod:
-					continue
+				case outputChan <- item:
 				case <-ctx.Done():
-					return
+					return ctx.Err()
 				}
 			}
-		}(childChan)
+			<-window
+			nextSeq++
+		}
 	}
 
-	go func() {
-		wg.Wait()
-		close(parentChan)
-	}()
+	return nil
+}
+
+func copyFilters(filters map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(filters)+2)
+	for filterKey, filterValue := range filters {
+		res[filterKey] = filterValue
+	}
 
-	return parentChan
+	return res
 }
 
 func CeilDivisionInt(x, y int) int {
@@ -33,6 +33,8 @@ import (
 
 var NullSleeper = func(sleepTime time.Duration) {}
 
+type ctxKeyMock struct{}
+
 type payloadMock struct {
 	ID int
 }
@@ -234,7 +236,7 @@ func TestReadingSuccess(t *testing.T) {
 				NullSleeper,
 			)
 
-			ctx, cancel := context.WithCancel(context.Background())
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKeyMock{}, "listing"))
 			defer cancel()
 			prodsChan := lister.Get(ctx, map[string]interface{}{"filterKey": "filterVal"})
 
@@ -242,7 +244,8 @@ func TestReadingSuccess(t *testing.T) {
 
 			assert.Equal(t, map[string]interface{}{"filterKey": "filterVal", "pageNo": 1, "recordsOnPage": 1}, dp.CountFiltersInput)
 			assert.Equal(t, ctx, dp.CountContextInput)
-			assert.Equal(t, ctx, dp.ReadContextInput)
+			//fetchers get a context derived from the input one, so they can be cancelled on the first failure
+			assert.Equal(t, "listing", dp.ReadContextInput.Value(ctxKeyMock{}))
 			assert.ElementsMatch(t, testCase.expectedBulkFilterInputs(), dp.ReadBulkFilters)
 
 			actualProgressCounts := make([]int, 0, len(actualProds))
@@ -392,7 +395,7 @@ func TestReadingGroupedSuccess(t *testing.T) {
 				NullSleeper,
 			)
 
-			ctx, cancel := context.WithCancel(context.Background())
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKeyMock{}, "listing"))
 			defer cancel()
 			prodsChanGrouped := lister.GetGrouped(ctx, map[string]interface{}{"filterKey": "filterVal"}, testCase.itemsCountPerGroup)
 
@@ -400,7 +403,8 @@ func TestReadingGroupedSuccess(t *testing.T) {
 
 			assert.Equal(t, map[string]interface{}{"filterKey": "filterVal", "pageNo": 1, "recordsOnPage": 1}, dp.CountFiltersInput)
 			assert.Equal(t, ctx, dp.CountContextInput)
-			assert.Equal(t, ctx, dp.ReadContextInput)
+			//fetchers get a context derived from the input one, so they can be cancelled on the first failure
+			assert.Equal(t, "listing", dp.ReadContextInput.Value(ctxKeyMock{}))
 			assert.ElementsMatch(t, testCase.expectedProdIdsFlat, flatIDs)
 			assert.Len(t, groups, len(testCase.expectedGroupCounts))
 
@@ -470,3 +474,368 @@ mainLoop:
 
 	return
 }
+
+type pagedDataProviderMock struct {
+	lock          sync.Mutex
+	totalCount    int
+	pageDelay     func(pageNo int) time.Duration
+	readErrors    []error
+	failingPage   int
+	readsCount    int
+	cancelledRead bool
+}
+
+func (pdm *pagedDataProviderMock) Count(ctx context.Context, filters map[string]interface{}) (int, error) {
+	return pdm.totalCount, nil
+}
+
+func (pdm *pagedDataProviderMock) Read(ctx context.Context, bulkFilters []map[string]interface{}, callback func(item interface{})) error {
+	pdm.lock.Lock()
+	pdm.readsCount++
+	var err error
+	if len(pdm.readErrors) > 0 {
+		err = pdm.readErrors[0]
+		pdm.readErrors = pdm.readErrors[1:]
+	}
+	pdm.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for _, bulkFilter := range bulkFilters {
+		pageNo := bulkFilter["pageNo"].(int)
+		limit := bulkFilter["recordsOnPage"].(int)
+		if pdm.pageDelay != nil {
+			select {
+			case <-time.After(pdm.pageDelay(pageNo)):
+			case <-ctx.Done():
+				pdm.lock.Lock()
+				pdm.cancelledRead = true
+				pdm.lock.Unlock()
+				return ctx.Err()
+			}
+		}
+		if pageNo == pdm.failingPage {
+			return errors.New("some fatal error")
+		}
+		for id := (pageNo - 1) * limit; id < pageNo*limit && id < pdm.totalCount; id++ {
+			callback(payloadMock{ID: id})
+		}
+	}
+
+	return nil
+}
+
+func TestGetDoesNotChangeFilters(t *testing.T) {
+	dp := &pagedDataProviderMock{totalCount: 30}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 10, MaxFetchersCount: 3}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := map[string]interface{}{"filterKey": "filterVal"}
+	actualProds := collectProdsFromChannel(lister.Get(ctx, filters))
+
+	assert.Len(t, actualProds, 30)
+	assert.Equal(t, map[string]interface{}{"filterKey": "filterVal"}, filters)
+}
+
+func TestReadingOrdered(t *testing.T) {
+	dp := &pagedDataProviderMock{
+		totalCount: 95,
+		//the later pages are faster, so without ordering they would come first
+		pageDelay: func(pageNo int) time.Duration {
+			return time.Duration(10-pageNo) * time.Millisecond
+		},
+	}
+	lister := NewLister(
+		ListingSettings{
+			MaxItemsPerRequest: 10,
+			MaxFetchersCount:   4,
+			Ordered:            true,
+		},
+		dp,
+		NullSleeper,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actualProds := collectProdsFromChannel(lister.Get(ctx, map[string]interface{}{}))
+
+	actualIDs := make([]int, 0, len(actualProds))
+	for _, prod := range actualProds {
+		assert.NoError(t, prod.Err)
+		actualIDs = append(actualIDs, prod.Payload.(payloadMock).ID)
+	}
+
+	expectedIDs := make([]int, 0, 95)
+	for id := 0; id < 95; id++ {
+		expectedIDs = append(expectedIDs, id)
+	}
+	assert.Equal(t, expectedIDs, actualIDs)
+}
+
+func TestReadErrorCancelsOtherFetchers(t *testing.T) {
+	dp := &pagedDataProviderMock{
+		totalCount:  30,
+		failingPage: 3,
+		pageDelay: func(pageNo int) time.Duration {
+			if pageNo == 3 {
+				return 20 * time.Millisecond
+			}
+			return time.Minute
+		},
+	}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 10, MaxFetchersCount: 3}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actualProds := collectProdsFromChannel(lister.Get(ctx, map[string]interface{}{}))
+
+	assert.Len(t, actualProds, 1)
+	assert.EqualError(t, actualProds[0].Err, "some fatal error")
+	assert.True(t, dp.cancelledRead)
+}
+
+func TestQuotaErrorIsRetried(t *testing.T) {
+	dp := &pagedDataProviderMock{
+		totalCount: 10,
+		readErrors: []error{NewErpError("Error", "getProducts: error", HourlyRequestQuota)},
+	}
+	sleptFor := make([]time.Duration, 0)
+	lister := NewLister(
+		ListingSettings{MaxItemsPerRequest: 10, QuotaRetryInterval: time.Second},
+		dp,
+		func(sleepTime time.Duration) {
+			sleptFor = append(sleptFor, sleepTime)
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actualProds := collectProdsFromChannel(lister.Get(ctx, map[string]interface{}{}))
+
+	assert.Len(t, actualProds, 10)
+	for _, prod := range actualProds {
+		assert.NoError(t, prod.Err)
+	}
+	assert.Equal(t, 2, dp.readsCount)
+	assert.Equal(t, []time.Duration{time.Second}, sleptFor)
+}
+
+func TestQuotaRetryWaitStopsOnCancel(t *testing.T) {
+	dp := &pagedDataProviderMock{
+		totalCount: 10,
+		readErrors: []error{NewErpError("Error", "getProducts: error", HourlyRequestQuota)},
+	}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 10, QuotaRetryInterval: time.Hour}, dp, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan []Item)
+	go func() {
+		done <- collectProdsFromChannel(lister.Get(ctx, map[string]interface{}{}))
+	}()
+
+	select {
+	case items := <-done:
+		assert.Equal(t, 1, dp.readsCount)
+		for _, item := range items {
+			assert.Nil(t, item.Payload)
+		}
+	case <-time.After(time.Second):
+		assert.Fail(t, "the quota retry backoff ignored the context cancellation")
+	}
+}
+
+func TestQuotaErrorRetriesDisabled(t *testing.T) {
+	dp := &pagedDataProviderMock{
+		totalCount: 10,
+		readErrors: []error{NewErpError("Error", "getProducts: error", HourlyRequestQuota)},
+	}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 10, MaxQuotaRetries: -1}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	actualProds := collectProdsFromChannel(lister.Get(ctx, map[string]interface{}{}))
+
+	assert.Len(t, actualProds, 1)
+	assert.True(t, IsQuotaError(actualProds[0].Err))
+	assert.Equal(t, 1, dp.readsCount)
+}
+
+func TestStreamIsClosedWhenConsumerCancels(t *testing.T) {
+	dp := &pagedDataProviderMock{totalCount: 1000}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 10, MaxFetchersCount: 5, Ordered: true}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	prodsChan := lister.Get(ctx, map[string]interface{}{})
+
+	<-prodsChan
+	cancel()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for range prodsChan {
+		}
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the items stream was not closed after the context cancellation")
+	}
+}
+
+func TestFetchersLimiterAdaptation(t *testing.T) {
+	limiter := newFetchersLimiter(8, 100*time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 8; i++ {
+		assert.NoError(t, limiter.acquire(ctx))
+	}
+
+	limiter.release(time.Millisecond, true)
+	assert.Equal(t, 4, limiter.currentLimit())
+
+	for i := 0; i < 7; i++ {
+		limiter.release(time.Second, false)
+	}
+	assert.Equal(t, 1, limiter.currentLimit())
+
+	//only one fetcher is allowed after the slow requests
+	assert.NoError(t, limiter.acquire(ctx))
+	assertAcquireTimesOut(t, limiter)
+
+	limiter.release(time.Millisecond, false)
+	assert.Equal(t, 2, limiter.currentLimit())
+	assert.NoError(t, limiter.acquire(ctx))
+	assert.NoError(t, limiter.acquire(ctx))
+	assertAcquireTimesOut(t, limiter)
+}
+
+func assertAcquireTimesOut(t *testing.T, limiter *fetchersLimiter) {
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, limiter.acquire(timeoutCtx))
+}
+
+func TestGroupedPartialGroupIsFlushedAfterMaxWait(t *testing.T) {
+	dp := &pagedDataProviderMock{
+		totalCount: 15,
+		pageDelay: func(pageNo int) time.Duration {
+			if pageNo == 2 {
+				return 300 * time.Millisecond
+			}
+			return 0
+		},
+	}
+	lister := NewLister(
+		ListingSettings{
+			MaxItemsPerRequest: 5,
+			MaxFetchersCount:   1,
+			Ordered:            true,
+			MaxGroupWait:       20 * time.Millisecond,
+		},
+		dp,
+		NullSleeper,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startedAt := time.Now()
+	groupsChan := lister.GetGrouped(ctx, map[string]interface{}{}, 10)
+
+	firstGroup := <-groupsChan
+	assert.Len(t, firstGroup, 5)
+	assert.True(t, time.Since(startedAt) < 250*time.Millisecond, "partial group should not wait for the slow page")
+
+	groupSizes := []int{len(firstGroup)}
+	for group := range groupsChan {
+		groupSizes = append(groupSizes, len(group))
+	}
+	assert.Equal(t, 15, sumInts(groupSizes))
+}
+
+func TestGroupedWithoutMaxWaitWaitsForFullGroups(t *testing.T) {
+	dp := &pagedDataProviderMock{totalCount: 25}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 5, MaxFetchersCount: 2}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	groupSizes := []int{}
+	for group := range lister.GetGrouped(ctx, map[string]interface{}{}, 10) {
+		groupSizes = append(groupSizes, len(group))
+	}
+	assert.Equal(t, []int{10, 10, 5}, groupSizes)
+}
+
+func TestGroupedByKey(t *testing.T) {
+	dp := &pagedDataProviderMock{totalCount: 23}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 5, MaxFetchersCount: 3}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keyFunc := func(payload interface{}) string {
+		if payload.(payloadMock).ID%2 == 0 {
+			return "even"
+		}
+		return "odd"
+	}
+
+	countsByKey := map[string]int{}
+	for group := range lister.GetGroupedByKey(ctx, map[string]interface{}{}, 4, keyFunc) {
+		assert.Empty(t, group.Errors)
+		assert.True(t, len(group.Items) > 0 && len(group.Items) <= 4)
+		for _, item := range group.Items {
+			assert.Equal(t, group.Key, keyFunc(item.Payload))
+		}
+		countsByKey[group.Key] += len(group.Items)
+	}
+	assert.Equal(t, map[string]int{"even": 12, "odd": 11}, countsByKey)
+}
+
+func TestGroupedByKeySeparatesErrors(t *testing.T) {
+	dp := &pagedDataProviderMock{totalCount: 30, failingPage: 3}
+	lister := NewLister(ListingSettings{MaxItemsPerRequest: 5, MaxFetchersCount: 1, Ordered: true}, dp, NullSleeper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	payloadCount := 0
+	errs := []Item{}
+	for group := range lister.GetGroupedByKey(ctx, map[string]interface{}{}, 100, nil) {
+		for _, item := range group.Items {
+			assert.NoError(t, item.Err)
+		}
+		if len(group.Errors) > 0 {
+			assert.Empty(t, group.Items)
+			assert.Equal(t, "", group.Key)
+		}
+		payloadCount += len(group.Items)
+		errs = append(errs, group.Errors...)
+	}
+
+	assert.True(t, payloadCount <= 10)
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0].Err, "some fatal error")
+	}
+}
+
+func sumInts(vals []int) int {
+	sum := 0
+	for _, val := range vals {
+		sum += val
+	}
+	return sum
+}
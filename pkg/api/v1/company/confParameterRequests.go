@@ -50,6 +50,15 @@ func (cli *Client) GetConfParameters(ctx context.Context) (*ConfParameter, error
 	return &res.ConfParameters[0], nil
 }
 
+//GetTimeZone returns the account timezone from the configuration parameters
+func GetTimeZone(ctx context.Context, manager Manager) (sharedCommon.TimeZone, error) {
+	conf, err := manager.GetConfParameters(ctx)
+	if err != nil {
+		return sharedCommon.TimeZone{}, err
+	}
+	return conf.AccountTimeZone()
+}
+
 func (cli *Client) GetDefaultLanguage(ctx context.Context) (*Language, error) {
 
 	const requestName = "getDefaultLanguage"
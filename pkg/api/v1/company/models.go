@@ -632,3 +632,8 @@ type (
 		Languages []Language     `json:"records"`
 	}
 )
+
+//AccountTimeZone returns the timezone of the account for the dates and the times in the API, UTC if it's not set
+func (c ConfParameter) AccountTimeZone() (common2.TimeZone, error) {
+	return common2.LoadTimeZone(c.Timezone)
+}
@@ -404,3 +404,18 @@ type (
 		BulkItems []AddCustomerRewardPointsResponseBulkItem `json:"requests"`
 	}
 )
+
+//ActualBalanceDecimal parses ActualBalance
+func (b CustomerBalance) ActualBalanceDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(b.ActualBalance.String())
+}
+
+//AvailableCreditDecimal parses AvailableCredit
+func (b CustomerBalance) AvailableCreditDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(b.AvailableCredit.String())
+}
+
+//CreditLimitDecimal returns CreditLimit as a decimal
+func (b CustomerBalance) CreditLimitDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromInt(int64(b.CreditLimit))
+}
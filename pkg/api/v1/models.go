@@ -188,6 +188,8 @@ type Currency struct {
 	Default      string `json:"default"`
 	NameShort    string `json:"nameShort"`
 	NameFraction string `json:"nameFraction"`
+	//Rate is the value of a unit of the currency in the default currency
+	Rate         string `json:"rate"`
 	Added        string `json:"added"`
 	LastModified string `json:"lastModified"`
 }
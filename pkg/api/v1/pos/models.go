@@ -21,6 +21,8 @@ package pos
 // THE SOFTWARE.
 
 import (
+	"time"
+
 	common2 "github.com/bhojpur/erp/pkg/api/v1/common"
 )
 
@@ -52,3 +54,18 @@ type (
 		ClockIns []Clocking     `json:"records"`
 	}
 )
+
+//InTime returns the clock-in time
+func (c Clocking) InTime(tz common2.TimeZone) time.Time {
+	return tz.Unix(c.InUnixTime)
+}
+
+//OutTime returns the clock-out time, the zero time if the employee hasn't clocked out
+func (c Clocking) OutTime(tz common2.TimeZone) time.Time {
+	return tz.Unix(c.OutUnixTime)
+}
+
+//LastModifiedTime returns the time of the last change of the point of sale
+func (p PointOfSale) LastModifiedTime(tz common2.TimeZone) time.Time {
+	return tz.Unix(int64(p.LastModified))
+}
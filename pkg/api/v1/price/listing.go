@@ -0,0 +1,151 @@
+package price
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+)
+
+type PriceListsListingDataProvider struct {
+	erpAPI Manager
+}
+
+func NewPriceListsListingDataProvider(erpClient Manager) *PriceListsListingDataProvider {
+	return &PriceListsListingDataProvider{
+		erpAPI: erpClient,
+	}
+}
+
+func (pldp *PriceListsListingDataProvider) Count(ctx context.Context, filters map[string]interface{}) (int, error) {
+	filters["recordsOnPage"] = 1
+	filters["pageNo"] = 1
+
+	resp, err := pldp.erpAPI.GetPriceListsBulk(ctx, []map[string]interface{}{filters}, map[string]string{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp.BulkItems) == 0 {
+		return 0, nil
+	}
+
+	return resp.BulkItems[0].Status.RecordsTotal, nil
+}
+
+func (pldp *PriceListsListingDataProvider) Read(ctx context.Context, bulkFilters []map[string]interface{}, callback func(item interface{})) error {
+	resp, err := pldp.erpAPI.GetPriceListsBulk(ctx, bulkFilters, map[string]string{})
+	if err != nil {
+		return err
+	}
+
+	for _, bulkItem := range resp.BulkItems {
+		for i := range bulkItem.PriceLists {
+			callback(bulkItem.PriceLists[i])
+		}
+	}
+
+	return nil
+}
+
+type SupplierPriceListsListingDataProvider struct {
+	erpAPI Manager
+}
+
+func NewSupplierPriceListsListingDataProvider(erpClient Manager) *SupplierPriceListsListingDataProvider {
+	return &SupplierPriceListsListingDataProvider{
+		erpAPI: erpClient,
+	}
+}
+
+func (spldp *SupplierPriceListsListingDataProvider) Count(ctx context.Context, filters map[string]interface{}) (int, error) {
+	filters["recordsOnPage"] = 1
+	filters["pageNo"] = 1
+
+	resp, err := spldp.erpAPI.GetSupplierPriceListsBulk(ctx, []map[string]interface{}{filters}, map[string]string{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp.BulkItems) == 0 {
+		return 0, nil
+	}
+
+	return resp.BulkItems[0].Status.RecordsTotal, nil
+}
+
+func (spldp *SupplierPriceListsListingDataProvider) Read(ctx context.Context, bulkFilters []map[string]interface{}, callback func(item interface{})) error {
+	resp, err := spldp.erpAPI.GetSupplierPriceListsBulk(ctx, bulkFilters, map[string]string{})
+	if err != nil {
+		return err
+	}
+
+	for _, bulkItem := range resp.BulkItems {
+		for i := range bulkItem.PriceLists {
+			callback(bulkItem.PriceLists[i])
+		}
+	}
+
+	return nil
+}
+
+type ProductsInSupplierPriceListListingDataProvider struct {
+	erpAPI Manager
+}
+
+func NewProductsInSupplierPriceListListingDataProvider(erpClient Manager) *ProductsInSupplierPriceListListingDataProvider {
+	return &ProductsInSupplierPriceListListingDataProvider{
+		erpAPI: erpClient,
+	}
+}
+
+func (pdp *ProductsInSupplierPriceListListingDataProvider) Count(ctx context.Context, filters map[string]interface{}) (int, error) {
+	filters["recordsOnPage"] = 1
+	filters["pageNo"] = 1
+
+	resp, err := pdp.erpAPI.GetProductsInSupplierPriceListBulk(ctx, []map[string]interface{}{filters}, map[string]string{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp.BulkItems) == 0 {
+		return 0, nil
+	}
+
+	return resp.BulkItems[0].Status.RecordsTotal, nil
+}
+
+func (pdp *ProductsInSupplierPriceListListingDataProvider) Read(ctx context.Context, bulkFilters []map[string]interface{}, callback func(item interface{})) error {
+	resp, err := pdp.erpAPI.GetProductsInSupplierPriceListBulk(ctx, bulkFilters, map[string]string{})
+	if err != nil {
+		return err
+	}
+
+	for _, bulkItem := range resp.BulkItems {
+		for i := range bulkItem.ProductsInSupplierPriceList {
+			callback(bulkItem.ProductsInSupplierPriceList[i])
+		}
+	}
+
+	return nil
+}
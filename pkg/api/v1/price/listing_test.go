@@ -0,0 +1,246 @@
+package price
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func sendPriceListsResponse(w http.ResponseWriter, errStatus sharedCommon.ApiError, totalCount int, priceListIDsBulk [][]int) error {
+	bulkResp := GetRegularPriceListResponseBulk{
+		Status: sharedCommon.Status{ResponseStatus: "ok"},
+	}
+
+	for _, priceListIDs := range priceListIDsBulk {
+		priceLists := make([]RegularPriceList, 0, len(priceListIDs))
+		for _, id := range priceListIDs {
+			priceLists = append(priceLists, RegularPriceList{PricelistID: id})
+		}
+		statusBulk := sharedCommon.StatusBulk{}
+		if errStatus == 0 {
+			statusBulk.ResponseStatus = "ok"
+		} else {
+			statusBulk.ResponseStatus = "not ok"
+		}
+		statusBulk.RecordsTotal = totalCount
+		statusBulk.ErrorCode = errStatus
+		statusBulk.RecordsInResponse = len(priceListIDs)
+
+		bulkResp.BulkItems = append(bulkResp.BulkItems, GetRegularPriceListResponseBulkItem{
+			Status:     statusBulk,
+			PriceLists: priceLists,
+		})
+	}
+
+	jsonRaw, err := json.Marshal(bulkResp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(jsonRaw)
+	return err
+}
+
+func TestPriceListsListingCountSuccess(t *testing.T) {
+	const totalCount = 10
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		requests := parsedRequest["requests"].([]map[string]interface{})
+		assert.Equal(t, float64(1), requests[0]["pageNo"])
+		assert.Equal(t, float64(1), requests[0]["recordsOnPage"])
+		assert.Equal(t, "getPriceLists", requests[0]["requestName"])
+		assert.Equal(t, "smeval", requests[0]["somekey"])
+
+		err = sendPriceListsResponse(w, 0, totalCount, [][]int{{1}})
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	dataProvider := NewPriceListsListingDataProvider(NewClient(baseClient))
+
+	actualCount, err := dataProvider.Count(context.Background(), map[string]interface{}{"somekey": "smeval"})
+	assert.NoError(t, err)
+	assert.Equal(t, totalCount, actualCount)
+}
+
+func TestPriceListsListingCountError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := sendPriceListsResponse(w, sharedCommon.MalformedRequest, 0, [][]int{{1}})
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	dataProvider := NewPriceListsListingDataProvider(NewClient(baseClient))
+
+	actualCount, err := dataProvider.Count(context.Background(), map[string]interface{}{"somekey": "smeval"})
+	assert.Error(t, err)
+	if err == nil {
+		return
+	}
+	assert.Contains(t, err.Error(), sharedCommon.MalformedRequest.String())
+	assert.Equal(t, 0, actualCount)
+}
+
+func TestPriceListsListingReadSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		requests := parsedRequest["requests"].([]map[string]interface{})
+		assert.Len(t, requests, 2)
+		assert.Equal(t, float64(2), requests[1]["pageNo"])
+
+		err = sendPriceListsResponse(w, 0, 3, [][]int{{1, 2}, {3}})
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	dataProvider := NewPriceListsListingDataProvider(NewClient(baseClient))
+
+	actualIDs := make([]int, 0, 3)
+	err := dataProvider.Read(
+		context.Background(),
+		[]map[string]interface{}{
+			{"pageNo": 1, "recordsOnPage": 2},
+			{"pageNo": 2, "recordsOnPage": 2},
+		},
+		func(item interface{}) {
+			assert.IsType(t, RegularPriceList{}, item)
+			actualIDs = append(actualIDs, item.(RegularPriceList).PricelistID)
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, actualIDs)
+}
+
+func TestSupplierPriceListsListingReadSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		requests := parsedRequest["requests"].([]map[string]interface{})
+		assert.Equal(t, "getSupplierPriceLists", requests[0]["requestName"])
+
+		bulkResp := GetPriceListsResponseBulk{
+			Status: sharedCommon.Status{ResponseStatus: "ok"},
+			BulkItems: []GetPriceListsResponseBulkItem{
+				{
+					Status:     sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok", RecordsTotal: 2}},
+					PriceLists: []PriceList{{ID: 4}, {ID: 5}},
+				},
+			},
+		}
+		jsonRaw, err := json.Marshal(bulkResp)
+		assert.NoError(t, err)
+		_, err = w.Write(jsonRaw)
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	dataProvider := NewSupplierPriceListsListingDataProvider(NewClient(baseClient))
+
+	actualIDs := make([]int, 0, 2)
+	err := dataProvider.Read(
+		context.Background(),
+		[]map[string]interface{}{{"pageNo": 1, "recordsOnPage": 2}},
+		func(item interface{}) {
+			actualIDs = append(actualIDs, item.(PriceList).ID)
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, actualIDs)
+}
+
+func TestProductsInSupplierPriceListListingReadSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		requests := parsedRequest["requests"].([]map[string]interface{})
+		assert.Equal(t, "getProductsInSupplierPriceList", requests[0]["requestName"])
+		assert.Equal(t, "3", requests[0]["supplierPriceListID"])
+
+		bulkResp := ProductsInSupplierPriceListResponseBulk{
+			Status: sharedCommon.Status{ResponseStatus: "ok"},
+			BulkItems: []ProductsInSupplierPriceListResponseBulkItem{
+				{
+					Status:                      sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok", RecordsTotal: 2}},
+					ProductsInSupplierPriceList: []ProductsInSupplierPriceList{{ProductID: 10}, {ProductID: 11}},
+				},
+			},
+		}
+		jsonRaw, err := json.Marshal(bulkResp)
+		assert.NoError(t, err)
+		_, err = w.Write(jsonRaw)
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	dataProvider := NewProductsInSupplierPriceListListingDataProvider(NewClient(baseClient))
+
+	actualIDs := make([]int, 0, 2)
+	err := dataProvider.Read(
+		context.Background(),
+		[]map[string]interface{}{{"pageNo": 1, "recordsOnPage": 2, "supplierPriceListID": "3"}},
+		func(item interface{}) {
+			actualIDs = append(actualIDs, item.(ProductsInSupplierPriceList).ProductID)
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10, 11}, actualIDs)
+}
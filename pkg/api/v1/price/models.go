@@ -21,6 +21,8 @@ package price
 // THE SOFTWARE.
 
 import (
+	"time"
+
 	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
 )
 
@@ -273,3 +275,58 @@ type DeleteProductsFromPriceListResponseBulk struct {
 	Status    sharedCommon.Status                   `json:"status"`
 	BulkItems []DeleteProductsFromPriceListBulkItem `json:"requests"`
 }
+
+//PriceDecimal returns Price as an exact decimal, the float32 digits beyond its precision are dropped
+func (r PriceListRule) PriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(r.Price)
+}
+
+//PriceDecimal returns Price as an exact decimal, the float32 digits beyond its precision are dropped
+func (r RegularPriceListRule) PriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(r.Price)
+}
+
+//PriceDecimal returns Price as an exact decimal, the float32 digits beyond its precision are dropped
+func (p ProductsInSupplierPriceList) PriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(p.Price)
+}
+
+//PriceDecimal returns Price as an exact decimal, the float32 digits beyond its precision are dropped
+func (p ProductsInPriceList) PriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(p.Price)
+}
+
+//SubsidyDecimal returns Subsidy as an exact decimal
+func (p ProductsInPriceList) SubsidyDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(p.Subsidy)
+}
+
+//ValidFromTime returns the start date of the price list in the account timezone, the zero time if it's not set
+func (p PriceList) ValidFromTime(tz sharedCommon.TimeZone) (time.Time, error) {
+	return tz.ParseDate(p.ValidFrom)
+}
+
+//ValidToTime returns the start of the last day of the price list in the account timezone, the zero time if it's not set
+func (p PriceList) ValidToTime(tz sharedCommon.TimeZone) (time.Time, error) {
+	return tz.ParseDate(p.ValidTo)
+}
+
+//LastModifiedTime returns the time of the last change of the price list
+func (p PriceList) LastModifiedTime(tz sharedCommon.TimeZone) time.Time {
+	return tz.Unix(int64(p.LastModifiedTimestamp))
+}
+
+//ValidFromTime returns the start date of the price list in the account timezone, the zero time if it's not set
+func (p RegularPriceList) ValidFromTime(tz sharedCommon.TimeZone) (time.Time, error) {
+	return tz.ParseDate(p.ValidFrom)
+}
+
+//ValidToTime returns the start of the last day of the price list in the account timezone, the zero time if it's not set
+func (p RegularPriceList) ValidToTime(tz sharedCommon.TimeZone) (time.Time, error) {
+	return tz.ParseDate(p.ValidTo)
+}
+
+//LastModifiedTime returns the time of the last change of the price list
+func (p RegularPriceList) LastModifiedTime(tz sharedCommon.TimeZone) time.Time {
+	return tz.Unix(int64(p.LastModifiedTimestamp))
+}
@@ -0,0 +1,442 @@
+package price
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//price list rule types
+const (
+	RuleTypeProduct  = "PRODUCT"
+	RuleTypeGroup    = "PRODGROUP"
+	RuleTypeCategory = "PRODCAT"
+)
+
+//the suggested assignment priorities, the price lists of the customer override the customer group ones
+//and those override the price lists of the warehouse
+const (
+	PriorityWarehouse     = 10
+	PriorityCustomerGroup = 20
+	PriorityCustomer      = 30
+)
+
+//ResolveStrategy decides which of the matching price lists wins
+type ResolveStrategy int
+
+const (
+	//FirstByPriority takes the price from the matching price list with the highest priority
+	FirstByPriority ResolveStrategy = iota
+	//LowestPrice takes the lowest price of all matching price lists
+	LowestPrice
+)
+
+//Assignment links a price list to a customer, a customer group or a warehouse,
+//the zero IDs match everything so an assignment without IDs applies to all sales
+type Assignment struct {
+	PriceListID     int
+	Priority        int
+	CustomerID      int
+	CustomerGroupID int
+	WarehouseID     int
+}
+
+func (a Assignment) matches(q PriceQuery) bool {
+	return (a.CustomerID == 0 || a.CustomerID == q.CustomerID) &&
+		(a.CustomerGroupID == 0 || a.CustomerGroupID == q.CustomerGroupID) &&
+		(a.WarehouseID == 0 || a.WarehouseID == q.WarehouseID)
+}
+
+//ResolverRule is a price list rule in the form the resolver evaluates it, a rule gives either an absolute Price
+//or a DiscountPercent from the base price. A product rule without both is a free item. It applies from MinAmount units
+type ResolverRule struct {
+	Type            string
+	ID              int
+	Price           float64
+	DiscountPercent float64
+	MinAmount       float64
+}
+
+func (r ResolverRule) String() string {
+	s := fmt.Sprintf("%s %d", r.Type, r.ID)
+	if r.MinAmount > 0 {
+		s += fmt.Sprintf(" from %s units", formatFloat(r.MinAmount))
+	}
+	if !r.isDiscount() {
+		return s + " price " + formatFloat(r.Price)
+	}
+	return s + " discount " + formatFloat(r.DiscountPercent) + "%"
+}
+
+//isDiscount tells if the rule gives a discount from the base price instead of a price, the price wins
+//when both are set and a product rule without both gives the price 0
+func (r ResolverRule) isDiscount() bool {
+	if r.Price != 0 {
+		return false
+	}
+	return r.DiscountPercent != 0 || r.Type != RuleTypeProduct
+}
+
+func (r ResolverRule) apply(basePrice float64) float64 {
+	if !r.isDiscount() {
+		return r.Price
+	}
+	return basePrice * (100 - r.DiscountPercent) / 100
+}
+
+//ruleKey identifies a product rule of a price list, the quantity breaks of a product are separate rules
+type ruleKey struct {
+	Type      string
+	ID        int
+	MinAmount float64
+}
+
+func (r ResolverRule) key() ruleKey {
+	return ruleKey{Type: r.Type, ID: r.ID, MinAmount: r.MinAmount}
+}
+
+func (r ResolverRule) specificity() int {
+	switch r.Type {
+	case RuleTypeProduct:
+		return 3
+	case RuleTypeGroup:
+		return 2
+	case RuleTypeCategory:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (r ResolverRule) matches(q PriceQuery) bool {
+	switch r.Type {
+	case RuleTypeProduct:
+		return r.ID == q.ProductID
+	case RuleTypeGroup:
+		return q.ProductGroupID != 0 && r.ID == q.ProductGroupID
+	case RuleTypeCategory:
+		return q.ProductCategoryID != 0 && r.ID == q.ProductCategoryID
+	default:
+		return false
+	}
+}
+
+type resolverPriceList struct {
+	ID        int
+	Name      string
+	ValidFrom string
+	ValidTo   string
+	Active    string
+	Rules     []ResolverRule
+	//ProductRules come from the product prices of the price list, they are kept when the price list is replaced
+	ProductRules []ResolverRule
+	productRules map[ruleKey]int
+}
+
+//addProductRule adds the rule or replaces the rule of the same product and quantity break
+func (p *resolverPriceList) addProductRule(rule ResolverRule) {
+	if p.productRules == nil {
+		p.productRules = map[ruleKey]int{}
+	}
+	if i, ok := p.productRules[rule.key()]; ok {
+		p.ProductRules[i] = rule
+		return
+	}
+	p.productRules[rule.key()] = len(p.ProductRules)
+	p.ProductRules = append(p.ProductRules, rule)
+}
+
+//validity returns an empty string when the price list can be used on the date or the reason why it can't be
+func (p *resolverPriceList) validity(date time.Time) string {
+	if p.Active != "" && p.Active != "1" {
+		return "inactive"
+	}
+	if date.IsZero() {
+		return ""
+	}
+
+	day := date.Format(sharedCommon.DateLayout)
+	if IsDateSet(p.ValidFrom) && day < p.ValidFrom {
+		return "valid from " + p.ValidFrom
+	}
+	if IsDateSet(p.ValidTo) && day > p.ValidTo {
+		return "valid until " + p.ValidTo
+	}
+
+	return ""
+}
+
+//IsDateSet tells if the price list start or end date is filled, the API returns 0000-00-00 for the missing dates
+func IsDateSet(date string) bool {
+	return date != "" && date != "0000-00-00"
+}
+
+//PriceQuery describes the sale for which the price is resolved
+type PriceQuery struct {
+	ProductID         int
+	ProductGroupID    int
+	ProductCategoryID int
+	//BasePrice is the price on the product card, the percentage rules are applied to it
+	BasePrice       float64
+	CustomerID      int
+	CustomerGroupID int
+	WarehouseID     int
+	Quantity        float64
+	//Date is checked against the validity of the price lists, the zero time ignores the validity
+	Date time.Time
+	//PriceListIDs skips the assignments and uses the listed price lists, the first one has the highest priority
+	PriceListIDs []int
+}
+
+//TraceStep explains a decision of the resolver, PriceListID is zero for the steps which are not about a price list
+type TraceStep struct {
+	PriceListID int
+	Message     string
+}
+
+func (s TraceStep) String() string {
+	if s.PriceListID == 0 {
+		return s.Message
+	}
+	return fmt.Sprintf("price list %d: %s", s.PriceListID, s.Message)
+}
+
+//Resolution is the price which applies to the query, PriceListID and Rule are empty when the base price is used
+type Resolution struct {
+	BasePrice   float64
+	Price       float64
+	PriceListID int
+	Rule        *ResolverRule
+	Trace       []TraceStep
+}
+
+//Explanation returns the trace as text, one step per line
+func (r *Resolution) Explanation() string {
+	lines := make([]string, 0, len(r.Trace))
+	for _, step := range r.Trace {
+		lines = append(lines, step.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Resolution) trace(priceListID int, format string, args ...interface{}) {
+	r.Trace = append(r.Trace, TraceStep{PriceListID: priceListID, Message: fmt.Sprintf(format, args...)})
+}
+
+//Resolver finds the price which applies to a sale from the cached price lists, it's not safe for concurrent changes
+type Resolver struct {
+	Strategy    ResolveStrategy
+	priceLists  map[int]*resolverPriceList
+	assignments []Assignment
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		priceLists: map[int]*resolverPriceList{},
+	}
+}
+
+func (r *Resolver) priceList(id int) *resolverPriceList {
+	priceList, ok := r.priceLists[id]
+	if !ok {
+		priceList = &resolverPriceList{ID: id}
+		r.priceLists[id] = priceList
+	}
+	return priceList
+}
+
+//AddPriceLists replaces the cached price lists with the same IDs, the rules added
+//with AddPriceListRules and AddProductsInPriceList are kept
+func (r *Resolver) AddPriceLists(priceLists ...RegularPriceList) {
+	for _, priceList := range priceLists {
+		cached := r.priceList(priceList.PricelistID)
+		cached.Name = priceList.Name
+		cached.ValidFrom = priceList.ValidFrom
+		cached.ValidTo = priceList.ValidTo
+		cached.Active = priceList.Active
+
+		rules := make([]ResolverRule, 0, len(priceList.Rules))
+		for _, rule := range priceList.Rules {
+			rules = append(rules, ResolverRule{
+				Type:            strings.ToUpper(rule.Type),
+				ID:              rule.ID,
+				Price:           sharedCommon.DecimalFromFloat32(rule.Price).Float64(),
+				DiscountPercent: float64(rule.DiscountPercent),
+			})
+		}
+		cached.Rules = rules
+	}
+}
+
+//AddPriceListRules adds the rules of a price list which can have quantity breaks, adding a rule
+//of the same product and quantity again replaces it
+func (r *Resolver) AddPriceListRules(priceListID int, rules ...PriceListRule) {
+	priceList := r.priceList(priceListID)
+	for _, rule := range rules {
+		ruleType := strings.ToUpper(rule.Type)
+		if ruleType == "" {
+			ruleType = RuleTypeProduct
+		}
+		priceList.addProductRule(ResolverRule{
+			Type:            ruleType,
+			ID:              rule.ProductID,
+			Price:           sharedCommon.DecimalFromFloat32(rule.Price).Float64(),
+			DiscountPercent: float64(rule.DiscountPercent),
+			MinAmount:       float64(rule.Amount),
+		})
+	}
+}
+
+//AddProductsInPriceList adds the product prices of a price list, Amount is the quantity from which the price applies,
+//adding a price of the same product and quantity again replaces it
+func (r *Resolver) AddProductsInPriceList(priceListID int, products ...ProductsInPriceList) {
+	priceList := r.priceList(priceListID)
+	for _, prod := range products {
+		priceList.addProductRule(ResolverRule{
+			Type:      RuleTypeProduct,
+			ID:        prod.ProductID,
+			Price:     sharedCommon.DecimalFromFloat32(prod.Price).Float64(),
+			MinAmount: float64(prod.Amount),
+		})
+	}
+}
+
+func (r *Resolver) Assign(assignments ...Assignment) {
+	r.assignments = append(r.assignments, assignments...)
+}
+
+//candidates returns the IDs of the price lists which apply to the query ordered by priority
+func (r *Resolver) candidates(q PriceQuery, res *Resolution) []int {
+	if len(q.PriceListIDs) > 0 {
+		res.trace(0, "using the requested price lists %v", q.PriceListIDs)
+		return q.PriceListIDs
+	}
+
+	matching := make([]Assignment, 0, len(r.assignments))
+	for _, assignment := range r.assignments {
+		if assignment.matches(q) {
+			matching = append(matching, assignment)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Priority > matching[j].Priority
+	})
+
+	ids := make([]int, 0, len(matching))
+	seen := map[int]bool{}
+	for _, assignment := range matching {
+		if seen[assignment.PriceListID] {
+			continue
+		}
+		seen[assignment.PriceListID] = true
+		ids = append(ids, assignment.PriceListID)
+		res.trace(assignment.PriceListID, "assigned with priority %d", assignment.Priority)
+	}
+	if len(ids) == 0 {
+		res.trace(0, "no price lists are assigned")
+	}
+
+	return ids
+}
+
+//bestRule picks the most specific rule of the price list and the biggest quantity break which the quantity reaches
+func (p *resolverPriceList) bestRule(q PriceQuery, res *Resolution) (ResolverRule, bool) {
+	var best ResolverRule
+	found := false
+	rules := make([]ResolverRule, 0, len(p.Rules)+len(p.ProductRules))
+	rules = append(rules, p.Rules...)
+	rules = append(rules, p.ProductRules...)
+	for _, rule := range rules {
+		if !rule.matches(q) {
+			continue
+		}
+		if rule.MinAmount > q.Quantity {
+			res.trace(p.ID, "rule %s skipped, quantity %s is below the break", rule, formatFloat(q.Quantity))
+			continue
+		}
+		if !found || rule.specificity() > best.specificity() ||
+			(rule.specificity() == best.specificity() && rule.MinAmount > best.MinAmount) {
+			best = rule
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+//Resolve finds the price of the query, when no price list has a rule for the product the base price is returned
+func (r *Resolver) Resolve(q PriceQuery) *Resolution {
+	res := &Resolution{
+		BasePrice: q.BasePrice,
+		Price:     q.BasePrice,
+	}
+
+	for _, priceListID := range r.candidates(q, res) {
+		priceList, ok := r.priceLists[priceListID]
+		if !ok {
+			res.trace(priceListID, "skipped, not in the cache")
+			continue
+		}
+		if reason := priceList.validity(q.Date); reason != "" {
+			res.trace(priceListID, "skipped, %s", reason)
+			continue
+		}
+
+		rule, ok := priceList.bestRule(q, res)
+		if !ok {
+			res.trace(priceListID, "no rule for the product")
+			continue
+		}
+
+		price := rule.apply(q.BasePrice)
+		if res.Rule != nil && price >= res.Price {
+			res.trace(priceListID, "rule %s gives %s, the price %s of price list %d is lower", rule, formatFloat(price), formatFloat(res.Price), res.PriceListID)
+			continue
+		}
+
+		res.trace(priceListID, "rule %s gives %s", rule, formatFloat(price))
+		res.Price = price
+		res.PriceListID = priceListID
+		res.Rule = &rule
+
+		if r.Strategy == FirstByPriority {
+			break
+		}
+	}
+
+	if res.Rule == nil {
+		res.trace(0, "using the base price %s", formatFloat(q.BasePrice))
+	} else {
+		res.trace(0, "price %s from price list %d wins", formatFloat(res.Price), res.PriceListID)
+	}
+
+	return res
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
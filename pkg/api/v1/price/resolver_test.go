@@ -0,0 +1,218 @@
+package price
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestResolver() *Resolver {
+	resolver := NewResolver()
+	resolver.AddPriceLists(
+		RegularPriceList{
+			PricelistID: 1,
+			Active:      "1",
+			Rules: []RegularPriceListRule{
+				{ID: 7, Type: "PRODGROUP", DiscountPercent: 10},
+				{ID: 3, Type: "PRODCAT", DiscountPercent: 50},
+			},
+		},
+		RegularPriceList{
+			PricelistID: 2,
+			Active:      "1",
+			Rules: []RegularPriceListRule{
+				{ID: 100, Type: "PRODUCT", Price: 9.99},
+			},
+		},
+		RegularPriceList{
+			PricelistID: 3,
+			Active:      "1",
+			ValidFrom:   "2020-01-01",
+			ValidTo:     "2020-12-31",
+			Rules: []RegularPriceListRule{
+				{ID: 100, Type: "PRODUCT", Price: 1},
+			},
+		},
+	)
+	resolver.AddProductsInPriceList(4,
+		ProductsInPriceList{ProductID: 100, Price: 15},
+		ProductsInPriceList{ProductID: 100, Price: 12, Amount: 10},
+		ProductsInPriceList{ProductID: 100, Price: 11, Amount: 50},
+	)
+	resolver.AddPriceLists(RegularPriceList{PricelistID: 4, Active: "1"})
+	resolver.Assign(
+		Assignment{PriceListID: 1, Priority: PriorityCustomer, CustomerID: 5},
+		Assignment{PriceListID: 3, Priority: PriorityCustomerGroup, CustomerGroupID: 2},
+		Assignment{PriceListID: 2, Priority: PriorityWarehouse, WarehouseID: 1},
+		Assignment{PriceListID: 4},
+	)
+
+	return resolver
+}
+
+func TestResolveByPriority(t *testing.T) {
+	resolver := newTestResolver()
+
+	res := resolver.Resolve(PriceQuery{
+		ProductID:      100,
+		ProductGroupID: 7,
+		BasePrice:      20,
+		CustomerID:     5,
+		WarehouseID:    1,
+		Quantity:       1,
+	})
+
+	assert.Equal(t, 18.0, res.Price)
+	assert.Equal(t, 1, res.PriceListID)
+	assert.Equal(t, &ResolverRule{Type: RuleTypeGroup, ID: 7, DiscountPercent: 10}, res.Rule)
+	assert.Equal(t, `price list 1: assigned with priority 30
+price list 2: assigned with priority 10
+price list 4: assigned with priority 0
+price list 1: rule PRODGROUP 7 discount 10% gives 18
+price 18 from price list 1 wins`, res.Explanation())
+}
+
+func TestResolveLowestPrice(t *testing.T) {
+	resolver := newTestResolver()
+	resolver.Strategy = LowestPrice
+
+	res := resolver.Resolve(PriceQuery{
+		ProductID:      100,
+		ProductGroupID: 7,
+		BasePrice:      20,
+		CustomerID:     5,
+		WarehouseID:    1,
+		Quantity:       1,
+	})
+
+	assert.Equal(t, 9.99, res.Price)
+	assert.Equal(t, 2, res.PriceListID)
+	assert.Equal(t, TraceStep{PriceListID: 4, Message: "rule PRODUCT 100 price 15 gives 15, the price 9.99 of price list 2 is lower"}, res.Trace[len(res.Trace)-2])
+}
+
+func TestResolveQuantityBreaks(t *testing.T) {
+	resolver := newTestResolver()
+
+	testCases := []struct {
+		quantity float64
+		expected float64
+	}{
+		{1, 15},
+		{9.5, 15},
+		{10, 12},
+		{49, 12},
+		{60, 11},
+	}
+	for _, testCase := range testCases {
+		res := resolver.Resolve(PriceQuery{ProductID: 100, BasePrice: 20, Quantity: testCase.quantity})
+		assert.Equal(t, testCase.expected, res.Price, "quantity %v", testCase.quantity)
+		assert.Equal(t, 4, res.PriceListID)
+	}
+
+	res := resolver.Resolve(PriceQuery{ProductID: 100, BasePrice: 20, Quantity: 12})
+	assert.Contains(t, res.Trace, TraceStep{PriceListID: 4, Message: "rule PRODUCT 100 from 50 units price 11 skipped, quantity 12 is below the break"})
+	assert.Equal(t, &ResolverRule{Type: RuleTypeProduct, ID: 100, Price: 12, MinAmount: 10}, res.Rule)
+}
+
+func TestResolveValidity(t *testing.T) {
+	resolver := newTestResolver()
+	resolver.AddPriceLists(RegularPriceList{PricelistID: 5, Active: "0", Rules: []RegularPriceListRule{{ID: 100, Type: "PRODUCT", Price: 2}}})
+
+	query := PriceQuery{
+		ProductID:       100,
+		BasePrice:       20,
+		CustomerGroupID: 2,
+		Quantity:        1,
+		Date:            time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
+	}
+	res := resolver.Resolve(query)
+	assert.Equal(t, 15.0, res.Price)
+	assert.Contains(t, res.Trace, TraceStep{PriceListID: 3, Message: "skipped, valid until 2020-12-31"})
+
+	query.Date = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	res = resolver.Resolve(query)
+	assert.Equal(t, 1.0, res.Price)
+	assert.Equal(t, 3, res.PriceListID)
+
+	query.Date = time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)
+	res = resolver.Resolve(query)
+	assert.Contains(t, res.Trace, TraceStep{PriceListID: 3, Message: "skipped, valid from 2020-01-01"})
+
+	query.PriceListIDs = []int{5, 6, 1}
+	query.ProductCategoryID = 3
+	res = resolver.Resolve(query)
+	assert.Equal(t, 10.0, res.Price)
+	assert.Equal(t, `using the requested price lists [5 6 1]
+price list 5: skipped, inactive
+price list 6: skipped, not in the cache
+price list 1: rule PRODCAT 3 discount 50% gives 10
+price 10 from price list 1 wins`, res.Explanation())
+}
+
+func TestResolveBasePrice(t *testing.T) {
+	resolver := NewResolver()
+
+	res := resolver.Resolve(PriceQuery{ProductID: 1, BasePrice: 3.5, Quantity: 1})
+	assert.Equal(t, 3.5, res.Price)
+	assert.Nil(t, res.Rule)
+	assert.Equal(t, "no price lists are assigned\nusing the base price 3.5", res.Explanation())
+}
+
+func TestResolveFreeItem(t *testing.T) {
+	resolver := NewResolver()
+	resolver.AddPriceLists(RegularPriceList{PricelistID: 1, Active: "1", Rules: []RegularPriceListRule{{ID: 100, Type: "PRODUCT", Price: 0}}})
+	resolver.Assign(Assignment{PriceListID: 1})
+
+	res := resolver.Resolve(PriceQuery{ProductID: 100, BasePrice: 20, Quantity: 1})
+	assert.Equal(t, 0.0, res.Price)
+	assert.Equal(t, 1, res.PriceListID)
+	assert.Contains(t, res.Trace, TraceStep{PriceListID: 1, Message: "rule PRODUCT 100 price 0 gives 0"})
+}
+
+func TestResolveProductDiscountPercent(t *testing.T) {
+	resolver := NewResolver()
+	resolver.AddPriceLists(RegularPriceList{PricelistID: 1, Active: "1", Rules: []RegularPriceListRule{{ID: 100, Type: "PRODUCT", DiscountPercent: 25}}})
+	resolver.Assign(Assignment{PriceListID: 1})
+
+	res := resolver.Resolve(PriceQuery{ProductID: 100, BasePrice: 20, Quantity: 1})
+	assert.Equal(t, 15.0, res.Price)
+	assert.Contains(t, res.Trace, TraceStep{PriceListID: 1, Message: "rule PRODUCT 100 discount 25% gives 15"})
+}
+
+func TestAddProductRulesTwice(t *testing.T) {
+	resolver := newTestResolver()
+	resolver.AddProductsInPriceList(4,
+		ProductsInPriceList{ProductID: 100, Price: 14},
+		ProductsInPriceList{ProductID: 100, Price: 12, Amount: 10},
+	)
+	resolver.AddPriceListRules(4, PriceListRule{ProductID: 100, Price: 10, Amount: 50})
+
+	assert.Len(t, resolver.priceLists[4].ProductRules, 3)
+
+	res := resolver.Resolve(PriceQuery{ProductID: 100, BasePrice: 20, Quantity: 1})
+	assert.Equal(t, 14.0, res.Price)
+
+	res = resolver.Resolve(PriceQuery{ProductID: 100, BasePrice: 20, Quantity: 60})
+	assert.Equal(t, 10.0, res.Price)
+}
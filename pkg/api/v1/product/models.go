@@ -705,3 +705,78 @@ type (
 		Status  sharedCommon.Status `json:"status"`
 	}
 )
+
+//PriceDecimal returns Price, the net price of the product card, as an exact decimal
+func (p Product) PriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.Price)
+}
+
+//PriceWithVatDecimal returns PriceWithVat as an exact decimal
+func (p Product) PriceWithVatDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.PriceWithVat)
+}
+
+//CostDecimal returns Cost as an exact decimal
+func (p Product) CostDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.Cost)
+}
+
+//FifoCostDecimal returns FifoCost as an exact decimal
+func (p Product) FifoCostDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.FifoCost)
+}
+
+//PurchasePriceDecimal returns PurchasePrice as an exact decimal
+func (p Product) PurchasePriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.PurchasePrice)
+}
+
+//AmountDecimal returns Amount, the quantity of the component, as an exact decimal
+func (c ProductComponent) AmountDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(c.Amount)
+}
+
+//FreeDecimal returns Free, the quantity available in the warehouse, as an exact decimal
+func (s StockInfo) FreeDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(s.Free)
+}
+
+//ReservedDecimal parses Reserved
+func (s StockInfo) ReservedDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(s.Reserved.String())
+}
+
+//TotalInStockDecimal parses TotalInStock
+func (s StockInfo) TotalInStockDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(s.TotalInStock.String())
+}
+
+//FifoCostDecimal returns FifoCost as an exact decimal, the float32 digits beyond its precision are dropped
+func (s StockInfo) FifoCostDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(s.FifoCost)
+}
+
+//PurchasePriceDecimal returns PurchasePrice as an exact decimal, the float32 digits beyond its precision are dropped
+func (s StockInfo) PurchasePriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat32(s.PurchasePrice)
+}
+
+//AmountInStockDecimal parses AmountInStock
+func (s GetProductStock) AmountInStockDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(s.AmountInStock.String())
+}
+
+//AmountReservedDecimal returns AmountReserved as an exact decimal
+func (s GetProductStock) AmountReservedDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(s.AmountReserved)
+}
+
+//AverageCostDecimal returns AverageCost as an exact decimal
+func (s GetProductStock) AverageCostDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(s.AverageCost)
+}
+
+//AveragePurchasePriceDecimal returns AveragePurchasePrice as an exact decimal
+func (s GetProductStock) AveragePurchasePriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(s.AveragePurchasePrice)
+}
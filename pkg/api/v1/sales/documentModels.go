@@ -22,6 +22,7 @@ package sales
 
 import (
 	"encoding/json"
+	"time"
 
 	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
 )
@@ -270,3 +271,103 @@ type (
 func (spdr SavePurchaseDocumentResponse) GetStatus() *sharedCommon.Status {
 	return &spdr.Status
 }
+
+//NetTotalDecimal returns NetTotal as an exact decimal
+func (d SaleDocument) NetTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(d.NetTotal)
+}
+
+//VatTotalDecimal returns VatTotal as an exact decimal
+func (d SaleDocument) VatTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(d.VatTotal)
+}
+
+//RoundingDecimal returns Rounding as an exact decimal
+func (d SaleDocument) RoundingDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(d.Rounding)
+}
+
+//TotalDecimal returns Total as an exact decimal
+func (d SaleDocument) TotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(d.Total)
+}
+
+//PaidDecimal parses Paid, the paid amount of the document
+func (d SaleDocument) PaidDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(d.Paid)
+}
+
+//CurrencyRateDecimal parses CurrencyRate, the rate is 0 when it's empty
+func (d SaleDocument) CurrencyRateDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(d.CurrencyRate)
+}
+
+//AmountDecimal parses Amount, the quantity of the row
+func (r InvoiceRow) AmountDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.Amount)
+}
+
+//PriceDecimal parses Price, the net unit price of the row before the discount
+func (r InvoiceRow) PriceDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.Price)
+}
+
+//DiscountDecimal parses Discount, the discount percentage of the row
+func (r InvoiceRow) DiscountDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.Discount)
+}
+
+//FinalNetPriceDecimal returns FinalNetPrice as an exact decimal
+func (r InvoiceRow) FinalNetPriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(r.FinalNetPrice)
+}
+
+//FinalPriceWithVATDecimal returns FinalPriceWithVAT as an exact decimal
+func (r InvoiceRow) FinalPriceWithVATDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(r.FinalPriceWithVAT)
+}
+
+//RowNetTotalDecimal returns RowNetTotal as an exact decimal
+func (r InvoiceRow) RowNetTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(r.RowNetTotal)
+}
+
+//RowVATDecimal returns RowVAT as an exact decimal
+func (r InvoiceRow) RowVATDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(r.RowVAT)
+}
+
+//RowTotalDecimal returns RowTotal as an exact decimal
+func (r InvoiceRow) RowTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(r.RowTotal)
+}
+
+//TotalDecimal returns Total as an exact decimal
+func (t VatTotalsByTaxRate) TotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(t.Total)
+}
+
+//AmountDecimal parses Amount, the quantity of the saved row
+func (r SaveInvoiceRow) AmountDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.Amount.String())
+}
+
+//DateTime returns the document date and time in the account timezone
+func (s SaleDocument) DateTime(tz sharedCommon.TimeZone) (time.Time, error) {
+	return tz.ParseDateTime(s.Date, s.Time)
+}
+
+//DeliveryDateTime returns the delivery date in the account timezone, the zero time if it's not set
+func (s SaleDocument) DeliveryDateTime(tz sharedCommon.TimeZone) (time.Time, error) {
+	return tz.ParseDate(s.DeliveryDate)
+}
+
+//LastModifiedTime returns the time of the last change of the document
+func (s SaleDocument) LastModifiedTime(tz sharedCommon.TimeZone) time.Time {
+	return tz.Unix(s.LastModified)
+}
+
+//AddedTime returns the creation time of the document
+func (s SaleDocument) AddedTime(tz sharedCommon.TimeZone) time.Time {
+	return tz.Unix(int64(s.Added))
+}
@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
 	"github.com/bhojpur/erp/pkg/internal/common"
@@ -346,3 +347,53 @@ func TestSaveSalesDocumentBulk(t *testing.T) {
 	assert.Len(t, bulkResp.BulkItems[1].Records, 1)
 	assert.Equal(t, json.Number("124"), bulkResp.BulkItems[1].Records[0].InvoiceID)
 }
+
+func TestSaleDocumentDecimals(t *testing.T) {
+	var doc SaleDocument
+	err := json.Unmarshal([]byte(`{
+		"total": 100.1, "netTotal": 83.42, "vatTotal": 16.68, "paid": "33.37", "currencyRate": "1",
+		"rows": [{"amount": "3.000", "price": "27.806667", "discount": "", "rowTotal": 100.1}]
+	}`), &doc)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "100.1", doc.TotalDecimal().String())
+	assert.True(t, doc.NetTotalDecimal().Add(doc.VatTotalDecimal()).Equal(doc.TotalDecimal()))
+	paid, err := doc.PaidDecimal()
+	assert.NoError(t, err)
+	assert.Equal(t, "66.73", doc.TotalDecimal().Sub(paid).String())
+
+	row := doc.InvoiceRows[0]
+	amount, err := row.AmountDecimal()
+	assert.NoError(t, err)
+	price, err := row.PriceDecimal()
+	assert.NoError(t, err)
+	discount, err := row.DiscountDecimal()
+	assert.NoError(t, err)
+	assert.True(t, discount.IsZero())
+	assert.Equal(t, "83.42", amount.Mul(price).StringFixed(2))
+	assert.Equal(t, "100.1", row.RowTotalDecimal().String())
+
+	doc.Paid = "n/a"
+	_, err = doc.PaidDecimal()
+	assert.EqualError(t, err, `invalid decimal "n/a"`)
+}
+
+func TestSaleDocumentTimes(t *testing.T) {
+	var doc SaleDocument
+	err := json.Unmarshal([]byte(`{
+		"date": "2022-06-30", "time": "23:45:00", "deliveryDate": "0000-00-00", "lastModified": 1656619200, "added": 1656613500
+	}`), &doc)
+	assert.NoError(t, err)
+
+	tz := sharedCommon.NewTimeZone(time.FixedZone("IST", 19800))
+	moment, err := doc.DateTime(tz)
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-06-30T18:15:00Z", moment.UTC().Format(time.RFC3339))
+
+	delivery, err := doc.DeliveryDateTime(tz)
+	assert.NoError(t, err)
+	assert.True(t, delivery.IsZero())
+
+	assert.Equal(t, "2022-07-01 01:30:00", doc.LastModifiedTime(tz).Format(sharedCommon.DateTimeLayout))
+	assert.Equal(t, "2022-06-30 23:55:00", doc.AddedTime(tz).Format(sharedCommon.DateTimeLayout))
+}
@@ -0,0 +1,386 @@
+package sales
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//ErrConversionNotAllowed is returned when the document can't be converted to the requested type
+var ErrConversionNotAllowed = errors.New("conversion is not allowed")
+
+//invoiceTypes are the documents which move the goods to the customer and can be credited
+var invoiceTypes = []string{SaleDocumentTypeInvoice, SaleDocumentTypeInvWayBill, SaleDocumentTypeCASHINVOICE, SaleDocumentTypeExportInvoice}
+
+//followUpTypes lists the types to which each document type can be converted
+var followUpTypes = map[string][]string{
+	SaleDocumentTypeOffer:         {SaleDocumentTypeOrder},
+	SaleDocumentTypeOrder:         invoiceTypes,
+	SaleDocumentTypeInvoice:       {SaleDocumentTypeCreditInvoice},
+	SaleDocumentTypeInvWayBill:    {SaleDocumentTypeCreditInvoice},
+	SaleDocumentTypeCASHINVOICE:   {SaleDocumentTypeCreditInvoice},
+	SaleDocumentTypeExportInvoice: {SaleDocumentTypeCreditInvoice},
+}
+
+//header fields copied from the base document to the follow-up document
+var followUpHeaderFields = []struct {
+	name  string
+	value func(doc SaleDocument) string
+}{
+	{"customerID", func(doc SaleDocument) string { return intParam(doc.CustomerID) }},
+	{"payerID", func(doc SaleDocument) string { return intParam(doc.PayerID) }},
+	{"shipToID", func(doc SaleDocument) string { return intParam(doc.ShipToID) }},
+	{"addressID", func(doc SaleDocument) string { return intParam(doc.AddressID) }},
+	{"payerAddressID", func(doc SaleDocument) string { return intParam(doc.PayerAddressID) }},
+	{"shipToAddressID", func(doc SaleDocument) string { return zeroToEmpty(doc.ShipToAddressID) }},
+	{"contactID", func(doc SaleDocument) string { return intParam(doc.ContactID) }},
+	{"employeeID", func(doc SaleDocument) string { return intParam(doc.EmployeeID) }},
+	{"warehouseID", func(doc SaleDocument) string { return intParam(doc.WarehouseID) }},
+	{"pointOfSaleID", func(doc SaleDocument) string { return intParam(doc.PointOfSaleID) }},
+	{"projectID", func(doc SaleDocument) string { return intParam(doc.ProjectID) }},
+	{"currencyCode", func(doc SaleDocument) string { return doc.CurrencyCode }},
+	{"currencyRate", func(doc SaleDocument) string { return doc.CurrencyRate }},
+	{"paymentDays", func(doc SaleDocument) string { return doc.PaymentDays }},
+	{"pricelistID", func(doc SaleDocument) string { return zeroToEmpty(doc.PricelistID) }},
+	{"notes", func(doc SaleDocument) string { return doc.Notes }},
+	{"internalNotes", func(doc SaleDocument) string { return doc.InternalNotes }},
+	{"referenceNumber", func(doc SaleDocument) string { return doc.ReferenceNumber }},
+	{"customReferenceNumber", func(doc SaleDocument) string { return doc.CustomReferenceNumber }},
+}
+
+//ConversionOptions controls how a follow-up document is created
+type ConversionOptions struct {
+	//Rows selects the rows by the stable row ID (the row ID when it's empty) and gives their quantities,
+	//all remaining quantities are used when it's empty
+	Rows map[string]float64
+	//Date is the date of the new document as YYYY-MM-DD, the API uses today when it's empty
+	Date string
+	//InvoiceState is the state of the new document e.g. READY, the API default is used when it's empty
+	InvoiceState string
+	//FollowUps are the loaded follow-up documents of the base document, when nil they are read from the API
+	//if the base document has any
+	FollowUps []SaleDocument
+}
+
+//CanConvert checks that the document type can be converted to the target type
+func CanConvert(from, to string) error {
+	for _, allowed := range followUpTypes[strings.ToUpper(from)] {
+		if allowed == strings.ToUpper(to) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s can't be converted to %s", ErrConversionNotAllowed, from, to)
+}
+
+//ConvertToOrder creates an order from all remaining rows of the offer
+func ConvertToOrder(ctx context.Context, docs DocumentManager, offer SaleDocument, opts ConversionOptions) (*SaleDocImportReport, error) {
+	return saveFollowUp(ctx, docs, offer, SaleDocumentTypeOrder, opts)
+}
+
+//InvoiceOrder invoices the order with the invoice type, e.g. INVWAYBILL, INVOICE by default,
+//the selected rows and quantities in the options allow partial invoicing
+func InvoiceOrder(ctx context.Context, docs DocumentManager, order SaleDocument, invoiceType string, opts ConversionOptions) (*SaleDocImportReport, error) {
+	if invoiceType == "" {
+		invoiceType = SaleDocumentTypeInvoice
+	}
+	return saveFollowUp(ctx, docs, order, invoiceType, opts)
+}
+
+//CreateCreditInvoice credits the invoice, the selected rows and quantities in the options allow partial returns
+func CreateCreditInvoice(ctx context.Context, docs DocumentManager, invoice SaleDocument, opts ConversionOptions) (*SaleDocImportReport, error) {
+	return saveFollowUp(ctx, docs, invoice, SaleDocumentTypeCreditInvoice, opts)
+}
+
+func saveFollowUp(ctx context.Context, docs DocumentManager, base SaleDocument, targetType string, opts ConversionOptions) (*SaleDocImportReport, error) {
+	if opts.FollowUps == nil && len(base.FollowUpDocuments) > 0 {
+		followUps, err := LoadFollowUps(ctx, docs, base)
+		if err != nil {
+			return nil, err
+		}
+		opts.FollowUps = followUps
+	}
+
+	filters, err := FollowUpFilters(base, targetType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := docs.SaveSalesDocument(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, sharedCommon.NewFromError("SaveSalesDocument: no records in response", nil, 0)
+	}
+
+	return &reports[0], nil
+}
+
+//LoadFollowUps reads the follow-up documents of the document with their rows
+func LoadFollowUps(ctx context.Context, docs DocumentManager, base SaleDocument) ([]SaleDocument, error) {
+	ids := make([]string, 0, len(base.FollowUpDocuments))
+	for _, followUp := range base.FollowUpDocuments {
+		ids = append(ids, strconv.Itoa(followUp.ID))
+	}
+
+	return docs.GetSalesDocuments(ctx, map[string]string{
+		"ids":                   strings.Join(ids, ","),
+		"getRowsForAllInvoices": "1",
+	})
+}
+
+//FollowUpFilters builds the saveSalesDocument parameters of the follow-up document without saving it
+func FollowUpFilters(base SaleDocument, targetType string, opts ConversionOptions) (map[string]string, error) {
+	targetType = strings.ToUpper(targetType)
+	if base.ID == 0 {
+		return nil, fmt.Errorf("%w: the %s is not saved", ErrConversionNotAllowed, strings.ToLower(base.Type))
+	}
+	if err := CanConvert(base.Type, targetType); err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(base.InvoiceState, "CANCELLED") {
+		return nil, fmt.Errorf("%w: %s %s is cancelled", ErrConversionNotAllowed, base.Type, base.Number)
+	}
+	if targetType == SaleDocumentTypeCreditInvoice && base.Confirmed != "1" {
+		return nil, fmt.Errorf("%w: %s %s is not confirmed", ErrConversionNotAllowed, base.Type, base.Number)
+	}
+
+	quantities, err := rowQuantities(base, targetType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := map[string]string{
+		"type":            targetType,
+		"baseDocumentIDs": strconv.Itoa(base.ID),
+	}
+	for _, field := range followUpHeaderFields {
+		if value := field.value(base); value != "" {
+			filters[field.name] = value
+		}
+	}
+	if targetType == SaleDocumentTypeCreditInvoice {
+		filters["creditToInvoiceID"] = strconv.Itoa(base.ID)
+	}
+	if opts.Date != "" {
+		filters["date"] = opts.Date
+	}
+	if opts.InvoiceState != "" {
+		filters["invoiceState"] = opts.InvoiceState
+	}
+
+	n := 0
+	for i, row := range base.InvoiceRows {
+		quantity := quantities[i]
+		if quantity <= 0 {
+			continue
+		}
+		if targetType == SaleDocumentTypeCreditInvoice {
+			quantity = -quantity
+		}
+
+		n++
+		suffix := strconv.Itoa(n)
+		setRowParam(filters, "productID"+suffix, zeroToEmpty(row.ProductID))
+		setRowParam(filters, "itemName"+suffix, row.ItemName)
+		setRowParam(filters, "vatrateID"+suffix, zeroToEmpty(row.VatrateID))
+		setRowParam(filters, "price"+suffix, row.Price)
+		setRowParam(filters, "discount"+suffix, zeroToEmpty(row.Discount))
+		filters["amount"+suffix] = strconv.FormatFloat(quantity, 'f', -1, 64)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: nothing is left to convert in %s %s", ErrConversionNotAllowed, base.Type, base.Number)
+	}
+
+	return filters, nil
+}
+
+//RemainingQuantities returns the quantities of the base document rows, keyed by the stable row ID (the row ID
+//when it's empty), which are not in the follow-up documents of the target kind yet. The follow-up rows are matched
+//to the base rows with the same product, price and discount first and then to any row of the same product
+func RemainingQuantities(base SaleDocument, followUps []SaleDocument, targetType string) (map[string]float64, error) {
+	remaining, err := remainingByRow(base, followUps, strings.ToUpper(targetType))
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[string]float64, len(remaining))
+	for i, row := range base.InvoiceRows {
+		quantities[RowKey(row)] += remaining[i]
+	}
+
+	return quantities, nil
+}
+
+func remainingByRow(base SaleDocument, followUps []SaleDocument, targetType string) ([]float64, error) {
+	remaining := make([]float64, len(base.InvoiceRows))
+	for i, row := range base.InvoiceRows {
+		amount, err := ParseRowAmount(row.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q of row %d", row.Amount, i+1)
+		}
+		remaining[i] = math.Abs(amount)
+	}
+
+	for _, followUp := range followUps {
+		if !sameKind(followUp.Type, targetType) || strings.EqualFold(followUp.InvoiceState, "CANCELLED") {
+			continue
+		}
+		for _, followUpRow := range followUp.InvoiceRows {
+			amount, err := ParseRowAmount(followUpRow.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("invalid amount %q in %s %s", followUpRow.Amount, followUp.Type, followUp.Number)
+			}
+			left := math.Abs(amount)
+
+			for _, samePrice := range []bool{true, false} {
+				for i, row := range base.InvoiceRows {
+					if left <= 0 {
+						break
+					}
+					if rowProductKey(row) != rowProductKey(followUpRow) || (samePrice && !samePriceAndDiscount(row, followUpRow)) {
+						continue
+					}
+					deducted := math.Min(remaining[i], left)
+					remaining[i] -= deducted
+					left -= deducted
+				}
+			}
+		}
+	}
+
+	return remaining, nil
+}
+
+//rowQuantities returns the quantities of the base rows which go to the follow-up document
+func rowQuantities(base SaleDocument, targetType string, opts ConversionOptions) ([]float64, error) {
+	remaining, err := remainingByRow(base, opts.FollowUps, targetType)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Rows) == 0 {
+		return remaining, nil
+	}
+
+	quantities := make([]float64, len(base.InvoiceRows))
+	for rowID, quantity := range opts.Rows {
+		index := -1
+		for i, row := range base.InvoiceRows {
+			if RowKey(row) == rowID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("%w: row %s is not in %s %s", ErrConversionNotAllowed, rowID, base.Type, base.Number)
+		}
+		if quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity of row %s must be positive", ErrConversionNotAllowed, rowID)
+		}
+		if quantity > remaining[index]+1e-9 {
+			return nil, fmt.Errorf("%w: row %s has only %s left, %s requested", ErrConversionNotAllowed, rowID,
+				strconv.FormatFloat(remaining[index], 'f', -1, 64), strconv.FormatFloat(quantity, 'f', -1, 64))
+		}
+		quantities[index] = quantity
+	}
+
+	return quantities, nil
+}
+
+//ParseRowAmount parses the quantity of a document row, the empty quantity of an untouched row is 0
+func ParseRowAmount(amount string) (float64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(amount, 64)
+}
+
+//samePriceAndDiscount compares the prices numerically, so 2.5 and 2.50 are the same price
+func samePriceAndDiscount(a, b InvoiceRow) bool {
+	return sameNumber(a.Price, b.Price) && sameNumber(a.Discount, b.Discount)
+}
+
+func sameNumber(a, b string) bool {
+	x, errX := ParseRowAmount(a)
+	y, errY := ParseRowAmount(b)
+	if errX != nil || errY != nil {
+		return a == b
+	}
+	return math.Abs(x-y) < 1e-9
+}
+
+//sameKind tells if the follow-up document uses up the quantities which the target document would use
+func sameKind(followUpType, targetType string) bool {
+	followUpType = strings.ToUpper(followUpType)
+	if targetType == SaleDocumentTypeCreditInvoice || targetType == SaleDocumentTypeOrder {
+		return followUpType == targetType
+	}
+	for _, invoiceType := range invoiceTypes {
+		if followUpType == invoiceType {
+			return true
+		}
+	}
+	return false
+}
+
+//RowKey identifies the row of a document, it's the stable row ID which survives the edits of the document
+//or the row ID when the stable one is empty
+func RowKey(row InvoiceRow) string {
+	if row.StableRowID != "" && row.StableRowID != "0" {
+		return row.StableRowID
+	}
+	return row.RowID
+}
+
+func rowProductKey(row InvoiceRow) string {
+	if zeroToEmpty(row.ProductID) != "" {
+		return "product:" + row.ProductID
+	}
+	return "item:" + row.ItemName
+}
+
+func setRowParam(filters map[string]string, key, value string) {
+	if value != "" {
+		filters[key] = value
+	}
+}
+
+func intParam(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func zeroToEmpty(value string) string {
+	if value == "0" {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,218 @@
+package sales
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOrder() SaleDocument {
+	return SaleDocument{
+		ID:           10,
+		Type:         SaleDocumentTypeOrder,
+		Number:       "O-10",
+		CustomerID:   5,
+		WarehouseID:  1,
+		CurrencyCode: "EUR",
+		PaymentDays:  "14",
+		Confirmed:    "1",
+		InvoiceRows: []InvoiceRow{
+			{StableRowID: "101", ProductID: "7", VatrateID: "1", Amount: "10", Price: "2.5", Discount: "10"},
+			{StableRowID: "102", ProductID: "8", VatrateID: "1", Amount: "3", Price: "10", Discount: "0"},
+			{StableRowID: "103", ProductID: "0", ItemName: "Delivery", VatrateID: "1", Amount: "1", Price: "5"},
+		},
+	}
+}
+
+func TestFollowUpFiltersConvertsAllRows(t *testing.T) {
+	offer := newTestOrder()
+	offer.Type = SaleDocumentTypeOffer
+
+	filters, err := FollowUpFilters(offer, SaleDocumentTypeOrder, ConversionOptions{Date: "2022-06-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"type":            "ORDER",
+		"baseDocumentIDs": "10",
+		"customerID":      "5",
+		"warehouseID":     "1",
+		"currencyCode":    "EUR",
+		"paymentDays":     "14",
+		"date":            "2022-06-01",
+		"productID1":      "7",
+		"vatrateID1":      "1",
+		"amount1":         "10",
+		"price1":          "2.5",
+		"discount1":       "10",
+		"productID2":      "8",
+		"vatrateID2":      "1",
+		"amount2":         "3",
+		"price2":          "10",
+		"itemName3":       "Delivery",
+		"vatrateID3":      "1",
+		"amount3":         "1",
+		"price3":          "5",
+	}, filters)
+}
+
+func TestFollowUpFiltersChecksConversion(t *testing.T) {
+	order := newTestOrder()
+
+	_, err := FollowUpFilters(order, SaleDocumentTypeOrder, ConversionOptions{})
+	assert.True(t, errors.Is(err, ErrConversionNotAllowed))
+	assert.EqualError(t, err, "conversion is not allowed: ORDER can't be converted to ORDER")
+
+	order.InvoiceState = "CANCELLED"
+	_, err = FollowUpFilters(order, SaleDocumentTypeInvoice, ConversionOptions{})
+	assert.EqualError(t, err, "conversion is not allowed: ORDER O-10 is cancelled")
+
+	invoice := newTestOrder()
+	invoice.Type = SaleDocumentTypeInvoice
+	invoice.Confirmed = "0"
+	_, err = FollowUpFilters(invoice, SaleDocumentTypeCreditInvoice, ConversionOptions{})
+	assert.EqualError(t, err, "conversion is not allowed: INVOICE O-10 is not confirmed")
+
+	order = newTestOrder()
+	_, err = FollowUpFilters(order, SaleDocumentTypeInvoice, ConversionOptions{Rows: map[string]float64{"999": 1}})
+	assert.EqualError(t, err, "conversion is not allowed: row 999 is not in ORDER O-10")
+
+	_, err = FollowUpFilters(order, SaleDocumentTypeInvoice, ConversionOptions{Rows: map[string]float64{"101": 11}})
+	assert.EqualError(t, err, "conversion is not allowed: row 101 has only 10 left, 11 requested")
+}
+
+func TestFollowUpFiltersPartialInvoicing(t *testing.T) {
+	order := newTestOrder()
+	followUps := []SaleDocument{
+		{Type: SaleDocumentTypeInvWayBill, Number: "I-1", InvoiceRows: []InvoiceRow{{ProductID: "7", Amount: "4"}}},
+		{Type: SaleDocumentTypeInvoice, Number: "I-2", InvoiceState: "CANCELLED", InvoiceRows: []InvoiceRow{{ProductID: "8", Amount: "3"}}},
+		{Type: SaleDocumentTypeCreditInvoice, Number: "C-1", InvoiceRows: []InvoiceRow{{ProductID: "8", Amount: "-3"}}},
+	}
+
+	filters, err := FollowUpFilters(order, SaleDocumentTypeInvWayBill, ConversionOptions{
+		Rows:      map[string]float64{"101": 6, "102": 1.5},
+		FollowUps: followUps,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "7", filters["productID1"])
+	assert.Equal(t, "6", filters["amount1"])
+	assert.Equal(t, "8", filters["productID2"])
+	assert.Equal(t, "1.5", filters["amount2"])
+	assert.NotContains(t, filters, "amount3")
+
+	_, err = FollowUpFilters(order, SaleDocumentTypeInvWayBill, ConversionOptions{
+		Rows:      map[string]float64{"101": 7},
+		FollowUps: followUps,
+	})
+	assert.EqualError(t, err, "conversion is not allowed: row 101 has only 6 left, 7 requested")
+
+	followUps = append(followUps, SaleDocument{Type: SaleDocumentTypeInvoice, InvoiceRows: []InvoiceRow{
+		{ProductID: "7", Amount: "6"},
+		{ProductID: "8", Amount: "3"},
+		{ItemName: "Delivery", Amount: "1"},
+	}})
+	_, err = FollowUpFilters(order, SaleDocumentTypeInvoice, ConversionOptions{FollowUps: followUps})
+	assert.EqualError(t, err, "conversion is not allowed: nothing is left to convert in ORDER O-10")
+}
+
+func TestCreateCreditInvoiceLoadsFollowUps(t *testing.T) {
+	invoice := newTestOrder()
+	invoice.ID = 20
+	invoice.Type = SaleDocumentTypeInvoice
+	invoice.Number = "I-20"
+	invoice.FollowUpDocuments = []BaseDocument{{ID: 30, Type: SaleDocumentTypeCreditInvoice}, {ID: 31}}
+
+	var savedFilters url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		var resp interface{}
+		switch r.Form.Get("request") {
+		case "getSalesDocuments":
+			assert.Equal(t, "30,31", r.Form.Get("ids"))
+			resp = GetSalesDocumentResponse{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				SalesDocuments: []SaleDocument{
+					{ID: 30, Type: SaleDocumentTypeCreditInvoice, InvoiceRows: []InvoiceRow{{ProductID: "7", Amount: "-8"}}},
+				},
+			}
+		case "saveSalesDocument":
+			savedFilters = r.Form
+			resp = PostSalesDocumentResponse{
+				Status:        sharedCommon.Status{ResponseStatus: "ok"},
+				ImportReports: SaleDocImportReports{{InvoiceID: "40", InvoiceNo: "C-40"}},
+			}
+		default:
+			t.Errorf("unexpected request %s", r.Form.Get("request"))
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	report, err := CreateCreditInvoice(context.Background(), NewClient(baseClient), invoice, ConversionOptions{
+		Rows: map[string]float64{"101": 2, "103": 1},
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, "C-40", report.InvoiceNo)
+	assert.Equal(t, "CREDITINVOICE", savedFilters.Get("type"))
+	assert.Equal(t, "20", savedFilters.Get("creditToInvoiceID"))
+	assert.Equal(t, "20", savedFilters.Get("baseDocumentIDs"))
+	assert.Equal(t, "-2", savedFilters.Get("amount1"))
+	assert.Equal(t, "Delivery", savedFilters.Get("itemName2"))
+	assert.Equal(t, "-1", savedFilters.Get("amount2"))
+
+	_, err = CreateCreditInvoice(context.Background(), NewClient(baseClient), invoice, ConversionOptions{
+		Rows: map[string]float64{"101": 3},
+	})
+	assert.EqualError(t, err, "conversion is not allowed: row 101 has only 2 left, 3 requested")
+}
+
+func TestRemainingQuantitiesMatchesPriceFirst(t *testing.T) {
+	invoice := newTestOrder()
+	invoice.Type = SaleDocumentTypeInvoice
+	invoice.InvoiceRows = append(invoice.InvoiceRows, InvoiceRow{StableRowID: "104", ProductID: "7", Amount: "4", Price: "0", Discount: "100"})
+
+	credits := []SaleDocument{
+		{Type: SaleDocumentTypeCreditInvoice, InvoiceRows: []InvoiceRow{{ProductID: "7", Amount: "-3", Price: "0.00", Discount: "100"}}},
+		{Type: SaleDocumentTypeCreditInvoice, InvoiceRows: []InvoiceRow{{ProductID: "7", Amount: "-2", Price: "2.50", Discount: "10"}}},
+		{Type: SaleDocumentTypeCreditInvoice, InvoiceState: "CANCELLED", InvoiceRows: []InvoiceRow{{ProductID: "8", Amount: "-3", Price: "10"}}},
+		{Type: SaleDocumentTypeCreditInvoice, InvoiceRows: []InvoiceRow{{ProductID: "7", Amount: "-3", Price: "1"}}},
+	}
+
+	remaining, err := RemainingQuantities(invoice, credits, "creditinvoice")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"101": 5, "102": 3, "103": 1, "104": 1}, remaining)
+}
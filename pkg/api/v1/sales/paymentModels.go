@@ -106,3 +106,13 @@ type (
 		BulkItems []SavePaymentsBulkItem `json:"requests"`
 	}
 )
+
+//SumDecimal parses Sum, the paid amount in the currency of the payment
+func (p PaymentInfo) SumDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(p.Sum)
+}
+
+//CurrencyRateDecimal parses CurrencyRate, the rate is 0 when it's empty
+func (p PaymentInfo) CurrencyRateDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(p.CurrencyRate)
+}
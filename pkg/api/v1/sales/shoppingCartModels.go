@@ -20,6 +20,10 @@ package sales
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
+import (
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
 type (
 	ShoppingAppliedPromotions struct {
 		Count        int `json:"count"`
@@ -58,3 +62,53 @@ type (
 		RowTotal             float64 `json:"rowTotal"`
 	}
 )
+
+//NetTotalDecimal returns NetTotal as an exact decimal
+func (t ShoppingCartTotals) NetTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(t.NetTotal)
+}
+
+//VATTotalDecimal returns VATTotal as an exact decimal
+func (t ShoppingCartTotals) VATTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(t.VATTotal)
+}
+
+//TotalDecimal returns Total as an exact decimal
+func (t ShoppingCartTotals) TotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(t.Total)
+}
+
+//AmountDecimal parses Amount, the quantity of the row
+func (p ShoppingCartProduct) AmountDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(p.Amount)
+}
+
+//DiscountDecimal returns Discount as an exact decimal
+func (p ShoppingCartProduct) DiscountDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.Discount)
+}
+
+//FinalPriceDecimal returns FinalPrice as an exact decimal
+func (p ShoppingCartProduct) FinalPriceDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.FinalPrice)
+}
+
+//FinalPriceWithVATDecimal returns FinalPriceWithVAT as an exact decimal
+func (p ShoppingCartProduct) FinalPriceWithVATDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.FinalPriceWithVAT)
+}
+
+//RowNetTotalDecimal returns RowNetTotal as an exact decimal
+func (p ShoppingCartProduct) RowNetTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.RowNetTotal)
+}
+
+//RowVATDecimal returns RowVAT as an exact decimal
+func (p ShoppingCartProduct) RowVATDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.RowVAT)
+}
+
+//RowTotalDecimal returns RowTotal as an exact decimal
+func (p ShoppingCartProduct) RowTotalDecimal() sharedCommon.Decimal {
+	return sharedCommon.DecimalFromFloat(p.RowTotal)
+}
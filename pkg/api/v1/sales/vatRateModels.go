@@ -32,6 +32,8 @@ type (
 		Code       string                      `json:"code"`
 		Active     string                      `json:"active"`
 		Attributes []sharedCommon.ObjAttribute `json:"attributes"`
+		//Components are the parts of a multi-component rate saved with saveVatRateComponent
+		Components []VatRateComponent `json:"components"`
 		//Added        string `json:"added"`
 		LastModified string `json:"lastModified"`
 		//IsReverseVat int    `json:"isReverseVat"`
@@ -40,6 +42,14 @@ type (
 
 	VatRates []VatRate
 
+	VatRateComponent struct {
+		ID        int    `json:"id"`
+		VatRateID int    `json:"vatRateID"`
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		Rate      string `json:"rate"`
+	}
+
 	NetTotalsByTaxRate struct {
 		VatrateID int     `json:"vatrateID"`
 		Total     float64 `json:"total"`
@@ -26,6 +26,10 @@ type InventoryManager interface {
 	SaveInventoryRegistration(ctx context.Context, filters map[string]string) (inventoryRegistrationID int, err error)
 	SaveInventoryRegistrationBulk(ctx context.Context, bulkRequest []map[string]interface{}, baseFilters map[string]string) (SaveInventoryRegistrationResponseBulk, error)
 	SaveInventoryWriteOff(ctx context.Context, filters map[string]string) (inventoryWriteOffID int, err error)
+	SaveInventoryWriteOffBulk(ctx context.Context, bulkRequest []map[string]interface{}, baseFilters map[string]string) (SaveInventoryWriteOffResponseBulk, error)
 	SaveInventoryTransfer(ctx context.Context, filters map[string]string) (inventoryTransferID int, err error)
+	GetInventoryRegistrations(ctx context.Context, filters map[string]string) ([]InventoryRegistration, error)
+	GetInventoryWriteOffs(ctx context.Context, filters map[string]string) ([]InventoryWriteOff, error)
+	GetInventoryTransfers(ctx context.Context, filters map[string]string) ([]InventoryTransfer, error)
 	GetReasonCodes(ctx context.Context, filters map[string]string) ([]ReasonCode, error)
 }
@@ -135,6 +135,59 @@ func (cli *Client) SaveInventoryWriteOff(ctx context.Context, filters map[string
 	return respData.Results[0].InventoryWriteOffID, nil
 }
 
+//SaveInventoryWriteOffBulk saves several inventory write-offs in one bulk request
+func (cli *Client) SaveInventoryWriteOffBulk(
+	ctx context.Context,
+	bulkRequest []map[string]interface{},
+	baseFilters map[string]string) (
+	SaveInventoryWriteOffResponseBulk,
+	error,
+) {
+	var bulkResp SaveInventoryWriteOffResponseBulk
+
+	if len(bulkRequest) > sharedCommon.MaxBulkRequestsCount {
+		return bulkResp, fmt.Errorf("cannot save more than %d inventory write-offs in one bulk request", sharedCommon.MaxBulkRequestsCount)
+	}
+
+	bulkInputs := make([]common.BulkInput, 0, len(bulkRequest))
+	for _, bulkInput := range bulkRequest {
+		bulkInputs = append(bulkInputs, common.BulkInput{
+			MethodName: "saveInventoryWriteOff",
+			Filters:    bulkInput,
+		})
+	}
+
+	resp, err := cli.SendRequestBulk(ctx, bulkInputs, baseFilters)
+	if err != nil {
+		return bulkResp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return bulkResp, err
+	}
+
+	if err := json.Unmarshal(body, &bulkResp); err != nil {
+		return bulkResp, fmt.Errorf("Bhojpur ERP API: failed to unmarshal SaveInventoryWriteOffResponseBulk from '%s': %v", string(body), err)
+	}
+
+	if !common.IsJSONResponseOK(&bulkResp.Status) {
+		return bulkResp, sharedCommon.NewErpError(bulkResp.Status.ErrorCode.String(), bulkResp.Status.Request+": "+bulkResp.Status.ResponseStatus, bulkResp.Status.ErrorCode)
+	}
+
+	for _, bulkRespItem := range bulkResp.BulkItems {
+		if !common.IsJSONResponseOK(&bulkRespItem.Status.Status) {
+			return bulkResp, sharedCommon.NewErpError(
+				bulkRespItem.Status.ErrorCode.String(),
+				fmt.Sprintf("%+v", bulkRespItem.Status),
+				bulkResp.Status.ErrorCode,
+			)
+		}
+	}
+
+	return bulkResp, nil
+}
+
 func (cli *Client) SaveInventoryTransfer(ctx context.Context, filters map[string]string) (inventoryTransferID int, err error) {
 	resp, err := cli.SendRequest(ctx, "saveInventoryTransfer", filters)
 	if err != nil {
@@ -161,6 +214,54 @@ func (cli *Client) SaveInventoryTransfer(ctx context.Context, filters map[string
 	return res.Results[0].InventoryTransferID, nil
 }
 
+//GetInventoryRegistrations reads the inventory registrations with their rows
+func (cli *Client) GetInventoryRegistrations(ctx context.Context, filters map[string]string) ([]InventoryRegistration, error) {
+	resp, err := cli.SendRequest(ctx, "getInventoryRegistrations", filters)
+	if err != nil {
+		return nil, err
+	}
+	var res GetInventoryRegistrationsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, sharedCommon.NewFromError("failed to unmarshal GetInventoryRegistrationsResponse", err, 0)
+	}
+	if !common.IsJSONResponseOK(&res.Status) {
+		return nil, sharedCommon.NewFromResponseStatus(&res.Status)
+	}
+	return res.InventoryRegistrations, nil
+}
+
+//GetInventoryWriteOffs reads the inventory write-offs with their rows
+func (cli *Client) GetInventoryWriteOffs(ctx context.Context, filters map[string]string) ([]InventoryWriteOff, error) {
+	resp, err := cli.SendRequest(ctx, "getInventoryWriteOffs", filters)
+	if err != nil {
+		return nil, err
+	}
+	var res GetInventoryWriteOffsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, sharedCommon.NewFromError("failed to unmarshal GetInventoryWriteOffsResponse", err, 0)
+	}
+	if !common.IsJSONResponseOK(&res.Status) {
+		return nil, sharedCommon.NewFromResponseStatus(&res.Status)
+	}
+	return res.InventoryWriteOffs, nil
+}
+
+//GetInventoryTransfers reads the inventory transfers with their rows
+func (cli *Client) GetInventoryTransfers(ctx context.Context, filters map[string]string) ([]InventoryTransfer, error) {
+	resp, err := cli.SendRequest(ctx, "getInventoryTransfers", filters)
+	if err != nil {
+		return nil, err
+	}
+	var res GetInventoryTransfersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, sharedCommon.NewFromError("failed to unmarshal GetInventoryTransfersResponse", err, 0)
+	}
+	if !common.IsJSONResponseOK(&res.Status) {
+		return nil, sharedCommon.NewFromResponseStatus(&res.Status)
+	}
+	return res.InventoryTransfers, nil
+}
+
 func (cli *Client) GetReasonCodes(ctx context.Context, filters map[string]string) ([]ReasonCode, error) {
 	resp, err := cli.SendRequest(ctx, "getReasonCodes", filters)
 	if err != nil {
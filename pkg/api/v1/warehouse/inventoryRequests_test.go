@@ -198,6 +198,53 @@ func TestSaveInventoryWriteOff(t *testing.T) {
 	assert.Equal(t, 999, writeOffID)
 }
 
+func TestSaveInventoryWriteOffBulk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statusBulk := sharedCommon.StatusBulk{}
+		statusBulk.ResponseStatus = "ok"
+
+		common.AssertRequestBulk(t, r, []map[string]interface{}{
+			{
+				"warehouseID": "1",
+				"reasonID":    "4",
+				"requestName": "saveInventoryWriteOff",
+			},
+			{
+				"warehouseID": "1",
+				"reasonID":    "5",
+				"requestName": "saveInventoryWriteOff",
+			},
+		})
+
+		bulkResp := SaveInventoryWriteOffResponseBulk{
+			Status: sharedCommon.Status{ResponseStatus: "ok"},
+			BulkItems: []SaveInventoryWriteOffBulkItem{
+				{Status: statusBulk, Results: []SaveInventoryWriteOffResult{{InventoryWriteOffID: 71}}},
+				{Status: statusBulk, Results: []SaveInventoryWriteOffResult{{InventoryWriteOffID: 72}}},
+			},
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(bulkResp))
+	}))
+
+	defer srv.Close()
+
+	cli := common.NewClient("somesess", "someclient", "", nil, nil)
+	cli.Url = srv.URL
+
+	bulkResp, err := NewClient(cli).SaveInventoryWriteOffBulk(context.Background(), []map[string]interface{}{
+		{"warehouseID": "1", "reasonID": "4"},
+		{"warehouseID": "1", "reasonID": "5"},
+	}, map[string]string{})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Len(t, bulkResp.BulkItems, 2)
+	assert.Equal(t, []SaveInventoryWriteOffResult{{InventoryWriteOffID: 71}}, bulkResp.BulkItems[0].Results)
+	assert.Equal(t, []SaveInventoryWriteOffResult{{InventoryWriteOffID: 72}}, bulkResp.BulkItems[1].Results)
+}
+
 func TestSaveInventoryTransfer(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		common.AssertFormValues(t, r, map[string]interface{}{
@@ -239,3 +286,80 @@ func TestSaveInventoryTransfer(t *testing.T) {
 
 	assert.Equal(t, 999, TransferID)
 }
+
+func TestGetInventoryTransfers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		common.AssertFormValues(t, r, map[string]interface{}{
+			"clientCode":           "someclient",
+			"sessionKey":           "somesess",
+			"request":              "getInventoryTransfers",
+			"inventoryTransferIDs": "12",
+		})
+
+		_, err := w.Write([]byte(`{"status":{"responseStatus":"ok"},"records":[{"inventoryTransferID":12,"warehouseFromID":1,` +
+			`"warehouseToID":2,"type":"TRANSFER","date":"2022-07-15","rows":[{"productID":5,"amount":"3","price":"10.5"}],` +
+			`"attributes":[{"attributeName":"eWayBillNo","attributeType":"text","attributeValue":"331001234567"}]}]}`))
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	cli := common.NewClient("somesess", "someclient", "", nil, nil)
+	cli.Url = srv.URL
+
+	cl := NewClient(cli)
+
+	transfers, err := cl.GetInventoryTransfers(context.Background(), map[string]string{"inventoryTransferIDs": "12"})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Len(t, transfers, 1)
+	assert.Equal(t, 12, transfers[0].InventoryTransferID)
+	assert.Equal(t, 2, transfers[0].WarehouseToID)
+	assert.Equal(t, []InventoryDocumentRow{{ProductID: 5, Amount: "3", Price: "10.5"}}, transfers[0].Rows)
+	assert.Equal(t, "331001234567", transfers[0].Attributes.Attributes[0].AttributeValue)
+}
+
+func TestGetInventoryRegistrationsAndWriteOffs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "2", r.Form.Get("pageNo"))
+
+		var err error
+		switch r.Form.Get("request") {
+		case "getInventoryRegistrations":
+			_, err = w.Write([]byte(`{"status":{"responseStatus":"ok"},"records":[{"inventoryRegistrationID":7,"warehouseID":1,` +
+				`"currencyCode":"USD","currencyRate":"0.9","date":"2022-07-01","confirmed":1,"rows":[{"productID":5,"amount":"10","price":"4.25"}]}]}`))
+		case "getInventoryWriteOffs":
+			_, err = w.Write([]byte(`{"status":{"responseStatus":"ok"},"records":[{"inventoryWriteOffID":8,"warehouseID":1,` +
+				`"date":"2022-07-02","confirmed":1,"rows":[{"productID":5,"amount":"1","price":"0"}]}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.Form.Get("request"))
+		}
+		assert.NoError(t, err)
+	}))
+
+	defer srv.Close()
+
+	cli := common.NewClient("somesess", "someclient", "", nil, nil)
+	cli.Url = srv.URL
+
+	cl := NewClient(cli)
+
+	registrations, err := cl.GetInventoryRegistrations(context.Background(), map[string]string{"pageNo": "2"})
+	assert.NoError(t, err)
+	if assert.Len(t, registrations, 1) {
+		assert.Equal(t, 7, registrations[0].InventoryRegistrationID)
+		assert.Equal(t, "0.9", registrations[0].CurrencyRate.String())
+		assert.Equal(t, []InventoryDocumentRow{{ProductID: 5, Amount: "10", Price: "4.25"}}, registrations[0].Rows)
+	}
+
+	writeOffs, err := cl.GetInventoryWriteOffs(context.Background(), map[string]string{"pageNo": "2"})
+	assert.NoError(t, err)
+	if assert.Len(t, writeOffs, 1) {
+		assert.Equal(t, 8, writeOffs[0].InventoryWriteOffID)
+		assert.Equal(t, "2022-07-02", writeOffs[0].Date)
+	}
+}
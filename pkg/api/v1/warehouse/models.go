@@ -124,6 +124,21 @@ type (
 		Results []SaveInventoryTransferResult `json:"records"`
 	}
 
+	GetInventoryRegistrationsResponse struct {
+		Status                 sharedCommon.Status     `json:"status"`
+		InventoryRegistrations []InventoryRegistration `json:"records"`
+	}
+
+	GetInventoryWriteOffsResponse struct {
+		Status             sharedCommon.Status `json:"status"`
+		InventoryWriteOffs []InventoryWriteOff `json:"records"`
+	}
+
+	GetInventoryTransfersResponse struct {
+		Status             sharedCommon.Status `json:"status"`
+		InventoryTransfers []InventoryTransfer `json:"records"`
+	}
+
 	SaveInventoryRegistrationBulkItem struct {
 		Status  sharedCommon.StatusBulk           `json:"status"`
 		Results []SaveInventoryRegistrationResult `json:"records"`
@@ -134,6 +149,84 @@ type (
 		BulkItems []SaveInventoryRegistrationBulkItem `json:"requests"`
 	}
 
+	SaveInventoryWriteOffBulkItem struct {
+		Status  sharedCommon.StatusBulk       `json:"status"`
+		Results []SaveInventoryWriteOffResult `json:"records"`
+	}
+
+	SaveInventoryWriteOffResponseBulk struct {
+		Status    sharedCommon.Status             `json:"status"`
+		BulkItems []SaveInventoryWriteOffBulkItem `json:"requests"`
+	}
+
+	//InventoryDocumentRow is a product row of an inventory registration, write-off or transfer
+	InventoryDocumentRow struct {
+		StableRowID int         `json:"stableRowID"`
+		ProductID   int         `json:"productID"`
+		Amount      json.Number `json:"amount"`
+		Price       json.Number `json:"price"`
+	}
+
+	InventoryRegistration struct {
+		InventoryRegistrationID int                    `json:"inventoryRegistrationID"`
+		CreatorID               int                    `json:"creatorID"`
+		WarehouseID             int                    `json:"warehouseID"`
+		StocktakingID           int                    `json:"stocktakingID"`
+		SupplierID              int                    `json:"supplierID"`
+		CurrencyCode            string                 `json:"currencyCode"`
+		CurrencyRate            json.Number            `json:"currencyRate"`
+		Date                    string                 `json:"date"`
+		Cause                   string                 `json:"cause"`
+		ReasonID                int                    `json:"reasonID"`
+		Notes                   string                 `json:"notes"`
+		Confirmed               int                    `json:"confirmed"`
+		Added                   int64                  `json:"added"`
+		LastModified            int64                  `json:"lastModified"`
+		Rows                    []InventoryDocumentRow `json:"rows"`
+		sharedCommon.Attributes
+	}
+
+	InventoryWriteOff struct {
+		InventoryWriteOffID int                    `json:"inventoryWriteOffID"`
+		InventoryWriteOffNo int                    `json:"inventoryWriteOffNo"`
+		CreatorID           int                    `json:"creatorID"`
+		WarehouseID         int                    `json:"warehouseID"`
+		StocktakingID       int                    `json:"stocktakingID"`
+		RecipientID         int                    `json:"recipientID"`
+		CurrencyCode        string                 `json:"currencyCode"`
+		CurrencyRate        json.Number            `json:"currencyRate"`
+		Date                string                 `json:"date"`
+		Comments            string                 `json:"comments"`
+		ReasonID            int                    `json:"reasonID"`
+		Confirmed           int                    `json:"confirmed"`
+		Added               int64                  `json:"added"`
+		LastModified        int64                  `json:"lastModified"`
+		Rows                []InventoryDocumentRow `json:"rows"`
+		sharedCommon.Attributes
+	}
+
+	InventoryTransfer struct {
+		InventoryTransferID      int                    `json:"inventoryTransferID"`
+		InventoryTransferNo      int                    `json:"inventoryTransferNo"`
+		CreatorID                int                    `json:"creatorID"`
+		WarehouseFromID          int                    `json:"warehouseFromID"`
+		WarehouseToID            int                    `json:"warehouseToID"`
+		DeliveryAddressID        int                    `json:"deliveryAddressID"`
+		CurrencyCode             string                 `json:"currencyCode"`
+		CurrencyRate             json.Number            `json:"currencyRate"`
+		Type                     string                 `json:"type"`
+		InventoryTransferOrderID int                    `json:"inventoryTransferOrderID"`
+		Date                     string                 `json:"date"`
+		ShippingDate             string                 `json:"shippingDate"`
+		ShippingDateActual       string                 `json:"shippingDateActual"`
+		Notes                    string                 `json:"notes"`
+		Confirmed                int                    `json:"confirmed"`
+		Added                    int64                  `json:"added"`
+		LastModified             int64                  `json:"lastModified"`
+		Rows                     []InventoryDocumentRow `json:"rows"`
+		sharedCommon.Attributes
+	}
+
 	ReasonCode struct {
 		ReasonID                             int    `json:"reasonID"`
 		Name                                 string `json:"name"`
@@ -148,3 +241,28 @@ type (
 		ReasonCodes []ReasonCode        `json:"records"`
 	}
 )
+
+//AmountDecimal parses Amount, the quantity of the row
+func (r InventoryDocumentRow) AmountDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.Amount.String())
+}
+
+//PriceDecimal parses Price, the unit price of the row
+func (r InventoryDocumentRow) PriceDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.Price.String())
+}
+
+//CurrencyRateDecimal parses CurrencyRate, the rate is 0 when it's empty
+func (r InventoryRegistration) CurrencyRateDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(r.CurrencyRate.String())
+}
+
+//CurrencyRateDecimal parses CurrencyRate, the rate is 0 when it's empty
+func (w InventoryWriteOff) CurrencyRateDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(w.CurrencyRate.String())
+}
+
+//CurrencyRateDecimal parses CurrencyRate, the rate is 0 when it's empty
+func (t InventoryTransfer) CurrencyRateDecimal() (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(t.CurrencyRate.String())
+}
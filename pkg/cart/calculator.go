@@ -0,0 +1,267 @@
+package cart
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//quotientPlaces is the precision of the divisions before the rounding
+const quotientPlaces = 10
+
+var (
+	hundred    = sharedCommon.DecimalFromInt(100)
+	onePercent = sharedCommon.NewDecimal(1, 2)
+)
+
+//Row is a line of the shopping cart
+type Row struct {
+	ProductID int
+	Amount    float64
+	//Price is the manually entered net price, nil means the price comes from the price lists or the product card
+	Price *float64
+	//Discount is the manually entered discount percentage
+	Discount float64
+	//VatrateID overrides the VAT rate of the product when it's not zero
+	VatrateID int
+}
+
+//Cart is the input of the calculation, it matches the parameters of calculateShoppingCart
+type Cart struct {
+	CustomerID  int
+	WarehouseID int
+	//CustomerGroupID is only used to find the price lists assigned to the group, it's not sent to the API
+	CustomerGroupID int
+	//PriceListIDs overrides the price list assignments of the catalog, the first list which has
+	//a rule for the product wins, so they should be ordered by priority
+	PriceListIDs []int
+	//Date is used to check the validity of the price lists, the zero time ignores the validity dates
+	Date time.Time
+	Rows []Row
+}
+
+//Filters converts the cart to the parameters of SalesManager.CalculateShoppingCart
+func (c Cart) Filters() map[string]string {
+	filters := map[string]string{}
+	if c.CustomerID != 0 {
+		filters["customerID"] = strconv.Itoa(c.CustomerID)
+	}
+	if c.WarehouseID != 0 {
+		filters["warehouseID"] = strconv.Itoa(c.WarehouseID)
+	}
+
+	for i, row := range c.Rows {
+		n := strconv.Itoa(i + 1)
+		filters["productID"+n] = strconv.Itoa(row.ProductID)
+		filters["amount"+n] = formatFloat(row.Amount)
+		if row.Price != nil {
+			filters["price"+n] = formatFloat(*row.Price)
+		}
+		if row.Discount != 0 {
+			filters["discount"+n] = formatFloat(row.Discount)
+		}
+		if row.VatrateID != 0 {
+			filters["vatrateID"+n] = strconv.Itoa(row.VatrateID)
+		}
+	}
+
+	return filters
+}
+
+//Calculator calculates the shopping carts from the cached catalog without calling the API
+type Calculator struct {
+	Catalog  *Catalog
+	Rounding Rounding
+}
+
+func NewCalculator(catalog *Catalog) *Calculator {
+	return &Calculator{
+		Catalog:  catalog,
+		Rounding: DefaultRounding,
+	}
+}
+
+//Calculate returns the same totals as SalesManager.CalculateShoppingCart would for the cart
+func (calc *Calculator) Calculate(cart Cart) (*sales.ShoppingCartTotals, error) {
+	totals := &sales.ShoppingCartTotals{
+		Rows:              make([]sales.ShoppingCartProduct, 0, len(cart.Rows)),
+		AppliedPromotions: []sales.ShoppingAppliedPromotions{},
+	}
+	promotionIndexes := map[int]int{}
+	var netTotal, vatTotal, total sharedCommon.Decimal
+
+	for i, row := range cart.Rows {
+		prod, ok := calc.Catalog.products[row.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("row %d: product %d is not in the catalog", i+1, row.ProductID)
+		}
+
+		vatrateID, vatRate, err := calc.vatRate(prod, row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		}
+
+		promotion, hasPromotion := calc.promotion(prod, row)
+		cartRow := calc.calculateRow(cart, prod, row, vatRate, promotion.DiscountPercent)
+		cartRow.RowNumber = i + 1
+		cartRow.VatRateID = vatrateID
+		totals.Rows = append(totals.Rows, cartRow)
+
+		netTotal = netTotal.Add(sharedCommon.DecimalFromFloat(cartRow.RowNetTotal))
+		vatTotal = vatTotal.Add(sharedCommon.DecimalFromFloat(cartRow.RowVAT))
+		total = total.Add(sharedCommon.DecimalFromFloat(cartRow.RowTotal))
+
+		if hasPromotion {
+			index, seen := promotionIndexes[promotion.PromotionID]
+			if !seen {
+				index = len(totals.AppliedPromotions)
+				promotionIndexes[promotion.PromotionID] = index
+				totals.AppliedPromotions = append(totals.AppliedPromotions, sales.ShoppingAppliedPromotions{PromotionID: promotion.PromotionID})
+			}
+			totals.AppliedPromotions[index].Count++
+		}
+	}
+
+	round := calc.Rounding.Mode.round
+	totals.NetTotal = round(netTotal, calc.Rounding.TotalDecimals).Float64()
+	totals.VATTotal = round(vatTotal, calc.Rounding.TotalDecimals).Float64()
+	totals.Total = round(total, calc.Rounding.TotalDecimals).Float64()
+
+	return totals, nil
+}
+
+func (calc *Calculator) calculateRow(cart Cart, prod product.Product, row Row, vatRate, promotionDiscount float64) sales.ShoppingCartProduct {
+	r := calc.Rounding
+	round := r.Mode.round
+
+	var price float64
+	if row.Price != nil {
+		price = *row.Price
+	} else {
+		price = calc.Catalog.priceListPrice(prod, cart, row)
+	}
+	originalPrice := round(sharedCommon.DecimalFromFloat(price), r.NetPriceDecimals)
+
+	manualDiscount := row.Discount
+	if prod.NonDiscountable != 0 {
+		manualDiscount = 0
+	}
+	promotion := sharedCommon.DecimalFromFloat(promotionDiscount)
+	manual := sharedCommon.DecimalFromFloat(manualDiscount)
+	//the promotion and the manual discounts are applied one after another
+	discount := hundred.Sub(percentOf(hundred.Sub(promotion), hundred.Sub(manual)))
+	rate := sharedCommon.DecimalFromFloat(vatRate)
+	amount := sharedCommon.DecimalFromFloat(row.Amount)
+	originalPriceWithVAT := round(percentOf(originalPrice, hundred.Add(rate)), r.PriceDecimals)
+
+	cartRow := sales.ShoppingCartProduct{
+		ProductID:            strconv.Itoa(prod.ProductID),
+		Amount:               formatFloat(row.Amount),
+		VatRate:              formatFloat(vatRate),
+		OriginalPrice:        originalPrice.Float64(),
+		OriginalPriceWithVAT: originalPriceWithVAT.Float64(),
+		PromotionDiscount:    round(promotion, r.TotalDecimals).Float64(),
+		ManualDiscount:       round(manual, r.TotalDecimals).Float64(),
+		Discount:             round(discount, r.TotalDecimals).Float64(),
+	}
+
+	var finalPrice, finalPriceWithVAT, rowNetTotal, rowVAT, rowTotal sharedCommon.Decimal
+	if r.NetBased {
+		finalPrice = round(percentOf(originalPrice, hundred.Sub(discount)), r.NetPriceDecimals)
+		finalPriceWithVAT = round(percentOf(finalPrice, hundred.Add(rate)), r.PriceDecimals)
+		rowNetTotal = round(finalPrice.Mul(amount), r.TotalDecimals)
+		rowVAT = round(percentOf(rowNetTotal, rate), r.TotalDecimals)
+		rowTotal = rowNetTotal.Add(rowVAT)
+	} else {
+		finalPriceWithVAT = round(percentOf(originalPriceWithVAT, hundred.Sub(discount)), r.PriceDecimals)
+		finalPrice = round(quotient(finalPriceWithVAT.Mul(hundred), hundred.Add(rate)), r.NetPriceDecimals)
+		rowTotal = round(finalPriceWithVAT.Mul(amount), r.TotalDecimals)
+		rowVAT = round(quotient(rowTotal.Mul(rate), hundred.Add(rate)), r.TotalDecimals)
+		rowNetTotal = rowTotal.Sub(rowVAT)
+	}
+	cartRow.FinalPrice = finalPrice.Float64()
+	cartRow.FinalPriceWithVAT = finalPriceWithVAT.Float64()
+	cartRow.RowNetTotal = rowNetTotal.Float64()
+	cartRow.RowVAT = rowVAT.Float64()
+	cartRow.RowTotal = rowTotal.Float64()
+
+	return cartRow
+}
+
+//vatRate takes the rate from the cached VAT rates and falls back to the rate on the product card
+func (calc *Calculator) vatRate(prod product.Product, row Row) (int, float64, error) {
+	vatrateID := row.VatrateID
+	if vatrateID == 0 {
+		vatrateID = int(prod.VatrateID)
+	}
+
+	if rate, ok := calc.Catalog.vatRates[vatrateID]; ok {
+		return vatrateID, rate, nil
+	}
+	if vatrateID == int(prod.VatrateID) && prod.VatrateID != 0 {
+		return vatrateID, prod.Vatrate, nil
+	}
+
+	return 0, 0, fmt.Errorf("VAT rate %d of product %d is not in the catalog", vatrateID, prod.ProductID)
+}
+
+//promotion picks the applicable promotion with the biggest discount
+func (calc *Calculator) promotion(prod product.Product, row Row) (Promotion, bool) {
+	var best Promotion
+	found := false
+	if prod.NonDiscountable != 0 {
+		return best, false
+	}
+
+	for _, promotion := range calc.Catalog.promotions {
+		if !promotion.applies(prod, row.Amount) {
+			continue
+		}
+		if !found || promotion.DiscountPercent > best.DiscountPercent {
+			best = promotion
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+//percentOf returns the percent of the value, it's exact unlike the division by 100
+func percentOf(value, percent sharedCommon.Decimal) sharedCommon.Decimal {
+	return value.Mul(percent).Mul(onePercent)
+}
+
+//quotient divides with the precision which is kept until the result is rounded to the decimals of the rounding,
+//the divisor is never zero because it's 100 plus the VAT rate
+func quotient(value, divisor sharedCommon.Decimal) sharedCommon.Decimal {
+	result, _ := value.Div(divisor, quotientPlaces)
+	return result
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,273 @@
+package cart
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCatalog(t *testing.T) *Catalog {
+	catalog := NewCatalog()
+	catalog.AddProducts(
+		product.Product{ProductID: 1, Price: 10, VatrateID: 1, GroupID: 5},
+		product.Product{ProductID: 2, Price: 4.1667, VatrateID: 1, GroupID: 7},
+		product.Product{ProductID: 3, Price: 8, VatrateID: 2, GroupID: 5},
+		product.Product{ProductID: 4, Price: 10, VatrateID: 1, GroupID: 7, NonDiscountable: 1},
+		product.Product{ProductID: 5, Price: 10, VatrateID: 3, Vatrate: 5, GroupID: 5},
+	)
+	err := catalog.AddVatRates(
+		sales.VatRate{ID: "1", Rate: "20"},
+		sales.VatRate{ID: "2", Rate: "9"},
+	)
+	assert.NoError(t, err)
+	catalog.AddPriceLists(
+		price.RegularPriceList{
+			PricelistID: 10,
+			Active:      "1",
+			Rules: []price.RegularPriceListRule{
+				{ID: 5, Type: "PRODGROUP", DiscountPercent: 50},
+				{ID: 3, Type: "PRODUCT", Price: 7.5},
+			},
+		},
+		price.RegularPriceList{
+			PricelistID: 11,
+			Active:      "1",
+			ValidFrom:   "2020-01-01",
+			ValidTo:     "2020-12-31",
+			Rules: []price.RegularPriceListRule{
+				{ID: 1, Type: "PRODUCT", Price: 1},
+			},
+		},
+	)
+	catalog.AddPromotions(Promotion{PromotionID: 100, GroupIDs: []int{7}, DiscountPercent: 15})
+
+	return catalog
+}
+
+func TestCalculateFromPricesWithVAT(t *testing.T) {
+	calc := NewCalculator(newTestCatalog(t))
+
+	totals, err := calc.Calculate(Cart{
+		PriceListIDs: []int{11, 10},
+		Date:         time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		Rows: []Row{
+			{ProductID: 1, Amount: 3, Discount: 10, VatrateID: 1},
+			{ProductID: 2, Amount: 2},
+			{ProductID: 3, Amount: 1},
+		},
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Len(t, totals.Rows, 3)
+	//the product rule wins over the group rule and the expired price list is skipped
+	assert.Equal(t, sales.ShoppingCartProduct{
+		RowNumber:            1,
+		ProductID:            "1",
+		Amount:               "3",
+		VatRateID:            1,
+		VatRate:              "20",
+		OriginalPrice:        5,
+		OriginalPriceWithVAT: 6,
+		ManualDiscount:       10,
+		Discount:             10,
+		FinalPrice:           4.5,
+		FinalPriceWithVAT:    5.4,
+		RowNetTotal:          13.5,
+		RowVAT:               2.7,
+		RowTotal:             16.2,
+	}, totals.Rows[0])
+	assert.Equal(t, sales.ShoppingCartProduct{
+		RowNumber:            2,
+		ProductID:            "2",
+		Amount:               "2",
+		VatRateID:            1,
+		VatRate:              "20",
+		OriginalPrice:        4.1667,
+		OriginalPriceWithVAT: 5,
+		PromotionDiscount:    15,
+		Discount:             15,
+		FinalPrice:           3.5417,
+		FinalPriceWithVAT:    4.25,
+		RowNetTotal:          7.08,
+		RowVAT:               1.42,
+		RowTotal:             8.5,
+	}, totals.Rows[1])
+	assert.Equal(t, sales.ShoppingCartProduct{
+		RowNumber:            3,
+		ProductID:            "3",
+		Amount:               "1",
+		VatRateID:            2,
+		VatRate:              "9",
+		OriginalPrice:        7.5,
+		OriginalPriceWithVAT: 8.18,
+		FinalPrice:           7.5046,
+		FinalPriceWithVAT:    8.18,
+		RowNetTotal:          7.5,
+		RowVAT:               0.68,
+		RowTotal:             8.18,
+	}, totals.Rows[2])
+
+	assert.Equal(t, 28.08, totals.NetTotal)
+	assert.Equal(t, 4.8, totals.VATTotal)
+	assert.Equal(t, 32.88, totals.Total)
+	assert.Equal(t, []sales.ShoppingAppliedPromotions{{PromotionID: 100, Count: 1}}, totals.AppliedPromotions)
+}
+
+func TestCalculateFromNetPrices(t *testing.T) {
+	calc := NewCalculator(newTestCatalog(t))
+	calc.Rounding.NetBased = true
+
+	manualPrice := 3.333
+	totals, err := calc.Calculate(Cart{
+		Rows: []Row{
+			{ProductID: 1, Amount: 3, Price: &manualPrice, Discount: 10},
+		},
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	row := totals.Rows[0]
+	assert.Equal(t, 3.333, row.OriginalPrice)
+	assert.Equal(t, 4.0, row.OriginalPriceWithVAT)
+	assert.Equal(t, 2.9997, row.FinalPrice)
+	assert.Equal(t, 3.6, row.FinalPriceWithVAT)
+	assert.Equal(t, 9.0, row.RowNetTotal)
+	assert.Equal(t, 1.8, row.RowVAT)
+	assert.Equal(t, 10.8, row.RowTotal)
+}
+
+func TestCalculateNonDiscountableProduct(t *testing.T) {
+	calc := NewCalculator(newTestCatalog(t))
+
+	totals, err := calc.Calculate(Cart{
+		Rows: []Row{{ProductID: 4, Amount: 1, Discount: 50}},
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	row := totals.Rows[0]
+	assert.Equal(t, 0.0, row.PromotionDiscount)
+	assert.Equal(t, 0.0, row.ManualDiscount)
+	assert.Equal(t, 12.0, row.RowTotal)
+	assert.Len(t, totals.AppliedPromotions, 0)
+}
+
+func TestCalculateVatRateFromProduct(t *testing.T) {
+	calc := NewCalculator(newTestCatalog(t))
+
+	totals, err := calc.Calculate(Cart{Rows: []Row{{ProductID: 5, Amount: 2}}})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, "5", totals.Rows[0].VatRate)
+	assert.Equal(t, 21.0, totals.Total)
+
+	_, err = calc.Calculate(Cart{Rows: []Row{{ProductID: 5, Amount: 2, VatrateID: 9}}})
+	assert.EqualError(t, err, "row 1: VAT rate 9 of product 5 is not in the catalog")
+
+	_, err = calc.Calculate(Cart{Rows: []Row{{ProductID: 6, Amount: 2}}})
+	assert.EqualError(t, err, "row 1: product 6 is not in the catalog")
+}
+
+func TestCalculateWithAssignedPriceLists(t *testing.T) {
+	catalog := newTestCatalog(t)
+	catalog.Prices.AddProductsInPriceList(12, price.ProductsInPriceList{ProductID: 1, Price: 9}, price.ProductsInPriceList{ProductID: 1, Price: 8, Amount: 10})
+	catalog.Prices.AddPriceLists(price.RegularPriceList{PricelistID: 12, Active: "1"})
+	catalog.Prices.Assign(price.Assignment{PriceListID: 12, Priority: price.PriorityCustomerGroup, CustomerGroupID: 3})
+	calc := NewCalculator(catalog)
+
+	totals, err := calc.Calculate(Cart{
+		CustomerGroupID: 3,
+		Rows: []Row{
+			{ProductID: 1, Amount: 1},
+			{ProductID: 1, Amount: 10},
+		},
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, 9.0, totals.Rows[0].OriginalPrice)
+	assert.Equal(t, 8.0, totals.Rows[1].OriginalPrice)
+	assert.Equal(t, 106.8, totals.Total)
+}
+
+func TestCartFilters(t *testing.T) {
+	manualPrice := 9.99
+	cart := Cart{
+		CustomerID:  7,
+		WarehouseID: 2,
+		Rows: []Row{
+			{ProductID: 1, Amount: 1.5},
+			{ProductID: 2, Amount: 2, Price: &manualPrice, Discount: 5, VatrateID: 3},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"customerID":  "7",
+		"warehouseID": "2",
+		"productID1":  "1",
+		"amount1":     "1.5",
+		"productID2":  "2",
+		"amount2":     "2",
+		"price2":      "9.99",
+		"discount2":   "5",
+		"vatrateID2":  "3",
+	}, cart.Filters())
+}
+
+func TestRound(t *testing.T) {
+	testCases := []struct {
+		mode     RoundingMode
+		value    float64
+		decimals int
+		expected float64
+	}{
+		{RoundHalfAwayFromZero, 1.005, 2, 1.01},
+		{RoundHalfAwayFromZero, -1.005, 2, -1.01},
+		{RoundHalfAwayFromZero, 0.125, 2, 0.13},
+		{RoundHalfAwayFromZero, 2.675, 2, 2.68},
+		{RoundHalfAwayFromZero, 1.23449, 4, 1.2345},
+		{RoundHalfEven, 0.125, 2, 0.12},
+		{RoundHalfEven, 0.135, 2, 0.14},
+		{RoundHalfEven, -0.125, 2, -0.12},
+		{RoundHalfEven, 0.1251, 2, 0.13},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.mode.Round(testCase.value, testCase.decimals), "%v %d", testCase.value, testCase.decimals)
+	}
+}
@@ -0,0 +1,127 @@
+package cart
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//Promotion is a percentage discount applied on top of the price list price, it mirrors the simple campaigns
+//which give a discount for products or product groups when at least MinAmount is bought
+type Promotion struct {
+	PromotionID     int
+	ProductIDs      []int
+	GroupIDs        []int
+	DiscountPercent float64
+	MinAmount       float64
+}
+
+func (p Promotion) applies(prod product.Product, amount float64) bool {
+	if amount < p.MinAmount {
+		return false
+	}
+	if len(p.ProductIDs) == 0 && len(p.GroupIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == prod.ProductID {
+			return true
+		}
+	}
+	for _, id := range p.GroupIDs {
+		if uint(id) == prod.GroupID {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Catalog is the cached data needed to calculate carts offline, it's not safe for concurrent changes
+type Catalog struct {
+	//Prices resolves the price list prices, the price lists and their assignments can be added to it directly
+	Prices     *price.Resolver
+	products   map[int]product.Product
+	vatRates   map[int]float64
+	promotions []Promotion
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{
+		Prices:   price.NewResolver(),
+		products: map[int]product.Product{},
+		vatRates: map[int]float64{},
+	}
+}
+
+func (c *Catalog) AddProducts(products ...product.Product) {
+	for _, prod := range products {
+		c.products[prod.ProductID] = prod
+	}
+}
+
+func (c *Catalog) AddVatRates(vatRates ...sales.VatRate) error {
+	for _, vatRate := range vatRates {
+		id, err := strconv.Atoi(vatRate.ID)
+		if err != nil {
+			return fmt.Errorf("invalid VAT rate ID %q: %v", vatRate.ID, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(vatRate.Rate), 64)
+		if err != nil {
+			return fmt.Errorf("invalid rate %q of VAT rate %d: %v", vatRate.Rate, id, err)
+		}
+		c.vatRates[id] = rate
+	}
+
+	return nil
+}
+
+func (c *Catalog) AddPriceLists(priceLists ...price.RegularPriceList) {
+	c.Prices.AddPriceLists(priceLists...)
+}
+
+func (c *Catalog) AddPromotions(promotions ...Promotion) {
+	c.promotions = append(c.promotions, promotions...)
+}
+
+//priceListPrice asks the resolver for the price of the cart row, the resolver falls back to the product card price
+func (c *Catalog) priceListPrice(prod product.Product, cart Cart, row Row) float64 {
+	resolution := c.Prices.Resolve(price.PriceQuery{
+		ProductID:         prod.ProductID,
+		ProductGroupID:    int(prod.GroupID),
+		ProductCategoryID: int(prod.CategoryId),
+		BasePrice:         prod.Price,
+		CustomerID:        cart.CustomerID,
+		CustomerGroupID:   cart.CustomerGroupID,
+		WarehouseID:       cart.WarehouseID,
+		Quantity:          row.Amount,
+		Date:              cart.Date,
+		PriceListIDs:      cart.PriceListIDs,
+	})
+
+	return resolution.Price
+}
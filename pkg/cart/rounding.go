@@ -0,0 +1,76 @@
+package cart
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"math"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//RoundingMode selects how the halves are rounded
+type RoundingMode int
+
+const (
+	//RoundHalfAwayFromZero rounds 0.125 to 0.13 and -0.125 to -0.13, it's what the API uses
+	RoundHalfAwayFromZero RoundingMode = iota
+	//RoundHalfEven rounds 0.125 to 0.12 and 0.135 to 0.14
+	RoundHalfEven
+)
+
+//Rounding describes the precision of the calculated values
+type Rounding struct {
+	Mode RoundingMode
+	//NetPriceDecimals is used for the unit prices without VAT
+	NetPriceDecimals int
+	//PriceDecimals is used for the unit prices with VAT
+	PriceDecimals int
+	//TotalDecimals is used for the row and document totals and for the discount percentages
+	TotalDecimals int
+	//NetBased calculates the rows from the net prices, the VAT is added to the row net total.
+	//By default the rows are calculated from the prices with VAT, as the point of sale does,
+	//and the VAT is extracted from the row total
+	NetBased bool
+}
+
+//DefaultRounding mirrors the default account settings of calculateShoppingCart
+var DefaultRounding = Rounding{
+	Mode:             RoundHalfAwayFromZero,
+	NetPriceDecimals: 4,
+	PriceDecimals:    2,
+	TotalDecimals:    2,
+}
+
+//Round rounds the value to the given number of decimals, the value is treated as the shortest
+//decimal which represents the float, so 1.005 is rounded to 1.01 and not to 1.00
+func (m RoundingMode) Round(value float64, decimals int) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	return m.round(sharedCommon.DecimalFromFloat(value), decimals).Float64()
+}
+
+func (m RoundingMode) round(value sharedCommon.Decimal, decimals int) sharedCommon.Decimal {
+	if m == RoundHalfEven {
+		return value.RoundHalfEven(int32(decimals))
+	}
+	return value.Round(int32(decimals))
+}
@@ -0,0 +1,151 @@
+package cart
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//DefaultTolerance allows the differences which come from the float representation of the server response
+const DefaultTolerance = 0.000001
+
+//ServerCalculator is the part of SalesManager which calculates the carts on the server
+type ServerCalculator interface {
+	CalculateShoppingCart(ctx context.Context, filters map[string]string) (*sales.ShoppingCartTotals, error)
+}
+
+//Mismatch is a value which differs between the local and the server calculation,
+//RowNumber is zero for the document totals
+type Mismatch struct {
+	RowNumber int
+	Field     string
+	Local     string
+	Server    string
+}
+
+func (m Mismatch) String() string {
+	if m.RowNumber == 0 {
+		return fmt.Sprintf("%s: local %s, server %s", m.Field, m.Local, m.Server)
+	}
+	return fmt.Sprintf("row %d %s: local %s, server %s", m.RowNumber, m.Field, m.Local, m.Server)
+}
+
+//Verification is the result of comparing the local calculation with the server
+type Verification struct {
+	Local      *sales.ShoppingCartTotals
+	Server     *sales.ShoppingCartTotals
+	Mismatches []Mismatch
+}
+
+func (v *Verification) OK() bool {
+	return len(v.Mismatches) == 0
+}
+
+//Verify calculates the cart locally and on the server and reports the values which differ by more than the tolerance
+func (calc *Calculator) Verify(ctx context.Context, server ServerCalculator, cart Cart, tolerance float64) (*Verification, error) {
+	local, err := calc.Calculate(cart)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := server.CalculateShoppingCart(ctx, cart.Filters())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verification{
+		Local:      local,
+		Server:     remote,
+		Mismatches: Compare(local, remote, tolerance),
+	}, nil
+}
+
+//Compare lists the differences between two calculations of the same cart, the rows are matched by the row number
+func Compare(local, server *sales.ShoppingCartTotals, tolerance float64) []Mismatch {
+	var mismatches []Mismatch
+	compareNumber := func(rowNumber int, field string, localValue, serverValue float64) {
+		if math.Abs(localValue-serverValue) > tolerance {
+			mismatches = append(mismatches, Mismatch{
+				RowNumber: rowNumber,
+				Field:     field,
+				Local:     formatFloat(localValue),
+				Server:    formatFloat(serverValue),
+			})
+		}
+	}
+	compareText := func(rowNumber int, field string, localValue, serverValue string) {
+		localNumber, localErr := strconv.ParseFloat(localValue, 64)
+		serverNumber, serverErr := strconv.ParseFloat(serverValue, 64)
+		if localErr == nil && serverErr == nil {
+			compareNumber(rowNumber, field, localNumber, serverNumber)
+			return
+		}
+		if localValue != serverValue {
+			mismatches = append(mismatches, Mismatch{RowNumber: rowNumber, Field: field, Local: localValue, Server: serverValue})
+		}
+	}
+
+	serverRows := make(map[int]sales.ShoppingCartProduct, len(server.Rows))
+	for _, row := range server.Rows {
+		serverRows[row.RowNumber] = row
+	}
+
+	for _, l := range local.Rows {
+		s, ok := serverRows[l.RowNumber]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{RowNumber: l.RowNumber, Field: "row", Local: l.ProductID, Server: "missing"})
+			continue
+		}
+		delete(serverRows, l.RowNumber)
+
+		compareText(l.RowNumber, "productID", l.ProductID, s.ProductID)
+		compareText(l.RowNumber, "amount", l.Amount, s.Amount)
+		compareNumber(l.RowNumber, "vatrateID", float64(l.VatRateID), float64(s.VatRateID))
+		compareText(l.RowNumber, "vatRate", l.VatRate, s.VatRate)
+		compareNumber(l.RowNumber, "originalPrice", l.OriginalPrice, s.OriginalPrice)
+		compareNumber(l.RowNumber, "originalPriceWithVAT", l.OriginalPriceWithVAT, s.OriginalPriceWithVAT)
+		compareNumber(l.RowNumber, "promotionDiscount", l.PromotionDiscount, s.PromotionDiscount)
+		compareNumber(l.RowNumber, "manualDiscount", l.ManualDiscount, s.ManualDiscount)
+		compareNumber(l.RowNumber, "discount", l.Discount, s.Discount)
+		compareNumber(l.RowNumber, "finalPrice", l.FinalPrice, s.FinalPrice)
+		compareNumber(l.RowNumber, "finalPriceWithVAT", l.FinalPriceWithVAT, s.FinalPriceWithVAT)
+		compareNumber(l.RowNumber, "rowNetTotal", l.RowNetTotal, s.RowNetTotal)
+		compareNumber(l.RowNumber, "rowVAT", l.RowVAT, s.RowVAT)
+		compareNumber(l.RowNumber, "rowTotal", l.RowTotal, s.RowTotal)
+	}
+
+	for _, s := range server.Rows {
+		if _, ok := serverRows[s.RowNumber]; ok {
+			mismatches = append(mismatches, Mismatch{RowNumber: s.RowNumber, Field: "row", Local: "missing", Server: s.ProductID})
+		}
+	}
+
+	compareNumber(0, "netTotal", local.NetTotal, server.NetTotal)
+	compareNumber(0, "vatTotal", local.VATTotal, server.VATTotal)
+	compareNumber(0, "total", local.Total, server.Total)
+
+	return mismatches
+}
@@ -0,0 +1,103 @@
+package cart
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	calc := NewCalculator(newTestCatalog(t))
+	cart := Cart{
+		CustomerID: 7,
+		Rows: []Row{
+			{ProductID: 1, Amount: 3, Discount: 10},
+			{ProductID: 4, Amount: 1},
+		},
+	}
+	local, err := calc.Calculate(cart)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	serverTotals := *local
+	serverTotals.Rows = append([]sales.ShoppingCartProduct{}, local.Rows...)
+	serverTotals.Rows[0].RowVAT += 0.01
+	serverTotals.Rows[0].Amount = "3.000"
+	serverTotals.Rows[1].RowTotal += 0.0000001
+	serverTotals.Total += 0.01
+
+	var filters url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		filters = r.Form
+		resp := struct {
+			Status  sharedCommon.Status
+			Records []sales.ShoppingCartTotals
+		}{
+			Status:  sharedCommon.Status{ResponseStatus: "ok"},
+			Records: []sales.ShoppingCartTotals{serverTotals},
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	verification, err := calc.Verify(context.Background(), sales.NewClient(baseClient), cart, DefaultTolerance)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, "calculateShoppingCart", filters.Get("request"))
+	assert.Equal(t, "7", filters.Get("customerID"))
+	assert.Equal(t, "1", filters.Get("productID1"))
+	assert.Equal(t, "10", filters.Get("discount1"))
+	assert.False(t, verification.OK())
+	assert.Equal(t, []Mismatch{
+		{RowNumber: 1, Field: "rowVAT", Local: "5.4", Server: "5.41"},
+		{RowNumber: 0, Field: "total", Local: "44.4", Server: "44.41"},
+	}, verification.Mismatches)
+	assert.Equal(t, "row 1 rowVAT: local 5.4, server 5.41", verification.Mismatches[0].String())
+}
+
+func TestCompareMissingRows(t *testing.T) {
+	local := &sales.ShoppingCartTotals{Rows: []sales.ShoppingCartProduct{{RowNumber: 1, ProductID: "1"}}}
+	server := &sales.ShoppingCartTotals{Rows: []sales.ShoppingCartProduct{{RowNumber: 2, ProductID: "2"}}}
+
+	assert.Equal(t, []Mismatch{
+		{RowNumber: 1, Field: "row", Local: "1", Server: "missing"},
+		{RowNumber: 2, Field: "row", Local: "missing", Server: "2"},
+	}, Compare(local, server, DefaultTolerance))
+}
@@ -0,0 +1,181 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//Document is the totals of a sales document in its own currency and in the base currency
+type Document struct {
+	DocumentID int
+	Type       string
+	Number     string
+	Date       time.Time
+	Currency   string
+	Rate       float64
+
+	NetTotal float64
+	VatTotal float64
+	Total    float64
+
+	BaseNetTotal sharedCommon.Decimal
+	BaseVatTotal sharedCommon.Decimal
+	BaseTotal    sharedCommon.Decimal
+}
+
+//Difference is the realised exchange gain or loss of a payment of a document in a foreign currency,
+//the difference is positive for a gain
+type Difference struct {
+	DocumentID int
+	Number     string
+	PaymentID  int
+	Date       time.Time
+	Currency   string
+	//Amount is the payment in the document currency
+	Amount       sharedCommon.Decimal
+	DocumentRate float64
+	PaymentRate  float64
+	Difference   sharedCommon.Decimal
+}
+
+//DocumentRate returns the rate of the document currency on the document date, the rate of the document is used
+//when the table has no rate of the currency
+func (t *Table) DocumentRate(doc sales.SaleDocument) (float64, error) {
+	date, err := sharedCommon.UTC.ParseDate(doc.Date)
+	if err != nil || date.IsZero() {
+		return 0, fmt.Errorf("invalid date %q of %s %s", doc.Date, doc.Type, doc.Number)
+	}
+	return t.RecordRate(doc.CurrencyCode, date, doc.CurrencyRate)
+}
+
+//Document converts the totals of the sales document into the base currency at the rate of the document date
+func (t *Table) Document(doc sales.SaleDocument) (Document, error) {
+	rate, err := t.DocumentRate(doc)
+	if err != nil {
+		return Document{}, fmt.Errorf("%s %s: %w", doc.Type, doc.Number, err)
+	}
+	date, _ := sharedCommon.UTC.ParseDate(doc.Date)
+
+	return Document{
+		DocumentID:   doc.ID,
+		Type:         doc.Type,
+		Number:       doc.Number,
+		Date:         date,
+		Currency:     t.currency(doc.CurrencyCode),
+		Rate:         rate,
+		NetTotal:     doc.NetTotal,
+		VatTotal:     doc.VatTotal,
+		Total:        doc.Total,
+		BaseNetTotal: convert(sharedCommon.DecimalFromFloat(doc.NetTotal), rate),
+		BaseVatTotal: convert(sharedCommon.DecimalFromFloat(doc.VatTotal), rate),
+		BaseTotal:    convert(sharedCommon.DecimalFromFloat(doc.Total), rate),
+	}, nil
+}
+
+//PaymentRate returns the rate of the payment currency on the payment date, the rate of the payment is used
+//when the table has no rate of the currency
+func (t *Table) PaymentRate(payment sales.PaymentInfo, currency string) (float64, error) {
+	date, err := sharedCommon.UTC.ParseDate(payment.Date)
+	if err != nil || date.IsZero() {
+		return 0, fmt.Errorf("invalid date %q of payment %d", payment.Date, payment.PaymentID)
+	}
+	if payment.CurrencyCode != "" {
+		currency = payment.CurrencyCode
+	}
+	return t.RecordRate(currency, date, payment.CurrencyRate)
+}
+
+//Differences returns the realised exchange differences of the payments of the document, the payments of other
+//documents or in other currencies and the documents in the base currency have no differences
+func (t *Table) Differences(doc sales.SaleDocument, payments []sales.PaymentInfo) ([]Difference, error) {
+	currency := t.currency(doc.CurrencyCode)
+	differences := []Difference{}
+	if currency == t.Base {
+		return differences, nil
+	}
+	documentRate, err := t.DocumentRate(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", doc.Type, doc.Number, err)
+	}
+
+	for _, payment := range payments {
+		if payment.DocumentID != doc.ID || (payment.CurrencyCode != "" && normalizeCode(payment.CurrencyCode) != currency) {
+			continue
+		}
+		amount, err := sharedCommon.ParseDecimal(payment.Sum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sum %q of payment %d", payment.Sum, payment.PaymentID)
+		}
+		paymentRate, err := t.PaymentRate(payment, currency)
+		if err != nil {
+			return nil, fmt.Errorf("payment %d: %w", payment.PaymentID, err)
+		}
+		difference := convert(amount, paymentRate).Sub(convert(amount, documentRate))
+		if difference.IsZero() {
+			continue
+		}
+
+		date, _ := sharedCommon.UTC.ParseDate(payment.Date)
+		differences = append(differences, Difference{
+			DocumentID:   doc.ID,
+			Number:       doc.Number,
+			PaymentID:    payment.PaymentID,
+			Date:         date,
+			Currency:     currency,
+			Amount:       amount,
+			DocumentRate: documentRate,
+			PaymentRate:  paymentRate,
+			Difference:   difference,
+		})
+	}
+	sort.SliceStable(differences, func(i, j int) bool {
+		return differences[i].Date.Before(differences[j].Date)
+	})
+
+	return differences, nil
+}
+
+//RecordRate returns the rate of the table or the rate of an API record, e.g. the currency rate of a purchase document,
+//when the table has no rate of the currency
+func (t *Table) RecordRate(code string, date time.Time, recordRate string) (float64, error) {
+	rate, err := t.Rate(code, date)
+	if err == nil || !errors.Is(err, ErrNoRate) || recordRate == "" {
+		return rate, err
+	}
+	if value, parseErr := ParseRate(recordRate); parseErr == nil {
+		return value, nil
+	}
+	return rate, err
+}
+
+func (t *Table) currency(code string) string {
+	if code == "" {
+		return t.Base
+	}
+	return normalizeCode(code)
+}
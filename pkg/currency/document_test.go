@@ -0,0 +1,161 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTable(t *testing.T) *Table {
+	table := NewTable("INR")
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), 80))
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 20, 0, 0, 0, 0, time.UTC), 82))
+	return table
+}
+
+func TestDocument(t *testing.T) {
+	table := testTable(t)
+
+	testCases := []struct {
+		name     string
+		doc      sales.SaleDocument
+		currency string
+		rate     float64
+		base     []string
+		err      string
+	}{
+		{
+			name: "table rate of the document date",
+			doc: sales.SaleDocument{
+				ID: 1, Type: "INVOICE", Number: "101", Date: "2022-07-15", CurrencyCode: "usd", CurrencyRate: "79.5",
+				NetTotal: 847.46, VatTotal: 152.54, Total: 1000,
+			},
+			currency: "USD",
+			rate:     80,
+			base:     []string{"67796.80", "12203.20", "80000.00"},
+		},
+		{
+			name:     "base currency",
+			doc:      sales.SaleDocument{ID: 2, Number: "102", Date: "2022-07-15", NetTotal: 400, VatTotal: 100, Total: 500},
+			currency: "INR",
+			rate:     1,
+			base:     []string{"400.00", "100.00", "500.00"},
+		},
+		{
+			name:     "document rate when the table has no rate of the currency",
+			doc:      sales.SaleDocument{ID: 3, Number: "103", Date: "2022-07-15", CurrencyCode: "GBP", CurrencyRate: "95.25", Total: 10},
+			currency: "GBP",
+			rate:     95.25,
+			base:     []string{"0.00", "0.00", "952.50"},
+		},
+		{
+			name: "no rate",
+			doc:  sales.SaleDocument{ID: 4, Type: "INVOICE", Number: "104", Date: "2022-06-15", CurrencyCode: "USD"},
+			err:  "INVOICE 104: no exchange rate of USD on 2022-06-15",
+		},
+	}
+	for _, testCase := range testCases {
+		doc, err := table.Document(testCase.doc)
+		if testCase.err != "" {
+			assert.True(t, errors.Is(err, ErrNoRate), testCase.name)
+			assert.EqualError(t, err, testCase.err, testCase.name)
+			continue
+		}
+		assert.NoError(t, err, testCase.name)
+		assert.Equal(t, testCase.doc.ID, doc.DocumentID, testCase.name)
+		assert.Equal(t, testCase.currency, doc.Currency, testCase.name)
+		assert.Equal(t, testCase.rate, doc.Rate, testCase.name)
+		assert.Equal(t, testCase.base, []string{doc.BaseNetTotal.StringFixed(2), doc.BaseVatTotal.StringFixed(2), doc.BaseTotal.StringFixed(2)}, testCase.name)
+	}
+}
+
+func TestDifferences(t *testing.T) {
+	table := testTable(t)
+	doc := sales.SaleDocument{ID: 1, Type: "INVOICE", Number: "101", Date: "2022-07-15", CurrencyCode: "USD", Total: 1000}
+	payments := []sales.PaymentInfo{
+		{PaymentID: 11, DocumentID: 1, Date: "2022-07-25", Sum: "400"},
+		{PaymentID: 12, DocumentID: 1, Date: "2022-07-16", Sum: "500", CurrencyCode: "USD"},
+		{PaymentID: 13, DocumentID: 1, Date: "2022-07-21", Sum: "100", CurrencyCode: "USD", CurrencyRate: "81"},
+		{PaymentID: 14, DocumentID: 2, Date: "2022-07-25", Sum: "100"},
+		{PaymentID: 15, DocumentID: 1, Date: "2022-07-25", Sum: "1000", CurrencyCode: "INR"},
+	}
+
+	type difference struct {
+		paymentID  int
+		date       time.Time
+		amount     string
+		difference string
+	}
+	testCases := []struct {
+		name     string
+		doc      sales.SaleDocument
+		payments []sales.PaymentInfo
+		expected []difference
+		err      string
+	}{
+		{
+			name:     "payments of the document in its currency ordered by the date",
+			doc:      doc,
+			payments: payments,
+			expected: []difference{
+				{13, time.Date(2022, 7, 21, 0, 0, 0, 0, time.UTC), "100.00", "200.00"},
+				{11, time.Date(2022, 7, 25, 0, 0, 0, 0, time.UTC), "400.00", "800.00"},
+			},
+		},
+		{
+			name:     "refund of a credit invoice at a lower rate is a gain",
+			doc:      sales.SaleDocument{ID: 5, Type: "CREDITINVOICE", Number: "105", Date: "2022-07-20", CurrencyCode: "GBP", CurrencyRate: "98", Total: -50},
+			payments: []sales.PaymentInfo{{PaymentID: 16, DocumentID: 5, Date: "2022-07-28", Sum: "-50", CurrencyRate: "96.5"}},
+			expected: []difference{{16, time.Date(2022, 7, 28, 0, 0, 0, 0, time.UTC), "-50.00", "75.00"}},
+		},
+		{
+			name:     "document in the base currency",
+			doc:      sales.SaleDocument{ID: 6, Date: "2022-07-15"},
+			payments: payments,
+			expected: []difference{},
+		},
+		{
+			name:     "invalid sum",
+			doc:      doc,
+			payments: []sales.PaymentInfo{{PaymentID: 17, DocumentID: 1, Date: "2022-07-25", Sum: "x"}},
+			err:      `invalid sum "x" of payment 17`,
+		},
+	}
+	for _, testCase := range testCases {
+		differences, err := table.Differences(testCase.doc, testCase.payments)
+		if testCase.err != "" {
+			assert.EqualError(t, err, testCase.err, testCase.name)
+			continue
+		}
+		assert.NoError(t, err, testCase.name)
+		actual := make([]difference, len(differences))
+		for i, d := range differences {
+			assert.Equal(t, testCase.doc.Number, d.Number, testCase.name)
+			actual[i] = difference{d.PaymentID, d.Date, d.Amount.StringFixed(2), d.Difference.StringFixed(2)}
+		}
+		assert.Equal(t, testCase.expected, actual, testCase.name)
+	}
+}
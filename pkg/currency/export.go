@@ -0,0 +1,63 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//WriteDifferencesCSV writes a row per realised exchange difference followed by the total gain or loss
+func WriteDifferencesCSV(w io.Writer, base string, differences []Difference) error {
+	out := csv.NewWriter(w)
+	header := []string{"date", "document_id", "number", "payment_id", "currency", "amount", "document_rate", "payment_rate", "difference_" + base}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	total := sharedCommon.Decimal{}
+	for _, difference := range differences {
+		total = total.Add(difference.Difference)
+		record := []string{
+			difference.Date.Format(sharedCommon.DateLayout),
+			strconv.Itoa(difference.DocumentID),
+			difference.Number,
+			strconv.Itoa(difference.PaymentID),
+			difference.Currency,
+			difference.Amount.StringFixed(moneyPlaces),
+			strconv.FormatFloat(difference.DocumentRate, 'f', -1, 64),
+			strconv.FormatFloat(difference.PaymentRate, 'f', -1, 64),
+			difference.Difference.StringFixed(moneyPlaces),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := out.Write([]string{"TOTAL", "", "", "", "", "", "", "", total.StringFixed(moneyPlaces)}); err != nil {
+		return err
+	}
+	out.Flush()
+
+	return out.Error()
+}
@@ -0,0 +1,96 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//documentsPerRequest is the number of the documents read by one request
+const documentsPerRequest = 100
+
+//LoadDifferences reads the payments of the period between the dates inclusive with their documents and returns
+//the realised exchange differences of the payments ordered by the date
+func (t *Table) LoadDifferences(ctx context.Context, salesAPI sales.Manager, settings sharedCommon.ListingSettings, from, to time.Time) ([]Difference, error) {
+	payments := map[int][]sales.PaymentInfo{}
+	lister := sharedCommon.NewLister(settings, sales.NewPaymentsListingDataProvider(salesAPI), nil)
+	filters := map[string]interface{}{}
+	sharedCommon.TimeFilters{DateFrom: t.TimeZone.Day(from), DateTo: t.TimeZone.Day(to)}.ApplyToListing(t.TimeZone, filters)
+	for item := range lister.Get(ctx, filters) {
+		if item.Err != nil {
+			return nil, item.Err
+		}
+		payment := item.Payload.(sales.PaymentInfo)
+		if payment.DocumentID == 0 {
+			continue
+		}
+		payments[payment.DocumentID] = append(payments[payment.DocumentID], payment)
+	}
+
+	ids := make([]int, 0, len(payments))
+	for id := range payments {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	differences := []Difference{}
+	for start := 0; start < len(ids); start += documentsPerRequest {
+		end := start + documentsPerRequest
+		if end > len(ids) {
+			end = len(ids)
+		}
+		chunk := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			chunk = append(chunk, strconv.Itoa(id))
+		}
+
+		docs, err := salesAPI.GetSalesDocuments(ctx, map[string]string{
+			"ids":           strings.Join(chunk, ","),
+			"recordsOnPage": strconv.Itoa(documentsPerRequest),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			docDifferences, err := t.Differences(doc, payments[doc.ID])
+			if err != nil {
+				return nil, err
+			}
+			differences = append(differences, docDifferences...)
+		}
+	}
+
+	sort.SliceStable(differences, func(i, j int) bool {
+		if !differences[i].Date.Equal(differences[j].Date) {
+			return differences[i].Date.Before(differences[j].Date)
+		}
+		return differences[i].PaymentID < differences[j].PaymentID
+	})
+
+	return differences, nil
+}
@@ -0,0 +1,97 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDifferences(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		var resp interface{}
+		if r.Form.Get("request") == "getSalesDocuments" {
+			assert.Equal(t, "1,2", r.Form.Get("ids"))
+			resp = sales.GetSalesDocumentResponse{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				SalesDocuments: []sales.SaleDocument{
+					{ID: 1, Type: "INVOICE", Number: "101", Date: "2022-07-15", CurrencyCode: "USD", Total: 1000},
+					{ID: 2, Type: "INVOICE", Number: "102", Date: "2022-07-15", Total: 1000},
+				},
+			}
+		} else {
+			request := parsedRequest["requests"].([]map[string]interface{})[0]
+			assert.Equal(t, "getPayments", request["requestName"])
+			assert.Equal(t, "2022-07-01", fmt.Sprint(request["dateFrom"]))
+			assert.Equal(t, "2022-07-31", fmt.Sprint(request["dateTo"]))
+			status := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok"}}
+			status.RecordsTotal = 4
+			resp = sales.GetPaymentsResponseBulk{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				BulkItems: []sales.GetPaymentsBulkItem{{
+					Status: status,
+					PaymentInfos: []sales.PaymentInfo{
+						{PaymentID: 11, DocumentID: 1, Date: "2022-07-25", Sum: "400"},
+						{PaymentID: 12, DocumentID: 2, Date: "2022-07-25", Sum: "1000"},
+						{PaymentID: 13, DocumentID: 1, Date: "2022-07-21", Sum: "100"},
+						{PaymentID: 14, Date: "2022-07-21", Sum: "100"},
+					},
+				}},
+			}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	//the period dates are midnight UTC, they must not move to the previous day west of UTC
+	table := testTable(t)
+	table.TimeZone = sharedCommon.NewTimeZone(time.FixedZone("UTC-5", -5*60*60))
+	differences, err := table.LoadDifferences(context.Background(), sales.NewClient(baseClient), sharedCommon.ListingSettings{}, time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 7, 31, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, differences, 2)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteDifferencesCSV(buf, "INR", differences))
+	assert.Equal(t, "date,document_id,number,payment_id,currency,amount,document_rate,payment_rate,difference_INR\n"+
+		"2022-07-21,1,101,13,USD,100.00,80,82,200.00\n"+
+		"2022-07-25,1,101,11,USD,400.00,80,82,800.00\n"+
+		"TOTAL,,,,,,,,1000.00\n", buf.String())
+}
@@ -0,0 +1,207 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+var (
+	//ErrNoRate is returned when the table has no rate of the currency on or before the date
+	ErrNoRate = errors.New("no exchange rate")
+	//ErrInvalidRate is returned for the rates which are not positive numbers
+	ErrInvalidRate = errors.New("invalid exchange rate")
+)
+
+//moneyPlaces is the precision of the converted amounts
+const moneyPlaces = 2
+
+//Rate is the value of a unit of the currency in the base currency from the date on
+type Rate struct {
+	Currency string
+	Date     time.Time
+	Rate     float64
+}
+
+//Table stores the dated exchange rates of the currencies against the base currency
+type Table struct {
+	Base string
+	//TimeZone is the account timezone of the date filters of the loaded payments, UTC when it's not set
+	TimeZone sharedCommon.TimeZone
+	rates    map[string][]Rate
+}
+
+//NewTable creates an empty table of the rates against the base currency
+func NewTable(base string) *Table {
+	return &Table{
+		Base:  normalizeCode(base),
+		rates: map[string][]Rate{},
+	}
+}
+
+//Add stores the rate of the currency from the date on, the rate of the same date is replaced
+func (t *Table) Add(code string, date time.Time, rate float64) error {
+	code = normalizeCode(code)
+	if code == "" {
+		return errors.New("currency code is required")
+	}
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return fmt.Errorf("%w %v of %s", ErrInvalidRate, rate, code)
+	}
+	if code == t.Base {
+		if rate != 1 {
+			return fmt.Errorf("%w %v of the base currency %s", ErrInvalidRate, rate, code)
+		}
+		return nil
+	}
+
+	date = sharedCommon.UTC.Day(date)
+	rates := t.rates[code]
+	i := sort.Search(len(rates), func(i int) bool {
+		return !rates[i].Date.Before(date)
+	})
+	if i < len(rates) && rates[i].Date.Equal(date) {
+		rates[i].Rate = rate
+		return nil
+	}
+	rates = append(rates, Rate{})
+	copy(rates[i+1:], rates[i:])
+	rates[i] = Rate{Currency: code, Date: date, Rate: rate}
+	t.rates[code] = rates
+
+	return nil
+}
+
+//AddString stores a rate which arrives as a string, e.g. the currency rate of the API records
+func (t *Table) AddString(code string, date time.Time, rate string) error {
+	value, err := ParseRate(rate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", normalizeCode(code), err)
+	}
+	return t.Add(code, date, value)
+}
+
+//Rate returns the latest rate of the currency on or before the date, the rate of the base currency is 1
+func (t *Table) Rate(code string, date time.Time) (float64, error) {
+	code = normalizeCode(code)
+	if code == "" || code == t.Base {
+		return 1, nil
+	}
+
+	date = sharedCommon.UTC.Day(date)
+	rates := t.rates[code]
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date.After(date)
+	})
+	if i == 0 {
+		return 0, fmt.Errorf("%w of %s on %s", ErrNoRate, code, date.Format(sharedCommon.DateLayout))
+	}
+
+	return rates[i-1].Rate, nil
+}
+
+//Rates returns the stored rates of the currency ordered by the date
+func (t *Table) Rates(code string) []Rate {
+	rates := t.rates[normalizeCode(code)]
+	res := make([]Rate, len(rates))
+	copy(res, rates)
+	return res
+}
+
+//Currencies returns the codes of the currencies with rates in alphabetical order
+func (t *Table) Currencies() []string {
+	codes := make([]string, 0, len(t.rates))
+	for code := range t.rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+//ToBase converts the amount of the currency into the base currency at the rate of the date
+func (t *Table) ToBase(amount sharedCommon.Decimal, code string, date time.Time) (sharedCommon.Decimal, error) {
+	rate, err := t.Rate(code, date)
+	if err != nil {
+		return sharedCommon.Decimal{}, err
+	}
+	return convert(amount, rate), nil
+}
+
+//Convert converts the amount between two currencies through their rates of the date
+func (t *Table) Convert(amount sharedCommon.Decimal, from, to string, date time.Time) (sharedCommon.Decimal, error) {
+	fromRate, err := t.Rate(from, date)
+	if err != nil {
+		return sharedCommon.Decimal{}, err
+	}
+	toRate, err := t.Rate(to, date)
+	if err != nil {
+		return sharedCommon.Decimal{}, err
+	}
+	return amount.Mul(sharedCommon.DecimalFromFloat(fromRate)).Div(sharedCommon.DecimalFromFloat(toRate), moneyPlaces)
+}
+
+//ParseRate parses a rate string of the API or a file, a decimal comma is accepted
+func ParseRate(value string) (float64, error) {
+	value = strings.Replace(strings.TrimSpace(value), ",", ".", 1)
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("%w %q", ErrInvalidRate, value)
+	}
+	return rate, nil
+}
+
+//convert multiplies the amount by the rate and rounds the result to cents
+func convert(amount sharedCommon.Decimal, rate float64) sharedCommon.Decimal {
+	return amount.Mul(sharedCommon.DecimalFromFloat(rate)).Round(moneyPlaces)
+}
+
+//Presentation is the currency the reports show the amounts of the documents in
+type Presentation string
+
+const (
+	//DocumentCurrency shows the amounts in the currency of each document
+	DocumentCurrency Presentation = "document"
+	//BaseCurrency converts the amounts into the base currency
+	BaseCurrency Presentation = "base"
+)
+
+//ParsePresentation parses the name of the presentation currency, document if empty
+func ParsePresentation(value string) (Presentation, error) {
+	switch Presentation(strings.ToLower(strings.TrimSpace(value))) {
+	case "", DocumentCurrency:
+		return DocumentCurrency, nil
+	case BaseCurrency:
+		return BaseCurrency, nil
+	}
+	return "", fmt.Errorf("unknown currency presentation %q, expected base or document", value)
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
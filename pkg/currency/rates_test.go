@@ -0,0 +1,208 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	api "github.com/bhojpur/erp/pkg/api/v1"
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableRate(t *testing.T) {
+	table := NewTable("eur")
+	assert.NoError(t, table.Add("usd", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 0.99))
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), 0.95))
+	assert.NoError(t, table.AddString("USD", time.Date(2022, 7, 10, 0, 0, 0, 0, time.UTC), " 0,97 "))
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 0.995))
+	assert.NoError(t, table.Add("EUR", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 1))
+
+	testCases := []struct {
+		currency string
+		date     time.Time
+		expected float64
+		err      string
+	}{
+		{currency: "USD", date: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), expected: 0.95},
+		{currency: "USD", date: time.Date(2022, 7, 9, 0, 0, 0, 0, time.UTC), expected: 0.95},
+		{currency: "USD", date: time.Date(2022, 7, 10, 0, 0, 0, 0, time.UTC), expected: 0.97},
+		{currency: "USD", date: time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), expected: 0.995},
+		{currency: "USD", date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), expected: 0.995},
+		{currency: "EUR", date: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), expected: 1},
+		{currency: "USD", date: time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC), err: "no exchange rate of USD on 2022-06-30"},
+		{currency: "GBP", date: time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), err: "no exchange rate of GBP on 2022-07-15"},
+	}
+	for _, testCase := range testCases {
+		rate, err := table.Rate(testCase.currency, testCase.date)
+		if testCase.err != "" {
+			assert.True(t, errors.Is(err, ErrNoRate), testCase.err)
+			assert.EqualError(t, err, testCase.err)
+			continue
+		}
+		assert.NoError(t, err, "%s on %s", testCase.currency, testCase.date)
+		assert.Equal(t, testCase.expected, rate, "%s on %s", testCase.currency, testCase.date)
+	}
+
+	assert.Equal(t, []string{"USD"}, table.Currencies())
+	assert.Len(t, table.Rates("usd"), 3)
+
+	assert.True(t, errors.Is(table.Add("USD", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 0), ErrInvalidRate))
+	assert.True(t, errors.Is(table.Add("EUR", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 1.1), ErrInvalidRate))
+	assert.EqualError(t, table.AddString("gbp", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), "n/a"), `GBP: invalid exchange rate "n/a"`)
+}
+
+func TestTableConversion(t *testing.T) {
+	table := NewTable("EUR")
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), 0.95))
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 10, 0, 0, 0, 0, time.UTC), 0.97))
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 0.995))
+	assert.NoError(t, table.Add("GBP", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), 1.17))
+	assert.NoError(t, table.Add("JPY", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), 0.5))
+
+	testCases := []struct {
+		name     string
+		amount   string
+		from     string
+		to       string
+		date     time.Time
+		expected string
+	}{
+		{name: "to base", amount: "1234.56", from: "USD", to: "EUR", date: time.Date(2022, 7, 12, 0, 0, 0, 0, time.UTC), expected: "1197.52"},
+		{name: "cross rate", amount: "100", from: "GBP", to: "USD", date: time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), expected: "117.59"},
+		{name: "half cent away from zero", amount: "-2.01", from: "JPY", to: "EUR", date: time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), expected: "-1.01"},
+	}
+	for _, testCase := range testCases {
+		amount := sharedCommon.MustParseDecimal(testCase.amount)
+		var converted sharedCommon.Decimal
+		var err error
+		if testCase.to == table.Base {
+			converted, err = table.ToBase(amount, testCase.from, testCase.date)
+		} else {
+			converted, err = table.Convert(amount, testCase.from, testCase.to, testCase.date)
+		}
+		assert.NoError(t, err, testCase.name)
+		assert.Equal(t, testCase.expected, converted.String(), testCase.name)
+	}
+}
+
+func TestReadECB(t *testing.T) {
+	testCases := []struct {
+		base     string
+		currency string
+		date     time.Time
+		expected float64
+	}{
+		{"EUR", "USD", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 1 / 1.0043},
+		{"EUR", "USD", time.Date(2022, 7, 14, 0, 0, 0, 0, time.UTC), 1 / 1.0005},
+		{"EUR", "INR", time.Date(2022, 7, 13, 0, 0, 0, 0, time.UTC), 1 / 79.88},
+		{"INR", "EUR", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 80.136},
+		{"INR", "USD", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 80.136 / 1.0043},
+		{"INR", "GBP", time.Date(2022, 7, 13, 0, 0, 0, 0, time.UTC), 79.88 / 0.8467},
+	}
+	for _, testCase := range testCases {
+		file, err := os.Open("testdata/eurofxref-hist.xml")
+		assert.NoError(t, err)
+		table := NewTable(testCase.base)
+		assert.NoError(t, table.ReadECB(file))
+		file.Close()
+
+		rate, err := table.Rate(testCase.currency, testCase.date)
+		assert.NoError(t, err)
+		assert.InDelta(t, testCase.expected, rate, 1e-12, "%s in %s", testCase.currency, testCase.base)
+	}
+
+	file, err := os.Open("testdata/eurofxref-hist.xml")
+	assert.NoError(t, err)
+	defer file.Close()
+	err = NewTable("SEK").ReadECB(file)
+	assert.True(t, errors.Is(err, ErrNoRate))
+	assert.EqualError(t, err, "no exchange rate of the base currency SEK on 2022-07-15 in the ECB rates")
+
+	err = NewTable("EUR").ReadECB(bytes.NewBufferString("<Envelope></Envelope>"))
+	assert.EqualError(t, err, "failed to read the ECB rates: no rates found")
+}
+
+func TestTableCSV(t *testing.T) {
+	table := NewTable("INR")
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 79.79))
+	assert.NoError(t, table.Add("USD", time.Date(2022, 7, 13, 0, 0, 0, 0, time.UTC), 79.84))
+	assert.NoError(t, table.Add("EUR", time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), 80.136))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, table.WriteCSV(buf))
+	assert.Equal(t, "currency,date,rate\nEUR,2022-07-15,80.136\nUSD,2022-07-13,79.84\nUSD,2022-07-15,79.79\n", buf.String())
+
+	restored := NewTable("INR")
+	assert.NoError(t, restored.ReadCSV(buf))
+	assert.Equal(t, table, restored)
+
+	err := restored.ReadCSV(bytes.NewBufferString("USD,15.07.2022,79.79\n"))
+	assert.EqualError(t, err, `line 1: invalid date "15.07.2022"`)
+}
+
+func TestLoadCurrencies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "getCurrencies", r.Form.Get("request"))
+		assert.NoError(t, json.NewEncoder(w).Encode(api.GetCurrenciesResponse{
+			Status: sharedCommon.Status{ResponseStatus: "ok"},
+			Currencies: []api.Currency{
+				{CurrencyID: "1", Code: "INR", Default: "1", Rate: "1"},
+				{CurrencyID: "2", Code: "USD", Default: "0", Rate: "79.79"},
+				{CurrencyID: "3", Code: "EUR", Default: "0", Rate: "80,136"},
+				{CurrencyID: "4", Code: "JPY", Default: "0"},
+			},
+		}))
+	}))
+	defer srv.Close()
+
+	cli, err := api.NewClientWithURL("somesess", "someclient", "", srv.URL, nil, nil)
+	assert.NoError(t, err)
+
+	table := NewTable("INR")
+	assert.NoError(t, table.Load(context.Background(), cli, time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []string{"EUR", "USD"}, table.Currencies())
+	rate, err := table.Rate("EUR", time.Date(2022, 7, 16, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 80.136, rate)
+
+	err = NewTable("EUR").Load(context.Background(), cli, time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC))
+	assert.EqualError(t, err, "default currency INR of the account is not the base currency EUR")
+}
+
+func TestParsePresentation(t *testing.T) {
+	for value, expected := range map[string]Presentation{"": DocumentCurrency, "Document": DocumentCurrency, "BASE": BaseCurrency} {
+		presentation, err := ParsePresentation(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, presentation)
+	}
+	_, err := ParsePresentation("local")
+	assert.EqualError(t, err, `unknown currency presentation "local", expected base or document`)
+}
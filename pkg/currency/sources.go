@@ -0,0 +1,170 @@
+package currency
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	api "github.com/bhojpur/erp/pkg/api/v1"
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//ECBBase is the currency the reference rates of the European Central Bank are quoted against
+const ECBBase = "EUR"
+
+//Lister reads the currencies of the account, it's implemented by the API client
+type Lister interface {
+	GetCurrencies(ctx context.Context, filters map[string]string) ([]api.Currency, error)
+}
+
+//ImportCurrencies stores the current rates of the account currencies as the rates of the date,
+//the default currency of the account has to be the base currency of the table
+func (t *Table) ImportCurrencies(currencies []api.Currency, date time.Time) error {
+	for _, currency := range currencies {
+		code := normalizeCode(currency.Code)
+		if currency.Default == "1" {
+			if code != t.Base {
+				return fmt.Errorf("default currency %s of the account is not the base currency %s", code, t.Base)
+			}
+			continue
+		}
+		if currency.Rate == "" {
+			continue
+		}
+		if err := t.AddString(code, date, currency.Rate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Load imports the currencies of the account as the rates of the date
+func (t *Table) Load(ctx context.Context, lister Lister, date time.Time) error {
+	currencies, err := lister.GetCurrencies(ctx, map[string]string{})
+	if err != nil {
+		return err
+	}
+	return t.ImportCurrencies(currencies, date)
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+//ReadECB imports the euro foreign exchange reference rates XML of the European Central Bank, both the daily file and
+//the history files are supported. The rates are quoted as the foreign units per euro and are converted into the values
+//in the base currency, which has to be the euro or one of the quoted currencies
+func (t *Table) ReadECB(r io.Reader) error {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to read the ECB rates: %v", err)
+	}
+	if len(envelope.Days) == 0 {
+		return fmt.Errorf("failed to read the ECB rates: no rates found")
+	}
+
+	for _, day := range envelope.Days {
+		date, err := sharedCommon.UTC.ParseDate(day.Time)
+		if err != nil || date.IsZero() {
+			return fmt.Errorf("invalid ECB rate date %q", day.Time)
+		}
+
+		perEuro := map[string]float64{ECBBase: 1}
+		for _, rate := range day.Rates {
+			value, err := ParseRate(rate.Rate)
+			if err != nil {
+				return fmt.Errorf("%s on %s: %w", rate.Currency, day.Time, err)
+			}
+			perEuro[normalizeCode(rate.Currency)] = value
+		}
+		base, ok := perEuro[t.Base]
+		if !ok {
+			return fmt.Errorf("%w of the base currency %s on %s in the ECB rates", ErrNoRate, t.Base, day.Time)
+		}
+		for code, value := range perEuro {
+			if code == t.Base {
+				continue
+			}
+			if err := t.Add(code, date, base/value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//WriteCSV writes all stored rates as currency, date and rate rows to keep the table between the runs
+func (t *Table) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"currency", "date", "rate"}); err != nil {
+		return err
+	}
+	for _, code := range t.Currencies() {
+		for _, rate := range t.rates[code] {
+			record := []string{code, rate.Date.Format(sharedCommon.DateLayout), strconv.FormatFloat(rate.Rate, 'f', -1, 64)}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//ReadCSV imports the rates written by WriteCSV
+func (t *Table) ReadCSV(r io.Reader) error {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = 3
+	records, err := in.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read the rates: %v", err)
+	}
+
+	for i, record := range records {
+		if i == 0 && record[0] == "currency" {
+			continue
+		}
+		date, err := sharedCommon.UTC.ParseDate(record[1])
+		if err != nil || date.IsZero() {
+			return fmt.Errorf("line %d: invalid date %q", i+1, record[1])
+		}
+		if err := t.AddString(record[0], date, record[2]); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
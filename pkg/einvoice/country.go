@@ -0,0 +1,92 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "strings"
+
+//countryCodes maps the English country names used in the customer and company records to ISO 3166-1 alpha-2 codes
+var countryCodes = map[string]string{
+	"australia":            "AU",
+	"austria":              "AT",
+	"belgium":              "BE",
+	"bulgaria":             "BG",
+	"canada":               "CA",
+	"china":                "CN",
+	"croatia":              "HR",
+	"cyprus":               "CY",
+	"czech republic":       "CZ",
+	"czechia":              "CZ",
+	"denmark":              "DK",
+	"estonia":              "EE",
+	"finland":              "FI",
+	"france":               "FR",
+	"germany":              "DE",
+	"great britain":        "GB",
+	"greece":               "GR",
+	"hungary":              "HU",
+	"iceland":              "IS",
+	"india":                "IN",
+	"ireland":              "IE",
+	"italy":                "IT",
+	"japan":                "JP",
+	"latvia":               "LV",
+	"liechtenstein":        "LI",
+	"lithuania":            "LT",
+	"luxembourg":           "LU",
+	"malta":                "MT",
+	"netherlands":          "NL",
+	"new zealand":          "NZ",
+	"norway":               "NO",
+	"poland":               "PL",
+	"portugal":             "PT",
+	"romania":              "RO",
+	"singapore":            "SG",
+	"slovakia":             "SK",
+	"slovenia":             "SI",
+	"spain":                "ES",
+	"sweden":               "SE",
+	"switzerland":          "CH",
+	"the netherlands":      "NL",
+	"ukraine":              "UA",
+	"united arab emirates": "AE",
+	"united kingdom":       "GB",
+	"united states":        "US",
+	"usa":                  "US",
+}
+
+//countryCode returns the alpha-2 code of a country given by its code or its English name
+func countryCode(country string) (string, bool) {
+	country = strings.TrimSpace(country)
+	if len(country) == 2 && isLetters(country) {
+		return strings.ToUpper(country), true
+	}
+	code, ok := countryCodes[strings.ToLower(country)]
+	return code, ok
+}
+
+func isLetters(value string) bool {
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
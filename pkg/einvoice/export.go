@@ -0,0 +1,701 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+const (
+	creditInvoiceType = "CREDITINVOICE"
+
+	//DefaultUnitCode is the UN/ECE rec 20 code of a piece
+	DefaultUnitCode = "C62"
+
+	//VAT categories of the UNCL5305 code list
+	StandardRated = "S"
+	ZeroRated     = "Z"
+	Exempt        = "E"
+
+	//the Peppol electronic address schemes of a GLN and an email
+	glnScheme   = "0088"
+	emailScheme = "EM"
+	gtinScheme  = "0160"
+
+	//PaymentMeansCreditTransfer is the UNCL4461 code of a bank transfer
+	PaymentMeansCreditTransfer = "30"
+)
+
+var (
+	ErrMissingEndpoint = errors.New("party has no electronic address")
+	ErrUnknownCountry  = errors.New("unknown country")
+	ErrUnknownVatRate  = errors.New("unknown VAT rate")
+)
+
+//hundred converts the percents of the rates and discounts
+var hundred = sharedCommon.DecimalFromInt(100)
+
+//Address is a postal address, Country is either the ISO 3166-1 alpha-2 code or the English name of the country
+type Address struct {
+	Street           string
+	AdditionalStreet string
+	City             string
+	PostalCode       string
+	State            string
+	Country          string
+}
+
+func (a Address) isZero() bool {
+	return a == Address{}
+}
+
+//Party is the seller, the buyer or the recipient of an e-invoice. The electronic address is the EndpointID
+//with its Peppol scheme, the GLN and then the email are used when it's empty
+type Party struct {
+	Name             string
+	RegistrationCode string
+	VATNumber        string
+	GLN              string
+	Email            string
+	Phone            string
+	EndpointID       string
+	EndpointScheme   string
+	Address          Address
+}
+
+//BankAccount is the account where the invoice is paid to
+type BankAccount struct {
+	IBAN string
+	BIC  string
+	Name string
+}
+
+//CompanyParty converts the company info to the seller of the e-invoices
+func CompanyParty(info company.Info) Party {
+	return Party{
+		Name:             info.Name,
+		RegistrationCode: info.Code,
+		VATNumber:        info.VAT,
+		Email:            info.Email,
+		Phone:            info.Phone,
+		Address: Address{
+			Street:  info.Address,
+			Country: info.Country,
+		},
+	}
+}
+
+//CompanyAccount returns the main bank account of the company
+func CompanyAccount(info company.Info) BankAccount {
+	return BankAccount{IBAN: info.BankIBAN, BIC: info.BankSWIFT, Name: info.BankName}
+}
+
+//CustomerParty converts a customer to the buyer or the recipient of the e-invoices,
+//the e-invoice email is preferred to the general email of the customer
+func CustomerParty(c customer.Customer) Party {
+	name := c.CompanyName
+	if name == "" {
+		name = c.FullName
+	}
+	email := c.EInvoiceEmail
+	if email == "" {
+		email = c.Email
+	}
+
+	return Party{
+		Name:             name,
+		RegistrationCode: c.Code,
+		VATNumber:        c.VatNumber,
+		GLN:              c.GLN,
+		Email:            email,
+		Phone:            c.Phone,
+		Address: Address{
+			Street:           c.Street,
+			AdditionalStreet: c.Address2,
+			City:             c.City,
+			PostalCode:       c.PostalCode,
+			State:            c.State,
+			Country:          c.Country,
+		},
+	}
+}
+
+//CustomerAddress converts one of the addresses of a customer
+func CustomerAddress(a sharedCommon.Address) Address {
+	return Address{
+		Street:           a.Street,
+		AdditionalStreet: a.Address2,
+		City:             a.City,
+		PostalCode:       a.PostalCode,
+		State:            a.State,
+		Country:          a.Country,
+	}
+}
+
+//Document is a sales document with everything needed to render it as a Peppol BIS Billing 3.0 invoice,
+//a credit invoice is rendered as a credit note
+type Document struct {
+	Sale   sales.SaleDocument
+	Seller Party
+	Buyer  Party
+	//Recipient is the ship-to party, the delivery is left out when it's nil
+	Recipient *Party
+	Account   BankAccount
+	VatRates  sales.VatRates
+	//ZeroRatedCategory is the VAT category of the rows with a zero rate, ZeroRated by default,
+	//the ExemptionReason is required for the Exempt category
+	ZeroRatedCategory string
+	ExemptionReason   string
+	//UnitCode is the unit of the quantities of all rows, DefaultUnitCode when empty
+	UnitCode string
+}
+
+//IsCreditNote tells if the document is rendered as a credit note
+func (d *Document) IsCreditNote() bool {
+	return d.Sale.Type == creditInvoiceType
+}
+
+type taxKey struct {
+	category string
+	rate     float64
+}
+
+type taxSubtotal struct {
+	taxKey
+	taxable sharedCommon.Decimal
+	tax     sharedCommon.Decimal
+	vat     bool
+}
+
+type line struct {
+	id        string
+	quantity  sharedCommon.Decimal
+	price     sharedCommon.Decimal
+	gross     sharedCommon.Decimal
+	amount    sharedCommon.Decimal
+	tax       taxKey
+	row       sales.InvoiceRow
+	allowance bool
+}
+
+//Build converts the document to the UBL Invoice or CreditNote element
+func (d *Document) Build() (interface{}, error) {
+	doc := d.Sale
+	currency := doc.CurrencyCode
+
+	seller, err := d.Seller.ubl()
+	if err != nil {
+		return nil, fmt.Errorf("seller: %w", err)
+	}
+	buyer, err := d.Buyer.ubl()
+	if err != nil {
+		return nil, fmt.Errorf("buyer: %w", err)
+	}
+	delivery, err := d.delivery()
+	if err != nil {
+		return nil, fmt.Errorf("delivery: %w", err)
+	}
+
+	lines, subtotals, err := d.lines()
+	if err != nil {
+		return nil, err
+	}
+
+	lineTotal, taxTotal := sharedCommon.Decimal{}, sharedCommon.Decimal{}
+	for _, l := range lines {
+		lineTotal = lineTotal.Add(l.amount)
+	}
+	taxTotals := ublTaxTotal{}
+	for _, subtotal := range subtotals {
+		taxTotal = taxTotal.Add(subtotal.tax)
+		category := d.taxCategory(subtotal.taxKey)
+		taxTotals.TaxSubtotals = append(taxTotals.TaxSubtotals, ublTaxSubtotal{
+			TaxableAmount: amount(currency, subtotal.taxable),
+			TaxAmount:     amount(currency, subtotal.tax),
+			TaxCategory:   category,
+		})
+	}
+	taxTotals.TaxAmount = amount(currency, taxTotal)
+
+	lineTotal, taxTotal = lineTotal.Round(2), taxTotal.Round(2)
+	docRounding := doc.RoundingDecimal().Mul(d.sign())
+	monetaryTotal := ublMonetaryTotal{
+		LineExtensionAmount: amount(currency, lineTotal),
+		TaxExclusiveAmount:  amount(currency, lineTotal),
+		TaxInclusiveAmount:  amount(currency, lineTotal.Add(taxTotal)),
+		PayableAmount:       amount(currency, lineTotal.Add(taxTotal).Add(docRounding)),
+	}
+	if !docRounding.IsZero() {
+		rounding := amount(currency, docRounding)
+		monetaryTotal.PayableRoundingAmount = &rounding
+	}
+
+	buyerReference := doc.CustomReferenceNumber
+	if buyerReference == "" {
+		buyerReference = doc.Number
+	}
+	billingReferences := []ublBillingReference{}
+	for _, base := range doc.BaseDocuments {
+		if base.Number == "" {
+			continue
+		}
+		billingReferences = append(billingReferences, ublBillingReference{
+			InvoiceDocumentReference: ublDocumentReference{ID: base.Number, IssueDate: base.Date},
+		})
+	}
+
+	if d.IsCreditNote() {
+		creditNote := &ublCreditNote{
+			Xmlns:                creditNoteNamespace,
+			XmlnsCac:             cacNamespace,
+			XmlnsCbc:             cbcNamespace,
+			CustomizationID:      CustomizationID,
+			ProfileID:            ProfileID,
+			ID:                   doc.Number,
+			IssueDate:            doc.Date,
+			CreditNoteTypeCode:   CreditNoteTypeCode,
+			Note:                 doc.Notes,
+			DocumentCurrencyCode: currency,
+			BuyerReference:       buyerReference,
+			BillingReference:     billingReferences,
+			Supplier:             ublPartyWrapper{Party: seller},
+			Customer:             ublPartyWrapper{Party: buyer},
+			Delivery:             delivery,
+			TaxTotal:             taxTotals,
+			LegalMonetaryTotal:   monetaryTotal,
+		}
+		for _, l := range lines {
+			creditNote.Lines = append(creditNote.Lines, ublCreditNoteLine{
+				ID:                  l.id,
+				CreditedQuantity:    d.quantity(l.quantity),
+				LineExtensionAmount: amount(currency, l.amount),
+				InvoicePeriod:       period(l.row),
+				Item:                d.item(l),
+				Price:               linePrice(currency, l),
+			})
+		}
+		return creditNote, nil
+	}
+
+	dueDate, err := d.dueDate()
+	if err != nil {
+		return nil, err
+	}
+	invoice := &ublInvoice{
+		Xmlns:                invoiceNamespace,
+		XmlnsCac:             cacNamespace,
+		XmlnsCbc:             cbcNamespace,
+		CustomizationID:      CustomizationID,
+		ProfileID:            ProfileID,
+		ID:                   doc.Number,
+		IssueDate:            doc.Date,
+		DueDate:              dueDate,
+		InvoiceTypeCode:      InvoiceTypeCode,
+		Note:                 doc.Notes,
+		DocumentCurrencyCode: currency,
+		BuyerReference:       buyerReference,
+		BillingReference:     billingReferences,
+		Supplier:             ublPartyWrapper{Party: seller},
+		Customer:             ublPartyWrapper{Party: buyer},
+		Delivery:             delivery,
+		PaymentMeans:         d.paymentMeans(),
+		PaymentTerms:         d.paymentTerms(),
+		TaxTotal:             taxTotals,
+		LegalMonetaryTotal:   monetaryTotal,
+	}
+	for _, l := range lines {
+		invoice.Lines = append(invoice.Lines, ublInvoiceLine{
+			ID:                  l.id,
+			InvoicedQuantity:    d.quantity(l.quantity),
+			LineExtensionAmount: amount(currency, l.amount),
+			InvoicePeriod:       period(l.row),
+			Item:                d.item(l),
+			Price:               linePrice(currency, l),
+		})
+	}
+	return invoice, nil
+}
+
+//Write renders the document as UBL XML
+func (d *Document) Write(w io.Writer) error {
+	root, err := d.Build()
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+//sign turns the negative quantities and totals of a credit invoice into the positive ones of a credit note
+func (d *Document) sign() sharedCommon.Decimal {
+	if d.IsCreditNote() {
+		return sharedCommon.DecimalFromInt(-1)
+	}
+	return sharedCommon.DecimalFromInt(1)
+}
+
+//lines converts the rows of the document and sums them by VAT category and rate. The VAT amounts
+//of the document are used when it has them, so the payable amount matches its total
+func (d *Document) lines() ([]line, []*taxSubtotal, error) {
+	rates := map[string]sharedCommon.Decimal{}
+	for _, rate := range d.VatRates {
+		value, err := sharedCommon.ParseDecimal(rate.Rate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid rate %q of VAT rate %s: %v", rate.Rate, rate.ID, err)
+		}
+		rates[rate.ID] = value
+	}
+
+	sign := d.sign()
+	lines := make([]line, 0, len(d.Sale.InvoiceRows))
+	subtotals := map[taxKey]*taxSubtotal{}
+	for i, row := range d.Sale.InvoiceRows {
+		quantity, err := row.AmountDecimal()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid amount %q of row %d: %v", row.Amount, i+1, err)
+		}
+		gross, err := row.PriceDecimal()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid price %q of row %d: %v", row.Price, i+1, err)
+		}
+		discount, err := row.DiscountDecimal()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid discount %q of row %d: %v", row.Discount, i+1, err)
+		}
+		rate, ok := rates[row.VatrateID]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w %s of row %d", ErrUnknownVatRate, row.VatrateID, i+1)
+		}
+
+		quantity = quantity.Mul(sign)
+		net, _ := gross.Mul(hundred.Sub(discount)).Div(hundred, 4)
+		//the unit price can't be negative in UBL, the sign is moved to the quantity
+		if net.Sign() < 0 {
+			net, gross, quantity = net.Neg(), gross.Neg(), quantity.Neg()
+		}
+		l := line{
+			id:       strconv.Itoa(i + 1),
+			quantity: quantity,
+			price:    net,
+			gross:    gross,
+			amount:   quantity.Mul(net).Round(2),
+			tax:      d.taxKey(rate),
+			row:      row,
+			//a charge on the price level isn't allowed, a negative discount only raises the net price
+			allowance: discount.Sign() > 0 && !net.Equal(gross),
+		}
+		lines = append(lines, l)
+
+		subtotal, ok := subtotals[l.tax]
+		if !ok {
+			subtotal = &taxSubtotal{taxKey: l.tax}
+			subtotals[l.tax] = subtotal
+		}
+		subtotal.taxable = subtotal.taxable.Add(l.amount)
+	}
+
+	keysByRateID := map[int]taxKey{}
+	for id, rate := range rates {
+		rateID, err := strconv.Atoi(id)
+		if err == nil {
+			keysByRateID[rateID] = d.taxKey(rate)
+		}
+	}
+	for _, vatTotal := range d.Sale.VatTotalsByTaxRates {
+		key, ok := keysByRateID[vatTotal.VatrateID]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w %d of the VAT totals", ErrUnknownVatRate, vatTotal.VatrateID)
+		}
+		if subtotal, ok := subtotals[key]; ok {
+			subtotal.tax = subtotal.tax.Add(vatTotal.TotalDecimal().Mul(sign))
+			subtotal.vat = true
+		}
+	}
+
+	result := make([]*taxSubtotal, 0, len(subtotals))
+	for _, subtotal := range subtotals {
+		subtotal.taxable = subtotal.taxable.Round(2)
+		if subtotal.vat {
+			subtotal.tax = subtotal.tax.Round(2)
+		} else {
+			subtotal.tax, _ = subtotal.taxable.Mul(sharedCommon.DecimalFromFloat(subtotal.rate)).Div(hundred, 2)
+		}
+		result = append(result, subtotal)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].rate != result[j].rate {
+			return result[i].rate > result[j].rate
+		}
+		return result[i].category < result[j].category
+	})
+
+	return lines, result, nil
+}
+
+func (d *Document) taxKey(rate sharedCommon.Decimal) taxKey {
+	if !rate.IsZero() {
+		return taxKey{category: StandardRated, rate: rate.Float64()}
+	}
+	category := d.ZeroRatedCategory
+	if category == "" {
+		category = ZeroRated
+	}
+	return taxKey{category: category, rate: 0}
+}
+
+func (d *Document) taxCategory(key taxKey) ublTaxCategory {
+	category := ublTaxCategory{
+		ID:        key.category,
+		Percent:   sharedCommon.DecimalFromFloat(key.rate).String(),
+		TaxScheme: ublTaxScheme{ID: "VAT"},
+	}
+	if key.category != StandardRated && key.category != ZeroRated {
+		category.TaxExemptionReason = d.ExemptionReason
+	}
+	return category
+}
+
+func (d *Document) item(l line) ublItem {
+	item := ublItem{
+		Name:                  l.row.ItemName,
+		ClassifiedTaxCategory: d.taxCategory(l.tax),
+	}
+	item.ClassifiedTaxCategory.TaxExemptionReason = ""
+	if l.row.Code != "" {
+		item.SellersItemIdentification = &ublItemID{ID: l.row.Code}
+	}
+	gtin := l.row.Barcode
+	if gtin == "" {
+		gtin = l.row.Code2
+	}
+	if isGTIN(gtin) {
+		item.StandardItemIdentification = &ublStandardItemID{ID: ublIdentifier{SchemeID: gtinScheme, Value: gtin}}
+	}
+	return item
+}
+
+func (d *Document) quantity(value sharedCommon.Decimal) ublQuantity {
+	unitCode := d.UnitCode
+	if unitCode == "" {
+		unitCode = DefaultUnitCode
+	}
+	return ublQuantity{UnitCode: unitCode, Value: value.Trim().String()}
+}
+
+func (d *Document) dueDate() (string, error) {
+	if d.Sale.Date == "" {
+		return "", nil
+	}
+	date, err := time.Parse(sharedCommon.DateLayout, d.Sale.Date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q of the document: %v", d.Sale.Date, err)
+	}
+	days, err := d.paymentDays()
+	if err != nil {
+		return "", err
+	}
+	return date.AddDate(0, 0, days).Format(sharedCommon.DateLayout), nil
+}
+
+func (d *Document) paymentDays() (int, error) {
+	if d.Sale.PaymentDays == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(d.Sale.PaymentDays)
+	if err != nil {
+		return 0, fmt.Errorf("invalid payment days %q of the document: %v", d.Sale.PaymentDays, err)
+	}
+	return days, nil
+}
+
+func (d *Document) paymentMeans() *ublPaymentMeans {
+	if d.Account.IBAN == "" {
+		return nil
+	}
+	account := &ublFinancialAccount{ID: strings.ReplaceAll(d.Account.IBAN, " ", ""), Name: d.Account.Name}
+	if d.Account.BIC != "" {
+		account.Branch = &ublBranchAccess{ID: d.Account.BIC}
+	}
+	return &ublPaymentMeans{
+		PaymentMeansCode: PaymentMeansCreditTransfer,
+		PaymentID:        d.Sale.ReferenceNumber,
+		PayeeAccount:     account,
+	}
+}
+
+func (d *Document) paymentTerms() *ublPaymentTerms {
+	days, err := d.paymentDays()
+	if err != nil || days <= 0 {
+		return nil
+	}
+	return &ublPaymentTerms{Note: fmt.Sprintf("Net %d days", days)}
+}
+
+func (d *Document) delivery() (*ublDelivery, error) {
+	date := d.Sale.DeliveryDate
+	if date == "" {
+		date = d.Sale.ShippingDate
+	}
+	if d.Recipient == nil && date == "" {
+		return nil, nil
+	}
+
+	delivery := &ublDelivery{ActualDeliveryDate: date}
+	if d.Recipient == nil {
+		return delivery, nil
+	}
+	if d.Recipient.Name != "" {
+		delivery.DeliveryParty = &ublDeliveryParty{PartyName: ublPartyName{Name: d.Recipient.Name}}
+	}
+	if !d.Recipient.Address.isZero() {
+		address := d.Recipient.Address
+		if address.Country == "" {
+			address.Country = d.Buyer.Address.Country
+		}
+		ublAddress, err := address.ubl()
+		if err != nil {
+			return nil, err
+		}
+		delivery.DeliveryLocation = &ublDeliveryLocation{Address: ublAddress}
+	}
+	return delivery, nil
+}
+
+func (p Party) ubl() (ublParty, error) {
+	endpoint := p.endpoint()
+	if endpoint == nil {
+		return ublParty{}, fmt.Errorf("%w: %s", ErrMissingEndpoint, p.Name)
+	}
+	address, err := p.Address.ubl()
+	if err != nil {
+		return ublParty{}, err
+	}
+
+	party := ublParty{
+		EndpointID:  endpoint,
+		Address:     address,
+		LegalEntity: ublLegalEntity{RegistrationName: p.Name, CompanyID: p.RegistrationCode},
+	}
+	if p.Name != "" {
+		party.PartyName = &ublPartyName{Name: p.Name}
+	}
+	if p.VATNumber != "" {
+		party.TaxScheme = &ublPartyTaxScheme{CompanyID: p.VATNumber, TaxScheme: ublTaxScheme{ID: "VAT"}}
+	}
+	if p.Phone != "" || p.Email != "" {
+		party.Contact = &ublContact{Telephone: p.Phone, ElectronicMail: p.Email}
+	}
+	return party, nil
+}
+
+func (p Party) endpoint() *ublIdentifier {
+	switch {
+	case p.EndpointID != "":
+		return &ublIdentifier{SchemeID: p.EndpointScheme, Value: p.EndpointID}
+	case p.GLN != "":
+		return &ublIdentifier{SchemeID: glnScheme, Value: p.GLN}
+	case p.Email != "":
+		return &ublIdentifier{SchemeID: emailScheme, Value: p.Email}
+	}
+	return nil
+}
+
+func (a Address) ubl() (ublAddress, error) {
+	code, ok := countryCode(a.Country)
+	if !ok {
+		return ublAddress{}, fmt.Errorf("%w %q", ErrUnknownCountry, a.Country)
+	}
+	return ublAddress{
+		StreetName:           a.Street,
+		AdditionalStreetName: a.AdditionalStreet,
+		CityName:             a.City,
+		PostalZone:           a.PostalCode,
+		CountrySubentity:     a.State,
+		Country:              ublCountry{IdentificationCode: code},
+	}, nil
+}
+
+func linePrice(currency string, l line) ublPrice {
+	p := ublPrice{PriceAmount: ublAmount{CurrencyID: currency, Value: l.price.Trim().String()}}
+	if l.allowance {
+		p.AllowanceCharge = &ublAllowanceCharge{
+			ChargeIndicator: false,
+			Amount:          ublAmount{CurrencyID: currency, Value: l.gross.Sub(l.price).Trim().String()},
+			BaseAmount:      ublAmount{CurrencyID: currency, Value: l.gross.Trim().String()},
+		}
+	}
+	return p
+}
+
+func period(row sales.InvoiceRow) *ublPeriod {
+	start, end := validDate(row.BillingStartDate), validDate(row.BillingEndDate)
+	if start == "" && end == "" {
+		return nil
+	}
+	return &ublPeriod{StartDate: start, EndDate: end}
+}
+
+//validDate drops the empty dates of the API like 0000-00-00
+func validDate(date string) string {
+	if _, err := time.Parse(sharedCommon.DateLayout, date); err != nil {
+		return ""
+	}
+	return date
+}
+
+func isGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func amount(currency string, value sharedCommon.Decimal) ublAmount {
+	return ublAmount{CurrencyID: currency, Value: value.StringFixed(2)}
+}
@@ -0,0 +1,201 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDocument() *Document {
+	return &Document{
+		Sale: sales.SaleDocument{
+			ID:                    12,
+			Type:                  "INVWAYBILL",
+			Number:                "1001",
+			Date:                  "2022-06-20",
+			DeliveryDate:          "2022-06-21",
+			PaymentDays:           "14",
+			CurrencyCode:          "EUR",
+			ReferenceNumber:       "10013",
+			CustomReferenceNumber: "PO-77",
+			VatTotalsByTaxRates:   sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 3.6}},
+			InvoiceRows: []sales.InvoiceRow{
+				{ProductID: "5", ItemName: "Coffee", Code: "C-1", Barcode: "4740000000017", VatrateID: "1", Amount: "2", Price: "10", Discount: "10"},
+				{ProductID: "6", ItemName: "Book", Code: "B-1", VatrateID: "2", Amount: "1", Price: "5", Discount: "0"},
+			},
+		},
+		Seller: Party{
+			Name:             "Seller Ltd",
+			RegistrationCode: "10000001",
+			VATNumber:        "EE100000001",
+			Email:            "billing@seller.example",
+			Address:          Address{Street: "Main 1", City: "Tallinn", PostalCode: "10111", Country: "Estonia"},
+		},
+		Buyer: Party{
+			Name:      "Buyer AB",
+			VATNumber: "SE556000000001",
+			GLN:       "7300010000001",
+			Address:   Address{Street: "Storgatan 2", City: "Stockholm", PostalCode: "11122", Country: "SE"},
+		},
+		Recipient: &Party{
+			Name:    "Buyer Warehouse",
+			Address: Address{Street: "Hamnvagen 3", City: "Malmo"},
+		},
+		Account: BankAccount{IBAN: "EE38 2200 2210 2014 5685", BIC: "HABAEE2X"},
+		VatRates: sales.VatRates{
+			{ID: "1", Rate: "20", Active: "1"},
+			{ID: "2", Rate: "0", Active: "1"},
+		},
+	}
+}
+
+func TestWriteInvoice(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, newTestDocument().Write(buf))
+	xml := buf.String()
+
+	assert.Contains(t, xml, `<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"`)
+	assert.Contains(t, xml, "<cbc:CustomizationID>"+CustomizationID+"</cbc:CustomizationID>")
+	assert.Contains(t, xml, "<cbc:DueDate>2022-07-04</cbc:DueDate>")
+	assert.Contains(t, xml, "<cbc:InvoiceTypeCode>380</cbc:InvoiceTypeCode>")
+	assert.Contains(t, xml, "<cbc:BuyerReference>PO-77</cbc:BuyerReference>")
+	assert.Contains(t, xml, `<cbc:EndpointID schemeID="EM">billing@seller.example</cbc:EndpointID>`)
+	assert.Contains(t, xml, `<cbc:EndpointID schemeID="0088">7300010000001</cbc:EndpointID>`)
+	assert.Contains(t, xml, "<cbc:IdentificationCode>EE</cbc:IdentificationCode>")
+	assert.Contains(t, xml, "<cbc:ID>EE382200221020145685</cbc:ID>")
+	assert.Contains(t, xml, "<cbc:PaymentID>10013</cbc:PaymentID>")
+	assert.Contains(t, xml, "<cbc:Note>Net 14 days</cbc:Note>")
+	assert.Contains(t, xml, "<cbc:ActualDeliveryDate>2022-06-21</cbc:ActualDeliveryDate>")
+	assert.Contains(t, xml, "<cbc:CityName>Malmo</cbc:CityName>")
+	assert.Contains(t, xml, `<cbc:TaxAmount currencyID="EUR">3.60</cbc:TaxAmount>`)
+	assert.Contains(t, xml, `<cbc:TaxableAmount currencyID="EUR">18.00</cbc:TaxableAmount>`)
+	assert.Contains(t, xml, `<cbc:TaxableAmount currencyID="EUR">5.00</cbc:TaxableAmount>`)
+	assert.Contains(t, xml, "<cbc:ID>Z</cbc:ID>")
+	assert.Contains(t, xml, `<cbc:LineExtensionAmount currencyID="EUR">23.00</cbc:LineExtensionAmount>`)
+	assert.Contains(t, xml, `<cbc:PayableAmount currencyID="EUR">26.60</cbc:PayableAmount>`)
+	assert.Contains(t, xml, `<cbc:InvoicedQuantity unitCode="C62">2</cbc:InvoicedQuantity>`)
+	assert.Contains(t, xml, `<cbc:ID schemeID="0160">4740000000017</cbc:ID>`)
+	assert.Contains(t, xml, `<cbc:PriceAmount currencyID="EUR">9</cbc:PriceAmount>`)
+	assert.Contains(t, xml, `<cbc:BaseAmount currencyID="EUR">10</cbc:BaseAmount>`)
+	assert.NotContains(t, xml, "PayableRoundingAmount")
+
+	invoice, err := ParseInvoice(buf)
+	assert.NoError(t, err)
+	assert.False(t, invoice.CreditNote)
+	assert.Equal(t, "1001", invoice.Number)
+	assert.Equal(t, "EE100000001", invoice.Supplier.VATNumber)
+	assert.Equal(t, "23.00", invoice.TaxExclusiveAmount.String())
+	assert.Equal(t, "3.60", invoice.TaxAmount.String())
+	assert.Equal(t, "26.60", invoice.PayableAmount.String())
+	assert.Equal(t, []string{
+		"1|Coffee|C-1||4740000000017|2|C62|9|18|20",
+		"2|Book|B-1|||1|C62|5|5|0",
+	}, purchaseLineRecords(invoice.Lines))
+}
+
+func TestWriteCreditNote(t *testing.T) {
+	doc := newTestDocument()
+	doc.Sale.Type = creditInvoiceType
+	doc.Sale.Number = "1002"
+	doc.Sale.BaseDocuments = []sales.BaseDocument{{ID: 12, Number: "1001", Type: "INVWAYBILL", Date: "2022-06-20"}}
+	doc.Sale.InvoiceRows = doc.Sale.InvoiceRows[:1]
+	doc.Sale.InvoiceRows[0].Amount = "-1"
+	doc.Sale.VatTotalsByTaxRates = sales.VatTotalsByTaxRates{{VatrateID: 1, Total: -1.8}}
+	doc.Sale.Rounding = -0.01
+	doc.Recipient = nil
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, doc.Write(buf))
+	xml := buf.String()
+
+	assert.Contains(t, xml, `<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"`)
+	assert.Contains(t, xml, "<cbc:CreditNoteTypeCode>381</cbc:CreditNoteTypeCode>")
+	assert.NotContains(t, xml, "DueDate")
+	assert.NotContains(t, xml, "PaymentMeans")
+	assert.Contains(t, xml, "<cbc:ID>1001</cbc:ID>\n      <cbc:IssueDate>2022-06-20</cbc:IssueDate>")
+	assert.Contains(t, xml, `<cbc:CreditedQuantity unitCode="C62">1</cbc:CreditedQuantity>`)
+	assert.Contains(t, xml, `<cbc:TaxAmount currencyID="EUR">1.80</cbc:TaxAmount>`)
+	assert.Contains(t, xml, `<cbc:PayableRoundingAmount currencyID="EUR">0.01</cbc:PayableRoundingAmount>`)
+	assert.Contains(t, xml, `<cbc:PayableAmount currencyID="EUR">10.81</cbc:PayableAmount>`)
+
+	invoice, err := ParseInvoice(buf)
+	assert.NoError(t, err)
+	assert.True(t, invoice.CreditNote)
+	assert.Equal(t, "1", invoice.Lines[0].Quantity.String())
+	assert.Equal(t, "10.81", invoice.PayableAmount.String())
+}
+
+func TestBuildComputesMissingVatTotals(t *testing.T) {
+	doc := newTestDocument()
+	doc.Sale.VatTotalsByTaxRates = nil
+	doc.Sale.InvoiceRows[0].Amount = "3"
+
+	root, err := doc.Build()
+	assert.NoError(t, err)
+	invoice := root.(*ublInvoice)
+	assert.Equal(t, "5.40", invoice.TaxTotal.TaxAmount.Value)
+	assert.Equal(t, "S", invoice.TaxTotal.TaxSubtotals[0].TaxCategory.ID)
+	assert.Equal(t, "20", invoice.TaxTotal.TaxSubtotals[0].TaxCategory.Percent)
+	assert.Equal(t, "37.40", invoice.LegalMonetaryTotal.PayableAmount.Value)
+}
+
+func TestCustomerParty(t *testing.T) {
+	party := CustomerParty(customer.Customer{
+		FullName:      "Buyer",
+		CompanyName:   "Buyer AB",
+		Code:          "556000-0000",
+		VatNumber:     "SE556000000001",
+		Email:         "info@buyer.example",
+		EInvoiceEmail: "invoices@buyer.example",
+		Street:        "Storgatan 2",
+		City:          "Stockholm",
+		Country:       "Sweden",
+	})
+	assert.Equal(t, "Buyer AB", party.Name)
+	assert.Equal(t, "invoices@buyer.example", party.Email)
+	assert.Equal(t, Address{Street: "Storgatan 2", City: "Stockholm", Country: "Sweden"}, party.Address)
+
+	endpoint := party.endpoint()
+	assert.Equal(t, &ublIdentifier{SchemeID: emailScheme, Value: "invoices@buyer.example"}, endpoint)
+}
+
+func TestBuildErrors(t *testing.T) {
+	doc := newTestDocument()
+	doc.Buyer.GLN = ""
+	_, err := doc.Build()
+	assert.True(t, errors.Is(err, ErrMissingEndpoint))
+
+	doc = newTestDocument()
+	doc.Seller.Address.Country = "Atlantis"
+	_, err = doc.Build()
+	assert.True(t, errors.Is(err, ErrUnknownCountry))
+
+	doc = newTestDocument()
+	doc.Sale.InvoiceRows[1].VatrateID = "9"
+	_, err = doc.Build()
+	assert.True(t, errors.Is(err, ErrUnknownVatRate))
+}
@@ -0,0 +1,458 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+const (
+	//PurchaseInvoiceType is the type of the purchase documents created from the invoices
+	PurchaseInvoiceType = "PRCINVOICE"
+	//PurchaseReturnType is the type of the purchase documents created from the credit notes
+	PurchaseReturnType = "PRCRETURN"
+)
+
+var ErrNotUBL = errors.New("not a UBL invoice or credit note")
+
+//PurchaseLine is a line of an incoming invoice, Price is the net unit price after the allowances
+type PurchaseLine struct {
+	ID             string
+	Name           string
+	SellersItemID  string
+	BuyersItemID   string
+	StandardItemID string
+	Quantity       sharedCommon.Decimal
+	UnitCode       string
+	Price          sharedCommon.Decimal
+	Amount         sharedCommon.Decimal
+	VatPercent     sharedCommon.Decimal
+}
+
+//PurchaseInvoice is an incoming UBL invoice or credit note
+type PurchaseInvoice struct {
+	CreditNote         bool
+	Number             string
+	IssueDate          string
+	DueDate            string
+	Currency           string
+	Note               string
+	BuyerReference     string
+	Supplier           Party
+	Lines              []PurchaseLine
+	TaxExclusiveAmount sharedCommon.Decimal
+	TaxAmount          sharedCommon.Decimal
+	PayableAmount      sharedCommon.Decimal
+}
+
+//The import structures use the local names only, so the namespace prefixes of the sender don't matter
+
+type inDocument struct {
+	XMLName        xml.Name
+	ID             string    `xml:"ID"`
+	IssueDate      string    `xml:"IssueDate"`
+	DueDate        string    `xml:"DueDate"`
+	Note           string    `xml:"Note"`
+	Currency       string    `xml:"DocumentCurrencyCode"`
+	BuyerReference string    `xml:"BuyerReference"`
+	Supplier       inParty   `xml:"AccountingSupplierParty>Party"`
+	PaymentDueDate string    `xml:"PaymentMeans>PaymentDueDate"`
+	TaxAmount      []inTotal `xml:"TaxTotal>TaxAmount"`
+	TaxExclusive   string    `xml:"LegalMonetaryTotal>TaxExclusiveAmount"`
+	Payable        string    `xml:"LegalMonetaryTotal>PayableAmount"`
+	InvoiceLines   []inLine  `xml:"InvoiceLine"`
+	CreditLines    []inLine  `xml:"CreditNoteLine"`
+}
+
+type inTotal struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type inParty struct {
+	Endpoint         inIdentifier `xml:"EndpointID"`
+	Identifications  []string     `xml:"PartyIdentification>ID"`
+	Name             string       `xml:"PartyName>Name"`
+	Street           string       `xml:"PostalAddress>StreetName"`
+	AdditionalStreet string       `xml:"PostalAddress>AdditionalStreetName"`
+	City             string       `xml:"PostalAddress>CityName"`
+	PostalCode       string       `xml:"PostalAddress>PostalZone"`
+	State            string       `xml:"PostalAddress>CountrySubentity"`
+	Country          string       `xml:"PostalAddress>Country>IdentificationCode"`
+	VATNumbers       []string     `xml:"PartyTaxScheme>CompanyID"`
+	RegistrationName string       `xml:"PartyLegalEntity>RegistrationName"`
+	RegistrationCode string       `xml:"PartyLegalEntity>CompanyID"`
+	Phone            string       `xml:"Contact>Telephone"`
+	Email            string       `xml:"Contact>ElectronicMail"`
+}
+
+type inIdentifier struct {
+	SchemeID string `xml:"schemeID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type inQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type inLine struct {
+	ID               string     `xml:"ID"`
+	InvoicedQuantity inQuantity `xml:"InvoicedQuantity"`
+	CreditedQuantity inQuantity `xml:"CreditedQuantity"`
+	Amount           string     `xml:"LineExtensionAmount"`
+	Name             string     `xml:"Item>Name"`
+	BuyersItemID     string     `xml:"Item>BuyersItemIdentification>ID"`
+	SellersItemID    string     `xml:"Item>SellersItemIdentification>ID"`
+	StandardItemID   string     `xml:"Item>StandardItemIdentification>ID"`
+	VatPercent       string     `xml:"Item>ClassifiedTaxCategory>Percent"`
+	PriceAmount      string     `xml:"Price>PriceAmount"`
+	BaseQuantity     string     `xml:"Price>BaseQuantity"`
+}
+
+//ParseInvoice reads a UBL 2.1 invoice or credit note
+func ParseInvoice(r io.Reader) (*PurchaseInvoice, error) {
+	doc := inDocument{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid UBL document: %v", err)
+	}
+
+	invoice := &PurchaseInvoice{
+		Number:         strings.TrimSpace(doc.ID),
+		IssueDate:      strings.TrimSpace(doc.IssueDate),
+		DueDate:        strings.TrimSpace(doc.DueDate),
+		Currency:       strings.TrimSpace(doc.Currency),
+		Note:           strings.TrimSpace(doc.Note),
+		BuyerReference: strings.TrimSpace(doc.BuyerReference),
+		Supplier:       doc.Supplier.party(),
+	}
+	lines := doc.InvoiceLines
+	switch doc.XMLName.Local {
+	case "Invoice":
+	case "CreditNote":
+		invoice.CreditNote = true
+		lines = doc.CreditLines
+	default:
+		return nil, fmt.Errorf("%w: root element %s", ErrNotUBL, doc.XMLName.Local)
+	}
+	if invoice.DueDate == "" {
+		invoice.DueDate = strings.TrimSpace(doc.PaymentDueDate)
+	}
+
+	var err error
+	if invoice.TaxExclusiveAmount, err = sharedCommon.ParseDecimal(strings.TrimSpace(doc.TaxExclusive)); err != nil {
+		return nil, fmt.Errorf("invalid tax exclusive amount %q: %v", doc.TaxExclusive, err)
+	}
+	if invoice.PayableAmount, err = sharedCommon.ParseDecimal(strings.TrimSpace(doc.Payable)); err != nil {
+		return nil, fmt.Errorf("invalid payable amount %q: %v", doc.Payable, err)
+	}
+	//the second tax total is in the tax currency when it differs from the document currency
+	for _, tax := range doc.TaxAmount {
+		if tax.CurrencyID != "" && tax.CurrencyID != invoice.Currency {
+			continue
+		}
+		if invoice.TaxAmount, err = sharedCommon.ParseDecimal(strings.TrimSpace(tax.Value)); err != nil {
+			return nil, fmt.Errorf("invalid tax amount %q: %v", tax.Value, err)
+		}
+		break
+	}
+
+	for i, in := range lines {
+		l, err := in.line()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		invoice.Lines = append(invoice.Lines, l)
+	}
+
+	return invoice, nil
+}
+
+func (p inParty) party() Party {
+	party := Party{
+		Name:             strings.TrimSpace(p.RegistrationName),
+		RegistrationCode: strings.TrimSpace(p.RegistrationCode),
+		Email:            strings.TrimSpace(p.Email),
+		Phone:            strings.TrimSpace(p.Phone),
+		EndpointID:       strings.TrimSpace(p.Endpoint.Value),
+		EndpointScheme:   p.Endpoint.SchemeID,
+		Address: Address{
+			Street:           strings.TrimSpace(p.Street),
+			AdditionalStreet: strings.TrimSpace(p.AdditionalStreet),
+			City:             strings.TrimSpace(p.City),
+			PostalCode:       strings.TrimSpace(p.PostalCode),
+			State:            strings.TrimSpace(p.State),
+			Country:          strings.TrimSpace(p.Country),
+		},
+	}
+	if party.Name == "" {
+		party.Name = strings.TrimSpace(p.Name)
+	}
+	if len(p.VATNumbers) > 0 {
+		party.VATNumber = strings.TrimSpace(p.VATNumbers[0])
+	}
+	if party.EndpointScheme == glnScheme {
+		party.GLN = party.EndpointID
+	}
+	return party
+}
+
+func (in inLine) line() (PurchaseLine, error) {
+	quantity := in.InvoicedQuantity
+	if strings.TrimSpace(quantity.Value) == "" {
+		quantity = in.CreditedQuantity
+	}
+
+	l := PurchaseLine{
+		ID:             strings.TrimSpace(in.ID),
+		Name:           strings.TrimSpace(in.Name),
+		SellersItemID:  strings.TrimSpace(in.SellersItemID),
+		BuyersItemID:   strings.TrimSpace(in.BuyersItemID),
+		StandardItemID: strings.TrimSpace(in.StandardItemID),
+		UnitCode:       quantity.UnitCode,
+	}
+	var err error
+	if l.Quantity, err = sharedCommon.ParseDecimal(strings.TrimSpace(quantity.Value)); err != nil {
+		return l, fmt.Errorf("invalid quantity %q: %v", quantity.Value, err)
+	}
+	if l.Amount, err = sharedCommon.ParseDecimal(strings.TrimSpace(in.Amount)); err != nil {
+		return l, fmt.Errorf("invalid line amount %q: %v", in.Amount, err)
+	}
+	if l.VatPercent, err = sharedCommon.ParseDecimal(strings.TrimSpace(in.VatPercent)); err != nil {
+		return l, fmt.Errorf("invalid VAT percent %q: %v", in.VatPercent, err)
+	}
+
+	//the line amount includes the line allowances and charges, the price is only used for the lines without a quantity
+	if !l.Quantity.IsZero() {
+		l.Price, _ = l.Amount.Div(l.Quantity, 4)
+		return l, nil
+	}
+	priceAmount, err := sharedCommon.ParseDecimal(strings.TrimSpace(in.PriceAmount))
+	if err != nil {
+		return l, fmt.Errorf("invalid price %q: %v", in.PriceAmount, err)
+	}
+	baseQuantity, err := sharedCommon.ParseDecimal(strings.TrimSpace(in.BaseQuantity))
+	if err != nil {
+		return l, fmt.Errorf("invalid base quantity %q: %v", in.BaseQuantity, err)
+	}
+	if baseQuantity.IsZero() {
+		baseQuantity = sharedCommon.DecimalFromInt(1)
+	}
+	l.Price, _ = priceAmount.Div(baseQuantity, 4)
+	return l, nil
+}
+
+//FindSupplier returns the supplier of the invoice by the VAT number, the GLN or the registration code
+func FindSupplier(suppliers []customer.Supplier, party Party) (customer.Supplier, bool) {
+	vatNumber := normalizeCode(party.VATNumber)
+	gln := normalizeCode(party.GLN)
+	code := normalizeCode(party.RegistrationCode)
+
+	for _, match := range []func(customer.Supplier) bool{
+		func(s customer.Supplier) bool { return vatNumber != "" && normalizeCode(s.VatNumber) == vatNumber },
+		func(s customer.Supplier) bool { return gln != "" && normalizeCode(s.Gln) == gln },
+		func(s customer.Supplier) bool { return code != "" && normalizeCode(s.Code) == code },
+	} {
+		for _, supplier := range suppliers {
+			if match(supplier) {
+				return supplier, true
+			}
+		}
+	}
+	return customer.Supplier{}, false
+}
+
+func normalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+//ProductResolver finds the products of the invoice lines. The seller's item ID is looked up in the codes of the
+//supplier's price lists and then in the supplier codes of the products, the standard item ID in the barcodes
+//and the buyer's item ID in the product codes
+type ProductResolver struct {
+	priceListCodes map[int]map[string]int
+	supplierCodes  map[string]int
+	barcodes       map[string]int
+	codes          map[string]int
+}
+
+func NewProductResolver() *ProductResolver {
+	return &ProductResolver{
+		priceListCodes: map[int]map[string]int{},
+		supplierCodes:  map[string]int{},
+		barcodes:       map[string]int{},
+		codes:          map[string]int{},
+	}
+}
+
+//AddProducts adds the codes of the products, the first product wins when several share a code
+func (r *ProductResolver) AddProducts(products ...product.Product) {
+	for _, p := range products {
+		addCode(r.supplierCodes, p.SupplierCode, p.ProductID)
+		addCode(r.barcodes, p.Code2, p.ProductID)
+		addCode(r.codes, p.Code, p.ProductID)
+	}
+}
+
+//AddSupplierPriceList adds the supplier codes of the products in a supplier price list
+func (r *ProductResolver) AddSupplierPriceList(priceList price.PriceList, products ...price.ProductsInSupplierPriceList) {
+	codes, ok := r.priceListCodes[priceList.SupplierID]
+	if !ok {
+		codes = map[string]int{}
+		r.priceListCodes[priceList.SupplierID] = codes
+	}
+	for _, p := range products {
+		addCode(codes, p.ProductSupplierCode, p.ProductID)
+	}
+}
+
+//Resolve returns the product ID of the line of the supplier's invoice
+func (r *ProductResolver) Resolve(supplierID int, l PurchaseLine) (int, bool) {
+	if id, ok := lookupCode(r.priceListCodes[supplierID], l.SellersItemID); ok {
+		return id, true
+	}
+	if id, ok := lookupCode(r.supplierCodes, l.SellersItemID); ok {
+		return id, true
+	}
+	if id, ok := lookupCode(r.barcodes, l.StandardItemID); ok {
+		return id, true
+	}
+	return lookupCode(r.codes, l.BuyersItemID)
+}
+
+func addCode(codes map[string]int, code string, productID int) {
+	code = strings.TrimSpace(code)
+	if code == "" || productID == 0 {
+		return
+	}
+	if _, ok := codes[code]; !ok {
+		codes[code] = productID
+	}
+}
+
+func lookupCode(codes map[string]int, code string) (int, bool) {
+	if code == "" || codes == nil {
+		return 0, false
+	}
+	id, ok := codes[code]
+	return id, ok
+}
+
+//ImportOptions are the parameters of the purchase document which don't come from the invoice
+type ImportOptions struct {
+	SupplierID  int
+	WarehouseID int
+	//Type overrides PurchaseInvoiceType and PurchaseReturnType
+	Type string
+}
+
+//PurchaseDocumentFilters converts the invoice to the parameters of savePurchaseDocument. The document is saved
+//unconfirmed, so it can be reviewed before the stock is received. The lines without a product are added by
+//their name only and returned, a VAT percent without an active VAT rate is an error
+func (inv *PurchaseInvoice) PurchaseDocumentFilters(resolver *ProductResolver, vatRates sales.VatRates, opts ImportOptions) (map[string]string, []PurchaseLine, error) {
+	docType := opts.Type
+	if docType == "" {
+		docType = PurchaseInvoiceType
+		if inv.CreditNote {
+			docType = PurchaseReturnType
+		}
+	}
+
+	filters := map[string]string{
+		"type":        docType,
+		"supplierID":  strconv.Itoa(opts.SupplierID),
+		"warehouseID": strconv.Itoa(opts.WarehouseID),
+		"confirmed":   "0",
+		"no":          inv.Number,
+	}
+	if inv.IssueDate != "" {
+		filters["date"] = inv.IssueDate
+	}
+	if inv.Currency != "" {
+		filters["currencyCode"] = inv.Currency
+	}
+	if inv.Note != "" {
+		filters["notes"] = inv.Note
+	}
+
+	unresolved := []PurchaseLine{}
+	for i, l := range inv.Lines {
+		vatRateID, err := findVatRate(vatRates, l.VatPercent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %s: %w", l.ID, err)
+		}
+
+		suffix := strconv.Itoa(i + 1)
+		if productID, ok := resolver.Resolve(opts.SupplierID, l); ok {
+			filters["productID"+suffix] = strconv.Itoa(productID)
+		} else {
+			unresolved = append(unresolved, l)
+		}
+		filters["itemName"+suffix] = l.Name
+		filters["amount"+suffix] = l.Quantity.Trim().String()
+		filters["price"+suffix] = l.Price.Trim().String()
+		filters["vatrateID"+suffix] = vatRateID
+	}
+
+	return filters, unresolved, nil
+}
+
+//findVatRate returns the active VAT rate with the percent, the lowest ID is used when there are several
+func findVatRate(vatRates sales.VatRates, percent sharedCommon.Decimal) (string, error) {
+	ids := []int{}
+	for _, rate := range vatRates {
+		if rate.Active == "0" {
+			continue
+		}
+		value, err := sharedCommon.ParseDecimal(rate.Rate)
+		if err != nil || !value.Equal(percent) {
+			continue
+		}
+		id, err := strconv.Atoi(rate.ID)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("%w %s%%", ErrUnknownVatRate, percent.Trim())
+	}
+	sort.Ints(ids)
+	return strconv.Itoa(ids[0]), nil
+}
@@ -0,0 +1,237 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPurchaseInvoice = `<?xml version="1.0" encoding="UTF-8"?>
+<ubl:Invoice xmlns:ubl="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+    xmlns:a="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+    xmlns:b="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <b:CustomizationID>urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0</b:CustomizationID>
+  <b:ID>INV-500</b:ID>
+  <b:IssueDate>2022-07-01</b:IssueDate>
+  <b:InvoiceTypeCode>380</b:InvoiceTypeCode>
+  <b:Note>July delivery</b:Note>
+  <b:DocumentCurrencyCode>EUR</b:DocumentCurrencyCode>
+  <a:AccountingSupplierParty>
+    <a:Party>
+      <b:EndpointID schemeID="0088">4012345000009</b:EndpointID>
+      <a:PartyName><b:Name>Supply GmbH</b:Name></a:PartyName>
+      <a:PostalAddress>
+        <b:CityName>Berlin</b:CityName>
+        <a:Country><b:IdentificationCode>DE</b:IdentificationCode></a:Country>
+      </a:PostalAddress>
+      <a:PartyTaxScheme>
+        <b:CompanyID>DE 123 456 789</b:CompanyID>
+        <a:TaxScheme><b:ID>VAT</b:ID></a:TaxScheme>
+      </a:PartyTaxScheme>
+      <a:PartyLegalEntity><b:RegistrationName>Supply GmbH</b:RegistrationName></a:PartyLegalEntity>
+    </a:Party>
+  </a:AccountingSupplierParty>
+  <a:PaymentMeans>
+    <b:PaymentMeansCode>30</b:PaymentMeansCode>
+    <b:PaymentDueDate>2022-07-31</b:PaymentDueDate>
+  </a:PaymentMeans>
+  <a:TaxTotal><b:TaxAmount currencyID="EUR">22.80</b:TaxAmount></a:TaxTotal>
+  <a:TaxTotal><b:TaxAmount currencyID="SEK">240.00</b:TaxAmount></a:TaxTotal>
+  <a:LegalMonetaryTotal>
+    <b:LineExtensionAmount currencyID="EUR">120.00</b:LineExtensionAmount>
+    <b:TaxExclusiveAmount currencyID="EUR">120.00</b:TaxExclusiveAmount>
+    <b:TaxInclusiveAmount currencyID="EUR">142.80</b:TaxInclusiveAmount>
+    <b:PayableAmount currencyID="EUR">142.80</b:PayableAmount>
+  </a:LegalMonetaryTotal>
+  <a:InvoiceLine>
+    <b:ID>10</b:ID>
+    <b:InvoicedQuantity unitCode="KGM">4</b:InvoicedQuantity>
+    <b:LineExtensionAmount currencyID="EUR">90.00</b:LineExtensionAmount>
+    <a:AllowanceCharge>
+      <b:ChargeIndicator>false</b:ChargeIndicator>
+      <b:Amount currencyID="EUR">10.00</b:Amount>
+    </a:AllowanceCharge>
+    <a:Item>
+      <b:Name>Coffee beans</b:Name>
+      <a:SellersItemIdentification><b:ID>SUP-1</b:ID></a:SellersItemIdentification>
+      <a:ClassifiedTaxCategory>
+        <b:ID>S</b:ID>
+        <b:Percent>19</b:Percent>
+        <a:TaxScheme><b:ID>VAT</b:ID></a:TaxScheme>
+      </a:ClassifiedTaxCategory>
+    </a:Item>
+    <a:Price><b:PriceAmount currencyID="EUR">25.00</b:PriceAmount></a:Price>
+  </a:InvoiceLine>
+  <a:InvoiceLine>
+    <b:ID>20</b:ID>
+    <b:InvoicedQuantity unitCode="C62">2</b:InvoicedQuantity>
+    <b:LineExtensionAmount currencyID="EUR">20.00</b:LineExtensionAmount>
+    <a:Item>
+      <b:Name>Mug</b:Name>
+      <a:StandardItemIdentification><b:ID schemeID="0160">4006381333931</b:ID></a:StandardItemIdentification>
+      <a:ClassifiedTaxCategory><b:ID>S</b:ID><b:Percent>19</b:Percent></a:ClassifiedTaxCategory>
+    </a:Item>
+    <a:Price><b:PriceAmount currencyID="EUR">10.00</b:PriceAmount></a:Price>
+  </a:InvoiceLine>
+  <a:InvoiceLine>
+    <b:ID>30</b:ID>
+    <b:InvoicedQuantity unitCode="C62">1</b:InvoicedQuantity>
+    <b:LineExtensionAmount currencyID="EUR">10.00</b:LineExtensionAmount>
+    <a:Item>
+      <b:Name>Freight</b:Name>
+      <a:ClassifiedTaxCategory><b:ID>S</b:ID><b:Percent>19</b:Percent></a:ClassifiedTaxCategory>
+    </a:Item>
+    <a:Price><b:PriceAmount currencyID="EUR">10.00</b:PriceAmount></a:Price>
+  </a:InvoiceLine>
+</ubl:Invoice>`
+
+func TestParseInvoice(t *testing.T) {
+	invoice, err := ParseInvoice(strings.NewReader(testPurchaseInvoice))
+	assert.NoError(t, err)
+
+	assert.False(t, invoice.CreditNote)
+	assert.Equal(t, "INV-500", invoice.Number)
+	assert.Equal(t, "2022-07-01", invoice.IssueDate)
+	assert.Equal(t, "2022-07-31", invoice.DueDate)
+	assert.Equal(t, "EUR", invoice.Currency)
+	assert.Equal(t, "22.80", invoice.TaxAmount.String())
+	assert.Equal(t, "142.80", invoice.PayableAmount.String())
+	assert.Equal(t, Party{
+		Name:           "Supply GmbH",
+		VATNumber:      "DE 123 456 789",
+		GLN:            "4012345000009",
+		EndpointID:     "4012345000009",
+		EndpointScheme: "0088",
+		Address:        Address{City: "Berlin", Country: "DE"},
+	}, invoice.Supplier)
+
+	assert.Len(t, invoice.Lines, 3)
+	assert.Equal(t, "10|Coffee beans|SUP-1|||4|KGM|22.5|90|19", purchaseLineRecords(invoice.Lines)[0])
+	assert.Equal(t, "4006381333931", invoice.Lines[1].StandardItemID)
+}
+
+//purchaseLineRecords formats the lines for the comparison, the decimals with equal values can differ in the scale
+func purchaseLineRecords(lines []PurchaseLine) []string {
+	records := []string{}
+	for _, l := range lines {
+		records = append(records, strings.Join([]string{
+			l.ID, l.Name, l.SellersItemID, l.BuyersItemID, l.StandardItemID, l.Quantity.Trim().String(), l.UnitCode,
+			l.Price.Trim().String(), l.Amount.Trim().String(), l.VatPercent.Trim().String(),
+		}, "|"))
+	}
+	return records
+}
+
+func TestParseInvoiceRejectsOtherDocuments(t *testing.T) {
+	_, err := ParseInvoice(strings.NewReader(`<Order><ID>1</ID></Order>`))
+	assert.True(t, errors.Is(err, ErrNotUBL))
+
+	_, err = ParseInvoice(strings.NewReader(`not xml`))
+	assert.Error(t, err)
+}
+
+func TestFindSupplier(t *testing.T) {
+	suppliers := []customer.Supplier{
+		{SupplierId: 1, Code: "HRB 1", Gln: "4012345000009"},
+		{SupplierId: 2, VatNumber: "DE123456789"},
+	}
+
+	supplier, ok := FindSupplier(suppliers, Party{VATNumber: "DE 123 456 789", GLN: "4012345000009"})
+	assert.True(t, ok)
+	assert.Equal(t, uint(2), supplier.SupplierId)
+
+	supplier, ok = FindSupplier(suppliers, Party{GLN: "4012345000009"})
+	assert.True(t, ok)
+	assert.Equal(t, uint(1), supplier.SupplierId)
+
+	_, ok = FindSupplier(suppliers, Party{RegistrationCode: "HRB 2"})
+	assert.False(t, ok)
+}
+
+func TestPurchaseDocumentFilters(t *testing.T) {
+	invoice, err := ParseInvoice(strings.NewReader(testPurchaseInvoice))
+	assert.NoError(t, err)
+
+	resolver := NewProductResolver()
+	resolver.AddProducts(
+		product.Product{ProductID: 7, Code: "MUG", Code2: "4006381333931"},
+		product.Product{ProductID: 8, Code: "BEANS", SupplierCode: "SUP-1"},
+	)
+	resolver.AddSupplierPriceList(
+		price.PriceList{ID: 3, SupplierID: 2},
+		price.ProductsInSupplierPriceList{ProductID: 9, ProductSupplierCode: "SUP-1"},
+	)
+	vatRates := sales.VatRates{
+		{ID: "4", Rate: "19", Active: "0"},
+		{ID: "6", Rate: "19", Active: "1"},
+		{ID: "5", Rate: "19", Active: "1"},
+	}
+
+	filters, unresolved, err := invoice.PurchaseDocumentFilters(resolver, vatRates, ImportOptions{SupplierID: 2, WarehouseID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"type":         PurchaseInvoiceType,
+		"supplierID":   "2",
+		"warehouseID":  "1",
+		"confirmed":    "0",
+		"no":           "INV-500",
+		"date":         "2022-07-01",
+		"currencyCode": "EUR",
+		"notes":        "July delivery",
+		"productID1":   "9",
+		"itemName1":    "Coffee beans",
+		"amount1":      "4",
+		"price1":       "22.5",
+		"vatrateID1":   "5",
+		"productID2":   "7",
+		"itemName2":    "Mug",
+		"amount2":      "2",
+		"price2":       "10",
+		"vatrateID2":   "5",
+		"itemName3":    "Freight",
+		"amount3":      "1",
+		"price3":       "10",
+		"vatrateID3":   "5",
+	}, filters)
+	assert.Len(t, unresolved, 1)
+	assert.Equal(t, "Freight", unresolved[0].Name)
+
+	//another supplier doesn't have the price list, the supplier code of the product is used
+	filters, _, err = invoice.PurchaseDocumentFilters(resolver, vatRates, ImportOptions{SupplierID: 3, WarehouseID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "8", filters["productID1"])
+
+	invoice.CreditNote = true
+	filters, _, err = invoice.PurchaseDocumentFilters(resolver, vatRates, ImportOptions{SupplierID: 2, WarehouseID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, PurchaseReturnType, filters["type"])
+
+	_, _, err = invoice.PurchaseDocumentFilters(resolver, vatRates[:1], ImportOptions{SupplierID: 2, WarehouseID: 1})
+	assert.True(t, errors.Is(err, ErrUnknownVatRate))
+}
@@ -0,0 +1,178 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+var ErrUnknownSupplier = errors.New("supplier of the invoice is not found")
+
+//LoadDocument reads the sales document with its rows, the company info, the VAT rates, the payer
+//and the ship-to customer with their addresses
+func LoadDocument(ctx context.Context, salesAPI sales.Manager, companies company.Manager, customers customer.Manager, documentID int) (*Document, error) {
+	docs, err := salesAPI.GetSalesDocuments(ctx, map[string]string{
+		"id":                    strconv.Itoa(documentID),
+		"getRowsForAllInvoices": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("sales document %d is not found", documentID)
+	}
+	doc := docs[0]
+
+	info, err := companies.GetCompanyInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vatRates, err := salesAPI.GetVatRates(ctx, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	buyerID, recipientID := parties(doc)
+	buyer, err := loadCustomer(ctx, customers, buyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &Document{
+		Sale:     doc,
+		Seller:   CompanyParty(*info),
+		Buyer:    CustomerParty(buyer),
+		Account:  CompanyAccount(*info),
+		VatRates: vatRates,
+	}
+
+	if recipientID == buyerID && doc.ShipToAddressID == "" {
+		return document, nil
+	}
+	recipient := buyer
+	if recipientID != buyerID {
+		if recipient, err = loadCustomer(ctx, customers, recipientID); err != nil {
+			return nil, err
+		}
+	}
+	party := CustomerParty(recipient)
+	for _, address := range recipient.CustomerAddresses {
+		if strconv.Itoa(address.AddressID) == doc.ShipToAddressID {
+			party.Address = CustomerAddress(address)
+			break
+		}
+	}
+	document.Recipient = &party
+
+	return document, nil
+}
+
+//parties returns the payer and the recipient of the document
+func parties(doc sales.SaleDocument) (buyerID, recipientID int) {
+	recipientID = doc.CustomerID
+	if recipientID == 0 {
+		recipientID = doc.ClientID
+	}
+	buyerID = recipientID
+	if doc.PayerID != 0 {
+		buyerID = doc.PayerID
+	}
+	if doc.ShipToID != 0 {
+		recipientID = doc.ShipToID
+	}
+	return buyerID, recipientID
+}
+
+func loadCustomer(ctx context.Context, customers customer.Manager, customerID int) (customer.Customer, error) {
+	found, err := customers.GetCustomers(ctx, map[string]string{
+		"customerID":   strconv.Itoa(customerID),
+		"getAddresses": "1",
+	})
+	if err != nil {
+		return customer.Customer{}, err
+	}
+	if len(found) == 0 {
+		return customer.Customer{}, fmt.Errorf("customer %d is not found", customerID)
+	}
+	return found[0], nil
+}
+
+//LoadSupplier finds the supplier of the invoice among all suppliers
+func (inv *PurchaseInvoice) LoadSupplier(ctx context.Context, customers customer.Manager, settings sharedCommon.ListingSettings) (customer.Supplier, error) {
+	suppliers := []customer.Supplier{}
+	lister := sharedCommon.NewLister(settings, customer.NewSupplierListingDataProvider(customers), nil)
+	for item := range lister.Get(ctx, map[string]interface{}{}) {
+		if item.Err != nil {
+			return customer.Supplier{}, item.Err
+		}
+		suppliers = append(suppliers, item.Payload.(customer.Supplier))
+	}
+
+	supplier, ok := FindSupplier(suppliers, inv.Supplier)
+	if !ok {
+		return customer.Supplier{}, fmt.Errorf("%w: %s", ErrUnknownSupplier, inv.Supplier.Name)
+	}
+	return supplier, nil
+}
+
+//Load reads the products and the supplier price lists of the supplier
+func (r *ProductResolver) Load(ctx context.Context, products product.Manager, prices price.Manager, settings sharedCommon.ListingSettings, supplierID int) error {
+	productsLister := sharedCommon.NewLister(settings, product.NewListingDataProvider(products), nil)
+	for item := range productsLister.Get(ctx, map[string]interface{}{}) {
+		if item.Err != nil {
+			return item.Err
+		}
+		r.AddProducts(item.Payload.(product.Product))
+	}
+
+	priceLists := []price.PriceList{}
+	lister := sharedCommon.NewLister(settings, price.NewSupplierPriceListsListingDataProvider(prices), nil)
+	for item := range lister.Get(ctx, map[string]interface{}{"supplierID": supplierID}) {
+		if item.Err != nil {
+			return item.Err
+		}
+		priceLists = append(priceLists, item.Payload.(price.PriceList))
+	}
+
+	priceListProductsLister := sharedCommon.NewLister(settings, price.NewProductsInSupplierPriceListListingDataProvider(prices), nil)
+	for _, priceList := range priceLists {
+		listProducts := []price.ProductsInSupplierPriceList{}
+		for item := range priceListProductsLister.Get(ctx, map[string]interface{}{"supplierPriceListID": priceList.ID}) {
+			if item.Err != nil {
+				return item.Err
+			}
+			listProducts = append(listProducts, item.Payload.(price.ProductsInSupplierPriceList))
+		}
+		r.AddSupplierPriceList(priceList, listProducts...)
+	}
+
+	return nil
+}
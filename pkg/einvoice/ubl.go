@@ -0,0 +1,272 @@
+package einvoice
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "encoding/xml"
+
+//The element names carry the cbc/cac prefixes directly, encoding/xml writes them as they are
+//and the namespaces are declared on the root element
+
+const (
+	invoiceNamespace    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	creditNoteNamespace = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	cacNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	cbcNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	//CustomizationID identifies the Peppol BIS Billing 3.0 specification
+	CustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	//ProfileID is the Peppol billing business process
+	ProfileID = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+
+	InvoiceTypeCode    = "380"
+	CreditNoteTypeCode = "381"
+)
+
+type ublInvoice struct {
+	XMLName              xml.Name              `xml:"Invoice"`
+	Xmlns                string                `xml:"xmlns,attr"`
+	XmlnsCac             string                `xml:"xmlns:cac,attr"`
+	XmlnsCbc             string                `xml:"xmlns:cbc,attr"`
+	CustomizationID      string                `xml:"cbc:CustomizationID"`
+	ProfileID            string                `xml:"cbc:ProfileID"`
+	ID                   string                `xml:"cbc:ID"`
+	IssueDate            string                `xml:"cbc:IssueDate"`
+	DueDate              string                `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode      string                `xml:"cbc:InvoiceTypeCode"`
+	Note                 string                `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string                `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference       string                `xml:"cbc:BuyerReference,omitempty"`
+	BillingReference     []ublBillingReference `xml:"cac:BillingReference"`
+	Supplier             ublPartyWrapper       `xml:"cac:AccountingSupplierParty"`
+	Customer             ublPartyWrapper       `xml:"cac:AccountingCustomerParty"`
+	Delivery             *ublDelivery          `xml:"cac:Delivery"`
+	PaymentMeans         *ublPaymentMeans      `xml:"cac:PaymentMeans"`
+	PaymentTerms         *ublPaymentTerms      `xml:"cac:PaymentTerms"`
+	TaxTotal             ublTaxTotal           `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal   ublMonetaryTotal      `xml:"cac:LegalMonetaryTotal"`
+	Lines                []ublInvoiceLine      `xml:"cac:InvoiceLine"`
+}
+
+type ublCreditNote struct {
+	XMLName              xml.Name              `xml:"CreditNote"`
+	Xmlns                string                `xml:"xmlns,attr"`
+	XmlnsCac             string                `xml:"xmlns:cac,attr"`
+	XmlnsCbc             string                `xml:"xmlns:cbc,attr"`
+	CustomizationID      string                `xml:"cbc:CustomizationID"`
+	ProfileID            string                `xml:"cbc:ProfileID"`
+	ID                   string                `xml:"cbc:ID"`
+	IssueDate            string                `xml:"cbc:IssueDate"`
+	CreditNoteTypeCode   string                `xml:"cbc:CreditNoteTypeCode"`
+	Note                 string                `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string                `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference       string                `xml:"cbc:BuyerReference,omitempty"`
+	BillingReference     []ublBillingReference `xml:"cac:BillingReference"`
+	Supplier             ublPartyWrapper       `xml:"cac:AccountingSupplierParty"`
+	Customer             ublPartyWrapper       `xml:"cac:AccountingCustomerParty"`
+	Delivery             *ublDelivery          `xml:"cac:Delivery"`
+	PaymentMeans         *ublPaymentMeans      `xml:"cac:PaymentMeans"`
+	PaymentTerms         *ublPaymentTerms      `xml:"cac:PaymentTerms"`
+	TaxTotal             ublTaxTotal           `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal   ublMonetaryTotal      `xml:"cac:LegalMonetaryTotal"`
+	Lines                []ublCreditNoteLine   `xml:"cac:CreditNoteLine"`
+}
+
+type ublBillingReference struct {
+	InvoiceDocumentReference ublDocumentReference `xml:"cac:InvoiceDocumentReference"`
+}
+
+type ublDocumentReference struct {
+	ID        string `xml:"cbc:ID"`
+	IssueDate string `xml:"cbc:IssueDate,omitempty"`
+}
+
+type ublPartyWrapper struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublParty struct {
+	EndpointID  *ublIdentifier     `xml:"cbc:EndpointID"`
+	PartyName   *ublPartyName      `xml:"cac:PartyName"`
+	Address     ublAddress         `xml:"cac:PostalAddress"`
+	TaxScheme   *ublPartyTaxScheme `xml:"cac:PartyTaxScheme"`
+	LegalEntity ublLegalEntity     `xml:"cac:PartyLegalEntity"`
+	Contact     *ublContact        `xml:"cac:Contact"`
+}
+
+type ublIdentifier struct {
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type ublPartyName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type ublAddress struct {
+	StreetName           string     `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreetName string     `xml:"cbc:AdditionalStreetName,omitempty"`
+	CityName             string     `xml:"cbc:CityName,omitempty"`
+	PostalZone           string     `xml:"cbc:PostalZone,omitempty"`
+	CountrySubentity     string     `xml:"cbc:CountrySubentity,omitempty"`
+	Country              ublCountry `xml:"cac:Country"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type ublPartyTaxScheme struct {
+	CompanyID string       `xml:"cbc:CompanyID"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublTaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+}
+
+type ublContact struct {
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublDelivery struct {
+	ActualDeliveryDate string               `xml:"cbc:ActualDeliveryDate,omitempty"`
+	DeliveryLocation   *ublDeliveryLocation `xml:"cac:DeliveryLocation"`
+	DeliveryParty      *ublDeliveryParty    `xml:"cac:DeliveryParty"`
+}
+
+type ublDeliveryLocation struct {
+	Address ublAddress `xml:"cac:Address"`
+}
+
+type ublDeliveryParty struct {
+	PartyName ublPartyName `xml:"cac:PartyName"`
+}
+
+type ublPaymentMeans struct {
+	PaymentMeansCode string               `xml:"cbc:PaymentMeansCode"`
+	PaymentID        string               `xml:"cbc:PaymentID,omitempty"`
+	PayeeAccount     *ublFinancialAccount `xml:"cac:PayeeFinancialAccount"`
+}
+
+type ublFinancialAccount struct {
+	ID     string           `xml:"cbc:ID"`
+	Name   string           `xml:"cbc:Name,omitempty"`
+	Branch *ublBranchAccess `xml:"cac:FinancialInstitutionBranch"`
+}
+
+type ublBranchAccess struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublPaymentTerms struct {
+	Note string `xml:"cbc:Note"`
+}
+
+type ublAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxCategory struct {
+	ID                 string       `xml:"cbc:ID"`
+	Percent            string       `xml:"cbc:Percent,omitempty"`
+	TaxExemptionReason string       `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme          ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount   ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount    ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount    ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	PayableRoundingAmount *ublAmount `xml:"cbc:PayableRoundingAmount"`
+	PayableAmount         ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublInvoiceLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	InvoicedQuantity    ublQuantity `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	InvoicePeriod       *ublPeriod  `xml:"cac:InvoicePeriod"`
+	Item                ublItem     `xml:"cac:Item"`
+	Price               ublPrice    `xml:"cac:Price"`
+}
+
+type ublCreditNoteLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	CreditedQuantity    ublQuantity `xml:"cbc:CreditedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	InvoicePeriod       *ublPeriod  `xml:"cac:InvoicePeriod"`
+	Item                ublItem     `xml:"cac:Item"`
+	Price               ublPrice    `xml:"cac:Price"`
+}
+
+type ublPeriod struct {
+	StartDate string `xml:"cbc:StartDate,omitempty"`
+	EndDate   string `xml:"cbc:EndDate,omitempty"`
+}
+
+type ublItem struct {
+	Name                       string             `xml:"cbc:Name"`
+	SellersItemIdentification  *ublItemID         `xml:"cac:SellersItemIdentification"`
+	StandardItemIdentification *ublStandardItemID `xml:"cac:StandardItemIdentification"`
+	ClassifiedTaxCategory      ublTaxCategory     `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ublItemID struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublStandardItemID struct {
+	ID ublIdentifier `xml:"cbc:ID"`
+}
+
+type ublPrice struct {
+	PriceAmount     ublAmount           `xml:"cbc:PriceAmount"`
+	AllowanceCharge *ublAllowanceCharge `xml:"cac:AllowanceCharge"`
+}
+
+type ublAllowanceCharge struct {
+	ChargeIndicator bool      `xml:"cbc:ChargeIndicator"`
+	Amount          ublAmount `xml:"cbc:Amount"`
+	BaseAmount      ublAmount `xml:"cbc:BaseAmount"`
+}
@@ -0,0 +1,127 @@
+package export
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	v1 "github.com/bhojpur/erp/pkg/api/v1"
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//DataProviderFactory creates the listing data provider of an entity
+type DataProviderFactory func(cli *v1.Client) sharedCommon.DataProvider
+
+//Entities are the exportable entities by their names
+var Entities = map[string]DataProviderFactory{
+	"products": func(cli *v1.Client) sharedCommon.DataProvider {
+		return product.NewListingDataProvider(cli.ProductManager)
+	},
+	"customers": func(cli *v1.Client) sharedCommon.DataProvider {
+		return customer.NewCustomerListingDataProvider(cli.CustomerManager)
+	},
+	"suppliers": func(cli *v1.Client) sharedCommon.DataProvider {
+		return customer.NewSupplierListingDataProvider(cli.CustomerManager)
+	},
+	"sales-documents": func(cli *v1.Client) sharedCommon.DataProvider {
+		return sales.NewSaleDocumentsListingDataProvider(cli.SalesManager)
+	},
+	"payments": func(cli *v1.Client) sharedCommon.DataProvider {
+		return sales.NewPaymentsListingDataProvider(cli.SalesManager)
+	},
+	"price-lists": func(cli *v1.Client) sharedCommon.DataProvider {
+		return price.NewPriceListsListingDataProvider(cli.PricesManager)
+	},
+	"supplier-price-lists": func(cli *v1.Client) sharedCommon.DataProvider {
+		return price.NewSupplierPriceListsListingDataProvider(cli.PricesManager)
+	},
+	"warehouses": func(cli *v1.Client) sharedCommon.DataProvider {
+		return warehouse.NewListingDataProvider(cli.WarehouseManager)
+	},
+}
+
+//EntityNames gives the sorted names of the exportable entities
+func EntityNames() []string {
+	names := make([]string, 0, len(Entities))
+	for name := range Entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+//Export writes the items of the stream to out in the format, the columns are resolved from the selection and
+//the first record, see ResolveColumns. The records are written as they come, so the stream is never kept in memory.
+//An error item stops the export, the count of written records is returned in any case
+func Export(ctx context.Context, items sharedCommon.ItemsStream, format Format, out io.Writer, selectedColumns []string) (int, error) {
+	var writer RecordWriter
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		case item, ok := <-items:
+			if !ok {
+				if writer == nil {
+					//no records, still writing a valid empty file with the explicitly selected columns
+					var err error
+					writer, err = NewRecordWriter(format, out, ResolveColumns(selectedColumns, map[string]interface{}{}))
+					if err != nil {
+						return count, err
+					}
+				}
+				return count, writer.Close()
+			}
+			if item.Err != nil {
+				if writer != nil {
+					_ = writer.Close()
+				}
+				return count, item.Err
+			}
+
+			record, err := FlattenRecord(item.Payload)
+			if err != nil {
+				return count, fmt.Errorf("failed to flatten record %d: %v", count+1, err)
+			}
+
+			if writer == nil {
+				writer, err = NewRecordWriter(format, out, ResolveColumns(selectedColumns, record))
+				if err != nil {
+					return count, err
+				}
+			}
+
+			if err := writer.Write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package export
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const attributesKey = "attributes"
+const longAttributesKey = "longAttributes"
+
+//FlattenRecord converts an API model to a flat map with the json field names as keys, nested objects are joined with a dot
+//e.g. "deliveryAddress.street". The attributes and long attributes lists are flattened by the attribute names
+//e.g. "attributes.color", other lists are kept as json strings. Numbers are kept as json.Number
+func FlattenRecord(record interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	flat := map[string]interface{}{}
+	if obj, ok := decoded.(map[string]interface{}); ok {
+		flattenObject("", obj, flat)
+	} else {
+		flat["value"] = decoded
+	}
+
+	return flat, nil
+}
+
+func flattenObject(prefix string, obj map[string]interface{}, flat map[string]interface{}) {
+	for key, val := range obj {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+
+		switch typedVal := val.(type) {
+		case map[string]interface{}:
+			flattenObject(fullKey, typedVal, flat)
+		case []interface{}:
+			if (key == attributesKey || key == longAttributesKey) && flattenAttributes(fullKey, typedVal, flat) {
+				continue
+			}
+			raw, err := json.Marshal(typedVal)
+			if err != nil {
+				flat[fullKey] = nil
+				continue
+			}
+			flat[fullKey] = string(raw)
+		default:
+			flat[fullKey] = typedVal
+		}
+	}
+}
+
+func flattenAttributes(prefix string, attrs []interface{}, flat map[string]interface{}) bool {
+	for _, attr := range attrs {
+		attrObj, ok := attr.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := attrObj["attributeName"].(string); !ok {
+			return false
+		}
+	}
+
+	for _, attr := range attrs {
+		attrObj := attr.(map[string]interface{})
+		flat[prefix+"."+attrObj["attributeName"].(string)] = attrObj["attributeValue"]
+	}
+
+	return true
+}
+
+//ResolveColumns gives the list of columns for the selection: an empty selection means all keys of the record sorted
+//by name, a selected column ending with ".*" is expanded to all keys of the record with this prefix, e.g. "attributes.*"
+func ResolveColumns(selected []string, record map[string]interface{}) []string {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(selected) == 0 {
+		return keys
+	}
+
+	columns := make([]string, 0, len(selected))
+	seen := map[string]bool{}
+	for _, col := range selected {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			continue
+		}
+		if !strings.HasSuffix(col, ".*") {
+			if !seen[col] {
+				seen[col] = true
+				columns = append(columns, col)
+			}
+			continue
+		}
+
+		prefix := strings.TrimSuffix(col, "*")
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) && !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	return columns
+}
+
+//FormatValue converts a flattened value to the string representation used in the text based formats,
+//nil is represented as an empty string
+func FormatValue(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return typedVal
+	case json.Number:
+		return typedVal.String()
+	case bool:
+		if typedVal {
+			return "true"
+		}
+		return "false"
+	default:
+		raw, err := json.Marshal(typedVal)
+		if err != nil {
+			return ""
+		}
+		return string(raw)
+	}
+}
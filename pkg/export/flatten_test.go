@@ -0,0 +1,101 @@
+package export
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type addressMock struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type recordMock struct {
+	ID      int          `json:"id"`
+	Name    string       `json:"name"`
+	Active  bool         `json:"active"`
+	Price   float64      `json:"price"`
+	Address addressMock  `json:"address"`
+	Tags    []string     `json:"tags"`
+	Missing *addressMock `json:"missing"`
+	sharedCommon.Attributes
+}
+
+func TestFlattenRecord(t *testing.T) {
+	record := recordMock{
+		ID:      12,
+		Name:    "Chair",
+		Active:  true,
+		Price:   10.5,
+		Address: addressMock{Street: "Main 1", City: "Delhi"},
+		Tags:    []string{"a", "b"},
+		Attributes: sharedCommon.Attributes{
+			Attributes: []sharedCommon.ObjAttribute{
+				{AttributeName: "color", AttributeType: "text", AttributeValue: "red"},
+				{AttributeName: "size", AttributeType: "int", AttributeValue: "3"},
+			},
+		},
+	}
+
+	flat, err := FlattenRecord(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":               json.Number("12"),
+		"name":             "Chair",
+		"active":           true,
+		"price":            json.Number("10.5"),
+		"address.street":   "Main 1",
+		"address.city":     "Delhi",
+		"tags":             `["a","b"]`,
+		"missing":          nil,
+		"attributes.color": "red",
+		"attributes.size":  "3",
+	}, flat)
+}
+
+func TestResolveColumns(t *testing.T) {
+	record := map[string]interface{}{
+		"id":               1,
+		"name":             "Chair",
+		"attributes.color": "red",
+		"attributes.size":  "3",
+	}
+
+	assert.Equal(t, []string{"attributes.color", "attributes.size", "id", "name"}, ResolveColumns(nil, record))
+	assert.Equal(
+		t,
+		[]string{"name", "attributes.color", "attributes.size", "unknown"},
+		ResolveColumns([]string{"name", "attributes.*", "attributes.color", " unknown "}, record),
+	)
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "", FormatValue(nil))
+	assert.Equal(t, "abc", FormatValue("abc"))
+	assert.Equal(t, "1.25", FormatValue(json.Number("1.25")))
+	assert.Equal(t, "false", FormatValue(false))
+	assert.Equal(t, "3", FormatValue(3))
+}
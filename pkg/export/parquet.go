@@ -0,0 +1,365 @@
+package export
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+//DefaultParquetRowGroupSize is the count of rows kept in memory before they are written as a row group
+const DefaultParquetRowGroupSize = 10000
+
+var parquetMagic = []byte("PAR1")
+
+//parquet format enums, see parquet.thrift
+const (
+	parquetTypeByteArray       = 6
+	parquetRepetitionOptional  = 1
+	parquetConvertedTypeUTF8   = 0
+	parquetEncodingPlain       = 0
+	parquetEncodingRLE         = 3
+	parquetCodecUncompressed   = 0
+	parquetPageTypeDataPage    = 0
+	parquetFileMetaDataVersion = 1
+)
+
+//ParquetWriter writes the records as a parquet file where every column is an optional UTF8 string. The values are
+//kept in memory only till the row group is full, the file footer is written by Close
+type ParquetWriter struct {
+	w            *countingWriter
+	columns      []string
+	rowGroupSize int
+	values       [][][]byte
+	defined      [][]bool
+	rowsInGroup  int
+	totalRows    int64
+	rowGroups    []parquetRowGroup
+	err          error
+}
+
+type parquetRowGroup struct {
+	numRows       int64
+	totalByteSize int64
+	chunks        []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	offset    int64
+	numValues int64
+	size      int64
+}
+
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.written += int64(n)
+	return n, err
+}
+
+func NewParquetWriter(w io.Writer, columns []string, rowGroupSize int) *ParquetWriter {
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultParquetRowGroupSize
+	}
+	pw := &ParquetWriter{
+		w:            &countingWriter{w: w},
+		columns:      columns,
+		rowGroupSize: rowGroupSize,
+	}
+	pw.resetGroup()
+	_, pw.err = pw.w.Write(parquetMagic)
+
+	return pw
+}
+
+func (pw *ParquetWriter) resetGroup() {
+	pw.values = make([][][]byte, len(pw.columns))
+	pw.defined = make([][]bool, len(pw.columns))
+	pw.rowsInGroup = 0
+}
+
+func (pw *ParquetWriter) Write(record map[string]interface{}) error {
+	if pw.err != nil {
+		return pw.err
+	}
+
+	for i, col := range pw.columns {
+		val, ok := record[col]
+		if !ok || val == nil {
+			pw.defined[i] = append(pw.defined[i], false)
+			continue
+		}
+		pw.defined[i] = append(pw.defined[i], true)
+		pw.values[i] = append(pw.values[i], []byte(FormatValue(val)))
+	}
+	pw.rowsInGroup++
+
+	if pw.rowsInGroup >= pw.rowGroupSize {
+		pw.err = pw.flushGroup()
+	}
+
+	return pw.err
+}
+
+func (pw *ParquetWriter) flushGroup() error {
+	if pw.rowsInGroup == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{
+		numRows: int64(pw.rowsInGroup),
+		chunks:  make([]parquetColumnChunk, 0, len(pw.columns)),
+	}
+	for i := range pw.columns {
+		page := encodeParquetPage(pw.defined[i], pw.values[i])
+
+		header := newThriftWriter()
+		header.structBegin()
+		header.i32Field(1, parquetPageTypeDataPage)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structFieldBegin(5)
+		header.i32Field(1, int32(len(pw.defined[i])))
+		header.i32Field(2, parquetEncodingPlain)
+		header.i32Field(3, parquetEncodingRLE)
+		header.i32Field(4, parquetEncodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := parquetColumnChunk{
+			offset:    pw.w.written,
+			numValues: int64(len(pw.defined[i])),
+			size:      int64(header.buf.Len() + len(page)),
+		}
+		if _, err := pw.w.Write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := pw.w.Write(page); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.totalByteSize += chunk.size
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.totalRows += group.numRows
+	pw.resetGroup()
+
+	return nil
+}
+
+//encodeParquetPage gives the definition levels with the RLE encoding prefixed by their length
+//followed by the plain encoded defined values, there are no repetition levels for flat columns
+func encodeParquetPage(defined []bool, values [][]byte) []byte {
+	levels := &bytes.Buffer{}
+	for i := 0; i < len(defined); {
+		runLen := 1
+		for i+runLen < len(defined) && defined[i+runLen] == defined[i] {
+			runLen++
+		}
+		writeUvarint(levels, uint64(runLen)<<1)
+		if defined[i] {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		i += runLen
+	}
+
+	page := &bytes.Buffer{}
+	lenBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lenBuf, uint32(levels.Len()))
+	page.Write(lenBuf)
+	page.Write(levels.Bytes())
+	for _, val := range values {
+		binary.LittleEndian.PutUint32(lenBuf, uint32(len(val)))
+		page.Write(lenBuf)
+		page.Write(val)
+	}
+
+	return page.Bytes()
+}
+
+//Close writes the last row group and the file footer
+func (pw *ParquetWriter) Close() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if err := pw.flushGroup(); err != nil {
+		pw.err = err
+		return err
+	}
+
+	meta := newThriftWriter()
+	meta.structBegin()
+	meta.i32Field(1, parquetFileMetaDataVersion)
+
+	meta.listFieldBegin(2, thriftTypeStruct, len(pw.columns)+1)
+	meta.structBegin()
+	meta.binaryField(4, "schema")
+	meta.i32Field(5, int32(len(pw.columns)))
+	meta.structEnd()
+	for _, col := range pw.columns {
+		meta.structBegin()
+		meta.i32Field(1, parquetTypeByteArray)
+		meta.i32Field(3, parquetRepetitionOptional)
+		meta.binaryField(4, col)
+		meta.i32Field(6, parquetConvertedTypeUTF8)
+		meta.structEnd()
+	}
+
+	meta.i64Field(3, pw.totalRows)
+
+	meta.listFieldBegin(4, thriftTypeStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		meta.structBegin()
+		meta.listFieldBegin(1, thriftTypeStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			meta.structBegin()
+			meta.i64Field(2, chunk.offset)
+			meta.structFieldBegin(3)
+			meta.i32Field(1, parquetTypeByteArray)
+			meta.listFieldBegin(2, thriftTypeI32, 2)
+			meta.writeVarint(int64(parquetEncodingPlain))
+			meta.writeVarint(int64(parquetEncodingRLE))
+			meta.listFieldBegin(3, thriftTypeBinary, 1)
+			meta.writeBinary(pw.columns[i])
+			meta.i32Field(4, parquetCodecUncompressed)
+			meta.i64Field(5, chunk.numValues)
+			meta.i64Field(6, chunk.size)
+			meta.i64Field(7, chunk.size)
+			meta.i64Field(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64Field(2, group.totalByteSize)
+		meta.i64Field(3, group.numRows)
+		meta.structEnd()
+	}
+
+	meta.binaryField(6, "bhojpur erp export")
+	meta.structEnd()
+
+	if _, err := pw.w.Write(meta.buf.Bytes()); err != nil {
+		pw.err = err
+		return err
+	}
+	lenBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lenBuf, uint32(meta.buf.Len()))
+	if _, err := pw.w.Write(lenBuf); err != nil {
+		pw.err = err
+		return err
+	}
+	_, pw.err = pw.w.Write(parquetMagic)
+
+	return pw.err
+}
+
+//thrift compact protocol types
+const (
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeStruct = 12
+)
+
+//thriftWriter is the subset of the thrift compact protocol needed to write the parquet metadata
+type thriftWriter struct {
+	buf        *bytes.Buffer
+	lastFields []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{buf: &bytes.Buffer{}}
+}
+
+func (tw *thriftWriter) structBegin() {
+	tw.lastFields = append(tw.lastFields, 0)
+}
+
+func (tw *thriftWriter) structEnd() {
+	tw.buf.WriteByte(0)
+	tw.lastFields = tw.lastFields[:len(tw.lastFields)-1]
+}
+
+func (tw *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := &tw.lastFields[len(tw.lastFields)-1]
+	delta := id - *last
+	if delta > 0 && delta <= 15 {
+		tw.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		tw.buf.WriteByte(fieldType)
+		tw.writeVarint(int64(id))
+	}
+	*last = id
+}
+
+func (tw *thriftWriter) structFieldBegin(id int16) {
+	tw.fieldHeader(id, thriftTypeStruct)
+	tw.structBegin()
+}
+
+func (tw *thriftWriter) i32Field(id int16, val int32) {
+	tw.fieldHeader(id, thriftTypeI32)
+	tw.writeVarint(int64(val))
+}
+
+func (tw *thriftWriter) i64Field(id int16, val int64) {
+	tw.fieldHeader(id, thriftTypeI64)
+	tw.writeVarint(val)
+}
+
+func (tw *thriftWriter) binaryField(id int16, val string) {
+	tw.fieldHeader(id, thriftTypeBinary)
+	tw.writeBinary(val)
+}
+
+func (tw *thriftWriter) listFieldBegin(id int16, elemType byte, size int) {
+	tw.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		tw.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	tw.buf.WriteByte(0xf0 | elemType)
+	writeUvarint(tw.buf, uint64(size))
+}
+
+func (tw *thriftWriter) writeBinary(val string) {
+	writeUvarint(tw.buf, uint64(len(val)))
+	tw.buf.WriteString(val)
+}
+
+//writeVarint writes a zigzag encoded varint
+func (tw *thriftWriter) writeVarint(val int64) {
+	writeUvarint(tw.buf, uint64((val<<1)^(val>>63)))
+}
+
+func writeUvarint(buf *bytes.Buffer, val uint64) {
+	varintBuf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(varintBuf, val)
+	buf.Write(varintBuf[:n])
+}
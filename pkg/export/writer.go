@@ -0,0 +1,152 @@
+package export
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+//ParseFormat validates the format name, the names are case insensitive
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatParquet:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, supported formats are %s, %s and %s", name, FormatCSV, FormatNDJSON, FormatParquet)
+	}
+}
+
+//RecordWriter writes flattened records with a fixed list of columns, the values missing in a record are written as nulls
+type RecordWriter interface {
+	Write(record map[string]interface{}) error
+	//Close flushes the buffered data, it doesn't close the underlying writer
+	Close() error
+}
+
+//NewRecordWriter creates a writer for the format, the columns are written in the given order
+func NewRecordWriter(format Format, w io.Writer, columns []string) (RecordWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case FormatParquet:
+		return NewParquetWriter(w, columns, DefaultParquetRowGroupSize), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	row     []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{
+		w:       csv.NewWriter(w),
+		columns: columns,
+		row:     make([]string, len(columns)),
+	}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(record map[string]interface{}) error {
+	for i, col := range cw.columns {
+		cw.row[i] = FormatValue(record[col])
+	}
+
+	return cw.w.Write(cw.row)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func newNDJSONWriter(w io.Writer, columns []string) *ndjsonWriter {
+	return &ndjsonWriter{
+		w:       bufio.NewWriter(w),
+		columns: columns,
+	}
+}
+
+//Write writes the columns in the selected order, a map would be sorted by the encoder
+func (nw *ndjsonWriter) Write(record map[string]interface{}) error {
+	if err := nw.w.WriteByte('{'); err != nil {
+		return err
+	}
+	for i, col := range nw.columns {
+		if i > 0 {
+			if err := nw.w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		key, err := json.Marshal(col)
+		if err != nil {
+			return err
+		}
+		val, err := json.Marshal(record[col])
+		if err != nil {
+			return err
+		}
+		if _, err := nw.w.Write(key); err != nil {
+			return err
+		}
+		if err := nw.w.WriteByte(':'); err != nil {
+			return err
+		}
+		if _, err := nw.w.Write(val); err != nil {
+			return err
+		}
+	}
+	_, err := nw.w.WriteString("}\n")
+
+	return err
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.w.Flush()
+}
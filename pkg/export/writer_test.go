@@ -0,0 +1,158 @@
+package export
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" NDJSON ")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatNDJSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	writer, err := NewRecordWriter(FormatCSV, out, []string{"id", "name"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.Write(map[string]interface{}{"id": json.Number("1"), "name": "Chair, red"}))
+	assert.NoError(t, writer.Write(map[string]interface{}{"id": json.Number("2")}))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "id,name\n1,\"Chair, red\"\n2,\n", out.String())
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	writer, err := NewRecordWriter(FormatNDJSON, out, []string{"name", "id"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.Write(map[string]interface{}{"id": json.Number("1"), "name": "Chair", "other": "x"}))
+	assert.NoError(t, writer.Write(map[string]interface{}{"id": json.Number("2")}))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "{\"name\":\"Chair\",\"id\":1}\n{\"name\":null,\"id\":2}\n", out.String())
+}
+
+func TestParquetWriterLayout(t *testing.T) {
+	out := &bytes.Buffer{}
+	writer := NewParquetWriter(out, []string{"id", "name"}, 2)
+	for _, name := range []interface{}{"Chair", nil, "Table"} {
+		assert.NoError(t, writer.Write(map[string]interface{}{"id": json.Number("1"), "name": name}))
+	}
+	assert.NoError(t, writer.Close())
+
+	data := out.Bytes()
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	assert.Contains(t, string(footer), "schema")
+	assert.Contains(t, string(footer), "name")
+
+	//the first page of the name column: RLE definition levels (2 defined, 1 undefined in the first group)
+	//and the plain values of the defined rows only
+	assert.Contains(t, string(data), "\x05\x00\x00\x00Chair")
+	assert.Contains(t, string(data), "\x05\x00\x00\x00Table")
+	assert.Equal(t, 1, bytes.Count(data, []byte("Chair")))
+}
+
+func TestParquetFieldHeaderWithLongDelta(t *testing.T) {
+	tw := newThriftWriter()
+	tw.structBegin()
+	tw.i32Field(1, 1)
+	tw.i32Field(20, -1)
+	tw.structEnd()
+
+	assert.Equal(t, []byte{0x15, 0x02, 0x05, 0x28, 0x01, 0x00}, tw.buf.Bytes())
+}
+
+type payloadMock struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func streamOf(items ...sharedCommon.Item) sharedCommon.ItemsStream {
+	stream := make(sharedCommon.ItemsStream, len(items))
+	for _, item := range items {
+		stream <- item
+	}
+	close(stream)
+
+	return stream
+}
+
+func TestExport(t *testing.T) {
+	out := &bytes.Buffer{}
+	count, err := Export(
+		context.Background(),
+		streamOf(
+			sharedCommon.Item{Payload: payloadMock{ID: 1, Name: "Chair"}},
+			sharedCommon.Item{Payload: payloadMock{ID: 2, Name: "Table"}},
+		),
+		FormatCSV,
+		out,
+		[]string{"name"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "name\nChair\nTable\n", out.String())
+}
+
+func TestExportWithoutRecords(t *testing.T) {
+	out := &bytes.Buffer{}
+	count, err := Export(context.Background(), streamOf(), FormatCSV, out, []string{"id", "name"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, "id,name\n", out.String())
+}
+
+func TestExportStopsOnErrorItem(t *testing.T) {
+	out := &bytes.Buffer{}
+	count, err := Export(
+		context.Background(),
+		streamOf(
+			sharedCommon.Item{Payload: payloadMock{ID: 1, Name: "Chair"}},
+			sharedCommon.Item{Err: errors.New("some error")},
+			sharedCommon.Item{Payload: payloadMock{ID: 2, Name: "Table"}},
+		),
+		FormatNDJSON,
+		out,
+		nil,
+	)
+	assert.EqualError(t, err, "some error")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "{\"id\":1,\"name\":\"Chair\"}\n", out.String())
+}
@@ -0,0 +1,403 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//Values of Customer.IndiaCustomerType, they are matched case-insensitively
+const (
+	CustomerRegistered   = "REGISTERED"
+	CustomerUnregistered = "UNREGISTERED"
+	CustomerConsumer     = "CONSUMER"
+	CustomerSEZ          = "SEZ"
+	CustomerOverseas     = "OVERSEAS"
+	CustomerDeemedExport = "DEEMED_EXPORT"
+)
+
+var (
+	ErrMissingState   = errors.New("missing state")
+	ErrUnknownProduct = errors.New("product of the row is not loaded")
+	ErrUnknownVatRate = errors.New("unknown VAT rate")
+)
+
+//saleTypes are the outward supplies reported in GSTR-1, the credit invoices are the credit notes
+var saleTypes = map[string]bool{
+	sales.SaleDocumentTypeInvoice:       true,
+	sales.SaleDocumentTypeInvWayBill:    true,
+	sales.SaleDocumentTypeCASHINVOICE:   true,
+	sales.SaleDocumentTypeExportInvoice: true,
+	sales.SaleDocumentTypeCreditInvoice: true,
+}
+
+//unitCodes are the unique quantity codes of the common unit names
+var unitCodes = map[string]string{
+	"pcs":    "NOS",
+	"pc":     "NOS",
+	"tk":     "NOS",
+	"nos":    "NOS",
+	"no":     "NOS",
+	"piece":  "NOS",
+	"pieces": "NOS",
+	"kg":     "KGS",
+	"kgs":    "KGS",
+	"g":      "GMS",
+	"gms":    "GMS",
+	"l":      "LTR",
+	"ltr":    "LTR",
+	"litre":  "LTR",
+	"liter":  "LTR",
+	"ml":     "MLT",
+	"m":      "MTR",
+	"mtr":    "MTR",
+	"metre":  "MTR",
+	"meter":  "MTR",
+	"box":    "BOX",
+	"set":    "SET",
+	"pack":   "PAC",
+	"pac":    "PAC",
+}
+
+//Party is the supplier, the buyer or the ship-to party with the address used by the GST documents
+type Party struct {
+	GSTIN     string
+	LegalName string
+	TradeName string
+	Address1  string
+	Address2  string
+	Location  string
+	PIN       string
+	//State is the name or the two digit code of the state
+	State   string
+	Country string
+	Phone   string
+	Email   string
+}
+
+//StateCode returns the code of the state of the party, the state of the GSTIN is used when the address has no state
+func (p Party) StateCode() (string, error) {
+	if strings.TrimSpace(p.State) != "" {
+		return StateCode(p.State)
+	}
+	if ValidateGSTIN(p.GSTIN) == nil {
+		return GSTINState(p.GSTIN), nil
+	}
+	return "", fmt.Errorf("%w of %s", ErrMissingState, p.LegalName)
+}
+
+//CompanyParty returns the supplier dispatching the goods from the warehouse, the company
+//GSTIN of the warehouse is preferred as the company has a registration per state
+func CompanyParty(info company.Info, w warehouse.Warehouse) Party {
+	party := Party{
+		GSTIN:     info.VAT,
+		LegalName: info.Name,
+		Address1:  info.Address,
+		Country:   info.Country,
+		Phone:     info.Phone,
+		Email:     info.Email,
+		Address2:  w.Address2,
+		Location:  w.City,
+		PIN:       w.PINcode,
+		State:     w.State,
+	}
+	if w.CompanyVatNumber != "" {
+		party.GSTIN = w.CompanyVatNumber
+	}
+	if w.CompanyName != "" {
+		party.LegalName = w.CompanyName
+		party.TradeName = info.Name
+	}
+	if w.Street != "" {
+		party.Address1 = w.Street
+	} else if w.Address != "" {
+		party.Address1 = w.Address
+	}
+	if w.Country != "" {
+		party.Country = w.Country
+	}
+	if w.Phone != "" {
+		party.Phone = w.Phone
+	}
+	if w.Email != "" {
+		party.Email = w.Email
+	}
+	return party
+}
+
+//CustomerParty returns the buyer with the main address of the customer
+func CustomerParty(c customer.Customer) Party {
+	name := c.CompanyName
+	if name == "" {
+		name = c.FullName
+	}
+	street := c.Street
+	if street == "" {
+		street = c.Address
+	}
+	return Party{
+		GSTIN:     c.VatNumber,
+		LegalName: name,
+		Address1:  street,
+		Address2:  c.Address2,
+		Location:  c.City,
+		PIN:       c.PostalCode,
+		State:     c.State,
+		Country:   c.Country,
+		Phone:     c.Phone,
+		Email:     c.Email,
+	}
+}
+
+//WithAddress returns the party at another address of it, e.g. the ship-to address
+func (p Party) WithAddress(a sharedCommon.Address) Party {
+	p.Address1 = a.Street
+	p.Address2 = a.Address2
+	p.Location = a.City
+	p.PIN = a.PostalCode
+	p.State = a.State
+	p.Country = a.Country
+	return p
+}
+
+//Document is a sales document with the parties, the VAT rates and the products of its rows
+type Document struct {
+	Sale sales.SaleDocument
+	//Seller is the supplier at the address of the warehouse the goods are dispatched from
+	Seller Party
+	Buyer  Party
+	//ShipTo is the recipient of the goods, the place of supply is the state of the buyer when it's nil
+	ShipTo *Party
+	//CustomerType is the IndiaCustomerType of the buyer, it's guessed from the GSTIN and the country when empty
+	CustomerType string
+	VatRates     sales.VatRates
+	//Products are the products of the rows by their IDs, they carry the HSN codes
+	Products map[int]product.Product
+	//UnitCode is the unique quantity code of the rows of products with an unknown unit, OTH by default
+	UnitCode string
+	//WithPayment tells if IGST is paid on the exports and the SEZ supplies, otherwise they are made under a bond or LUT
+	WithPayment bool
+}
+
+//Line is a row of the document with the HSN code and the GST amounts in rupees
+type Line struct {
+	Number      int
+	ProductID   int
+	Description string
+	HSN         string
+	Service     bool
+	Quantity    float64
+	Unit        string
+	UnitPrice   float64
+	Gross       float64
+	Discount    float64
+	Taxable     float64
+	Rates       Rates
+	Amounts     Amounts
+	Total       float64
+}
+
+//IsCreditNote tells if the document is a credit invoice
+func (d *Document) IsCreditNote() bool {
+	return strings.EqualFold(d.Sale.Type, sales.SaleDocumentTypeCreditInvoice)
+}
+
+//BuyerType returns the normalized customer type of the buyer
+func (d *Document) BuyerType() string {
+	customerType := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(d.CustomerType), " ", "_"))
+	if customerType != "" {
+		return customerType
+	}
+	gstin := NormalizeGSTIN(d.Buyer.GSTIN)
+	if gstin != "" && gstin != UnregisteredPerson {
+		return CustomerRegistered
+	}
+	if country := strings.ToLower(strings.TrimSpace(d.Buyer.Country)); country != "" && country != "india" && country != "in" {
+		return CustomerOverseas
+	}
+	return CustomerUnregistered
+}
+
+//IsRegistered tells if the buyer has a GSTIN, the SEZ units and the deemed exports are registered too
+func (d *Document) IsRegistered() bool {
+	switch d.BuyerType() {
+	case CustomerRegistered, CustomerSEZ, CustomerDeemedExport:
+		return true
+	}
+	return false
+}
+
+//Origin returns the state code of the warehouse the goods are dispatched from
+func (d *Document) Origin() (string, error) {
+	return d.Seller.StateCode()
+}
+
+//PlaceOfSupply returns the state code of the recipient of the goods, 96 for the exports
+func (d *Document) PlaceOfSupply() (string, error) {
+	if d.BuyerType() == CustomerOverseas {
+		return ForeignCountry, nil
+	}
+	if d.ShipTo != nil && strings.TrimSpace(d.ShipTo.State) != "" {
+		return StateCode(d.ShipTo.State)
+	}
+	return d.Buyer.StateCode()
+}
+
+//SupplyType returns the supply type of the document, the exports and the supplies to SEZ are always inter-state
+func (d *Document) SupplyType() (SupplyType, error) {
+	switch d.BuyerType() {
+	case CustomerOverseas, CustomerSEZ:
+		return InterState, nil
+	}
+	origin, err := d.Origin()
+	if err != nil {
+		return "", fmt.Errorf("origin: %w", err)
+	}
+	pos, err := d.PlaceOfSupply()
+	if err != nil {
+		return "", fmt.Errorf("place of supply: %w", err)
+	}
+	return Supply(origin, pos)
+}
+
+//Lines converts the rows of the document to rupees and calculates their GST, the amounts of
+//the credit invoices are positive. The zero rated supplies without the payment of tax keep the rate
+//without the tax amounts
+func (d *Document) Lines() ([]Line, error) {
+	supply, err := d.SupplyType()
+	if err != nil {
+		return nil, fmt.Errorf("sales document %d: %w", d.Sale.ID, err)
+	}
+	currencyRate, err := d.Sale.CurrencyRateDecimal()
+	if err != nil {
+		return nil, fmt.Errorf("sales document %d: invalid currency rate %q", d.Sale.ID, d.Sale.CurrencyRate)
+	}
+	if currencyRate.IsZero() {
+		currencyRate = sharedCommon.DecimalFromInt(1)
+	}
+
+	lines := make([]Line, 0, len(d.Sale.InvoiceRows))
+	for i, row := range d.Sale.InvoiceRows {
+		line, err := d.line(row, supply, currencyRate)
+		if err != nil {
+			return nil, fmt.Errorf("sales document %d row %d: %w", d.Sale.ID, i+1, err)
+		}
+		line.Number = i + 1
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func (d *Document) line(row sales.InvoiceRow, supply SupplyType, currencyRate sharedCommon.Decimal) (Line, error) {
+	productID, _ := strconv.Atoi(row.ProductID)
+	p, ok := d.Products[productID]
+	if !ok {
+		return Line{}, fmt.Errorf("%w: %q", ErrUnknownProduct, row.ProductID)
+	}
+	hsn, err := ProductHSN(p)
+	if err != nil {
+		return Line{}, err
+	}
+
+	rate, err := d.vatRate(row.VatrateID)
+	if err != nil {
+		return Line{}, err
+	}
+	rates, err := RatesOf(rate, supply)
+	if err != nil {
+		return Line{}, err
+	}
+
+	quantity, err := row.AmountDecimal()
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid amount %q", row.Amount)
+	}
+	price, err := row.PriceDecimal()
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid price %q", row.Price)
+	}
+	unitPrice := price.Abs().Mul(currencyRate).Round(2)
+	taxable := row.RowNetTotalDecimal().Abs().Mul(currencyRate).Round(2)
+	gross := quantity.Abs().Mul(unitPrice).Round(2)
+
+	line := Line{
+		ProductID:   productID,
+		Description: row.ItemName,
+		HSN:         hsn,
+		Service:     IsService(hsn),
+		Quantity:    quantity.Abs().Float64(),
+		Unit:        d.unit(p),
+		UnitPrice:   unitPrice.Float64(),
+		Gross:       gross.Float64(),
+		Taxable:     taxable.Float64(),
+		Rates:       rates,
+	}
+	if discount := gross.Sub(taxable); discount.Sign() > 0 {
+		line.Discount = discount.Float64()
+	}
+	if !d.zeroRated() {
+		line.Amounts = rates.Amounts(line.Taxable)
+	}
+	line.Total = sharedCommon.RoundFloat(line.Taxable+line.Amounts.Total(), 2)
+
+	return line, nil
+}
+
+//zeroRated tells if the exports or the SEZ supplies are made under a bond or LUT without paying the tax
+func (d *Document) zeroRated() bool {
+	switch d.BuyerType() {
+	case CustomerOverseas, CustomerSEZ:
+		return !d.WithPayment
+	}
+	return false
+}
+
+func (d *Document) vatRate(id string) (sales.VatRate, error) {
+	for _, rate := range d.VatRates {
+		if rate.ID == id {
+			return rate, nil
+		}
+	}
+	return sales.VatRate{}, fmt.Errorf("%w %q", ErrUnknownVatRate, id)
+}
+
+func (d *Document) unit(p product.Product) string {
+	if p.UnitName != nil {
+		if code, ok := unitCodes[strings.ToLower(strings.TrimSpace(*p.UnitName))]; ok {
+			return code
+		}
+	}
+	if d.UnitCode != "" {
+		return d.UnitCode
+	}
+	return "OTH"
+}
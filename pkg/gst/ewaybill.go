@@ -0,0 +1,625 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//Names of the attributes tracking the e-way bill of the sales documents and the inventory transfers
+const (
+	EWayBillAttribute     = "eWayBillNo"
+	EWayBillDateAttribute = "eWayBillDate"
+)
+
+//DefaultEWayBillThreshold is the consignment value above which the movement of goods needs an e-way bill
+const DefaultEWayBillThreshold = 50000
+
+//eWayBillOtherCountry is the state code of the foreign parties in the e-way bills
+const eWayBillOtherCountry = 99
+
+var (
+	ErrBelowThreshold  = errors.New("consignment value does not exceed the e-way bill threshold")
+	ErrEWayBillExists  = errors.New("e-way bill is generated already")
+	ErrNotShippable    = errors.New("document does not move goods")
+	ErrInvalidEWayBill = errors.New("invalid e-way bill")
+)
+
+var (
+	eWayBillNumberPattern = regexp.MustCompile(`^[0-9]{12}$`)
+	eWayBillDocPattern    = regexp.MustCompile(`^[A-Za-z0-9/-]{1,16}$`)
+	vehiclePatterns       = []*regexp.Regexp{
+		regexp.MustCompile(`^[A-Z]{2}[0-9]{1,2}[A-Z]{0,3}[0-9]{4}$`),
+		regexp.MustCompile(`^TR[A-Z0-9]{6,13}$`),
+	}
+)
+
+//shippingTypes are the document type and the sub supply type of the sales documents moving goods
+var shippingTypes = map[string][2]string{
+	sales.SaleDocumentTypeInvWayBill:    {"INV", "1"},
+	sales.SaleDocumentTypeInvoice:       {"INV", "1"},
+	sales.SaleDocumentTypeCASHINVOICE:   {"INV", "1"},
+	sales.SaleDocumentTypeWayBill:       {"CHL", "1"},
+	sales.SaleDocumentTypeExportInvoice: {"INV", "3"},
+}
+
+//transportModes are the e-way bill transport modes by the keywords the words of the transport type names start with
+var transportModes = []struct {
+	keywords []string
+	mode     string
+}{
+	{[]string{"road", "truck", "lorry", "van", "tempo", "courier"}, "1"},
+	{[]string{"rail", "train"}, "2"},
+	{[]string{"air", "flight"}, "3"},
+	{[]string{"ship", "sea", "vessel"}, "4"},
+}
+
+//Transport are the details of the part B of the e-way bill
+type Transport struct {
+	//Mode is the name of the transport type, e.g. the TransportTypeName of the sales document,
+	//road, rail, air or ship
+	Mode          string
+	VehicleNumber string
+	//Distance is the approximate distance in kilometres, the portal calculates it from the PIN codes when it's 0
+	Distance        int
+	TransporterID   string
+	TransporterName string
+	//DocumentNumber and DocumentDate are the transport document, e.g. the railway receipt or the airway bill
+	DocumentNumber string
+	DocumentDate   time.Time
+}
+
+//EWayBillOptions are the transport and the threshold of the e-way bill
+type EWayBillOptions struct {
+	Transport Transport
+	//Threshold is the consignment value above which an e-way bill is needed, DefaultEWayBillThreshold when it's 0
+	Threshold float64
+	//Force builds the e-way bill of a consignment below the threshold too
+	Force bool
+}
+
+//EWayBill is the JSON of the e-way bill generation of the e-way bill portal
+type EWayBill struct {
+	SupplyType        string         `json:"supplyType"`
+	SubSupplyType     string         `json:"subSupplyType"`
+	SubSupplyDesc     string         `json:"subSupplyDesc,omitempty"`
+	DocType           string         `json:"docType"`
+	DocNo             string         `json:"docNo"`
+	DocDate           string         `json:"docDate"`
+	FromGstin         string         `json:"fromGstin"`
+	FromTrdName       string         `json:"fromTrdName"`
+	FromAddr1         string         `json:"fromAddr1"`
+	FromAddr2         string         `json:"fromAddr2"`
+	FromPlace         string         `json:"fromPlace"`
+	FromPincode       int            `json:"fromPincode"`
+	ActFromStateCode  int            `json:"actFromStateCode"`
+	FromStateCode     int            `json:"fromStateCode"`
+	ToGstin           string         `json:"toGstin"`
+	ToTrdName         string         `json:"toTrdName"`
+	ToAddr1           string         `json:"toAddr1"`
+	ToAddr2           string         `json:"toAddr2"`
+	ToPlace           string         `json:"toPlace"`
+	ToPincode         int            `json:"toPincode"`
+	ActToStateCode    int            `json:"actToStateCode"`
+	ToStateCode       int            `json:"toStateCode"`
+	TransactionType   int            `json:"transactionType"`
+	TotalValue        float64        `json:"totalValue"`
+	CgstValue         float64        `json:"cgstValue"`
+	SgstValue         float64        `json:"sgstValue"`
+	IgstValue         float64        `json:"igstValue"`
+	CessValue         float64        `json:"cessValue"`
+	CessNonAdvolValue float64        `json:"cessNonAdvolValue"`
+	OtherValue        float64        `json:"otherValue"`
+	TotInvValue       float64        `json:"totInvValue"`
+	TransporterID     string         `json:"transporterId"`
+	TransporterName   string         `json:"transporterName"`
+	TransDocNo        string         `json:"transDocNo"`
+	TransMode         string         `json:"transMode"`
+	TransDistance     string         `json:"transDistance"`
+	TransDocDate      string         `json:"transDocDate"`
+	VehicleNo         string         `json:"vehicleNo"`
+	VehicleType       string         `json:"vehicleType"`
+	ItemList          []EWayBillItem `json:"itemList"`
+}
+
+type EWayBillItem struct {
+	ProductName   string  `json:"productName"`
+	ProductDesc   string  `json:"productDesc"`
+	HsnCode       int     `json:"hsnCode"`
+	Quantity      float64 `json:"quantity"`
+	QtyUnit       string  `json:"qtyUnit"`
+	CgstRate      float64 `json:"cgstRate"`
+	SgstRate      float64 `json:"sgstRate"`
+	IgstRate      float64 `json:"igstRate"`
+	CessRate      float64 `json:"cessRate"`
+	CessNonadvol  float64 `json:"cessNonadvol"`
+	TaxableAmount float64 `json:"taxableAmount"`
+}
+
+//consignment is the header of an e-way bill before the parties and the lines are converted
+type consignment struct {
+	docType       string
+	subSupplyType string
+	subSupplyDesc string
+	number        string
+	date          string
+	from          Party
+	to            Party
+	shipTo        *Party
+	export        bool
+	lines         []Line
+	rounding      float64
+}
+
+//EWayBill builds the e-way bill of the goods shipped with the sales document, the transport mode
+//is the transport type of the document unless it's given in the options
+func (d *Document) EWayBill(opts EWayBillOptions) (*EWayBill, error) {
+	types, ok := shippingTypes[strings.ToUpper(d.Sale.Type)]
+	if !ok {
+		return nil, fmt.Errorf("sales document %d of type %s: %w", d.Sale.ID, d.Sale.Type, ErrNotShippable)
+	}
+	if number := EWayBillNumber(d.Sale.Attributes.Attributes); number != "" {
+		return nil, fmt.Errorf("sales document %d: %w with number %s", d.Sale.ID, ErrEWayBillExists, number)
+	}
+	lines, err := d.Lines()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Transport.Mode == "" {
+		opts.Transport.Mode = d.Sale.TransportTypeName
+	}
+
+	bill, err := newEWayBill(consignment{
+		docType:       types[0],
+		subSupplyType: types[1],
+		number:        d.Sale.Number,
+		date:          d.Sale.Date,
+		from:          d.Seller,
+		to:            d.Buyer,
+		shipTo:        d.ShipTo,
+		export:        d.BuyerType() == CustomerOverseas,
+		lines:         lines,
+		rounding:      d.Sale.Rounding,
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("sales document %d: %w", d.Sale.ID, err)
+	}
+	return bill, nil
+}
+
+//Transfer is an inventory transfer between the warehouses with the parties at their addresses
+type Transfer struct {
+	Transfer warehouse.InventoryTransfer
+	From     Party
+	To       Party
+	VatRates sales.VatRates
+	Products map[int]product.Product
+	//UnitCode is the unique quantity code of the rows of products with an unknown unit, OTH by default
+	UnitCode string
+}
+
+//EWayBill builds the e-way bill of the transfer as a delivery challan. The transfers within the GSTIN are
+//for own use without tax, the transfers between the GSTINs of the company carry GST by the VAT rates of the products
+func (t *Transfer) EWayBill(opts EWayBillOptions) (*EWayBill, error) {
+	if number := EWayBillNumber(t.Transfer.Attributes.Attributes); number != "" {
+		return nil, fmt.Errorf("inventory transfer %d: %w with number %s", t.Transfer.InventoryTransferID, ErrEWayBillExists, number)
+	}
+	lines, err := t.Lines()
+	if err != nil {
+		return nil, err
+	}
+
+	c := consignment{
+		docType:       "CHL",
+		subSupplyType: "5",
+		number:        strconv.Itoa(t.Transfer.InventoryTransferNo),
+		date:          t.Transfer.Date,
+		from:          t.From,
+		to:            t.To,
+		lines:         lines,
+	}
+	if t.Transfer.InventoryTransferNo == 0 {
+		c.number = strconv.Itoa(t.Transfer.InventoryTransferID)
+	}
+	if !t.sameGSTIN() {
+		c.subSupplyType = "8"
+		c.subSupplyDesc = "Stock transfer"
+	}
+
+	bill, err := newEWayBill(c, opts)
+	if err != nil {
+		return nil, fmt.Errorf("inventory transfer %d: %w", t.Transfer.InventoryTransferID, err)
+	}
+	return bill, nil
+}
+
+func (t *Transfer) sameGSTIN() bool {
+	return NormalizeGSTIN(t.From.GSTIN) == NormalizeGSTIN(t.To.GSTIN)
+}
+
+//Lines values the rows of the transfer at their prices, the tax is calculated only between the GSTINs
+func (t *Transfer) Lines() ([]Line, error) {
+	var supply SupplyType
+	if !t.sameGSTIN() {
+		from, err := t.From.StateCode()
+		if err != nil {
+			return nil, fmt.Errorf("inventory transfer %d: origin: %w", t.Transfer.InventoryTransferID, err)
+		}
+		to, err := t.To.StateCode()
+		if err != nil {
+			return nil, fmt.Errorf("inventory transfer %d: destination: %w", t.Transfer.InventoryTransferID, err)
+		}
+		if supply, err = Supply(from, to); err != nil {
+			return nil, fmt.Errorf("inventory transfer %d: %w", t.Transfer.InventoryTransferID, err)
+		}
+	}
+
+	doc := &Document{VatRates: t.VatRates, UnitCode: t.UnitCode}
+	lines := make([]Line, 0, len(t.Transfer.Rows))
+	for i, row := range t.Transfer.Rows {
+		line, err := t.line(doc, row, supply)
+		if err != nil {
+			return nil, fmt.Errorf("inventory transfer %d row %d: %w", t.Transfer.InventoryTransferID, i+1, err)
+		}
+		line.Number = i + 1
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func (t *Transfer) line(doc *Document, row warehouse.InventoryDocumentRow, supply SupplyType) (Line, error) {
+	p, ok := t.Products[row.ProductID]
+	if !ok {
+		return Line{}, fmt.Errorf("%w: %d", ErrUnknownProduct, row.ProductID)
+	}
+	hsn, err := ProductHSN(p)
+	if err != nil {
+		return Line{}, err
+	}
+	quantity, err := sharedCommon.ParseDecimal(row.Amount.String())
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid amount %q", row.Amount)
+	}
+	price, err := sharedCommon.ParseDecimal(row.Price.String())
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid price %q", row.Price)
+	}
+
+	line := Line{
+		ProductID:   p.ProductID,
+		Description: p.Name,
+		HSN:         hsn,
+		Service:     IsService(hsn),
+		Quantity:    quantity.Float64(),
+		Unit:        doc.unit(p),
+		UnitPrice:   price.Float64(),
+		Gross:       quantity.Mul(price).Round(2).Float64(),
+	}
+	line.Taxable = line.Gross
+	if supply != "" {
+		rate, err := doc.vatRate(strconv.FormatUint(p.VatrateID, 10))
+		if err != nil {
+			return Line{}, err
+		}
+		if line.Rates, err = RatesOf(rate, supply); err != nil {
+			return Line{}, err
+		}
+		line.Amounts = line.Rates.Amounts(line.Taxable)
+	}
+	line.Total = sharedCommon.RoundFloat(line.Taxable+line.Amounts.Total(), 2)
+
+	return line, nil
+}
+
+func newEWayBill(c consignment, opts EWayBillOptions) (*EWayBill, error) {
+	date, err := time.Parse(sharedCommon.DateLayout, c.date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", c.date)
+	}
+	mode, err := TransportMode(opts.Transport.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if mode == "" && opts.Transport.VehicleNumber != "" {
+		mode = "1"
+	}
+
+	bill := &EWayBill{
+		SupplyType:      "O",
+		SubSupplyType:   c.subSupplyType,
+		SubSupplyDesc:   c.subSupplyDesc,
+		DocType:         c.docType,
+		DocNo:           c.number,
+		DocDate:         date.Format("02/01/2006"),
+		TransactionType: 1,
+		TransporterID:   NormalizeGSTIN(opts.Transport.TransporterID),
+		TransporterName: opts.Transport.TransporterName,
+		TransDocNo:      opts.Transport.DocumentNumber,
+		TransMode:       mode,
+		TransDistance:   strconv.Itoa(opts.Transport.Distance),
+		VehicleNo:       NormalizeVehicleNumber(opts.Transport.VehicleNumber),
+	}
+	if !opts.Transport.DocumentDate.IsZero() {
+		bill.TransDocDate = opts.Transport.DocumentDate.Format("02/01/2006")
+	}
+	if bill.VehicleNo != "" {
+		bill.VehicleType = "R"
+	}
+
+	bill.FromGstin = NormalizeGSTIN(c.from.GSTIN)
+	bill.FromTrdName = c.from.LegalName
+	bill.FromAddr1, bill.FromAddr2, bill.FromPlace = c.from.Address1, c.from.Address2, c.from.Location
+	if bill.FromPincode, err = pinCode(c.from); err != nil {
+		return nil, fmt.Errorf("origin: %w", err)
+	}
+	if bill.FromStateCode, err = stateNumber(c.from); err != nil {
+		return nil, fmt.Errorf("origin: %w", err)
+	}
+	bill.ActFromStateCode = bill.FromStateCode
+
+	bill.ToGstin = NormalizeGSTIN(c.to.GSTIN)
+	if bill.ToGstin == "" || c.export {
+		bill.ToGstin = UnregisteredPerson
+	}
+	bill.ToTrdName = c.to.LegalName
+	destination := c.to
+	if c.export && c.shipTo == nil {
+		return nil, fmt.Errorf("%w: the ship-to address of an export is the port of export", ErrMissingState)
+	}
+	if c.shipTo != nil {
+		destination = *c.shipTo
+		if destination.Address1 != c.to.Address1 || destination.Location != c.to.Location || destination.PIN != c.to.PIN {
+			bill.TransactionType = 2
+		}
+	}
+	bill.ToAddr1, bill.ToAddr2, bill.ToPlace = destination.Address1, destination.Address2, destination.Location
+	if bill.ToPincode, err = pinCode(destination); err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+	if bill.ActToStateCode, err = stateNumber(destination); err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+	bill.ToStateCode = bill.ActToStateCode
+	if c.export {
+		bill.ToStateCode = eWayBillOtherCountry
+	} else if c.shipTo != nil {
+		if bill.ToStateCode, err = stateNumber(c.to); err != nil {
+			return nil, fmt.Errorf("buyer: %w", err)
+		}
+	}
+
+	for _, line := range c.lines {
+		hsn, _ := strconv.Atoi(line.HSN)
+		bill.ItemList = append(bill.ItemList, EWayBillItem{
+			ProductName:   line.Description,
+			ProductDesc:   line.Description,
+			HsnCode:       hsn,
+			Quantity:      line.Quantity,
+			QtyUnit:       line.Unit,
+			CgstRate:      line.Rates.CGST,
+			SgstRate:      line.Rates.SGST,
+			IgstRate:      line.Rates.IGST,
+			CessRate:      line.Rates.Cess,
+			TaxableAmount: line.Taxable,
+		})
+		bill.TotalValue += line.Taxable
+		bill.CgstValue += line.Amounts.CGST
+		bill.SgstValue += line.Amounts.SGST
+		bill.IgstValue += line.Amounts.IGST
+		bill.CessValue += line.Amounts.Cess
+		bill.TotInvValue += line.Total
+	}
+	bill.TotalValue = sharedCommon.RoundFloat(bill.TotalValue, 2)
+	bill.CgstValue = sharedCommon.RoundFloat(bill.CgstValue, 2)
+	bill.SgstValue = sharedCommon.RoundFloat(bill.SgstValue, 2)
+	bill.IgstValue = sharedCommon.RoundFloat(bill.IgstValue, 2)
+	bill.CessValue = sharedCommon.RoundFloat(bill.CessValue, 2)
+	bill.OtherValue = sharedCommon.RoundFloat(c.rounding, 2)
+	bill.TotInvValue = sharedCommon.RoundFloat(bill.TotInvValue+bill.OtherValue, 2)
+
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = DefaultEWayBillThreshold
+	}
+	if bill.TotInvValue <= threshold && !opts.Force {
+		return nil, fmt.Errorf("%w: %.2f is not above %.2f", ErrBelowThreshold, bill.TotInvValue, threshold)
+	}
+
+	if err := bill.Validate(); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+//TransportMode returns the e-way bill transport mode of the transport type name, 1 road, 2 rail, 3 air or 4 ship.
+//The mode is empty when the name is empty
+func TransportMode(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", nil
+	}
+	if name == "1" || name == "2" || name == "3" || name == "4" {
+		return name, nil
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, transportMode := range transportModes {
+		for _, keyword := range transportMode.keywords {
+			for _, word := range words {
+				if strings.HasPrefix(word, keyword) {
+					return transportMode.mode, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: unknown transport type %q, expected road, rail, air or ship", ErrInvalidEWayBill, name)
+}
+
+//NormalizeVehicleNumber removes the spaces and the dashes and upper cases the vehicle number
+func NormalizeVehicleNumber(number string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(number))
+}
+
+//Validate checks the e-way bill against the rules of the portal
+func (bill *EWayBill) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(eWayBillDocPattern.MatchString(bill.DocNo), "docNo %q should have up to 16 letters, digits, / or -", bill.DocNo)
+	if err := ValidateGSTIN(bill.FromGstin); err != nil {
+		problems = append(problems, fmt.Sprintf("fromGstin: %v", err))
+	}
+	if bill.ToGstin != UnregisteredPerson {
+		if err := ValidateGSTIN(bill.ToGstin); err != nil {
+			problems = append(problems, fmt.Sprintf("toGstin: %v", err))
+		}
+	}
+	check(bill.FromPincode >= 100000 && bill.FromPincode <= 999999, "fromPincode %d should have 6 digits", bill.FromPincode)
+	check(bill.ToPincode >= 100000 && bill.ToPincode <= 999999, "toPincode %d should have 6 digits", bill.ToPincode)
+	check(len(bill.ItemList) > 0 && len(bill.ItemList) <= 250, "itemList should have 1 to 250 items")
+	for i, item := range bill.ItemList {
+		check(ValidateHSN(strconv.Itoa(item.HsnCode)) == nil, "itemList[%d].hsnCode %d is not an HSN/SAC code", i, item.HsnCode)
+	}
+
+	distance, _ := strconv.Atoi(bill.TransDistance)
+	check(distance >= 0 && distance <= 4000, "transDistance %s should be 0 to 4000 km", bill.TransDistance)
+	if bill.TransporterID != "" {
+		if err := ValidateGSTIN(bill.TransporterID); err != nil {
+			problems = append(problems, fmt.Sprintf("transporterId: %v", err))
+		}
+	}
+	switch {
+	case bill.TransMode == "" && bill.TransporterID == "":
+		problems = append(problems, "transporterId is required when the part B is left for the transporter")
+	case bill.TransMode == "1" && bill.VehicleNo == "" && bill.TransporterID == "":
+		problems = append(problems, "vehicleNo or transporterId is required for road transport")
+	case bill.TransMode != "" && bill.TransMode != "1" && (bill.TransDocNo == "" || bill.TransDocDate == ""):
+		problems = append(problems, "transDocNo and transDocDate are required for rail, air and ship transport")
+	}
+	if bill.VehicleNo != "" {
+		valid := false
+		for _, pattern := range vehiclePatterns {
+			valid = valid || pattern.MatchString(bill.VehicleNo)
+		}
+		check(valid, "vehicleNo %q is not a vehicle registration number", bill.VehicleNo)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidEWayBill, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+//WriteJSON writes the e-way bill as indented JSON
+func (bill *EWayBill) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bill)
+}
+
+//EWayBillNumber returns the e-way bill number tracked in the attributes of a document
+func EWayBillNumber(attributes []sharedCommon.ObjAttribute) string {
+	for _, attribute := range attributes {
+		if attribute.AttributeName == EWayBillAttribute {
+			return strings.TrimSpace(attribute.AttributeValue)
+		}
+	}
+	return ""
+}
+
+//ValidateEWayBillNumber checks the 12 digits of the e-way bill number
+func ValidateEWayBillNumber(number string) error {
+	if !eWayBillNumberPattern.MatchString(number) {
+		return fmt.Errorf("%w number %q: expected 12 digits", ErrInvalidEWayBill, number)
+	}
+	return nil
+}
+
+//SaveSaleEWayBill tracks the e-way bill number and its date on the sales document
+func SaveSaleEWayBill(ctx context.Context, docs sales.DocumentManager, documentID int, number string, date time.Time) error {
+	filters, err := eWayBillFilters(number, date)
+	if err != nil {
+		return err
+	}
+	filters["id"] = strconv.Itoa(documentID)
+	_, err = docs.SaveSalesDocument(ctx, filters)
+	return err
+}
+
+//SaveTransferEWayBill tracks the e-way bill number and its date on the inventory transfer
+func SaveTransferEWayBill(ctx context.Context, inventory warehouse.InventoryManager, transferID int, number string, date time.Time) error {
+	filters, err := eWayBillFilters(number, date)
+	if err != nil {
+		return err
+	}
+	filters["inventoryTransferID"] = strconv.Itoa(transferID)
+	_, err = inventory.SaveInventoryTransfer(ctx, filters)
+	return err
+}
+
+func eWayBillFilters(number string, date time.Time) (map[string]string, error) {
+	if err := ValidateEWayBillNumber(number); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"attributeName1":  EWayBillAttribute,
+		"attributeType1":  "text",
+		"attributeValue1": number,
+		"attributeName2":  EWayBillDateAttribute,
+		"attributeType2":  "text",
+		"attributeValue2": date.Format(sharedCommon.DateLayout),
+	}, nil
+}
+
+func pinCode(p Party) (int, error) {
+	value, err := strconv.Atoi(strings.ReplaceAll(p.PIN, " ", ""))
+	if err != nil {
+		return 0, fmt.Errorf("invalid PIN code %q of %s", p.PIN, p.LegalName)
+	}
+	return value, nil
+}
+
+func stateNumber(p Party) (int, error) {
+	code, err := p.StateCode()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(code)
+}
@@ -0,0 +1,286 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTransport = Transport{VehicleNumber: "ka 01 ab-1234", Distance: 350}
+
+//testWaybill is an inter-state waybill billed to Bengaluru and shipped to Chennai
+func testWaybill() *Document {
+	doc := testDocument()
+	doc.Sale.Type = sales.SaleDocumentTypeInvWayBill
+	doc.Sale.TransportTypeName = "Truck"
+	doc.Sale.InvoiceRows = append(doc.Sale.InvoiceRows,
+		sales.InvoiceRow{ProductID: "3", ItemName: "Car", VatrateID: "3", Amount: "1", Price: "500000", RowNetTotal: 500000})
+	doc.ShipTo = &Party{
+		GSTIN:     "33AAACB1234C1ZM",
+		LegalName: "Tirupati Traders Chennai",
+		Address1:  "8 Anna Salai",
+		Location:  "Chennai",
+		PIN:       "600002",
+		State:     "Tamil Nadu",
+	}
+	return doc
+}
+
+func testTransfer(toGSTIN, toState, toPIN string) *Transfer {
+	to := testSeller
+	to.GSTIN = toGSTIN
+	to.Address1 = "3 Industrial Area"
+	to.State = toState
+	to.PIN = toPIN
+	car := testProducts[3]
+	car.Name = "Car"
+	car.VatrateID = 3
+	return &Transfer{
+		Transfer: warehouse.InventoryTransfer{
+			InventoryTransferID: 7,
+			InventoryTransferNo: 17,
+			WarehouseFromID:     1,
+			WarehouseToID:       2,
+			Date:                "2022-07-18",
+			Rows:                []warehouse.InventoryDocumentRow{{ProductID: 3, Amount: "1", Price: "400000"}},
+		},
+		From:     testSeller,
+		To:       to,
+		VatRates: testVatRates,
+		Products: map[int]product.Product{3: car},
+	}
+}
+
+func TestSaleEWayBill(t *testing.T) {
+	bill, err := testWaybill().EWayBill(EWayBillOptions{Transport: testTransport})
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, bill.WriteJSON(buf))
+	assert.JSONEq(t, string(readFixture(t, "ewaybill.json")), buf.String())
+}
+
+func TestSaleEWayBillErrors(t *testing.T) {
+	_, err := testDocument().EWayBill(EWayBillOptions{Transport: testTransport})
+	assert.True(t, errors.Is(err, ErrBelowThreshold))
+	assert.EqualError(t, err, "sales document 100: consignment value does not exceed the e-way bill threshold: 23010.30 is not above 50000.00")
+
+	bill, err := testDocument().EWayBill(EWayBillOptions{Transport: testTransport, Threshold: 20000})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bill.TransactionType)
+	assert.Equal(t, "1", bill.TransMode)
+	assert.Equal(t, 29, bill.ToStateCode)
+
+	doc := testWaybill()
+	doc.Sale.Attributes.Attributes = []sharedCommon.ObjAttribute{{AttributeName: EWayBillAttribute, AttributeValue: "331001234567"}}
+	_, err = doc.EWayBill(EWayBillOptions{Transport: testTransport})
+	assert.True(t, errors.Is(err, ErrEWayBillExists))
+
+	doc = testWaybill()
+	doc.Sale.Type = sales.SaleDocumentTypeCreditInvoice
+	_, err = doc.EWayBill(EWayBillOptions{Transport: testTransport})
+	assert.True(t, errors.Is(err, ErrNotShippable))
+
+	testCases := []struct {
+		transport Transport
+		message   string
+	}{
+		{Transport{Mode: "Rail", Distance: 5000}, "sales document 100: invalid e-way bill: transDistance 5000 should be 0 to 4000 km; " +
+			"transDocNo and transDocDate are required for rail, air and ship transport"},
+		{Transport{VehicleNumber: "KA-1234"}, `sales document 100: invalid e-way bill: vehicleNo "KA1234" is not a vehicle registration number`},
+		{Transport{Mode: "Own"}, `sales document 100: invalid e-way bill: unknown transport type "own", expected road, rail, air or ship`},
+		{Transport{Mode: "-"}, `sales document 100: invalid e-way bill: unknown transport type "-", expected road, rail, air or ship`},
+	}
+	for _, testCase := range testCases {
+		doc := testWaybill()
+		doc.Sale.TransportTypeName = ""
+		_, err := doc.EWayBill(EWayBillOptions{Transport: testCase.transport})
+		assert.True(t, errors.Is(err, ErrInvalidEWayBill))
+		assert.EqualError(t, err, testCase.message)
+	}
+
+	doc = testWaybill()
+	doc.Sale.TransportTypeName = ""
+	_, err = doc.EWayBill(EWayBillOptions{})
+	assert.EqualError(t, err, "sales document 100: invalid e-way bill: transporterId is required when the part B is left for the transporter")
+
+	bill, err = doc.EWayBill(EWayBillOptions{Transport: Transport{
+		Mode:           "Air cargo",
+		DocumentNumber: "AWB-1",
+		DocumentDate:   time.Date(2022, 7, 16, 0, 0, 0, 0, time.UTC),
+		TransporterID:  "27aapfu0939f1zv",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", bill.TransMode)
+	assert.Equal(t, "16/07/2022", bill.TransDocDate)
+	assert.Equal(t, "27AAPFU0939F1ZV", bill.TransporterID)
+}
+
+func TestTransferEWayBill(t *testing.T) {
+	bill, err := testTransfer(testSeller.GSTIN, "Karnataka", "562123").EWayBill(EWayBillOptions{Transport: Transport{Mode: "road", VehicleNumber: "KA01AB1234"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "CHL", bill.DocType)
+	assert.Equal(t, "17", bill.DocNo)
+	assert.Equal(t, "5", bill.SubSupplyType)
+	assert.Equal(t, "", bill.SubSupplyDesc)
+	assert.Equal(t, 562123, bill.ToPincode)
+	assert.Equal(t, []EWayBillItem{{ProductName: "Car", ProductDesc: "Car", HsnCode: 87032291, Quantity: 1, QtyUnit: "NOS", TaxableAmount: 400000}}, bill.ItemList)
+	assert.Equal(t, 400000.0, bill.TotInvValue)
+	assert.Equal(t, 0.0, bill.IgstValue)
+
+	bill, err = testTransfer("27AAPFU0939F1ZV", "Maharashtra", "400001").EWayBill(EWayBillOptions{Transport: Transport{Mode: "road", VehicleNumber: "KA01AB1234"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "8", bill.SubSupplyType)
+	assert.Equal(t, "Stock transfer", bill.SubSupplyDesc)
+	assert.Equal(t, 27, bill.ToStateCode)
+	assert.Equal(t, []EWayBillItem{{
+		ProductName: "Car", ProductDesc: "Car", HsnCode: 87032291, Quantity: 1, QtyUnit: "NOS", IgstRate: 28, CessRate: 12, TaxableAmount: 400000,
+	}}, bill.ItemList)
+	assert.Equal(t, 112000.0, bill.IgstValue)
+	assert.Equal(t, 48000.0, bill.CessValue)
+	assert.Equal(t, 560000.0, bill.TotInvValue)
+
+	transfer := testTransfer(testSeller.GSTIN, "Karnataka", "562123")
+	transfer.Transfer.Attributes.Attributes = []sharedCommon.ObjAttribute{{AttributeName: EWayBillAttribute, AttributeValue: "331001234567"}}
+	_, err = transfer.EWayBill(EWayBillOptions{})
+	assert.EqualError(t, err, "inventory transfer 7: e-way bill is generated already with number 331001234567")
+}
+
+func TestTransportMode(t *testing.T) {
+	testCases := map[string]string{"": "", "Road": "1", "Courier van": "1", "Railways": "2", "2": "2", "AIR": "3", "Sea freight": "4", "Air cargo": "3"}
+	for name, expected := range testCases {
+		mode, err := TransportMode(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, mode, name)
+	}
+}
+
+func TestSaveEWayBill(t *testing.T) {
+	forms := map[string]url.Values{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		forms[r.Form.Get("request")] = r.Form
+		var resp interface{}
+		switch r.Form.Get("request") {
+		case "saveSalesDocument":
+			resp = sales.PostSalesDocumentResponse{
+				Status:        sharedCommon.Status{ResponseStatus: "ok"},
+				ImportReports: sales.SaleDocImportReports{{InvoiceID: "100"}},
+			}
+		case "saveInventoryTransfer":
+			resp = warehouse.SaveInventoryTransferResponse{
+				Status:  sharedCommon.Status{ResponseStatus: "ok"},
+				Results: []warehouse.SaveInventoryTransferResult{{InventoryTransferID: 7}},
+			}
+		default:
+			t.Errorf("unexpected request %s", r.Form.Get("request"))
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	date := time.Date(2022, 7, 18, 10, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, SaveSaleEWayBill(context.Background(), sales.NewClient(baseClient), 100, "331001234567", date))
+	assert.NoError(t, SaveTransferEWayBill(context.Background(), warehouse.NewClient(baseClient), 7, "331001234568", date))
+
+	assert.Equal(t, "100", forms["saveSalesDocument"].Get("id"))
+	assert.Equal(t, EWayBillAttribute, forms["saveSalesDocument"].Get("attributeName1"))
+	assert.Equal(t, "331001234567", forms["saveSalesDocument"].Get("attributeValue1"))
+	assert.Equal(t, EWayBillDateAttribute, forms["saveSalesDocument"].Get("attributeName2"))
+	assert.Equal(t, "2022-07-18", forms["saveSalesDocument"].Get("attributeValue2"))
+	assert.Equal(t, "7", forms["saveInventoryTransfer"].Get("inventoryTransferID"))
+	assert.Equal(t, "331001234568", forms["saveInventoryTransfer"].Get("attributeValue1"))
+
+	err := SaveSaleEWayBill(context.Background(), sales.NewClient(baseClient), 100, "3310012345", date)
+	assert.EqualError(t, err, `invalid e-way bill number "3310012345": expected 12 digits`)
+}
+
+func TestLoadTransfer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		var resp interface{}
+		status := sharedCommon.Status{ResponseStatus: "ok"}
+		switch r.Form.Get("request") {
+		case "getInventoryTransfers":
+			assert.Equal(t, "7", r.Form.Get("inventoryTransferIDs"))
+			resp = warehouse.GetInventoryTransfersResponse{Status: status, InventoryTransfers: []warehouse.InventoryTransfer{testTransfer("", "", "").Transfer}}
+		case "getCompanyInfo":
+			resp = company.GetCompanyInfoResponse{Status: status, CompanyInfos: company.Infos{{Name: "Bhojpur", VAT: "29AAGCB7383J1Z4"}}}
+		case "getVatRates":
+			resp = sales.GetVatRatesResponse{Status: status, VatRates: testVatRates}
+		case "getWarehouses":
+			w := warehouse.Warehouse{WarehouseID: r.Form.Get("warehouseID"), State: "Karnataka", PINcode: "560001"}
+			if w.WarehouseID == "2" {
+				w.State, w.CompanyVatNumber = "Maharashtra", "27AAPFU0939F1ZV"
+			}
+			resp = warehouse.GetWarehousesResponse{Status: status, Warehouses: warehouse.Warehouses{w}}
+		case "getProducts":
+			assert.Equal(t, "3", r.Form.Get("productIDs"))
+			resp = product.GetProductsResponse{Status: status, Products: []product.Product{testProducts[3]}}
+		default:
+			t.Errorf("unexpected request %s", r.Form.Get("request"))
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	loader := &Loader{
+		Sales:      sales.NewClient(baseClient),
+		Companies:  company.NewClient(baseClient),
+		Warehouses: warehouse.NewClient(baseClient),
+		Products:   product.NewClient(baseClient),
+	}
+
+	transfer, err := loader.LoadTransfer(context.Background(), 7)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, "29AAGCB7383J1Z4", transfer.From.GSTIN)
+	assert.Equal(t, "Karnataka", transfer.From.State)
+	assert.Equal(t, "27AAPFU0939F1ZV", transfer.To.GSTIN)
+	assert.Equal(t, "Maharashtra", transfer.To.State)
+	assert.Contains(t, transfer.Products, 3)
+	assert.Len(t, transfer.VatRates, 3)
+}
@@ -0,0 +1,77 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//UnregisteredPerson is the GSTIN of the buyers without a registration in the e-invoices
+const UnregisteredPerson = "URP"
+
+const gstinAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+var ErrInvalidGSTIN = errors.New("invalid GSTIN")
+
+//gstinPattern is the state code, the PAN, the entity number, the default Z and the check character
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+//NormalizeGSTIN removes the spaces and upper cases the GSTIN
+func NormalizeGSTIN(gstin string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(gstin), ""))
+}
+
+//ValidateGSTIN checks the format, the state code and the check character of the GSTIN
+func ValidateGSTIN(gstin string) error {
+	gstin = NormalizeGSTIN(gstin)
+	if !gstinPattern.MatchString(gstin) {
+		return fmt.Errorf("%w %q: expected 15 characters of the state code, PAN, entity number and check character", ErrInvalidGSTIN, gstin)
+	}
+	if _, ok := stateNames[gstin[:2]]; !ok {
+		return fmt.Errorf("%w %q: unknown state code %s", ErrInvalidGSTIN, gstin, gstin[:2])
+	}
+	if check := gstinCheckCharacter(gstin[:14]); gstin[14] != check {
+		return fmt.Errorf("%w %q: check character should be %c", ErrInvalidGSTIN, gstin, check)
+	}
+	return nil
+}
+
+//GSTINState returns the state code of a valid GSTIN
+func GSTINState(gstin string) string {
+	gstin = NormalizeGSTIN(gstin)
+	if len(gstin) < 2 {
+		return ""
+	}
+	return gstin[:2]
+}
+
+//gstinCheckCharacter is the Luhn mod 36 check character of the first 14 characters
+func gstinCheckCharacter(body string) byte {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		product := strings.IndexByte(gstinAlphabet, body[i]) * (i%2 + 1)
+		sum += product/36 + product%36
+	}
+	return gstinAlphabet[(36-sum%36)%36]
+}
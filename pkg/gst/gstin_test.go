@@ -0,0 +1,113 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateGSTIN(t *testing.T) {
+	assert.NoError(t, ValidateGSTIN("27AAPFU0939F1ZV"))
+	assert.NoError(t, ValidateGSTIN(" 29aagcb7383j1z4 "))
+	assert.Equal(t, "29", GSTINState("29aagcb7383j1z4"))
+
+	testCases := []struct {
+		gstin   string
+		message string
+	}{
+		{"27AAPFU0939F1ZW", `invalid GSTIN "27AAPFU0939F1ZW": check character should be V`},
+		{"27AAPFU0939F1Z", `invalid GSTIN "27AAPFU0939F1Z": expected 15 characters of the state code, PAN, entity number and check character`},
+		{"27AAPF10939F1ZV", `invalid GSTIN "27AAPF10939F1ZV": expected 15 characters of the state code, PAN, entity number and check character`},
+		{"99AAPFU0939F1ZV", `invalid GSTIN "99AAPFU0939F1ZV": unknown state code 99`},
+	}
+	for _, testCase := range testCases {
+		err := ValidateGSTIN(testCase.gstin)
+		assert.True(t, errors.Is(err, ErrInvalidGSTIN), testCase.gstin)
+		assert.EqualError(t, err, testCase.message)
+	}
+}
+
+func TestStateCode(t *testing.T) {
+	testCases := map[string]string{
+		"Karnataka":                 "29",
+		"29":                        "29",
+		"7":                         "07",
+		"tamil nadu":                "33",
+		"Orissa":                    "21",
+		"Andaman & Nicobar Islands": "35",
+		"Dadra & Nagar Haveli":      "26",
+		"Jammu and Kashmir":         "01",
+		" Maharashtra ":             "27",
+		"Dadra and Nagar Haveli and Daman and Diu": "26",
+	}
+	for state, expected := range testCases {
+		code, err := StateCode(state)
+		assert.NoError(t, err, state)
+		assert.Equal(t, expected, code, state)
+	}
+
+	_, err := StateCode("Bavaria")
+	assert.True(t, errors.Is(err, ErrUnknownState))
+	assert.Equal(t, "Karnataka", StateName("29"))
+	assert.True(t, IsUnionTerritory("04"))
+	assert.False(t, IsUnionTerritory("07"))
+}
+
+func TestValidateHSN(t *testing.T) {
+	for _, code := range []string{"8517", "851713", "85171300", "998314"} {
+		assert.NoError(t, ValidateHSN(code), code)
+	}
+	testCases := map[string]string{
+		"85A7":    `invalid HSN/SAC code "85A7": only digits are allowed`,
+		"85171":   `invalid HSN/SAC code "85171": HSN has 4, 6 or 8 digits`,
+		"9983":    `invalid HSN/SAC code "9983": SAC has 6 digits`,
+		"0012":    `invalid HSN/SAC code "0012": unknown chapter 00`,
+		"8517130": `invalid HSN/SAC code "8517130": HSN has 4, 6 or 8 digits`,
+	}
+	for code, message := range testCases {
+		assert.EqualError(t, ValidateHSN(code), message)
+	}
+}
+
+func TestProductHSN(t *testing.T) {
+	p := product.Product{ProductID: 5}
+	p.Attributes = sharedCommon.Attributes{Attributes: []sharedCommon.ObjAttribute{
+		{AttributeName: "color", AttributeValue: "red"},
+		{AttributeName: HSNAttribute, AttributeValue: " 8517.13.00 "},
+	}}
+	code, err := ProductHSN(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "85171300", code)
+
+	p.Attributes.Attributes[1].AttributeValue = "851"
+	_, err = ProductHSN(p)
+	assert.True(t, errors.Is(err, ErrInvalidHSN))
+	assert.EqualError(t, err, `product 5: invalid HSN/SAC code "851": HSN has 4, 6 or 8 digits`)
+
+	_, err = ProductHSN(product.Product{ProductID: 6})
+	assert.True(t, errors.Is(err, ErrMissingHSN))
+	assert.EqualError(t, err, "missing HSN/SAC code of product 6, set the hsnCode attribute")
+}
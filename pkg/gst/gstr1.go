@@ -0,0 +1,463 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//DefaultB2CLLimit is the invoice value above which the inter-state supplies to unregistered buyers
+//are reported invoice by invoice
+const DefaultB2CLLimit = 100000
+
+//GSTR1 is the summary of the outward supplies of a GSTIN in a month in the JSON of the GST offline tool
+type GSTR1 struct {
+	GSTIN  string        `json:"gstin"`
+	Period string        `json:"fp"`
+	B2B    []GSTR1Buyer  `json:"b2b,omitempty"`
+	B2CL   []GSTR1State  `json:"b2cl,omitempty"`
+	B2CS   []GSTR1Small  `json:"b2cs,omitempty"`
+	CDNR   []GSTR1Notes  `json:"cdnr,omitempty"`
+	Exp    []GSTR1Export `json:"exp,omitempty"`
+	HSN    *GSTR1HSN     `json:"hsn,omitempty"`
+}
+
+//GSTR1Buyer are the invoices of a registered buyer
+type GSTR1Buyer struct {
+	CTIN     string         `json:"ctin"`
+	Invoices []GSTR1Invoice `json:"inv"`
+}
+
+//GSTR1State are the large invoices to the unregistered buyers of a state
+type GSTR1State struct {
+	Pos      string         `json:"pos"`
+	Invoices []GSTR1Invoice `json:"inv"`
+}
+
+type GSTR1Invoice struct {
+	Number        string      `json:"inum"`
+	Date          string      `json:"idt"`
+	Value         float64     `json:"val"`
+	Pos           string      `json:"pos,omitempty"`
+	ReverseCharge string      `json:"rchrg,omitempty"`
+	Type          string      `json:"inv_typ,omitempty"`
+	Items         []GSTR1Item `json:"itms"`
+}
+
+//GSTR1Notes are the credit notes of a registered buyer
+type GSTR1Notes struct {
+	CTIN  string      `json:"ctin"`
+	Notes []GSTR1Note `json:"nt"`
+}
+
+type GSTR1Note struct {
+	Type          string      `json:"ntty"`
+	Number        string      `json:"nt_num"`
+	Date          string      `json:"nt_dt"`
+	Value         float64     `json:"val"`
+	Pos           string      `json:"pos"`
+	ReverseCharge string      `json:"rchrg"`
+	InvoiceType   string      `json:"inv_typ"`
+	Items         []GSTR1Item `json:"itms"`
+}
+
+//GSTR1Item are the totals of a rate of an invoice
+type GSTR1Item struct {
+	Number int             `json:"num"`
+	Detail GSTR1ItemDetail `json:"itm_det"`
+}
+
+type GSTR1ItemDetail struct {
+	Taxable float64 `json:"txval"`
+	Rate    float64 `json:"rt"`
+	IGST    float64 `json:"iamt,omitempty"`
+	CGST    float64 `json:"camt,omitempty"`
+	SGST    float64 `json:"samt,omitempty"`
+	Cess    float64 `json:"csamt"`
+}
+
+//GSTR1Small are the totals of the other supplies to the unregistered buyers by the state and the rate
+type GSTR1Small struct {
+	SupplyType SupplyType `json:"sply_ty"`
+	Pos        string     `json:"pos"`
+	Type       string     `json:"typ"`
+	Taxable    float64    `json:"txval"`
+	Rate       float64    `json:"rt"`
+	IGST       float64    `json:"iamt,omitempty"`
+	CGST       float64    `json:"camt,omitempty"`
+	SGST       float64    `json:"samt,omitempty"`
+	Cess       float64    `json:"csamt"`
+}
+
+//GSTR1Export are the export invoices with or without the payment of IGST
+type GSTR1Export struct {
+	Type     string               `json:"exp_typ"`
+	Invoices []GSTR1ExportInvoice `json:"inv"`
+}
+
+type GSTR1ExportInvoice struct {
+	Number string            `json:"inum"`
+	Date   string            `json:"idt"`
+	Value  float64           `json:"val"`
+	Items  []GSTR1ExportItem `json:"itms"`
+}
+
+type GSTR1ExportItem struct {
+	Taxable float64 `json:"txval"`
+	Rate    float64 `json:"rt"`
+	IGST    float64 `json:"iamt"`
+	Cess    float64 `json:"csamt"`
+}
+
+//GSTR1HSN is the HSN-wise summary of the outward supplies
+type GSTR1HSN struct {
+	Data []GSTR1HSNLine `json:"data"`
+}
+
+type GSTR1HSNLine struct {
+	Number      int     `json:"num"`
+	HSN         string  `json:"hsn_sc"`
+	Description string  `json:"desc"`
+	Unit        string  `json:"uqc"`
+	Quantity    float64 `json:"qty"`
+	Rate        float64 `json:"rt"`
+	Value       float64 `json:"val"`
+	Taxable     float64 `json:"txval"`
+	IGST        float64 `json:"iamt"`
+	CGST        float64 `json:"camt"`
+	SGST        float64 `json:"samt"`
+	Cess        float64 `json:"csamt"`
+}
+
+//GSTR1Builder collects the sales documents of a GSTIN in a month into GSTR-1
+type GSTR1Builder struct {
+	GSTIN string
+	Month time.Time
+	//TimeZone is the account timezone of the date filters of the loaded documents, UTC when it's not set
+	TimeZone sharedCommon.TimeZone
+	//B2CLLimit is the invoice value above which the inter-state supplies to unregistered buyers are reported
+	//invoice by invoice, DefaultB2CLLimit when it's 0
+	B2CLLimit float64
+	b2b       map[string][]GSTR1Invoice
+	b2cl      map[string][]GSTR1Invoice
+	b2cs      map[string]*GSTR1Small
+	cdnr      map[string][]GSTR1Note
+	exp       map[string][]GSTR1ExportInvoice
+	hsn       map[string]*GSTR1HSNLine
+	ids       map[int]bool
+}
+
+//NewGSTR1Builder creates a builder of the month of the date
+func NewGSTR1Builder(gstin string, month time.Time) *GSTR1Builder {
+	return &GSTR1Builder{
+		GSTIN: NormalizeGSTIN(gstin),
+		Month: time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC),
+		b2b:   map[string][]GSTR1Invoice{},
+		b2cl:  map[string][]GSTR1Invoice{},
+		b2cs:  map[string]*GSTR1Small{},
+		cdnr:  map[string][]GSTR1Note{},
+		exp:   map[string][]GSTR1ExportInvoice{},
+		hsn:   map[string]*GSTR1HSNLine{},
+		ids:   map[int]bool{},
+	}
+}
+
+//Add adds the confirmed invoices and credit invoices of the month supplied from the GSTIN of the builder,
+//the other documents are skipped. The credit notes of the unregistered buyers reduce the B2C small totals
+func (b *GSTR1Builder) Add(d *Document) error {
+	doc := d.Sale
+	if !saleTypes[strings.ToUpper(doc.Type)] || doc.Confirmed == "0" || strings.EqualFold(doc.InvoiceState, "CANCELLED") {
+		return nil
+	}
+	if b.ids[doc.ID] || NormalizeGSTIN(d.Seller.GSTIN) != b.GSTIN {
+		return nil
+	}
+	date, err := time.Parse(sharedCommon.DateLayout, doc.Date)
+	if err != nil {
+		return fmt.Errorf("sales document %d: invalid date %q", doc.ID, doc.Date)
+	}
+	if date.Year() != b.Month.Year() || date.Month() != b.Month.Month() {
+		return nil
+	}
+
+	supply, err := d.SupplyType()
+	if err != nil {
+		return fmt.Errorf("sales document %d: %w", doc.ID, err)
+	}
+	pos, err := d.PlaceOfSupply()
+	if err != nil {
+		return fmt.Errorf("sales document %d: %w", doc.ID, err)
+	}
+	lines, err := d.Lines()
+	if err != nil {
+		return err
+	}
+
+	value := 0.0
+	for _, line := range lines {
+		value += line.Total
+	}
+	value = sharedCommon.RoundFloat(value, 2)
+	items := gstr1Items(lines)
+	number := doc.Number
+	formatted := date.Format("02-01-2006")
+	ctin := NormalizeGSTIN(d.Buyer.GSTIN)
+	buyerType := d.BuyerType()
+
+	switch {
+	case buyerType == CustomerOverseas:
+		exportType := "WOPAY"
+		if d.WithPayment {
+			exportType = "WPAY"
+		}
+		invoice := GSTR1ExportInvoice{Number: number, Date: formatted, Value: value}
+		for _, item := range items {
+			invoice.Items = append(invoice.Items, GSTR1ExportItem{
+				Taxable: item.Detail.Taxable,
+				Rate:    item.Detail.Rate,
+				IGST:    item.Detail.IGST,
+				Cess:    item.Detail.Cess,
+			})
+		}
+		b.exp[exportType] = append(b.exp[exportType], invoice)
+	case d.IsRegistered() && d.IsCreditNote():
+		b.cdnr[ctin] = append(b.cdnr[ctin], GSTR1Note{
+			Type:          "C",
+			Number:        number,
+			Date:          formatted,
+			Value:         value,
+			Pos:           pos,
+			ReverseCharge: "N",
+			InvoiceType:   b.invoiceType(d),
+			Items:         items,
+		})
+	case d.IsRegistered():
+		b.b2b[ctin] = append(b.b2b[ctin], GSTR1Invoice{
+			Number:        number,
+			Date:          formatted,
+			Value:         value,
+			Pos:           pos,
+			ReverseCharge: "N",
+			Type:          b.invoiceType(d),
+			Items:         items,
+		})
+	case supply == InterState && !d.IsCreditNote() && value > b.b2clLimit():
+		b.b2cl[pos] = append(b.b2cl[pos], GSTR1Invoice{Number: number, Date: formatted, Value: value, Items: items})
+	default:
+		sign := 1.0
+		if d.IsCreditNote() {
+			sign = -1
+		}
+		for _, item := range items {
+			key := fmt.Sprintf("%s|%s|%v", supply, pos, item.Detail.Rate)
+			small, ok := b.b2cs[key]
+			if !ok {
+				small = &GSTR1Small{SupplyType: supply, Pos: pos, Type: "OE", Rate: item.Detail.Rate}
+				b.b2cs[key] = small
+			}
+			small.Taxable += sign * item.Detail.Taxable
+			small.IGST += sign * item.Detail.IGST
+			small.CGST += sign * item.Detail.CGST
+			small.SGST += sign * item.Detail.SGST
+			small.Cess += sign * item.Detail.Cess
+		}
+	}
+
+	b.addHSN(lines, d.IsCreditNote())
+	b.ids[doc.ID] = true
+	return nil
+}
+
+func (b *GSTR1Builder) b2clLimit() float64 {
+	if b.B2CLLimit == 0 {
+		return DefaultB2CLLimit
+	}
+	return b.B2CLLimit
+}
+
+//invoiceType is R for the regular invoices, SEWP and SEWOP for SEZ and DE for the deemed exports
+func (b *GSTR1Builder) invoiceType(d *Document) string {
+	switch d.BuyerType() {
+	case CustomerSEZ:
+		if d.WithPayment {
+			return "SEWP"
+		}
+		return "SEWOP"
+	case CustomerDeemedExport:
+		return "DE"
+	}
+	return "R"
+}
+
+//addHSN sums the lines by the HSN code, the unit and the rate, the credit notes reduce the totals
+func (b *GSTR1Builder) addHSN(lines []Line, credit bool) {
+	sign := 1.0
+	if credit {
+		sign = -1
+	}
+	for _, line := range lines {
+		key := fmt.Sprintf("%s|%s|%v", line.HSN, line.Unit, line.Rates.GST)
+		summary, ok := b.hsn[key]
+		if !ok {
+			summary = &GSTR1HSNLine{HSN: line.HSN, Description: line.Description, Unit: line.Unit, Rate: line.Rates.GST}
+			b.hsn[key] = summary
+		}
+		summary.Quantity += sign * line.Quantity
+		summary.Value += sign * line.Total
+		summary.Taxable += sign * line.Taxable
+		summary.IGST += sign * line.Amounts.IGST
+		summary.CGST += sign * line.Amounts.CGST
+		summary.SGST += sign * line.Amounts.SGST
+		summary.Cess += sign * line.Amounts.Cess
+	}
+}
+
+//gstr1Items sums the lines of an invoice by the rate
+func gstr1Items(lines []Line) []GSTR1Item {
+	byRate := map[float64]*GSTR1ItemDetail{}
+	rates := []float64{}
+	for _, line := range lines {
+		detail, ok := byRate[line.Rates.GST]
+		if !ok {
+			detail = &GSTR1ItemDetail{Rate: line.Rates.GST}
+			byRate[line.Rates.GST] = detail
+			rates = append(rates, line.Rates.GST)
+		}
+		detail.Taxable += line.Taxable
+		detail.IGST += line.Amounts.IGST
+		detail.CGST += line.Amounts.CGST
+		detail.SGST += line.Amounts.SGST
+		detail.Cess += line.Amounts.Cess
+	}
+	sort.Float64s(rates)
+
+	items := make([]GSTR1Item, 0, len(rates))
+	for i, rate := range rates {
+		detail := byRate[rate]
+		items = append(items, GSTR1Item{
+			Number: i + 1,
+			Detail: GSTR1ItemDetail{
+				Taxable: sharedCommon.RoundFloat(detail.Taxable, 2),
+				Rate:    rate,
+				IGST:    sharedCommon.RoundFloat(detail.IGST, 2),
+				CGST:    sharedCommon.RoundFloat(detail.CGST, 2),
+				SGST:    sharedCommon.RoundFloat(detail.SGST, 2),
+				Cess:    sharedCommon.RoundFloat(detail.Cess, 2),
+			},
+		})
+	}
+	return items
+}
+
+//GSTR1 returns the summary with the sections sorted by the GSTIN, the state, the number and the HSN code
+func (b *GSTR1Builder) GSTR1() *GSTR1 {
+	ret := &GSTR1{GSTIN: b.GSTIN, Period: b.Month.Format("012006")}
+
+	for _, ctin := range sortedKeys(b.b2b) {
+		ret.B2B = append(ret.B2B, GSTR1Buyer{CTIN: ctin, Invoices: sortInvoices(b.b2b[ctin])})
+	}
+	for _, pos := range sortedKeys(b.b2cl) {
+		ret.B2CL = append(ret.B2CL, GSTR1State{Pos: pos, Invoices: sortInvoices(b.b2cl[pos])})
+	}
+	smallKeys := make([]string, 0, len(b.b2cs))
+	for key := range b.b2cs {
+		smallKeys = append(smallKeys, key)
+	}
+	sort.Strings(smallKeys)
+	for _, key := range smallKeys {
+		small := *b.b2cs[key]
+		small.Taxable = sharedCommon.RoundFloat(small.Taxable, 2)
+		small.IGST = sharedCommon.RoundFloat(small.IGST, 2)
+		small.CGST = sharedCommon.RoundFloat(small.CGST, 2)
+		small.SGST = sharedCommon.RoundFloat(small.SGST, 2)
+		small.Cess = sharedCommon.RoundFloat(small.Cess, 2)
+		ret.B2CS = append(ret.B2CS, small)
+	}
+	cdnrKeys := make([]string, 0, len(b.cdnr))
+	for ctin := range b.cdnr {
+		cdnrKeys = append(cdnrKeys, ctin)
+	}
+	sort.Strings(cdnrKeys)
+	for _, ctin := range cdnrKeys {
+		notes := append([]GSTR1Note{}, b.cdnr[ctin]...)
+		sort.Slice(notes, func(i, j int) bool { return notes[i].Number < notes[j].Number })
+		ret.CDNR = append(ret.CDNR, GSTR1Notes{CTIN: ctin, Notes: notes})
+	}
+	for _, exportType := range []string{"WPAY", "WOPAY"} {
+		if invoices, ok := b.exp[exportType]; ok {
+			invoices = append([]GSTR1ExportInvoice{}, invoices...)
+			sort.Slice(invoices, func(i, j int) bool { return invoices[i].Number < invoices[j].Number })
+			ret.Exp = append(ret.Exp, GSTR1Export{Type: exportType, Invoices: invoices})
+		}
+	}
+
+	if len(b.hsn) > 0 {
+		hsnKeys := make([]string, 0, len(b.hsn))
+		for key := range b.hsn {
+			hsnKeys = append(hsnKeys, key)
+		}
+		sort.Strings(hsnKeys)
+		ret.HSN = &GSTR1HSN{}
+		for i, key := range hsnKeys {
+			line := *b.hsn[key]
+			line.Number = i + 1
+			line.Quantity = sharedCommon.RoundFloat(line.Quantity, 2)
+			line.Value = sharedCommon.RoundFloat(line.Value, 2)
+			line.Taxable = sharedCommon.RoundFloat(line.Taxable, 2)
+			line.IGST = sharedCommon.RoundFloat(line.IGST, 2)
+			line.CGST = sharedCommon.RoundFloat(line.CGST, 2)
+			line.SGST = sharedCommon.RoundFloat(line.SGST, 2)
+			line.Cess = sharedCommon.RoundFloat(line.Cess, 2)
+			ret.HSN.Data = append(ret.HSN.Data, line)
+		}
+	}
+
+	return ret
+}
+
+//WriteJSON writes the summary as indented JSON
+func (r *GSTR1) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func sortedKeys(invoices map[string][]GSTR1Invoice) []string {
+	keys := make([]string, 0, len(invoices))
+	for key := range invoices {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortInvoices(invoices []GSTR1Invoice) []GSTR1Invoice {
+	sorted := append([]GSTR1Invoice{}, invoices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+	return sorted
+}
@@ -0,0 +1,114 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSale(id int, docType, number, date string, rows ...sales.InvoiceRow) sales.SaleDocument {
+	return sales.SaleDocument{ID: id, Type: docType, Number: number, Date: date, Confirmed: "1", InvoiceRows: rows}
+}
+
+func testConsumer(state string) Party {
+	return Party{LegalName: "Walk-in customer", State: state}
+}
+
+func testGSTR1Documents() []*Document {
+	phone := func(amount, price string, net float64) sales.InvoiceRow {
+		return sales.InvoiceRow{ProductID: "1", ItemName: "Phone", VatrateID: "1", Amount: amount, Price: price, RowNetTotal: net}
+	}
+	service := func(amount, price string, net float64) sales.InvoiceRow {
+		return sales.InvoiceRow{ProductID: "2", ItemName: "Installation", VatrateID: "1", Amount: amount, Price: price, RowNetTotal: net}
+	}
+	document := func(sale sales.SaleDocument, buyer Party) *Document {
+		return &Document{Sale: sale, Seller: testSeller, Buyer: buyer, VatRates: testVatRates, Products: testProducts}
+	}
+
+	registered := testDocument().Buyer
+	tamilNadu := Party{GSTIN: "33AAACB1234C1ZM", LegalName: "Chennai Stores", State: "Tamil Nadu"}
+	foreign := Party{LegalName: "Acme Inc", Country: "US"}
+
+	docs := []*Document{
+		testDocument(),
+		document(testSale(101, "INVWAYBILL", "INV/2022/101", "2022-07-16",
+			sales.InvoiceRow{ProductID: "1", ItemName: "Phone", VatrateID: "2", Amount: "1", Price: "1000", RowNetTotal: 1000}), tamilNadu),
+		document(testSale(102, "CREDITINVOICE", "CN/2022/1", "2022-07-20", phone("-1", "10000", -9000)), registered),
+		document(testSale(103, "CASHINVOICE", "INV/2022/103", "2022-07-21", phone("1", "5000", 5000)), testConsumer("Maharashtra")),
+		document(testSale(104, "INVOICE", "INV/2022/104", "2022-07-22",
+			sales.InvoiceRow{ProductID: "3", ItemName: "Car", VatrateID: "3", Amount: "1", Price: "500000", RowNetTotal: 500000}), testConsumer("27")),
+		document(testSale(105, "CASHINVOICE", "INV/2022/105", "2022-07-23", service("1", "1000", 1000)), testConsumer("Karnataka")),
+		document(testSale(106, "CREDITINVOICE", "CN/2022/2", "2022-07-24", service("-1", "200", -200)), testConsumer("Karnataka")),
+		document(testSale(107, "EXPORTINVOICE", "INV/2022/107", "2022-07-25", phone("1", "125", 125)), foreign),
+		//skipped: another GSTIN of the company, unconfirmed, another month and an order
+		document(testSale(108, "INVOICE", "INV/2022/108", "2022-07-25", phone("1", "100", 100)), registered),
+		document(testSale(109, "INVOICE", "INV/2022/109", "2022-07-25", phone("1", "100", 100)), registered),
+		document(testSale(110, "INVOICE", "INV/2022/110", "2022-08-01", phone("1", "100", 100)), registered),
+		document(testSale(111, "ORDER", "ORD/2022/111", "2022-07-25", phone("1", "100", 100)), registered),
+	}
+	docs[7].Sale.CurrencyCode = "USD"
+	docs[7].Sale.CurrencyRate = "80"
+	docs[8].Seller.GSTIN = "27AAPFU0939F1ZV"
+	docs[8].Seller.State = "Maharashtra"
+	docs[9].Sale.Confirmed = "0"
+
+	return docs
+}
+
+func TestGSTR1(t *testing.T) {
+	builder := NewGSTR1Builder("29aagcb7383j1z4", time.Date(2022, 7, 10, 0, 0, 0, 0, time.UTC))
+	for _, doc := range testGSTR1Documents() {
+		assert.NoError(t, builder.Add(doc))
+	}
+	//documents are added once
+	assert.NoError(t, builder.Add(testDocument()))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, builder.GSTR1().WriteJSON(buf))
+	assert.JSONEq(t, string(readFixture(t, "gstr1.json")), buf.String())
+}
+
+func TestGSTR1B2CLLimit(t *testing.T) {
+	builder := NewGSTR1Builder("29AAGCB7383J1Z4", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC))
+	builder.B2CLLimit = 1000000
+	assert.NoError(t, builder.Add(testGSTR1Documents()[4]))
+
+	ret := builder.GSTR1()
+	assert.Empty(t, ret.B2CL)
+	assert.Equal(t, []GSTR1Small{
+		{SupplyType: InterState, Pos: "27", Type: "OE", Taxable: 500000, Rate: 28, IGST: 140000, Cess: 60000},
+	}, ret.B2CS)
+}
+
+//TestGSTR1SchemaFixture checks that the types cover every field of the fixture of the offline tool
+func TestGSTR1SchemaFixture(t *testing.T) {
+	decoder := json.NewDecoder(bytes.NewReader(readFixture(t, "gstr1.json")))
+	decoder.DisallowUnknownFields()
+	ret := &GSTR1{}
+	assert.NoError(t, decoder.Decode(ret))
+	assert.Equal(t, "072022", ret.Period)
+}
@@ -0,0 +1,83 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+)
+
+//HSNAttribute is the name of the product attribute holding the HSN code of the goods or the SAC of the services
+const HSNAttribute = "hsnCode"
+
+var (
+	ErrInvalidHSN = errors.New("invalid HSN/SAC code")
+	ErrMissingHSN = errors.New("missing HSN/SAC code")
+)
+
+//ValidateHSN checks the HSN code of the goods, 4, 6 or 8 digits, or the 6 digit service accounting code
+//starting with 99
+func ValidateHSN(code string) error {
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w %q: only digits are allowed", ErrInvalidHSN, code)
+		}
+	}
+	if IsService(code) {
+		if len(code) != 6 {
+			return fmt.Errorf("%w %q: SAC has 6 digits", ErrInvalidHSN, code)
+		}
+		return nil
+	}
+	if len(code) != 4 && len(code) != 6 && len(code) != 8 {
+		return fmt.Errorf("%w %q: HSN has 4, 6 or 8 digits", ErrInvalidHSN, code)
+	}
+	if code[:2] == "00" {
+		return fmt.Errorf("%w %q: unknown chapter 00", ErrInvalidHSN, code)
+	}
+	return nil
+}
+
+//IsService tells if the code is a service accounting code
+func IsService(code string) bool {
+	return strings.HasPrefix(code, "99")
+}
+
+//ProductHSN returns the valid HSN/SAC code of the product from its attribute
+func ProductHSN(p product.Product) (string, error) {
+	for _, attribute := range p.Attributes.Attributes {
+		if attribute.AttributeName != HSNAttribute {
+			continue
+		}
+		code := strings.ReplaceAll(strings.TrimSpace(attribute.AttributeValue), ".", "")
+		if code == "" {
+			break
+		}
+		if err := ValidateHSN(code); err != nil {
+			return "", fmt.Errorf("product %d: %w", p.ProductID, err)
+		}
+		return code, nil
+	}
+	return "", fmt.Errorf("%w of product %d, set the %s attribute", ErrMissingHSN, p.ProductID, HSNAttribute)
+}
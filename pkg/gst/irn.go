@@ -0,0 +1,424 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//IRNVersion is the version of the e-invoice schema of the invoice registration portal
+const IRNVersion = "1.1"
+
+var (
+	ErrIRNNotApplicable = errors.New("e-invoice is not applicable to the supplies to unregistered buyers")
+	ErrInvalidIRN       = errors.New("invalid e-invoice")
+)
+
+var (
+	documentNumberPattern = regexp.MustCompile(`^[A-Za-z1-9][A-Za-z0-9/-]{0,15}$`)
+	hsnPattern            = regexp.MustCompile(`^[0-9]{4,8}$`)
+)
+
+//gstRates are the GST rates accepted by the portal
+var gstRates = map[float64]bool{
+	0: true, 0.1: true, 0.25: true, 1: true, 1.5: true, 3: true, 5: true, 6: true, 7.5: true, 12: true, 18: true, 28: true,
+}
+
+//supplyTypes are the supply types of the e-invoices
+var supplyTypes = map[string]bool{"B2B": true, "SEZWP": true, "SEZWOP": true, "EXPWP": true, "EXPWOP": true, "DEXP": true}
+
+//IRNInvoice is the e-invoice JSON submitted to the invoice registration portal to get the IRN
+type IRNInvoice struct {
+	Version    string         `json:"Version"`
+	TranDtls   IRNTransaction `json:"TranDtls"`
+	DocDtls    IRNDocument    `json:"DocDtls"`
+	SellerDtls IRNParty       `json:"SellerDtls"`
+	BuyerDtls  IRNParty       `json:"BuyerDtls"`
+	ShipDtls   *IRNParty      `json:"ShipDtls,omitempty"`
+	ItemList   []IRNItem      `json:"ItemList"`
+	ValDtls    IRNValues      `json:"ValDtls"`
+	ExpDtls    *IRNExport     `json:"ExpDtls,omitempty"`
+}
+
+type IRNTransaction struct {
+	TaxSch      string `json:"TaxSch"`
+	SupTyp      string `json:"SupTyp"`
+	RegRev      string `json:"RegRev"`
+	IgstOnIntra string `json:"IgstOnIntra"`
+}
+
+type IRNDocument struct {
+	Typ string `json:"Typ"`
+	No  string `json:"No"`
+	Dt  string `json:"Dt"`
+}
+
+//IRNParty is the seller, the buyer or the ship-to party, only the buyer has the place of supply
+type IRNParty struct {
+	Gstin string `json:"Gstin"`
+	LglNm string `json:"LglNm"`
+	TrdNm string `json:"TrdNm,omitempty"`
+	Pos   string `json:"Pos,omitempty"`
+	Addr1 string `json:"Addr1"`
+	Addr2 string `json:"Addr2,omitempty"`
+	Loc   string `json:"Loc"`
+	Pin   int    `json:"Pin"`
+	Stcd  string `json:"Stcd"`
+	Ph    string `json:"Ph,omitempty"`
+	Em    string `json:"Em,omitempty"`
+}
+
+type IRNItem struct {
+	SlNo       string  `json:"SlNo"`
+	PrdDesc    string  `json:"PrdDesc,omitempty"`
+	IsServc    string  `json:"IsServc"`
+	HsnCd      string  `json:"HsnCd"`
+	Qty        float64 `json:"Qty"`
+	Unit       string  `json:"Unit"`
+	UnitPrice  float64 `json:"UnitPrice"`
+	TotAmt     float64 `json:"TotAmt"`
+	Discount   float64 `json:"Discount"`
+	AssAmt     float64 `json:"AssAmt"`
+	GstRt      float64 `json:"GstRt"`
+	IgstAmt    float64 `json:"IgstAmt"`
+	CgstAmt    float64 `json:"CgstAmt"`
+	SgstAmt    float64 `json:"SgstAmt"`
+	CesRt      float64 `json:"CesRt"`
+	CesAmt     float64 `json:"CesAmt"`
+	TotItemVal float64 `json:"TotItemVal"`
+}
+
+type IRNValues struct {
+	AssVal    float64 `json:"AssVal"`
+	CgstVal   float64 `json:"CgstVal"`
+	SgstVal   float64 `json:"SgstVal"`
+	IgstVal   float64 `json:"IgstVal"`
+	CesVal    float64 `json:"CesVal"`
+	Discount  float64 `json:"Discount"`
+	OthChrg   float64 `json:"OthChrg"`
+	RndOffAmt float64 `json:"RndOffAmt"`
+	TotInvVal float64 `json:"TotInvVal"`
+}
+
+type IRNExport struct {
+	ForCur  string `json:"ForCur,omitempty"`
+	CntCode string `json:"CntCode,omitempty"`
+}
+
+//IRN renders the document as an e-invoice and validates it against the rules of the schema
+func (d *Document) IRN() (*IRNInvoice, error) {
+	supplyType, err := d.irnSupplyType()
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse(sharedCommon.DateLayout, d.Sale.Date)
+	if err != nil {
+		return nil, fmt.Errorf("sales document %d: invalid date %q", d.Sale.ID, d.Sale.Date)
+	}
+	lines, err := d.Lines()
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &IRNInvoice{
+		Version: IRNVersion,
+		TranDtls: IRNTransaction{
+			TaxSch:      "GST",
+			SupTyp:      supplyType,
+			RegRev:      "N",
+			IgstOnIntra: "N",
+		},
+		DocDtls: IRNDocument{Typ: "INV", No: d.Sale.Number, Dt: date.Format("02/01/2006")},
+	}
+	if d.IsCreditNote() {
+		inv.DocDtls.Typ = "CRN"
+	}
+
+	if inv.SellerDtls, err = irnParty(d.Seller, false); err != nil {
+		return nil, fmt.Errorf("seller: %w", err)
+	}
+	export := d.BuyerType() == CustomerOverseas
+	if inv.BuyerDtls, err = irnParty(d.Buyer, export); err != nil {
+		return nil, fmt.Errorf("buyer: %w", err)
+	}
+	if inv.BuyerDtls.Pos, err = d.PlaceOfSupply(); err != nil {
+		return nil, fmt.Errorf("buyer: %w", err)
+	}
+	if d.ShipTo != nil {
+		shipTo, err := irnParty(*d.ShipTo, export && strings.TrimSpace(d.ShipTo.State) == "")
+		if err != nil {
+			return nil, fmt.Errorf("ship-to: %w", err)
+		}
+		inv.ShipDtls = &shipTo
+	}
+	if export {
+		inv.ExpDtls = &IRNExport{CntCode: countryCode(d.Buyer.Country)}
+		if currency := strings.ToUpper(d.Sale.CurrencyCode); currency != "" && currency != "INR" {
+			inv.ExpDtls.ForCur = currency
+		}
+	}
+
+	for _, line := range lines {
+		item := IRNItem{
+			SlNo:       strconv.Itoa(line.Number),
+			PrdDesc:    line.Description,
+			IsServc:    "N",
+			HsnCd:      line.HSN,
+			Qty:        line.Quantity,
+			Unit:       line.Unit,
+			UnitPrice:  line.UnitPrice,
+			TotAmt:     line.Gross,
+			Discount:   line.Discount,
+			AssAmt:     line.Taxable,
+			GstRt:      line.Rates.GST,
+			IgstAmt:    line.Amounts.IGST,
+			CgstAmt:    line.Amounts.CGST,
+			SgstAmt:    line.Amounts.SGST,
+			CesRt:      line.Rates.Cess,
+			CesAmt:     line.Amounts.Cess,
+			TotItemVal: line.Total,
+		}
+		if line.Service {
+			item.IsServc = "Y"
+		}
+		inv.ItemList = append(inv.ItemList, item)
+
+		inv.ValDtls.AssVal += item.AssAmt
+		inv.ValDtls.CgstVal += item.CgstAmt
+		inv.ValDtls.SgstVal += item.SgstAmt
+		inv.ValDtls.IgstVal += item.IgstAmt
+		inv.ValDtls.CesVal += item.CesAmt
+		inv.ValDtls.TotInvVal += item.TotItemVal
+	}
+	inv.ValDtls.AssVal = sharedCommon.RoundFloat(inv.ValDtls.AssVal, 2)
+	inv.ValDtls.CgstVal = sharedCommon.RoundFloat(inv.ValDtls.CgstVal, 2)
+	inv.ValDtls.SgstVal = sharedCommon.RoundFloat(inv.ValDtls.SgstVal, 2)
+	inv.ValDtls.IgstVal = sharedCommon.RoundFloat(inv.ValDtls.IgstVal, 2)
+	inv.ValDtls.CesVal = sharedCommon.RoundFloat(inv.ValDtls.CesVal, 2)
+	inv.ValDtls.RndOffAmt = sharedCommon.RoundFloat(math.Abs(d.Sale.Rounding), 2)
+	if d.Sale.Rounding < 0 != d.IsCreditNote() {
+		inv.ValDtls.RndOffAmt = -inv.ValDtls.RndOffAmt
+	}
+	inv.ValDtls.TotInvVal = sharedCommon.RoundFloat(inv.ValDtls.TotInvVal+inv.ValDtls.RndOffAmt, 2)
+
+	if err := inv.Validate(); err != nil {
+		return nil, fmt.Errorf("sales document %d: %w", d.Sale.ID, err)
+	}
+	return inv, nil
+}
+
+func (d *Document) irnSupplyType() (string, error) {
+	switch d.BuyerType() {
+	case CustomerRegistered:
+		return "B2B", nil
+	case CustomerDeemedExport:
+		return "DEXP", nil
+	case CustomerSEZ:
+		if d.WithPayment {
+			return "SEZWP", nil
+		}
+		return "SEZWOP", nil
+	case CustomerOverseas:
+		if d.WithPayment {
+			return "EXPWP", nil
+		}
+		return "EXPWOP", nil
+	}
+	return "", fmt.Errorf("sales document %d: %w", d.Sale.ID, ErrIRNNotApplicable)
+}
+
+//irnParty converts the party, the foreign buyers have no GSTIN, PIN or state
+func irnParty(p Party, foreign bool) (IRNParty, error) {
+	party := IRNParty{
+		Gstin: NormalizeGSTIN(p.GSTIN),
+		LglNm: p.LegalName,
+		TrdNm: p.TradeName,
+		Addr1: p.Address1,
+		Addr2: p.Address2,
+		Loc:   p.Location,
+		Ph:    digits(p.Phone),
+		Em:    p.Email,
+	}
+	if foreign {
+		party.Gstin = UnregisteredPerson
+		party.Pin = 999999
+		party.Stcd = ForeignCountry
+		return party, nil
+	}
+
+	state, err := p.StateCode()
+	if err != nil {
+		return IRNParty{}, err
+	}
+	party.Stcd = state
+	if party.Pin, err = strconv.Atoi(strings.ReplaceAll(p.PIN, " ", "")); err != nil {
+		return IRNParty{}, fmt.Errorf("invalid PIN code %q of %s", p.PIN, p.LegalName)
+	}
+	return party, nil
+}
+
+//Validate checks the e-invoice against the rules of the schema and the value checks of the portal
+func (inv *IRNInvoice) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(inv.Version == IRNVersion, "Version should be %s", IRNVersion)
+	check(inv.TranDtls.TaxSch == "GST", "TranDtls.TaxSch should be GST")
+	check(supplyTypes[inv.TranDtls.SupTyp], "unknown TranDtls.SupTyp %q", inv.TranDtls.SupTyp)
+	check(isYesNo(inv.TranDtls.RegRev), "TranDtls.RegRev should be Y or N")
+	check(isYesNo(inv.TranDtls.IgstOnIntra), "TranDtls.IgstOnIntra should be Y or N")
+
+	check(inv.DocDtls.Typ == "INV" || inv.DocDtls.Typ == "CRN" || inv.DocDtls.Typ == "DBN", "unknown DocDtls.Typ %q", inv.DocDtls.Typ)
+	check(documentNumberPattern.MatchString(inv.DocDtls.No), "DocDtls.No %q should have up to 16 letters, digits, / or - and not start with 0, / or -", inv.DocDtls.No)
+	_, err := time.Parse("02/01/2006", inv.DocDtls.Dt)
+	check(err == nil, "DocDtls.Dt %q should be DD/MM/YYYY", inv.DocDtls.Dt)
+
+	export := strings.HasPrefix(inv.TranDtls.SupTyp, "EXP")
+	problems = append(problems, validateParty("SellerDtls", inv.SellerDtls, false)...)
+	problems = append(problems, validateParty("BuyerDtls", inv.BuyerDtls, export)...)
+	_, knownPos := stateNames[inv.BuyerDtls.Pos]
+	check(knownPos, "unknown BuyerDtls.Pos %q", inv.BuyerDtls.Pos)
+	if inv.ShipDtls != nil {
+		problems = append(problems, validateParty("ShipDtls", *inv.ShipDtls, export && inv.ShipDtls.Gstin == UnregisteredPerson)...)
+	}
+
+	intraState := inv.SellerDtls.Stcd == inv.BuyerDtls.Pos && inv.TranDtls.IgstOnIntra == "N" &&
+		!export && !strings.HasPrefix(inv.TranDtls.SupTyp, "SEZ")
+	check(len(inv.ItemList) > 0 && len(inv.ItemList) <= 1000, "ItemList should have 1 to 1000 items")
+	sums := IRNValues{}
+	for i, item := range inv.ItemList {
+		field := fmt.Sprintf("ItemList[%d]", i)
+		check(len(item.SlNo) > 0 && len(item.SlNo) <= 6, "%s.SlNo should have 1 to 6 characters", field)
+		check(hsnPattern.MatchString(item.HsnCd), "%s.HsnCd %q should have 4 to 8 digits", field, item.HsnCd)
+		check(isYesNo(item.IsServc), "%s.IsServc should be Y or N", field)
+		check(item.IsServc == "N" || IsService(item.HsnCd), "%s.HsnCd %q of a service should be a SAC starting with 99", field, item.HsnCd)
+		check(gstRates[item.GstRt], "%s.GstRt %v is not a GST rate", field, item.GstRt)
+		if intraState {
+			check(item.IgstAmt == 0, "%s.IgstAmt should be 0 in the intra-state supplies", field)
+		} else {
+			check(item.CgstAmt == 0 && item.SgstAmt == 0, "%s.CgstAmt and SgstAmt should be 0 in the inter-state supplies", field)
+		}
+		check(near(item.TotAmt, item.UnitPrice*item.Qty), "%s.TotAmt %v should be UnitPrice * Qty", field, item.TotAmt)
+		check(near(item.AssAmt, item.TotAmt-item.Discount), "%s.AssAmt %v should be TotAmt - Discount", field, item.AssAmt)
+		check(near(item.TotItemVal, item.AssAmt+item.IgstAmt+item.CgstAmt+item.SgstAmt+item.CesAmt),
+			"%s.TotItemVal %v should be the sum of AssAmt and the taxes", field, item.TotItemVal)
+
+		sums.AssVal += item.AssAmt
+		sums.CgstVal += item.CgstAmt
+		sums.SgstVal += item.SgstAmt
+		sums.IgstVal += item.IgstAmt
+		sums.CesVal += item.CesAmt
+		sums.TotInvVal += item.TotItemVal
+	}
+
+	values := inv.ValDtls
+	check(near(values.AssVal, sums.AssVal), "ValDtls.AssVal %v should be the sum of the AssAmt of the items", values.AssVal)
+	check(near(values.CgstVal, sums.CgstVal), "ValDtls.CgstVal %v should be the sum of the CgstAmt of the items", values.CgstVal)
+	check(near(values.SgstVal, sums.SgstVal), "ValDtls.SgstVal %v should be the sum of the SgstAmt of the items", values.SgstVal)
+	check(near(values.IgstVal, sums.IgstVal), "ValDtls.IgstVal %v should be the sum of the IgstAmt of the items", values.IgstVal)
+	check(near(values.CesVal, sums.CesVal), "ValDtls.CesVal %v should be the sum of the CesAmt of the items", values.CesVal)
+	check(near(values.TotInvVal, sums.TotInvVal-values.Discount+values.OthChrg+values.RndOffAmt),
+		"ValDtls.TotInvVal %v should be the sum of the TotItemVal of the items with the charges and the rounding", values.TotInvVal)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidIRN, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateParty(field string, p IRNParty, foreign bool) []string {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	if foreign {
+		check(p.Gstin == UnregisteredPerson, "%s.Gstin of a foreign party should be %s", field, UnregisteredPerson)
+		check(p.Pin == 999999, "%s.Pin of a foreign party should be 999999", field)
+		check(p.Stcd == ForeignCountry, "%s.Stcd of a foreign party should be %s", field, ForeignCountry)
+	} else {
+		if err := ValidateGSTIN(p.Gstin); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.Gstin: %v", field, err))
+		}
+		check(p.Pin >= 100000 && p.Pin <= 999999, "%s.Pin %d should have 6 digits", field, p.Pin)
+		_, knownState := stateNames[p.Stcd]
+		check(knownState, "unknown %s.Stcd %q", field, p.Stcd)
+	}
+	check(len(p.LglNm) >= 3 && len(p.LglNm) <= 100, "%s.LglNm should have 3 to 100 characters", field)
+	check(len(p.Addr1) >= 1 && len(p.Addr1) <= 100, "%s.Addr1 should have 1 to 100 characters", field)
+	check(len(p.Addr2) <= 100, "%s.Addr2 should have up to 100 characters", field)
+	check(len(p.Loc) >= 3 && len(p.Loc) <= 50, "%s.Loc should have 3 to 50 characters", field)
+	check(p.Ph == "" || len(p.Ph) >= 6 && len(p.Ph) <= 12, "%s.Ph should have 6 to 12 digits", field)
+	check(p.Em == "" || strings.Contains(p.Em, "@") && len(p.Em) <= 100, "%s.Em %q is not an email", field, p.Em)
+
+	return problems
+}
+
+//WriteJSON writes the e-invoice as indented JSON
+func (inv *IRNInvoice) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(inv)
+}
+
+func isYesNo(value string) bool {
+	return value == "Y" || value == "N"
+}
+
+//near tells if the values match within the tolerance of one rupee of the portal
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1
+}
+
+func digits(value string) string {
+	b := strings.Builder{}
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+//countryCode returns the country code of the exports when the country is given as a two letter code
+func countryCode(country string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if len(country) == 2 {
+		return country
+	}
+	return ""
+}
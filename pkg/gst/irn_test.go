@@ -0,0 +1,229 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+var testVatRates = sales.VatRates{
+	{ID: "1", Name: "GST 18%", Rate: "18", Components: []sales.VatRateComponent{
+		{ID: 11, VatRateID: 1, Name: "CGST 9%", Type: "CGST", Rate: "9"},
+		{ID: 12, VatRateID: 1, Name: "SGST 9%", Type: "SGST", Rate: "9"},
+		{ID: 13, VatRateID: 1, Name: "IGST 18%", Type: "IGST", Rate: "18"},
+	}},
+	{ID: "2", Name: "GST 5%", Rate: "5"},
+	{ID: "3", Name: "GST 28% + cess", Rate: "40", Components: []sales.VatRateComponent{
+		{ID: 31, VatRateID: 3, Name: "CGST 14%", Type: "CGST", Rate: "14"},
+		{ID: 32, VatRateID: 3, Name: "SGST 14%", Type: "SGST", Rate: "14"},
+		{ID: 33, VatRateID: 3, Name: "IGST 28%", Type: "IGST", Rate: "28"},
+		{ID: 34, VatRateID: 3, Name: "Cess 12%", Type: "CESS", Rate: "12"},
+	}},
+}
+
+func testProduct(id int, hsn, unit string) product.Product {
+	p := product.Product{ProductID: id}
+	if unit != "" {
+		p.UnitName = &unit
+	}
+	if hsn != "" {
+		p.Attributes = sharedCommon.Attributes{Attributes: []sharedCommon.ObjAttribute{
+			{AttributeName: HSNAttribute, AttributeType: "text", AttributeValue: hsn},
+		}}
+	}
+	return p
+}
+
+var testProducts = map[int]product.Product{
+	1: testProduct(1, "85171300", "pcs"),
+	2: testProduct(2, "998314", ""),
+	3: testProduct(3, "87032291", "Pcs"),
+	4: testProduct(4, "", "pcs"),
+}
+
+var testSeller = Party{
+	GSTIN:     "29AAGCB7383J1Z4",
+	LegalName: "Bhojpur Consulting Private Limited",
+	TradeName: "Bhojpur",
+	Address1:  "12 MG Road",
+	Location:  "Bengaluru",
+	PIN:       "560001",
+	State:     "Karnataka",
+	Country:   "India",
+	Phone:     "+91 80 4123 4567",
+	Email:     "billing@bhojpur.example",
+}
+
+//testDocument is an intra-state invoice of a phone with a discount and a service
+func testDocument() *Document {
+	return &Document{
+		Sale: sales.SaleDocument{
+			ID:        100,
+			Type:      sales.SaleDocumentTypeInvoice,
+			Number:    "INV/2022/100",
+			Date:      "2022-07-15",
+			Confirmed: "1",
+			Rounding:  -0.3,
+			InvoiceRows: []sales.InvoiceRow{
+				{ProductID: "1", ItemName: "Phone", VatrateID: "1", Amount: "2", Price: "10000", Discount: "10", RowNetTotal: 18000},
+				{ProductID: "2", ItemName: "Installation", VatrateID: "1", Amount: "1", Price: "1500.5", RowNetTotal: 1500.5},
+			},
+		},
+		Seller: testSeller,
+		Buyer: Party{
+			GSTIN:     "29AABCT1332L1ZA",
+			LegalName: "Tirupati Traders",
+			Address1:  "4 Residency Road",
+			Location:  "Bengaluru",
+			PIN:       "560025",
+			State:     "Karnataka",
+		},
+		VatRates: testVatRates,
+		Products: testProducts,
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	return data
+}
+
+func TestIRN(t *testing.T) {
+	inv, err := testDocument().IRN()
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, inv.WriteJSON(buf))
+	assert.JSONEq(t, string(readFixture(t, "irn_b2b.json")), buf.String())
+}
+
+func TestIRNInterState(t *testing.T) {
+	doc := testDocument()
+	shipTo := doc.Buyer
+	shipTo.GSTIN = "33AAACB1234C1ZM"
+	shipTo.Address1 = "8 Anna Salai"
+	shipTo.Location = "Chennai"
+	shipTo.PIN = "600002"
+	shipTo.State = "Tamil Nadu"
+	doc.ShipTo = &shipTo
+	doc.Sale.InvoiceRows = append(doc.Sale.InvoiceRows,
+		sales.InvoiceRow{ProductID: "3", ItemName: "Car", VatrateID: "3", Amount: "1", Price: "500000", RowNetTotal: 500000})
+
+	inv, err := doc.IRN()
+	assert.NoError(t, err)
+	assert.Equal(t, "33", inv.BuyerDtls.Pos)
+	assert.Equal(t, "33", inv.ShipDtls.Stcd)
+	assert.Equal(t, IRNItem{
+		SlNo: "3", PrdDesc: "Car", IsServc: "N", HsnCd: "87032291", Qty: 1, Unit: "NOS", UnitPrice: 500000,
+		TotAmt: 500000, AssAmt: 500000, GstRt: 28, IgstAmt: 140000, CesRt: 12, CesAmt: 60000, TotItemVal: 700000,
+	}, inv.ItemList[2])
+	assert.Equal(t, IRNValues{
+		AssVal: 519500.5, IgstVal: 143510.09, CesVal: 60000, RndOffAmt: -0.3, TotInvVal: 723010.29,
+	}, inv.ValDtls)
+}
+
+func TestIRNCreditNoteAndExport(t *testing.T) {
+	doc := testDocument()
+	doc.Sale.Type = sales.SaleDocumentTypeCreditInvoice
+	doc.Sale.Rounding = 0.3
+	doc.Sale.InvoiceRows = doc.Sale.InvoiceRows[1:]
+	doc.Sale.InvoiceRows[0].Amount = "-1"
+	doc.Sale.InvoiceRows[0].RowNetTotal = -1500.5
+
+	inv, err := doc.IRN()
+	assert.NoError(t, err)
+	assert.Equal(t, "CRN", inv.DocDtls.Typ)
+	assert.Equal(t, IRNValues{AssVal: 1500.5, CgstVal: 135.05, SgstVal: 135.05, RndOffAmt: -0.3, TotInvVal: 1770.3}, inv.ValDtls)
+
+	doc = testDocument()
+	doc.Sale.CurrencyCode = "USD"
+	doc.Sale.CurrencyRate = "80"
+	doc.Sale.Rounding = 0
+	doc.Sale.InvoiceRows = []sales.InvoiceRow{
+		{ProductID: "1", ItemName: "Phone", VatrateID: "1", Amount: "1", Price: "125", RowNetTotal: 125},
+	}
+	doc.Buyer = Party{LegalName: "Acme Inc", Address1: "1 Main Street", Location: "Springfield", Country: "US"}
+	doc.WithPayment = true
+
+	inv, err = doc.IRN()
+	assert.NoError(t, err)
+	assert.Equal(t, "EXPWP", inv.TranDtls.SupTyp)
+	assert.Equal(t, IRNParty{Gstin: "URP", LglNm: "Acme Inc", Pos: "96", Addr1: "1 Main Street", Loc: "Springfield", Pin: 999999, Stcd: "96"}, inv.BuyerDtls)
+	assert.Equal(t, &IRNExport{ForCur: "USD", CntCode: "US"}, inv.ExpDtls)
+	assert.Equal(t, IRNValues{AssVal: 10000, IgstVal: 1800, TotInvVal: 11800}, inv.ValDtls)
+}
+
+func TestIRNErrors(t *testing.T) {
+	doc := testDocument()
+	doc.Buyer.GSTIN = ""
+	_, err := doc.IRN()
+	assert.True(t, errors.Is(err, ErrIRNNotApplicable))
+
+	doc = testDocument()
+	doc.Sale.InvoiceRows[1].ProductID = "4"
+	_, err = doc.IRN()
+	assert.True(t, errors.Is(err, ErrMissingHSN))
+	assert.EqualError(t, err, "sales document 100 row 2: missing HSN/SAC code of product 4, set the hsnCode attribute")
+
+	doc = testDocument()
+	doc.Sale.InvoiceRows[0].ProductID = "9"
+	_, err = doc.IRN()
+	assert.True(t, errors.Is(err, ErrUnknownProduct))
+
+	doc = testDocument()
+	doc.Sale.Number = "0/22"
+	doc.Buyer.PIN = "56002"
+	doc.Buyer.GSTIN = "29AABCT1332L1ZB"
+	_, err = doc.IRN()
+	assert.True(t, errors.Is(err, ErrInvalidIRN))
+	assert.EqualError(t, err, `sales document 100: invalid e-invoice: `+
+		`DocDtls.No "0/22" should have up to 16 letters, digits, / or - and not start with 0, / or -; `+
+		`BuyerDtls.Gstin: invalid GSTIN "29AABCT1332L1ZB": check character should be A; `+
+		`BuyerDtls.Pin 56002 should have 6 digits`)
+}
+
+//TestIRNSchemaFixture checks that the types cover every field of the sample of the portal
+//and the sample passes the validation
+func TestIRNSchemaFixture(t *testing.T) {
+	decoder := json.NewDecoder(bytes.NewReader(readFixture(t, "irn_schema_sample.json")))
+	decoder.DisallowUnknownFields()
+	inv := &IRNInvoice{}
+	assert.NoError(t, decoder.Decode(inv))
+	assert.NoError(t, inv.Validate())
+
+	inv.ItemList[0].GstRt = 17
+	inv.ItemList[0].IgstAmt = 1
+	err := inv.Validate()
+	assert.True(t, strings.Contains(err.Error(), "ItemList[0].GstRt 17 is not a GST rate"))
+	assert.True(t, strings.Contains(err.Error(), "ItemList[0].IgstAmt should be 0 in the intra-state supplies"))
+}
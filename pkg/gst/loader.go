@@ -0,0 +1,309 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//Loader reads the sales documents with their parties and products, the company info, the VAT rates,
+//the warehouses, the customers and the products are cached between the documents
+type Loader struct {
+	Sales      sales.Manager
+	Companies  company.Manager
+	Customers  customer.Manager
+	Warehouses warehouse.Manager
+	Products   product.Manager
+	//UnitCode is the unique quantity code of the rows of products with an unknown unit
+	UnitCode string
+	//WithPayment tells if IGST is paid on the exports and the SEZ supplies
+	WithPayment bool
+
+	info       *company.Info
+	vatRates   sales.VatRates
+	warehouses map[int]warehouse.Warehouse
+	customers  map[int]customer.Customer
+	products   map[int]product.Product
+}
+
+//LoadDocument reads the sales document with its rows and everything needed to calculate its GST
+func (l *Loader) LoadDocument(ctx context.Context, documentID int) (*Document, error) {
+	docs, err := l.Sales.GetSalesDocuments(ctx, map[string]string{
+		"id":                    strconv.Itoa(documentID),
+		"getRowsForAllInvoices": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("sales document %d is not found", documentID)
+	}
+	return l.Document(ctx, docs[0])
+}
+
+//Document completes the sales document read with its rows
+func (l *Loader) Document(ctx context.Context, doc sales.SaleDocument) (*Document, error) {
+	if err := l.loadCompany(ctx); err != nil {
+		return nil, err
+	}
+
+	w, err := l.warehouse(ctx, doc.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	buyerID, recipientID := parties(doc)
+	buyer, err := l.customer(ctx, buyerID)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make([]int, 0, len(doc.InvoiceRows))
+	for _, row := range doc.InvoiceRows {
+		id, _ := strconv.Atoi(row.ProductID)
+		productIDs = append(productIDs, id)
+	}
+	if err := l.loadProducts(ctx, productIDs); err != nil {
+		return nil, err
+	}
+
+	document := &Document{
+		Sale:         doc,
+		Seller:       CompanyParty(*l.info, w),
+		Buyer:        CustomerParty(buyer),
+		CustomerType: buyer.IndiaCustomerType,
+		VatRates:     l.vatRates,
+		Products:     l.products,
+		UnitCode:     l.UnitCode,
+		WithPayment:  l.WithPayment,
+	}
+
+	if recipientID == buyerID && doc.ShipToAddressID == "" {
+		return document, nil
+	}
+	recipient := buyer
+	if recipientID != buyerID {
+		if recipient, err = l.customer(ctx, recipientID); err != nil {
+			return nil, err
+		}
+	}
+	shipTo := CustomerParty(recipient)
+	for _, address := range recipient.CustomerAddresses {
+		if strconv.Itoa(address.AddressID) == doc.ShipToAddressID {
+			shipTo = shipTo.WithAddress(address)
+			break
+		}
+	}
+	document.ShipTo = &shipTo
+
+	return document, nil
+}
+
+//LoadTransfer reads the inventory transfer with the company at the addresses of the warehouses
+func (l *Loader) LoadTransfer(ctx context.Context, transferID int) (*Transfer, error) {
+	transfers, err := l.Warehouses.GetInventoryTransfers(ctx, map[string]string{
+		"inventoryTransferIDs": strconv.Itoa(transferID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("inventory transfer %d is not found", transferID)
+	}
+	transfer := transfers[0]
+
+	if err := l.loadCompany(ctx); err != nil {
+		return nil, err
+	}
+	from, err := l.warehouse(ctx, transfer.WarehouseFromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := l.warehouse(ctx, transfer.WarehouseToID)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make([]int, 0, len(transfer.Rows))
+	for _, row := range transfer.Rows {
+		productIDs = append(productIDs, row.ProductID)
+	}
+	if err := l.loadProducts(ctx, productIDs); err != nil {
+		return nil, err
+	}
+
+	return &Transfer{
+		Transfer: transfer,
+		From:     CompanyParty(*l.info, from),
+		To:       CompanyParty(*l.info, to),
+		VatRates: l.vatRates,
+		Products: l.products,
+		UnitCode: l.UnitCode,
+	}, nil
+}
+
+//Load adds the sales documents of the month of the builder to GSTR-1
+func (b *GSTR1Builder) Load(ctx context.Context, loader *Loader, settings sharedCommon.ListingSettings) error {
+	types := make([]string, 0, len(saleTypes))
+	for docType := range saleTypes {
+		types = append(types, docType)
+	}
+	sort.Strings(types)
+
+	filters := map[string]interface{}{
+		"types":                 strings.Join(types, ","),
+		"getRowsForAllInvoices": 1,
+	}
+	sharedCommon.TimeFilters{DateFrom: b.TimeZone.Day(b.Month), DateTo: b.TimeZone.Day(b.Month.AddDate(0, 1, -1))}.ApplyToListing(b.TimeZone, filters)
+	lister := sharedCommon.NewLister(settings, sales.NewSaleDocumentsListingDataProvider(loader.Sales), nil)
+	for item := range lister.Get(ctx, filters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		doc := item.Payload.(sales.SaleDocument)
+		if !saleTypes[strings.ToUpper(doc.Type)] || doc.Confirmed == "0" || strings.EqualFold(doc.InvoiceState, "CANCELLED") {
+			continue
+		}
+		document, err := loader.Document(ctx, doc)
+		if err != nil {
+			return err
+		}
+		if err := b.Add(document); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) loadCompany(ctx context.Context) error {
+	if l.info != nil {
+		return nil
+	}
+	info, err := l.Companies.GetCompanyInfo(ctx)
+	if err != nil {
+		return err
+	}
+	vatRates, err := l.Sales.GetVatRates(ctx, map[string]string{})
+	if err != nil {
+		return err
+	}
+	l.info, l.vatRates = info, vatRates
+	return nil
+}
+
+func (l *Loader) warehouse(ctx context.Context, warehouseID int) (warehouse.Warehouse, error) {
+	if w, ok := l.warehouses[warehouseID]; ok {
+		return w, nil
+	}
+	found, err := l.Warehouses.GetWarehouses(ctx, map[string]string{"warehouseID": strconv.Itoa(warehouseID)})
+	if err != nil {
+		return warehouse.Warehouse{}, err
+	}
+	for _, w := range found {
+		if w.WarehouseID == strconv.Itoa(warehouseID) {
+			if l.warehouses == nil {
+				l.warehouses = map[int]warehouse.Warehouse{}
+			}
+			l.warehouses[warehouseID] = w
+			return w, nil
+		}
+	}
+	return warehouse.Warehouse{}, fmt.Errorf("warehouse %d is not found", warehouseID)
+}
+
+func (l *Loader) customer(ctx context.Context, customerID int) (customer.Customer, error) {
+	if c, ok := l.customers[customerID]; ok {
+		return c, nil
+	}
+	found, err := l.Customers.GetCustomers(ctx, map[string]string{
+		"customerID":   strconv.Itoa(customerID),
+		"getAddresses": "1",
+	})
+	if err != nil {
+		return customer.Customer{}, err
+	}
+	if len(found) == 0 {
+		return customer.Customer{}, fmt.Errorf("customer %d is not found", customerID)
+	}
+	if l.customers == nil {
+		l.customers = map[int]customer.Customer{}
+	}
+	l.customers[customerID] = found[0]
+	return found[0], nil
+}
+
+//loadProducts reads the products which are not cached yet
+func (l *Loader) loadProducts(ctx context.Context, productIDs []int) error {
+	if l.products == nil {
+		l.products = map[int]product.Product{}
+	}
+	ids := []string{}
+	seen := map[int]bool{}
+	for _, id := range productIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, ok := l.products[id]; !ok {
+			ids = append(ids, strconv.Itoa(id))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	found, err := l.Products.GetProducts(ctx, map[string]string{
+		"productIDs":    strings.Join(ids, ","),
+		"recordsOnPage": "1000",
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range found {
+		l.products[p.ProductID] = p
+	}
+	return nil
+}
+
+//parties returns the payer and the recipient of the document
+func parties(doc sales.SaleDocument) (buyerID, recipientID int) {
+	recipientID = doc.CustomerID
+	if recipientID == 0 {
+		recipientID = doc.ClientID
+	}
+	buyerID = recipientID
+	if doc.PayerID != 0 {
+		buyerID = doc.PayerID
+	}
+	if doc.ShipToID != 0 {
+		recipientID = doc.ShipToID
+	}
+	return buyerID, recipientID
+}
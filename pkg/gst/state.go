@@ -0,0 +1,143 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//ForeignCountry is the state code of the place of supply of the exports
+const ForeignCountry = "96"
+
+var ErrUnknownState = errors.New("unknown state")
+
+//stateNames are the two digit GST state codes with the names of the states and union territories
+var stateNames = map[string]string{
+	"01": "Jammu and Kashmir",
+	"02": "Himachal Pradesh",
+	"03": "Punjab",
+	"04": "Chandigarh",
+	"05": "Uttarakhand",
+	"06": "Haryana",
+	"07": "Delhi",
+	"08": "Rajasthan",
+	"09": "Uttar Pradesh",
+	"10": "Bihar",
+	"11": "Sikkim",
+	"12": "Arunachal Pradesh",
+	"13": "Nagaland",
+	"14": "Manipur",
+	"15": "Mizoram",
+	"16": "Tripura",
+	"17": "Meghalaya",
+	"18": "Assam",
+	"19": "West Bengal",
+	"20": "Jharkhand",
+	"21": "Odisha",
+	"22": "Chhattisgarh",
+	"23": "Madhya Pradesh",
+	"24": "Gujarat",
+	"26": "Dadra and Nagar Haveli and Daman and Diu",
+	"27": "Maharashtra",
+	"29": "Karnataka",
+	"30": "Goa",
+	"31": "Lakshadweep",
+	"32": "Kerala",
+	"33": "Tamil Nadu",
+	"34": "Puducherry",
+	"35": "Andaman and Nicobar Islands",
+	"36": "Telangana",
+	"37": "Andhra Pradesh",
+	"38": "Ladakh",
+	"96": "Foreign Country",
+	"97": "Other Territory",
+}
+
+//stateAliases are the former and the common alternative names of the states
+var stateAliases = map[string]string{
+	"orissa":              "21",
+	"pondicherry":         "34",
+	"uttaranchal":         "05",
+	"newdelhi":            "07",
+	"nctofdelhi":          "07",
+	"damananddiu":         "26",
+	"dadraandnagarhaveli": "26",
+	"andamanandnicobar":   "35",
+	"jk":                  "01",
+}
+
+//unionTerritories are the union territories without a legislature, they levy UTGST instead of SGST
+var unionTerritories = map[string]bool{
+	"04": true,
+	"26": true,
+	"31": true,
+	"35": true,
+	"38": true,
+}
+
+var stateCodes = func() map[string]string {
+	codes := make(map[string]string, len(stateNames)+len(stateAliases))
+	for code, name := range stateNames {
+		codes[normalizeState(name)] = code
+	}
+	for alias, code := range stateAliases {
+		codes[alias] = code
+	}
+	return codes
+}()
+
+//StateCode returns the two digit GST code of the state by its name or its code
+func StateCode(state string) (string, error) {
+	state = strings.TrimSpace(state)
+	if len(state) == 1 && state[0] >= '1' && state[0] <= '9' {
+		state = "0" + state
+	}
+	if _, ok := stateNames[state]; ok {
+		return state, nil
+	}
+	if code, ok := stateCodes[normalizeState(state)]; ok {
+		return code, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownState, state)
+}
+
+//StateName returns the name of the state by its two digit GST code
+func StateName(code string) string {
+	return stateNames[code]
+}
+
+//IsUnionTerritory tells if the state is a union territory without a legislature
+func IsUnionTerritory(code string) bool {
+	return unionTerritories[code]
+}
+
+func normalizeState(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "&", "and")
+	b := strings.Builder{}
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//SupplyType tells if the goods or services are supplied within the state or between the states
+type SupplyType string
+
+const (
+	IntraState SupplyType = "INTRA"
+	InterState SupplyType = "INTER"
+)
+
+//Types of the VAT rate components
+const (
+	TypeCGST  = "CGST"
+	TypeSGST  = "SGST"
+	TypeUTGST = "UTGST"
+	TypeIGST  = "IGST"
+	TypeCess  = "CESS"
+)
+
+var ErrMissingComponent = errors.New("missing GST component")
+
+//Supply determines the supply type from the state of the origin, the warehouse, and the place of supply,
+//the ship-to state. The supplies to foreign countries are inter-state
+func Supply(origin, placeOfSupply string) (SupplyType, error) {
+	from, err := StateCode(origin)
+	if err != nil {
+		return "", fmt.Errorf("origin: %w", err)
+	}
+	to, err := StateCode(placeOfSupply)
+	if err != nil {
+		return "", fmt.Errorf("place of supply: %w", err)
+	}
+	if from == to {
+		return IntraState, nil
+	}
+	return InterState, nil
+}
+
+//Rates are the GST rates of a VAT rate for a supply, either CGST and SGST or IGST.
+//SGST holds the UTGST in the union territories
+type Rates struct {
+	GST        float64
+	IGST       float64
+	CGST       float64
+	SGST       float64
+	Cess       float64
+	Components []sales.VatRateComponent
+}
+
+//Amounts are the tax amounts of a taxable value
+type Amounts struct {
+	IGST float64
+	CGST float64
+	SGST float64
+	Cess float64
+}
+
+//Total is the sum of the tax amounts
+func (a Amounts) Total() float64 {
+	return sharedCommon.RoundFloat(a.IGST+a.CGST+a.SGST+a.Cess, 2)
+}
+
+//RatesOf picks the CGST and SGST or UTGST components of the VAT rate for the intra-state supplies and
+//the IGST component for the inter-state ones. Cess is levied on both. A rate without the components of
+//the supply falls back to the other ones, IGST is the sum of CGST and SGST, and a rate without any
+//components is split in halves within the state
+func RatesOf(rate sales.VatRate, supply SupplyType) (Rates, error) {
+	var cgst, sgst, igst, cess []sales.VatRateComponent
+	for _, component := range rate.Components {
+		componentType := strings.ToUpper(strings.TrimSpace(component.Type))
+		if componentType == "" {
+			componentType = strings.ToUpper(strings.TrimSpace(component.Name))
+		}
+		switch componentType {
+		case TypeCGST:
+			cgst = append(cgst, component)
+		case TypeSGST, TypeUTGST:
+			sgst = append(sgst, component)
+		case TypeIGST:
+			igst = append(igst, component)
+		case TypeCess:
+			cess = append(cess, component)
+		}
+	}
+
+	rates := Rates{}
+	var err error
+	if rates.Cess, err = sumRates(cess); err != nil {
+		return Rates{}, fmt.Errorf("VAT rate %s: %v", rate.ID, err)
+	}
+	rates.Components = append(rates.Components, cess...)
+
+	switch {
+	case len(cgst)+len(sgst)+len(igst) == 0:
+		value, err := sharedCommon.ParseDecimal(rate.Rate)
+		if err != nil {
+			return Rates{}, fmt.Errorf("VAT rate %s: invalid rate %q", rate.ID, rate.Rate)
+		}
+		total := value.Sub(sharedCommon.DecimalFromFloat(rates.Cess)).Float64()
+		if supply == IntraState {
+			rates.CGST, rates.SGST = total/2, total/2
+		} else {
+			rates.IGST = total
+		}
+	case supply == IntraState && len(cgst)+len(sgst) > 0:
+		if len(cgst) == 0 || len(sgst) == 0 {
+			return Rates{}, fmt.Errorf("%w: VAT rate %s needs both CGST and SGST or UTGST", ErrMissingComponent, rate.ID)
+		}
+		if rates.CGST, err = sumRates(cgst); err != nil {
+			return Rates{}, fmt.Errorf("VAT rate %s: %v", rate.ID, err)
+		}
+		if rates.SGST, err = sumRates(sgst); err != nil {
+			return Rates{}, fmt.Errorf("VAT rate %s: %v", rate.ID, err)
+		}
+		rates.Components = append(append(rates.Components, cgst...), sgst...)
+	case supply == IntraState:
+		igstRate, err := sumRates(igst)
+		if err != nil {
+			return Rates{}, fmt.Errorf("VAT rate %s: %v", rate.ID, err)
+		}
+		rates.CGST, rates.SGST = igstRate/2, igstRate/2
+		rates.Components = append(rates.Components, igst...)
+	case len(igst) > 0:
+		if rates.IGST, err = sumRates(igst); err != nil {
+			return Rates{}, fmt.Errorf("VAT rate %s: %v", rate.ID, err)
+		}
+		rates.Components = append(rates.Components, igst...)
+	default:
+		if rates.IGST, err = sumRates(append(append([]sales.VatRateComponent{}, cgst...), sgst...)); err != nil {
+			return Rates{}, fmt.Errorf("VAT rate %s: %v", rate.ID, err)
+		}
+		rates.Components = append(append(rates.Components, cgst...), sgst...)
+	}
+	rates.GST = sharedCommon.RoundFloat(rates.IGST+rates.CGST+rates.SGST, 2)
+
+	return rates, nil
+}
+
+//Amounts calculates the tax of the taxable value, CGST and SGST are rounded separately
+func (r Rates) Amounts(taxable float64) Amounts {
+	return Amounts{
+		IGST: percentOf(taxable, r.IGST),
+		CGST: percentOf(taxable, r.CGST),
+		SGST: percentOf(taxable, r.SGST),
+		Cess: percentOf(taxable, r.Cess),
+	}
+}
+
+//percentOf calculates the percent of the amount exactly and rounds it to paise
+func percentOf(amount, rate float64) float64 {
+	value, _ := sharedCommon.DecimalFromFloat(amount).Mul(sharedCommon.DecimalFromFloat(rate)).Div(sharedCommon.DecimalFromInt(100), 2)
+	return value.Float64()
+}
+
+func sumRates(components []sales.VatRateComponent) (float64, error) {
+	sum := sharedCommon.Decimal{}
+	for _, component := range components {
+		value, err := sharedCommon.ParseDecimal(component.Rate)
+		if err != nil {
+			return 0, fmt.Errorf("invalid rate %q of component %d", component.Rate, component.ID)
+		}
+		sum = sum.Add(value)
+	}
+	return sum.Float64(), nil
+}
@@ -0,0 +1,91 @@
+package gst
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupply(t *testing.T) {
+	supply, err := Supply("Karnataka", "29")
+	assert.NoError(t, err)
+	assert.Equal(t, IntraState, supply)
+
+	supply, err = Supply("Karnataka", "Tamil Nadu")
+	assert.NoError(t, err)
+	assert.Equal(t, InterState, supply)
+
+	supply, err = Supply("Karnataka", ForeignCountry)
+	assert.NoError(t, err)
+	assert.Equal(t, InterState, supply)
+
+	_, err = Supply("Karnataka", "")
+	assert.EqualError(t, err, `place of supply: unknown state ""`)
+}
+
+func TestRatesOf(t *testing.T) {
+	full := sales.VatRate{ID: "1", Rate: "18", Components: []sales.VatRateComponent{
+		{ID: 11, Type: "CGST", Rate: "9"},
+		{ID: 12, Type: "SGST", Rate: "9"},
+		{ID: 13, Type: "IGST", Rate: "18"},
+	}}
+	withCess := sales.VatRate{ID: "2", Rate: "40", Components: []sales.VatRateComponent{
+		{ID: 21, Name: "CGST", Rate: "14"},
+		{ID: 22, Name: "UTGST", Rate: "14"},
+		{ID: 23, Name: "Cess", Type: "cess", Rate: "12"},
+	}}
+	plain := sales.VatRate{ID: "3", Rate: "5"}
+	igstOnly := sales.VatRate{ID: "4", Rate: "12", Components: []sales.VatRateComponent{{ID: 41, Type: "IGST", Rate: "12"}}}
+
+	testCases := []struct {
+		rate     sales.VatRate
+		supply   SupplyType
+		expected Rates
+	}{
+		{full, IntraState, Rates{GST: 18, CGST: 9, SGST: 9, Components: full.Components[:2]}},
+		{full, InterState, Rates{GST: 18, IGST: 18, Components: full.Components[2:]}},
+		{withCess, IntraState, Rates{GST: 28, CGST: 14, SGST: 14, Cess: 12, Components: []sales.VatRateComponent{
+			withCess.Components[2], withCess.Components[0], withCess.Components[1],
+		}}},
+		{withCess, InterState, Rates{GST: 28, IGST: 28, Cess: 12, Components: []sales.VatRateComponent{
+			withCess.Components[2], withCess.Components[0], withCess.Components[1],
+		}}},
+		{plain, IntraState, Rates{GST: 5, CGST: 2.5, SGST: 2.5}},
+		{plain, InterState, Rates{GST: 5, IGST: 5}},
+		{igstOnly, IntraState, Rates{GST: 12, CGST: 6, SGST: 6, Components: igstOnly.Components}},
+	}
+	for i, testCase := range testCases {
+		rates, err := RatesOf(testCase.rate, testCase.supply)
+		assert.NoError(t, err, i)
+		assert.Equal(t, testCase.expected, rates, i)
+	}
+
+	_, err := RatesOf(sales.VatRate{ID: "5", Components: []sales.VatRateComponent{{ID: 51, Type: "CGST", Rate: "9"}}}, IntraState)
+	assert.True(t, errors.Is(err, ErrMissingComponent))
+
+	rates, _ := RatesOf(withCess, IntraState)
+	assert.Equal(t, Amounts{CGST: 14.14, SGST: 14.14, Cess: 12.12}, rates.Amounts(101))
+	assert.Equal(t, 40.4, rates.Amounts(101).Total())
+}
@@ -0,0 +1,267 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "github.com/bhojpur/erp/pkg/api/v1"
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/internal/common"
+)
+
+//RowResult is the outcome of saving one row
+type RowResult struct {
+	ID int
+	//AlreadyExisted is set when the API found an existing record instead of creating a new one
+	AlreadyExisted bool
+	//Err is a *sharedCommon.ErpError if the API rejected the row
+	Err        error
+	ErrorField string
+}
+
+//Saver sends the bulk request and gives one result per request item. An error means that the whole chunk failed
+type Saver func(ctx context.Context, requests []map[string]interface{}) ([]RowResult, error)
+
+//Entity describes the validation rules and the bulk save call of an importable entity
+type Entity struct {
+	Name string
+	//IDField is the field of an existing record ID, rows having it update records instead of creating them
+	IDField string
+	//RequiredOnCreate are the fields required for new records
+	RequiredOnCreate []string
+	//RequiredOneOfOnCreate is the list of fields where at least one should be set for new records
+	RequiredOneOfOnCreate []string
+	//RequiredOnUpdate are the fields required for updates in addition to IDField
+	RequiredOnUpdate []string
+	IntFields        []string
+	NumberFields     []string
+	NewSaver         func(cli *v1.Client) Saver
+}
+
+//ValidationError tells which field of the row is invalid
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (ve *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ve.Field, ve.Message)
+}
+
+//Entities are the importable entities by their names
+var Entities = map[string]Entity{
+	"products": {
+		Name:             "products",
+		IDField:          "productID",
+		RequiredOnCreate: []string{"groupID"},
+		IntFields:        []string{"productID", "groupID", "vatrateID", "unitID", "categoryID", "brandID", "supplierID", "priorityGroupID"},
+		NumberFields:     []string{"price", "cost", "netWeight", "grossWeight", "volume", "length", "width", "height"},
+		NewSaver: func(cli *v1.Client) Saver {
+			return func(ctx context.Context, requests []map[string]interface{}) ([]RowResult, error) {
+				resp, err := cli.ProductManager.SaveProductBulk(ctx, requests, map[string]string{})
+				items := make([]bulkItemResult, 0, len(resp.BulkItems))
+				for _, bulkItem := range resp.BulkItems {
+					item := bulkItemResult{status: bulkItem.Status}
+					if len(bulkItem.Products) > 0 {
+						item.id = bulkItem.Products[0].ProductID
+					}
+					items = append(items, item)
+				}
+				return collectResults(len(requests), items, err)
+			}
+		},
+	},
+	"customers": {
+		Name:                  "customers",
+		IDField:               "customerID",
+		RequiredOneOfOnCreate: []string{"companyName", "lastName", "fullName"},
+		IntFields:             []string{"customerID", "groupID", "countryID", "paymentDays", "customerManagerID", "priceListID"},
+		NumberFields:          []string{"credit"},
+		NewSaver: func(cli *v1.Client) Saver {
+			return func(ctx context.Context, requests []map[string]interface{}) ([]RowResult, error) {
+				resp, err := cli.CustomerManager.SaveCustomerBulk(ctx, requests, map[string]string{})
+				items := make([]bulkItemResult, 0, len(resp.BulkItems))
+				for _, bulkItem := range resp.BulkItems {
+					item := bulkItemResult{status: bulkItem.Status}
+					if len(bulkItem.Records) > 0 {
+						item.id = bulkItem.Records[0].CustomerID
+						item.alreadyExisted = bulkItem.Records[0].AlreadyExists
+					}
+					items = append(items, item)
+				}
+				return collectResults(len(requests), items, err)
+			}
+		},
+	},
+	"suppliers": {
+		Name:                  "suppliers",
+		IDField:               "supplierID",
+		RequiredOneOfOnCreate: []string{"companyName", "fullName"},
+		IntFields:             []string{"supplierID", "groupID", "countryID", "paymentDays", "supplierManagerID"},
+		NewSaver: func(cli *v1.Client) Saver {
+			return func(ctx context.Context, requests []map[string]interface{}) ([]RowResult, error) {
+				resp, err := cli.CustomerManager.SaveSupplierBulk(ctx, requests, map[string]string{})
+				items := make([]bulkItemResult, 0, len(resp.BulkItems))
+				for _, bulkItem := range resp.BulkItems {
+					item := bulkItemResult{status: bulkItem.Status}
+					if len(bulkItem.Records) > 0 {
+						item.id = bulkItem.Records[0].SupplierID
+						item.alreadyExisted = bulkItem.Records[0].AlreadyExists
+					}
+					items = append(items, item)
+				}
+				return collectResults(len(requests), items, err)
+			}
+		},
+	},
+	"prices": {
+		Name:             "prices",
+		IDField:          "priceListProductID",
+		RequiredOnCreate: []string{"priceListID", "productID", "price"},
+		RequiredOnUpdate: []string{"price"},
+		IntFields:        []string{"priceListProductID", "priceListID", "productID", "amount"},
+		NumberFields:     []string{"price", "discountPercent"},
+		NewSaver: func(cli *v1.Client) Saver {
+			return func(ctx context.Context, requests []map[string]interface{}) ([]RowResult, error) {
+				resp, err := cli.PricesManager.ChangeProductToPriceListBulk(ctx, requests, map[string]string{})
+				items := make([]bulkItemResult, 0, len(resp.BulkItems))
+				for _, bulkItem := range resp.BulkItems {
+					item := bulkItemResult{status: bulkItem.Status}
+					if len(bulkItem.Records) > 0 {
+						item.id = bulkItem.Records[0].PriceListProductID
+					}
+					items = append(items, item)
+				}
+				return collectResults(len(requests), items, err)
+			}
+		},
+	},
+}
+
+//EntityNames gives the sorted names of the importable entities
+func EntityNames() []string {
+	names := make([]string, 0, len(Entities))
+	for name := range Entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+//IsUpdate tells if the row changes an existing record
+func (e Entity) IsUpdate(fields map[string]string) bool {
+	return e.IDField != "" && fields[e.IDField] != ""
+}
+
+//Validate checks the row fields before sending them, required are the additional fields required by the mapping
+func (e Entity) Validate(fields map[string]string, required []string) error {
+	requiredFields := make([]string, 0, len(required)+len(e.RequiredOnCreate))
+	requiredFields = append(requiredFields, required...)
+	if e.IsUpdate(fields) {
+		requiredFields = append(requiredFields, e.RequiredOnUpdate...)
+	} else {
+		requiredFields = append(requiredFields, e.RequiredOnCreate...)
+	}
+	for _, field := range requiredFields {
+		if fields[field] == "" {
+			return &ValidationError{Field: field, Message: "value is required"}
+		}
+	}
+
+	if !e.IsUpdate(fields) && len(e.RequiredOneOfOnCreate) > 0 {
+		found := false
+		for _, field := range e.RequiredOneOfOnCreate {
+			if fields[field] != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ValidationError{
+				Field:   strings.Join(e.RequiredOneOfOnCreate, "|"),
+				Message: "one of the fields is required",
+			}
+		}
+	}
+
+	for _, field := range e.IntFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("%q is not an integer", value)}
+		}
+	}
+
+	for _, field := range e.NumberFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("%q is not a number", value)}
+		}
+	}
+
+	return nil
+}
+
+type bulkItemResult struct {
+	status         sharedCommon.StatusBulk
+	id             int
+	alreadyExisted bool
+}
+
+//collectResults converts the bulk items to row results, the bulk calls return an error if any item failed,
+//so the error is used only if the response doesn't have a result for every request
+func collectResults(requestsCount int, items []bulkItemResult, err error) ([]RowResult, error) {
+	if len(items) != requestsCount {
+		if err == nil {
+			err = fmt.Errorf("expected %d bulk results, got %d", requestsCount, len(items))
+		}
+		return nil, err
+	}
+
+	results := make([]RowResult, 0, len(items))
+	for _, item := range items {
+		if !common.IsJSONResponseOK(&item.status.Status) {
+			results = append(results, RowResult{
+				Err: sharedCommon.NewErpError(
+					item.status.ErrorCode.String(),
+					item.status.RequestName+": "+item.status.ResponseStatus,
+					item.status.ErrorCode,
+				),
+				ErrorField: item.status.ErrorField,
+			})
+			continue
+		}
+		results = append(results, RowResult{ID: item.id, AlreadyExisted: item.alreadyExisted})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,117 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/bhojpur/erp/pkg/api/v1"
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityValidate(t *testing.T) {
+	customers := Entities["customers"]
+	assert.NoError(t, customers.Validate(map[string]string{"lastName": "Singh"}, nil))
+	assert.NoError(t, customers.Validate(map[string]string{"customerID": "3", "email": "a@b.in"}, nil))
+	assert.EqualError(
+		t,
+		customers.Validate(map[string]string{"email": "a@b.in"}, nil),
+		"companyName|lastName|fullName: one of the fields is required",
+	)
+	assert.EqualError(
+		t,
+		customers.Validate(map[string]string{"lastName": "Singh", "groupID": "x"}, nil),
+		`groupID: "x" is not an integer`,
+	)
+	assert.EqualError(
+		t,
+		customers.Validate(map[string]string{"lastName": "Singh"}, []string{"email"}),
+		"email: value is required",
+	)
+
+	prices := Entities["prices"]
+	assert.NoError(t, prices.Validate(map[string]string{"priceListID": "1", "productID": "2", "price": "9.99"}, nil))
+	assert.NoError(t, prices.Validate(map[string]string{"priceListProductID": "5", "price": "9.99"}, nil))
+	assert.EqualError(t, prices.Validate(map[string]string{"priceListProductID": "5"}, nil), "price: value is required")
+	assert.EqualError(t, prices.Validate(map[string]string{"productID": "2", "price": "1"}, nil), "priceListID: value is required")
+}
+
+func TestProductsSaverPartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		requests := parsedRequest["requests"].([]map[string]interface{})
+		if assert.Len(t, requests, 2) {
+			assert.Equal(t, "saveProduct", requests[0]["requestName"])
+			assert.Equal(t, "A1", requests[0]["code"])
+			assert.Equal(t, "color", requests[0]["attributeName1"])
+		}
+
+		_, err = w.Write([]byte(`{
+			"status": {"responseStatus": "ok"},
+			"requests": [
+				{"status": {"requestName": "saveProduct", "responseStatus": "ok"}, "records": [{"productID": 55}]},
+				{"status": {"requestName": "saveProduct", "responseStatus": "error", "errorCode": 1012, "errorField": "code"}, "records": []}
+			]
+		}`))
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	cli, err := v1.NewClientWithURL("somesess", "someclient", "", srv.URL, nil, nil)
+	assert.NoError(t, err)
+
+	save := Entities["products"].NewSaver(cli)
+	results, err := save(context.Background(), []map[string]interface{}{
+		toRequest(map[string]string{"code": "A1", "groupID": "1", "attributes.color": "red"}),
+		toRequest(map[string]string{"code": "A1", "groupID": "1"}),
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 2) {
+		return
+	}
+
+	assert.Equal(t, RowResult{ID: 55}, results[0])
+
+	var erpErr *sharedCommon.ErpError
+	if assert.True(t, errors.As(results[1].Err, &erpErr)) {
+		assert.Equal(t, sharedCommon.ParamIsNotUnique, erpErr.Code)
+	}
+	assert.Equal(t, "code", results[1].ErrorField)
+}
+
+func TestCollectResultsWithMissingItems(t *testing.T) {
+	_, err := collectResults(2, []bulkItemResult{{}}, nil)
+	assert.EqualError(t, err, "expected 2 bulk results, got 1")
+
+	_, err = collectResults(2, nil, errors.New("some error"))
+	assert.EqualError(t, err, "some error")
+}
@@ -0,0 +1,211 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//Importer validates the rows of a source file and saves them in bulk chunks
+type Importer struct {
+	Entity  Entity
+	Mapping Mapping
+	//Save is not called in the dry run mode, so it can be nil then
+	Save Saver
+	//DryRun validates the rows and reports them as valid or invalid without sending them
+	DryRun bool
+	//ChunkSize is the count of rows in one bulk call, sharedCommon.MaxBulkRequestsCount is used by default
+	ChunkSize int
+	//Completed rows are skipped, use ReadCompletedRows to get them from the report of the previous run
+	Completed map[int]bool
+}
+
+type Summary struct {
+	Rows    int
+	Skipped int
+	Invalid int
+	Valid   int
+	Saved   int
+	Failed  int
+}
+
+type pendingRow struct {
+	num      int
+	isUpdate bool
+	request  map[string]interface{}
+}
+
+//Run imports all rows of the source and writes the result of every processed row to the report
+func (im *Importer) Run(ctx context.Context, src RowReader, report *ReportWriter) (Summary, error) {
+	summary := Summary{}
+	if im.Mapping.Entity != "" && im.Mapping.Entity != im.Entity.Name {
+		return summary, fmt.Errorf("the mapping is made for %s, not for %s", im.Mapping.Entity, im.Entity.Name)
+	}
+	if !im.DryRun && im.Save == nil {
+		return summary, errors.New("saver is required unless it's a dry run")
+	}
+
+	chunkSize := im.ChunkSize
+	if chunkSize <= 0 || chunkSize > sharedCommon.MaxBulkRequestsCount {
+		chunkSize = sharedCommon.MaxBulkRequestsCount
+	}
+
+	header, err := src.Read()
+	if err == io.EOF {
+		return summary, errors.New("the source file is empty")
+	}
+	if err != nil {
+		return summary, err
+	}
+	if err := im.Mapping.CheckHeader(header); err != nil {
+		return summary, err
+	}
+
+	chunk := make([]pendingRow, 0, chunkSize)
+	rowNum := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		row, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read row %d: %v", rowNum+1, err)
+		}
+		rowNum++
+		if isBlankRow(row) {
+			continue
+		}
+		summary.Rows++
+
+		if im.Completed[rowNum] {
+			summary.Skipped++
+			continue
+		}
+
+		fields := im.Mapping.Fields(header, row)
+		if err := im.Entity.Validate(fields, im.Mapping.Required); err != nil {
+			summary.Invalid++
+			reportRow := ReportRow{Row: rowNum, Status: StatusInvalid, Message: err.Error()}
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				reportRow.ErrorField = validationErr.Field
+				reportRow.Message = validationErr.Message
+			}
+			if err := report.Write(reportRow); err != nil {
+				return summary, err
+			}
+			continue
+		}
+
+		if im.DryRun {
+			summary.Valid++
+			if err := report.Write(ReportRow{Row: rowNum, Status: StatusValid}); err != nil {
+				return summary, err
+			}
+			continue
+		}
+
+		chunk = append(chunk, pendingRow{num: rowNum, isUpdate: im.Entity.IsUpdate(fields), request: toRequest(fields)})
+		if len(chunk) == chunkSize {
+			if err := im.saveChunk(ctx, chunk, report, &summary); err != nil {
+				return summary, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+
+	if len(chunk) > 0 {
+		if err := im.saveChunk(ctx, chunk, report, &summary); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+//saveChunk sends the rows and reports their results, a failure of the whole chunk is reported for every row,
+//only a report writing error or the cancelled context stop the import
+func (im *Importer) saveChunk(ctx context.Context, chunk []pendingRow, report *ReportWriter, summary *Summary) error {
+	requests := make([]map[string]interface{}, 0, len(chunk))
+	for _, row := range chunk {
+		requests = append(requests, row.request)
+	}
+
+	results, err := im.Save(ctx, requests)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		results = make([]RowResult, len(chunk))
+		for i := range results {
+			results[i].Err = err
+		}
+	}
+
+	reportRows := make([]ReportRow, 0, len(chunk))
+	for i, row := range chunk {
+		result := results[i]
+		reportRow := ReportRow{Row: row.num, ID: result.ID}
+		switch {
+		case result.Err != nil:
+			summary.Failed++
+			reportRow.Status = StatusFailed
+			reportRow.ErrorField = result.ErrorField
+			reportRow.Message = result.Err.Error()
+			var erpErr *sharedCommon.ErpError
+			if errors.As(result.Err, &erpErr) {
+				reportRow.ErrorCode = erpErr.Code
+			}
+		case result.AlreadyExisted:
+			summary.Saved++
+			reportRow.Status = StatusExisting
+		case row.isUpdate:
+			summary.Saved++
+			reportRow.Status = StatusUpdated
+		default:
+			summary.Saved++
+			reportRow.Status = StatusCreated
+		}
+		reportRows = append(reportRows, reportRow)
+	}
+
+	return report.Write(reportRows...)
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,190 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type saverMock struct {
+	calls   [][]map[string]interface{}
+	results func(call int, requests []map[string]interface{}) ([]RowResult, error)
+}
+
+func (sm *saverMock) save(ctx context.Context, requests []map[string]interface{}) ([]RowResult, error) {
+	sm.calls = append(sm.calls, requests)
+	if sm.results != nil {
+		return sm.results(len(sm.calls), requests)
+	}
+
+	results := make([]RowResult, 0, len(requests))
+	for i := range requests {
+		results = append(results, RowResult{ID: len(sm.calls)*100 + i})
+	}
+
+	return results, nil
+}
+
+const productsCSV = `SKU,Name,Group,Price
+A1,Chair,1,10
+A2,Table,,12
+A3,Lamp,2,abc
+
+A4,Desk,2,30
+A5,Bed,3,40
+`
+
+var productsMapping = Mapping{
+	Entity:  "products",
+	Columns: map[string]string{"SKU": "code", "Name": "name", "Group": "groupID", "Price": "price"},
+}
+
+func runImport(t *testing.T, imp *Importer, source string, writeHeader bool) (Summary, string, error) {
+	out := &bytes.Buffer{}
+	report, err := NewReportWriter(out, writeHeader)
+	assert.NoError(t, err)
+
+	summary, err := imp.Run(context.Background(), NewCSVReader(strings.NewReader(source), ','), report)
+
+	return summary, out.String(), err
+}
+
+func TestImportInChunks(t *testing.T) {
+	saver := &saverMock{}
+	imp := &Importer{
+		Entity:    Entities["products"],
+		Mapping:   productsMapping,
+		Save:      saver.save,
+		ChunkSize: 2,
+	}
+
+	summary, report, err := runImport(t, imp, productsCSV, true)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{Rows: 5, Invalid: 2, Saved: 3}, summary)
+
+	if assert.Len(t, saver.calls, 2) {
+		assert.Equal(t, []map[string]interface{}{
+			{"code": "A1", "name": "Chair", "groupID": "1", "price": "10"},
+			{"code": "A4", "name": "Desk", "groupID": "2", "price": "30"},
+		}, saver.calls[0])
+		assert.Len(t, saver.calls[1], 1)
+	}
+
+	assert.Equal(t, `row,status,id,error_code,error_field,message
+2,invalid,,,groupID,value is required
+3,invalid,,,price,"""abc"" is not a number"
+1,created,100,,,
+4,created,101,,,
+5,created,200,,,
+`, report)
+}
+
+func TestImportReportsRowErrors(t *testing.T) {
+	saver := &saverMock{
+		results: func(call int, requests []map[string]interface{}) ([]RowResult, error) {
+			if call == 2 {
+				return nil, errors.New("connection reset")
+			}
+			return []RowResult{
+				{ID: 7},
+				{Err: sharedCommon.NewErpError("error", "saveProduct: error", sharedCommon.ParamIsNotUnique), ErrorField: "code"},
+			}, nil
+		},
+	}
+	imp := &Importer{
+		Entity:    Entities["products"],
+		Mapping:   Mapping{Columns: map[string]string{"SKU": "code", "Name": "name", "Group": "groupID"}},
+		Save:      saver.save,
+		ChunkSize: 2,
+	}
+
+	summary, report, err := runImport(t, imp, "SKU,Name,Group\nA1,Chair,1\nA2,Table,1\nA3,Lamp,1\n", false)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{Rows: 3, Saved: 1, Failed: 2}, summary)
+
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t, "1,created,7,,,", lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "2,failed,,1012,code,"), lines[1])
+		assert.Equal(t, "3,failed,,,,connection reset", lines[2])
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	imp := &Importer{
+		Entity:  Entities["products"],
+		Mapping: productsMapping,
+		DryRun:  true,
+	}
+
+	summary, report, err := runImport(t, imp, productsCSV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{Rows: 5, Invalid: 2, Valid: 3}, summary)
+	assert.Contains(t, report, "1,valid,,,,\n")
+	assert.Contains(t, report, "2,invalid,,,groupID,value is required\n")
+}
+
+func TestImportResume(t *testing.T) {
+	previousReport := "row,status,id,error_code,error_field,message\n1,created,100,,,\n4,failed,,,,timeout\n5,existing,200,,,\n"
+	completed, err := ReadCompletedRows(strings.NewReader(previousReport))
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]bool{1: true, 5: true}, completed)
+
+	saver := &saverMock{}
+	imp := &Importer{
+		Entity:    Entities["products"],
+		Mapping:   productsMapping,
+		Save:      saver.save,
+		Completed: completed,
+	}
+
+	summary, _, err := runImport(t, imp, productsCSV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{Rows: 5, Skipped: 2, Invalid: 2, Saved: 1}, summary)
+	if assert.Len(t, saver.calls, 1) {
+		assert.Equal(t, []map[string]interface{}{{"code": "A4", "name": "Desk", "groupID": "2", "price": "30"}}, saver.calls[0])
+	}
+}
+
+func TestImportChecksMapping(t *testing.T) {
+	imp := &Importer{
+		Entity:  Entities["customers"],
+		Mapping: productsMapping,
+		DryRun:  true,
+	}
+	_, _, err := runImport(t, imp, productsCSV, false)
+	assert.EqualError(t, err, "the mapping is made for products, not for customers")
+
+	imp = &Importer{
+		Entity:  Entities["products"],
+		Mapping: productsMapping,
+		DryRun:  true,
+	}
+	_, _, err = runImport(t, imp, "SKU,Name\nA1,Chair\n", false)
+	assert.EqualError(t, err, "columns Group, Price from the mapping are missing in the file")
+}
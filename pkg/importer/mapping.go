@@ -0,0 +1,146 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const attributesPrefix = "attributes."
+
+//Mapping describes how the columns of the source file are converted to the API fields
+type Mapping struct {
+	//Entity is optional, if it's set it should match the imported entity
+	Entity string `json:"entity"`
+	//Columns maps the source column names to the API field names, a field "attributes.<name>" is sent as a text attribute.
+	//If it's empty, the column names are used as the API field names
+	Columns map[string]string `json:"columns"`
+	//Defaults are the field values used when a row has no value for the field
+	Defaults map[string]string `json:"defaults"`
+	//Required are the API fields which must have a value in every row in addition to the entity requirements
+	Required []string `json:"required"`
+	//Sheet is the name of the XLSX sheet to import, the first sheet is used if it's empty
+	Sheet string `json:"sheet"`
+}
+
+func ReadMapping(r io.Reader) (Mapping, error) {
+	mapping := Mapping{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&mapping); err != nil {
+		return mapping, fmt.Errorf("failed to read the mapping: %v", err)
+	}
+
+	return mapping, nil
+}
+
+func LoadMappingFile(path string) (Mapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Mapping{}, err
+	}
+	defer file.Close()
+
+	return ReadMapping(file)
+}
+
+//Fields converts a source row to the API fields, empty cells are skipped so that defaults are applied
+func (m Mapping) Fields(header, row []string) map[string]string {
+	fields := make(map[string]string, len(header)+len(m.Defaults))
+	for i, column := range header {
+		if i >= len(row) {
+			break
+		}
+		value := strings.TrimSpace(row[i])
+		if value == "" {
+			continue
+		}
+
+		field := strings.TrimSpace(column)
+		if len(m.Columns) > 0 {
+			mappedField, ok := m.Columns[field]
+			if !ok {
+				continue
+			}
+			field = mappedField
+		}
+		fields[field] = value
+	}
+
+	for field, value := range m.Defaults {
+		if _, ok := fields[field]; !ok {
+			fields[field] = value
+		}
+	}
+
+	return fields
+}
+
+//CheckHeader gives an error if any mapped column is missing in the source file
+func (m Mapping) CheckHeader(header []string) error {
+	existing := make(map[string]bool, len(header))
+	for _, column := range header {
+		existing[strings.TrimSpace(column)] = true
+	}
+
+	missing := make([]string, 0)
+	for column := range m.Columns {
+		if !existing[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("columns %s from the mapping are missing in the file", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+//toRequest converts the fields to the bulk request item, attributes are sent in the attributeNameN,
+//attributeTypeN and attributeValueN fields ordered by the attribute name
+func toRequest(fields map[string]string) map[string]interface{} {
+	request := make(map[string]interface{}, len(fields))
+	attrNames := make([]string, 0)
+	for field, value := range fields {
+		if strings.HasPrefix(field, attributesPrefix) {
+			attrNames = append(attrNames, strings.TrimPrefix(field, attributesPrefix))
+			continue
+		}
+		request[field] = value
+	}
+
+	sort.Strings(attrNames)
+	for i, name := range attrNames {
+		num := strconv.Itoa(i + 1)
+		request["attributeName"+num] = name
+		request["attributeType"+num] = "text"
+		request["attributeValue"+num] = fields[attributesPrefix+name]
+	}
+
+	return request
+}
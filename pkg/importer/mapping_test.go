@@ -0,0 +1,90 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMapping(t *testing.T) {
+	mapping, err := ReadMapping(strings.NewReader(`{
+		"entity": "products",
+		"columns": {"SKU": "code", "Color": "attributes.color"},
+		"defaults": {"groupID": "3"},
+		"required": ["code"]
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Mapping{
+		Entity:   "products",
+		Columns:  map[string]string{"SKU": "code", "Color": "attributes.color"},
+		Defaults: map[string]string{"groupID": "3"},
+		Required: []string{"code"},
+	}, mapping)
+
+	_, err = ReadMapping(strings.NewReader(`{"column": {}}`))
+	assert.Error(t, err)
+}
+
+func TestMappingFields(t *testing.T) {
+	mapping := Mapping{
+		Columns:  map[string]string{"SKU": "code", "Group": "groupID", "Color": "attributes.color"},
+		Defaults: map[string]string{"groupID": "3", "status": "ACTIVE"},
+	}
+
+	fields := mapping.Fields([]string{"SKU", "Group", "Color", "Ignored"}, []string{" A1 ", "", "red", "x"})
+	assert.Equal(t, map[string]string{
+		"code":             "A1",
+		"groupID":          "3",
+		"attributes.color": "red",
+		"status":           "ACTIVE",
+	}, fields)
+
+	fields = Mapping{}.Fields([]string{"code", "name"}, []string{"A1"})
+	assert.Equal(t, map[string]string{"code": "A1"}, fields)
+}
+
+func TestMappingCheckHeader(t *testing.T) {
+	mapping := Mapping{Columns: map[string]string{"SKU": "code", "Name": "name", "Color": "attributes.color"}}
+
+	assert.NoError(t, mapping.CheckHeader([]string{"Name", "SKU ", "Color"}))
+	assert.EqualError(t, mapping.CheckHeader([]string{"Name"}), "columns Color, SKU from the mapping are missing in the file")
+}
+
+func TestToRequest(t *testing.T) {
+	request := toRequest(map[string]string{
+		"code":             "A1",
+		"attributes.size":  "L",
+		"attributes.color": "red",
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"code":            "A1",
+		"attributeName1":  "color",
+		"attributeType1":  "text",
+		"attributeValue1": "red",
+		"attributeName2":  "size",
+		"attributeType2":  "text",
+		"attributeValue2": "L",
+	}, request)
+}
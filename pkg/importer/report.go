@@ -0,0 +1,133 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//row statuses in the report
+const (
+	StatusCreated  = "created"
+	StatusUpdated  = "updated"
+	StatusExisting = "existing"
+	StatusFailed   = "failed"
+	StatusInvalid  = "invalid"
+	StatusValid    = "valid"
+)
+
+var reportHeader = []string{"row", "status", "id", "error_code", "error_field", "message"}
+
+//ReportRow is the result of one source row, Row is the 1-based number of the data row without the header
+type ReportRow struct {
+	Row        int
+	Status     string
+	ID         int
+	ErrorCode  sharedCommon.ApiError
+	ErrorField string
+	Message    string
+}
+
+//IsCompleted tells if the row doesn't need to be imported again on resume
+func (rr ReportRow) IsCompleted() bool {
+	return rr.Status == StatusCreated || rr.Status == StatusUpdated || rr.Status == StatusExisting
+}
+
+//ReportWriter writes the report as CSV, every Write is flushed so that the report can be used to resume an interrupted import
+type ReportWriter struct {
+	w *csv.Writer
+}
+
+//NewReportWriter starts a new report, when the report is appended to on resume, the header should not be written again
+func NewReportWriter(w io.Writer, writeHeader bool) (*ReportWriter, error) {
+	rw := &ReportWriter{w: csv.NewWriter(w)}
+	if writeHeader {
+		if err := rw.w.Write(reportHeader); err != nil {
+			return nil, err
+		}
+		rw.w.Flush()
+		if err := rw.w.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rw, nil
+}
+
+func (rw *ReportWriter) Write(rows ...ReportRow) error {
+	for _, row := range rows {
+		id := ""
+		if row.ID != 0 {
+			id = strconv.Itoa(row.ID)
+		}
+		code := ""
+		if row.ErrorCode != 0 {
+			code = strconv.Itoa(int(row.ErrorCode))
+		}
+		err := rw.w.Write([]string{strconv.Itoa(row.Row), row.Status, id, code, row.ErrorField, row.Message})
+		if err != nil {
+			return err
+		}
+	}
+	rw.w.Flush()
+
+	return rw.w.Error()
+}
+
+//ReadCompletedRows gives the numbers of the rows which were imported according to a previous report
+func ReadCompletedRows(r io.Reader) (map[int]bool, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+
+	completed := map[int]bool{}
+	isHeader := true
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return completed, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the report: %v", err)
+		}
+		if isHeader {
+			isHeader = false
+			if len(record) > 0 && record[0] == reportHeader[0] {
+				continue
+			}
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		rowNum, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid row number %q in the report", record[0])
+		}
+		if (ReportRow{Status: record[1]}).IsCompleted() {
+			completed[rowNum] = true
+		}
+	}
+}
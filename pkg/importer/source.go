@@ -0,0 +1,328 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//RowReader reads the source file row by row, the first row is the header. Read returns io.EOF after the last row
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+type csvRowReader struct {
+	r         *csv.Reader
+	firstRead bool
+}
+
+//NewCSVReader reads comma separated rows, a different delimiter can be given e.g. ';'
+func NewCSVReader(r io.Reader, delimiter rune) RowReader {
+	csvReader := csv.NewReader(r)
+	if delimiter != 0 {
+		csvReader.Comma = delimiter
+	}
+	csvReader.FieldsPerRecord = -1
+
+	return &csvRowReader{r: csvReader, firstRead: true}
+}
+
+func (cr *csvRowReader) Read() ([]string, error) {
+	row, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	if cr.firstRead && len(row) > 0 {
+		//excel adds the byte order mark to UTF-8 files
+		row[0] = strings.TrimPrefix(row[0], "\ufeff")
+	}
+	cr.firstRead = false
+
+	return row, nil
+}
+
+const xlsxRelsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+//xlsxRowReader streams the rows of one worksheet, only the shared strings table is kept in memory
+type xlsxRowReader struct {
+	sheet         io.ReadCloser
+	dec           *xml.Decoder
+	sharedStrings []string
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string     `xml:"name,attr"`
+		Attr []xml.Attr `xml:",any,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.Text
+	}
+	parts := make([]string, 0, len(rt.Runs))
+	for _, run := range rt.Runs {
+		parts = append(parts, run.Text)
+	}
+
+	return strings.Join(parts, "")
+}
+
+type xlsxCell struct {
+	Ref       string       `xml:"r,attr"`
+	Type      string       `xml:"t,attr"`
+	Value     string       `xml:"v"`
+	InlineStr xlsxRichText `xml:"is"`
+}
+
+//NewXLSXReader reads the rows of the sheet with the given name or of the first sheet if the name is empty.
+//The cells are read as they are stored, e.g. dates are given as serial numbers
+func NewXLSXReader(r io.ReaderAt, size int64, sheetName string) (RowReader, io.Closer, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open xlsx: %v", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := findXLSXSheet(files, sheetName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedStrings := []string{}
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		table := xlsxSharedStrings{}
+		if err := decodeZipXML(file, &table); err != nil {
+			return nil, nil, err
+		}
+		sharedStrings = make([]string, 0, len(table.Items))
+		for _, item := range table.Items {
+			sharedStrings = append(sharedStrings, item.String())
+		}
+	}
+
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, nil, fmt.Errorf("xlsx sheet file %s is missing", sheetPath)
+	}
+	sheet, err := sheetFile.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := &xlsxRowReader{
+		sheet:         sheet,
+		dec:           xml.NewDecoder(sheet),
+		sharedStrings: sharedStrings,
+	}
+
+	return reader, sheet, nil
+}
+
+func findXLSXSheet(files map[string]*zip.File, sheetName string) (string, error) {
+	workbookFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("xlsx workbook is missing")
+	}
+	workbook := xlsxWorkbook{}
+	if err := decodeZipXML(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", fmt.Errorf("xlsx workbook relationships are missing")
+	}
+	rels := xlsxRelationships{}
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+
+	for _, sheet := range workbook.Sheets {
+		if sheetName != "" && sheet.Name != sheetName {
+			continue
+		}
+		relID := ""
+		for _, attr := range sheet.Attr {
+			if attr.Name.Local == "id" && attr.Name.Space == xlsxRelsNamespace {
+				relID = attr.Value
+			}
+		}
+		for _, rel := range rels.Relationships {
+			if rel.ID != relID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+		return "", fmt.Errorf("xlsx sheet %s has no file", sheet.Name)
+	}
+
+	if sheetName != "" {
+		return "", fmt.Errorf("xlsx sheet %s is not found", sheetName)
+	}
+
+	return "", fmt.Errorf("xlsx has no sheets")
+}
+
+func decodeZipXML(file *zip.File, target interface{}) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(target); err != nil {
+		return fmt.Errorf("failed to read %s: %v", file.Name, err)
+	}
+
+	return nil
+}
+
+func (xr *xlsxRowReader) Read() ([]string, error) {
+	for {
+		token, err := xr.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		return xr.readRow()
+	}
+}
+
+func (xr *xlsxRowReader) readRow() ([]string, error) {
+	row := make([]string, 0)
+	for {
+		token, err := xr.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		switch typedToken := token.(type) {
+		case xml.StartElement:
+			if typedToken.Name.Local != "c" {
+				continue
+			}
+			cell := xlsxCell{}
+			if err := xr.dec.DecodeElement(&cell, &typedToken); err != nil {
+				return nil, err
+			}
+
+			colIndex := len(row)
+			if cell.Ref != "" {
+				colIndex, err = xlsxColumnIndex(cell.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for len(row) <= colIndex {
+				row = append(row, "")
+			}
+
+			row[colIndex], err = xr.cellValue(cell)
+			if err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if typedToken.Name.Local == "row" {
+				return row, nil
+			}
+		}
+	}
+}
+
+func (xr *xlsxRowReader) cellValue(cell xlsxCell) (string, error) {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || index < 0 || index >= len(xr.sharedStrings) {
+			return "", fmt.Errorf("invalid shared string index %q in cell %s", cell.Value, cell.Ref)
+		}
+		return xr.sharedStrings[index], nil
+	case "inlineStr":
+		return cell.InlineStr.String(), nil
+	case "b":
+		if cell.Value == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	default:
+		return cell.Value, nil
+	}
+}
+
+//xlsxColumnIndex converts the cell reference like "AB12" to the zero based column index
+func xlsxColumnIndex(ref string) (int, error) {
+	index := 0
+	letters := 0
+	for _, char := range ref {
+		if char < 'A' || char > 'Z' {
+			break
+		}
+		index = index*26 + int(char-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+
+	return index - 1, nil
+}
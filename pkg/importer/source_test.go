@@ -0,0 +1,141 @@
+package importer
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAllRows(t *testing.T, reader RowReader) [][]string {
+	rows := make([][]string, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows
+		}
+		if !assert.NoError(t, err) {
+			return rows
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	reader := NewCSVReader(strings.NewReader("\ufeffcode;name\nA1;\"Chair; red\"\nA2\n"), ';')
+
+	assert.Equal(t, [][]string{{"code", "name"}, {"A1", "Chair; red"}, {"A2"}}, readAllRows(t, reader))
+}
+
+func buildXLSX(t *testing.T, files map[string]string) *bytes.Reader {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+var xlsxFiles = map[string]string{
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Info" sheetId="1" r:id="rId1"/><sheet name="Products" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+	"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>code</t></si><si><t>name</t></si><si><r><t>Chair </t></r><r><t>red</t></r></si><si><t>about</t></si>
+</sst>`,
+	"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>3</v></c></row>
+</sheetData></worksheet>`,
+	"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>price</t></is></c></row>
+<row r="2"><c r="A2"><v>101</v></c><c r="B2" t="s"><v>2</v></c><c r="D2"><v>9.5</v></c></row>
+<row r="4"><c r="B4" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+}
+
+func TestXLSXReader(t *testing.T) {
+	file := buildXLSX(t, xlsxFiles)
+
+	reader, closer, err := NewXLSXReader(file, file.Size(), "Products")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer.Close()
+
+	assert.Equal(
+		t,
+		[][]string{
+			{"code", "name", "", "price"},
+			{"101", "Chair red", "", "9.5"},
+			{"", "true"},
+		},
+		readAllRows(t, reader),
+	)
+}
+
+func TestXLSXReaderFirstSheet(t *testing.T) {
+	file := buildXLSX(t, xlsxFiles)
+
+	reader, closer, err := NewXLSXReader(file, file.Size(), "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer.Close()
+
+	assert.Equal(t, [][]string{{"about"}}, readAllRows(t, reader))
+}
+
+func TestXLSXReaderUnknownSheet(t *testing.T) {
+	file := buildXLSX(t, xlsxFiles)
+
+	_, _, err := NewXLSXReader(file, file.Size(), "Customers")
+	assert.EqualError(t, err, "xlsx sheet Customers is not found")
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	for ref, expected := range map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "AB2": 27} {
+		actual, err := xlsxColumnIndex(ref)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, ref)
+	}
+
+	_, err := xlsxColumnIndex("12")
+	assert.Error(t, err)
+}
@@ -0,0 +1,324 @@
+package printing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//Label keys of the texts printed on the documents
+const (
+	LabelInvoice       = "invoice"
+	LabelCreditInvoice = "creditInvoice"
+	LabelReceipt       = "receipt"
+	LabelDeliveryNote  = "deliveryNote"
+	LabelNumber        = "number"
+	LabelDate          = "date"
+	LabelDueDate       = "dueDate"
+	LabelReference     = "reference"
+	LabelYourReference = "yourReference"
+	LabelBuyer         = "buyer"
+	LabelShipTo        = "shipTo"
+	LabelRegCode       = "regCode"
+	LabelVatNumber     = "vatNumber"
+	LabelPhone         = "phone"
+	LabelEmail         = "email"
+	LabelCode          = "code"
+	LabelName          = "name"
+	LabelQuantity      = "quantity"
+	LabelPrice         = "price"
+	LabelDiscount      = "discount"
+	LabelVatRate       = "vatRate"
+	LabelNetTotal      = "netTotal"
+	LabelTotal         = "total"
+	LabelVat           = "vat"
+	LabelVatBreakdown  = "vatBreakdown"
+	LabelTaxable       = "taxable"
+	LabelRounding      = "rounding"
+	LabelTotalDue      = "totalDue"
+	LabelPaymentInfo   = "paymentInfo"
+	LabelBank          = "bank"
+	LabelPage          = "page"
+)
+
+//Language has the texts and the number and date formats of a language
+type Language struct {
+	Code               string
+	Labels             map[string]string
+	DecimalSeparator   string
+	ThousandsSeparator string
+	//SymbolAfter puts the currency symbol after the amount
+	SymbolAfter bool
+	DateLayout  string
+}
+
+//DefaultLanguage is used when the template has no language
+const DefaultLanguage = "en"
+
+var languages = map[string]Language{
+	"en": {
+		Code:               "en",
+		DecimalSeparator:   ".",
+		ThousandsSeparator: ",",
+		DateLayout:         "02 Jan 2006",
+		Labels: map[string]string{
+			LabelInvoice:       "Invoice",
+			LabelCreditInvoice: "Credit invoice",
+			LabelReceipt:       "Receipt",
+			LabelDeliveryNote:  "Delivery note",
+			LabelNumber:        "No.",
+			LabelDate:          "Date",
+			LabelDueDate:       "Due date",
+			LabelReference:     "Reference number",
+			LabelYourReference: "Your reference",
+			LabelBuyer:         "Buyer",
+			LabelShipTo:        "Ship to",
+			LabelRegCode:       "Reg. code",
+			LabelVatNumber:     "VAT number",
+			LabelPhone:         "Phone",
+			LabelEmail:         "Email",
+			LabelCode:          "Code",
+			LabelName:          "Description",
+			LabelQuantity:      "Qty",
+			LabelPrice:         "Price",
+			LabelDiscount:      "Disc. %",
+			LabelVatRate:       "VAT",
+			LabelNetTotal:      "Net total",
+			LabelTotal:         "Total",
+			LabelVat:           "VAT",
+			LabelVatBreakdown:  "VAT breakdown",
+			LabelTaxable:       "Taxable amount",
+			LabelRounding:      "Rounding",
+			LabelTotalDue:      "Total due",
+			LabelPaymentInfo:   "Payment details",
+			LabelBank:          "Bank",
+			LabelPage:          "Page",
+		},
+	},
+	"et": {
+		Code:               "et",
+		DecimalSeparator:   ",",
+		ThousandsSeparator: " ",
+		SymbolAfter:        true,
+		DateLayout:         "02.01.2006",
+		Labels: map[string]string{
+			LabelInvoice:       "Arve",
+			LabelCreditInvoice: "Kreeditarve",
+			LabelReceipt:       "Kviitung",
+			LabelDeliveryNote:  "Saateleht",
+			LabelNumber:        "Nr",
+			LabelDate:          "Kuupäev",
+			LabelDueDate:       "Maksetähtaeg",
+			LabelReference:     "Viitenumber",
+			LabelYourReference: "Teie viide",
+			LabelBuyer:         "Ostja",
+			LabelShipTo:        "Kauba saaja",
+			LabelRegCode:       "Reg. kood",
+			LabelVatNumber:     "KMKR nr",
+			LabelPhone:         "Telefon",
+			LabelEmail:         "E-post",
+			LabelCode:          "Kood",
+			LabelName:          "Nimetus",
+			LabelQuantity:      "Kogus",
+			LabelPrice:         "Hind",
+			LabelDiscount:      "Soodus %",
+			LabelVatRate:       "KM",
+			LabelNetTotal:      "Summa",
+			LabelTotal:         "Kokku",
+			LabelVat:           "Käibemaks",
+			LabelVatBreakdown:  "Käibemaks määrade kaupa",
+			LabelTaxable:       "Maksustatav summa",
+			LabelRounding:      "Ümardus",
+			LabelTotalDue:      "Tasuda",
+			LabelPaymentInfo:   "Makse andmed",
+			LabelBank:          "Pank",
+			LabelPage:          "Lehekülg",
+		},
+	},
+	"fi": {
+		Code:               "fi",
+		DecimalSeparator:   ",",
+		ThousandsSeparator: " ",
+		SymbolAfter:        true,
+		DateLayout:         "2.1.2006",
+		Labels: map[string]string{
+			LabelInvoice:       "Lasku",
+			LabelCreditInvoice: "Hyvityslasku",
+			LabelReceipt:       "Kuitti",
+			LabelDeliveryNote:  "Lähetysluettelo",
+			LabelNumber:        "Nro",
+			LabelDate:          "Päivämäärä",
+			LabelDueDate:       "Eräpäivä",
+			LabelReference:     "Viitenumero",
+			LabelYourReference: "Viitteenne",
+			LabelBuyer:         "Ostaja",
+			LabelShipTo:        "Toimitusosoite",
+			LabelRegCode:       "Y-tunnus",
+			LabelVatNumber:     "ALV-numero",
+			LabelPhone:         "Puhelin",
+			LabelEmail:         "Sähköposti",
+			LabelCode:          "Koodi",
+			LabelName:          "Nimike",
+			LabelQuantity:      "Määrä",
+			LabelPrice:         "Hinta",
+			LabelDiscount:      "Ale %",
+			LabelVatRate:       "ALV",
+			LabelNetTotal:      "Veroton",
+			LabelTotal:         "Yhteensä",
+			LabelVat:           "ALV",
+			LabelVatBreakdown:  "ALV-erittely",
+			LabelTaxable:       "Veroton summa",
+			LabelRounding:      "Pyöristys",
+			LabelTotalDue:      "Maksettava",
+			LabelPaymentInfo:   "Maksutiedot",
+			LabelBank:          "Pankki",
+			LabelPage:          "Sivu",
+		},
+	},
+	"de": {
+		Code:               "de",
+		DecimalSeparator:   ",",
+		ThousandsSeparator: ".",
+		SymbolAfter:        true,
+		DateLayout:         "02.01.2006",
+		Labels: map[string]string{
+			LabelInvoice:       "Rechnung",
+			LabelCreditInvoice: "Gutschrift",
+			LabelReceipt:       "Quittung",
+			LabelDeliveryNote:  "Lieferschein",
+			LabelNumber:        "Nr.",
+			LabelDate:          "Datum",
+			LabelDueDate:       "Fällig am",
+			LabelReference:     "Referenznummer",
+			LabelYourReference: "Ihre Referenz",
+			LabelBuyer:         "Käufer",
+			LabelShipTo:        "Lieferadresse",
+			LabelRegCode:       "Handelsregister",
+			LabelVatNumber:     "USt-IdNr.",
+			LabelPhone:         "Telefon",
+			LabelEmail:         "E-Mail",
+			LabelCode:          "Art.-Nr.",
+			LabelName:          "Bezeichnung",
+			LabelQuantity:      "Menge",
+			LabelPrice:         "Preis",
+			LabelDiscount:      "Rabatt %",
+			LabelVatRate:       "USt.",
+			LabelNetTotal:      "Netto",
+			LabelTotal:         "Gesamt",
+			LabelVat:           "USt.",
+			LabelVatBreakdown:  "Umsatzsteuer nach Steuersätzen",
+			LabelTaxable:       "Nettobetrag",
+			LabelRounding:      "Rundung",
+			LabelTotalDue:      "Zahlbetrag",
+			LabelPaymentInfo:   "Zahlungsinformationen",
+			LabelBank:          "Bank",
+			LabelPage:          "Seite",
+		},
+	},
+}
+
+//currencySymbols are the symbols which the standard PDF fonts can print, other currencies are printed by their code
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"GBP": "£",
+	"USD": "$",
+	"JPY": "¥",
+}
+
+//LookupLanguage returns one of the built-in languages: en, et, fi and de
+func LookupLanguage(code string) (Language, bool) {
+	language, ok := languages[strings.ToLower(code)]
+	return language, ok
+}
+
+//Label returns the text of the key, the English text is used when the language doesn't have it
+func (l Language) Label(key string) string {
+	if text, ok := l.Labels[key]; ok {
+		return text
+	}
+	return languages[DefaultLanguage].Labels[key]
+}
+
+//FormatNumber formats the value with the given decimals, -1 prints as many decimals as needed
+func (l Language) FormatNumber(value float64, decimals int) string {
+	if decimals >= 0 {
+		value = sharedCommon.RoundFloat(value, int32(decimals))
+	}
+	if value == 0 {
+		//avoids -0
+		value = 0
+	}
+
+	text := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	integer, fraction := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		integer, fraction = text[:i], text[i+1:]
+	}
+
+	grouped := strings.Builder{}
+	if value < 0 {
+		grouped.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(l.ThousandsSeparator)
+		}
+		grouped.WriteRune(digit)
+	}
+	if fraction != "" {
+		grouped.WriteString(l.DecimalSeparator)
+		grouped.WriteString(fraction)
+	}
+	return grouped.String()
+}
+
+//FormatMoney formats the amount with two decimals and the symbol or the code of the currency
+func (l Language) FormatMoney(value float64, currency string) string {
+	amount := l.FormatNumber(value, 2)
+	if currency == "" {
+		return amount
+	}
+	symbol, ok := currencySymbols[strings.ToUpper(currency)]
+	if !ok || l.SymbolAfter {
+		if !ok {
+			symbol = strings.ToUpper(currency)
+		}
+		return amount + " " + symbol
+	}
+	if strings.HasPrefix(amount, "-") {
+		return "-" + symbol + amount[1:]
+	}
+	return symbol + amount
+}
+
+//FormatDate formats a date of the API, the dates which can't be parsed are returned as they are
+func (l Language) FormatDate(date string) string {
+	parsed, err := time.Parse(sharedCommon.DateLayout, date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format(l.DateLayout)
+}
@@ -0,0 +1,67 @@
+package printing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatNumber(t *testing.T) {
+	en, _ := LookupLanguage("en")
+	et, _ := LookupLanguage("ET")
+	de, _ := LookupLanguage("de")
+
+	assert.Equal(t, "1,234,567.50", en.FormatNumber(1234567.5, 2))
+	assert.Equal(t, "1 234 567,50", et.FormatNumber(1234567.5, 2))
+	assert.Equal(t, "-1.234,57", de.FormatNumber(-1234.567, 2))
+	assert.Equal(t, "999", en.FormatNumber(999, -1))
+	assert.Equal(t, "2,5", et.FormatNumber(2.5, -1))
+	assert.Equal(t, "0.00", en.FormatNumber(-0.001, 2))
+}
+
+func TestFormatMoney(t *testing.T) {
+	en, _ := LookupLanguage("en")
+	fi, _ := LookupLanguage("fi")
+
+	assert.Equal(t, "€1,200.00", en.FormatMoney(1200, "EUR"))
+	assert.Equal(t, "-$5.10", en.FormatMoney(-5.1, "usd"))
+	assert.Equal(t, "1 200,00 €", fi.FormatMoney(1200, "EUR"))
+	assert.Equal(t, "10.00 INR", en.FormatMoney(10, "INR"))
+	assert.Equal(t, "10.00", en.FormatMoney(10, ""))
+}
+
+func TestFormatDateAndLabels(t *testing.T) {
+	en, _ := LookupLanguage("en")
+	et, _ := LookupLanguage("et")
+
+	assert.Equal(t, "20 Jun 2022", en.FormatDate("2022-06-20"))
+	assert.Equal(t, "20.06.2022", et.FormatDate("2022-06-20"))
+	assert.Equal(t, "0000-00-00", et.FormatDate("0000-00-00"))
+
+	assert.Equal(t, "Arve", et.Label(LabelInvoice))
+	et.Labels = map[string]string{}
+	assert.Equal(t, "Invoice", et.Label(LabelInvoice))
+
+	_, ok := LookupLanguage("xx")
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package printing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//LoadData reads the sales document with its rows, the company info, the VAT rates and the customers of the document
+func LoadData(ctx context.Context, salesAPI sales.Manager, companies company.Manager, customers customer.Manager, documentID int) (*Data, error) {
+	docs, err := salesAPI.GetSalesDocuments(ctx, map[string]string{
+		"id":                    strconv.Itoa(documentID),
+		"getRowsForAllInvoices": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("sales document %d is not found", documentID)
+	}
+	doc := docs[0]
+
+	info, err := companies.GetCompanyInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vatRates, err := salesAPI.GetVatRates(ctx, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	data := &Data{Document: doc, Company: *info, VatRates: vatRates}
+
+	//a receipt of an anonymous sale has no customer
+	buyerID := doc.PayerID
+	if buyerID == 0 {
+		buyerID = doc.ClientID
+	}
+	if buyerID == 0 {
+		buyerID = doc.CustomerID
+	}
+	if buyerID == 0 {
+		return data, nil
+	}
+	if data.Buyer, err = loadCustomer(ctx, customers, buyerID); err != nil {
+		return nil, err
+	}
+
+	shipTo := data.Buyer
+	shipToID := doc.ShipToID
+	if shipToID == 0 && doc.CustomerID != buyerID {
+		shipToID = doc.CustomerID
+	}
+	if shipToID != 0 && shipToID != buyerID {
+		if shipTo, err = loadCustomer(ctx, customers, shipToID); err != nil {
+			return nil, err
+		}
+		data.ShipTo = shipTo
+	}
+	for i, address := range shipTo.CustomerAddresses {
+		if strconv.Itoa(address.AddressID) == doc.ShipToAddressID {
+			data.ShipToAddress = &shipTo.CustomerAddresses[i]
+			break
+		}
+	}
+
+	return data, nil
+}
+
+func loadCustomer(ctx context.Context, customers customer.Manager, customerID int) (*customer.Customer, error) {
+	found, err := customers.GetCustomers(ctx, map[string]string{
+		"customerID":   strconv.Itoa(customerID),
+		"getAddresses": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("customer %d is not found", customerID)
+	}
+	return &found[0], nil
+}
@@ -0,0 +1,740 @@
+package printing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	creditInvoiceType = "CREDITINVOICE"
+
+	a4Width  = 210.0
+	a4Height = 297.0
+	//receiptDraftHeight is the page height of the first pass which measures the height of a receipt
+	receiptDraftHeight = 5000.0
+)
+
+//Data is everything printed on a document, the header and the footer of the template are executed with it
+type Data struct {
+	Document sales.SaleDocument
+	Company  company.Info
+	//Buyer is the payer of the document, ShipTo and ShipToAddress are printed when they differ from it
+	Buyer         *customer.Customer
+	ShipTo        *customer.Customer
+	ShipToAddress *sharedCommon.Address
+	VatRates      sales.VatRates
+}
+
+//Renderer prints the sales documents as PDF without the hosted printing service
+type Renderer struct {
+	template Template
+	language Language
+	header   *template.Template
+	footer   *template.Template
+	compress bool
+}
+
+//NewRenderer checks the template and prepares its language, header and footer
+func NewRenderer(tpl Template) (*Renderer, error) {
+	if err := tpl.Validate(); err != nil {
+		return nil, err
+	}
+
+	code := tpl.Language
+	if code == "" {
+		code = DefaultLanguage
+	}
+	language, _ := LookupLanguage(code)
+	labels := make(map[string]string, len(language.Labels)+len(tpl.Labels))
+	for key, text := range language.Labels {
+		labels[key] = text
+	}
+	for key, text := range tpl.Labels {
+		labels[key] = text
+	}
+	language.Labels = labels
+
+	header, err := template.New("header").Parse(tpl.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	footer, err := template.New("footer").Parse(tpl.Footer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid footer: %v", err)
+	}
+
+	return &Renderer{
+		template: tpl,
+		language: language,
+		header:   header,
+		footer:   footer,
+		compress: true,
+	}, nil
+}
+
+//Language returns the language of the renderer with the labels of the template
+func (r *Renderer) Language() Language {
+	return r.language
+}
+
+//Render writes the document as PDF. A receipt without a page height is rendered twice,
+//the first pass measures the height of its content
+func (r *Renderer) Render(w io.Writer, data Data) error {
+	header, err := executeText(r.header, data)
+	if err != nil {
+		return fmt.Errorf("header: %v", err)
+	}
+	footer, err := executeText(r.footer, data)
+	if err != nil {
+		return fmt.Errorf("footer: %v", err)
+	}
+
+	width, height := r.template.PageWidth, r.template.PageHeight
+	if width == 0 {
+		width = a4Width
+	}
+	if height == 0 && r.template.Kind != KindReceipt {
+		height = a4Height
+	}
+	if height == 0 {
+		draft := r.newPage(data, header, footer, width, receiptDraftHeight)
+		if err := draft.render(); err != nil {
+			return err
+		}
+		_, breakMargin := draft.pdf.GetAutoPageBreak()
+		height = draft.pdf.GetY() + breakMargin
+	}
+
+	p := r.newPage(data, header, footer, width, height)
+	if err := p.render(); err != nil {
+		return err
+	}
+	return p.pdf.Output(w)
+}
+
+func executeText(tpl *template.Template, data Data) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+//page holds the state of rendering one document
+type page struct {
+	*Renderer
+	pdf      *gofpdf.Fpdf
+	tr       func(string) string
+	data     Data
+	header   string
+	footer   string
+	font     string
+	fontSize float64
+	margin   float64
+	width    float64
+	rates    map[string]sales.VatRate
+}
+
+func (r *Renderer) newPage(data Data, header, footer string, width, height float64) *page {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           gofpdf.SizeType{Wd: width, Ht: height},
+	})
+	pdf.SetCompression(r.compress)
+
+	p := &page{
+		Renderer: r,
+		pdf:      pdf,
+		tr:       pdf.UnicodeTranslatorFromDescriptor(""),
+		data:     data,
+		header:   header,
+		footer:   footer,
+		font:     r.template.FontFamily,
+		fontSize: r.template.FontSize,
+		margin:   r.template.Margin,
+		rates:    map[string]sales.VatRate{},
+	}
+	if p.font == "" {
+		p.font = "Helvetica"
+	}
+	if p.fontSize == 0 {
+		p.fontSize = 9
+	}
+	if p.margin == 0 {
+		p.margin = 15
+	}
+	p.width = width - 2*p.margin
+	for _, rate := range data.VatRates {
+		p.rates[rate.ID] = rate
+	}
+	return p
+}
+
+func (p *page) render() error {
+	pdf := p.pdf
+	pdf.SetMargins(p.margin, p.margin, p.margin)
+	pdf.SetAutoPageBreak(true, p.margin+p.lineHeight()*2)
+	pdf.SetTitle(p.title()+" "+p.data.Document.Number, true)
+	pdf.SetCreator("Bhojpur ERP", true)
+	pdf.AliasNbPages("")
+	if p.template.Kind != KindReceipt {
+		pdf.SetFooterFunc(p.pageFooter)
+	}
+	pdf.AddPage()
+
+	if p.template.Kind == KindReceipt {
+		p.receiptHeader()
+	} else {
+		p.documentHeader()
+	}
+	if p.header != "" {
+		p.text(p.header, "", p.fontSize)
+		pdf.Ln(2)
+	}
+	p.rows()
+	if p.template.ShowTotals && p.template.Kind != KindDeliveryNote {
+		p.totals()
+	}
+	if p.template.ShowVatBreakdown && p.template.Kind != KindDeliveryNote {
+		p.vatBreakdown()
+	}
+	if p.template.ShowPaymentInfo && p.template.Kind == KindInvoice {
+		p.paymentInfo()
+	}
+	if p.template.Kind == KindReceipt && p.footer != "" {
+		pdf.Ln(2)
+		p.centered(p.footer, "", p.fontSize)
+	}
+
+	return pdf.Error()
+}
+
+func (p *page) lineHeight() float64 {
+	return p.fontSize * 0.5
+}
+
+func (p *page) title() string {
+	if p.template.Title != "" {
+		return p.template.Title
+	}
+	switch p.template.Kind {
+	case KindReceipt:
+		return p.language.Label(LabelReceipt)
+	case KindDeliveryNote:
+		return p.language.Label(LabelDeliveryNote)
+	}
+	if p.data.Document.Type == creditInvoiceType {
+		return p.language.Label(LabelCreditInvoice)
+	}
+	return p.language.Label(LabelInvoice)
+}
+
+//text writes the lines of the text over the whole width
+func (p *page) text(text, style string, size float64) {
+	p.pdf.SetFont(p.font, style, size)
+	p.pdf.MultiCell(0, size*0.5, p.tr(text), "", "L", false)
+}
+
+func (p *page) centered(text, style string, size float64) {
+	p.pdf.SetFont(p.font, style, size)
+	p.pdf.MultiCell(0, size*0.5, p.tr(text), "", "C", false)
+}
+
+func (p *page) documentHeader() {
+	pdf := p.pdf
+	doc := p.data.Document
+	top := pdf.GetY()
+
+	if p.template.LogoPath != "" {
+		pdf.ImageOptions(p.template.LogoPath, p.margin, top, 0, 18, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		pdf.SetY(top + 20)
+	}
+	pdf.SetFont(p.font, "B", p.fontSize+9)
+	pdf.CellFormat(0, 10, p.tr(p.title()+" "+doc.Number), "", 1, "L", false, 0, "")
+
+	//the seller is printed on the right and the document details on the left
+	detailsTop := pdf.GetY()
+	half := p.width / 2
+	pdf.SetXY(p.margin+half, top)
+	pdf.SetLeftMargin(p.margin + half)
+	p.text(p.data.Company.Name, "B", p.fontSize+1)
+	p.text(strings.Join(nonEmpty(
+		p.data.Company.Address,
+		p.labeled(LabelRegCode, p.data.Company.Code),
+		p.labeled(LabelVatNumber, p.data.Company.VAT),
+		p.labeled(LabelPhone, p.data.Company.Phone),
+		p.labeled(LabelEmail, p.data.Company.Email),
+	), "\n"), "", p.fontSize)
+	sellerBottom := pdf.GetY()
+	pdf.SetLeftMargin(p.margin)
+
+	pdf.SetXY(p.margin, detailsTop)
+	details := [][2]string{
+		{LabelDate, p.language.FormatDate(doc.Date)},
+	}
+	if p.template.Kind == KindInvoice && doc.Type != creditInvoiceType {
+		details = append(details, [2]string{LabelDueDate, p.dueDate()})
+	}
+	details = append(details,
+		[2]string{LabelReference, doc.ReferenceNumber},
+		[2]string{LabelYourReference, doc.CustomReferenceNumber},
+	)
+	for _, detail := range details {
+		if detail[1] == "" {
+			continue
+		}
+		pdf.SetFont(p.font, "B", p.fontSize)
+		pdf.CellFormat(35, p.lineHeight(), p.tr(p.language.Label(detail[0])), "", 0, "L", false, 0, "")
+		pdf.SetFont(p.font, "", p.fontSize)
+		pdf.CellFormat(half-35, p.lineHeight(), p.tr(detail[1]), "", 1, "L", false, 0, "")
+	}
+	if pdf.GetY() < sellerBottom {
+		pdf.SetY(sellerBottom)
+	}
+	pdf.Ln(4)
+
+	//the buyer on the left and the recipient on the right
+	partiesTop := pdf.GetY()
+	p.party(LabelBuyer, p.buyerLines(), p.margin, half)
+	buyerBottom := pdf.GetY()
+	if lines := p.shipToLines(); len(lines) > 0 {
+		pdf.SetXY(p.margin+half, partiesTop)
+		p.party(LabelShipTo, lines, p.margin+half, half)
+	}
+	if pdf.GetY() < buyerBottom {
+		pdf.SetY(buyerBottom)
+	}
+	pdf.Ln(4)
+}
+
+func (p *page) party(label string, lines []string, x, width float64) {
+	pdf := p.pdf
+	pdf.SetLeftMargin(x)
+	pdf.SetFont(p.font, "B", p.fontSize)
+	pdf.CellFormat(width, p.lineHeight(), p.tr(p.language.Label(label)), "", 1, "L", false, 0, "")
+	pdf.SetFont(p.font, "", p.fontSize)
+	for _, line := range lines {
+		pdf.MultiCell(width-2, p.lineHeight(), p.tr(line), "", "L", false)
+	}
+	pdf.SetLeftMargin(p.margin)
+}
+
+func (p *page) receiptHeader() {
+	pdf := p.pdf
+	doc := p.data.Document
+
+	if p.template.LogoPath != "" {
+		top := pdf.GetY()
+		pdf.ImageOptions(p.template.LogoPath, p.margin, top, p.width, 0, true, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		pdf.Ln(2)
+	}
+	p.centered(p.data.Company.Name, "B", p.fontSize+2)
+	p.centered(strings.Join(nonEmpty(
+		p.data.Company.Address,
+		p.labeled(LabelRegCode, p.data.Company.Code),
+		p.labeled(LabelVatNumber, p.data.Company.VAT),
+	), "\n"), "", p.fontSize)
+	pdf.Ln(2)
+	p.centered(p.title()+" "+doc.Number, "B", p.fontSize+1)
+	dateTime := p.language.FormatDate(doc.Date)
+	if doc.Time != "" {
+		dateTime += " " + doc.Time
+	}
+	p.centered(dateTime, "", p.fontSize)
+	if p.data.Buyer != nil {
+		p.centered(customerName(*p.data.Buyer), "", p.fontSize)
+	}
+	pdf.Ln(2)
+}
+
+func (p *page) labeled(label, value string) string {
+	if value == "" {
+		return ""
+	}
+	return p.language.Label(label) + ": " + value
+}
+
+func (p *page) buyerLines() []string {
+	doc := p.data.Document
+	if p.data.Buyer == nil {
+		name := doc.PayerName
+		if name == "" {
+			name = doc.ClientName
+		}
+		address := doc.PayerAddress
+		if address == "" {
+			address = doc.Address
+		}
+		return nonEmpty(name, address)
+	}
+
+	buyer := *p.data.Buyer
+	return nonEmpty(
+		customerName(buyer),
+		customerAddress(buyer),
+		p.labeled(LabelRegCode, buyer.Code),
+		p.labeled(LabelVatNumber, buyer.VatNumber),
+	)
+}
+
+func (p *page) shipToLines() []string {
+	doc := p.data.Document
+	name := doc.ShipToName
+	if p.data.ShipTo != nil {
+		name = customerName(*p.data.ShipTo)
+	}
+	address := doc.ShipToAddress
+	if p.data.ShipToAddress != nil {
+		a := p.data.ShipToAddress
+		address = joinAddress(a.Street, a.Address2, a.PostalCode, a.City, a.State, a.Country)
+	}
+	if address == "" && p.data.ShipTo != nil {
+		address = customerAddress(*p.data.ShipTo)
+	}
+
+	buyerLines := p.buyerLines()
+	if len(buyerLines) > 0 && name == buyerLines[0] && (address == "" || len(buyerLines) > 1 && address == buyerLines[1]) {
+		return nil
+	}
+	return nonEmpty(name, address)
+}
+
+func (p *page) dueDate() string {
+	date, err := time.Parse(sharedCommon.DateLayout, p.data.Document.Date)
+	if err != nil {
+		return ""
+	}
+	days, _ := strconv.Atoi(p.data.Document.PaymentDays)
+	return p.language.FormatDate(date.AddDate(0, 0, days).Format(sharedCommon.DateLayout))
+}
+
+//columnWidths returns the widths of the columns in millimeters, the name column takes what's left
+func (p *page) columnWidths() []float64 {
+	fixed := 0.0
+	for _, column := range p.template.Columns {
+		fixed += columnWidths[column]
+	}
+	scale := 1.0
+	//the narrow pages get narrower columns, leaving at least a third for the name
+	if fixed > p.width*2/3 {
+		scale = p.width * 2 / 3 / fixed
+	}
+
+	widths := make([]float64, len(p.template.Columns))
+	rest := p.width
+	for i, column := range p.template.Columns {
+		widths[i] = columnWidths[column] * scale
+		rest -= widths[i]
+	}
+	for i, column := range p.template.Columns {
+		if column == ColumnName {
+			widths[i] = rest
+		}
+	}
+	return widths
+}
+
+func (p *page) columnLabel(column string) string {
+	switch column {
+	case ColumnCode:
+		return p.language.Label(LabelCode)
+	case ColumnName:
+		return p.language.Label(LabelName)
+	case ColumnQuantity:
+		return p.language.Label(LabelQuantity)
+	case ColumnPrice:
+		return p.language.Label(LabelPrice)
+	case ColumnDiscount:
+		return p.language.Label(LabelDiscount)
+	case ColumnVatRate:
+		return p.language.Label(LabelVatRate)
+	case ColumnNetTotal:
+		return p.language.Label(LabelNetTotal)
+	}
+	return p.language.Label(LabelTotal)
+}
+
+func (p *page) columnValue(column string, row sales.InvoiceRow) string {
+	switch column {
+	case ColumnCode:
+		return row.Code
+	case ColumnName:
+		return row.ItemName
+	case ColumnQuantity:
+		return p.language.FormatNumber(parseNumber(row.Amount), -1)
+	case ColumnPrice:
+		return p.formatPrice(parseNumber(row.Price))
+	case ColumnDiscount:
+		discount := parseNumber(row.Discount)
+		if discount == 0 {
+			return ""
+		}
+		return p.language.FormatNumber(discount, -1)
+	case ColumnVatRate:
+		rate, ok := p.rates[row.VatrateID]
+		if !ok {
+			return ""
+		}
+		return p.language.FormatNumber(parseNumber(rate.Rate), -1) + "%"
+	case ColumnNetTotal:
+		return p.language.FormatNumber(row.RowNetTotal, 2)
+	}
+	return p.language.FormatNumber(row.RowTotal, 2)
+}
+
+//formatPrice prints at least two decimals and more when the price has them
+func (p *page) formatPrice(price float64) string {
+	if sharedCommon.DecimalFromFloat(price).Trim().Scale() <= 2 {
+		return p.language.FormatNumber(price, 2)
+	}
+	return p.language.FormatNumber(price, -1)
+}
+
+func (p *page) rows() {
+	pdf := p.pdf
+	widths := p.columnWidths()
+	height := p.lineHeight()
+
+	tableHeader := func() {
+		pdf.SetFont(p.font, "B", p.fontSize)
+		for i, column := range p.template.Columns {
+			pdf.CellFormat(widths[i], height+1, p.tr(p.columnLabel(column)), "B", 0, columnAlign(column), false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	tableHeader()
+
+	_, pageHeight := pdf.GetPageSize()
+	_, breakMargin := pdf.GetAutoPageBreak()
+	pdf.SetFont(p.font, "", p.fontSize)
+	for _, row := range p.data.Document.InvoiceRows {
+		cells := make([][]string, len(p.template.Columns))
+		lines := 1
+		for i, column := range p.template.Columns {
+			value := p.tr(p.columnValue(column, row))
+			if column != ColumnName {
+				cells[i] = []string{value}
+				continue
+			}
+			for _, line := range pdf.SplitLines([]byte(value), widths[i]-1) {
+				cells[i] = append(cells[i], string(line))
+			}
+			if len(cells[i]) > lines {
+				lines = len(cells[i])
+			}
+		}
+
+		rowHeight := float64(lines)*height + 1
+		if pdf.GetY()+rowHeight > pageHeight-breakMargin {
+			pdf.AddPage()
+			tableHeader()
+			pdf.SetFont(p.font, "", p.fontSize)
+		}
+
+		x, y := pdf.GetXY()
+		for i, column := range p.template.Columns {
+			for j, line := range cells[i] {
+				pdf.SetXY(x, y+0.5+float64(j)*height)
+				pdf.CellFormat(widths[i], height, line, "", 0, columnAlign(column), false, 0, "")
+			}
+			x += widths[i]
+		}
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Line(p.margin, y+rowHeight, p.margin+p.width, y+rowHeight)
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.SetXY(p.margin, y+rowHeight)
+	}
+	pdf.Ln(2)
+}
+
+func columnAlign(column string) string {
+	if column == ColumnCode || column == ColumnName {
+		return "L"
+	}
+	return "R"
+}
+
+func (p *page) totals() {
+	doc := p.data.Document
+	currency := doc.CurrencyCode
+	lines := [][2]string{
+		{p.language.Label(LabelNetTotal), p.language.FormatMoney(doc.NetTotal, currency)},
+		{p.language.Label(LabelVat), p.language.FormatMoney(doc.VatTotal, currency)},
+	}
+	if doc.Rounding != 0 {
+		lines = append(lines, [2]string{p.language.Label(LabelRounding), p.language.FormatMoney(doc.Rounding, currency)})
+	}
+	lines = append(lines, [2]string{p.language.Label(LabelTotalDue), p.language.FormatMoney(doc.Total, currency)})
+
+	labelWidth, valueWidth := p.width*0.6, p.width*0.4
+	if p.template.Kind != KindReceipt {
+		labelWidth, valueWidth = p.width-70, 70
+	}
+	for i, line := range lines {
+		style := ""
+		if i == len(lines)-1 {
+			style = "B"
+		}
+		p.pdf.SetFont(p.font, style, p.fontSize)
+		p.pdf.CellFormat(labelWidth, p.lineHeight()+1, p.tr(line[0]), "", 0, "R", false, 0, "")
+		p.pdf.CellFormat(valueWidth, p.lineHeight()+1, p.tr(line[1]), "", 1, "R", false, 0, "")
+	}
+	p.pdf.Ln(2)
+}
+
+//vatBreakdown prints the taxable amount, the VAT and the total of each VAT rate of the document
+func (p *page) vatBreakdown() {
+	doc := p.data.Document
+	if len(doc.VatTotalsByTaxRates) == 0 {
+		return
+	}
+	netTotals := map[int]float64{}
+	for _, total := range doc.NetTotalsByTaxRate {
+		netTotals[total.VatrateID] += total.Total
+	}
+
+	pdf := p.pdf
+	if p.template.Kind == KindReceipt {
+		pdf.SetFont(p.font, "B", p.fontSize)
+		pdf.CellFormat(0, p.lineHeight()+1, p.tr(p.language.Label(LabelVatBreakdown)), "", 1, "L", false, 0, "")
+	} else {
+		p.text(p.language.Label(LabelVatBreakdown), "B", p.fontSize+1)
+	}
+
+	widths := []float64{p.width * 0.25, p.width * 0.25, p.width * 0.25, p.width * 0.25}
+	if p.template.Kind != KindReceipt {
+		widths = []float64{40, 35, 35, 35}
+	}
+	writeRow := func(cells []string, style string) {
+		pdf.SetFont(p.font, style, p.fontSize)
+		for i, cell := range cells {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], p.lineHeight()+1, p.tr(cell), "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	writeRow([]string{
+		p.language.Label(LabelVatRate), p.language.Label(LabelTaxable), p.language.Label(LabelVat), p.language.Label(LabelTotal),
+	}, "B")
+	for _, vat := range doc.VatTotalsByTaxRates {
+		name := strconv.Itoa(vat.VatrateID)
+		if rate, ok := p.rates[name]; ok {
+			name = rate.Name
+			if name == "" {
+				name = p.language.FormatNumber(parseNumber(rate.Rate), -1) + "%"
+			}
+		}
+		net := netTotals[vat.VatrateID]
+		writeRow([]string{
+			name,
+			p.language.FormatNumber(net, 2),
+			p.language.FormatNumber(vat.Total, 2),
+			p.language.FormatNumber(net+vat.Total, 2),
+		}, "")
+	}
+	pdf.Ln(2)
+}
+
+func (p *page) paymentInfo() {
+	info := p.data.Company
+	lines := nonEmpty(
+		p.labeled(LabelBank, strings.Join(nonEmpty(info.BankName, info.BankIBAN, info.BankSWIFT), ", ")),
+		p.labeled(LabelReference, p.data.Document.ReferenceNumber),
+	)
+	if len(lines) == 0 {
+		return
+	}
+	p.text(p.language.Label(LabelPaymentInfo), "B", p.fontSize+1)
+	p.text(strings.Join(lines, "\n"), "", p.fontSize)
+}
+
+func (p *page) pageFooter() {
+	pdf := p.pdf
+	_, pageHeight := pdf.GetPageSize()
+	lines := 1
+	if p.footer != "" {
+		lines += len(pdf.SplitLines([]byte(p.tr(p.footer)), p.width))
+	}
+	pdf.SetY(pageHeight - p.margin - float64(lines)*p.lineHeight())
+	pdf.SetFont(p.font, "", p.fontSize-1)
+	if p.footer != "" {
+		pdf.MultiCell(0, p.lineHeight(), p.tr(p.footer), "T", "C", false)
+	}
+	pdf.CellFormat(0, p.lineHeight(), p.tr(fmt.Sprintf("%s %d/{nb}", p.language.Label(LabelPage), pdf.PageNo())), "", 0, "R", false, 0, "")
+}
+
+func customerName(c customer.Customer) string {
+	if c.CompanyName != "" {
+		return c.CompanyName
+	}
+	return c.FullName
+}
+
+func customerAddress(c customer.Customer) string {
+	address := joinAddress(c.Street, c.Address2, c.PostalCode, c.City, c.State, c.Country)
+	if address == "" {
+		return c.Address
+	}
+	return address
+}
+
+//joinAddress prints the street lines and the city line of an address
+func joinAddress(street, address2, postalCode, city, state, country string) string {
+	return strings.Join(nonEmpty(
+		street,
+		address2,
+		strings.Join(nonEmpty(postalCode, city), " "),
+		strings.Join(nonEmpty(state, country), ", "),
+	), "\n")
+}
+
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func parseNumber(value string) float64 {
+	number, _ := strconv.ParseFloat(value, 64)
+	return number
+}
@@ -0,0 +1,213 @@
+package printing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/company"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestData() Data {
+	return Data{
+		Document: sales.SaleDocument{
+			Type:                  "INVWAYBILL",
+			Number:                "1001",
+			Date:                  "2022-06-20",
+			PaymentDays:           "14",
+			CurrencyCode:          "EUR",
+			ReferenceNumber:       "10013",
+			CustomReferenceNumber: "PO-77",
+			NetTotal:              1023,
+			VatTotal:              203.6,
+			Total:                 1226.6,
+			VatTotalsByTaxRates:   sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 203.6}},
+			NetTotalsByTaxRate:    sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 1018}, {VatrateID: 2, Total: 5}},
+			ShipToAddressID:       "8",
+			InvoiceRows: []sales.InvoiceRow{
+				{Code: "C-1", ItemName: "Kohv", VatrateID: "1", Amount: "2", Price: "10", Discount: "10", RowNetTotal: 18, RowTotal: 21.6},
+				{Code: "M-1", ItemName: "Masin", VatrateID: "1", Amount: "1", Price: "1000", RowNetTotal: 1000, RowTotal: 1200},
+				{Code: "B-1", ItemName: "Raamat", VatrateID: "2", Amount: "1", Price: "5.125", RowNetTotal: 5, RowTotal: 5},
+			},
+		},
+		Company: company.Info{
+			Name:     "Müüja OÜ",
+			Code:     "10000001",
+			VAT:      "EE100000001",
+			Address:  "Pikk 1, Tallinn",
+			BankIBAN: "EE382200221020145685",
+		},
+		Buyer: &customer.Customer{
+			CompanyName: "Ostja AS",
+			Street:      "Lai 2",
+			City:        "Tartu",
+			CustomerAddresses: sharedCommon.Addresses{
+				{AddressID: 8, Street: "Lao 3", City: "Tartu"},
+			},
+		},
+		ShipToAddress: &sharedCommon.Address{AddressID: 8, Street: "Lao 3", City: "Tartu"},
+		VatRates: sales.VatRates{
+			{ID: "1", Name: "KM 20%", Rate: "20"},
+			{ID: "2", Rate: "0"},
+		},
+	}
+}
+
+func render(t *testing.T, tpl Template, data Data) string {
+	renderer, err := NewRenderer(tpl)
+	assert.NoError(t, err)
+	renderer.compress = false
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, renderer.Render(buf, data))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	return buf.String()
+}
+
+//cp1252 converts the expected text to the encoding of the standard PDF fonts
+func cp1252(text string) string {
+	return strings.NewReplacer("ü", "\xfc", "Ü", "\xdc", "ä", "\xe4", "õ", "\xf5", "€", "\x80").Replace(text)
+}
+
+func TestRenderInvoice(t *testing.T) {
+	tpl := DefaultTemplate(KindInvoice)
+	tpl.Language = "et"
+	tpl.Header = "Tellimus {{.Document.CustomReferenceNumber}}"
+	tpl.Footer = "{{.Company.Name}} | {{.Company.VAT}}"
+	pdf := render(t, tpl, newTestData())
+
+	for _, text := range []string{
+		"(Arve 1001)",
+		"(M\xfc\xfcja O\xdc)",
+		"(20.06.2022)",
+		"(04.07.2022)",
+		"(Ostja AS)",
+		"(Kauba saaja)",
+		"(Lao 3)",
+		"(Tellimus PO-77)",
+		"(1 000,00)",
+		"(5,125)",
+		"(20%)",
+		cp1252("(1 226,60 €)"),
+		"(KM 20%)",
+		"(1 018,00)",
+		"(0%)",
+		"(Lehek\xfclg 1/1)",
+		"(M\xfc\xfcja O\xdc | EE100000001)",
+		"(Pank: EE382200221020145685)",
+	} {
+		assert.Contains(t, pdf, text)
+	}
+}
+
+func TestRenderDeliveryNoteWithoutPrices(t *testing.T) {
+	data := newTestData()
+	data.ShipToAddress = nil
+	pdf := render(t, DefaultTemplate(KindDeliveryNote), data)
+
+	assert.Contains(t, pdf, "(Delivery note 1001)")
+	assert.Contains(t, pdf, "(Masin)")
+	assert.NotContains(t, pdf, "(Ship to)")
+	assert.NotContains(t, pdf, "1,000.00")
+	assert.NotContains(t, pdf, "(VAT breakdown)")
+}
+
+func TestRenderReceiptGrowsWithRows(t *testing.T) {
+	data := newTestData()
+	short := render(t, DefaultTemplate(KindReceipt), data)
+
+	for i := 0; i < 30; i++ {
+		data.Document.InvoiceRows = append(data.Document.InvoiceRows, data.Document.InvoiceRows[0])
+	}
+	long := render(t, DefaultTemplate(KindReceipt), data)
+
+	assert.Contains(t, short, "(Receipt 1001)")
+	assert.Contains(t, short, "(\x801,226.60)")
+	assert.Equal(t, 1, strings.Count(short, "/Type /Page\n"))
+	assert.Equal(t, 1, strings.Count(long, "/Type /Page\n"))
+	assert.Greater(t, len(long), len(short))
+	assert.NotEqual(t, mediaBox(short), mediaBox(long))
+}
+
+func mediaBox(pdf string) string {
+	start := strings.Index(pdf, "/MediaBox")
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(pdf[start:], "]")
+	return pdf[start : start+end]
+}
+
+func TestRenderCreditInvoiceTitle(t *testing.T) {
+	data := newTestData()
+	data.Document.Type = creditInvoiceType
+	tpl := DefaultTemplate(KindInvoice)
+	tpl.Labels = map[string]string{LabelCreditInvoice: "Credit note"}
+
+	pdf := render(t, tpl, data)
+	assert.Contains(t, pdf, "(Credit note 1001)")
+	assert.NotContains(t, pdf, "(Due date)")
+}
+
+func TestReadTemplate(t *testing.T) {
+	tpl, err := ReadTemplate(strings.NewReader(`{"kind": "receipt", "language": "fi", "labels": {"receipt": "Kassakuitti"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, KindReceipt, tpl.Kind)
+	assert.Equal(t, 80.0, tpl.PageWidth)
+	assert.True(t, tpl.ShowTotals)
+	assert.Equal(t, []string{ColumnName, ColumnQuantity, ColumnTotal}, tpl.Columns)
+
+	renderer, err := NewRenderer(tpl)
+	assert.NoError(t, err)
+	assert.Equal(t, "Kassakuitti", renderer.Language().Label(LabelReceipt))
+	assert.Equal(t, "Kuitti", languages["fi"].Labels[LabelReceipt])
+
+	tpl, err = ReadTemplate(strings.NewReader(`{"columns": ["name", "total"], "showVatBreakdown": false}`))
+	assert.NoError(t, err)
+	assert.Equal(t, KindInvoice, tpl.Kind)
+	assert.Equal(t, []string{ColumnName, ColumnTotal}, tpl.Columns)
+	assert.False(t, tpl.ShowVatBreakdown)
+	assert.True(t, tpl.ShowPaymentInfo)
+
+	_, err = ReadTemplate(strings.NewReader(`{"kind": "letter"}`))
+	assert.True(t, errors.Is(err, ErrUnknownKind))
+	_, err = ReadTemplate(strings.NewReader(`{"columns": ["weight"]}`))
+	assert.True(t, errors.Is(err, ErrUnknownColumn))
+	_, err = ReadTemplate(strings.NewReader(`{"language": "xx"}`))
+	assert.True(t, errors.Is(err, ErrUnknownLanguage))
+
+	_, err = NewRenderer(Template{Kind: KindInvoice, Header: "{{.Missing"})
+	assert.Error(t, err)
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, KindReceipt, KindOf("CASHINVOICE"))
+	assert.Equal(t, KindDeliveryNote, KindOf("WAYBILL"))
+	assert.Equal(t, KindInvoice, KindOf("INVWAYBILL"))
+	assert.Equal(t, KindInvoice, KindOf(creditInvoiceType))
+}
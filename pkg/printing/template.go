@@ -0,0 +1,179 @@
+package printing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+//Kind is the layout of a printed document
+type Kind string
+
+const (
+	KindInvoice      Kind = "invoice"
+	KindReceipt      Kind = "receipt"
+	KindDeliveryNote Kind = "deliveryNote"
+)
+
+//Columns of the rows table
+const (
+	ColumnCode     = "code"
+	ColumnName     = "name"
+	ColumnQuantity = "quantity"
+	ColumnPrice    = "price"
+	ColumnDiscount = "discount"
+	ColumnVatRate  = "vatRate"
+	ColumnNetTotal = "netTotal"
+	ColumnTotal    = "total"
+)
+
+//columnWidths are the relative widths of the columns, the name column takes the remaining width
+var columnWidths = map[string]float64{
+	ColumnCode:     22,
+	ColumnQuantity: 16,
+	ColumnPrice:    22,
+	ColumnDiscount: 16,
+	ColumnVatRate:  14,
+	ColumnNetTotal: 24,
+	ColumnTotal:    24,
+}
+
+var (
+	ErrUnknownKind     = errors.New("unknown document kind")
+	ErrUnknownColumn   = errors.New("unknown column")
+	ErrUnknownLanguage = errors.New("unknown language")
+)
+
+//Template is the customizable layout of the printed documents, it's usually read from a JSON file.
+//Header and Footer are text/template texts executed with the Data of the document
+type Template struct {
+	Kind     Kind   `json:"kind"`
+	Language string `json:"language,omitempty"`
+	//Labels replace the texts of the language
+	Labels map[string]string `json:"labels,omitempty"`
+	//PageWidth and PageHeight are in millimeters, A4 is used when they are zero.
+	//A receipt without a height grows with its rows
+	PageWidth  float64 `json:"pageWidth,omitempty"`
+	PageHeight float64 `json:"pageHeight,omitempty"`
+	Margin     float64 `json:"margin,omitempty"`
+	//FontFamily is one of the standard PDF fonts: Helvetica, Times or Courier
+	FontFamily string   `json:"fontFamily,omitempty"`
+	FontSize   float64  `json:"fontSize,omitempty"`
+	LogoPath   string   `json:"logoPath,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Header     string   `json:"header,omitempty"`
+	Footer     string   `json:"footer,omitempty"`
+	Columns    []string `json:"columns,omitempty"`
+	//ShowTotals prints the totals and ShowVatBreakdown the VAT amounts by rate after the rows
+	ShowTotals       bool `json:"showTotals"`
+	ShowVatBreakdown bool `json:"showVatBreakdown"`
+	ShowPaymentInfo  bool `json:"showPaymentInfo"`
+}
+
+//DefaultTemplate returns the built-in layout of the kind
+func DefaultTemplate(kind Kind) Template {
+	switch kind {
+	case KindReceipt:
+		return Template{
+			Kind:             KindReceipt,
+			PageWidth:        80,
+			Margin:           4,
+			FontSize:         8,
+			Columns:          []string{ColumnName, ColumnQuantity, ColumnTotal},
+			ShowTotals:       true,
+			ShowVatBreakdown: true,
+		}
+	case KindDeliveryNote:
+		return Template{
+			Kind:    KindDeliveryNote,
+			Columns: []string{ColumnCode, ColumnName, ColumnQuantity},
+		}
+	}
+	return Template{
+		Kind:             KindInvoice,
+		Columns:          []string{ColumnCode, ColumnName, ColumnQuantity, ColumnPrice, ColumnDiscount, ColumnVatRate, ColumnNetTotal},
+		ShowTotals:       true,
+		ShowVatBreakdown: true,
+		ShowPaymentInfo:  true,
+	}
+}
+
+//KindOf returns the kind of a sales document type, receipts are printed for the cash sales
+//and delivery notes for the waybills
+func KindOf(documentType string) Kind {
+	switch documentType {
+	case "CASHINVOICE", "RECEIPT":
+		return KindReceipt
+	case "WAYBILL":
+		return KindDeliveryNote
+	}
+	return KindInvoice
+}
+
+//ReadTemplate reads a JSON template, the fields it doesn't have are taken from the default template
+//of its kind and the missing kind is an invoice
+func ReadTemplate(r io.Reader) (Template, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Template{}, err
+	}
+
+	kind := struct {
+		Kind Kind `json:"kind"`
+	}{}
+	if err := json.Unmarshal(content, &kind); err != nil {
+		return Template{}, fmt.Errorf("invalid template: %v", err)
+	}
+	if kind.Kind == "" {
+		kind.Kind = KindInvoice
+	}
+
+	tpl := DefaultTemplate(kind.Kind)
+	if err := json.Unmarshal(content, &tpl); err != nil {
+		return Template{}, fmt.Errorf("invalid template: %v", err)
+	}
+	tpl.Kind = kind.Kind
+	return tpl, tpl.Validate()
+}
+
+//Validate checks the kind, the language and the columns of the template
+func (t Template) Validate() error {
+	switch t.Kind {
+	case KindInvoice, KindReceipt, KindDeliveryNote:
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownKind, t.Kind)
+	}
+	if t.Language != "" {
+		if _, ok := LookupLanguage(t.Language); !ok {
+			return fmt.Errorf("%w %q", ErrUnknownLanguage, t.Language)
+		}
+	}
+	for _, column := range t.Columns {
+		if _, ok := columnWidths[column]; !ok && column != ColumnName {
+			return fmt.Errorf("%w %q", ErrUnknownColumn, column)
+		}
+	}
+	return nil
+}
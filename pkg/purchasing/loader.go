@@ -0,0 +1,117 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//LoadPriceLists reads all active supplier price lists with their products and adds them to the selector
+func (s *Selector) LoadPriceLists(ctx context.Context, prices price.Manager, settings sharedCommon.ListingSettings) error {
+	lister := sharedCommon.NewLister(settings, price.NewSupplierPriceListsListingDataProvider(prices), nil)
+
+	priceLists := []price.PriceList{}
+	for item := range lister.Get(ctx, map[string]interface{}{"active": 1}) {
+		if item.Err != nil {
+			return item.Err
+		}
+		priceLists = append(priceLists, item.Payload.(price.PriceList))
+	}
+
+	productsLister := sharedCommon.NewLister(settings, price.NewProductsInSupplierPriceListListingDataProvider(prices), nil)
+	for _, priceList := range priceLists {
+		products := []price.ProductsInSupplierPriceList{}
+		for item := range productsLister.Get(ctx, map[string]interface{}{"supplierPriceListID": priceList.ID}) {
+			if item.Err != nil {
+				return item.Err
+			}
+			products = append(products, item.Payload.(price.ProductsInSupplierPriceList))
+		}
+		s.AddPriceList(priceList, products...)
+	}
+
+	return nil
+}
+
+//LoadSupplierCurrencies reads the currencies of the suppliers which have a price list in the selector
+func (s *Selector) LoadSupplierCurrencies(ctx context.Context, customers customer.Manager, settings sharedCommon.ListingSettings) error {
+	supplierIDs := map[int]bool{}
+	for _, priceList := range s.priceLists {
+		supplierIDs[priceList.SupplierID] = true
+	}
+	if len(supplierIDs) == 0 {
+		return nil
+	}
+
+	lister := sharedCommon.NewLister(settings, customer.NewSupplierListingDataProvider(customers), nil)
+	for item := range lister.Get(ctx, map[string]interface{}{}) {
+		if item.Err != nil {
+			return item.Err
+		}
+		supplier := item.Payload.(customer.Supplier)
+		if supplierIDs[int(supplier.SupplierId)] && supplier.CurrencyCode != "" {
+			s.SupplierCurrencies[int(supplier.SupplierId)] = supplier.CurrencyCode
+		}
+	}
+
+	return nil
+}
+
+//LoadStock reads the stock of all products in the warehouses
+func (p *Planner) LoadStock(ctx context.Context, products product.Manager, warehouseIDs ...int) error {
+	for _, warehouseID := range warehouseIDs {
+		records, err := products.GetProductStock(ctx, map[string]string{"warehouseID": strconv.Itoa(warehouseID)})
+		if err != nil {
+			return err
+		}
+		if err := p.AddProductStock(warehouseID, records...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//LoadSales reads the sales documents of the sales period which ends on the date
+func (p *Planner) LoadSales(ctx context.Context, salesAPI sales.Manager, date time.Time, settings sharedCommon.ListingSettings) error {
+	lister := sharedCommon.NewLister(settings, sales.NewSaleDocumentsListingDataProvider(salesAPI), nil)
+	filters := map[string]interface{}{"getRowsForAllInvoices": 1}
+	sharedCommon.TimeFilters{DateFrom: p.TimeZone.Day(date.Add(day - p.SalesPeriod)), DateTo: p.TimeZone.Day(date)}.ApplyToListing(p.TimeZone, filters)
+
+	for item := range lister.Get(ctx, filters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := p.AddSales(item.Payload.(sales.SaleDocument)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
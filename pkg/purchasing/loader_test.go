@@ -0,0 +1,108 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPriceListsAndCurrencies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		request := parsedRequest["requests"].([]map[string]interface{})[0]
+		status := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok"}}
+		var resp interface{}
+		switch request["requestName"] {
+		case "getSupplierPriceLists":
+			assert.Equal(t, "1", fmt.Sprint(request["active"]))
+			status.RecordsTotal = 2
+			resp = price.GetPriceListsResponseBulk{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				BulkItems: []price.GetPriceListsResponseBulkItem{{
+					Status:     status,
+					PriceLists: []price.PriceList{{ID: 1, SupplierID: 10, Active: "1"}, {ID: 2, SupplierID: 20, Active: "1"}},
+				}},
+			}
+		case "getProductsInSupplierPriceList":
+			status.RecordsTotal = 1
+			priceListID := fmt.Sprint(request["supplierPriceListID"])
+			productPrice := float32(5)
+			if priceListID == "2" {
+				productPrice = 8
+			}
+			resp = price.ProductsInSupplierPriceListResponseBulk{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				BulkItems: []price.ProductsInSupplierPriceListResponseBulkItem{{
+					Status:                      status,
+					ProductsInSupplierPriceList: []price.ProductsInSupplierPriceList{{ProductID: 100, Price: productPrice}},
+				}},
+			}
+		case "getSuppliers":
+			status.RecordsTotal = 2
+			resp = customer.GetSuppliersResponseBulk{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				BulkItems: []customer.GetSuppliersResponseBulkItem{{
+					Status:    status,
+					Suppliers: []customer.Supplier{{SupplierId: 10, CurrencyCode: "USD"}, {SupplierId: 30, CurrencyCode: "SEK"}},
+				}},
+			}
+		default:
+			t.Errorf("unexpected request %v", request["requestName"])
+			return
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	selector := NewSelector(FixedRates{BaseCurrency: "EUR", Rates: map[string]float64{"USD": 2}})
+	ctx := context.Background()
+	err := selector.LoadPriceLists(ctx, price.NewClient(baseClient), sharedCommon.ListingSettings{})
+	assert.NoError(t, err)
+	err = selector.LoadSupplierCurrencies(ctx, customer.NewClient(baseClient), sharedCommon.ListingSettings{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[int]string{10: "USD"}, selector.SupplierCurrencies)
+
+	candidates, err := selector.Rank(100, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{20, 10}, supplierIDs(candidates))
+	assert.Equal(t, 10.0, candidates[1].UnitCost)
+}
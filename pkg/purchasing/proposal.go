@@ -0,0 +1,162 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//PurchaseOrderType is the type of the purchase documents created for the approved orders
+const PurchaseOrderType = "PRCORDER"
+
+//OrderLine is a product of a proposed purchase order, Quantity can differ from the SuggestedQuantity
+//because of the minimum order quantity and the master pack size of the supplier
+type OrderLine struct {
+	ProductID           int     `json:"productID"`
+	SupplierCode        string  `json:"supplierCode,omitempty"`
+	SupplierPriceListID int     `json:"supplierPriceListID"`
+	SuggestedQuantity   float64 `json:"suggestedQuantity"`
+	Quantity            float64 `json:"quantity"`
+	Price               float64 `json:"price"`
+	Reason              string  `json:"reason,omitempty"`
+}
+
+//PurchaseOrder is a proposed draft purchase order, it's only created when it's approved
+type PurchaseOrder struct {
+	SupplierID   int                  `json:"supplierID"`
+	SupplierName string               `json:"supplierName,omitempty"`
+	WarehouseID  int                  `json:"warehouseID"`
+	Currency     string               `json:"currency,omitempty"`
+	Total        sharedCommon.Decimal `json:"total"`
+	Approved     bool                 `json:"approved"`
+	Lines        []OrderLine          `json:"lines"`
+	//DocumentID is the created draft, the created orders are skipped when the proposal is applied again
+	DocumentID int `json:"documentID,omitempty"`
+}
+
+//Filters converts the order to the parameters of savePurchaseDocument, the order is saved unconfirmed as a draft
+func (o PurchaseOrder) Filters() map[string]string {
+	filters := map[string]string{
+		"type":        PurchaseOrderType,
+		"supplierID":  strconv.Itoa(o.SupplierID),
+		"warehouseID": strconv.Itoa(o.WarehouseID),
+		"confirmed":   "0",
+	}
+	if o.Currency != "" {
+		filters["currencyCode"] = o.Currency
+	}
+
+	n := 0
+	for _, line := range o.Lines {
+		if line.Quantity <= 0 {
+			continue
+		}
+		n++
+		suffix := strconv.Itoa(n)
+		filters["productID"+suffix] = strconv.Itoa(line.ProductID)
+		filters["amount"+suffix] = strconv.FormatFloat(line.Quantity, 'f', -1, 64)
+		filters["price"+suffix] = strconv.FormatFloat(line.Price, 'f', -1, 64)
+	}
+
+	return filters
+}
+
+//Proposal is the result of the planning, it can be saved to a file, reviewed and approved by a person and applied later
+type Proposal struct {
+	Orders []PurchaseOrder `json:"orders"`
+	//Unassigned are the suggestions of the products which no supplier sells
+	Unassigned []Suggestion `json:"unassigned"`
+}
+
+func ReadProposal(r io.Reader) (*Proposal, error) {
+	proposal := &Proposal{}
+	if err := json.NewDecoder(r).Decode(proposal); err != nil {
+		return nil, fmt.Errorf("invalid proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+func (p *Proposal) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+//ApproveAll marks all orders as approved
+func (p *Proposal) ApproveAll() {
+	for i := range p.Orders {
+		p.Orders[i].Approved = true
+	}
+}
+
+//PurchaseDocumentSaver is the part of SalesManager which creates the purchase documents
+type PurchaseDocumentSaver interface {
+	SavePurchaseDocument(ctx context.Context, filters map[string]string) (sales.PurchaseDocImportReports, error)
+}
+
+//OrderResult is the outcome of saving an approved order, DocumentID is zero in the dry run or when saving failed
+type OrderResult struct {
+	SupplierID     int
+	WarehouseID    int
+	DocumentID     int
+	DocumentNumber string
+	Err            error
+}
+
+//Apply creates the drafts of the approved orders, the dry run only returns the orders which would be created.
+//All approved orders are tried and an error is returned when any of them failed. The IDs of the created drafts
+//are stored in the orders, so the proposal should be written again after it's applied
+func (p *Proposal) Apply(ctx context.Context, saver PurchaseDocumentSaver, dryRun bool) ([]OrderResult, error) {
+	results := []OrderResult{}
+	failed := 0
+	for i, order := range p.Orders {
+		if !order.Approved || order.DocumentID != 0 {
+			continue
+		}
+
+		result := OrderResult{SupplierID: order.SupplierID, WarehouseID: order.WarehouseID}
+		if !dryRun {
+			reports, err := saver.SavePurchaseDocument(ctx, order.Filters())
+			if err != nil {
+				result.Err = err
+				failed++
+			} else if len(reports) > 0 {
+				result.DocumentID = reports[0].InvoiceID
+				result.DocumentNumber = reports[0].InvoiceNo
+				p.Orders[i].DocumentID = result.DocumentID
+			}
+		}
+		results = append(results, result)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("failed to create %d of %d purchase orders", failed, len(results))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,55 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Rates converts the prices of the suppliers to the base currency of the company
+type Rates interface {
+	//Rate returns how many units of the base currency one unit of the currency is worth
+	Rate(currency string) (float64, error)
+}
+
+//FixedRates is a static table of exchange rates, the base currency and the empty currency have the rate 1
+type FixedRates struct {
+	BaseCurrency string
+	Rates        map[string]float64
+}
+
+func (r FixedRates) Rate(currency string) (float64, error) {
+	if currency == "" || strings.EqualFold(currency, r.BaseCurrency) {
+		return 1, nil
+	}
+
+	for code, rate := range r.Rates {
+		if strings.EqualFold(code, currency) {
+			if rate <= 0 {
+				return 0, fmt.Errorf("invalid exchange rate %v of %s", rate, currency)
+			}
+			return rate, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no exchange rate for %s", currency)
+}
@@ -0,0 +1,291 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+const day = 24 * time.Hour
+
+//default planning periods
+const (
+	DefaultLeadTime    = 7 * day
+	DefaultSafetyStock = 7 * day
+	DefaultCoverage    = 30 * day
+)
+
+//StockLevel is the stock of a product in a warehouse, the zero ReorderPoint and RestockLevel are derived from the sales
+type StockLevel struct {
+	ProductID    int
+	WarehouseID  int
+	InStock      float64
+	Reserved     float64
+	ReorderPoint float64
+	RestockLevel float64
+}
+
+type stockKey struct {
+	ProductID   int
+	WarehouseID int
+}
+
+//Suggestion is the quantity which should be bought to restock a product in a warehouse
+type Suggestion struct {
+	ProductID    int     `json:"productID"`
+	WarehouseID  int     `json:"warehouseID"`
+	Available    float64 `json:"available"`
+	DailySales   float64 `json:"dailySales"`
+	ReorderPoint float64 `json:"reorderPoint"`
+	RestockLevel float64 `json:"restockLevel"`
+	Quantity     float64 `json:"quantity"`
+	Reason       string  `json:"reason"`
+}
+
+//Planner suggests the purchases from the stock levels and the sales velocity, it's not safe for concurrent changes
+type Planner struct {
+	//Selector picks the suppliers of the suggested products
+	Selector *Selector
+	//SalesPeriod is the length of the period of the added sales, the daily sales are the sold quantity divided by its days
+	SalesPeriod time.Duration
+	//LeadTime and SafetyStock give the reorder point of the products which don't have it set,
+	//it's the sales during both periods
+	LeadTime    time.Duration
+	SafetyStock time.Duration
+	//Coverage is for how long the stock should last after the restock when the restock level is not set
+	Coverage time.Duration
+	//TimeZone is the account timezone of the date filters of the loaded sales, UTC when it's not set
+	TimeZone sharedCommon.TimeZone
+	stock    map[stockKey]StockLevel
+	sold     map[stockKey]float64
+}
+
+func NewPlanner(selector *Selector, salesPeriod time.Duration) *Planner {
+	return &Planner{
+		Selector:    selector,
+		SalesPeriod: salesPeriod,
+		LeadTime:    DefaultLeadTime,
+		SafetyStock: DefaultSafetyStock,
+		Coverage:    DefaultCoverage,
+		stock:       map[stockKey]StockLevel{},
+		sold:        map[stockKey]float64{},
+	}
+}
+
+func (p *Planner) AddStockLevels(levels ...StockLevel) {
+	for _, level := range levels {
+		p.stock[stockKey{ProductID: level.ProductID, WarehouseID: level.WarehouseID}] = level
+	}
+}
+
+//AddProductStock adds the records of ProductManager.GetProductStock for the warehouse
+func (p *Planner) AddProductStock(warehouseID int, records ...product.GetProductStock) error {
+	for _, record := range records {
+		inStock := 0.0
+		if record.AmountInStock != "" {
+			var err error
+			inStock, err = record.AmountInStock.Float64()
+			if err != nil {
+				return fmt.Errorf("invalid amount in stock %q of product %d: %v", record.AmountInStock, record.ProductID, err)
+			}
+		}
+
+		p.AddStockLevels(StockLevel{
+			ProductID:    record.ProductID,
+			WarehouseID:  warehouseID,
+			InStock:      inStock,
+			Reserved:     record.AmountReserved,
+			ReorderPoint: float64(record.ReorderPoint),
+			RestockLevel: record.RestockLevel,
+		})
+	}
+
+	return nil
+}
+
+//isSale tells if the document type moves the goods to the customer and if it's a credit invoice which returns them
+func isSale(documentType string) (sale, credit bool) {
+	switch strings.ToUpper(documentType) {
+	case sales.SaleDocumentTypeInvoice, sales.SaleDocumentTypeCASHINVOICE, sales.SaleDocumentTypeInvWayBill,
+		sales.SaleDocumentTypeWayBill, sales.SaleDocumentTypeExportInvoice:
+		return true, false
+	case sales.SaleDocumentTypeCreditInvoice:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+//AddSales adds the sold quantities of the documents, the orders, offers and other documents which
+//don't move the goods are skipped
+func (p *Planner) AddSales(documents ...sales.SaleDocument) error {
+	for _, document := range documents {
+		sale, credit := isSale(document.Type)
+		if !sale {
+			continue
+		}
+
+		for _, row := range document.InvoiceRows {
+			if row.ProductID == "" || row.ProductID == "0" {
+				continue
+			}
+			productID, err := strconv.Atoi(row.ProductID)
+			if err != nil {
+				return fmt.Errorf("document %d: invalid product ID %q", document.ID, row.ProductID)
+			}
+			amount, err := strconv.ParseFloat(row.Amount, 64)
+			if err != nil {
+				return fmt.Errorf("document %d: invalid amount %q of product %d", document.ID, row.Amount, productID)
+			}
+			//the credit invoices can have the returned amounts with either sign
+			if credit {
+				amount = -math.Abs(amount)
+			}
+			p.sold[stockKey{ProductID: productID, WarehouseID: document.WarehouseID}] += amount
+		}
+	}
+
+	return nil
+}
+
+//DailySales returns the average sold quantity per day, the returns can't make it negative
+func (p *Planner) DailySales(productID, warehouseID int) float64 {
+	days := p.SalesPeriod.Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return math.Max(0, p.sold[stockKey{ProductID: productID, WarehouseID: warehouseID}]/days)
+}
+
+//Suggest returns the products which reached the reorder point ordered by the warehouse and the product,
+//the quantities are rounded up to whole units
+func (p *Planner) Suggest() []Suggestion {
+	keys := make(map[stockKey]bool, len(p.stock)+len(p.sold))
+	for key := range p.stock {
+		keys[key] = true
+	}
+	for key := range p.sold {
+		keys[key] = true
+	}
+
+	suggestions := []Suggestion{}
+	for key := range keys {
+		level := p.stock[key]
+		dailySales := p.DailySales(key.ProductID, key.WarehouseID)
+		available := level.InStock - level.Reserved
+
+		reorderPoint, restockLevel := level.ReorderPoint, level.RestockLevel
+		reason := "reorder point"
+		if reorderPoint <= 0 {
+			reorderPoint = dailySales * (p.LeadTime + p.SafetyStock).Hours() / 24
+			reason = "sales during lead time and safety stock"
+		}
+		if restockLevel <= 0 {
+			restockLevel = reorderPoint + dailySales*p.Coverage.Hours()/24
+		}
+
+		if reorderPoint <= 0 || available > reorderPoint || restockLevel <= available {
+			continue
+		}
+
+		suggestions = append(suggestions, Suggestion{
+			ProductID:    key.ProductID,
+			WarehouseID:  key.WarehouseID,
+			Available:    available,
+			DailySales:   dailySales,
+			ReorderPoint: reorderPoint,
+			RestockLevel: restockLevel,
+			Quantity:     math.Ceil(restockLevel - available - 1e-9),
+			Reason: fmt.Sprintf("available %s is at or below the %s %s, restocking to %s",
+				formatQuantity(available), reason, formatQuantity(reorderPoint), formatQuantity(restockLevel)),
+		})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].WarehouseID != suggestions[j].WarehouseID {
+			return suggestions[i].WarehouseID < suggestions[j].WarehouseID
+		}
+		return suggestions[i].ProductID < suggestions[j].ProductID
+	})
+
+	return suggestions
+}
+
+//Propose groups the suggestions to draft purchase orders of the best suppliers, one order per supplier and warehouse
+func (p *Planner) Propose() (*Proposal, error) {
+	proposal := &Proposal{
+		Orders:     []PurchaseOrder{},
+		Unassigned: []Suggestion{},
+	}
+	orderIndexes := map[[2]int]int{}
+
+	for _, suggestion := range p.Suggest() {
+		candidates, err := p.Selector.Rank(suggestion.ProductID, suggestion.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 {
+			proposal.Unassigned = append(proposal.Unassigned, suggestion)
+			continue
+		}
+		best := candidates[0]
+
+		key := [2]int{best.SupplierID, suggestion.WarehouseID}
+		index, ok := orderIndexes[key]
+		if !ok {
+			index = len(proposal.Orders)
+			orderIndexes[key] = index
+			proposal.Orders = append(proposal.Orders, PurchaseOrder{
+				SupplierID:   best.SupplierID,
+				SupplierName: best.SupplierName,
+				WarehouseID:  suggestion.WarehouseID,
+				Currency:     best.Currency,
+			})
+		}
+
+		order := &proposal.Orders[index]
+		order.Lines = append(order.Lines, OrderLine{
+			ProductID:           suggestion.ProductID,
+			SupplierCode:        best.SupplierCode,
+			SupplierPriceListID: best.SupplierPriceListID,
+			SuggestedQuantity:   suggestion.Quantity,
+			Quantity:            best.OrderQuantity,
+			Price:               best.UnitPrice,
+			Reason:              suggestion.Reason,
+		})
+		order.Total = order.Total.Add(sharedCommon.DecimalFromFloat(best.UnitPrice).Mul(sharedCommon.DecimalFromFloat(best.OrderQuantity)))
+	}
+
+	return proposal, nil
+}
+
+func formatQuantity(value float64) string {
+	return strconv.FormatFloat(sharedCommon.RoundFloat(value, 2), 'f', -1, 64)
+}
@@ -0,0 +1,216 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPlanner(t *testing.T) *Planner {
+	selector := NewSelector(nil)
+	selector.Date = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	selector.AddPriceList(
+		price.PriceList{ID: 1, SupplierID: 10, SupplierName: "Acme", Active: "1"},
+		price.ProductsInSupplierPriceList{ProductID: 1, Price: 2, MasterPackQuantity: 10, ProductSupplierCode: "A1"},
+	)
+	selector.AddPriceList(
+		price.PriceList{ID: 2, SupplierID: 20, SupplierName: "Globex", Active: "1"},
+		price.ProductsInSupplierPriceList{ProductID: 2, Price: 5},
+	)
+
+	planner := NewPlanner(selector, 30*day)
+	assert.NoError(t, planner.AddProductStock(1,
+		product.GetProductStock{ProductID: 1, AmountInStock: "5", AmountReserved: 1, ReorderPoint: 10, RestockLevel: 40},
+		product.GetProductStock{ProductID: 2, AmountInStock: "100"},
+		product.GetProductStock{ProductID: 4, AmountInStock: "0"},
+	))
+	assert.NoError(t, planner.AddProductStock(2,
+		product.GetProductStock{ProductID: 2, AmountInStock: "10.000"},
+	))
+	assert.NoError(t, planner.AddSales(
+		sales.SaleDocument{ID: 1, Type: "INVOICE", WarehouseID: 1, InvoiceRows: []sales.InvoiceRow{
+			{ProductID: "2", Amount: "60"},
+			{ProductID: "3", Amount: "15"},
+			{ProductID: "0", Amount: "1"},
+		}},
+		sales.SaleDocument{ID: 2, Type: "CASHINVOICE", WarehouseID: 2, InvoiceRows: []sales.InvoiceRow{{ProductID: "2", Amount: "35"}}},
+		sales.SaleDocument{ID: 3, Type: "CREDITINVOICE", WarehouseID: 2, InvoiceRows: []sales.InvoiceRow{{ProductID: "2", Amount: "-5"}}},
+		sales.SaleDocument{ID: 4, Type: "ORDER", WarehouseID: 2, InvoiceRows: []sales.InvoiceRow{{ProductID: "2", Amount: "100"}}},
+	))
+
+	return planner
+}
+
+func TestSuggest(t *testing.T) {
+	planner := newTestPlanner(t)
+
+	assert.Equal(t, 2.0, planner.DailySales(2, 1))
+	assert.Equal(t, 1.0, planner.DailySales(2, 2))
+
+	suggestions := planner.Suggest()
+	assert.Equal(t, []Suggestion{
+		{
+			ProductID:    1,
+			WarehouseID:  1,
+			Available:    4,
+			ReorderPoint: 10,
+			RestockLevel: 40,
+			Quantity:     36,
+			Reason:       "available 4 is at or below the reorder point 10, restocking to 40",
+		},
+		{
+			ProductID:    3,
+			WarehouseID:  1,
+			DailySales:   0.5,
+			ReorderPoint: 7,
+			RestockLevel: 22,
+			Quantity:     22,
+			Reason:       "available 0 is at or below the sales during lead time and safety stock 7, restocking to 22",
+		},
+		{
+			ProductID:    2,
+			WarehouseID:  2,
+			Available:    10,
+			DailySales:   1,
+			ReorderPoint: 14,
+			RestockLevel: 44,
+			Quantity:     34,
+			Reason:       "available 10 is at or below the sales during lead time and safety stock 14, restocking to 44",
+		},
+	}, suggestions)
+}
+
+func TestPropose(t *testing.T) {
+	planner := newTestPlanner(t)
+
+	proposal, err := planner.Propose()
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, []PurchaseOrder{
+		{
+			SupplierID:   10,
+			SupplierName: "Acme",
+			WarehouseID:  1,
+			Total:        sharedCommon.MustParseDecimal("80"),
+			Lines: []OrderLine{{
+				ProductID:           1,
+				SupplierCode:        "A1",
+				SupplierPriceListID: 1,
+				SuggestedQuantity:   36,
+				Quantity:            40,
+				Price:               2,
+				Reason:              "available 4 is at or below the reorder point 10, restocking to 40",
+			}},
+		},
+		{
+			SupplierID:   20,
+			SupplierName: "Globex",
+			WarehouseID:  2,
+			Total:        sharedCommon.MustParseDecimal("170"),
+			Lines: []OrderLine{{
+				ProductID:           2,
+				SupplierPriceListID: 2,
+				SuggestedQuantity:   34,
+				Quantity:            34,
+				Price:               5,
+				Reason:              "available 10 is at or below the sales during lead time and safety stock 14, restocking to 44",
+			}},
+		},
+	}, proposal.Orders)
+	assert.Len(t, proposal.Unassigned, 1)
+	assert.Equal(t, 3, proposal.Unassigned[0].ProductID)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, proposal.Write(buf))
+	readProposal, err := ReadProposal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, proposal, readProposal)
+}
+
+type purchaseDocumentSaverMock struct {
+	filters []map[string]string
+	err     error
+}
+
+func (m *purchaseDocumentSaverMock) SavePurchaseDocument(ctx context.Context, filters map[string]string) (sales.PurchaseDocImportReports, error) {
+	m.filters = append(m.filters, filters)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return sales.PurchaseDocImportReports{{InvoiceID: 100 + len(m.filters), InvoiceNo: "PO-1"}}, nil
+}
+
+func TestApplyApprovedOrders(t *testing.T) {
+	proposal, err := newTestPlanner(t).Propose()
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	proposal.Orders[1].Approved = true
+
+	saver := &purchaseDocumentSaverMock{}
+	results, err := proposal.Apply(context.Background(), saver, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []OrderResult{{SupplierID: 20, WarehouseID: 2}}, results)
+	assert.Empty(t, saver.filters)
+
+	results, err = proposal.Apply(context.Background(), saver, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []OrderResult{{SupplierID: 20, WarehouseID: 2, DocumentID: 101, DocumentNumber: "PO-1"}}, results)
+	assert.Equal(t, []map[string]string{{
+		"type":        "PRCORDER",
+		"supplierID":  "20",
+		"warehouseID": "2",
+		"confirmed":   "0",
+		"productID1":  "2",
+		"amount1":     "34",
+		"price1":      "5",
+	}}, saver.filters)
+	assert.Equal(t, 101, proposal.Orders[1].DocumentID)
+
+	//the created order is skipped when the proposal is applied again
+	saver = &purchaseDocumentSaverMock{}
+	results, err = proposal.Apply(context.Background(), saver, false)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Empty(t, saver.filters)
+
+	proposal.ApproveAll()
+	saver = &purchaseDocumentSaverMock{err: errors.New("boom")}
+	results, err = proposal.Apply(context.Background(), saver, false)
+	assert.EqualError(t, err, "failed to create 1 of 1 purchase orders")
+	assert.Len(t, results, 1)
+	assert.EqualError(t, results[0].Err, "boom")
+	assert.Equal(t, 0, proposal.Orders[0].DocumentID)
+}
@@ -0,0 +1,329 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+)
+
+//DefaultExpiryWarning is how long before the end date the price lists are reported as expiring
+const DefaultExpiryWarning = 30 * 24 * time.Hour
+
+//offer is a price row of a supplier price list, MinAmount is the quantity from which the price applies
+type offer struct {
+	ProductID            int
+	SupplierCode         string
+	Price                float64
+	MinAmount            float64
+	MinimumOrderQuantity float64
+	MasterPackQuantity   float64
+}
+
+type supplierPriceList struct {
+	price.PriceList
+	offers map[int][]offer
+}
+
+//Candidate is the cost of buying the product from one supplier, the costs are in the base currency
+type Candidate struct {
+	ProductID           int
+	SupplierID          int
+	SupplierName        string
+	SupplierPriceListID int
+	PriceListName       string
+	SupplierCode        string
+	Currency            string
+	//UnitPrice is the price in the supplier currency
+	UnitPrice float64
+	UnitCost  float64
+	//OrderQuantity is the requested quantity raised to the minimum order quantity and the master pack size
+	OrderQuantity float64
+	TotalCost     float64
+	ValidTo       string
+	Expiring      bool
+}
+
+//Recommendation lists the suppliers of a product from the cheapest, Recommended is nil when no supplier sells the product
+type Recommendation struct {
+	ProductID   int
+	Quantity    float64
+	Recommended *Candidate
+	Candidates  []Candidate
+}
+
+//ExpiringPriceList is an active price list which ends within the expiry warning period
+type ExpiringPriceList struct {
+	SupplierPriceListID int
+	SupplierID          int
+	SupplierName        string
+	Name                string
+	ValidTo             string
+	DaysLeft            int
+}
+
+//Selector ranks the suppliers of the products by the net unit cost, it's not safe for concurrent changes
+type Selector struct {
+	//Rates converts the supplier currencies, nil means all prices are in the base currency
+	Rates Rates
+	//SupplierCurrencies are the currency codes of the suppliers, the missing suppliers use the base currency
+	SupplierCurrencies map[int]string
+	//Date is used to check the validity of the price lists, the zero time means today
+	Date          time.Time
+	ExpiryWarning time.Duration
+	priceLists    []*supplierPriceList
+}
+
+func NewSelector(rates Rates) *Selector {
+	return &Selector{
+		Rates:              rates,
+		SupplierCurrencies: map[int]string{},
+		ExpiryWarning:      DefaultExpiryWarning,
+	}
+}
+
+//AddPriceList adds a supplier price list with its products, the product prices of the price list
+//rules are used as well, the discount rules are ignored because the supplier prices have no base price
+func (s *Selector) AddPriceList(priceList price.PriceList, products ...price.ProductsInSupplierPriceList) {
+	spl := &supplierPriceList{
+		PriceList: priceList,
+		offers:    map[int][]offer{},
+	}
+
+	for _, rule := range priceList.Rules {
+		if rule.Price <= 0 || (rule.Type != "" && !strings.EqualFold(rule.Type, price.RuleTypeProduct)) {
+			continue
+		}
+		spl.offers[rule.ProductID] = append(spl.offers[rule.ProductID], offer{
+			ProductID: rule.ProductID,
+			Price:     sharedCommon.DecimalFromFloat32(rule.Price).Float64(),
+			MinAmount: float64(rule.Amount),
+		})
+	}
+	for _, prod := range products {
+		spl.offers[prod.ProductID] = append(spl.offers[prod.ProductID], offer{
+			ProductID:            prod.ProductID,
+			SupplierCode:         prod.ProductSupplierCode,
+			Price:                sharedCommon.DecimalFromFloat32(prod.Price).Float64(),
+			MinAmount:            float64(prod.Amount),
+			MinimumOrderQuantity: float64(prod.MinimumOrderQuantity),
+			MasterPackQuantity:   float64(prod.MasterPackQuantity),
+		})
+	}
+
+	s.priceLists = append(s.priceLists, spl)
+}
+
+//ProductIDs returns the sorted IDs of all products in the valid price lists
+func (s *Selector) ProductIDs() []int {
+	seen := map[int]bool{}
+	ids := []int{}
+	for _, priceList := range s.validPriceLists() {
+		for productID := range priceList.offers {
+			if !seen[productID] {
+				seen[productID] = true
+				ids = append(ids, productID)
+			}
+		}
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+func (s *Selector) date() time.Time {
+	if s.Date.IsZero() {
+		return time.Now()
+	}
+	return s.Date
+}
+
+func (s *Selector) validPriceLists() []*supplierPriceList {
+	day := s.date().Format(sharedCommon.DateLayout)
+	valid := make([]*supplierPriceList, 0, len(s.priceLists))
+	for _, priceList := range s.priceLists {
+		if priceList.Active != "" && priceList.Active != "1" {
+			continue
+		}
+		if price.IsDateSet(priceList.ValidFrom) && day < priceList.ValidFrom {
+			continue
+		}
+		if price.IsDateSet(priceList.ValidTo) && day > priceList.ValidTo {
+			continue
+		}
+		valid = append(valid, priceList)
+	}
+
+	return valid
+}
+
+//daysLeft returns the count of days the price list is still valid, false when it has no end date
+func (s *Selector) daysLeft(priceList *supplierPriceList) (int, bool) {
+	if !price.IsDateSet(priceList.ValidTo) {
+		return 0, false
+	}
+	validTo, err := sharedCommon.UTC.ParseDate(priceList.ValidTo)
+	if err != nil {
+		return 0, false
+	}
+
+	today := sharedCommon.UTC.Day(s.date())
+	return int(validTo.Sub(today).Hours() / 24), true
+}
+
+func (s *Selector) isExpiring(priceList *supplierPriceList) bool {
+	daysLeft, ok := s.daysLeft(priceList)
+	return ok && time.Duration(daysLeft)*24*time.Hour < s.ExpiryWarning
+}
+
+//ExpiringPriceLists returns the valid price lists which end within the expiry warning period, the soonest first
+func (s *Selector) ExpiringPriceLists() []ExpiringPriceList {
+	expiring := []ExpiringPriceList{}
+	for _, priceList := range s.validPriceLists() {
+		if !s.isExpiring(priceList) {
+			continue
+		}
+		daysLeft, _ := s.daysLeft(priceList)
+		expiring = append(expiring, ExpiringPriceList{
+			SupplierPriceListID: priceList.ID,
+			SupplierID:          priceList.SupplierID,
+			SupplierName:        priceList.SupplierName,
+			Name:                priceList.Name,
+			ValidTo:             priceList.ValidTo,
+			DaysLeft:            daysLeft,
+		})
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].DaysLeft < expiring[j].DaysLeft
+	})
+
+	return expiring
+}
+
+//Rank returns the suppliers of the product for the quantity ordered by the unit cost, then by the total cost
+func (s *Selector) Rank(productID int, quantity float64) ([]Candidate, error) {
+	candidates := []Candidate{}
+	for _, priceList := range s.validPriceLists() {
+		offers := priceList.offers[productID]
+		if len(offers) == 0 {
+			continue
+		}
+
+		currency := s.SupplierCurrencies[priceList.SupplierID]
+		rate := 1.0
+		if s.Rates != nil {
+			var err error
+			rate, err = s.Rates.Rate(currency)
+			if err != nil {
+				return nil, fmt.Errorf("supplier price list %d: %v", priceList.ID, err)
+			}
+		}
+
+		best, orderQuantity := bestOffer(offers, quantity)
+		unitCost := best.Price * rate
+		candidates = append(candidates, Candidate{
+			ProductID:           productID,
+			SupplierID:          priceList.SupplierID,
+			SupplierName:        priceList.SupplierName,
+			SupplierPriceListID: priceList.ID,
+			PriceListName:       priceList.Name,
+			SupplierCode:        best.SupplierCode,
+			Currency:            currency,
+			UnitPrice:           best.Price,
+			UnitCost:            unitCost,
+			OrderQuantity:       orderQuantity,
+			TotalCost:           unitCost * orderQuantity,
+			ValidTo:             priceList.ValidTo,
+			Expiring:            s.isExpiring(priceList),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.UnitCost != b.UnitCost {
+			return a.UnitCost < b.UnitCost
+		}
+		if a.TotalCost != b.TotalCost {
+			return a.TotalCost < b.TotalCost
+		}
+		if a.Expiring != b.Expiring {
+			return !a.Expiring
+		}
+		return a.SupplierID < b.SupplierID
+	})
+
+	return candidates, nil
+}
+
+//Recommend ranks the suppliers of every product for the same quantity
+func (s *Selector) Recommend(productIDs []int, quantity float64) ([]Recommendation, error) {
+	recommendations := make([]Recommendation, 0, len(productIDs))
+	for _, productID := range productIDs {
+		candidates, err := s.Rank(productID, quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		recommendation := Recommendation{
+			ProductID:  productID,
+			Quantity:   quantity,
+			Candidates: candidates,
+		}
+		if len(candidates) > 0 {
+			recommendation.Recommended = &candidates[0]
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+
+	return recommendations, nil
+}
+
+//bestOffer finds the quantity which has to be ordered and the price which applies to it, the quantity is raised
+//to the minimum order quantity, to the smallest quantity break and then to a multiple of the master pack
+func bestOffer(offers []offer, quantity float64) (offer, float64) {
+	smallest := offers[0]
+	for _, o := range offers[1:] {
+		if o.MinAmount < smallest.MinAmount {
+			smallest = o
+		}
+	}
+
+	orderQuantity := math.Max(quantity, smallest.MinAmount)
+	orderQuantity = math.Max(orderQuantity, smallest.MinimumOrderQuantity)
+	if smallest.MasterPackQuantity > 0 {
+		orderQuantity = math.Ceil(orderQuantity/smallest.MasterPackQuantity) * smallest.MasterPackQuantity
+	}
+
+	best := smallest
+	for _, o := range offers {
+		if o.MinAmount <= orderQuantity && (o.MinAmount > best.MinAmount || (o.MinAmount == best.MinAmount && o.Price < best.Price)) {
+			best = o
+		}
+	}
+
+	return best, orderQuantity
+}
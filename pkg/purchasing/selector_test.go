@@ -0,0 +1,143 @@
+package purchasing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/price"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSelector() *Selector {
+	selector := NewSelector(FixedRates{BaseCurrency: "EUR", Rates: map[string]float64{"USD": 0.9}})
+	selector.Date = time.Date(2022, 6, 1, 15, 0, 0, 0, time.UTC)
+	selector.SupplierCurrencies[20] = "USD"
+	selector.SupplierCurrencies[50] = "eur"
+
+	selector.AddPriceList(
+		price.PriceList{ID: 1, SupplierID: 10, SupplierName: "Acme", Active: "1", ValidTo: "2022-06-20"},
+		price.ProductsInSupplierPriceList{ProductID: 100, Price: 10, ProductSupplierCode: "A-100"},
+		price.ProductsInSupplierPriceList{ProductID: 100, Price: 9, Amount: 50, ProductSupplierCode: "A-100"},
+	)
+	selector.AddPriceList(
+		price.PriceList{ID: 2, SupplierID: 20, SupplierName: "Globex", Active: "1", ValidFrom: "2022-01-01", ValidTo: "2023-01-01"},
+		price.ProductsInSupplierPriceList{ProductID: 100, Price: 10.5, MinimumOrderQuantity: 20, MasterPackQuantity: 12},
+		price.ProductsInSupplierPriceList{ProductID: 200, Price: 3},
+	)
+	selector.AddPriceList(
+		price.PriceList{ID: 3, SupplierID: 30, Active: "0"},
+		price.ProductsInSupplierPriceList{ProductID: 100, Price: 1},
+	)
+	selector.AddPriceList(
+		price.PriceList{ID: 4, SupplierID: 40, Active: "1", ValidTo: "2022-05-31"},
+		price.ProductsInSupplierPriceList{ProductID: 300, Price: 1},
+	)
+	selector.AddPriceList(price.PriceList{
+		ID:         5,
+		SupplierID: 50,
+		Active:     "1",
+		Rules: []price.PriceListRule{
+			{ProductID: 100, Price: 9.8, Type: "PRODUCT"},
+			{ProductID: 100, DiscountPercent: 50, Type: "PRODUCT"},
+		},
+	})
+
+	return selector
+}
+
+func supplierIDs(candidates []Candidate) []int {
+	ids := make([]int, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.SupplierID)
+	}
+	return ids
+}
+
+func TestRankByUnitCost(t *testing.T) {
+	selector := newTestSelector()
+
+	candidates, err := selector.Rank(100, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{20, 50, 10}, supplierIDs(candidates))
+
+	globex := candidates[0]
+	assert.Equal(t, "USD", globex.Currency)
+	assert.Equal(t, 10.5, globex.UnitPrice)
+	assert.InDelta(t, 9.45, globex.UnitCost, 0.000001)
+	assert.Equal(t, 24.0, globex.OrderQuantity)
+	assert.InDelta(t, 226.8, globex.TotalCost, 0.000001)
+	assert.False(t, globex.Expiring)
+
+	acme := candidates[2]
+	assert.Equal(t, "A-100", acme.SupplierCode)
+	assert.Equal(t, 10.0, acme.UnitCost)
+	assert.Equal(t, 10.0, acme.OrderQuantity)
+	assert.True(t, acme.Expiring)
+
+	candidates, err = selector.Rank(100, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10, 20, 50}, supplierIDs(candidates))
+	assert.Equal(t, 9.0, candidates[0].UnitCost)
+	assert.Equal(t, 540.0, candidates[0].TotalCost)
+	assert.Equal(t, 60.0, candidates[1].OrderQuantity)
+}
+
+func TestRecommend(t *testing.T) {
+	selector := newTestSelector()
+
+	assert.Equal(t, []int{100, 200}, selector.ProductIDs())
+
+	recommendations, err := selector.Recommend([]int{100, 200, 300}, 5)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Len(t, recommendations, 3)
+	assert.Equal(t, 20, recommendations[0].Recommended.SupplierID)
+	assert.Equal(t, 20, recommendations[1].Recommended.SupplierID)
+	assert.InDelta(t, 2.7, recommendations[1].Recommended.UnitCost, 0.000001)
+	assert.Nil(t, recommendations[2].Recommended)
+	assert.Empty(t, recommendations[2].Candidates)
+}
+
+func TestRankMissingRate(t *testing.T) {
+	selector := newTestSelector()
+	selector.SupplierCurrencies[20] = "SEK"
+
+	_, err := selector.Rank(100, 1)
+	assert.EqualError(t, err, "supplier price list 2: no exchange rate for SEK")
+}
+
+func TestExpiringPriceLists(t *testing.T) {
+	selector := newTestSelector()
+
+	assert.Equal(t, []ExpiringPriceList{
+		{SupplierPriceListID: 1, SupplierID: 10, SupplierName: "Acme", ValidTo: "2022-06-20", DaysLeft: 19},
+	}, selector.ExpiringPriceLists())
+
+	selector.ExpiryWarning = 365 * 24 * time.Hour
+	expiring := selector.ExpiringPriceLists()
+	assert.Len(t, expiring, 2)
+	assert.Equal(t, 214, expiring[1].DaysLeft)
+}
@@ -0,0 +1,145 @@
+package rebalancing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//LoadWarehouses adds the stores taking part, all warehouses of the account when no IDs are given
+func (p *Planner) LoadWarehouses(ctx context.Context, warehouses warehouse.Manager, warehouseIDs ...int) error {
+	records, err := warehouses.GetWarehouses(ctx, map[string]string{})
+	if err != nil {
+		return err
+	}
+
+	wanted := map[int]bool{}
+	for _, id := range warehouseIDs {
+		wanted[id] = true
+	}
+	for _, record := range records {
+		id, err := strconv.Atoi(record.WarehouseID)
+		if err != nil {
+			return fmt.Errorf("invalid warehouse ID %q: %v", record.WarehouseID, err)
+		}
+		if len(wanted) == 0 || wanted[id] {
+			p.Warehouses[id] = record.Name
+		}
+	}
+	for _, id := range warehouseIDs {
+		if _, ok := p.Warehouses[id]; !ok {
+			return fmt.Errorf("warehouse %d is not found", id)
+		}
+	}
+
+	return nil
+}
+
+//LoadStock reads the stock of all products in the stores
+func (p *Planner) LoadStock(ctx context.Context, products product.Manager) error {
+	for warehouseID := range p.Warehouses {
+		records, err := products.GetProductStock(ctx, map[string]string{"warehouseID": strconv.Itoa(warehouseID)})
+		if err != nil {
+			return err
+		}
+		if err := p.AddProductStock(warehouseID, records...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//LoadSales reads the sales documents of the sales period which ends on the date
+func (p *Planner) LoadSales(ctx context.Context, salesAPI sales.Manager, date time.Time, settings sharedCommon.ListingSettings) error {
+	lister := sharedCommon.NewLister(settings, sales.NewSaleDocumentsListingDataProvider(salesAPI), nil)
+	filters := map[string]interface{}{"getRowsForAllInvoices": 1}
+	sharedCommon.TimeFilters{DateFrom: p.TimeZone.Day(date.Add(day - p.SalesPeriod)), DateTo: p.TimeZone.Day(date)}.ApplyToListing(p.TimeZone, filters)
+
+	for item := range lister.Get(ctx, filters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := p.AddSales(item.Payload.(sales.SaleDocument)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//ReadTargets reads the min/max targets from a CSV file with the warehouse_id, product_id, min and max columns
+func ReadTargets(r io.Reader) ([]Target, error) {
+	in := csv.NewReader(r)
+	in.TrimLeadingSpace = true
+
+	records, err := in.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return []Target{}, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"warehouse_id", "product_id", "min", "max"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("targets have no %s column", name)
+		}
+	}
+
+	targets := make([]Target, 0, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+		target := Target{}
+		if target.WarehouseID, err = strconv.Atoi(strings.TrimSpace(record[columns["warehouse_id"]])); err != nil {
+			return nil, fmt.Errorf("targets line %d: invalid warehouse %q", line, record[columns["warehouse_id"]])
+		}
+		if target.ProductID, err = strconv.Atoi(strings.TrimSpace(record[columns["product_id"]])); err != nil {
+			return nil, fmt.Errorf("targets line %d: invalid product %q", line, record[columns["product_id"]])
+		}
+		if target.Min, err = strconv.ParseFloat(strings.TrimSpace(record[columns["min"]]), 64); err != nil {
+			return nil, fmt.Errorf("targets line %d: invalid min %q", line, record[columns["min"]])
+		}
+		if target.Max, err = strconv.ParseFloat(strings.TrimSpace(record[columns["max"]]), 64); err != nil {
+			return nil, fmt.Errorf("targets line %d: invalid max %q", line, record[columns["max"]])
+		}
+		if target.Min < 0 || target.Max < target.Min {
+			return nil, fmt.Errorf("targets line %d: the min can't be negative or above the max", line)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,73 @@
+package rebalancing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWarehousesAndStock(t *testing.T) {
+	stockRequests := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+
+		ok := sharedCommon.Status{ResponseStatus: "ok"}
+		var resp interface{}
+		switch r.Form.Get("request") {
+		case "getWarehouses":
+			resp = warehouse.GetWarehousesResponse{Status: ok, Warehouses: warehouse.Warehouses{
+				{WarehouseID: "1", Name: "Central"},
+				{WarehouseID: "2", Name: "Mall"},
+				{WarehouseID: "3", Name: "Airport"},
+			}}
+		case "getProductStock":
+			stockRequests = append(stockRequests, r.Form.Get("warehouseID"))
+			resp = product.GetProductStockResponse{Status: ok, GetProductStock: []product.GetProductStock{{ProductID: 10, AmountInStock: "4"}}}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	planner := NewPlanner(28 * day)
+	assert.NoError(t, planner.LoadWarehouses(context.Background(), warehouse.NewClient(baseClient), 1, 3))
+	assert.Equal(t, map[int]string{1: "Central", 3: "Airport"}, planner.Warehouses)
+
+	assert.NoError(t, planner.LoadStock(context.Background(), product.NewClient(baseClient)))
+	sort.Strings(stockRequests)
+	assert.Equal(t, []string{"1", "3"}, stockRequests)
+	assert.Equal(t, 4.0, planner.stock[stockKey{ProductID: 10, WarehouseID: 3}].InStock)
+
+	err := NewPlanner(28*day).LoadWarehouses(context.Background(), warehouse.NewClient(baseClient), 4)
+	assert.EqualError(t, err, "warehouse 4 is not found")
+}
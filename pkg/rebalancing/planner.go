@@ -0,0 +1,378 @@
+package rebalancing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+const day = 24 * time.Hour
+
+//default periods of sales the stock of a store should cover
+const (
+	DefaultMinCover = 7 * day
+	DefaultMaxCover = 28 * day
+)
+
+//StockLevel is the stock of a product in a store, the reorder point and the restock level are used as
+//the min/max target when the product has no explicit target
+type StockLevel struct {
+	ProductID    int
+	WarehouseID  int
+	InStock      float64
+	Reserved     float64
+	ReorderPoint float64
+	RestockLevel float64
+}
+
+//Target is the least and the most stock a store should have of a product
+type Target struct {
+	ProductID   int     `json:"productID"`
+	WarehouseID int     `json:"warehouseID"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+}
+
+type stockKey struct {
+	ProductID   int
+	WarehouseID int
+}
+
+//Position is the stock of a product in a store compared to its target, Quantity is how much the store
+//needs to reach the max when it's below the min or how much it has above the max
+type Position struct {
+	ProductID   int     `json:"productID"`
+	WarehouseID int     `json:"warehouseID"`
+	Available   float64 `json:"available"`
+	DailySales  float64 `json:"dailySales"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Quantity    float64 `json:"quantity"`
+}
+
+//Planner proposes the transfers between the stores from the stock levels and the sales velocity,
+//it's not safe for concurrent changes
+type Planner struct {
+	//SalesPeriod is the length of the period of the added sales, the daily sales are the sold quantity divided by its days
+	SalesPeriod time.Duration
+	//MinCover and MaxCover are the days of sales which give the min/max target of the products
+	//which have no target, reorder point or restock level
+	MinCover time.Duration
+	MaxCover time.Duration
+	//MinShipment is the least quantity worth a shipment, the transfers of the smaller quantities aren't proposed
+	MinShipment float64
+	//Warehouses are the names of the stores taking part, the stock and sales of other warehouses are ignored
+	//when it's not empty
+	Warehouses map[int]string
+	//TimeZone is the account timezone of the date filters of the loaded sales, UTC when it's not set
+	TimeZone sharedCommon.TimeZone
+	stock    map[stockKey]StockLevel
+	sold     map[stockKey]float64
+	targets  map[stockKey]Target
+}
+
+func NewPlanner(salesPeriod time.Duration) *Planner {
+	return &Planner{
+		SalesPeriod: salesPeriod,
+		MinCover:    DefaultMinCover,
+		MaxCover:    DefaultMaxCover,
+		Warehouses:  map[int]string{},
+		stock:       map[stockKey]StockLevel{},
+		sold:        map[stockKey]float64{},
+		targets:     map[stockKey]Target{},
+	}
+}
+
+func (p *Planner) AddStockLevels(levels ...StockLevel) {
+	for _, level := range levels {
+		p.stock[stockKey{ProductID: level.ProductID, WarehouseID: level.WarehouseID}] = level
+	}
+}
+
+//AddTargets sets the min/max targets, they take precedence over the reorder points and the sales velocity
+func (p *Planner) AddTargets(targets ...Target) {
+	for _, target := range targets {
+		p.targets[stockKey{ProductID: target.ProductID, WarehouseID: target.WarehouseID}] = target
+	}
+}
+
+//AddProductStock adds the records of ProductManager.GetProductStock for the warehouse
+func (p *Planner) AddProductStock(warehouseID int, records ...product.GetProductStock) error {
+	for _, record := range records {
+		inStock := 0.0
+		if record.AmountInStock != "" {
+			var err error
+			inStock, err = record.AmountInStock.Float64()
+			if err != nil {
+				return fmt.Errorf("invalid amount in stock %q of product %d: %v", record.AmountInStock, record.ProductID, err)
+			}
+		}
+
+		p.AddStockLevels(StockLevel{
+			ProductID:    record.ProductID,
+			WarehouseID:  warehouseID,
+			InStock:      inStock,
+			Reserved:     record.AmountReserved,
+			ReorderPoint: float64(record.ReorderPoint),
+			RestockLevel: record.RestockLevel,
+		})
+	}
+
+	return nil
+}
+
+//AddSales adds the sold quantities of the invoices and receipts, the credit invoices reduce them
+//and the documents which don't move the goods are skipped
+func (p *Planner) AddSales(documents ...sales.SaleDocument) error {
+	for _, document := range documents {
+		credit := false
+		switch strings.ToUpper(document.Type) {
+		case sales.SaleDocumentTypeInvoice, sales.SaleDocumentTypeCASHINVOICE, sales.SaleDocumentTypeInvWayBill,
+			sales.SaleDocumentTypeWayBill, sales.SaleDocumentTypeExportInvoice:
+		case sales.SaleDocumentTypeCreditInvoice:
+			credit = true
+		default:
+			continue
+		}
+
+		for _, row := range document.InvoiceRows {
+			if row.ProductID == "" || row.ProductID == "0" {
+				continue
+			}
+			productID, err := strconv.Atoi(row.ProductID)
+			if err != nil {
+				return fmt.Errorf("document %d: invalid product ID %q", document.ID, row.ProductID)
+			}
+			amount, err := strconv.ParseFloat(row.Amount, 64)
+			if err != nil {
+				return fmt.Errorf("document %d: invalid amount %q of product %d", document.ID, row.Amount, productID)
+			}
+			if credit {
+				amount = -math.Abs(amount)
+			}
+			p.sold[stockKey{ProductID: productID, WarehouseID: document.WarehouseID}] += amount
+		}
+	}
+
+	return nil
+}
+
+//DailySales returns the average sold quantity per day, the returns can't make it negative
+func (p *Planner) DailySales(productID, warehouseID int) float64 {
+	days := p.SalesPeriod.Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return math.Max(0, p.sold[stockKey{ProductID: productID, WarehouseID: warehouseID}]/days)
+}
+
+//Target returns the min/max target of the product in the store, the explicit target, the reorder point
+//and restock level or the sales during MinCover and MaxCover, in that order. The max is never below the min
+func (p *Planner) Target(productID, warehouseID int) Target {
+	key := stockKey{ProductID: productID, WarehouseID: warehouseID}
+	target, ok := p.targets[key]
+	if !ok {
+		level := p.stock[key]
+		dailySales := p.DailySales(productID, warehouseID)
+		target = Target{ProductID: productID, WarehouseID: warehouseID, Min: level.ReorderPoint, Max: level.RestockLevel}
+		if target.Min <= 0 {
+			target.Min = dailySales * p.MinCover.Hours() / 24
+		}
+		if target.Max <= 0 {
+			target.Max = dailySales * p.MaxCover.Hours() / 24
+		}
+	}
+	target.Max = math.Max(target.Max, target.Min)
+
+	return target
+}
+
+//Positions returns the stores which need the products because they are below the min and the stores which have
+//them above the max, ordered by the product and the store. The needed quantities are rounded up and the excess
+//quantities down to whole units
+func (p *Planner) Positions() (needs, excesses []Position) {
+	keys := map[stockKey]bool{}
+	for key := range p.stock {
+		keys[key] = true
+	}
+	for key := range p.sold {
+		keys[key] = true
+	}
+	for key := range p.targets {
+		keys[key] = true
+	}
+
+	needs = []Position{}
+	excesses = []Position{}
+	for key := range keys {
+		if _, ok := p.Warehouses[key.WarehouseID]; len(p.Warehouses) > 0 && !ok {
+			continue
+		}
+
+		level := p.stock[key]
+		target := p.Target(key.ProductID, key.WarehouseID)
+		position := Position{
+			ProductID:   key.ProductID,
+			WarehouseID: key.WarehouseID,
+			Available:   level.InStock - level.Reserved,
+			DailySales:  p.DailySales(key.ProductID, key.WarehouseID),
+			Min:         target.Min,
+			Max:         target.Max,
+		}
+		switch {
+		case position.Available < target.Min:
+			position.Quantity = math.Ceil(target.Max - position.Available - 1e-9)
+			needs = append(needs, position)
+		case position.Available > target.Max:
+			position.Quantity = math.Floor(position.Available - target.Max + 1e-9)
+			if position.Quantity > 0 {
+				excesses = append(excesses, position)
+			}
+		}
+	}
+	sortPositions(needs)
+	sortPositions(excesses)
+
+	return needs, excesses
+}
+
+func sortPositions(positions []Position) {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].ProductID != positions[j].ProductID {
+			return positions[i].ProductID < positions[j].ProductID
+		}
+		return positions[i].WarehouseID < positions[j].WarehouseID
+	})
+}
+
+type lane struct {
+	from int
+	to   int
+}
+
+//Propose matches the excesses to the needs. The stores never give away the stock they need for their max, so
+//a transfer can't move a store below its target. To keep the number of shipments low, it repeatedly picks the pair
+//of stores which can move the most units and ships all the products it can between them, the needs which no store
+//can cover are returned as Unmet
+func (p *Planner) Propose() *Proposal {
+	needs, excesses := p.Positions()
+
+	needed := map[stockKey]float64{}
+	for _, need := range needs {
+		needed[stockKey{ProductID: need.ProductID, WarehouseID: need.WarehouseID}] = need.Quantity
+	}
+	excess := map[stockKey]float64{}
+	donors := map[int][]int{}
+	for _, position := range excesses {
+		excess[stockKey{ProductID: position.ProductID, WarehouseID: position.WarehouseID}] = position.Quantity
+		donors[position.ProductID] = append(donors[position.ProductID], position.WarehouseID)
+	}
+
+	proposal := &Proposal{Transfers: []Transfer{}, Unmet: []Position{}}
+	for {
+		totals := map[lane]float64{}
+		lines := map[lane]int{}
+		for _, need := range needs {
+			to := stockKey{ProductID: need.ProductID, WarehouseID: need.WarehouseID}
+			for _, from := range donors[need.ProductID] {
+				quantity := math.Min(needed[to], excess[stockKey{ProductID: need.ProductID, WarehouseID: from}])
+				if quantity > 0 {
+					totals[lane{from: from, to: need.WarehouseID}] += quantity
+					lines[lane{from: from, to: need.WarehouseID}]++
+				}
+			}
+		}
+
+		var best lane
+		bestTotal := 0.0
+		for candidate, total := range totals {
+			if total > bestTotal || total == bestTotal && betterLane(candidate, best, lines) {
+				best, bestTotal = candidate, total
+			}
+		}
+		if bestTotal <= 0 || bestTotal < p.MinShipment {
+			break
+		}
+
+		transfer := Transfer{
+			FromWarehouseID:   best.from,
+			FromWarehouseName: p.Warehouses[best.from],
+			ToWarehouseID:     best.to,
+			ToWarehouseName:   p.Warehouses[best.to],
+			Lines:             []TransferLine{},
+		}
+		for _, need := range needs {
+			if need.WarehouseID != best.to {
+				continue
+			}
+			to := stockKey{ProductID: need.ProductID, WarehouseID: best.to}
+			from := stockKey{ProductID: need.ProductID, WarehouseID: best.from}
+			quantity := math.Min(needed[to], excess[from])
+			if quantity <= 0 {
+				continue
+			}
+			needed[to] -= quantity
+			excess[from] -= quantity
+			transfer.Quantity += quantity
+			transfer.Lines = append(transfer.Lines, TransferLine{
+				ProductID: need.ProductID,
+				Quantity:  quantity,
+				Reason: fmt.Sprintf("store %d has %s available, below the min %s, store %d has %s, above the max %s",
+					best.to, formatQuantity(need.Available), formatQuantity(need.Min),
+					best.from, formatQuantity(p.stock[from].InStock-p.stock[from].Reserved), formatQuantity(p.Target(need.ProductID, best.from).Max)),
+			})
+		}
+		proposal.Transfers = append(proposal.Transfers, transfer)
+	}
+
+	for _, need := range needs {
+		remaining := needed[stockKey{ProductID: need.ProductID, WarehouseID: need.WarehouseID}]
+		if remaining > 0 {
+			need.Quantity = remaining
+			proposal.Unmet = append(proposal.Unmet, need)
+		}
+	}
+
+	return proposal
+}
+
+//betterLane breaks the ties of the moved quantity by the number of products and then by the store IDs
+func betterLane(candidate, best lane, lines map[lane]int) bool {
+	if lines[candidate] != lines[best] {
+		return lines[candidate] > lines[best]
+	}
+	if candidate.from != best.from {
+		return candidate.from < best.from
+	}
+	return candidate.to < best.to
+}
+
+func formatQuantity(value float64) string {
+	return strconv.FormatFloat(sharedCommon.RoundFloat(value, 2), 'f', -1, 64)
+}
@@ -0,0 +1,173 @@
+package rebalancing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPlanner(t *testing.T) *Planner {
+	planner := NewPlanner(28 * day)
+	planner.Warehouses = map[int]string{1: "Central", 2: "Mall", 3: "Airport"}
+	assert.NoError(t, planner.AddProductStock(1,
+		product.GetProductStock{ProductID: 10, AmountInStock: "40"},
+		product.GetProductStock{ProductID: 11, AmountInStock: "31", AmountReserved: 1},
+	))
+	assert.NoError(t, planner.AddProductStock(2,
+		product.GetProductStock{ProductID: 10, AmountInStock: "2"},
+		product.GetProductStock{ProductID: 11, AmountInStock: "0", ReorderPoint: 4, RestockLevel: 12},
+		product.GetProductStock{ProductID: 12, AmountInStock: "0", ReorderPoint: 3, RestockLevel: 8},
+	))
+	assert.NoError(t, planner.AddProductStock(3,
+		product.GetProductStock{ProductID: 10, AmountInStock: "10.000"},
+		product.GetProductStock{ProductID: 11, AmountInStock: "1", ReorderPoint: 2, RestockLevel: 6},
+		product.GetProductStock{ProductID: 12, AmountInStock: "50", ReorderPoint: 5, RestockLevel: 20},
+	))
+	planner.AddTargets(Target{ProductID: 11, WarehouseID: 1, Min: 5, Max: 10})
+	assert.NoError(t, planner.AddSales(
+		sales.SaleDocument{ID: 1, Type: "INVOICE", WarehouseID: 2, InvoiceRows: []sales.InvoiceRow{{ProductID: "10", Amount: "28"}}},
+		sales.SaleDocument{ID: 2, Type: "CASHINVOICE", WarehouseID: 3, InvoiceRows: []sales.InvoiceRow{{ProductID: "10", Amount: "60"}}},
+		sales.SaleDocument{ID: 3, Type: "CREDITINVOICE", WarehouseID: 3, InvoiceRows: []sales.InvoiceRow{{ProductID: "10", Amount: "4"}}},
+		sales.SaleDocument{ID: 4, Type: "ORDER", WarehouseID: 1, InvoiceRows: []sales.InvoiceRow{{ProductID: "10", Amount: "100"}}},
+		sales.SaleDocument{ID: 5, Type: "INVOICE", WarehouseID: 9, InvoiceRows: []sales.InvoiceRow{{ProductID: "10", Amount: "5"}}},
+	))
+
+	return planner
+}
+
+func TestPositions(t *testing.T) {
+	planner := newTestPlanner(t)
+
+	assert.Equal(t, 1.0, planner.DailySales(10, 2))
+	assert.Equal(t, 2.0, planner.DailySales(10, 3))
+	assert.Equal(t, Target{ProductID: 10, WarehouseID: 3, Min: 14, Max: 56}, planner.Target(10, 3))
+	assert.Equal(t, Target{ProductID: 11, WarehouseID: 1, Min: 5, Max: 10}, planner.Target(11, 1))
+
+	needs, excesses := planner.Positions()
+	assert.Equal(t, []string{"10/2 26", "10/3 46", "11/2 12", "11/3 5", "12/2 8"}, positionLines(needs))
+	assert.Equal(t, []string{"10/1 40", "11/1 20", "12/3 30"}, positionLines(excesses))
+}
+
+func TestPropose(t *testing.T) {
+	planner := newTestPlanner(t)
+
+	proposal := planner.Propose()
+	assert.Equal(t, []string{"1>3 45: 10x40 11x5", "1>2 12: 11x12", "3>2 8: 12x8"}, transferLines(proposal.Transfers))
+	assert.Equal(t, "Central", proposal.Transfers[0].FromWarehouseName)
+	assert.Equal(t, "Airport", proposal.Transfers[0].ToWarehouseName)
+	assert.Equal(t, "store 3 has 10 available, below the min 14, store 1 has 40, above the max 0", proposal.Transfers[0].Lines[0].Reason)
+	assert.Equal(t, []string{"10/2 26", "10/3 6"}, positionLines(proposal.Unmet))
+
+	planner.MinShipment = 10
+	proposal = planner.Propose()
+	assert.Equal(t, []string{"1>3 45: 10x40 11x5", "1>2 12: 11x12"}, transferLines(proposal.Transfers))
+	assert.Equal(t, []string{"10/2 26", "10/3 6", "12/2 8"}, positionLines(proposal.Unmet))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, proposal.Write(buf))
+	readProposal, err := ReadProposal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, proposal, readProposal)
+}
+
+type transferSaverMock struct {
+	filters []map[string]string
+	err     error
+}
+
+func (m *transferSaverMock) SaveInventoryTransfer(ctx context.Context, filters map[string]string) (int, error) {
+	m.filters = append(m.filters, filters)
+	if m.err != nil {
+		return 0, m.err
+	}
+	return 200 + len(m.filters), nil
+}
+
+func TestApplyApprovedTransfers(t *testing.T) {
+	proposal := newTestPlanner(t).Propose()
+	proposal.Transfers[1].Approved = true
+
+	saver := &transferSaverMock{}
+	results, err := proposal.Apply(context.Background(), saver, false, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []TransferResult{{FromWarehouseID: 1, ToWarehouseID: 2, Lines: 1}}, results)
+	assert.Empty(t, saver.filters)
+
+	results, err = proposal.Apply(context.Background(), saver, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []TransferResult{{FromWarehouseID: 1, ToWarehouseID: 2, Lines: 1, TransferID: 201}}, results)
+	assert.Equal(t, []map[string]string{{
+		"warehouseFromID": "1",
+		"warehouseToID":   "2",
+		"confirmed":       "1",
+		"productID1":      "11",
+		"amount1":         "12",
+	}}, saver.filters)
+	assert.Equal(t, 201, proposal.Transfers[1].TransferID)
+
+	proposal.ApproveAll()
+	saver = &transferSaverMock{err: errors.New("boom")}
+	results, err = proposal.Apply(context.Background(), saver, false, false)
+	assert.EqualError(t, err, "failed to save 2 of 2 inventory transfers")
+	assert.Len(t, results, 2)
+	assert.Equal(t, "0", saver.filters[0]["confirmed"])
+	assert.Equal(t, 0, proposal.Transfers[0].TransferID)
+}
+
+func TestReadTargets(t *testing.T) {
+	targets, err := ReadTargets(strings.NewReader("warehouse_id,product_id,min,max\n1,10,2,8\n2, 10, 0.5, 4\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{{ProductID: 10, WarehouseID: 1, Min: 2, Max: 8}, {ProductID: 10, WarehouseID: 2, Min: 0.5, Max: 4}}, targets)
+
+	_, err = ReadTargets(strings.NewReader("warehouse_id,product_id,min\n"))
+	assert.EqualError(t, err, "targets have no max column")
+
+	_, err = ReadTargets(strings.NewReader("warehouse_id,product_id,min,max\n1,10,9,8\n"))
+	assert.EqualError(t, err, "targets line 2: the min can't be negative or above the max")
+}
+
+func positionLines(positions []Position) []string {
+	res := []string{}
+	for _, position := range positions {
+		res = append(res, fmt.Sprintf("%d/%d %s", position.ProductID, position.WarehouseID, formatQuantity(position.Quantity)))
+	}
+	return res
+}
+
+func transferLines(transfers []Transfer) []string {
+	res := []string{}
+	for _, transfer := range transfers {
+		line := fmt.Sprintf("%d>%d %s:", transfer.FromWarehouseID, transfer.ToWarehouseID, formatQuantity(transfer.Quantity))
+		for _, transferLine := range transfer.Lines {
+			line += fmt.Sprintf(" %dx%s", transferLine.ProductID, formatQuantity(transferLine.Quantity))
+		}
+		res = append(res, line)
+	}
+	return res
+}
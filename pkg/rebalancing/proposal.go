@@ -0,0 +1,148 @@
+package rebalancing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+//TransferLine is a product of a proposed transfer
+type TransferLine struct {
+	ProductID int     `json:"productID"`
+	Quantity  float64 `json:"quantity"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+//Transfer is a proposed shipment between two stores, it's only saved when it's approved
+type Transfer struct {
+	FromWarehouseID   int            `json:"fromWarehouseID"`
+	FromWarehouseName string         `json:"fromWarehouseName,omitempty"`
+	ToWarehouseID     int            `json:"toWarehouseID"`
+	ToWarehouseName   string         `json:"toWarehouseName,omitempty"`
+	Quantity          float64        `json:"quantity"`
+	Approved          bool           `json:"approved"`
+	Lines             []TransferLine `json:"lines"`
+	//TransferID is the saved inventory transfer, the saved transfers are skipped when the proposal is applied again
+	TransferID int `json:"transferID,omitempty"`
+}
+
+//Filters converts the transfer to the parameters of saveInventoryTransfer, an unconfirmed transfer
+//doesn't move the stock until it's confirmed in the back office
+func (t Transfer) Filters(confirm bool) map[string]string {
+	filters := map[string]string{
+		"warehouseFromID": strconv.Itoa(t.FromWarehouseID),
+		"warehouseToID":   strconv.Itoa(t.ToWarehouseID),
+		"confirmed":       "0",
+	}
+	if confirm {
+		filters["confirmed"] = "1"
+	}
+
+	n := 0
+	for _, line := range t.Lines {
+		if line.Quantity <= 0 {
+			continue
+		}
+		n++
+		suffix := strconv.Itoa(n)
+		filters["productID"+suffix] = strconv.Itoa(line.ProductID)
+		filters["amount"+suffix] = strconv.FormatFloat(line.Quantity, 'f', -1, 64)
+	}
+
+	return filters
+}
+
+//Proposal is the result of the planning, it can be saved to a file, reviewed and approved by a person and applied later
+type Proposal struct {
+	Transfers []Transfer `json:"transfers"`
+	//Unmet are the needs which no store has enough stock to cover
+	Unmet []Position `json:"unmet"`
+}
+
+func ReadProposal(r io.Reader) (*Proposal, error) {
+	proposal := &Proposal{}
+	if err := json.NewDecoder(r).Decode(proposal); err != nil {
+		return nil, fmt.Errorf("invalid proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+func (p *Proposal) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+//ApproveAll marks all transfers as approved
+func (p *Proposal) ApproveAll() {
+	for i := range p.Transfers {
+		p.Transfers[i].Approved = true
+	}
+}
+
+//TransferSaver is the part of InventoryManager which creates the inventory transfers
+type TransferSaver interface {
+	SaveInventoryTransfer(ctx context.Context, filters map[string]string) (inventoryTransferID int, err error)
+}
+
+//TransferResult is the outcome of saving an approved transfer, TransferID is zero in the dry run or when saving failed
+type TransferResult struct {
+	FromWarehouseID int
+	ToWarehouseID   int
+	Lines           int
+	TransferID      int
+	Err             error
+}
+
+//Apply saves the approved transfers, confirmed when confirm is set, the dry run only returns the transfers
+//which would be saved. All approved transfers are tried and an error is returned when any of them failed.
+//The IDs of the saved transfers are stored in the proposal, so it should be written again after it's applied
+func (p *Proposal) Apply(ctx context.Context, saver TransferSaver, confirm, dryRun bool) ([]TransferResult, error) {
+	results := []TransferResult{}
+	failed := 0
+	for i, transfer := range p.Transfers {
+		if !transfer.Approved || transfer.TransferID != 0 {
+			continue
+		}
+
+		result := TransferResult{FromWarehouseID: transfer.FromWarehouseID, ToWarehouseID: transfer.ToWarehouseID, Lines: len(transfer.Lines)}
+		if !dryRun {
+			id, err := saver.SaveInventoryTransfer(ctx, transfer.Filters(confirm))
+			if err != nil {
+				result.Err = err
+				failed++
+			} else {
+				result.TransferID = id
+				p.Transfers[i].TransferID = id
+			}
+		}
+		results = append(results, result)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("failed to save %d of %d inventory transfers", failed, len(results))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,414 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/currency"
+)
+
+const day = 24 * time.Hour
+
+//Bucket is an aging interval of the open items by the days past the due date
+type Bucket int
+
+const (
+	BucketCurrent Bucket = iota
+	Bucket1To30
+	Bucket31To60
+	Bucket61To90
+	BucketOver90
+	bucketCount
+)
+
+//Buckets lists all aging buckets in order
+var Buckets = []Bucket{BucketCurrent, Bucket1To30, Bucket31To60, Bucket61To90, BucketOver90}
+
+func (b Bucket) String() string {
+	switch b {
+	case BucketCurrent:
+		return "current"
+	case Bucket1To30:
+		return "1-30"
+	case Bucket31To60:
+		return "31-60"
+	case Bucket61To90:
+		return "61-90"
+	case BucketOver90:
+		return "90+"
+	}
+	return fmt.Sprintf("Bucket(%d)", int(b))
+}
+
+//bucketOf returns the bucket of the days past the due date
+func bucketOf(daysOverdue int) Bucket {
+	switch {
+	case daysOverdue <= 0:
+		return BucketCurrent
+	case daysOverdue <= 30:
+		return Bucket1To30
+	case daysOverdue <= 60:
+		return Bucket31To60
+	case daysOverdue <= 90:
+		return Bucket61To90
+	}
+	return BucketOver90
+}
+
+//receivableTypes are the documents which the customer has to pay or which reduce the debt of the customer
+var receivableTypes = map[string]bool{
+	sales.SaleDocumentTypeInvoice:       true,
+	sales.SaleDocumentTypeInvWayBill:    true,
+	sales.SaleDocumentTypeCASHINVOICE:   true,
+	sales.SaleDocumentTypeExportInvoice: true,
+	sales.SaleDocumentTypeCreditInvoice: true,
+}
+
+//OpenItem is an invoice or a credit invoice which is not fully paid, the credit invoices have negative amounts
+type OpenItem struct {
+	DocumentID            int
+	Type                  string
+	Number                string
+	ReferenceNumber       string
+	CustomReferenceNumber string
+	CustomerID            int
+	CustomerName          string
+	Date                  time.Time
+	DueDate               time.Time
+	Currency              string
+	Total                 sharedCommon.Decimal
+	Paid                  sharedCommon.Decimal
+	Open                  sharedCommon.Decimal
+	DaysOverdue           int
+	Bucket                Bucket
+}
+
+//Balance is the open amount of a currency split into the aging buckets
+type Balance struct {
+	Currency string
+	Buckets  [bucketCount]sharedCommon.Decimal
+	Total    sharedCommon.Decimal
+}
+
+func (b *Balance) add(item OpenItem) {
+	b.Buckets[item.Bucket] = b.Buckets[item.Bucket].Add(item.Open).Round(2)
+	b.Total = b.Total.Add(item.Open).Round(2)
+}
+
+//CustomerAging is the open items of a customer with the balances per currency
+type CustomerAging struct {
+	CustomerID   int
+	CustomerName string
+	Items        []OpenItem
+	Balances     []Balance
+}
+
+//AgingReport is the accounts receivable aging of all customers
+type AgingReport struct {
+	AsOf      time.Time
+	Customers []CustomerAging
+	//Totals are the balances of all customers per currency
+	Totals []Balance
+}
+
+//Ledger collects the sales documents and payments of the customers for the receivables reports
+type Ledger struct {
+	//AsOf is the date of the reports, the documents and payments after it are ignored
+	AsOf time.Time
+	//DefaultCurrency is used for the documents and payments without a currency
+	DefaultCurrency string
+	//Presentation is the currency of the report amounts, the documents are converted at the rates of their dates
+	//and the payments at the rates of the payment dates into the base currency of the Rates
+	Presentation currency.Presentation
+	Rates        *currency.Table
+
+	documents []sales.SaleDocument
+	payments  []sales.PaymentInfo
+	now       func() time.Time
+}
+
+//NewLedger creates a ledger for the reports as of the date
+func NewLedger(asOf time.Time) *Ledger {
+	return &Ledger{
+		AsOf:      sharedCommon.UTC.Day(asOf),
+		documents: []sales.SaleDocument{},
+		payments:  []sales.PaymentInfo{},
+		now:       time.Now,
+	}
+}
+
+//AddDocuments adds the sales documents, only the confirmed invoices and credit invoices which are not cancelled are kept
+func (l *Ledger) AddDocuments(docs ...sales.SaleDocument) {
+	for _, doc := range docs {
+		if !receivableTypes[strings.ToUpper(doc.Type)] || doc.Confirmed == "0" || strings.EqualFold(doc.InvoiceState, "CANCELLED") {
+			continue
+		}
+		l.documents = append(l.documents, doc)
+	}
+}
+
+//AddPayments adds the payments of the customers
+func (l *Ledger) AddPayments(payments ...sales.PaymentInfo) error {
+	for _, payment := range payments {
+		if _, err := payment.SumDecimal(); err != nil {
+			return fmt.Errorf("invalid sum %q of payment %d", payment.Sum, payment.PaymentID)
+		}
+		l.payments = append(l.payments, payment)
+	}
+
+	return nil
+}
+
+//OpenItems returns the documents which are not fully paid as of the date ordered by the customer, due date and number
+func (l *Ledger) OpenItems() ([]OpenItem, error) {
+	if err := l.checkPresentation(); err != nil {
+		return nil, err
+	}
+
+	paid := map[int]sharedCommon.Decimal{}
+	for _, payment := range l.payments {
+		if payment.DocumentID == 0 || l.afterAsOf(payment.Date) {
+			continue
+		}
+		sum, _ := payment.SumDecimal()
+		paid[payment.DocumentID] = paid[payment.DocumentID].Add(sum)
+	}
+
+	items := []OpenItem{}
+	for _, doc := range l.documents {
+		item, err := l.openItem(doc, paid)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			continue
+		}
+		items = append(items, *item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].CustomerID != items[j].CustomerID {
+			return items[i].CustomerID < items[j].CustomerID
+		}
+		if !items[i].DueDate.Equal(items[j].DueDate) {
+			return items[i].DueDate.Before(items[j].DueDate)
+		}
+		return items[i].Number < items[j].Number
+	})
+
+	return items, nil
+}
+
+func (l *Ledger) openItem(doc sales.SaleDocument, paid map[int]sharedCommon.Decimal) (*OpenItem, error) {
+	date, dueDate, err := documentDates(doc)
+	if err != nil {
+		return nil, err
+	}
+	if date.After(l.AsOf) {
+		return nil, nil
+	}
+
+	paidSum, ok := paid[doc.ID]
+	//the paid amount and the payment status of the document are current, so they can't be used
+	//for an earlier date when the document was paid after it
+	if l.asOfToday() {
+		if !ok {
+			//the document paid amount is used when the payments of the document are not loaded
+			paidSum, err = doc.PaidDecimal()
+			if err != nil {
+				return nil, fmt.Errorf("invalid paid amount %q of %s %s", doc.Paid, doc.Type, doc.Number)
+			}
+		}
+		if strings.EqualFold(doc.PaymentStatus, "PAID") {
+			paidSum = doc.TotalDecimal()
+		}
+	}
+
+	//the amounts are rounded to cents, so a settled document has exactly nothing open
+	total := doc.TotalDecimal().Round(2)
+	paidSum = paidSum.Round(2)
+	open := total.Sub(paidSum)
+	if open.IsZero() {
+		return nil, nil
+	}
+
+	daysOverdue := int(l.AsOf.Sub(dueDate) / day)
+	customerID, customerName := customerOf(doc)
+
+	if l.inBaseCurrency() {
+		//the open amount stays at the rate of the document, the exchange differences are realised by the payments
+		rate, err := l.Rates.DocumentRate(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", doc.Type, doc.Number, err)
+		}
+		total = total.Mul(sharedCommon.DecimalFromFloat(rate)).Round(2)
+		paidSum = paidSum.Mul(sharedCommon.DecimalFromFloat(rate)).Round(2)
+		open = total.Sub(paidSum)
+	}
+
+	return &OpenItem{
+		DocumentID:            doc.ID,
+		Type:                  strings.ToUpper(doc.Type),
+		Number:                doc.Number,
+		ReferenceNumber:       doc.ReferenceNumber,
+		CustomReferenceNumber: doc.CustomReferenceNumber,
+		CustomerID:            customerID,
+		CustomerName:          customerName,
+		Date:                  date,
+		DueDate:               dueDate,
+		Currency:              l.reportCurrency(doc.CurrencyCode),
+		Total:                 total,
+		Paid:                  paidSum,
+		Open:                  open,
+		DaysOverdue:           daysOverdue,
+		Bucket:                bucketOf(daysOverdue),
+	}, nil
+}
+
+//Aging builds the aging report of the open items
+func (l *Ledger) Aging() (*AgingReport, error) {
+	items, err := l.OpenItems()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AgingReport{
+		AsOf:      l.AsOf,
+		Customers: []CustomerAging{},
+	}
+	totals := balances{}
+	for _, item := range items {
+		n := len(report.Customers)
+		if n == 0 || report.Customers[n-1].CustomerID != item.CustomerID {
+			report.Customers = append(report.Customers, CustomerAging{
+				CustomerID:   item.CustomerID,
+				CustomerName: item.CustomerName,
+			})
+			n++
+		}
+		report.Customers[n-1].Items = append(report.Customers[n-1].Items, item)
+		totals.add(item)
+	}
+	for i := range report.Customers {
+		customerBalances := balances{}
+		for _, item := range report.Customers[i].Items {
+			customerBalances.add(item)
+		}
+		report.Customers[i].Balances = customerBalances.sorted()
+	}
+	report.Totals = totals.sorted()
+
+	return report, nil
+}
+
+//balances accumulates the open items per currency
+type balances map[string]*Balance
+
+func (b balances) add(item OpenItem) {
+	balance, ok := b[item.Currency]
+	if !ok {
+		balance = &Balance{Currency: item.Currency}
+		b[item.Currency] = balance
+	}
+	balance.add(item)
+}
+
+func (b balances) sorted() []Balance {
+	res := make([]Balance, 0, len(b))
+	for _, balance := range b {
+		res = append(res, *balance)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Currency < res[j].Currency
+	})
+
+	return res
+}
+
+func (l *Ledger) currency(code string) string {
+	if code == "" {
+		return l.DefaultCurrency
+	}
+	return strings.ToUpper(code)
+}
+
+//reportCurrency is the currency of the report amounts of a document or payment in the currency
+func (l *Ledger) reportCurrency(code string) string {
+	if l.inBaseCurrency() {
+		return l.Rates.Base
+	}
+	return l.currency(code)
+}
+
+func (l *Ledger) inBaseCurrency() bool {
+	return l.Presentation == currency.BaseCurrency
+}
+
+func (l *Ledger) checkPresentation() error {
+	if l.inBaseCurrency() && l.Rates == nil {
+		return errors.New("exchange rates are required for the reports in the base currency")
+	}
+	return nil
+}
+
+//asOfToday tells if the reports are for the current state of the documents
+func (l *Ledger) asOfToday() bool {
+	return !l.AsOf.Before(sharedCommon.UTC.Day(l.now()))
+}
+
+func (l *Ledger) afterAsOf(date string) bool {
+	parsed, err := sharedCommon.UTC.ParseDate(date)
+	return err == nil && parsed.After(l.AsOf)
+}
+
+//documentDates returns the date and the due date of the document
+func documentDates(doc sales.SaleDocument) (date, dueDate time.Time, err error) {
+	date, err = sharedCommon.UTC.ParseDate(doc.Date)
+	if err != nil || date.IsZero() {
+		return date, dueDate, fmt.Errorf("invalid date %q of %s %s", doc.Date, doc.Type, doc.Number)
+	}
+
+	paymentDays := 0
+	if doc.PaymentDays != "" {
+		paymentDays, err = strconv.Atoi(doc.PaymentDays)
+		if err != nil {
+			return date, dueDate, fmt.Errorf("invalid payment days %q of %s %s", doc.PaymentDays, doc.Type, doc.Number)
+		}
+	}
+
+	return date, date.AddDate(0, 0, paymentDays), nil
+}
+
+//customerOf returns the customer who pays the document
+func customerOf(doc sales.SaleDocument) (int, string) {
+	if doc.PayerID != 0 && doc.PayerID != doc.CustomerID {
+		return doc.PayerID, doc.PayerName
+	}
+	return doc.CustomerID, doc.ClientName
+}
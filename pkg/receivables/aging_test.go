@@ -0,0 +1,260 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLedger(t *testing.T) *Ledger {
+	ledger := NewLedger(time.Date(2022, 6, 30, 15, 0, 0, 0, time.UTC))
+	ledger.now = func() time.Time { return time.Date(2022, 6, 30, 15, 0, 0, 0, time.UTC) }
+	ledger.DefaultCurrency = "EUR"
+	ledger.AddDocuments(
+		sales.SaleDocument{ID: 1, Type: "INVOICE", Number: "I-1", CustomerID: 5, ClientName: "Acme", Date: "2022-06-20", PaymentDays: "14", Total: 100},
+		sales.SaleDocument{ID: 2, Type: "INVOICE", Number: "I-2", CustomerID: 5, ClientName: "Acme", Date: "2022-05-01", PaymentDays: "14", Total: 200, CurrencyCode: "EUR"},
+		sales.SaleDocument{ID: 3, Type: "INVOICE", Number: "I-3", CustomerID: 5, Date: "2022-01-01", Total: 80, PaymentStatus: "PAID"},
+		sales.SaleDocument{ID: 4, Type: "CREDITINVOICE", Number: "C-4", CustomerID: 5, Date: "2022-06-25", Total: -30},
+		sales.SaleDocument{ID: 5, Type: "INVWAYBILL", Number: "I-5", CustomerID: 6, PayerID: 7, PayerName: "Beta Holding", Date: "2022-04-01", Total: 40.5, Paid: "0.25", CurrencyCode: "usd"},
+		sales.SaleDocument{ID: 6, Type: "INVOICE", Number: "I-6", CustomerID: 5, Date: "2022-06-01", Total: 10, InvoiceState: "CANCELLED"},
+		sales.SaleDocument{ID: 7, Type: "ORDER", Number: "O-7", CustomerID: 5, Date: "2022-06-01", Total: 10},
+		sales.SaleDocument{ID: 8, Type: "INVOICE", Number: "I-8", CustomerID: 5, Date: "2022-07-05", Total: 10},
+	)
+	err := ledger.AddPayments(
+		sales.PaymentInfo{PaymentID: 1, DocumentID: 2, CustomerID: 5, Date: "2022-05-20", Sum: "50", ReferenceNumber: "RF-2"},
+		sales.PaymentInfo{PaymentID: 2, DocumentID: 3, Date: "2022-01-10", Sum: "80", Type: "TRANSFER"},
+		sales.PaymentInfo{PaymentID: 3, DocumentID: 1, CustomerID: 5, Date: "2022-07-01", Sum: "100"},
+	)
+	assert.NoError(t, err)
+
+	return ledger
+}
+
+func TestAging(t *testing.T) {
+	report, err := newTestLedger(t).Aging()
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Len(t, report.Customers, 2)
+	acme := report.Customers[0]
+	assert.Equal(t, 5, acme.CustomerID)
+	assert.Equal(t, "Acme", acme.CustomerName)
+	assert.Equal(t, []string{"I-2", "C-4", "I-1"}, itemNumbers(acme.Items))
+	assert.Equal(t, "150.00", acme.Items[0].Open.String())
+	assert.Equal(t, 46, acme.Items[0].DaysOverdue)
+	assert.Equal(t, Bucket31To60, acme.Items[0].Bucket)
+	assert.Equal(t, [][]string{{"EUR", "100.00", "-30.00", "150.00", "0.00", "0.00", "220.00"}}, balanceRecords(acme.Balances))
+
+	beta := report.Customers[1]
+	assert.Equal(t, 7, beta.CustomerID)
+	assert.Equal(t, "Beta Holding", beta.CustomerName)
+	assert.Equal(t, Bucket61To90, beta.Items[0].Bucket)
+	assert.Equal(t, [][]string{{"USD", "0.00", "0.00", "0.00", "40.25", "0.00", "40.25"}}, balanceRecords(beta.Balances))
+
+	assert.Equal(t, [][]string{
+		{"EUR", "100.00", "-30.00", "150.00", "0.00", "0.00", "220.00"},
+		{"USD", "0.00", "0.00", "0.00", "40.25", "0.00", "40.25"},
+	}, balanceRecords(report.Totals))
+}
+
+func TestAgingPaidAfterAsOf(t *testing.T) {
+	testCases := []struct {
+		name string
+		asOf time.Time
+		paid []string
+		open []string
+	}{
+		{"payment after the date is still open", time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC), []string{"40.00"}, []string{"60.00"}},
+		{"both payments on or before the date", time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), []string{}, []string{}},
+	}
+	for _, testCase := range testCases {
+		ledger := NewLedger(testCase.asOf)
+		ledger.now = func() time.Time { return time.Date(2022, 8, 1, 9, 0, 0, 0, time.UTC) }
+		ledger.AddDocuments(sales.SaleDocument{ID: 1, Type: "INVOICE", Number: "I-1", CustomerID: 5, Date: "2022-06-01", Total: 100, Paid: "100", PaymentStatus: "PAID"})
+		assert.NoError(t, ledger.AddPayments(
+			sales.PaymentInfo{PaymentID: 1, DocumentID: 1, Date: "2022-06-10", Sum: "40"},
+			sales.PaymentInfo{PaymentID: 2, DocumentID: 1, Date: "2022-07-15", Sum: "60"},
+		))
+
+		items, err := ledger.OpenItems()
+		assert.NoError(t, err, testCase.name)
+		paid, open := []string{}, []string{}
+		for _, item := range items {
+			paid = append(paid, item.Paid.String())
+			open = append(open, item.Open.String())
+		}
+		assert.Equal(t, testCase.paid, paid, testCase.name)
+		assert.Equal(t, testCase.open, open, testCase.name)
+	}
+}
+
+func TestAgingAsOfTodayUsesDocumentPaid(t *testing.T) {
+	ledger := NewLedger(time.Now())
+	ledger.AddDocuments(
+		sales.SaleDocument{ID: 1, Type: "INVOICE", Number: "I-1", CustomerID: 5, Date: "2022-06-01", Total: 100, Paid: "30"},
+		sales.SaleDocument{ID: 2, Type: "INVOICE", Number: "I-2", CustomerID: 5, Date: "2022-06-01", Total: 50, PaymentStatus: "PAID"},
+	)
+
+	items, err := ledger.OpenItems()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"I-1"}, itemNumbers(items))
+	if len(items) == 1 {
+		assert.Equal(t, "70.00", items[0].Open.String())
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	testCases := []struct {
+		daysOverdue int
+		bucket      Bucket
+	}{
+		{-5, BucketCurrent},
+		{0, BucketCurrent},
+		{1, Bucket1To30},
+		{30, Bucket1To30},
+		{31, Bucket31To60},
+		{90, Bucket61To90},
+		{91, BucketOver90},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.bucket, bucketOf(testCase.daysOverdue), "%d days overdue", testCase.daysOverdue)
+	}
+	assert.Equal(t, "90+", BucketOver90.String())
+}
+
+func TestStatement(t *testing.T) {
+	testCases := []struct {
+		name       string
+		customerID int
+		from       time.Time
+		csv        string
+		err        string
+	}{
+		{
+			name:       "opening balance of the earlier documents",
+			customerID: 5,
+			from:       time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+			csv: `date,kind,reference,due_date,currency,debit,credit,balance
+2022-06-01,OPENING,,,EUR,,,150.00
+2022-06-20,INVOICE,I-1,2022-07-04,EUR,100.00,0.00,250.00
+2022-06-25,CREDITINVOICE,C-4,2022-06-25,EUR,0.00,30.00,220.00
+2022-06-30,CLOSING,,,EUR,100.00,30.00,220.00
+`,
+		},
+		{
+			name:       "period starting after its end",
+			customerID: 5,
+			from:       time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC),
+			err:        "statement period starts on 2022-07-01 after its end on 2022-06-30",
+		},
+	}
+	for _, testCase := range testCases {
+		statement, err := newTestLedger(t).Statement(testCase.customerID, testCase.from)
+		if testCase.err != "" {
+			assert.EqualError(t, err, testCase.err, testCase.name)
+			continue
+		}
+		assert.NoError(t, err, testCase.name)
+		if err != nil {
+			continue
+		}
+
+		assert.Equal(t, "Acme", statement.CustomerName, testCase.name)
+		assert.Len(t, statement.Aging, 1, testCase.name)
+		assert.Equal(t, "120.00", formatAmount(statement.Totals[0].Overdue), testCase.name)
+		buf := &bytes.Buffer{}
+		assert.NoError(t, WriteStatementCSV(buf, statement))
+		assert.Equal(t, testCase.csv, buf.String(), testCase.name)
+	}
+}
+
+func TestBaseCurrency(t *testing.T) {
+	ledger := newTestLedger(t)
+	ledger.Presentation = currency.BaseCurrency
+	_, err := ledger.Aging()
+	assert.EqualError(t, err, "exchange rates are required for the reports in the base currency")
+
+	ledger.Rates = currency.NewTable("EUR")
+	assert.NoError(t, ledger.Rates.Add("USD", time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), 0.8))
+	assert.NoError(t, ledger.Rates.Add("USD", time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), 0.9))
+	assert.NoError(t, ledger.AddPayments(sales.PaymentInfo{PaymentID: 4, DocumentID: 5, Date: "2022-06-10", Sum: "20", ReferenceNumber: "RF-5"}))
+
+	report, err := ledger.Aging()
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	beta := report.Customers[1]
+	assert.Equal(t, "EUR", beta.Items[0].Currency)
+	assert.Equal(t, "32.40", beta.Items[0].Total.String())
+	assert.Equal(t, "16.00", beta.Items[0].Paid.String())
+	assert.Equal(t, "16.40", beta.Items[0].Open.String())
+	assert.Equal(t, [][]string{{"EUR", "100.00", "-30.00", "150.00", "16.40", "0.00", "236.40"}}, balanceRecords(report.Totals))
+
+	statement, err := ledger.Statement(7, time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteStatementCSV(buf, statement))
+	assert.Equal(t, `date,kind,reference,due_date,currency,debit,credit,balance
+2022-04-01,OPENING,,,EUR,,,0.00
+2022-04-01,INVWAYBILL,I-5,2022-04-01,EUR,32.40,0.00,32.40
+2022-06-10,PAYMENT,RF-5,,EUR,0.00,18.00,14.40
+2022-06-10,FXDIFF,I-5,,EUR,2.00,0.00,16.40
+2022-06-30,CLOSING,,,EUR,34.40,18.00,16.40
+`, buf.String())
+	assert.Equal(t, "16.40", formatAmount(statement.Totals[0].Overdue))
+}
+
+func TestInvalidDocumentDate(t *testing.T) {
+	ledger := NewLedger(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))
+	ledger.AddDocuments(sales.SaleDocument{Type: "INVOICE", Number: "I-1", Date: "20.06.2022"})
+
+	_, err := ledger.Aging()
+	assert.EqualError(t, err, `invalid date "20.06.2022" of INVOICE I-1`)
+	assert.EqualError(t, ledger.AddPayments(sales.PaymentInfo{PaymentID: 4, Sum: "1,5"}), `invalid sum "1,5" of payment 4`)
+}
+
+func itemNumbers(items []OpenItem) []string {
+	numbers := make([]string, 0, len(items))
+	for _, item := range items {
+		numbers = append(numbers, item.Number)
+	}
+	return numbers
+}
+
+func balanceRecords(balances []Balance) [][]string {
+	records := make([][]string, 0, len(balances))
+	for _, balance := range balances {
+		records = append(records, balanceRecord("", "", balance)[2:])
+	}
+	return records
+}
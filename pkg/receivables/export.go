@@ -0,0 +1,201 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/jung-kurt/gofpdf"
+)
+
+//WriteAgingCSV writes a row per customer and currency followed by the totals per currency
+func WriteAgingCSV(w io.Writer, report *AgingReport) error {
+	header := []string{"customer_id", "customer_name", "currency"}
+	for _, bucket := range Buckets {
+		header = append(header, bucket.String())
+	}
+	header = append(header, "total")
+
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, customer := range report.Customers {
+		for _, balance := range customer.Balances {
+			if err := out.Write(balanceRecord(strconv.Itoa(customer.CustomerID), customer.CustomerName, balance)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, balance := range report.Totals {
+		if err := out.Write(balanceRecord("", "TOTAL", balance)); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+func balanceRecord(customerID, customerName string, balance Balance) []string {
+	record := []string{customerID, customerName, balance.Currency}
+	for _, bucket := range Buckets {
+		record = append(record, formatAmount(balance.Buckets[bucket]))
+	}
+
+	return append(record, formatAmount(balance.Total))
+}
+
+//WriteStatementCSV writes the statement lines between the opening and closing balances of each currency
+func WriteStatementCSV(w io.Writer, statement *Statement) error {
+	out := csv.NewWriter(w)
+	err := out.Write([]string{"date", "kind", "reference", "due_date", "currency", "debit", "credit", "balance"})
+	if err != nil {
+		return err
+	}
+
+	for _, total := range statement.Totals {
+		err := out.Write([]string{
+			formatDate(statement.From), "OPENING", "", "", total.Currency, "", "", formatAmount(total.Opening),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, line := range statement.Lines {
+		err := out.Write([]string{
+			formatDate(line.Date), line.Kind, line.Reference, formatDate(line.DueDate), line.Currency,
+			formatAmount(line.Debit), formatAmount(line.Credit), formatAmount(line.Balance),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, total := range statement.Totals {
+		err := out.Write([]string{
+			formatDate(statement.To), "CLOSING", "", "", total.Currency,
+			formatAmount(total.Debit), formatAmount(total.Credit), formatAmount(total.Closing),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteStatementPDF renders the statement as an A4 PDF document
+func WriteStatementPDF(w io.Writer, statement *Statement) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Statement of account", true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Statement of account", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("Customer: %s (%d)", statement.CustomerName, statement.CustomerID)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Period: %s - %s", formatDate(statement.From), formatDate(statement.To)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{22, 30, 34, 22, 16, 22, 22, 22}
+	writeRow := func(cells []string, style string) {
+		pdf.SetFont("Helvetica", style, 9)
+		for i, cell := range cells {
+			align := "L"
+			if i >= 5 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, tr(cell), "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	writeRow([]string{"Date", "Kind", "Reference", "Due date", "Currency", "Debit", "Credit", "Balance"}, "B")
+	for _, total := range statement.Totals {
+		writeRow([]string{formatDate(statement.From), "Opening balance", "", "", total.Currency, "", "", formatAmount(total.Opening)}, "")
+	}
+	for _, line := range statement.Lines {
+		writeRow([]string{
+			formatDate(line.Date), line.Kind, line.Reference, formatDate(line.DueDate), line.Currency,
+			formatAmount(line.Debit), formatAmount(line.Credit), formatAmount(line.Balance),
+		}, "")
+	}
+	for _, total := range statement.Totals {
+		writeRow([]string{
+			formatDate(statement.To), "Closing balance", "", "", total.Currency,
+			formatAmount(total.Debit), formatAmount(total.Credit), formatAmount(total.Closing),
+		}, "B")
+	}
+
+	if len(statement.Aging) > 0 {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 8, "Open items by days overdue", "", 1, "L", false, 0, "")
+
+		agingWidths := []float64{26, 26, 26, 26, 26, 26, 26}
+		header := []string{"Currency"}
+		for _, bucket := range Buckets {
+			header = append(header, bucket.String())
+		}
+		header = append(header, "Total")
+
+		rows := [][]string{header}
+		for _, balance := range statement.Aging {
+			rows = append(rows, balanceRecord("", "", balance)[2:])
+		}
+		for i, row := range rows {
+			style := ""
+			if i == 0 {
+				style = "B"
+			}
+			pdf.SetFont("Helvetica", style, 9)
+			for j, cell := range row {
+				align := "R"
+				if j == 0 {
+					align = "L"
+				}
+				pdf.CellFormat(agingWidths[j], 6, cell, "B", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	return pdf.Output(w)
+}
+
+func formatAmount(value sharedCommon.Decimal) string {
+	return value.StringFixed(2)
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(sharedCommon.DateLayout)
+}
@@ -0,0 +1,68 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAgingCSV(t *testing.T) {
+	report, err := newTestLedger(t).Aging()
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteAgingCSV(buf, report))
+	assert.Equal(t, `customer_id,customer_name,currency,current,1-30,31-60,61-90,90+,total
+5,Acme,EUR,100.00,-30.00,150.00,0.00,0.00,220.00
+7,Beta Holding,USD,0.00,0.00,0.00,40.25,0.00,40.25
+,TOTAL,EUR,100.00,-30.00,150.00,0.00,0.00,220.00
+,TOTAL,USD,0.00,0.00,0.00,40.25,0.00,40.25
+`, buf.String())
+}
+
+func TestWriteStatementCSV(t *testing.T) {
+	statement, err := newTestLedger(t).Statement(5, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteStatementCSV(buf, statement))
+	assert.Equal(t, `date,kind,reference,due_date,currency,debit,credit,balance
+2022-06-01,OPENING,,,EUR,,,150.00
+2022-06-20,INVOICE,I-1,2022-07-04,EUR,100.00,0.00,250.00
+2022-06-25,CREDITINVOICE,C-4,2022-06-25,EUR,0.00,30.00,220.00
+2022-06-30,CLOSING,,,EUR,100.00,30.00,220.00
+`, buf.String())
+}
+
+func TestWriteStatementPDF(t *testing.T) {
+	statement, err := newTestLedger(t).Statement(5, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	statement.CustomerName = "Acme Größe"
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteStatementPDF(buf, statement))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, buf.String(), "%%EOF")
+}
@@ -0,0 +1,72 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//Load reads the invoices, credit invoices and payments until the date of the ledger,
+//the documents and payments of all customers are read when the customer ID is 0
+func (l *Ledger) Load(ctx context.Context, salesAPI sales.Manager, settings sharedCommon.ListingSettings, customerID int) error {
+	types := make([]string, 0, len(receivableTypes))
+	for docType := range receivableTypes {
+		types = append(types, docType)
+	}
+	sort.Strings(types)
+
+	docFilters := map[string]interface{}{
+		"types":  strings.Join(types, ","),
+		"dateTo": l.AsOf.Format(sharedCommon.DateLayout),
+	}
+	paymentFilters := map[string]interface{}{
+		"dateTo": l.AsOf.Format(sharedCommon.DateLayout),
+	}
+	if customerID != 0 {
+		docFilters["clientID"] = customerID
+		paymentFilters["customerID"] = customerID
+	}
+
+	lister := sharedCommon.NewLister(settings, sales.NewSaleDocumentsListingDataProvider(salesAPI), nil)
+	for item := range lister.Get(ctx, docFilters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		l.AddDocuments(item.Payload.(sales.SaleDocument))
+	}
+
+	paymentsLister := sharedCommon.NewLister(settings, sales.NewPaymentsListingDataProvider(salesAPI), nil)
+	for item := range paymentsLister.Get(ctx, paymentFilters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := l.AddPayments(item.Payload.(sales.PaymentInfo)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
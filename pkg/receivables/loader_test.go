@@ -0,0 +1,92 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		request := parsedRequest["requests"].([]map[string]interface{})[0]
+		assert.Equal(t, "2022-06-30", fmt.Sprint(request["dateTo"]))
+		status := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok"}}
+		status.RecordsTotal = 1
+		var resp interface{}
+		switch request["requestName"] {
+		case "getSalesDocuments":
+			assert.Equal(t, "CASHINVOICE,CREDITINVOICE,EXPORTINVOICE,INVOICE,INVWAYBILL", request["types"])
+			assert.Equal(t, "5", fmt.Sprint(request["clientID"]))
+			resp = sales.GetSaleDocumentResponseBulk{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				BulkItems: []sales.GetSaleDocumentBulkItem{{
+					Status:        status,
+					SaleDocuments: []sales.SaleDocument{{ID: 1, Type: "INVOICE", Number: "I-1", CustomerID: 5, Date: "2022-06-01", Total: 100}},
+				}},
+			}
+		case "getPayments":
+			assert.Equal(t, "5", fmt.Sprint(request["customerID"]))
+			resp = sales.GetPaymentsResponseBulk{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				BulkItems: []sales.GetPaymentsBulkItem{{
+					Status:       status,
+					PaymentInfos: []sales.PaymentInfo{{PaymentID: 1, DocumentID: 1, Date: "2022-06-10", Sum: "60"}},
+				}},
+			}
+		default:
+			t.Errorf("unexpected request %v", request["requestName"])
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	ledger := NewLedger(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))
+	err := ledger.Load(context.Background(), sales.NewClient(baseClient), sharedCommon.ListingSettings{}, 5)
+	assert.NoError(t, err)
+
+	items, err := ledger.OpenItems()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "40.00", items[0].Open.String())
+		assert.Equal(t, Bucket1To30, items[0].Bucket)
+	}
+}
@@ -0,0 +1,295 @@
+package receivables
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+const (
+	//PaymentKind is the kind of the statement lines of the payments
+	PaymentKind = "PAYMENT"
+	//FXDifferenceKind is the kind of the statement lines of the realised exchange differences of the payments,
+	//they are only reported in the base currency
+	FXDifferenceKind = "FXDIFF"
+)
+
+//StatementLine is a document or payment in the customer statement
+type StatementLine struct {
+	Date time.Time
+	//Kind is the document type or PAYMENT
+	Kind string
+	//Reference is the document number or the payment reference
+	Reference string
+	//DueDate is zero for the payments
+	DueDate  time.Time
+	Currency string
+	Debit    sharedCommon.Decimal
+	Credit   sharedCommon.Decimal
+	//Balance is the running balance of the currency
+	Balance sharedCommon.Decimal
+}
+
+//CurrencyTotal sums the statement of a currency
+type CurrencyTotal struct {
+	Currency string
+	Opening  sharedCommon.Decimal
+	Debit    sharedCommon.Decimal
+	Credit   sharedCommon.Decimal
+	Closing  sharedCommon.Decimal
+	Overdue  sharedCommon.Decimal
+}
+
+//Statement is the account statement of a customer for the period
+type Statement struct {
+	CustomerID   int
+	CustomerName string
+	From         time.Time
+	To           time.Time
+	Lines        []StatementLine
+	Totals       []CurrencyTotal
+	//Aging is the aging of the open items of the customer at the end of the period
+	Aging []Balance
+}
+
+//Statement builds the statement of the customer from the date until the date of the ledger,
+//the documents and payments before the period make up the opening balance
+func (l *Ledger) Statement(customerID int, from time.Time) (*Statement, error) {
+	from = sharedCommon.UTC.Day(from)
+	if err := l.checkPresentation(); err != nil {
+		return nil, err
+	}
+	if from.After(l.AsOf) {
+		return nil, fmt.Errorf("statement period starts on %s after its end on %s", from.Format(sharedCommon.DateLayout), l.AsOf.Format(sharedCommon.DateLayout))
+	}
+
+	statement := &Statement{
+		CustomerID: customerID,
+		From:       from,
+		To:         l.AsOf,
+		Lines:      []StatementLine{},
+	}
+
+	entries := []StatementLine{}
+	documentCustomers := map[int]int{}
+	documentCurrencies := map[int]string{}
+	customerDocuments := []sales.SaleDocument{}
+	for _, doc := range l.documents {
+		docCustomerID, docCustomerName := customerOf(doc)
+		documentCustomers[doc.ID] = docCustomerID
+		documentCurrencies[doc.ID] = l.currency(doc.CurrencyCode)
+		if docCustomerID != customerID {
+			continue
+		}
+		if statement.CustomerName == "" {
+			statement.CustomerName = docCustomerName
+		}
+		customerDocuments = append(customerDocuments, doc)
+
+		date, dueDate, err := documentDates(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		total := doc.TotalDecimal()
+		if l.inBaseCurrency() {
+			rate, err := l.Rates.DocumentRate(doc)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", doc.Type, doc.Number, err)
+			}
+			total = total.Mul(sharedCommon.DecimalFromFloat(rate)).Round(2)
+		}
+
+		line := StatementLine{
+			Date:      date,
+			Kind:      strings.ToUpper(doc.Type),
+			Reference: doc.Number,
+			DueDate:   dueDate,
+			Currency:  l.reportCurrency(doc.CurrencyCode),
+		}
+		if total.Sign() >= 0 {
+			line.Debit = total
+		} else {
+			line.Credit = total.Neg()
+		}
+		entries = append(entries, line)
+	}
+
+	for _, payment := range l.payments {
+		paymentCustomerID := payment.CustomerID
+		if paymentCustomerID == 0 {
+			paymentCustomerID = documentCustomers[payment.DocumentID]
+		}
+		if paymentCustomerID != customerID {
+			continue
+		}
+
+		date, err := sharedCommon.UTC.ParseDate(payment.Date)
+		if err != nil || date.IsZero() {
+			return nil, fmt.Errorf("invalid date %q of payment %d", payment.Date, payment.PaymentID)
+		}
+		currency := payment.CurrencyCode
+		if currency == "" {
+			currency = documentCurrencies[payment.DocumentID]
+		}
+		reference := payment.ReferenceNumber
+		if reference == "" {
+			reference = payment.Type
+		}
+
+		sum, _ := payment.SumDecimal()
+		if l.inBaseCurrency() {
+			rate, err := l.Rates.PaymentRate(payment, l.currency(currency))
+			if err != nil {
+				return nil, fmt.Errorf("payment %d: %w", payment.PaymentID, err)
+			}
+			sum = sum.Mul(sharedCommon.DecimalFromFloat(rate)).Round(2)
+		}
+		line := StatementLine{
+			Date:      date,
+			Kind:      PaymentKind,
+			Reference: reference,
+			Currency:  l.reportCurrency(currency),
+		}
+		if sum.Sign() >= 0 {
+			line.Credit = sum
+		} else {
+			line.Debit = sum.Neg()
+		}
+		entries = append(entries, line)
+	}
+
+	if l.inBaseCurrency() {
+		lines, err := l.fxDifferenceLines(customerDocuments)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, lines...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		if kindOrder(entries[i].Kind) != kindOrder(entries[j].Kind) {
+			return kindOrder(entries[i].Kind) < kindOrder(entries[j].Kind)
+		}
+		return entries[i].Reference < entries[j].Reference
+	})
+
+	totals := map[string]*CurrencyTotal{}
+	for _, entry := range entries {
+		if entry.Date.After(l.AsOf) {
+			continue
+		}
+		total, ok := totals[entry.Currency]
+		if !ok {
+			total = &CurrencyTotal{Currency: entry.Currency}
+			totals[entry.Currency] = total
+		}
+
+		if entry.Date.Before(from) {
+			total.Opening = total.Opening.Add(entry.Debit).Sub(entry.Credit).Round(2)
+			total.Closing = total.Opening
+			continue
+		}
+		total.Debit = total.Debit.Add(entry.Debit).Round(2)
+		total.Credit = total.Credit.Add(entry.Credit).Round(2)
+		total.Closing = total.Closing.Add(entry.Debit).Sub(entry.Credit).Round(2)
+		entry.Balance = total.Closing
+		statement.Lines = append(statement.Lines, entry)
+	}
+
+	report, err := l.Aging()
+	if err != nil {
+		return nil, err
+	}
+	for _, customer := range report.Customers {
+		if customer.CustomerID == customerID {
+			statement.Aging = customer.Balances
+			break
+		}
+	}
+	for _, balance := range statement.Aging {
+		total, ok := totals[balance.Currency]
+		if !ok {
+			continue
+		}
+		total.Overdue = balance.Total.Sub(balance.Buckets[BucketCurrent]).Round(2)
+	}
+
+	statement.Totals = make([]CurrencyTotal, 0, len(totals))
+	for _, total := range totals {
+		statement.Totals = append(statement.Totals, *total)
+	}
+	sort.Slice(statement.Totals, func(i, j int) bool {
+		return statement.Totals[i].Currency < statement.Totals[j].Currency
+	})
+
+	return statement, nil
+}
+
+//fxDifferenceLines returns the realised exchange differences of the payments of the documents, a gain is a debit
+//because the payment line credits more than the document debited
+func (l *Ledger) fxDifferenceLines(docs []sales.SaleDocument) ([]StatementLine, error) {
+	lines := []StatementLine{}
+	for _, doc := range docs {
+		doc.CurrencyCode = l.currency(doc.CurrencyCode)
+		differences, err := l.Rates.Differences(doc, l.payments)
+		if err != nil {
+			return nil, err
+		}
+		for _, difference := range differences {
+			line := StatementLine{
+				Date:      difference.Date,
+				Kind:      FXDifferenceKind,
+				Reference: doc.Number,
+				Currency:  l.Rates.Base,
+			}
+			amount := difference.Difference
+			if amount.Sign() >= 0 {
+				line.Debit = amount
+			} else {
+				line.Credit = amount.Neg()
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+//kindOrder sorts the documents before the payments and the payments before their exchange differences
+func kindOrder(kind string) int {
+	switch kind {
+	case PaymentKind:
+		return 1
+	case FXDifferenceKind:
+		return 2
+	}
+	return 0
+}
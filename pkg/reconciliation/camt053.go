@@ -0,0 +1,221 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//camtDocument is the part of the ISO 20022 BankToCustomerStatement (camt.053) used for the reconciliation,
+//the element names match any version of the message because the namespace is ignored
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID      string        `xml:"Id"`
+	IBAN    string        `xml:"Acct>Id>IBAN"`
+	Other   string        `xml:"Acct>Id>Othr>Id"`
+	Ccy     string        `xml:"Acct>Ccy"`
+	Balance []camtBalance `xml:"Bal"`
+	Entries []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtEntry struct {
+	Ref         string          `xml:"NtryRef"`
+	Amount      camtAmount      `xml:"Amt"`
+	CdtDbtInd   string          `xml:"CdtDbtInd"`
+	Status      camtStatus      `xml:"Sts"`
+	BookingDate string          `xml:"BookgDt>Dt"`
+	BookingTime string          `xml:"BookgDt>DtTm"`
+	ValueDate   string          `xml:"ValDt>Dt"`
+	ServicerRef string          `xml:"AcctSvcrRef"`
+	Details     []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTxDetails struct {
+	ServicerRef  string     `xml:"Refs>AcctSvcrRef"`
+	EndToEndID   string     `xml:"Refs>EndToEndId"`
+	Amount       camtAmount `xml:"Amt"`
+	DebtorName   string     `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPty    string     `xml:"RltdPties>Dbtr>Pty>Nm"`
+	DebtorIBAN   string     `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+	DebtorOther  string     `xml:"RltdPties>DbtrAcct>Id>Othr>Id"`
+	CreditorName string     `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPty  string     `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Unstructured []string   `xml:"RmtInf>Ustrd"`
+	CreditorRef  []string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+//ParseCAMT053 parses the booked entries of the ISO 20022 camt.053 statements, an entry with several
+//transaction details e.g. a batch of transfers becomes one transaction per detail
+func ParseCAMT053(r io.Reader) ([]Statement, error) {
+	doc := camtDocument{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 statement: %v", err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("invalid camt.053 statement: no statements found")
+	}
+
+	statements := make([]Statement, 0, len(doc.Statements))
+	for _, stmt := range doc.Statements {
+		statement := Statement{
+			ID:           stmt.ID,
+			Account:      firstNonEmpty(stmt.IBAN, stmt.Other),
+			Currency:     stmt.Ccy,
+			Transactions: []Transaction{},
+		}
+
+		for _, balance := range stmt.Balance {
+			amount, err := camtSignedAmount(balance.Amount, balance.CdtDbtInd)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: invalid balance: %v", stmt.ID, err)
+			}
+			switch balance.Code {
+			case "OPBD", "PRCD":
+				statement.OpeningBalance = amount
+			case "CLBD":
+				statement.ClosingBalance = amount
+			}
+			if statement.Currency == "" {
+				statement.Currency = balance.Amount.Currency
+			}
+		}
+
+		for i, entry := range stmt.Entries {
+			status := strings.TrimSpace(firstNonEmpty(entry.Status.Code, entry.Status.Text))
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			transactions, err := camtTransactions(entry)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: entry %d: %v", stmt.ID, i+1, err)
+			}
+			statement.Transactions = append(statement.Transactions, transactions...)
+		}
+
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+func camtTransactions(entry camtEntry) ([]Transaction, error) {
+	date := entry.BookingDate
+	if date == "" && len(entry.BookingTime) >= len(sharedCommon.DateLayout) {
+		date = entry.BookingTime[:len(sharedCommon.DateLayout)]
+	}
+
+	details := entry.Details
+	if len(details) == 0 {
+		details = []camtTxDetails{{}}
+	}
+
+	transactions := make([]Transaction, 0, len(details))
+	for i, detail := range details {
+		amount := entry.Amount
+		if len(details) > 1 && detail.Amount.Value != "" {
+			amount = detail.Amount
+		}
+		signed, err := camtSignedAmount(amount, entry.CdtDbtInd)
+		if err != nil {
+			return nil, err
+		}
+
+		id := firstNonEmpty(detail.ServicerRef, entry.ServicerRef, entry.Ref)
+		if len(details) > 1 && detail.ServicerRef == "" && id != "" {
+			id = fmt.Sprintf("%s/%d", id, i+1)
+		}
+
+		transaction := Transaction{
+			ID:          id,
+			Date:        date,
+			ValueDate:   entry.ValueDate,
+			Amount:      signed,
+			Currency:    amount.Currency,
+			Reference:   firstNonEmpty(strings.Join(detail.CreditorRef, " "), endToEndID(detail.EndToEndID)),
+			Description: strings.Join(detail.Unstructured, " "),
+		}
+		if signed.Sign() >= 0 {
+			transaction.PayerName = firstNonEmpty(detail.DebtorName, detail.DebtorPty)
+			transaction.PayerAccount = firstNonEmpty(detail.DebtorIBAN, detail.DebtorOther)
+		} else {
+			transaction.PayerName = firstNonEmpty(detail.CreditorName, detail.CreditorPty)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+//camtSignedAmount returns the amount with the sign of the credit or debit indicator, the indicator
+//of a reversal already tells the direction of the reversing entry
+func camtSignedAmount(amount camtAmount, cdtDbtInd string) (sharedCommon.Decimal, error) {
+	value, err := sharedCommon.ParseDecimal(amount.Value)
+	if err != nil || strings.TrimSpace(amount.Value) == "" {
+		return sharedCommon.Decimal{}, fmt.Errorf("invalid amount %q", amount.Value)
+	}
+	if cdtDbtInd == "DBIT" {
+		value = value.Neg()
+	}
+
+	return value, nil
+}
+
+//endToEndID skips the placeholder which is used when the payer didn't give an ID
+func endToEndID(id string) string {
+	if id == "NOTPROVIDED" {
+		return ""
+	}
+	return id
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCAMT053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG-1</MsgId></GrpHdr>
+    <Stmt>
+      <Id>STMT-2022-06-30</Id>
+      <Acct><Id><IBAN>EE382200221020145685</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1270.50</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="EUR">150.50</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2022-06-29</Dt></BookgDt>
+        <ValDt><Dt>2022-06-29</Dt></ValDt>
+        <AcctSvcrRef>BANK-1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+          <RltdPties>
+            <Dbtr><Nm>Acme Ltd</Nm></Dbtr>
+            <DbtrAcct><Id><IBAN>EE471000001020145685</IBAN></Id></DbtrAcct>
+          </RltdPties>
+          <RmtInf>
+            <Ustrd>Invoice I-2</Ustrd>
+            <Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd>
+          </RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">200.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2022-06-30T10:15:00</DtTm></BookgDt>
+        <AcctSvcrRef>BANK-2</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">120.00</Amt>
+            <Refs><EndToEndId>E2E-1</EndToEndId></Refs>
+            <RltdPties><Dbtr><Pty><Nm>Beta Holding</Nm></Pty></Dbtr></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">80.00</Amt>
+            <RmtInf><Ustrd>I-7</Ustrd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">80.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2022-06-30</Dt></BookgDt>
+        <AcctSvcrRef>BANK-3</AcctSvcrRef>
+        <NtryDtls><TxDtls><RltdPties><Cdtr><Nm>Landlord</Nm></Cdtr></RltdPties></TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">999.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	statements, err := ParseStatements(strings.NewReader(testCAMT053))
+	assert.NoError(t, err)
+	if !assert.Len(t, statements, 1) {
+		return
+	}
+
+	statement := statements[0]
+	assert.Equal(t, "STMT-2022-06-30", statement.ID)
+	assert.Equal(t, "EE382200221020145685", statement.Account)
+	assert.Equal(t, "EUR", statement.Currency)
+	assert.Equal(t, "1000.00", statement.OpeningBalance.String())
+	assert.Equal(t, "1270.50", statement.ClosingBalance.String())
+	assert.Equal(t, []Transaction{
+		{
+			ID: "BANK-1", Date: "2022-06-29", ValueDate: "2022-06-29", Amount: sharedCommon.MustParseDecimal("150.50"), Currency: "EUR",
+			Reference: "RF18539007547034", Description: "Invoice I-2", PayerName: "Acme Ltd", PayerAccount: "EE471000001020145685",
+		},
+		{ID: "BANK-2/1", Date: "2022-06-30", Amount: sharedCommon.MustParseDecimal("120.00"), Currency: "EUR", Reference: "E2E-1", PayerName: "Beta Holding"},
+		{ID: "BANK-2/2", Date: "2022-06-30", Amount: sharedCommon.MustParseDecimal("80.00"), Currency: "EUR", Description: "I-7"},
+		{ID: "BANK-3", Date: "2022-06-30", Amount: sharedCommon.MustParseDecimal("-80.00"), Currency: "EUR", PayerName: "Landlord"},
+	}, statement.Transactions)
+}
+
+func TestParseCAMT053Errors(t *testing.T) {
+	_, err := ParseCAMT053(strings.NewReader(`<Document><BkToCstmrStmt></BkToCstmrStmt></Document>`))
+	assert.EqualError(t, err, "invalid camt.053 statement: no statements found")
+
+	_, err = ParseCAMT053(strings.NewReader(`<Document><BkToCstmrStmt><Stmt><Id>S1</Id><Ntry><Amt Ccy="EUR">1,5</Amt></Ntry></Stmt></BkToCstmrStmt></Document>`))
+	assert.EqualError(t, err, `statement S1: entry 1: invalid amount "1,5"`)
+
+	_, err = ParseStatements(strings.NewReader("date;amount"))
+	assert.Equal(t, ErrUnknownFormat, err)
+}
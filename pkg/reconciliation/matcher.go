@@ -0,0 +1,302 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/receivables"
+)
+
+//the weights of the matching criteria, a transaction with the reference and the open amount of the invoice
+//and the name of the customer has the confidence 1
+const (
+	referenceWeight = 0.5
+	numberWeight    = 0.4
+	amountWeight    = 0.3
+	totalWeight     = 0.2
+	partialWeight   = 0.05
+	nameWeight      = 0.2
+)
+
+const (
+	//DefaultMinConfidence is the lowest confidence of the proposed matches
+	DefaultMinConfidence = 0.3
+	//DefaultConfirmConfidence is the confidence from which the matches are confirmed without a review
+	DefaultConfirmConfidence = 0.9
+
+	//references shorter than this are not searched from the free text
+	minReferenceLength = 4
+	//references shorter than this are not searched across the spaces in the free text
+	minGroupedReferenceLength = 6
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+//Matcher matches the incoming bank transactions to the open invoices
+type Matcher struct {
+	//MinConfidence is the lowest confidence of the proposed matches
+	MinConfidence float64
+	//ConfirmConfidence is the confidence from which the matches are confirmed automatically, 0 disables it
+	ConfirmConfidence float64
+
+	items []receivables.OpenItem
+}
+
+//NewMatcher creates a matcher of the open invoices, the credit invoices are skipped
+func NewMatcher(items ...receivables.OpenItem) *Matcher {
+	m := &Matcher{
+		MinConfidence:     DefaultMinConfidence,
+		ConfirmConfidence: DefaultConfirmConfidence,
+		items:             []receivables.OpenItem{},
+	}
+	for _, item := range items {
+		if item.Open.Round(2).Sign() > 0 {
+			m.items = append(m.items, item)
+		}
+	}
+
+	return m
+}
+
+type candidate struct {
+	transaction int
+	item        int
+	confidence  float64
+	reasons     []string
+	byReference bool
+}
+
+//Match proposes the invoices paid by the incoming transactions. The candidates are assigned from the highest
+//confidence down, a transaction pays several invoices only when their references or numbers are in it
+func (m *Matcher) Match(transactions []Transaction) *Proposal {
+	candidates := []candidate{}
+	for i, transaction := range transactions {
+		if transaction.Amount.Round(2).Sign() <= 0 {
+			continue
+		}
+		for j, item := range m.items {
+			c := score(transaction, item)
+			if c.confidence < m.MinConfidence-1e-9 {
+				continue
+			}
+			c.transaction, c.item = i, j
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].confidence != candidates[j].confidence {
+			return candidates[i].confidence > candidates[j].confidence
+		}
+		if candidates[i].transaction != candidates[j].transaction {
+			return candidates[i].transaction < candidates[j].transaction
+		}
+		return m.items[candidates[i].item].DueDate.Before(m.items[candidates[j].item].DueDate)
+	})
+
+	//the remaining amounts are exact cents, so splitting a transaction between many invoices doesn't drift
+	left := make([]sharedCommon.Decimal, len(transactions))
+	for i, transaction := range transactions {
+		left[i] = transaction.Amount.Round(2)
+	}
+	open := make([]sharedCommon.Decimal, len(m.items))
+	for i, item := range m.items {
+		open[i] = item.Open.Round(2)
+	}
+	matched := make([]bool, len(transactions))
+
+	proposal := &Proposal{Matches: []Match{}, Unmatched: []Transaction{}}
+	for _, c := range candidates {
+		if left[c.transaction].Sign() <= 0 || open[c.item].Sign() <= 0 {
+			continue
+		}
+		if matched[c.transaction] && !c.byReference {
+			continue
+		}
+
+		transaction := transactions[c.transaction]
+		item := m.items[c.item]
+		amount := left[c.transaction]
+		if open[c.item].Cmp(amount) < 0 {
+			amount = open[c.item]
+		}
+		left[c.transaction] = left[c.transaction].Sub(amount)
+		open[c.item] = open[c.item].Sub(amount)
+		matched[c.transaction] = true
+
+		proposal.Matches = append(proposal.Matches, Match{
+			Transaction:    transaction,
+			DocumentID:     item.DocumentID,
+			DocumentNumber: item.Number,
+			CustomerID:     item.CustomerID,
+			CustomerName:   item.CustomerName,
+			OpenAmount:     item.Open,
+			Amount:         amount,
+			Confidence:     sharedCommon.DecimalFromFloat(c.confidence).Round(2).Float64(),
+			Reasons:        c.reasons,
+			Confirmed:      m.ConfirmConfidence > 0 && c.confidence >= m.ConfirmConfidence-1e-9,
+		})
+	}
+
+	for i, transaction := range transactions {
+		if transaction.Amount.Round(2).Sign() > 0 && !matched[i] {
+			proposal.Unmatched = append(proposal.Unmatched, transaction)
+		}
+	}
+
+	return proposal
+}
+
+//score rates how likely the transaction pays the open item
+func score(transaction Transaction, item receivables.OpenItem) candidate {
+	c := candidate{reasons: []string{}}
+	if item.Currency != "" && transaction.Currency != "" && !strings.EqualFold(item.Currency, transaction.Currency) {
+		return c
+	}
+
+	text := transaction.Reference + " " + transaction.Description
+	switch {
+	case matchesReference(transaction.Reference, text, item.ReferenceNumber):
+		c.confidence += referenceWeight
+		c.byReference = true
+		c.reasons = append(c.reasons, "reference number")
+	case matchesReference(transaction.Reference, text, item.CustomReferenceNumber):
+		c.confidence += referenceWeight
+		c.byReference = true
+		c.reasons = append(c.reasons, "custom reference number")
+	case containsToken(text, item.Number):
+		c.confidence += numberWeight
+		c.byReference = true
+		c.reasons = append(c.reasons, "invoice number")
+	}
+
+	//the amounts are compared in cents
+	amount := transaction.Amount.Round(2)
+	switch {
+	case amount.Equal(item.Open):
+		c.confidence += amountWeight
+		c.reasons = append(c.reasons, "open amount")
+	case amount.Equal(item.Total):
+		c.confidence += totalWeight
+		c.reasons = append(c.reasons, "invoice total")
+	case c.byReference && amount.Cmp(item.Open) < 0:
+		c.confidence += partialWeight
+		c.reasons = append(c.reasons, "partial payment")
+	}
+
+	if similarity := nameSimilarity(transaction.PayerName, item.CustomerName); similarity > 0 {
+		c.confidence += nameWeight * similarity
+		c.reasons = append(c.reasons, "payer name")
+	}
+
+	return c
+}
+
+//matchesReference compares the references without spaces and punctuation, the reference given by the payer
+//has to be equal and the free text has to contain it
+func matchesReference(reference, text, invoiceReference string) bool {
+	invoiceReference = normalizeReference(invoiceReference)
+	if len(invoiceReference) < minReferenceLength {
+		return false
+	}
+	if normalizeReference(reference) == invoiceReference {
+		return true
+	}
+
+	for _, token := range nonAlphanumeric.Split(strings.ToUpper(text), -1) {
+		if strings.TrimLeft(token, "0") == strings.TrimLeft(invoiceReference, "0") {
+			return true
+		}
+	}
+	//the references are often split into groups e.g. RF18 5390 0754 7034, only the references with letters
+	//are searched this way because a numeric reference could be a part of another number
+	if len(invoiceReference) < minGroupedReferenceLength || strings.IndexFunc(invoiceReference, unicode.IsLetter) < 0 {
+		return false
+	}
+	return strings.Contains(normalizeReference(text), invoiceReference)
+}
+
+//containsToken tells if the text contains the value between the word boundaries
+func containsToken(text, value string) bool {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return false
+	}
+	text, value = strings.ToUpper(text), strings.ToUpper(value)
+	for start := 0; ; {
+		i := strings.Index(text[start:], value)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(value)
+		if (i == 0 || !isAlphanumeric(text[i-1])) && (end == len(text) || !isAlphanumeric(text[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isAlphanumeric(c byte) bool {
+	return 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func normalizeReference(reference string) string {
+	return nonAlphanumeric.ReplaceAllString(strings.ToUpper(reference), "")
+}
+
+//nameSimilarity is the share of the words of the customer name which are in the payer name
+func nameSimilarity(payerName, customerName string) float64 {
+	customerWords := nameWords(customerName)
+	if len(customerWords) == 0 {
+		return 0
+	}
+
+	payerWords := map[string]bool{}
+	for _, word := range nameWords(payerName) {
+		payerWords[word] = true
+	}
+	found := 0
+	for _, word := range customerWords {
+		if payerWords[word] {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(customerWords))
+}
+
+func nameWords(name string) []string {
+	words := []string{}
+	separator := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+	for _, word := range strings.FieldsFunc(strings.ToUpper(name), separator) {
+		if len([]rune(word)) >= 2 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,121 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/receivables"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOpenItems() []receivables.OpenItem {
+	due := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	return []receivables.OpenItem{
+		{DocumentID: 2, Number: "I-2", ReferenceNumber: "RF18 5390 0754 7034", CustomerID: 5, CustomerName: "Acme Ltd", Currency: "EUR", Total: sharedCommon.MustParseDecimal("200"), Open: sharedCommon.MustParseDecimal("150.5"), DueDate: due},
+		{DocumentID: 8, Number: "I-8", CustomerID: 7, CustomerName: "Beta Holding", Currency: "EUR", Total: sharedCommon.MustParseDecimal("120"), Open: sharedCommon.MustParseDecimal("120"), DueDate: due.AddDate(0, 0, 1)},
+		{DocumentID: 7, Number: "I-7", CustomerID: 7, CustomerName: "Beta Holding", Currency: "EUR", Total: sharedCommon.MustParseDecimal("80"), Open: sharedCommon.MustParseDecimal("80"), DueDate: due},
+		{DocumentID: 9, Number: "I-9", CustomReferenceNumber: "PO-5566", CustomerID: 9, CustomerName: "Gamma", Currency: "USD", Total: sharedCommon.MustParseDecimal("120"), Open: sharedCommon.MustParseDecimal("120"), DueDate: due},
+		{DocumentID: 10, Number: "C-10", CustomerID: 5, Currency: "EUR", Total: sharedCommon.MustParseDecimal("-10"), Open: sharedCommon.MustParseDecimal("-10"), DueDate: due},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	transactions := []Transaction{
+		{ID: "T1", Amount: sharedCommon.MustParseDecimal("150.5"), Currency: "EUR", Reference: "RF18539007547034", PayerName: "ACME LTD"},
+		{ID: "T2", Amount: sharedCommon.MustParseDecimal("200"), Currency: "EUR", Description: "Payment for I-7 and I-8", PayerName: "Beta Holding OU"},
+		{ID: "T3", Amount: sharedCommon.MustParseDecimal("120"), Currency: "EUR", PayerName: "Unknown"},
+		{ID: "T4", Amount: sharedCommon.MustParseDecimal("-50"), Currency: "EUR", Description: "I-2"},
+		{ID: "T5", Amount: sharedCommon.MustParseDecimal("120"), Currency: "USD", PayerName: "Gamma Inc"},
+	}
+
+	proposal := NewMatcher(newTestOpenItems()...).Match(transactions)
+
+	assert.Equal(t, []string{"T1:I-2", "T2:I-7", "T2:I-8", "T5:I-9"}, matchKeys(proposal.Matches))
+	assert.Equal(t, 1.0, proposal.Matches[0].Confidence)
+	assert.Equal(t, []string{"reference number", "open amount", "payer name"}, proposal.Matches[0].Reasons)
+	assert.True(t, proposal.Matches[0].Confirmed)
+
+	assert.Equal(t, 0.6, proposal.Matches[1].Confidence)
+	assert.Equal(t, "80.00", proposal.Matches[1].Amount.String())
+	assert.Equal(t, "120.00", proposal.Matches[2].Amount.String())
+	assert.False(t, proposal.Matches[1].Confirmed)
+
+	assert.Equal(t, 0.5, proposal.Matches[3].Confidence)
+	assert.Equal(t, []string{"open amount", "payer name"}, proposal.Matches[3].Reasons)
+
+	if assert.Len(t, proposal.Unmatched, 1) {
+		assert.Equal(t, "T3", proposal.Unmatched[0].ID)
+	}
+}
+
+func TestMatchPartialPaymentByCustomReference(t *testing.T) {
+	matcher := NewMatcher(newTestOpenItems()...)
+	matcher.ConfirmConfidence = 0
+
+	proposal := matcher.Match([]Transaction{{ID: "T1", Amount: sharedCommon.MustParseDecimal("100"), Currency: "USD", Description: "po 5566 first half"}})
+	if !assert.Equal(t, []string{"T1:I-9"}, matchKeys(proposal.Matches)) {
+		return
+	}
+	assert.Equal(t, 0.55, proposal.Matches[0].Confidence)
+	assert.Equal(t, []string{"custom reference number", "partial payment"}, proposal.Matches[0].Reasons)
+	assert.False(t, proposal.Matches[0].Confirmed)
+}
+
+func TestMatchSplitsInExactCents(t *testing.T) {
+	due := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	matcher := NewMatcher(
+		receivables.OpenItem{DocumentID: 1, Number: "A-1", Currency: "EUR", Total: sharedCommon.MustParseDecimal("0.1"), Open: sharedCommon.MustParseDecimal("0.1"), DueDate: due},
+		receivables.OpenItem{DocumentID: 2, Number: "A-2", Currency: "EUR", Total: sharedCommon.MustParseDecimal("0.2"), Open: sharedCommon.MustParseDecimal("0.2"), DueDate: due},
+		receivables.OpenItem{DocumentID: 3, Number: "A-3", Currency: "EUR", Total: sharedCommon.MustParseDecimal("33.37"), Open: sharedCommon.MustParseDecimal("33.37"), DueDate: due},
+	)
+
+	proposal := matcher.Match([]Transaction{{ID: "T1", Amount: sharedCommon.MustParseDecimal("33.67"), Currency: "EUR", Description: "A-1 A-2 A-3"}})
+	assert.Equal(t, []string{"T1:A-1", "T1:A-2", "T1:A-3"}, matchKeys(proposal.Matches))
+	amounts := []string{}
+	for _, match := range proposal.Matches {
+		amounts = append(amounts, match.Amount.String())
+	}
+	assert.Equal(t, []string{"0.10", "0.20", "33.37"}, amounts)
+	assert.Empty(t, proposal.Unmatched)
+}
+
+func TestMatchesReference(t *testing.T) {
+	assert.True(t, matchesReference("", "ref 00012345", "12345"))
+	assert.True(t, matchesReference("", "RF18 5390 0754 7034", "RF18539007547034"))
+	assert.False(t, matchesReference("", "order 123456", "12345"))
+	assert.False(t, matchesReference("", "12", "12"))
+	assert.False(t, matchesReference("", "PO 55", "PO55"))
+	assert.True(t, containsToken("paid I-7, thanks", "i-7"))
+	assert.False(t, containsToken("paid I-77", "I-7"))
+	assert.False(t, containsToken("paid AI-7", "I-7"))
+	assert.True(t, containsToken("paid I-77 and I-7", "I-7"))
+}
+
+func matchKeys(matches []Match) []string {
+	keys := make([]string, 0, len(matches))
+	for _, match := range matches {
+		keys = append(keys, match.Transaction.ID+":"+match.DocumentNumber)
+	}
+	return keys
+}
@@ -0,0 +1,300 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//mt940Tag matches the start of a field e.g. :61:
+var mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+//mt940Line matches the statement line (:61:) value date, entry date, mark, funds code, amount,
+//transaction type, customer reference and bank reference
+var mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([A-Z][A-Z0-9]{3})([^/\n]*)(?://([^\n]*))?`)
+
+//mt940Balance matches the balance fields (:60F:, :62F:) mark, date, currency and amount
+var mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)`)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+//ParseMT940 parses the SWIFT MT940 statements, the information to the account owner (:86:) is read
+//in the structured ?20 - ?33 form, in the /CODE/value form and as free text
+func ParseMT940(r io.Reader) ([]Statement, error) {
+	fields, err := mt940Fields(r)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []Statement{}
+	var statement *Statement
+	lastTag := ""
+	for _, field := range fields {
+		if field.tag != "20" && statement == nil {
+			return nil, fmt.Errorf("invalid MT940 statement: field :%s: before :20:", field.tag)
+		}
+
+		switch field.tag {
+		case "20":
+			statements = append(statements, Statement{ID: strings.TrimSpace(field.value), Transactions: []Transaction{}})
+			statement = &statements[len(statements)-1]
+		case "25":
+			statement.Account = strings.TrimSpace(field.value)
+		case "60F", "60M", "62F", "62M":
+			currency, amount, err := mt940ParseBalance(field.value)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: %v", statement.ID, err)
+			}
+			statement.Currency = currency
+			if strings.HasPrefix(field.tag, "60") {
+				statement.OpeningBalance = amount
+			} else {
+				statement.ClosingBalance = amount
+			}
+		case "61":
+			transaction, err := mt940ParseLine(field.value, statement.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: %v", statement.ID, err)
+			}
+			if transaction.ID == "" {
+				transaction.ID = fmt.Sprintf("%s/%d", statement.ID, len(statement.Transactions)+1)
+			}
+			statement.Transactions = append(statement.Transactions, transaction)
+		case "86":
+			//the information after the closing balance belongs to the statement, not to a transaction
+			if lastTag == "61" {
+				mt940ApplyInformation(&statement.Transactions[len(statement.Transactions)-1], field.value)
+			}
+		}
+		lastTag = field.tag
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("invalid MT940 statement: no statements found")
+	}
+
+	return statements, nil
+}
+
+//mt940Fields splits the messages into the fields, the SWIFT block headers and trailers are skipped
+func mt940Fields(r io.Reader) ([]mt940Field, error) {
+	fields := []mt940Field{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if i := strings.Index(line, "{4:"); i >= 0 {
+			line = line[i+len("{4:"):]
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "{") || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		if match := mt940Tag.FindStringSubmatch(line); match != nil {
+			fields = append(fields, mt940Field{tag: match[1], value: line[len(match[0]):]})
+			continue
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid MT940 statement: unexpected line %q", line)
+		}
+		fields[len(fields)-1].value += "\n" + line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func mt940ParseBalance(value string) (string, sharedCommon.Decimal, error) {
+	match := mt940Balance.FindStringSubmatch(value)
+	if match == nil {
+		return "", sharedCommon.Decimal{}, fmt.Errorf("invalid balance %q", value)
+	}
+
+	amount, err := mt940ParseAmount(match[4])
+	if err != nil {
+		return "", sharedCommon.Decimal{}, err
+	}
+	if match[1] == "D" {
+		amount = amount.Neg()
+	}
+
+	return match[3], amount, nil
+}
+
+func mt940ParseLine(value, currency string) (Transaction, error) {
+	match := mt940Line.FindStringSubmatch(value)
+	if match == nil {
+		return Transaction{}, fmt.Errorf("invalid statement line %q", value)
+	}
+
+	valueDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid value date in %q", value)
+	}
+	bookingDate := valueDate
+	if match[2] != "" {
+		bookingDate, err = time.Parse("0102", match[2])
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid entry date in %q", value)
+		}
+		//the entry date has no year, it's the year of the value date unless they are on both sides of the new year
+		bookingDate = bookingDate.AddDate(valueDate.Year(), 0, 0)
+		switch {
+		case bookingDate.Sub(valueDate) > 180*24*time.Hour:
+			bookingDate = bookingDate.AddDate(-1, 0, 0)
+		case valueDate.Sub(bookingDate) > 180*24*time.Hour:
+			bookingDate = bookingDate.AddDate(1, 0, 0)
+		}
+	}
+
+	amount, err := mt940ParseAmount(match[5])
+	if err != nil {
+		return Transaction{}, err
+	}
+	//the reversal of a credit is an outgoing amount and the reversal of a debit is an incoming one
+	if match[3] == "D" || match[3] == "RC" {
+		amount = amount.Neg()
+	}
+
+	reference := strings.TrimSpace(match[7])
+	if reference == "NONREF" {
+		reference = ""
+	}
+
+	return Transaction{
+		ID:        strings.TrimSpace(match[8]),
+		Date:      bookingDate.Format(sharedCommon.DateLayout),
+		ValueDate: valueDate.Format(sharedCommon.DateLayout),
+		Amount:    amount,
+		Currency:  currency,
+		Reference: reference,
+	}, nil
+}
+
+func mt940ParseAmount(value string) (sharedCommon.Decimal, error) {
+	amount, err := sharedCommon.ParseDecimal(strings.Replace(value, ",", ".", 1))
+	if err != nil || strings.TrimSpace(value) == "" {
+		return sharedCommon.Decimal{}, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
+
+//mt940Subfield matches the subfields of the structured information e.g. ?20
+var mt940Subfield = regexp.MustCompile(`\?(\d{2})`)
+
+//mt940Code matches the codes of the information in the /CODE/value form
+var mt940Code = regexp.MustCompile(`/(EREF|KREF|MREF|CRED|DEBT|NAME|REMI|IBAN|BIC|ORDP|BENM|PURP|SVWZ|ABWA|ACCW)/`)
+
+func mt940ApplyInformation(transaction *Transaction, value string) {
+	compact := strings.Replace(value, "\n", "", -1)
+
+	//structured information e.g. 166?00GUTSCHRIFT?20Invoice 123?32Acme
+	if len(compact) > 4 && compact[3] == '?' {
+		purpose := []string{}
+		names := []string{}
+		for _, subfield := range mt940Subfields(compact[3:]) {
+			code, _ := strconv.Atoi(subfield[0])
+			switch {
+			case code >= 20 && code <= 29, code >= 60 && code <= 63:
+				purpose = append(purpose, subfield[1])
+			case code == 31:
+				transaction.PayerAccount = strings.TrimSpace(subfield[1])
+			case code == 32 || code == 33:
+				names = append(names, subfield[1])
+			}
+		}
+		transaction.PayerName = strings.TrimSpace(strings.Join(names, ""))
+		transaction.Description = strings.TrimSpace(strings.Join(purpose, ""))
+		mt940ApplyCodes(transaction, transaction.Description)
+		return
+	}
+
+	if mt940Code.MatchString(compact) {
+		mt940ApplyCodes(transaction, compact)
+		return
+	}
+
+	transaction.Description = strings.TrimSpace(strings.Replace(value, "\n", " ", -1))
+}
+
+func mt940Subfields(value string) [][2]string {
+	indexes := mt940Subfield.FindAllStringSubmatchIndex(value, -1)
+	subfields := make([][2]string, 0, len(indexes))
+	for i, index := range indexes {
+		end := len(value)
+		if i+1 < len(indexes) {
+			end = indexes[i+1][0]
+		}
+		subfields = append(subfields, [2]string{value[index[2]:index[3]], value[index[1]:end]})
+	}
+
+	return subfields
+}
+
+//mt940ApplyCodes reads the /CODE/value information and the SEPA codes in the purpose e.g. EREF+123
+func mt940ApplyCodes(transaction *Transaction, value string) {
+	for _, sepa := range []string{"EREF+", "KREF+", "SVWZ+"} {
+		value = strings.Replace(value, sepa, "/"+strings.TrimSuffix(sepa, "+")+"/", -1)
+	}
+
+	indexes := mt940Code.FindAllStringSubmatchIndex(value, -1)
+	if len(indexes) == 0 {
+		return
+	}
+	if prefix := strings.TrimSpace(value[:indexes[0][0]]); prefix != "" {
+		transaction.Description = prefix
+	} else {
+		transaction.Description = ""
+	}
+
+	for i, index := range indexes {
+		end := len(value)
+		if i+1 < len(indexes) {
+			end = indexes[i+1][0]
+		}
+		code := value[index[2]:index[3]]
+		content := strings.Trim(value[index[1]:end], "/ ")
+		switch code {
+		case "EREF":
+			if content != "NOTPROVIDED" && transaction.Reference == "" {
+				transaction.Reference = content
+			}
+		case "NAME":
+			transaction.PayerName = content
+		case "IBAN":
+			transaction.PayerAccount = content
+		case "REMI", "SVWZ":
+			content = strings.TrimPrefix(strings.TrimPrefix(content, "USTD//"), "STRD/CUR//")
+			transaction.Description = strings.TrimSpace(strings.TrimSpace(transaction.Description + " " + content))
+		}
+	}
+}
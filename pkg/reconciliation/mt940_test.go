@@ -0,0 +1,79 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMT940 = `{1:F01BANKEE2XAXXX0000000000}{2:I940BANKEE2XXXXXN}{4:
+:20:STMT-1
+:25:EE382200221020145685
+:28C:1/1
+:60F:C220628EUR1000,00
+:61:2206290629C150,50NTRFRF18539007547034//BANK-1
+:86:166?00GUTSCHRIFT?20EREF+E2E-9?21SVWZ+Invoice I-2 t?22hanks?31EE471000001020145685?32Acme Ltd
+:61:2212310102CR120,NTRFNONREF
+:86:/ORDP//NAME/Beta Holding/REMI/USTD//Payment for
+ I-7/IBAN/EE121000001020145685
+:61:220630D80,00NMSCNONREF//BANK-3
+:86:Rent June
+:62F:C220630EUR1190,50
+:86:Statement information
+-}`
+
+func TestParseMT940(t *testing.T) {
+	statements, err := ParseStatements(strings.NewReader(testMT940))
+	assert.NoError(t, err)
+	if !assert.Len(t, statements, 1) {
+		return
+	}
+
+	statement := statements[0]
+	assert.Equal(t, "STMT-1", statement.ID)
+	assert.Equal(t, "EE382200221020145685", statement.Account)
+	assert.Equal(t, "EUR", statement.Currency)
+	assert.Equal(t, "1000.00", statement.OpeningBalance.String())
+	assert.Equal(t, "1190.50", statement.ClosingBalance.String())
+	assert.Equal(t, []Transaction{
+		{
+			ID: "BANK-1", Date: "2022-06-29", ValueDate: "2022-06-29", Amount: sharedCommon.MustParseDecimal("150.50"), Currency: "EUR",
+			Reference: "RF18539007547034", Description: "Invoice I-2 thanks", PayerName: "Acme Ltd", PayerAccount: "EE471000001020145685",
+		},
+		{
+			ID: "STMT-1/2", Date: "2023-01-02", ValueDate: "2022-12-31", Amount: sharedCommon.MustParseDecimal("120"), Currency: "EUR",
+			Description: "Payment for I-7", PayerName: "Beta Holding", PayerAccount: "EE121000001020145685",
+		},
+		{ID: "BANK-3", Date: "2022-06-30", ValueDate: "2022-06-30", Amount: sharedCommon.MustParseDecimal("-80.00"), Currency: "EUR", Description: "Rent June"},
+	}, statement.Transactions)
+}
+
+func TestParseMT940Errors(t *testing.T) {
+	_, err := ParseMT940(strings.NewReader(":25:EE38\n"))
+	assert.EqualError(t, err, "invalid MT940 statement: field :25: before :20:")
+
+	_, err = ParseMT940(strings.NewReader(":20:S1\n:60F:C220628EUR1000,00\n:61:22062X\n"))
+	assert.EqualError(t, err, `statement S1: invalid statement line "22062X"`)
+}
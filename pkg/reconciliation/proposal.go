@@ -0,0 +1,193 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//PaymentType is the type of the payments created for the confirmed matches
+const PaymentType = "TRANSFER"
+
+//Match is a proposed payment of the invoice from the bank transaction, it's only saved when it's confirmed
+type Match struct {
+	Transaction    Transaction          `json:"transaction"`
+	DocumentID     int                  `json:"documentID"`
+	DocumentNumber string               `json:"documentNumber"`
+	CustomerID     int                  `json:"customerID"`
+	CustomerName   string               `json:"customerName,omitempty"`
+	OpenAmount     sharedCommon.Decimal `json:"openAmount"`
+	//Amount is the part of the transaction which pays the invoice
+	Amount     sharedCommon.Decimal `json:"amount"`
+	Confidence float64              `json:"confidence"`
+	Reasons    []string             `json:"reasons"`
+	Confirmed  bool                 `json:"confirmed"`
+	//PaymentID is the payment created for the match, the applied matches are skipped when the proposal is applied again
+	PaymentID int `json:"paymentID,omitempty"`
+}
+
+//Filters converts the match to the parameters of savePayment, the bank sum is the part of the transaction
+//which pays the invoice, so the payments of a split transaction add up to the transaction amount
+func (m Match) Filters() map[string]interface{} {
+	filters := map[string]interface{}{
+		"documentID":         m.DocumentID,
+		"customerID":         m.CustomerID,
+		"type":               PaymentType,
+		"sum":                m.Amount.StringFixed(2),
+		"bankSum":            m.Amount.StringFixed(2),
+		"bankDocumentNumber": m.Transaction.ID,
+	}
+	optional := map[string]string{
+		"date":                m.Transaction.Date,
+		"bankDate":            m.Transaction.Date,
+		"currencyCode":        m.Transaction.Currency,
+		"bankCurrency":        m.Transaction.Currency,
+		"bankPayerName":       m.Transaction.PayerName,
+		"bankPayerAccount":    m.Transaction.PayerAccount,
+		"bankReferenceNumber": m.Transaction.Reference,
+		"bankDescription":     m.Transaction.Description,
+	}
+	for key, value := range optional {
+		if value != "" {
+			filters[key] = value
+		}
+	}
+
+	return filters
+}
+
+//Proposal is the result of the matching, it can be saved to a file, reviewed and confirmed by a person and applied later
+type Proposal struct {
+	Matches []Match `json:"matches"`
+	//Unmatched are the incoming transactions without any matching invoice
+	Unmatched []Transaction `json:"unmatched"`
+}
+
+func ReadProposal(r io.Reader) (*Proposal, error) {
+	proposal := &Proposal{}
+	if err := json.NewDecoder(r).Decode(proposal); err != nil {
+		return nil, fmt.Errorf("invalid proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+func (p *Proposal) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+//ConfirmAbove confirms the matches with at least the confidence and returns the count of the confirmed matches
+func (p *Proposal) ConfirmAbove(confidence float64) int {
+	confirmed := 0
+	for i := range p.Matches {
+		if p.Matches[i].Confidence >= confidence-1e-9 {
+			p.Matches[i].Confirmed = true
+		}
+		if p.Matches[i].Confirmed {
+			confirmed++
+		}
+	}
+	return confirmed
+}
+
+//PaymentSaver is the part of SalesManager which creates the payments
+type PaymentSaver interface {
+	SavePaymentsBulk(ctx context.Context, bulkFilters []map[string]interface{}, baseFilters map[string]string) (sales.SavePaymentsResponseBulk, error)
+}
+
+//PaymentResult is the outcome of saving a confirmed match, PaymentID is zero in the dry run or when saving failed
+type PaymentResult struct {
+	TransactionID  string
+	DocumentID     int
+	DocumentNumber string
+	Amount         sharedCommon.Decimal
+	PaymentID      int
+	Err            error
+}
+
+//Apply creates the payments of the confirmed matches in bulk requests, the dry run only returns the payments
+//which would be created. All confirmed matches are tried and an error is returned when any of them failed.
+//The IDs of the created payments are stored in the matches, so the proposal should be written again
+//after it's applied, the matches which already have a payment are skipped
+func (p *Proposal) Apply(ctx context.Context, saver PaymentSaver, dryRun bool) ([]PaymentResult, error) {
+	results := []PaymentResult{}
+	filters := []map[string]interface{}{}
+	//indexes are the positions of the matches of the results
+	indexes := []int{}
+	for i, match := range p.Matches {
+		if !match.Confirmed || match.PaymentID != 0 {
+			continue
+		}
+		indexes = append(indexes, i)
+		results = append(results, PaymentResult{
+			TransactionID:  match.Transaction.ID,
+			DocumentID:     match.DocumentID,
+			DocumentNumber: match.DocumentNumber,
+			Amount:         match.Amount,
+		})
+		filters = append(filters, match.Filters())
+	}
+	if dryRun {
+		return results, nil
+	}
+
+	failed := 0
+	for start := 0; start < len(filters); start += sharedCommon.MaxBulkRequestsCount {
+		end := start + sharedCommon.MaxBulkRequestsCount
+		if end > len(filters) {
+			end = len(filters)
+		}
+
+		resp, err := saver.SavePaymentsBulk(ctx, filters[start:end], map[string]string{})
+		for i := start; i < end; i++ {
+			bulkIndex := i - start
+			switch {
+			case bulkIndex < len(resp.BulkItems) && resp.BulkItems[bulkIndex].Status.ResponseStatus == "ok" &&
+				len(resp.BulkItems[bulkIndex].Records) > 0:
+				results[i].PaymentID = resp.BulkItems[bulkIndex].Records[0].PaymentID
+				p.Matches[indexes[i]].PaymentID = results[i].PaymentID
+			case bulkIndex < len(resp.BulkItems) && resp.BulkItems[bulkIndex].Status.ResponseStatus != "ok":
+				item := resp.BulkItems[bulkIndex]
+				results[i].Err = sharedCommon.NewErpError(item.Status.ErrorCode.String(), item.Status.Request+": "+item.Status.ResponseStatus, item.Status.ErrorCode)
+				failed++
+			case err != nil:
+				results[i].Err = err
+				failed++
+			default:
+				results[i].Err = fmt.Errorf("savePayment: no records in response")
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("failed to create %d of %d payments", failed, len(results))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,153 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProposal() *Proposal {
+	return &Proposal{
+		Matches: []Match{
+			{
+				Transaction: Transaction{ID: "T1", Date: "2022-06-29", Amount: sharedCommon.MustParseDecimal("150.5"), Currency: "EUR", Reference: "RF18539007547034", PayerName: "Acme Ltd"},
+				DocumentID:  2, DocumentNumber: "I-2", CustomerID: 5, OpenAmount: sharedCommon.MustParseDecimal("150.5"), Amount: sharedCommon.MustParseDecimal("150.5"), Confidence: 1, Confirmed: true,
+			},
+			{
+				Transaction: Transaction{ID: "T2", Date: "2022-06-30", Amount: sharedCommon.MustParseDecimal("200"), Currency: "EUR"},
+				DocumentID:  7, DocumentNumber: "I-7", CustomerID: 7, OpenAmount: sharedCommon.MustParseDecimal("80"), Amount: sharedCommon.MustParseDecimal("80"), Confidence: 0.6,
+			},
+			{
+				Transaction: Transaction{ID: "T2", Date: "2022-06-30", Amount: sharedCommon.MustParseDecimal("200"), Currency: "EUR"},
+				DocumentID:  8, DocumentNumber: "I-8", CustomerID: 7, OpenAmount: sharedCommon.MustParseDecimal("120"), Amount: sharedCommon.MustParseDecimal("120"), Confidence: 0.6,
+			},
+		},
+		Unmatched: []Transaction{},
+	}
+}
+
+func TestProposalRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, newTestProposal().Write(buf))
+
+	proposal, err := ReadProposal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, newTestProposal(), proposal)
+
+	assert.Equal(t, 1, proposal.ConfirmAbove(0.7))
+	assert.Equal(t, 3, proposal.ConfirmAbove(0.6))
+
+	_, err = ReadProposal(bytes.NewBufferString("matches"))
+	assert.Error(t, err)
+}
+
+func TestMatchFilters(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"documentID":          2,
+		"customerID":          5,
+		"type":                "TRANSFER",
+		"sum":                 "150.50",
+		"bankSum":             "150.50",
+		"bankDocumentNumber":  "T1",
+		"date":                "2022-06-29",
+		"bankDate":            "2022-06-29",
+		"currencyCode":        "EUR",
+		"bankCurrency":        "EUR",
+		"bankPayerName":       "Acme Ltd",
+		"bankReferenceNumber": "RF18539007547034",
+	}, newTestProposal().Matches[0].Filters())
+
+	filters := newTestProposal().Matches[1].Filters()
+	assert.Equal(t, "80.00", filters["sum"])
+	assert.Equal(t, "80.00", filters["bankSum"])
+}
+
+func TestApply(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		bulkRequests := parsedRequest["requests"].([]map[string]interface{})
+		assert.Len(t, bulkRequests, 2)
+		for _, request := range bulkRequests {
+			assert.Equal(t, "savePayment", request["requestName"])
+			assert.Equal(t, "TRANSFER", request["type"])
+		}
+		assert.Equal(t, "2", fmt.Sprint(bulkRequests[0]["documentID"]))
+		assert.Equal(t, "80.00", fmt.Sprint(bulkRequests[1]["sum"]))
+
+		okStatus := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok"}}
+		errorStatus := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "error", ErrorCode: 1011}}
+		resp := sales.SavePaymentsResponseBulk{
+			Status: sharedCommon.Status{ResponseStatus: "ok"},
+			BulkItems: []sales.SavePaymentsBulkItem{
+				{Status: okStatus, Records: []sales.SavePaymentID{{PaymentID: 501}}},
+				{Status: errorStatus},
+			},
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	proposal := newTestProposal()
+	proposal.Matches[1].Confirmed = true
+
+	results, err := proposal.Apply(context.Background(), sales.NewClient(baseClient), true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 0, requests)
+
+	results, err = proposal.Apply(context.Background(), sales.NewClient(baseClient), false)
+	assert.EqualError(t, err, "failed to create 1 of 2 payments")
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, 501, results[0].PaymentID)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "I-7", results[1].DocumentNumber)
+		assert.Error(t, results[1].Err)
+	}
+	assert.Equal(t, 501, proposal.Matches[0].PaymentID)
+	assert.Equal(t, 0, proposal.Matches[1].PaymentID)
+
+	results, err = proposal.Apply(context.Background(), sales.NewClient(baseClient), true)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "I-7", results[0].DocumentNumber)
+	}
+}
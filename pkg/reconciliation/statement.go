@@ -0,0 +1,77 @@
+package reconciliation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//ErrUnknownFormat is returned when the statement is neither CAMT.053 nor MT940
+var ErrUnknownFormat = errors.New("unknown bank statement format")
+
+//Transaction is a booked entry of the bank statement, the incoming payments have positive amounts
+type Transaction struct {
+	//ID is the bank reference of the entry, it's used as the bank document number of the payment
+	ID        string               `json:"id"`
+	Date      string               `json:"date"`
+	ValueDate string               `json:"valueDate,omitempty"`
+	Amount    sharedCommon.Decimal `json:"amount"`
+	Currency  string               `json:"currency"`
+	//Reference is the structured creditor reference or the end to end ID given by the payer
+	Reference string `json:"reference,omitempty"`
+	//Description is the unstructured remittance information
+	Description  string `json:"description,omitempty"`
+	PayerName    string `json:"payerName,omitempty"`
+	PayerAccount string `json:"payerAccount,omitempty"`
+}
+
+//Statement is an account statement of the bank
+type Statement struct {
+	ID             string               `json:"id"`
+	Account        string               `json:"account"`
+	Currency       string               `json:"currency"`
+	OpeningBalance sharedCommon.Decimal `json:"openingBalance"`
+	ClosingBalance sharedCommon.Decimal `json:"closingBalance"`
+	Transactions   []Transaction        `json:"transactions"`
+}
+
+//ParseStatements detects the format of the bank statement file and parses it
+func ParseStatements(r io.Reader) ([]Statement, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return ParseCAMT053(bytes.NewReader(trimmed))
+	case bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte(":")):
+		return ParseMT940(bytes.NewReader(trimmed))
+	}
+
+	return nil, ErrUnknownFormat
+}
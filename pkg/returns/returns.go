@@ -0,0 +1,350 @@
+package returns
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//ErrNotReturnable is returned when the return request doesn't fit into the returnable quantities of the invoice
+var ErrNotReturnable = errors.New("return is not allowed")
+
+//Line is a row of the original invoice with the quantities sold, already returned and still returnable
+type Line struct {
+	StableRowID string
+	ProductID   string
+	ItemName    string
+	Price       string
+	Discount    string
+	Sold        float64
+	Returned    float64
+	Returnable  float64
+}
+
+//Request is a return of some rows of the invoice
+type Request struct {
+	//Lines are the returned quantities keyed by the stable row ID of the invoice row
+	Lines map[string]float64
+	//Date is the date of the credit invoice as YYYY-MM-DD, the API uses today when it's empty
+	Date string
+	//InvoiceState is the state of the credit invoice, the API default is used when it's empty
+	InvoiceState string
+	//WarehouseID is the warehouse where the returned goods are restocked,
+	//the warehouse of the invoice is used when it's 0
+	WarehouseID int
+	//WriteOff writes the returned goods off the warehouse after crediting, e.g. when they are damaged
+	WriteOff bool
+	//WriteOffReasonID is the reason code of the write-off
+	WriteOffReasonID int
+	//Notes are added to the credit invoice
+	Notes string
+}
+
+//Result tells which documents were created for the return
+type Result struct {
+	CreditInvoiceID     int
+	CreditInvoiceNo     string
+	InventoryWriteOffID int
+}
+
+//Tracker tracks the returns of an invoice
+type Tracker struct {
+	Invoice sales.SaleDocument
+	//Credits are the credit invoices of the invoice
+	Credits []sales.SaleDocument
+}
+
+//NewTracker creates a tracker of the invoice, the credit invoices are picked from its follow-up documents
+func NewTracker(invoice sales.SaleDocument, followUps []sales.SaleDocument) *Tracker {
+	credits := []sales.SaleDocument{}
+	for _, doc := range followUps {
+		if strings.EqualFold(doc.Type, sales.SaleDocumentTypeCreditInvoice) {
+			credits = append(credits, doc)
+		}
+	}
+
+	return &Tracker{
+		Invoice: invoice,
+		Credits: credits,
+	}
+}
+
+//LoadTracker reads the invoice and all credit invoices linked to it
+func LoadTracker(ctx context.Context, docs sales.DocumentManager, invoiceID int) (*Tracker, error) {
+	invoices, err := docs.GetSalesDocuments(ctx, map[string]string{
+		"id":                    strconv.Itoa(invoiceID),
+		"getRowsForAllInvoices": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return nil, fmt.Errorf("invoice %d is not found", invoiceID)
+	}
+
+	invoice := invoices[0]
+	if len(invoice.FollowUpDocuments) == 0 {
+		return NewTracker(invoice, nil), nil
+	}
+
+	followUps, err := sales.LoadFollowUps(ctx, docs, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTracker(invoice, followUps), nil
+}
+
+//Returnable returns the quantities which can still be returned keyed by the stable row ID
+func (t *Tracker) Returnable() (map[string]float64, error) {
+	return sales.RemainingQuantities(t.Invoice, t.Credits, sales.SaleDocumentTypeCreditInvoice)
+}
+
+//Lines lists the invoice rows with their sold, returned and returnable quantities
+func (t *Tracker) Lines() ([]Line, error) {
+	returnable, err := t.Returnable()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]Line, 0, len(t.Invoice.InvoiceRows))
+	index := map[string]int{}
+	for _, row := range t.Invoice.InvoiceRows {
+		sold, err := sales.ParseRowAmount(row.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q of row %s", row.Amount, row.StableRowID)
+		}
+
+		key := sales.RowKey(row)
+		if i, ok := index[key]; ok {
+			lines[i].Sold += math.Abs(sold)
+			continue
+		}
+		index[key] = len(lines)
+		lines = append(lines, Line{
+			StableRowID: key,
+			ProductID:   row.ProductID,
+			ItemName:    row.ItemName,
+			Price:       row.Price,
+			Discount:    row.Discount,
+			Sold:        math.Abs(sold),
+		})
+	}
+	for i := range lines {
+		lines[i].Returnable = returnable[lines[i].StableRowID]
+		lines[i].Returned = lines[i].Sold - lines[i].Returnable
+	}
+
+	return lines, nil
+}
+
+//Validate checks the return request against the returnable quantities and reports all problems at once
+func (t *Tracker) Validate(req Request) error {
+	if !isInvoice(t.Invoice.Type) {
+		return fmt.Errorf("%w: %s %s is not an invoice", ErrNotReturnable, t.Invoice.Type, t.Invoice.Number)
+	}
+	if len(req.Lines) == 0 {
+		return fmt.Errorf("%w: no rows to return", ErrNotReturnable)
+	}
+	if req.WriteOff && req.WriteOffReasonID == 0 {
+		return fmt.Errorf("%w: the write-off needs a reason code", ErrNotReturnable)
+	}
+
+	returnable, err := t.Returnable()
+	if err != nil {
+		return err
+	}
+
+	rowIDs := make([]string, 0, len(req.Lines))
+	for rowID := range req.Lines {
+		rowIDs = append(rowIDs, rowID)
+	}
+	sort.Strings(rowIDs)
+
+	problems := []string{}
+	for _, rowID := range rowIDs {
+		quantity := req.Lines[rowID]
+		left, ok := returnable[rowID]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("row %s is not in %s %s", rowID, t.Invoice.Type, t.Invoice.Number))
+		case quantity <= 0:
+			problems = append(problems, fmt.Sprintf("quantity of row %s must be positive", rowID))
+		case quantity > left+1e-9:
+			problems = append(problems, fmt.Sprintf("row %s has only %s returnable, %s requested",
+				rowID, formatFloat(left), formatFloat(quantity)))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrNotReturnable, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+//CreditInvoiceFilters builds the saveSalesDocument parameters of the credit invoice, the rows have negative
+//amounts and the prices and discounts of the original rows
+func (t *Tracker) CreditInvoiceFilters(req Request) (map[string]string, error) {
+	if err := t.Validate(req); err != nil {
+		return nil, err
+	}
+
+	filters, err := sales.FollowUpFilters(t.Invoice, sales.SaleDocumentTypeCreditInvoice, sales.ConversionOptions{
+		Rows:         req.Lines,
+		Date:         req.Date,
+		InvoiceState: req.InvoiceState,
+		FollowUps:    t.Credits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.WarehouseID != 0 {
+		filters["warehouseID"] = strconv.Itoa(req.WarehouseID)
+	}
+	if req.Notes != "" {
+		filters["notes"] = req.Notes
+	}
+
+	return filters, nil
+}
+
+//Return creates the credit invoice of the request and writes the returned goods off when requested,
+//the inventory manager is needed only for the write-off. The created credit invoice is added to the tracker
+func (t *Tracker) Return(ctx context.Context, docs sales.DocumentManager, inventory warehouse.InventoryManager, req Request) (*Result, error) {
+	filters, err := t.CreditInvoiceFilters(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.WriteOff && inventory == nil {
+		return nil, fmt.Errorf("%w: the write-off needs the inventory manager", ErrNotReturnable)
+	}
+
+	reports, err := docs.SaveSalesDocument(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, sharedCommon.NewFromError("SaveSalesDocument: no records in response", nil, 0)
+	}
+
+	creditInvoiceID, err := reports[0].InvoiceID.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid credit invoice ID %q: %v", reports[0].InvoiceID, err)
+	}
+	res := &Result{
+		CreditInvoiceID: int(creditInvoiceID),
+		CreditInvoiceNo: reports[0].InvoiceNo,
+	}
+	credit := creditInvoice(res, filters)
+	t.Credits = append(t.Credits, credit)
+
+	if !req.WriteOff {
+		return res, nil
+	}
+
+	writeOffFilters := writeOffFilters(credit, req.WriteOffReasonID)
+	if writeOffFilters == nil {
+		return res, nil
+	}
+	res.InventoryWriteOffID, err = inventory.SaveInventoryWriteOff(ctx, writeOffFilters)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+//creditInvoice rebuilds the saved credit invoice from its parameters
+func creditInvoice(res *Result, filters map[string]string) sales.SaleDocument {
+	warehouseID, _ := strconv.Atoi(filters["warehouseID"])
+	credit := sales.SaleDocument{
+		ID:           res.CreditInvoiceID,
+		Type:         sales.SaleDocumentTypeCreditInvoice,
+		Number:       res.CreditInvoiceNo,
+		WarehouseID:  warehouseID,
+		InvoiceState: filters["invoiceState"],
+	}
+	for n := 1; ; n++ {
+		suffix := strconv.Itoa(n)
+		amount, ok := filters["amount"+suffix]
+		if !ok {
+			break
+		}
+		credit.InvoiceRows = append(credit.InvoiceRows, sales.InvoiceRow{
+			ProductID: filters["productID"+suffix],
+			ItemName:  filters["itemName"+suffix],
+			Price:     filters["price"+suffix],
+			Discount:  filters["discount"+suffix],
+			Amount:    amount,
+		})
+	}
+
+	return credit
+}
+
+//writeOffFilters builds the saveInventoryWriteOff parameters of the credited products,
+//nil is returned when the credit invoice has no products e.g. only services without product cards
+func writeOffFilters(credit sales.SaleDocument, reasonID int) map[string]string {
+	filters := map[string]string{
+		"warehouseID": strconv.Itoa(credit.WarehouseID),
+		"reasonID":    strconv.Itoa(reasonID),
+		"comments":    fmt.Sprintf("Returned with credit invoice %s", credit.Number),
+	}
+
+	n := 0
+	for _, row := range credit.InvoiceRows {
+		if row.ProductID == "" {
+			continue
+		}
+		amount, err := sales.ParseRowAmount(row.Amount)
+		if err != nil || amount == 0 {
+			continue
+		}
+		n++
+		suffix := strconv.Itoa(n)
+		filters["productID"+suffix] = row.ProductID
+		filters["amount"+suffix] = formatFloat(math.Abs(amount))
+	}
+	if n == 0 {
+		return nil
+	}
+
+	return filters
+}
+
+func isInvoice(docType string) bool {
+	return sales.CanConvert(docType, sales.SaleDocumentTypeCreditInvoice) == nil
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,207 @@
+package returns
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestInvoice() sales.SaleDocument {
+	return sales.SaleDocument{
+		ID:          20,
+		Type:        sales.SaleDocumentTypeInvoice,
+		Number:      "I-20",
+		CustomerID:  5,
+		WarehouseID: 1,
+		Confirmed:   "1",
+		InvoiceRows: []sales.InvoiceRow{
+			{StableRowID: "101", ProductID: "7", VatrateID: "1", Amount: "10", Price: "2.5", Discount: "10"},
+			{StableRowID: "102", ProductID: "8", VatrateID: "1", Amount: "3", Price: "10"},
+			{StableRowID: "103", ItemName: "Delivery", VatrateID: "1", Amount: "1", Price: "5"},
+		},
+	}
+}
+
+func TestTrackerLines(t *testing.T) {
+	tracker := NewTracker(newTestInvoice(), []sales.SaleDocument{
+		{Type: sales.SaleDocumentTypeCreditInvoice, InvoiceRows: []sales.InvoiceRow{{ProductID: "7", Amount: "-4", Price: "2.5", Discount: "10"}}},
+		{Type: sales.SaleDocumentTypeCreditInvoice, InvoiceRows: []sales.InvoiceRow{{ItemName: "Delivery", Amount: "-1", Price: "5"}}},
+		{Type: sales.SaleDocumentTypeInvoice, InvoiceRows: []sales.InvoiceRow{{ProductID: "8", Amount: "3"}}},
+	})
+	assert.Len(t, tracker.Credits, 2)
+
+	lines, err := tracker.Lines()
+	assert.NoError(t, err)
+	assert.Equal(t, []Line{
+		{StableRowID: "101", ProductID: "7", Price: "2.5", Discount: "10", Sold: 10, Returned: 4, Returnable: 6},
+		{StableRowID: "102", ProductID: "8", Price: "10", Sold: 3, Returned: 0, Returnable: 3},
+		{StableRowID: "103", ItemName: "Delivery", Price: "5", Sold: 1, Returned: 1, Returnable: 0},
+	}, lines)
+}
+
+func TestTrackerLinesWithEmptyAmount(t *testing.T) {
+	invoice := newTestInvoice()
+	invoice.InvoiceRows = append(invoice.InvoiceRows, sales.InvoiceRow{StableRowID: "104", ItemName: "Note"})
+
+	lines, err := NewTracker(invoice, nil).Lines()
+	assert.NoError(t, err)
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, Line{StableRowID: "104", ItemName: "Note"}, lines[3])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tracker := NewTracker(newTestInvoice(), []sales.SaleDocument{
+		{Type: sales.SaleDocumentTypeCreditInvoice, InvoiceRows: []sales.InvoiceRow{{ProductID: "7", Amount: "-4", Price: "2.5", Discount: "10"}}},
+	})
+
+	assert.NoError(t, tracker.Validate(Request{Lines: map[string]float64{"101": 6, "102": 1}}))
+
+	err := tracker.Validate(Request{Lines: map[string]float64{"101": 7, "102": 0, "999": 1}})
+	assert.True(t, errors.Is(err, ErrNotReturnable))
+	assert.EqualError(t, err, "return is not allowed: row 101 has only 6 returnable, 7 requested; "+
+		"quantity of row 102 must be positive; row 999 is not in INVOICE I-20")
+
+	err = tracker.Validate(Request{Lines: map[string]float64{"101": 1}, WriteOff: true})
+	assert.EqualError(t, err, "return is not allowed: the write-off needs a reason code")
+
+	order := newTestInvoice()
+	order.Type = sales.SaleDocumentTypeOrder
+	err = NewTracker(order, nil).Validate(Request{Lines: map[string]float64{"101": 1}})
+	assert.EqualError(t, err, "return is not allowed: ORDER I-20 is not an invoice")
+}
+
+func TestCreditInvoiceFiltersRestockWarehouse(t *testing.T) {
+	tracker := NewTracker(newTestInvoice(), nil)
+
+	filters, err := tracker.CreditInvoiceFilters(Request{
+		Lines:       map[string]float64{"101": 2.5},
+		Date:        "2022-06-10",
+		WarehouseID: 3,
+		Notes:       "Wrong size",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"type":              "CREDITINVOICE",
+		"baseDocumentIDs":   "20",
+		"creditToInvoiceID": "20",
+		"customerID":        "5",
+		"warehouseID":       "3",
+		"date":              "2022-06-10",
+		"notes":             "Wrong size",
+		"productID1":        "7",
+		"vatrateID1":        "1",
+		"amount1":           "-2.5",
+		"price1":            "2.5",
+		"discount1":         "10",
+	}, filters)
+}
+
+func TestReturnWithWriteOff(t *testing.T) {
+	var creditFilters, writeOffFilters url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		var resp interface{}
+		switch r.Form.Get("request") {
+		case "getSalesDocuments":
+			if r.Form.Get("id") != "" {
+				assert.Equal(t, "20", r.Form.Get("id"))
+				invoice := newTestInvoice()
+				invoice.FollowUpDocuments = []sales.BaseDocument{{ID: 30}}
+				resp = sales.GetSalesDocumentResponse{
+					Status:         sharedCommon.Status{ResponseStatus: "ok"},
+					SalesDocuments: []sales.SaleDocument{invoice},
+				}
+				break
+			}
+			assert.Equal(t, "30", r.Form.Get("ids"))
+			resp = sales.GetSalesDocumentResponse{
+				Status: sharedCommon.Status{ResponseStatus: "ok"},
+				SalesDocuments: []sales.SaleDocument{
+					{ID: 30, Type: sales.SaleDocumentTypeCreditInvoice, InvoiceRows: []sales.InvoiceRow{{ProductID: "8", Amount: "-1", Price: "10"}}},
+				},
+			}
+		case "saveSalesDocument":
+			creditFilters = r.Form
+			resp = sales.PostSalesDocumentResponse{
+				Status:        sharedCommon.Status{ResponseStatus: "ok"},
+				ImportReports: sales.SaleDocImportReports{{InvoiceID: "40", InvoiceNo: "C-40"}},
+			}
+		case "saveInventoryWriteOff":
+			writeOffFilters = r.Form
+			resp = warehouse.SaveInventoryWriteOffResponse{
+				Status:  sharedCommon.Status{ResponseStatus: "ok"},
+				Results: []warehouse.SaveInventoryWriteOffResult{{InventoryWriteOffID: 50}},
+			}
+		default:
+			t.Errorf("unexpected request %s", r.Form.Get("request"))
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	docs := sales.NewClient(baseClient)
+
+	tracker, err := LoadTracker(context.Background(), docs, 20)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Len(t, tracker.Credits, 1)
+
+	res, err := tracker.Return(context.Background(), docs, warehouse.NewClient(baseClient), Request{
+		Lines:            map[string]float64{"102": 2, "103": 1},
+		WriteOff:         true,
+		WriteOffReasonID: 4,
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, &Result{CreditInvoiceID: 40, CreditInvoiceNo: "C-40", InventoryWriteOffID: 50}, res)
+	assert.Equal(t, "-2", creditFilters.Get("amount1"))
+	assert.Equal(t, "-1", creditFilters.Get("amount2"))
+	assert.Equal(t, "1", writeOffFilters.Get("warehouseID"))
+	assert.Equal(t, "4", writeOffFilters.Get("reasonID"))
+	assert.Equal(t, "8", writeOffFilters.Get("productID1"))
+	assert.Equal(t, "2", writeOffFilters.Get("amount1"))
+	assert.Equal(t, "", writeOffFilters.Get("productID2"))
+
+	returnable, err := tracker.Returnable()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"101": 10, "102": 0, "103": 0}, returnable)
+}
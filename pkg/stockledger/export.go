@@ -0,0 +1,96 @@
+package stockledger
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+//WriteCSV writes a row per ledger entry
+func WriteCSV(w io.Writer, ledger *Ledger) error {
+	out := csv.NewWriter(w)
+	header := []string{"warehouse_id", "product_id", "date", "kind", "document_type", "document_id", "number", "row",
+		"counterpart_warehouse_id", "reason", "quantity", "balance"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, entry := range ledger.Entries {
+		record := []string{
+			strconv.Itoa(entry.WarehouseID),
+			strconv.Itoa(entry.ProductID),
+			entry.Date,
+			string(entry.Kind),
+			entry.DocumentType,
+			formatID(entry.DocumentID),
+			entry.Number,
+			formatID(entry.Row),
+			formatID(entry.CounterpartWarehouseID),
+			entry.Reason,
+			entry.Quantity.String(),
+			entry.Balance.String(),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteDiscrepanciesCSV writes a row per product which stock differs from the ledger balance
+func WriteDiscrepanciesCSV(w io.Writer, discrepancies []Discrepancy) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"warehouse_id", "product_id", "ledger", "stock", "difference"}); err != nil {
+		return err
+	}
+	for _, d := range discrepancies {
+		record := []string{
+			strconv.Itoa(d.WarehouseID),
+			strconv.Itoa(d.ProductID),
+			d.Ledger.String(),
+			d.Stock.String(),
+			d.Difference.String(),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteJSON writes the value as indented JSON, e.g. the ledger entries or the discrepancies
+func WriteJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func formatID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
@@ -0,0 +1,246 @@
+package stockledger
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/valuation"
+)
+
+//Opening is the entry with the balance of the movements before the start of the ledger
+const Opening valuation.Kind = "OPENING"
+
+//Entry is a movement of a product in a warehouse with the balance after it, the issued quantities are negative
+type Entry struct {
+	Date         string         `json:"date"`
+	Kind         valuation.Kind `json:"kind"`
+	DocumentType string         `json:"documentType,omitempty"`
+	DocumentID   int            `json:"documentID,omitempty"`
+	Number       string         `json:"number,omitempty"`
+	Row          int            `json:"row,omitempty"`
+	WarehouseID  int            `json:"warehouseID"`
+	ProductID    int            `json:"productID"`
+	//CounterpartWarehouseID is the other warehouse of a transfer
+	CounterpartWarehouseID int                  `json:"counterpartWarehouseID,omitempty"`
+	ReasonID               int                  `json:"reasonID,omitempty"`
+	Reason                 string               `json:"reason,omitempty"`
+	Quantity               sharedCommon.Decimal `json:"quantity"`
+	Balance                sharedCommon.Decimal `json:"balance"`
+}
+
+//Filter limits the ledger to the period and to the products and the warehouses, the zero values don't limit it
+type Filter struct {
+	//From starts the ledger, the earlier movements are summed into the opening entries
+	From         time.Time
+	To           time.Time
+	ProductIDs   []int
+	WarehouseIDs []int
+}
+
+func (f Filter) includes(warehouseID, productID int) bool {
+	return contains(f.WarehouseIDs, warehouseID) && contains(f.ProductIDs, productID)
+}
+
+func contains(ids []int, id int) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, value := range ids {
+		if value == id {
+			return true
+		}
+	}
+	return false
+}
+
+type key struct {
+	warehouseID int
+	productID   int
+}
+
+//Ledger is the chronological list of the movements of the products by warehouse with the running balance
+type Ledger struct {
+	Filter Filter
+	//Entries are ordered by the warehouse, the product and the replay order of the movements
+	Entries  []Entry
+	balances map[key]sharedCommon.Decimal
+}
+
+//Build creates the ledger of the movements, the reason codes name the reasons of the write-offs
+func Build(movements []valuation.Movement, reasons []warehouse.ReasonCode, filter Filter) *Ledger {
+	names := map[int]string{}
+	for _, reason := range reasons {
+		names[reason.ReasonID] = reason.Name
+	}
+	sorted := make([]valuation.Movement, len(movements))
+	copy(sorted, movements)
+	valuation.Sort(sorted)
+
+	ledger := &Ledger{Filter: filter, balances: map[key]sharedCommon.Decimal{}}
+	entries := map[key][]Entry{}
+	add := func(m valuation.Movement, warehouseID, counterpartID int, quantity sharedCommon.Decimal) {
+		if !filter.includes(warehouseID, m.ProductID) {
+			return
+		}
+		k := key{warehouseID: warehouseID, productID: m.ProductID}
+		balance := ledger.balances[k].Add(quantity)
+		ledger.balances[k] = balance
+
+		if m.Date.Before(filter.From) {
+			if len(entries[k]) == 0 {
+				entries[k] = []Entry{{Date: filter.From.Format(sharedCommon.DateLayout), Kind: Opening, WarehouseID: warehouseID, ProductID: m.ProductID}}
+			}
+			entries[k][0].Quantity = balance
+			entries[k][0].Balance = balance
+			return
+		}
+		entries[k] = append(entries[k], Entry{
+			Date:                   m.Date.Format(sharedCommon.DateLayout),
+			Kind:                   m.Kind,
+			DocumentType:           m.DocumentType,
+			DocumentID:             m.DocumentID,
+			Number:                 m.Number,
+			Row:                    m.Row,
+			WarehouseID:            warehouseID,
+			ProductID:              m.ProductID,
+			CounterpartWarehouseID: counterpartID,
+			ReasonID:               m.ReasonID,
+			Reason:                 names[m.ReasonID],
+			Quantity:               quantity,
+			Balance:                balance,
+		})
+	}
+
+	for _, m := range sorted {
+		if !filter.To.IsZero() && m.Date.After(filter.To) {
+			break
+		}
+		switch {
+		case m.Kind == valuation.Transfer:
+			add(m, m.WarehouseID, m.ToWarehouseID, m.Quantity.Neg())
+			add(m, m.ToWarehouseID, m.WarehouseID, m.Quantity)
+		case m.Kind.Inbound():
+			add(m, m.WarehouseID, 0, m.Quantity)
+		default:
+			add(m, m.WarehouseID, 0, m.Quantity.Neg())
+		}
+	}
+
+	for _, k := range sortedKeys(entries) {
+		ledger.Entries = append(ledger.Entries, entries[k]...)
+	}
+	if ledger.Entries == nil {
+		ledger.Entries = []Entry{}
+	}
+	return ledger
+}
+
+//Balance returns the balance of the product in the warehouse at the end of the ledger
+func (l *Ledger) Balance(warehouseID, productID int) sharedCommon.Decimal {
+	return l.balances[key{warehouseID: warehouseID, productID: productID}]
+}
+
+//Warehouses returns the warehouses which have movements in the ledger
+func (l *Ledger) Warehouses() []int {
+	seen := map[int]bool{}
+	res := []int{}
+	for k := range l.balances {
+		if !seen[k.warehouseID] {
+			seen[k.warehouseID] = true
+			res = append(res, k.warehouseID)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
+//Discrepancy is the difference between the stock of the ERP and the balance of the ledger,
+//a positive difference means the ERP has more units than the documents explain
+type Discrepancy struct {
+	WarehouseID int                  `json:"warehouseID"`
+	ProductID   int                  `json:"productID"`
+	Ledger      sharedCommon.Decimal `json:"ledger"`
+	Stock       sharedCommon.Decimal `json:"stock"`
+	Difference  sharedCommon.Decimal `json:"difference"`
+}
+
+//Compare compares the balances of the warehouse with the current stock of the ERP, the ledger should run
+//until today for the comparison to be meaningful
+func (l *Ledger) Compare(warehouseID int, stock []product.GetProductStock) ([]Discrepancy, error) {
+	res := []Discrepancy{}
+	compared := map[int]bool{}
+	for _, record := range stock {
+		if !l.Filter.includes(warehouseID, record.ProductID) {
+			continue
+		}
+		amount, err := record.AmountInStockDecimal()
+		if err != nil {
+			return nil, fmt.Errorf("stock of product %d in warehouse %d: %v", record.ProductID, warehouseID, err)
+		}
+		compared[record.ProductID] = true
+		balance := l.Balance(warehouseID, record.ProductID)
+		if !balance.Equal(amount) {
+			res = append(res, Discrepancy{
+				WarehouseID: warehouseID,
+				ProductID:   record.ProductID,
+				Ledger:      balance,
+				Stock:       amount,
+				Difference:  amount.Sub(balance),
+			})
+		}
+	}
+
+	for k, balance := range l.balances {
+		if k.warehouseID != warehouseID || compared[k.productID] || balance.IsZero() {
+			continue
+		}
+		res = append(res, Discrepancy{
+			WarehouseID: warehouseID,
+			ProductID:   k.productID,
+			Ledger:      balance,
+			Difference:  balance.Neg(),
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ProductID < res[j].ProductID
+	})
+	return res, nil
+}
+
+func sortedKeys(entries map[key][]Entry) []key {
+	keys := make([]key, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].warehouseID != keys[j].warehouseID {
+			return keys[i].warehouseID < keys[j].warehouseID
+		}
+		return keys[i].productID < keys[j].productID
+	})
+	return keys
+}
@@ -0,0 +1,160 @@
+package stockledger
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/valuation"
+	"github.com/stretchr/testify/assert"
+)
+
+var testReasons = []warehouse.ReasonCode{{ReasonID: 3, Name: "Damaged", Purpose: "WRITEOFF"}}
+
+func testMovements(t *testing.T) []valuation.Movement {
+	collector := valuation.NewCollector()
+	assert.NoError(t, collector.AddPurchases(
+		purchase.PurchaseDocument{ID: 1, Type: purchase.PurchaseInvoiceWaybill, Confirmed: 1, WarehouseID: 1, Number: "P1", Date: "2022-06-01",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "10", Price: "5"}, {ProductID: 6, Amount: "4", Price: "1"}}},
+	))
+	assert.NoError(t, collector.AddSales(
+		sales.SaleDocument{ID: 20, Type: "INVWAYBILL", Confirmed: "1", WarehouseID: 1, Number: "S20", Date: "2022-06-10",
+			InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "3"}}},
+		sales.SaleDocument{ID: 21, Type: "CREDITINVOICE", Confirmed: "1", WarehouseID: 1, Number: "C21", Date: "2022-06-15",
+			InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "-1"}}},
+	))
+	assert.NoError(t, collector.AddTransfers(
+		warehouse.InventoryTransfer{InventoryTransferID: 40, InventoryTransferNo: 4, Confirmed: 1, WarehouseFromID: 1, WarehouseToID: 2,
+			Date: "2022-06-12", Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "2"}}},
+	))
+	assert.NoError(t, collector.AddWriteOffs(
+		warehouse.InventoryWriteOff{InventoryWriteOffID: 30, InventoryWriteOffNo: 3, Confirmed: 1, WarehouseID: 2, ReasonID: 3, Date: "2022-06-20",
+			Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "1"}}},
+	))
+	assert.NoError(t, collector.AddRegistrations(
+		warehouse.InventoryRegistration{InventoryRegistrationID: 50, Confirmed: 1, WarehouseID: 2, Date: "2022-06-25",
+			Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "4", Price: "4"}}},
+	))
+	return collector.Movements()
+}
+
+func TestBuild(t *testing.T) {
+	testCases := []struct {
+		name   string
+		filter Filter
+		csv    string
+	}{
+		{
+			name:   "all",
+			filter: Filter{},
+			csv: "warehouse_id,product_id,date,kind,document_type,document_id,number,row,counterpart_warehouse_id,reason,quantity,balance\n" +
+				"1,5,2022-06-01,PURCHASE,PRCINVOICE,1,P1,1,,,10,10\n" +
+				"1,5,2022-06-10,SALE,INVWAYBILL,20,S20,1,,,-3,7\n" +
+				"1,5,2022-06-12,TRANSFER,,40,4,1,2,,-2,5\n" +
+				"1,5,2022-06-15,SALE_RETURN,CREDITINVOICE,21,C21,1,,,1,6\n" +
+				"1,6,2022-06-01,PURCHASE,PRCINVOICE,1,P1,2,,,4,4\n" +
+				"2,5,2022-06-12,TRANSFER,,40,4,1,1,,2,2\n" +
+				"2,5,2022-06-20,WRITEOFF,,30,3,1,,Damaged,-1,1\n" +
+				"2,5,2022-06-25,REGISTRATION,,50,50,1,,,4,5\n",
+		},
+		{
+			name: "period, product and warehouse",
+			filter: Filter{
+				From:         time.Date(2022, 6, 11, 0, 0, 0, 0, time.UTC),
+				To:           time.Date(2022, 6, 20, 0, 0, 0, 0, time.UTC),
+				ProductIDs:   []int{5},
+				WarehouseIDs: []int{1},
+			},
+			csv: "warehouse_id,product_id,date,kind,document_type,document_id,number,row,counterpart_warehouse_id,reason,quantity,balance\n" +
+				"1,5,2022-06-11,OPENING,,,,,,,7,7\n" +
+				"1,5,2022-06-12,TRANSFER,,40,4,1,2,,-2,5\n" +
+				"1,5,2022-06-15,SALE_RETURN,CREDITINVOICE,21,C21,1,,,1,6\n",
+		},
+		{
+			name:   "warehouse",
+			filter: Filter{WarehouseIDs: []int{2}},
+			csv: "warehouse_id,product_id,date,kind,document_type,document_id,number,row,counterpart_warehouse_id,reason,quantity,balance\n" +
+				"2,5,2022-06-12,TRANSFER,,40,4,1,1,,2,2\n" +
+				"2,5,2022-06-20,WRITEOFF,,30,3,1,,Damaged,-1,1\n" +
+				"2,5,2022-06-25,REGISTRATION,,50,50,1,,,4,5\n",
+		},
+		{
+			name:   "period before the documents",
+			filter: Filter{To: time.Date(2022, 5, 31, 0, 0, 0, 0, time.UTC)},
+			csv:    "warehouse_id,product_id,date,kind,document_type,document_id,number,row,counterpart_warehouse_id,reason,quantity,balance\n",
+		},
+	}
+	for _, testCase := range testCases {
+		ledger := Build(testMovements(t), testReasons, testCase.filter)
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, WriteCSV(buf, ledger))
+		assert.Equal(t, testCase.csv, buf.String(), testCase.name)
+	}
+
+	ledger := Build(testMovements(t), testReasons, Filter{})
+	assert.Equal(t, "6", ledger.Balance(1, 5).String())
+	assert.Equal(t, []int{1, 2}, ledger.Warehouses())
+}
+
+func TestCompare(t *testing.T) {
+	ledger := Build(testMovements(t), testReasons, Filter{})
+
+	testCases := []struct {
+		name  string
+		stock []product.GetProductStock
+		csv   string
+		err   string
+	}{
+		{
+			//product 6 has no stock in the ERP and product 7 has no documents
+			name:  "missing products on both sides",
+			stock: []product.GetProductStock{{ProductID: 5, AmountInStock: "6"}, {ProductID: 7, AmountInStock: "2.5"}},
+			csv:   "warehouse_id,product_id,ledger,stock,difference\n1,6,4,0,-4\n1,7,0,2.5,2.5\n",
+		},
+		{
+			name:  "matching stock",
+			stock: []product.GetProductStock{{ProductID: 5, AmountInStock: "6"}, {ProductID: 6, AmountInStock: "4.000"}},
+			csv:   "warehouse_id,product_id,ledger,stock,difference\n",
+		},
+		{
+			name:  "invalid stock",
+			stock: []product.GetProductStock{{ProductID: 5, AmountInStock: "n/a"}},
+			err:   `stock of product 5 in warehouse 1: invalid decimal "n/a"`,
+		},
+	}
+	for _, testCase := range testCases {
+		discrepancies, err := ledger.Compare(1, testCase.stock)
+		if testCase.err != "" {
+			assert.EqualError(t, err, testCase.err, testCase.name)
+			continue
+		}
+		assert.NoError(t, err, testCase.name)
+		buf := &bytes.Buffer{}
+		assert.NoError(t, WriteDiscrepanciesCSV(buf, discrepancies))
+		assert.Equal(t, testCase.csv, buf.String(), testCase.name)
+	}
+}
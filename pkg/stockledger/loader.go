@@ -0,0 +1,83 @@
+package stockledger
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"strconv"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/valuation"
+)
+
+//Load reads the documents which moved the stock until the end of the filter, until today when it's not set,
+//and builds the ledger
+func Load(
+	ctx context.Context,
+	salesAPI sales.Manager,
+	purchases purchase.Manager,
+	warehouses warehouse.Manager,
+	settings sharedCommon.ListingSettings,
+	filter Filter,
+) (*Ledger, error) {
+	to := filter.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	collector := valuation.NewCollector()
+	if err := collector.Load(ctx, salesAPI, purchases, warehouses, settings, to); err != nil {
+		return nil, err
+	}
+	reasons, err := warehouses.GetReasonCodes(ctx, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	return Build(collector.Movements(), reasons, filter), nil
+}
+
+//LoadDiscrepancies compares the balances with the current stock of the warehouses of the filter,
+//of the warehouses of the ledger when the filter doesn't limit them
+func (l *Ledger) LoadDiscrepancies(ctx context.Context, products product.Manager) ([]Discrepancy, error) {
+	warehouseIDs := l.Filter.WarehouseIDs
+	if len(warehouseIDs) == 0 {
+		warehouseIDs = l.Warehouses()
+	}
+
+	res := []Discrepancy{}
+	for _, warehouseID := range warehouseIDs {
+		stock, err := products.GetProductStock(ctx, map[string]string{"warehouseID": strconv.Itoa(warehouseID)})
+		if err != nil {
+			return nil, err
+		}
+		discrepancies, err := l.Compare(warehouseID, stock)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, discrepancies...)
+	}
+	return res, nil
+}
@@ -0,0 +1,97 @@
+package stockledger
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	stockRequests := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		ok := sharedCommon.Status{ResponseStatus: "ok"}
+		var resp interface{}
+		switch r.Form.Get("request") {
+		case "getInventoryRegistrations":
+			resp = warehouse.GetInventoryRegistrationsResponse{Status: ok, InventoryRegistrations: []warehouse.InventoryRegistration{
+				{InventoryRegistrationID: 1, Confirmed: 1, WarehouseID: 1, Date: "2022-06-01", Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "10"}}},
+			}}
+		case "getInventoryWriteOffs":
+			resp = warehouse.GetInventoryWriteOffsResponse{Status: ok, InventoryWriteOffs: []warehouse.InventoryWriteOff{
+				{InventoryWriteOffID: 2, Confirmed: 1, WarehouseID: 1, ReasonID: 9, Date: "2022-06-02", Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "1"}}},
+			}}
+		case "getInventoryTransfers":
+			resp = warehouse.GetInventoryTransfersResponse{Status: ok, InventoryTransfers: []warehouse.InventoryTransfer{}}
+		case "getReasonCodes":
+			resp = warehouse.GetReasonCodesResponse{Status: ok, ReasonCodes: []warehouse.ReasonCode{{ReasonID: 9, Name: "Expired"}}}
+		case "getProductStock":
+			stockRequests = append(stockRequests, r.Form.Get("warehouseID"))
+			resp = product.GetProductStockResponse{Status: ok, GetProductStock: []product.GetProductStock{{ProductID: 5, AmountInStock: "8"}}}
+		default:
+			bulk := parsedRequest["requests"].([]map[string]interface{})[0]
+			if bulk["requestName"] == "getSalesDocuments" {
+				resp = sales.GetSaleDocumentResponseBulk{Status: ok, BulkItems: []sales.GetSaleDocumentBulkItem{{Status: sharedCommon.StatusBulk{Status: ok}}}}
+			} else {
+				resp = purchase.GetPurchaseDocumentResponseBulk{Status: ok, BulkItems: []purchase.GetPurchaseDocumentBulkItem{{Status: sharedCommon.StatusBulk{Status: ok}}}}
+			}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	ledger, err := Load(context.Background(), sales.NewClient(baseClient), purchase.NewClient(baseClient), warehouse.NewClient(baseClient),
+		sharedCommon.ListingSettings{}, Filter{To: time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteCSV(buf, ledger))
+	assert.Equal(t, "warehouse_id,product_id,date,kind,document_type,document_id,number,row,counterpart_warehouse_id,reason,quantity,balance\n"+
+		"1,5,2022-06-01,REGISTRATION,,1,1,1,,,10,10\n"+
+		"1,5,2022-06-02,WRITEOFF,,2,2,1,,Expired,-1,9\n", buf.String())
+
+	discrepancies, err := ledger.LoadDiscrepancies(context.Background(), product.NewClient(baseClient))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, stockRequests)
+	if assert.Len(t, discrepancies, 1) {
+		assert.Equal(t, "-1", discrepancies[0].Difference.String())
+	}
+}
@@ -0,0 +1,159 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+var ErrInvalidCount = errors.New("invalid count")
+
+//Count is a counted quantity of a product identified by its ID or, when ProductID is not set,
+//by its barcode, code or ID in Identifier
+type Count struct {
+	ProductID  int
+	Identifier string
+	Quantity   sharedCommon.Decimal
+	ReasonID   int
+}
+
+var (
+	productIDColumns  = []string{"product_id", "productid"}
+	identifierColumns = []string{"barcode", "code"}
+	quantityColumns   = []string{"counted", "quantity", "qty", "amount"}
+)
+
+//ReadCounts reads a filled in count sheet or a scanner export. A count sheet has a header row with
+//the product_id, code or barcode and the counted column, the rows with an empty count are skipped.
+//A scanner export has no header, every row is a barcode or code with an optional quantity
+//which defaults to 1
+func ReadCounts(r io.Reader) ([]Count, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+
+	records, err := in.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return []Count{}, nil
+	}
+
+	header := map[string]int{}
+	for i, name := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	productID := findColumn(header, productIDColumns)
+	identifier := findColumn(header, identifierColumns)
+	quantity := findColumn(header, quantityColumns)
+	if (productID >= 0 || identifier >= 0) && quantity >= 0 {
+		return readSheetCounts(records[1:], productID, identifier, quantity, findColumn(header, []string{"reason_id", "reasonid"}))
+	}
+
+	return readScannerCounts(records)
+}
+
+//findColumn returns the index of the first of the names in the header, -1 when none of them is there
+func findColumn(header map[string]int, names []string) int {
+	for _, name := range names {
+		if i, ok := header[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func readSheetCounts(records [][]string, productID, identifier, quantity, reason int) ([]Count, error) {
+	res := []Count{}
+	for i, record := range records {
+		line := i + 2
+		counted := field(record, quantity)
+		if counted == "" {
+			continue
+		}
+		id := field(record, productID)
+		if id == "" {
+			id = field(record, identifier)
+		}
+		count, err := parseCount(line, id, counted)
+		if err != nil {
+			return nil, err
+		}
+		if productID >= 0 && field(record, productID) != "" {
+			count.ProductID, err = strconv.Atoi(id)
+			if err != nil {
+				return nil, fmt.Errorf("%w on line %d: product %q", ErrInvalidCount, line, id)
+			}
+		}
+		if reasonID := field(record, reason); reasonID != "" {
+			count.ReasonID, err = strconv.Atoi(reasonID)
+			if err != nil {
+				return nil, fmt.Errorf("%w on line %d: reason %q", ErrInvalidCount, line, reasonID)
+			}
+		}
+		res = append(res, count)
+	}
+	return res, nil
+}
+
+func readScannerCounts(records [][]string) ([]Count, error) {
+	res := []Count{}
+	for i, record := range records {
+		if field(record, 0) == "" {
+			continue
+		}
+		quantity := field(record, 1)
+		if quantity == "" {
+			quantity = "1"
+		}
+		count, err := parseCount(i+1, field(record, 0), quantity)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, count)
+	}
+	return res, nil
+}
+
+func parseCount(line int, identifier, quantity string) (Count, error) {
+	if identifier == "" {
+		return Count{}, fmt.Errorf("%w on line %d: no product", ErrInvalidCount, line)
+	}
+	parsed, err := sharedCommon.ParseDecimal(strings.Replace(quantity, ",", ".", 1))
+	if err != nil || parsed.Sign() < 0 {
+		return Count{}, fmt.Errorf("%w on line %d: quantity %q", ErrInvalidCount, line, quantity)
+	}
+	return Count{Identifier: identifier, Quantity: parsed}, nil
+}
+
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,120 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+//WriteSheet saves the stocktake as JSON
+func WriteSheet(w io.Writer, sheet *Sheet) error {
+	return WriteJSON(w, sheet)
+}
+
+//ReadSheet reads a stocktake saved with WriteSheet
+func ReadSheet(r io.Reader) (*Sheet, error) {
+	sheet := &Sheet{}
+	if err := json.NewDecoder(r).Decode(sheet); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+//WriteCountSheetCSV writes the count sheet to fill in, a blind sheet doesn't show the expected quantities.
+//The filled in sheet is read back with ReadCounts
+func WriteCountSheetCSV(w io.Writer, sheet *Sheet, blind bool) error {
+	out := csv.NewWriter(w)
+	header := []string{"product_id", "code", "barcode", "name", "group_id"}
+	if !blind {
+		header = append(header, "expected")
+	}
+	header = append(header, "counted", "reason_id")
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, line := range sheet.Lines {
+		record := []string{
+			strconv.Itoa(line.ProductID),
+			line.Code,
+			line.Barcode,
+			line.Name,
+			formatID(line.GroupID),
+		}
+		if !blind {
+			record = append(record, line.Expected.String())
+		}
+		counted := ""
+		if line.Counted != nil {
+			counted = line.Counted.String()
+		}
+		record = append(record, counted, formatID(line.ReasonID))
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteVariancesCSV writes a row per variance
+func WriteVariancesCSV(w io.Writer, variances []Variance) error {
+	out := csv.NewWriter(w)
+	header := []string{"product_id", "code", "name", "expected", "counted", "variance", "unit_cost", "value", "reason_id"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, variance := range variances {
+		record := []string{
+			strconv.Itoa(variance.ProductID),
+			variance.Code,
+			variance.Name,
+			variance.Expected.String(),
+			variance.Counted.String(),
+			variance.Quantity.String(),
+			variance.UnitCost.String(),
+			variance.Value.StringFixed(2),
+			formatID(variance.ReasonID),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteJSON writes the value as indented JSON
+func WriteJSON(w io.Writer, value interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
+
+func formatID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
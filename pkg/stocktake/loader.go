@@ -0,0 +1,189 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+)
+
+var ErrNoStockFile = errors.New("no stock file in the response")
+
+type stockRecord struct {
+	amount sharedCommon.Decimal
+	cost   sharedCommon.Decimal
+}
+
+//Freeze generates the count sheet of the scope with the expected quantities of the warehouse stock file
+//at this moment. The sheet lists every product of the groups, or of the account when the scope has no groups,
+//the unit cost is the average cost of the stock or the product cost when the warehouse has no cost for it.
+//The stock file is downloaded with the client, with the default client when it's nil
+func Freeze(
+	ctx context.Context,
+	products product.Manager,
+	settings sharedCommon.ListingSettings,
+	client *http.Client,
+	scope Scope,
+) (*Sheet, error) {
+	frozenAt := time.Now()
+	stock, err := loadStockFile(ctx, products, client, scope.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []map[string]interface{}{{}}
+	if len(scope.GroupIDs) > 0 {
+		filters = filters[:0]
+		for _, groupID := range scope.GroupIDs {
+			filters = append(filters, map[string]interface{}{"groupID": groupID})
+		}
+	}
+
+	lines := []Line{}
+	listed := map[int]bool{}
+	lister := sharedCommon.NewLister(settings, product.NewListingDataProvider(products), nil)
+	for _, filter := range filters {
+		for item := range lister.Get(ctx, filter) {
+			if item.Err != nil {
+				return nil, item.Err
+			}
+			prod := item.Payload.(product.Product)
+			if listed[prod.ProductID] {
+				continue
+			}
+			listed[prod.ProductID] = true
+
+			record := stock[prod.ProductID]
+			cost := record.cost
+			if cost.IsZero() {
+				cost = sharedCommon.DecimalFromFloat(prod.Cost)
+			}
+			lines = append(lines, Line{
+				ProductID: prod.ProductID,
+				Code:      prod.Code,
+				Barcode:   prod.Code2,
+				Name:      prod.Name,
+				GroupID:   int(prod.GroupID),
+				Expected:  record.amount,
+				UnitCost:  cost,
+			})
+		}
+	}
+
+	if len(scope.GroupIDs) == 0 {
+		for productID, record := range stock {
+			if !listed[productID] {
+				lines = append(lines, Line{ProductID: productID, Expected: record.amount, UnitCost: record.cost})
+			}
+		}
+	}
+
+	return NewSheet(scope, frozenAt, lines), nil
+}
+
+func loadStockFile(ctx context.Context, products product.Manager, client *http.Client, warehouseID int) (map[int]stockRecord, error) {
+	files, err := products.GetProductStockFile(ctx, map[string]string{"warehouseID": strconv.Itoa(warehouseID)})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 || files[0].ReportLink == "" {
+		return nil, ErrNoStockFile
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, files[0].ReportLink, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download the stock file: status %d", resp.StatusCode)
+	}
+
+	return readStockFile(resp.Body)
+}
+
+//readStockFile reads the productID, amountInStock and averageCost columns of the stock file
+func readStockFile(r io.Reader) (map[int]stockRecord, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+
+	header, err := in.Read()
+	if err == io.EOF {
+		return map[int]stockRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	productID := findColumn(columns, []string{"productid"})
+	amount := findColumn(columns, []string{"amountinstock"})
+	cost := findColumn(columns, []string{"averagecost"})
+	if productID < 0 || amount < 0 {
+		return nil, errors.New("the stock file has no productID or amountInStock column")
+	}
+
+	res := map[int]stockRecord{}
+	for line := 2; ; line++ {
+		record, err := in.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.Atoi(field(record, productID))
+		if err != nil {
+			return nil, fmt.Errorf("stock file line %d: invalid product %q", line, field(record, productID))
+		}
+		stock := stockRecord{}
+		if stock.amount, err = parseStockNumber(field(record, amount)); err != nil {
+			return nil, fmt.Errorf("stock file line %d: %v", line, err)
+		}
+		if stock.cost, err = parseStockNumber(field(record, cost)); err != nil {
+			return nil, fmt.Errorf("stock file line %d: %v", line, err)
+		}
+		res[id] = stock
+	}
+}
+
+func parseStockNumber(value string) (sharedCommon.Decimal, error) {
+	return sharedCommon.ParseDecimal(strings.Replace(value, ",", ".", 1))
+}
@@ -0,0 +1,202 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stock.csv" {
+			fmt.Fprint(w, "productID,amountInStock,averageCost\n3,4,12\n7,10,0.5\n8,1,2\n")
+			return
+		}
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		ok := sharedCommon.Status{ResponseStatus: "ok"}
+		var resp interface{}
+		switch r.Form.Get("request") {
+		case "getProductStock":
+			assert.Equal(t, "2", r.Form.Get("warehouseID"))
+			assert.Equal(t, "CSV", r.Form.Get("responseType"))
+			resp = product.GetProductStockFileResponse{Status: ok, GetProductStockFile: []product.GetProductStockFile{{ReportLink: srv.URL + "/stock.csv"}}}
+		default:
+			bulk := parsedRequest["requests"].([]map[string]interface{})[0]
+			assert.Equal(t, "getProducts", bulk["requestName"])
+			assert.Equal(t, "5", fmt.Sprint(bulk["groupID"]))
+			status := sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "ok", RecordsTotal: 2}}
+			anchor := product.Product{ProductID: 3, Code: "A-3", GroupID: 5, Cost: 11}
+			cable := product.Product{ProductID: 9, Code: "C-9", Code2: "4740000000099", GroupID: 5, Cost: 3}
+			resp = product.GetProductsResponseBulk{Status: ok, BulkItems: []product.GetProductsResponseBulkItem{{Status: status, Products: []product.Product{anchor, cable}}}}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	sheet, err := Freeze(context.Background(), product.NewClient(baseClient), sharedCommon.ListingSettings{}, srv.Client(), Scope{WarehouseID: 2, GroupIDs: []int{5}})
+	assert.NoError(t, err)
+	if !assert.Len(t, sheet.Lines, 2) {
+		return
+	}
+	assert.Equal(t, "A-3 4 12", sheet.Lines[0].Code+" "+sheet.Lines[0].Expected.String()+" "+sheet.Lines[0].UnitCost.String())
+	assert.Equal(t, "C-9 0 3", sheet.Lines[1].Code+" "+sheet.Lines[1].Expected.String()+" "+sheet.Lines[1].UnitCost.String())
+	assert.Equal(t, "4740000000099", sheet.Lines[1].Barcode)
+	assert.False(t, sheet.FrozenAt.IsZero())
+}
+
+func TestPost(t *testing.T) {
+	saved := map[string][]map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		ok := sharedCommon.Status{ResponseStatus: "ok"}
+		statusBulk := sharedCommon.StatusBulk{Status: ok}
+		var resp interface{}
+		if r.Form.Get("request") == "getReasonCodes" {
+			resp = warehouse.GetReasonCodesResponse{Status: ok, ReasonCodes: []warehouse.ReasonCode{{ReasonID: 2}, {ReasonID: 4}}}
+		} else {
+			bulk := parsedRequest["requests"].([]map[string]interface{})
+			name := bulk[0]["requestName"].(string)
+			saved[name] = append(saved[name], bulk...)
+			if name == "saveInventoryRegistration" {
+				resp = warehouse.SaveInventoryRegistrationResponseBulk{Status: ok, BulkItems: []warehouse.SaveInventoryRegistrationBulkItem{
+					{Status: statusBulk, Results: []warehouse.SaveInventoryRegistrationResult{{InventoryRegistrationID: 31}}},
+				}}
+			} else {
+				resp = warehouse.SaveInventoryWriteOffResponseBulk{Status: ok, BulkItems: []warehouse.SaveInventoryWriteOffBulkItem{
+					{Status: statusBulk, Results: []warehouse.SaveInventoryWriteOffResult{{InventoryWriteOffID: 41}}},
+				}}
+			}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	inventory := warehouse.NewClient(baseClient)
+
+	sheet := testSheet()
+	assert.NoError(t, sheet.Apply([]Count{
+		{Identifier: "A-3", Quantity: sharedCommon.DecimalFromInt(6), ReasonID: 2},
+		{Identifier: "B-7", Quantity: sharedCommon.DecimalFromInt(7), ReasonID: 4},
+	}))
+
+	assert.NoError(t, sheet.Post(context.Background(), inventory))
+	assert.Equal(t, []Posting{
+		{Kind: Registration, ReasonID: 2, DocumentID: 31, Rows: 1},
+		{Kind: WriteOff, ReasonID: 4, DocumentID: 41, Rows: 1},
+	}, sheet.Postings)
+	if assert.Len(t, saved["saveInventoryWriteOff"], 1) {
+		writeOff := saved["saveInventoryWriteOff"][0]
+		assert.Equal(t, "1", fmt.Sprint(writeOff["warehouseID"]))
+		assert.Equal(t, "7", fmt.Sprint(writeOff["productID1"]))
+		assert.Equal(t, "3", fmt.Sprint(writeOff["amount1"]))
+	}
+
+	assert.Equal(t, ErrPosted, sheet.Post(context.Background(), inventory))
+	assert.Equal(t, ErrPosted, sheet.Apply([]Count{{Identifier: "C-9", Quantity: sharedCommon.DecimalFromInt(1)}}))
+	assert.Len(t, saved["saveInventoryRegistration"], 1)
+}
+
+func TestPostRecordsSavedDocumentsOfFailedBulk(t *testing.T) {
+	failing := true
+	saved := []map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		ok := sharedCommon.Status{ResponseStatus: "ok"}
+		var resp interface{}
+		if r.Form.Get("request") == "getReasonCodes" {
+			resp = warehouse.GetReasonCodesResponse{Status: ok, ReasonCodes: []warehouse.ReasonCode{{ReasonID: 2}, {ReasonID: 4}}}
+		} else {
+			bulk := parsedRequest["requests"].([]map[string]interface{})
+			saved = append(saved, bulk...)
+			items := []warehouse.SaveInventoryWriteOffBulkItem{}
+			for i := range bulk {
+				if failing && i == 1 {
+					items = append(items, warehouse.SaveInventoryWriteOffBulkItem{
+						Status: sharedCommon.StatusBulk{Status: sharedCommon.Status{ResponseStatus: "error", ErrorCode: 1011}},
+					})
+					continue
+				}
+				items = append(items, warehouse.SaveInventoryWriteOffBulkItem{
+					Status:  sharedCommon.StatusBulk{Status: ok},
+					Results: []warehouse.SaveInventoryWriteOffResult{{InventoryWriteOffID: 41 + len(saved) - len(bulk) + i}},
+				})
+			}
+			resp = warehouse.SaveInventoryWriteOffResponseBulk{Status: ok, BulkItems: items}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+	inventory := warehouse.NewClient(baseClient)
+
+	sheet := testSheet()
+	assert.NoError(t, sheet.Apply([]Count{
+		{Identifier: "A-3", Quantity: sharedCommon.DecimalFromInt(4)},
+		{Identifier: "B-7", Quantity: sharedCommon.DecimalFromInt(7), ReasonID: 4},
+		{Identifier: "C-9", Quantity: sharedCommon.DecimalFromInt(0), ReasonID: 2},
+	}))
+
+	assert.Error(t, sheet.Post(context.Background(), inventory))
+	assert.Equal(t, []Posting{{Kind: WriteOff, ReasonID: 2, DocumentID: 41, Rows: 1}}, sheet.Postings)
+
+	failing = false
+	assert.NoError(t, sheet.Post(context.Background(), inventory))
+	assert.Equal(t, []Posting{
+		{Kind: WriteOff, ReasonID: 2, DocumentID: 41, Rows: 1},
+		{Kind: WriteOff, ReasonID: 4, DocumentID: 43, Rows: 1},
+	}, sheet.Postings)
+	if assert.Len(t, saved, 3) {
+		assert.Equal(t, "7", fmt.Sprint(saved[2]["productID1"]))
+	}
+}
@@ -0,0 +1,189 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//Document is an inventory registration of the surpluses or a write-off of the shortages of a reason code
+type Document struct {
+	Kind      string     `json:"kind"`
+	ReasonID  int        `json:"reasonID"`
+	Variances []Variance `json:"variances"`
+}
+
+//Documents groups the variances into a registration and a write-off per reason code
+func (s *Sheet) Documents() []Document {
+	byKey := map[string]*Document{}
+	res := []*Document{}
+	for _, variance := range s.Variances() {
+		kind := Registration
+		if variance.Quantity.Sign() < 0 {
+			kind = WriteOff
+		}
+		key := fmt.Sprintf("%s-%d", kind, variance.ReasonID)
+		doc, ok := byKey[key]
+		if !ok {
+			doc = &Document{Kind: kind, ReasonID: variance.ReasonID}
+			byKey[key] = doc
+			res = append(res, doc)
+		}
+		doc.Variances = append(doc.Variances, variance)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Kind != res[j].Kind {
+			return res[i].Kind == Registration
+		}
+		return res[i].ReasonID < res[j].ReasonID
+	})
+
+	docs := make([]Document, 0, len(res))
+	for _, doc := range res {
+		docs = append(docs, *doc)
+	}
+	return docs
+}
+
+//Filters returns the save request of the document, the rows are priced at the unit cost
+func (d Document) Filters(sheet *Sheet) map[string]interface{} {
+	date := sheet.FrozenAt.Format(sharedCommon.DateLayout)
+	filters := map[string]interface{}{
+		"warehouseID": sheet.WarehouseID,
+		"reasonID":    d.ReasonID,
+		"date":        date,
+	}
+	comment := "Stocktake of " + date
+	if d.Kind == Registration {
+		filters["notes"] = comment
+	} else {
+		filters["comments"] = comment
+	}
+	for i, variance := range d.Variances {
+		n := i + 1
+		filters[fmt.Sprintf("productID%d", n)] = variance.ProductID
+		filters[fmt.Sprintf("amount%d", n)] = variance.Quantity.Abs().String()
+		filters[fmt.Sprintf("price%d", n)] = variance.UnitCost.String()
+	}
+	return filters
+}
+
+func (s *Sheet) posted(doc Document) bool {
+	for _, posting := range s.Postings {
+		if posting.Kind == doc.Kind && posting.ReasonID == doc.ReasonID {
+			return true
+		}
+	}
+	return false
+}
+
+//Post validates the reason codes and saves the documents in bulk requests, the surpluses as registrations
+//and the shortages as write-offs. The saved documents are recorded in Postings, so after a failure
+//posting again saves only the remaining ones
+func (s *Sheet) Post(ctx context.Context, inventory warehouse.InventoryManager) error {
+	reasons, err := inventory.GetReasonCodes(ctx, map[string]string{})
+	if err != nil {
+		return err
+	}
+	if err := s.Validate(reasons); err != nil {
+		return err
+	}
+
+	registrations := []Document{}
+	writeOffs := []Document{}
+	for _, doc := range s.Documents() {
+		if s.posted(doc) {
+			continue
+		}
+		if doc.Kind == Registration {
+			registrations = append(registrations, doc)
+		} else {
+			writeOffs = append(writeOffs, doc)
+		}
+	}
+	if len(registrations)+len(writeOffs) == 0 && len(s.Postings) > 0 {
+		return ErrPosted
+	}
+
+	err = s.postBulk(registrations, func(bulk []map[string]interface{}) ([]int, error) {
+		resp, err := inventory.SaveInventoryRegistrationBulk(ctx, bulk, map[string]string{})
+		ids := make([]int, 0, len(resp.BulkItems))
+		for _, item := range resp.BulkItems {
+			id := 0
+			if len(item.Results) > 0 {
+				id = item.Results[0].InventoryRegistrationID
+			}
+			ids = append(ids, id)
+		}
+		return ids, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.postBulk(writeOffs, func(bulk []map[string]interface{}) ([]int, error) {
+		resp, err := inventory.SaveInventoryWriteOffBulk(ctx, bulk, map[string]string{})
+		ids := make([]int, 0, len(resp.BulkItems))
+		for _, item := range resp.BulkItems {
+			id := 0
+			if len(item.Results) > 0 {
+				id = item.Results[0].InventoryWriteOffID
+			}
+			ids = append(ids, id)
+		}
+		return ids, err
+	})
+}
+
+//postBulk saves the documents in chunks, save returns the IDs of the saved documents in the order of the bulk
+//and 0 for the ones which failed. The documents with an ID are recorded even when the bulk request failed,
+//so they aren't saved twice when posting again
+func (s *Sheet) postBulk(docs []Document, save func(bulk []map[string]interface{}) ([]int, error)) error {
+	for start := 0; start < len(docs); start += sharedCommon.MaxBulkRequestsCount {
+		end := start + sharedCommon.MaxBulkRequestsCount
+		if end > len(docs) {
+			end = len(docs)
+		}
+		bulk := make([]map[string]interface{}, 0, end-start)
+		for _, doc := range docs[start:end] {
+			bulk = append(bulk, doc.Filters(s))
+		}
+
+		ids, err := save(bulk)
+		for i, doc := range docs[start:end] {
+			if i >= len(ids) {
+				break
+			}
+			if ids[i] == 0 {
+				continue
+			}
+			s.Postings = append(s.Postings, Posting{Kind: doc.Kind, ReasonID: doc.ReasonID, DocumentID: ids[i], Rows: len(doc.Variances)})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
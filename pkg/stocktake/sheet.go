@@ -0,0 +1,276 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+var (
+	ErrUnknownProduct = errors.New("product is not on the count sheet")
+	ErrMissingReason  = errors.New("variance has no reason code")
+	ErrUnknownReason  = errors.New("unknown reason code")
+	ErrPosted         = errors.New("stocktake is already posted")
+)
+
+//Scope is the warehouse which is counted, limited to some product groups when GroupIDs are set
+type Scope struct {
+	WarehouseID int   `json:"warehouseID"`
+	GroupIDs    []int `json:"groupIDs,omitempty"`
+}
+
+//Line is a product of the count sheet, Expected is the stock frozen when the sheet was generated
+//and Counted stays nil until the product is counted
+type Line struct {
+	ProductID int                   `json:"productID"`
+	Code      string                `json:"code"`
+	Barcode   string                `json:"barcode,omitempty"`
+	Name      string                `json:"name"`
+	GroupID   int                   `json:"groupID,omitempty"`
+	Expected  sharedCommon.Decimal  `json:"expected"`
+	UnitCost  sharedCommon.Decimal  `json:"unitCost"`
+	Counted   *sharedCommon.Decimal `json:"counted,omitempty"`
+	ReasonID  int                   `json:"reasonID,omitempty"`
+}
+
+//Posting is an inventory document saved from the variances of a reason code
+type Posting struct {
+	Kind       string `json:"kind"`
+	ReasonID   int    `json:"reasonID"`
+	DocumentID int    `json:"documentID"`
+	Rows       int    `json:"rows"`
+}
+
+const (
+	Registration = "REGISTRATION"
+	WriteOff     = "WRITEOFF"
+)
+
+//Sheet is a stocktake of a scope, it's saved between generating the count sheet, entering the counts
+//and posting the variances. With UncountedAsZero the products which weren't counted are treated as missing,
+//otherwise they are left out of the variances
+type Sheet struct {
+	Scope
+	FrozenAt        time.Time `json:"frozenAt"`
+	UncountedAsZero bool      `json:"uncountedAsZero"`
+	Lines           []Line    `json:"lines"`
+	Postings        []Posting `json:"postings,omitempty"`
+}
+
+//Variance is the difference of the counted and the expected quantity of a product, Value is the difference at unit cost
+type Variance struct {
+	ProductID int                  `json:"productID"`
+	Code      string               `json:"code"`
+	Name      string               `json:"name"`
+	Expected  sharedCommon.Decimal `json:"expected"`
+	Counted   sharedCommon.Decimal `json:"counted"`
+	Quantity  sharedCommon.Decimal `json:"quantity"`
+	UnitCost  sharedCommon.Decimal `json:"unitCost"`
+	Value     sharedCommon.Decimal `json:"value"`
+	ReasonID  int                  `json:"reasonID"`
+}
+
+//NewSheet creates the count sheet with the lines ordered by group and code
+func NewSheet(scope Scope, frozenAt time.Time, lines []Line) *Sheet {
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].GroupID != lines[j].GroupID {
+			return lines[i].GroupID < lines[j].GroupID
+		}
+		if lines[i].Code != lines[j].Code {
+			return lines[i].Code < lines[j].Code
+		}
+		return lines[i].ProductID < lines[j].ProductID
+	})
+
+	return &Sheet{Scope: scope, FrozenAt: frozenAt, Lines: lines}
+}
+
+//Find returns the line of a barcode, a product code or a product ID, in that order
+func (s *Sheet) Find(identifier string) (*Line, bool) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return nil, false
+	}
+	for i := range s.Lines {
+		if s.Lines[i].Barcode != "" && s.Lines[i].Barcode == identifier {
+			return &s.Lines[i], true
+		}
+	}
+	for i := range s.Lines {
+		if s.Lines[i].Code != "" && strings.EqualFold(s.Lines[i].Code, identifier) {
+			return &s.Lines[i], true
+		}
+	}
+	if id, err := strconv.Atoi(identifier); err == nil {
+		for i := range s.Lines {
+			if s.Lines[i].ProductID == id {
+				return &s.Lines[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (s *Sheet) find(count Count) (*Line, bool) {
+	if count.ProductID == 0 {
+		return s.Find(count.Identifier)
+	}
+	for i := range s.Lines {
+		if s.Lines[i].ProductID == count.ProductID {
+			return &s.Lines[i], true
+		}
+	}
+	return nil, false
+}
+
+//Apply adds the counts to the lines, so repeated scans of a product sum up. Nothing is applied
+//when some of the counted products aren't on the sheet
+func (s *Sheet) Apply(counts []Count) error {
+	if len(s.Postings) > 0 {
+		return ErrPosted
+	}
+
+	lines := make([]*Line, len(counts))
+	unknown := []string{}
+	for i, count := range counts {
+		line, ok := s.find(count)
+		if !ok {
+			unknown = append(unknown, count.Identifier)
+			continue
+		}
+		lines[i] = line
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownProduct, strings.Join(unknown, ", "))
+	}
+
+	for i, count := range counts {
+		line := lines[i]
+		counted := count.Quantity
+		if line.Counted != nil {
+			counted = line.Counted.Add(counted)
+		}
+		line.Counted = &counted
+		if count.ReasonID != 0 {
+			line.ReasonID = count.ReasonID
+		}
+	}
+	return nil
+}
+
+//SetDefaultReason sets the reason code of the lines which don't have one yet
+func (s *Sheet) SetDefaultReason(reasonID int) {
+	for i := range s.Lines {
+		if s.Lines[i].ReasonID == 0 {
+			s.Lines[i].ReasonID = reasonID
+		}
+	}
+}
+
+//Uncounted returns the lines which weren't counted
+func (s *Sheet) Uncounted() []Line {
+	res := []Line{}
+	for _, line := range s.Lines {
+		if line.Counted == nil {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+//Variances returns the lines where the counted quantity differs from the expected one
+func (s *Sheet) Variances() []Variance {
+	res := []Variance{}
+	for _, line := range s.Lines {
+		if line.Counted == nil && !s.UncountedAsZero {
+			continue
+		}
+		counted := sharedCommon.Decimal{}
+		if line.Counted != nil {
+			counted = *line.Counted
+		}
+		quantity := counted.Sub(line.Expected)
+		if quantity.IsZero() {
+			continue
+		}
+		res = append(res, Variance{
+			ProductID: line.ProductID,
+			Code:      line.Code,
+			Name:      line.Name,
+			Expected:  line.Expected,
+			Counted:   counted,
+			Quantity:  quantity,
+			UnitCost:  line.UnitCost,
+			Value:     quantity.Mul(line.UnitCost).Round(2),
+			ReasonID:  line.ReasonID,
+		})
+	}
+	return res
+}
+
+func (v Variance) label() string {
+	if v.Code != "" {
+		return v.Code
+	}
+	return strconv.Itoa(v.ProductID)
+}
+
+//VarianceValue returns the total value of the variances
+func VarianceValue(variances []Variance) sharedCommon.Decimal {
+	total := sharedCommon.Decimal{}
+	for _, variance := range variances {
+		total = total.Add(variance.Value)
+	}
+	return total
+}
+
+//Validate checks that every variance has a reason code which exists in the account
+func (s *Sheet) Validate(reasons []warehouse.ReasonCode) error {
+	known := make(map[int]bool, len(reasons))
+	for _, reason := range reasons {
+		known[reason.ReasonID] = true
+	}
+
+	missing := []string{}
+	unknown := []string{}
+	for _, variance := range s.Variances() {
+		switch {
+		case variance.ReasonID == 0:
+			missing = append(missing, variance.label())
+		case !known[variance.ReasonID]:
+			unknown = append(unknown, fmt.Sprintf("%s (%d)", variance.label(), variance.ReasonID))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingReason, strings.Join(missing, ", "))
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownReason, strings.Join(unknown, ", "))
+	}
+	return nil
+}
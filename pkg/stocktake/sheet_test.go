@@ -0,0 +1,154 @@
+package stocktake
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSheet() *Sheet {
+	return NewSheet(Scope{WarehouseID: 1}, time.Date(2022, 6, 30, 18, 0, 0, 0, time.UTC), []Line{
+		{ProductID: 7, Code: "B-7", Barcode: "4740000000077", Name: "Bolt", Expected: sharedCommon.DecimalFromInt(10), UnitCost: sharedCommon.MustParseDecimal("0.5")},
+		{ProductID: 3, Code: "A-3", Barcode: "4740000000033", Name: "Anchor", Expected: sharedCommon.DecimalFromInt(4), UnitCost: sharedCommon.DecimalFromInt(12)},
+		{ProductID: 9, Code: "C-9", Name: "Cable", Expected: sharedCommon.DecimalFromInt(2), UnitCost: sharedCommon.DecimalFromInt(3)},
+	})
+}
+
+func TestReadCounts(t *testing.T) {
+	scanned, err := ReadCounts(strings.NewReader("4740000000077\n4740000000077\n\nC-9,2\nA-3,1.5\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4740000000077 1", "4740000000077 1", "C-9 2", "A-3 1.5"}, countLines(scanned))
+
+	sheet, err := ReadCounts(strings.NewReader("product_id,code,name,counted,reason_id\n7,B-7,Bolt,8,4\n3,A-3,Anchor,,\n"))
+	assert.NoError(t, err)
+	if assert.Len(t, sheet, 1) {
+		assert.Equal(t, Count{ProductID: 7, Identifier: "7", Quantity: sharedCommon.DecimalFromInt(8), ReasonID: 4}, sheet[0])
+	}
+
+	_, err = ReadCounts(strings.NewReader("B-7,-1\n"))
+	assert.True(t, errors.Is(err, ErrInvalidCount))
+	assert.EqualError(t, err, `invalid count on line 1: quantity "-1"`)
+}
+
+func TestApplyAndVariances(t *testing.T) {
+	sheet := testSheet()
+	assert.Equal(t, []int{3, 7, 9}, []int{sheet.Lines[0].ProductID, sheet.Lines[1].ProductID, sheet.Lines[2].ProductID})
+
+	err := sheet.Apply([]Count{
+		{Identifier: "4740000000077", Quantity: sharedCommon.DecimalFromInt(6)},
+		{Identifier: "b-7", Quantity: sharedCommon.DecimalFromInt(1)},
+		{ProductID: 3, Identifier: "3", Quantity: sharedCommon.DecimalFromInt(5), ReasonID: 2},
+	})
+	assert.NoError(t, err)
+
+	err = sheet.Apply([]Count{{Identifier: "X-1", Quantity: sharedCommon.DecimalFromInt(1)}, {Identifier: "C-9", Quantity: sharedCommon.DecimalFromInt(1)}})
+	assert.EqualError(t, err, "product is not on the count sheet: X-1")
+	assert.Len(t, sheet.Uncounted(), 1)
+
+	variances := sheet.Variances()
+	assert.Equal(t, []string{"A-3 1 12.00 2", "B-7 -3 -1.50 0"}, varianceLines(variances))
+	assert.Equal(t, "10.50", VarianceValue(variances).String())
+
+	sheet.UncountedAsZero = true
+	assert.Equal(t, []string{"A-3 1 12.00 2", "B-7 -3 -1.50 0", "C-9 -2 -6.00 0"}, varianceLines(sheet.Variances()))
+}
+
+func TestValidateAndDocuments(t *testing.T) {
+	sheet := testSheet()
+	assert.NoError(t, sheet.Apply([]Count{
+		{Identifier: "B-7", Quantity: sharedCommon.DecimalFromInt(7)},
+		{Identifier: "A-3", Quantity: sharedCommon.DecimalFromInt(6), ReasonID: 2},
+		{Identifier: "C-9", Quantity: sharedCommon.DecimalFromInt(1), ReasonID: 5},
+	}))
+	reasons := []warehouse.ReasonCode{{ReasonID: 2, Name: "Found"}, {ReasonID: 4, Name: "Damaged"}}
+
+	err := sheet.Validate(reasons)
+	assert.True(t, errors.Is(err, ErrMissingReason))
+	assert.EqualError(t, err, "variance has no reason code: B-7")
+
+	sheet.SetDefaultReason(4)
+	assert.EqualError(t, sheet.Validate(reasons), "unknown reason code: C-9 (5)")
+
+	sheet.Lines[2].ReasonID = 4
+	assert.NoError(t, sheet.Validate(reasons))
+
+	docs := sheet.Documents()
+	if assert.Len(t, docs, 2) {
+		assert.Equal(t, Registration, docs[0].Kind)
+		assert.Len(t, docs[0].Variances, 1)
+		assert.Equal(t, WriteOff, docs[1].Kind)
+		assert.Equal(t, 4, docs[1].ReasonID)
+
+		filters := docs[1].Filters(sheet)
+		assert.Equal(t, "2022-06-30", filters["date"])
+		assert.Equal(t, 7, filters["productID1"])
+		assert.Equal(t, "3", filters["amount1"])
+		assert.Equal(t, "0.5", filters["price1"])
+		assert.Equal(t, 9, filters["productID2"])
+		assert.Equal(t, "1", filters["amount2"])
+	}
+}
+
+func TestCountSheetRoundTrip(t *testing.T) {
+	sheet := testSheet()
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteCountSheetCSV(buf, sheet, true))
+	assert.True(t, strings.HasPrefix(buf.String(), "product_id,code,barcode,name,group_id,counted,reason_id\n3,A-3,4740000000033,Anchor,,,\n"))
+
+	filled := strings.Replace(buf.String(), "3,A-3,4740000000033,Anchor,,,", "3,A-3,4740000000033,Anchor,,4,2", 1)
+	counts, err := ReadCounts(strings.NewReader(filled))
+	assert.NoError(t, err)
+	assert.NoError(t, sheet.Apply(counts))
+
+	buf.Reset()
+	assert.NoError(t, WriteSheet(buf, sheet))
+	read, err := ReadSheet(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, varianceLines(read.Variances()))
+	if assert.NotNil(t, read.Lines[0].Counted) {
+		assert.Equal(t, "4", read.Lines[0].Counted.String())
+	}
+	assert.Equal(t, 2, read.Lines[0].ReasonID)
+}
+
+func countLines(counts []Count) []string {
+	res := []string{}
+	for _, count := range counts {
+		res = append(res, count.Identifier+" "+count.Quantity.String())
+	}
+	return res
+}
+
+func varianceLines(variances []Variance) []string {
+	res := []string{}
+	for _, variance := range variances {
+		res = append(res, strings.Join([]string{variance.Code, variance.Quantity.String(), variance.Value.StringFixed(2), strconv.Itoa(variance.ReasonID)}, " "))
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+package taxreport
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//WriteCSV writes a row per VAT rate followed by the rows of its components and the totals of the return
+func WriteCSV(w io.Writer, ret *Return) error {
+	out := csv.NewWriter(w)
+	err := out.Write([]string{
+		"direction", "vat_rate_id", "vat_rate", "code", "rate", "component", "component_rate", "taxable", "tax", "documents",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, lines := range [][]Line{ret.Output, ret.Input} {
+		for _, line := range lines {
+			err := out.Write([]string{
+				string(line.Direction), strconv.Itoa(line.VatRateID), line.VatRateName, line.Code, formatRate(line.Rate),
+				"", "", formatAmount(line.Taxable), formatAmount(line.Tax), strconv.Itoa(line.Documents),
+			})
+			if err != nil {
+				return err
+			}
+			for _, component := range line.Components {
+				err := out.Write([]string{
+					string(line.Direction), strconv.Itoa(line.VatRateID), line.VatRateName, line.Code, formatRate(line.Rate),
+					component.Name, formatRate(component.Rate), formatAmount(line.Taxable), formatAmount(component.Tax), "",
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, total := range [][2]string{
+		{"OUTPUT_TAX", formatAmount(ret.OutputTax)},
+		{"INPUT_TAX", formatAmount(ret.InputTax)},
+		{"PAYABLE", formatAmount(ret.Payable)},
+	} {
+		if err := out.Write([]string{total[0], "", "", "", "", "", "", "", total[1], ""}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteJSON writes the return as indented JSON
+func WriteJSON(w io.Writer, ret *Return) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ret)
+}
+
+func formatAmount(value sharedCommon.Decimal) string {
+	return value.StringFixed(2)
+}
+
+func formatRate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,68 @@
+package taxreport
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+)
+
+//Load reads the sales and the purchase documents of the period
+func (b *Builder) Load(ctx context.Context, salesAPI sales.Manager, purchases purchase.Manager, settings sharedCommon.ListingSettings) error {
+	types := make([]string, 0, len(saleTypes))
+	for docType := range saleTypes {
+		types = append(types, docType)
+	}
+	sort.Strings(types)
+
+	period := sharedCommon.TimeFilters{DateFrom: b.TimeZone.Day(b.From), DateTo: b.TimeZone.Day(b.To)}
+	salesFilters := map[string]interface{}{"types": strings.Join(types, ",")}
+	period.ApplyToListing(b.TimeZone, salesFilters)
+
+	lister := sharedCommon.NewLister(settings, sales.NewSaleDocumentsListingDataProvider(salesAPI), nil)
+	for item := range lister.Get(ctx, salesFilters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := b.AddSales(item.Payload.(sales.SaleDocument)); err != nil {
+			return err
+		}
+	}
+
+	purchaseLister := sharedCommon.NewLister(settings, purchase.NewListingDataProvider(purchases), nil)
+	purchaseFilters := map[string]interface{}{}
+	period.ApplyToListing(b.TimeZone, purchaseFilters)
+	for item := range purchaseLister.Get(ctx, purchaseFilters) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := b.AddPurchases(item.Payload.(purchase.PurchaseDocument)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
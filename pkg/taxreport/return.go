@@ -0,0 +1,371 @@
+package taxreport
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/currency"
+)
+
+//Direction tells if the tax is collected on the sales or paid on the purchases
+type Direction string
+
+const (
+	Output Direction = "OUTPUT"
+	Input  Direction = "INPUT"
+)
+
+//saleTypes are the sales documents which carry output tax, the credit invoices reduce it
+var saleTypes = map[string]bool{
+	sales.SaleDocumentTypeInvoice:       true,
+	sales.SaleDocumentTypeInvWayBill:    true,
+	sales.SaleDocumentTypeCASHINVOICE:   true,
+	sales.SaleDocumentTypeExportInvoice: true,
+	sales.SaleDocumentTypeCreditInvoice: true,
+}
+
+//purchaseTypes are the purchase documents which carry input tax, the returns reduce it
+var purchaseTypes = map[purchase.PurchaseOrderType]bool{
+	purchase.PurchaseInvoiceWaybill: true,
+	purchase.PurchaseReceipt:        true,
+	purchase.PurchaseInvoice:        true,
+	purchase.PurchaseReturn:         true,
+}
+
+//Component is the part of the tax of a multi-component rate, the tax of the rate is split
+//between the components in proportion to their rates
+type Component struct {
+	ID   int                  `json:"id"`
+	Name string               `json:"name"`
+	Type string               `json:"type,omitempty"`
+	Rate float64              `json:"rate"`
+	Tax  sharedCommon.Decimal `json:"tax"`
+}
+
+//Line is the taxable amount and the tax of a VAT rate in the base currency
+type Line struct {
+	Direction   Direction            `json:"direction"`
+	VatRateID   int                  `json:"vatRateID"`
+	VatRateName string               `json:"vatRateName"`
+	Code        string               `json:"code,omitempty"`
+	Rate        float64              `json:"rate"`
+	Taxable     sharedCommon.Decimal `json:"taxable"`
+	Tax         sharedCommon.Decimal `json:"tax"`
+	Documents   int                  `json:"documents"`
+	Components  []Component          `json:"components,omitempty"`
+}
+
+//Return is the VAT or GST return of a period, Payable is negative when the input tax exceeds the output tax
+type Return struct {
+	CompanyName string               `json:"companyName,omitempty"`
+	CompanyVAT  string               `json:"companyVAT,omitempty"`
+	From        string               `json:"from"`
+	To          string               `json:"to"`
+	Output      []Line               `json:"output"`
+	Input       []Line               `json:"input"`
+	OutputTax   sharedCommon.Decimal `json:"outputTax"`
+	InputTax    sharedCommon.Decimal `json:"inputTax"`
+	Payable     sharedCommon.Decimal `json:"payable"`
+}
+
+//Builder sums the tax of the documents of a period by the VAT rate
+type Builder struct {
+	From time.Time
+	To   time.Time
+	//Rates converts the documents into the base currency at the rates of the document dates when set,
+	//the currency rates of the documents are used otherwise
+	Rates *currency.Table
+	//TimeZone is the account timezone of the date filters of the loaded documents, UTC when it's not set
+	TimeZone    sharedCommon.TimeZone
+	rates       map[int]sales.VatRate
+	output      map[int]*Line
+	input       map[int]*Line
+	saleIDs     map[int]bool
+	purchaseIDs map[int]bool
+}
+
+//NewBuilder creates a builder of the period between the dates inclusive
+func NewBuilder(from, to time.Time, vatRates sales.VatRates) (*Builder, error) {
+	rates := map[int]sales.VatRate{}
+	for _, rate := range vatRates {
+		id, err := strconv.Atoi(rate.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q of VAT rate %s", rate.ID, rate.Name)
+		}
+		if _, err := sharedCommon.ParseDecimal(rate.Rate); err != nil {
+			return nil, fmt.Errorf("invalid rate %q of VAT rate %d", rate.Rate, id)
+		}
+		for _, component := range rate.Components {
+			if _, err := sharedCommon.ParseDecimal(component.Rate); err != nil {
+				return nil, fmt.Errorf("invalid rate %q of component %d of VAT rate %d", component.Rate, component.ID, id)
+			}
+		}
+		rates[id] = rate
+	}
+
+	return &Builder{
+		From:        sharedCommon.UTC.Day(from),
+		To:          sharedCommon.UTC.Day(to),
+		rates:       rates,
+		output:      map[int]*Line{},
+		input:       map[int]*Line{},
+		saleIDs:     map[int]bool{},
+		purchaseIDs: map[int]bool{},
+	}, nil
+}
+
+//AddSales adds the output tax of the confirmed invoices of the period, the other documents are skipped
+func (b *Builder) AddSales(docs ...sales.SaleDocument) error {
+	for _, doc := range docs {
+		if !saleTypes[strings.ToUpper(doc.Type)] || doc.Confirmed == "0" || strings.EqualFold(doc.InvoiceState, "CANCELLED") {
+			continue
+		}
+		if b.saleIDs[doc.ID] {
+			continue
+		}
+		inPeriod, err := b.inPeriod(doc.Date)
+		if err != nil {
+			return fmt.Errorf("sales document %d: %v", doc.ID, err)
+		}
+		if !inPeriod {
+			continue
+		}
+		rate, err := b.currencyRate(doc.CurrencyCode, doc.Date, doc.CurrencyRate)
+		if err != nil {
+			return fmt.Errorf("sales document %d: %v", doc.ID, err)
+		}
+
+		credit := strings.EqualFold(doc.Type, sales.SaleDocumentTypeCreditInvoice)
+		err = b.add(b.output, Output, credit, rate, totals(doc.NetTotalsByTaxRate), totals(doc.VatTotalsByTaxRates))
+		if err != nil {
+			return fmt.Errorf("sales document %d: %v", doc.ID, err)
+		}
+		b.saleIDs[doc.ID] = true
+	}
+
+	return nil
+}
+
+//AddPurchases adds the input tax of the confirmed purchase invoices of the period, the other documents are skipped
+func (b *Builder) AddPurchases(docs ...purchase.PurchaseDocument) error {
+	for _, doc := range docs {
+		if !purchaseTypes[doc.Type] || doc.Confirmed == 0 || b.purchaseIDs[doc.ID] {
+			continue
+		}
+		inPeriod, err := b.inPeriod(doc.Date)
+		if err != nil {
+			return fmt.Errorf("purchase document %d: %v", doc.ID, err)
+		}
+		if !inPeriod {
+			continue
+		}
+		rate, err := b.currencyRate(doc.CurrencyCode, doc.Date, doc.CurrencyRate.String())
+		if err != nil {
+			return fmt.Errorf("purchase document %d: %v", doc.ID, err)
+		}
+
+		nets, vats := map[int]sharedCommon.Decimal{}, map[int]sharedCommon.Decimal{}
+		for _, total := range doc.NetTotalsByTaxRate {
+			nets[total.VatrateID] = nets[total.VatrateID].Add(sharedCommon.DecimalFromFloat(total.Total))
+		}
+		for _, total := range doc.VatTotalsByTaxRate {
+			vats[total.VatrateID] = vats[total.VatrateID].Add(sharedCommon.DecimalFromFloat(total.Total))
+		}
+		if err := b.add(b.input, Input, doc.Type == purchase.PurchaseReturn, rate, nets, vats); err != nil {
+			return fmt.Errorf("purchase document %d: %v", doc.ID, err)
+		}
+		b.purchaseIDs[doc.ID] = true
+	}
+
+	return nil
+}
+
+func totals(byRate sales.VatTotalsByTaxRates) map[int]sharedCommon.Decimal {
+	result := map[int]sharedCommon.Decimal{}
+	for _, total := range byRate {
+		result[total.VatrateID] = result[total.VatrateID].Add(total.TotalDecimal())
+	}
+	return result
+}
+
+//add sums the totals of a document converted to the base currency. The credit documents always
+//reduce the tax, whatever the sign of their totals is
+func (b *Builder) add(lines map[int]*Line, direction Direction, credit bool, currencyRate sharedCommon.Decimal, nets, vats map[int]sharedCommon.Decimal) error {
+	if credit {
+		sum := sharedCommon.Decimal{}
+		for _, net := range nets {
+			sum = sum.Add(net)
+		}
+		for _, vat := range vats {
+			sum = sum.Add(vat)
+		}
+		if sum.Sign() > 0 {
+			currencyRate = currencyRate.Neg()
+		}
+	}
+
+	ids := map[int]bool{}
+	for id := range nets {
+		ids[id] = true
+	}
+	for id := range vats {
+		ids[id] = true
+	}
+	for id := range ids {
+		line, ok := lines[id]
+		if !ok {
+			rate, ok := b.rates[id]
+			if !ok {
+				return fmt.Errorf("unknown VAT rate %d", id)
+			}
+			value, _ := sharedCommon.ParseDecimal(rate.Rate)
+			line = &Line{
+				Direction:   direction,
+				VatRateID:   id,
+				VatRateName: rate.Name,
+				Code:        rate.Code,
+				Rate:        value.Float64(),
+			}
+			lines[id] = line
+		}
+		line.Taxable = line.Taxable.Add(nets[id].Mul(currencyRate))
+		line.Tax = line.Tax.Add(vats[id].Mul(currencyRate))
+		line.Documents++
+	}
+
+	return nil
+}
+
+//Return rounds the sums and splits the tax of the multi-component rates
+func (b *Builder) Return() *Return {
+	ret := &Return{
+		From:   b.From.Format(sharedCommon.DateLayout),
+		To:     b.To.Format(sharedCommon.DateLayout),
+		Output: b.lines(b.output),
+		Input:  b.lines(b.input),
+	}
+	for _, line := range ret.Output {
+		ret.OutputTax = ret.OutputTax.Add(line.Tax)
+	}
+	for _, line := range ret.Input {
+		ret.InputTax = ret.InputTax.Add(line.Tax)
+	}
+	ret.OutputTax = ret.OutputTax.Round(2)
+	ret.InputTax = ret.InputTax.Round(2)
+	ret.Payable = ret.OutputTax.Sub(ret.InputTax)
+
+	return ret
+}
+
+func (b *Builder) lines(byRate map[int]*Line) []Line {
+	lines := make([]Line, 0, len(byRate))
+	for id, line := range byRate {
+		l := *line
+		l.Taxable = l.Taxable.Round(2)
+		l.Tax = l.Tax.Round(2)
+		l.Components = splitTax(b.rates[id], l.Tax)
+		lines = append(lines, l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Rate != lines[j].Rate {
+			return lines[i].Rate > lines[j].Rate
+		}
+		return lines[i].VatRateID < lines[j].VatRateID
+	})
+
+	return lines
+}
+
+//splitTax divides the tax between the components in proportion to their rates,
+//the last component gets the rounding difference so the parts add up to the tax
+func splitTax(rate sales.VatRate, tax sharedCommon.Decimal) []Component {
+	if len(rate.Components) == 0 {
+		return nil
+	}
+
+	sum := sharedCommon.Decimal{}
+	rates := make([]sharedCommon.Decimal, 0, len(rate.Components))
+	components := make([]Component, 0, len(rate.Components))
+	for _, c := range rate.Components {
+		value, _ := sharedCommon.ParseDecimal(c.Rate)
+		sum = sum.Add(value)
+		rates = append(rates, value)
+		components = append(components, Component{ID: c.ID, Name: c.Name, Type: c.Type, Rate: value.Float64()})
+	}
+
+	rest := tax
+	for i := range components {
+		if i == len(components)-1 {
+			components[i].Tax = rest.Round(2)
+			break
+		}
+		if part, err := tax.Mul(rates[i]).Div(sum, 2); err == nil {
+			components[i].Tax = part
+		}
+		rest = rest.Sub(components[i].Tax)
+	}
+
+	return components
+}
+
+func (b *Builder) inPeriod(date string) (bool, error) {
+	parsed, err := sharedCommon.UTC.ParseDate(date)
+	if err != nil || parsed.IsZero() {
+		return false, fmt.Errorf("invalid date %q", date)
+	}
+	return !parsed.Before(b.From) && !parsed.After(b.To), nil
+}
+
+//currencyRate is the rate of the document currency on the document date from the rate table or the document
+func (b *Builder) currencyRate(code, date, rate string) (sharedCommon.Decimal, error) {
+	if b.Rates == nil {
+		return currencyRate(rate)
+	}
+	parsed, err := sharedCommon.UTC.ParseDate(date)
+	if err != nil || parsed.IsZero() {
+		return sharedCommon.Decimal{}, fmt.Errorf("invalid date %q", date)
+	}
+	value, err := b.Rates.RecordRate(code, parsed, rate)
+	if err != nil {
+		return sharedCommon.Decimal{}, err
+	}
+	return sharedCommon.DecimalFromFloat(value), nil
+}
+
+//currencyRate is the rate of the document currency to the base currency, an empty or zero rate is 1
+func currencyRate(value string) (sharedCommon.Decimal, error) {
+	rate, err := sharedCommon.ParseDecimal(value)
+	if err != nil {
+		return sharedCommon.Decimal{}, fmt.Errorf("invalid currency rate %q", value)
+	}
+	if rate.IsZero() {
+		return sharedCommon.DecimalFromInt(1), nil
+	}
+	return rate, nil
+}
@@ -0,0 +1,212 @@
+package taxreport
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBuilder(t *testing.T) *Builder {
+	builder, err := NewBuilder(
+		time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 7, 31, 0, 0, 0, 0, time.UTC),
+		sales.VatRates{
+			{ID: "1", Name: "VAT 20%", Rate: "20"},
+			{ID: "2", Name: "Zero", Rate: "0"},
+			{ID: "3", Name: "GST 18%", Code: "GST18", Rate: "18", Components: []sales.VatRateComponent{
+				{ID: 31, VatRateID: 3, Name: "CGST", Type: "CGST", Rate: "9"},
+				{ID: 32, VatRateID: 3, Name: "SGST", Type: "SGST", Rate: "9"},
+			}},
+		},
+	)
+	assert.NoError(t, err)
+
+	err = builder.AddSales(
+		sales.SaleDocument{
+			ID: 1, Type: "INVWAYBILL", Confirmed: "1", Date: "2022-07-05",
+			NetTotalsByTaxRate:  sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 100}, {VatrateID: 2, Total: 50}},
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 20}},
+		},
+		//a foreign currency invoice is converted to the base currency
+		sales.SaleDocument{
+			ID: 2, Type: "INVOICE", Confirmed: "1", Date: "2022-07-31", CurrencyRate: "0.5",
+			NetTotalsByTaxRate:  sales.VatTotalsByTaxRates{{VatrateID: 3, Total: 100.03}},
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 3, Total: 18.01}},
+		},
+		//credit invoices reduce the output tax with any sign of their totals
+		sales.SaleDocument{
+			ID: 3, Type: "CREDITINVOICE", Confirmed: "1", Date: "2022-07-10",
+			NetTotalsByTaxRate:  sales.VatTotalsByTaxRates{{VatrateID: 1, Total: -10}},
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: -2}},
+		},
+		sales.SaleDocument{
+			ID: 4, Type: "CREDITINVOICE", Confirmed: "1", Date: "2022-07-11",
+			NetTotalsByTaxRate:  sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 5}},
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 1}},
+		},
+		//skipped: unconfirmed, cancelled, an order, outside of the period and a duplicate
+		sales.SaleDocument{ID: 5, Type: "INVOICE", Confirmed: "0", Date: "2022-07-05", VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 99}}},
+		sales.SaleDocument{ID: 6, Type: "INVOICE", Confirmed: "1", InvoiceState: "CANCELLED", Date: "2022-07-05", VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 99}}},
+		sales.SaleDocument{ID: 7, Type: "ORDER", Confirmed: "1", Date: "2022-07-05", VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 99}}},
+		sales.SaleDocument{ID: 8, Type: "INVOICE", Confirmed: "1", Date: "2022-08-01", VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 99}}},
+		sales.SaleDocument{
+			ID: 1, Type: "INVWAYBILL", Confirmed: "1", Date: "2022-07-05",
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 20}},
+		},
+	)
+	assert.NoError(t, err)
+
+	err = builder.AddPurchases(
+		purchase.PurchaseDocument{
+			ID: 1, Type: purchase.PurchaseInvoiceWaybill, Confirmed: 1, Date: "2022-07-02",
+			NetTotalsByTaxRate: []purchase.VatRate{{VatrateID: 1, Total: 40}},
+			VatTotalsByTaxRate: []purchase.VatRate{{VatrateID: 1, Total: 8}},
+		},
+		purchase.PurchaseDocument{
+			ID: 2, Type: purchase.PurchaseReturn, Confirmed: 1, Date: "2022-07-03",
+			NetTotalsByTaxRate: []purchase.VatRate{{VatrateID: 1, Total: 5}},
+			VatTotalsByTaxRate: []purchase.VatRate{{VatrateID: 1, Total: 1}},
+		},
+		purchase.PurchaseDocument{
+			ID: 3, Type: purchase.PurchaseOrder, Confirmed: 1, Date: "2022-07-03",
+			VatTotalsByTaxRate: []purchase.VatRate{{VatrateID: 1, Total: 99}},
+		},
+	)
+	assert.NoError(t, err)
+
+	return builder
+}
+
+func TestReturn(t *testing.T) {
+	ret := newTestBuilder(t).Return()
+
+	assert.Equal(t, "2022-07-01", ret.From)
+	assert.Equal(t, "2022-07-31", ret.To)
+	assert.Equal(t, []string{
+		"OUTPUT|1|VAT 20%||20|85.00|17.00|3",
+		"OUTPUT|3|GST 18%|GST18|18|50.02|9.01|1|31:CGST:CGST:9:4.51|32:SGST:SGST:9:4.50",
+		"OUTPUT|2|Zero||0|50.00|0.00|1",
+	}, lineRecords(ret.Output))
+	assert.Equal(t, []string{"INPUT|1|VAT 20%||20|35.00|7.00|2"}, lineRecords(ret.Input))
+	assert.Equal(t, "26.01", ret.OutputTax.String())
+	assert.Equal(t, "7.00", ret.InputTax.String())
+	assert.Equal(t, "19.01", ret.Payable.String())
+}
+
+//lineRecords formats the lines for the comparison, the decimals with equal values can differ in the scale
+func lineRecords(lines []Line) []string {
+	records := []string{}
+	for _, line := range lines {
+		record := fmt.Sprintf("%s|%d|%s|%s|%v|%s|%s|%d",
+			line.Direction, line.VatRateID, line.VatRateName, line.Code, line.Rate, line.Taxable, line.Tax, line.Documents)
+		for _, c := range line.Components {
+			record += fmt.Sprintf("|%d:%s:%s:%v:%s", c.ID, c.Name, c.Type, c.Rate, c.Tax)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRateTable(t *testing.T) {
+	builder, err := NewBuilder(
+		time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 7, 31, 0, 0, 0, 0, time.UTC),
+		sales.VatRates{{ID: "1", Name: "VAT 20%", Rate: "20"}},
+	)
+	assert.NoError(t, err)
+	builder.Rates = currency.NewTable("EUR")
+	assert.NoError(t, builder.Rates.Add("USD", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), 0.8))
+
+	//the rate of the table on the document date wins over the rate of the document
+	err = builder.AddSales(
+		sales.SaleDocument{
+			ID: 1, Type: "INVOICE", Confirmed: "1", Date: "2022-07-10", CurrencyCode: "USD", CurrencyRate: "0.5",
+			NetTotalsByTaxRate:  sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 100}},
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 20}},
+		},
+		sales.SaleDocument{
+			ID: 2, Type: "INVOICE", Confirmed: "1", Date: "2022-07-10",
+			NetTotalsByTaxRate:  sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 10}},
+			VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 1, Total: 2}},
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"OUTPUT|1|VAT 20%||20|90.00|18.00|2"}, lineRecords(builder.Return().Output))
+
+	err = builder.AddSales(sales.SaleDocument{ID: 3, Type: "INVOICE", Confirmed: "1", Date: "2022-07-10", CurrencyCode: "GBP"})
+	assert.EqualError(t, err, "sales document 3: no exchange rate of GBP on 2022-07-10")
+}
+
+func TestAddSalesErrors(t *testing.T) {
+	builder := newTestBuilder(t)
+
+	err := builder.AddSales(sales.SaleDocument{
+		ID: 10, Type: "INVOICE", Date: "2022-07-05",
+		VatTotalsByTaxRates: sales.VatTotalsByTaxRates{{VatrateID: 9, Total: 1}},
+	})
+	assert.EqualError(t, err, "sales document 10: unknown VAT rate 9")
+
+	err = builder.AddSales(sales.SaleDocument{ID: 11, Type: "INVOICE", Date: "05.07.2022"})
+	assert.EqualError(t, err, `sales document 11: invalid date "05.07.2022"`)
+
+	_, err = NewBuilder(time.Now(), time.Now(), sales.VatRates{{ID: "1", Rate: "x"}})
+	assert.EqualError(t, err, `invalid rate "x" of VAT rate 1`)
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteCSV(buf, newTestBuilder(t).Return()))
+	assert.Equal(t, `direction,vat_rate_id,vat_rate,code,rate,component,component_rate,taxable,tax,documents
+OUTPUT,1,VAT 20%,,20,,,85.00,17.00,3
+OUTPUT,3,GST 18%,GST18,18,,,50.02,9.01,1
+OUTPUT,3,GST 18%,GST18,18,CGST,9,50.02,4.51,
+OUTPUT,3,GST 18%,GST18,18,SGST,9,50.02,4.50,
+OUTPUT,2,Zero,,0,,,50.00,0.00,1
+INPUT,1,VAT 20%,,20,,,35.00,7.00,2
+OUTPUT_TAX,,,,,,,,26.01,
+INPUT_TAX,,,,,,,,7.00,
+PAYABLE,,,,,,,,19.01,
+`, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	ret := newTestBuilder(t).Return()
+	ret.CompanyName = "Acme"
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteJSON(buf, ret))
+
+	read := &Return{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), read))
+	assert.Equal(t, "Acme", read.CompanyName)
+	assert.Equal(t, lineRecords(ret.Output), lineRecords(read.Output))
+	assert.Equal(t, lineRecords(ret.Input), lineRecords(read.Input))
+	assert.Equal(t, []string{"26.01", "7.00", "19.01"}, []string{read.OutputTax.String(), read.InputTax.String(), read.Payable.String()})
+}
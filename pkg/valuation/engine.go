@@ -0,0 +1,413 @@
+package valuation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"sort"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+const (
+	//valuePlaces is the precision of the value of the partly issued layers
+	valuePlaces = 8
+	//moneyPlaces is the precision of the reported values and costs
+	moneyPlaces = 2
+	//unitCostPlaces is the precision of the reported unit costs
+	unitCostPlaces = 4
+)
+
+//StockLine is the stock of a product in a warehouse and its value in the base currency
+type StockLine struct {
+	WarehouseID int                  `json:"warehouseID"`
+	ProductID   int                  `json:"productID"`
+	Quantity    sharedCommon.Decimal `json:"quantity"`
+	Value       sharedCommon.Decimal `json:"value"`
+	UnitCost    sharedCommon.Decimal `json:"unitCost"`
+}
+
+//RowCost is the cost of the goods sold by a sales row, the returns have the negative quantity, cost and revenue
+type RowCost struct {
+	DocumentID  int                  `json:"documentID"`
+	Number      string               `json:"number"`
+	Kind        Kind                 `json:"kind"`
+	Date        string               `json:"date"`
+	Row         int                  `json:"row"`
+	WarehouseID int                  `json:"warehouseID"`
+	ProductID   int                  `json:"productID"`
+	Quantity    sharedCommon.Decimal `json:"quantity"`
+	Cost        sharedCommon.Decimal `json:"cost"`
+	Revenue     sharedCommon.Decimal `json:"revenue"`
+	Margin      sharedCommon.Decimal `json:"margin"`
+}
+
+//DocumentCost compares the cost of the goods sold by a sales document with the cost stored in the ERP
+type DocumentCost struct {
+	DocumentID   int                  `json:"documentID"`
+	Number       string               `json:"number"`
+	Date         string               `json:"date"`
+	Cost         sharedCommon.Decimal `json:"cost"`
+	RecordedCost sharedCommon.Decimal `json:"recordedCost"`
+	Difference   sharedCommon.Decimal `json:"difference"`
+}
+
+//Shortage is the issue of more units than there were in the warehouse, the missing units are costed
+//at the last known unit cost and the later receipts cover them
+type Shortage struct {
+	Date        string               `json:"date"`
+	Kind        Kind                 `json:"kind"`
+	DocumentID  int                  `json:"documentID"`
+	Number      string               `json:"number"`
+	WarehouseID int                  `json:"warehouseID"`
+	ProductID   int                  `json:"productID"`
+	Quantity    sharedCommon.Decimal `json:"quantity"`
+}
+
+//Report is the stock value at the end of the day and the cost of the goods sold until it
+type Report struct {
+	Date      string               `json:"date"`
+	Method    Method               `json:"method"`
+	Stock     []StockLine          `json:"stock"`
+	Value     sharedCommon.Decimal `json:"value"`
+	Costs     []RowCost            `json:"costs"`
+	Shortages []Shortage           `json:"shortages"`
+	recorded  map[int]float64
+}
+
+//CostsBetween returns the costs of the sales rows of the period between the dates inclusive
+func (r *Report) CostsBetween(from, to time.Time) []RowCost {
+	fromDay, toDay := from.Format(sharedCommon.DateLayout), to.Format(sharedCommon.DateLayout)
+	res := []RowCost{}
+	for _, cost := range r.Costs {
+		if (from.IsZero() || cost.Date >= fromDay) && (to.IsZero() || cost.Date <= toDay) {
+			res = append(res, cost)
+		}
+	}
+	return res
+}
+
+//Documents sums the row costs by the sales document and compares them with the costs stored in the ERP
+func Documents(costs []RowCost, recorded map[int]float64) []DocumentCost {
+	res := []DocumentCost{}
+	index := map[int]int{}
+	for _, cost := range costs {
+		i, ok := index[cost.DocumentID]
+		if !ok {
+			i = len(res)
+			index[cost.DocumentID] = i
+			res = append(res, DocumentCost{
+				DocumentID:   cost.DocumentID,
+				Number:       cost.Number,
+				Date:         cost.Date,
+				RecordedCost: sharedCommon.DecimalFromFloat(recorded[cost.DocumentID]),
+			})
+		}
+		res[i].Cost = res[i].Cost.Add(cost.Cost)
+	}
+	for i := range res {
+		res[i].Difference = res[i].Cost.Sub(res[i].RecordedCost)
+	}
+	return res
+}
+
+//DocumentCosts sums the costs of the sales rows of the period by the document
+func (r *Report) DocumentCosts(from, to time.Time) []DocumentCost {
+	return Documents(r.CostsBetween(from, to), r.recorded)
+}
+
+//layer is a quantity received at the same cost, the value is kept instead of the unit cost so that
+//the partly issued layers don't lose cents
+type layer struct {
+	quantity sharedCommon.Decimal
+	value    sharedCommon.Decimal
+}
+
+//position is the stock of a product in a warehouse, a negative quantity is a shortage valued at the last cost
+type position struct {
+	quantity sharedCommon.Decimal
+	value    sharedCommon.Decimal
+	layers   []layer
+	lastCost sharedCommon.Decimal
+}
+
+func (p *position) receive(method Method, l layer) {
+	if l.quantity.Sign() <= 0 {
+		return
+	}
+	unitCost, _ := l.value.Div(l.quantity, valuePlaces)
+
+	if p.quantity.Sign() < 0 {
+		covered := l.quantity
+		if covered.Cmp(p.quantity.Neg()) > 0 {
+			covered = p.quantity.Neg()
+		}
+		p.quantity = p.quantity.Add(covered)
+		p.value = p.quantity.Mul(p.lastCost)
+		if covered.Equal(l.quantity) {
+			p.lastCost = unitCost
+			return
+		}
+		rest := l.quantity.Sub(covered)
+		l = layer{quantity: rest, value: rest.Mul(unitCost)}
+	}
+
+	p.quantity = p.quantity.Add(l.quantity)
+	p.value = p.value.Add(l.value)
+	if method == FIFO {
+		p.layers = append(p.layers, l)
+		p.lastCost = unitCost
+		return
+	}
+	p.lastCost, _ = p.value.Div(p.quantity, valuePlaces)
+}
+
+//issue takes the units from the stock and returns the layers at their cost, the missing units are
+//returned as a layer at the last cost
+func (p *position) issue(method Method, quantity sharedCommon.Decimal) (issued []layer, missing sharedCommon.Decimal) {
+	available := p.quantity
+	if available.Sign() < 0 {
+		available = sharedCommon.Decimal{}
+	}
+	take := quantity
+	if take.Cmp(available) > 0 {
+		take = available
+	}
+
+	if take.Sign() > 0 {
+		if method == FIFO {
+			issued = p.issueLayers(take)
+		} else {
+			value := p.value
+			if !take.Equal(p.quantity) {
+				value, _ = p.value.Mul(take).Div(p.quantity, valuePlaces)
+			}
+			issued = []layer{{quantity: take, value: value}}
+		}
+		for _, l := range issued {
+			p.quantity = p.quantity.Sub(l.quantity)
+			p.value = p.value.Sub(l.value)
+		}
+	}
+
+	missing = quantity.Sub(take)
+	if missing.Sign() > 0 {
+		l := layer{quantity: missing, value: missing.Mul(p.lastCost)}
+		issued = append(issued, l)
+		p.quantity = p.quantity.Sub(l.quantity)
+		p.value = p.value.Sub(l.value)
+	}
+	return issued, missing
+}
+
+func (p *position) issueLayers(quantity sharedCommon.Decimal) []layer {
+	issued := []layer{}
+	for quantity.Sign() > 0 && len(p.layers) > 0 {
+		head := &p.layers[0]
+		if quantity.Cmp(head.quantity) >= 0 {
+			issued = append(issued, *head)
+			quantity = quantity.Sub(head.quantity)
+			p.layers = p.layers[1:]
+			continue
+		}
+		value, _ := head.value.Mul(quantity).Div(head.quantity, valuePlaces)
+		issued = append(issued, layer{quantity: quantity, value: value})
+		head.quantity = head.quantity.Sub(quantity)
+		head.value = head.value.Sub(value)
+		quantity = sharedCommon.Decimal{}
+	}
+	return issued
+}
+
+//unitCost is the cost of a unit in the stock, the last cost when the stock is empty
+func (p *position) unitCost() sharedCommon.Decimal {
+	if p.quantity.Sign() > 0 {
+		cost, _ := p.value.Div(p.quantity, valuePlaces)
+		return cost
+	}
+	return p.lastCost
+}
+
+type positionKey struct {
+	warehouseID int
+	productID   int
+}
+
+//saleKey is a product of a sales document, the returns take the cost of the returned sale
+type saleKey struct {
+	documentID int
+	productID  int
+}
+
+//Sort orders the movements by the date, the receipts of a day come before the issues, the movements
+//of the same kind by the creation of the document
+func Sort(movements []Movement) {
+	sort.SliceStable(movements, func(i, j int) bool {
+		a, b := movements[i], movements[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.Kind.Inbound() != b.Kind.Inbound() {
+			return a.Kind.Inbound()
+		}
+		if a.Added != b.Added {
+			return a.Added < b.Added
+		}
+		if a.DocumentID != b.DocumentID {
+			return a.DocumentID < b.DocumentID
+		}
+		return a.Row < b.Row
+	})
+}
+
+//Run replays the movements until the end of the day and returns the stock value and the cost of the goods sold
+func (v *Valuator) Run(date time.Time) *Report {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	movements := v.Movements()
+
+	report := &Report{
+		Date:      date.Format(sharedCommon.DateLayout),
+		Method:    v.Method,
+		Stock:     []StockLine{},
+		Costs:     []RowCost{},
+		Shortages: []Shortage{},
+		recorded:  v.recordedCosts,
+	}
+	positions := map[positionKey]*position{}
+	get := func(warehouseID, productID int) *position {
+		key := positionKey{warehouseID: warehouseID, productID: productID}
+		p, ok := positions[key]
+		if !ok {
+			p = &position{}
+			positions[key] = p
+		}
+		return p
+	}
+	sold := map[saleKey]layer{}
+
+	for _, m := range movements {
+		if m.Date.After(date) {
+			break
+		}
+		from := get(m.WarehouseID, m.ProductID)
+		day := m.Date.Format(sharedCommon.DateLayout)
+
+		switch m.Kind {
+		case Purchase, Registration:
+			from.receive(v.Method, layer{quantity: m.Quantity, value: m.Quantity.Mul(m.UnitCost)})
+		case SaleReturn:
+			value := m.Quantity.Mul(v.returnCost(m, sold, from))
+			from.receive(v.Method, layer{quantity: m.Quantity, value: value})
+			report.addCost(m, day, m.Quantity.Neg(), value.Neg(), m.Revenue.Neg())
+		default:
+			issued, missing := from.issue(v.Method, m.Quantity)
+			if missing.Sign() > 0 {
+				report.Shortages = append(report.Shortages, Shortage{
+					Date:        day,
+					Kind:        m.Kind,
+					DocumentID:  m.DocumentID,
+					Number:      m.Number,
+					WarehouseID: m.WarehouseID,
+					ProductID:   m.ProductID,
+					Quantity:    missing,
+				})
+			}
+			cost := sharedCommon.Decimal{}
+			for _, l := range issued {
+				cost = cost.Add(l.value)
+			}
+
+			switch m.Kind {
+			case Transfer:
+				to := get(m.ToWarehouseID, m.ProductID)
+				for _, l := range issued {
+					to.receive(v.Method, l)
+				}
+			case Sale:
+				key := saleKey{documentID: m.DocumentID, productID: m.ProductID}
+				total := sold[key]
+				sold[key] = layer{quantity: total.quantity.Add(m.Quantity), value: total.value.Add(cost)}
+				report.addCost(m, day, m.Quantity, cost, m.Revenue)
+			}
+		}
+	}
+
+	keys := make([]positionKey, 0, len(positions))
+	for key := range positions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].warehouseID != keys[j].warehouseID {
+			return keys[i].warehouseID < keys[j].warehouseID
+		}
+		return keys[i].productID < keys[j].productID
+	})
+	for _, key := range keys {
+		p := positions[key]
+		if p.quantity.IsZero() && p.value.Round(moneyPlaces).IsZero() {
+			continue
+		}
+		value := p.value.Round(moneyPlaces)
+		report.Stock = append(report.Stock, StockLine{
+			WarehouseID: key.warehouseID,
+			ProductID:   key.productID,
+			Quantity:    p.quantity,
+			Value:       value,
+			UnitCost:    p.unitCost().Round(unitCostPlaces),
+		})
+		report.Value = report.Value.Add(value)
+	}
+
+	return report
+}
+
+//returnCost is the unit cost of the returned units, the cost of the returned sale when it's known,
+//the unit cost of the movement or the current cost in the warehouse otherwise
+func (v *Valuator) returnCost(m Movement, sold map[saleKey]layer, p *position) sharedCommon.Decimal {
+	for _, id := range m.BaseDocumentIDs {
+		sale, ok := sold[saleKey{documentID: id, productID: m.ProductID}]
+		if ok && sale.quantity.Sign() > 0 {
+			cost, _ := sale.value.Div(sale.quantity, valuePlaces)
+			return cost
+		}
+	}
+	if !m.UnitCost.IsZero() {
+		return m.UnitCost
+	}
+	return p.unitCost()
+}
+
+func (r *Report) addCost(m Movement, day string, quantity, cost, revenue sharedCommon.Decimal) {
+	cost = cost.Round(moneyPlaces)
+	revenue = revenue.Round(moneyPlaces)
+	r.Costs = append(r.Costs, RowCost{
+		DocumentID:  m.DocumentID,
+		Number:      m.Number,
+		Kind:        m.Kind,
+		Date:        day,
+		Row:         m.Row,
+		WarehouseID: m.WarehouseID,
+		ProductID:   m.ProductID,
+		Quantity:    quantity,
+		Cost:        cost,
+		Revenue:     revenue,
+		Margin:      revenue.Sub(cost),
+	})
+}
@@ -0,0 +1,125 @@
+package valuation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+)
+
+//WriteStockCSV writes a row per product and warehouse followed by the total stock value
+func WriteStockCSV(w io.Writer, report *Report) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"date", "warehouse_id", "product_id", "quantity", "unit_cost", "value"}); err != nil {
+		return err
+	}
+	for _, line := range report.Stock {
+		record := []string{
+			report.Date,
+			strconv.Itoa(line.WarehouseID),
+			strconv.Itoa(line.ProductID),
+			line.Quantity.String(),
+			line.UnitCost.StringFixed(unitCostPlaces),
+			line.Value.StringFixed(moneyPlaces),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := out.Write([]string{"TOTAL", "", "", "", "", report.Value.StringFixed(moneyPlaces)}); err != nil {
+		return err
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteCostsCSV writes a row per sales row with its cost of the goods sold followed by the totals
+func WriteCostsCSV(w io.Writer, costs []RowCost) error {
+	out := csv.NewWriter(w)
+	header := []string{"date", "document_id", "number", "kind", "row", "warehouse_id", "product_id", "quantity", "cost", "revenue", "margin"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	var cost, revenue sharedCommon.Decimal
+	for _, row := range costs {
+		cost = cost.Add(row.Cost)
+		revenue = revenue.Add(row.Revenue)
+		record := []string{
+			row.Date,
+			strconv.Itoa(row.DocumentID),
+			row.Number,
+			string(row.Kind),
+			strconv.Itoa(row.Row),
+			strconv.Itoa(row.WarehouseID),
+			strconv.Itoa(row.ProductID),
+			row.Quantity.String(),
+			row.Cost.StringFixed(moneyPlaces),
+			row.Revenue.StringFixed(moneyPlaces),
+			row.Margin.StringFixed(moneyPlaces),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	total := []string{"TOTAL", "", "", "", "", "", "", "", cost.StringFixed(moneyPlaces), revenue.StringFixed(moneyPlaces), revenue.Sub(cost).StringFixed(moneyPlaces)}
+	if err := out.Write(total); err != nil {
+		return err
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteDocumentsCSV writes a row per sales document with the cost of the goods sold and the cost stored in the ERP
+func WriteDocumentsCSV(w io.Writer, documents []DocumentCost) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"date", "document_id", "number", "cost", "recorded_cost", "difference"}); err != nil {
+		return err
+	}
+	for _, doc := range documents {
+		record := []string{
+			doc.Date,
+			strconv.Itoa(doc.DocumentID),
+			doc.Number,
+			doc.Cost.StringFixed(moneyPlaces),
+			doc.RecordedCost.StringFixed(moneyPlaces),
+			doc.Difference.StringFixed(moneyPlaces),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+//WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,130 @@
+package valuation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+//inventoryDocumentsPerPage is the page size of the inventory registrations, write-offs and transfers
+const inventoryDocumentsPerPage = 100
+
+//Load reads the documents which moved the stock until the end of the day, the cost layers are rebuilt
+//from the whole history
+func (c *Collector) Load(
+	ctx context.Context,
+	salesAPI sales.Manager,
+	purchases purchase.Manager,
+	warehouses warehouse.Manager,
+	settings sharedCommon.ListingSettings,
+	to time.Time,
+) error {
+	dateTo := to.Format(sharedCommon.DateLayout)
+
+	types := make([]string, 0, len(saleTypes))
+	for docType := range saleTypes {
+		types = append(types, docType)
+	}
+	sort.Strings(types)
+
+	lister := sharedCommon.NewLister(settings, sales.NewSaleDocumentsListingDataProvider(salesAPI), nil)
+	for item := range lister.Get(ctx, map[string]interface{}{
+		"types":                 strings.Join(types, ","),
+		"dateTo":                dateTo,
+		"getRowsForAllInvoices": 1,
+	}) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := c.AddSales(item.Payload.(sales.SaleDocument)); err != nil {
+			return err
+		}
+	}
+
+	purchaseLister := sharedCommon.NewLister(settings, purchase.NewListingDataProvider(purchases), nil)
+	for item := range purchaseLister.Get(ctx, map[string]interface{}{"dateTo": dateTo}) {
+		if item.Err != nil {
+			return item.Err
+		}
+		if err := c.AddPurchases(item.Payload.(purchase.PurchaseDocument)); err != nil {
+			return err
+		}
+	}
+
+	filters := map[string]string{"dateTo": dateTo}
+	err := readPages(filters, func(page map[string]string) (int, error) {
+		registrations, err := warehouses.GetInventoryRegistrations(ctx, page)
+		if err != nil {
+			return 0, err
+		}
+		return len(registrations), c.AddRegistrations(registrations...)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = readPages(filters, func(page map[string]string) (int, error) {
+		writeOffs, err := warehouses.GetInventoryWriteOffs(ctx, page)
+		if err != nil {
+			return 0, err
+		}
+		return len(writeOffs), c.AddWriteOffs(writeOffs...)
+	})
+	if err != nil {
+		return err
+	}
+
+	return readPages(filters, func(page map[string]string) (int, error) {
+		transfers, err := warehouses.GetInventoryTransfers(ctx, page)
+		if err != nil {
+			return 0, err
+		}
+		return len(transfers), c.AddTransfers(transfers...)
+	})
+}
+
+//readPages calls the request with the page filters until a page isn't full
+func readPages(filters map[string]string, read func(page map[string]string) (int, error)) error {
+	for pageNo := 1; ; pageNo++ {
+		page := map[string]string{
+			"recordsOnPage": strconv.Itoa(inventoryDocumentsPerPage),
+			"pageNo":        strconv.Itoa(pageNo),
+		}
+		for key, value := range filters {
+			page[key] = value
+		}
+		count, err := read(page)
+		if err != nil {
+			return err
+		}
+		if count < inventoryDocumentsPerPage {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package valuation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	pages := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parsedRequest, err := common.ExtractBulkFiltersFromRequest(r)
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+
+		ok := sharedCommon.Status{ResponseStatus: "ok"}
+		status := sharedCommon.StatusBulk{Status: ok}
+		status.RecordsTotal = 1
+
+		var resp interface{}
+		request := r.Form.Get("request")
+		switch request {
+		case "getInventoryRegistrations", "getInventoryWriteOffs", "getInventoryTransfers":
+			assert.Equal(t, "2022-06-30", r.Form.Get("dateTo"))
+			assert.Equal(t, "100", r.Form.Get("recordsOnPage"))
+			pages[request] = append(pages[request], r.Form.Get("pageNo"))
+		}
+		switch request {
+		case "getInventoryRegistrations":
+			//a full page is followed by the next one
+			registrations := make([]warehouse.InventoryRegistration, 0, 100)
+			if r.Form.Get("pageNo") == "1" {
+				for i := 1; i <= 100; i++ {
+					registrations = append(registrations, warehouse.InventoryRegistration{InventoryRegistrationID: i, Confirmed: 1, WarehouseID: 1, Date: "2022-06-01",
+						Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "1", Price: "2"}}})
+				}
+			}
+			resp = warehouse.GetInventoryRegistrationsResponse{Status: ok, InventoryRegistrations: registrations}
+		case "getInventoryWriteOffs":
+			resp = warehouse.GetInventoryWriteOffsResponse{Status: ok, InventoryWriteOffs: []warehouse.InventoryWriteOff{
+				{InventoryWriteOffID: 1, Confirmed: 1, WarehouseID: 1, Date: "2022-06-20", Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "10"}}},
+			}}
+		case "getInventoryTransfers":
+			resp = warehouse.GetInventoryTransfersResponse{Status: ok, InventoryTransfers: []warehouse.InventoryTransfer{}}
+		default:
+			bulk := parsedRequest["requests"].([]map[string]interface{})[0]
+			assert.Equal(t, "2022-06-30", fmt.Sprint(bulk["dateTo"]))
+			if bulk["requestName"] == "getSalesDocuments" {
+				assert.Equal(t, "CASHINVOICE,CREDITINVOICE,EXPORTINVOICE,INVWAYBILL,WAYBILL", fmt.Sprint(bulk["types"]))
+				resp = sales.GetSaleDocumentResponseBulk{Status: ok, BulkItems: []sales.GetSaleDocumentBulkItem{{
+					Status: status,
+					SaleDocuments: []sales.SaleDocument{{ID: 7, Type: "INVWAYBILL", WarehouseID: 1, Date: "2022-06-10",
+						InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "40", RowNetTotal: 120}}}},
+				}}}
+			} else {
+				assert.Equal(t, "getPurchaseDocuments", bulk["requestName"])
+				resp = purchase.GetPurchaseDocumentResponseBulk{Status: ok, BulkItems: []purchase.GetPurchaseDocumentBulkItem{{
+					Status: status,
+					PurchaseDocuments: []purchase.PurchaseDocument{{ID: 8, Type: purchase.PurchaseInvoiceWaybill, Confirmed: 1, WarehouseID: 1, Date: "2022-06-05",
+						Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "50", Price: "3"}}}},
+				}}}
+			}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	baseClient := common.NewClient("somesess", "someclient", "", nil, nil)
+	baseClient.Url = srv.URL
+
+	v, err := NewValuator(FIFO)
+	assert.NoError(t, err)
+	err = v.Load(context.Background(), sales.NewClient(baseClient), purchase.NewClient(baseClient), warehouse.NewClient(baseClient),
+		sharedCommon.ListingSettings{}, time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"getInventoryRegistrations": {"1", "2"},
+		"getInventoryWriteOffs":     {"1"},
+		"getInventoryTransfers":     {"1"},
+	}, pages)
+
+	//100 units at 2 and 50 at 3, 40 sold and 10 written off from the oldest
+	report := v.Run(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteStockCSV(buf, report))
+	assert.Equal(t, "date,warehouse_id,product_id,quantity,unit_cost,value\n"+
+		"2022-06-30,1,5,100,2.5000,250.00\n"+
+		"TOTAL,,,,,250.00\n", buf.String())
+	buf.Reset()
+	assert.NoError(t, WriteCostsCSV(buf, report.Costs))
+	assert.Equal(t, "date,document_id,number,kind,row,warehouse_id,product_id,quantity,cost,revenue,margin\n"+
+		"2022-06-10,7,,SALE,1,1,5,40,80.00,120.00,40.00\n"+
+		"TOTAL,,,,,,,,80.00,120.00,40.00\n", buf.String())
+}
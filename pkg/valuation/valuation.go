@@ -0,0 +1,474 @@
+package valuation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/currency"
+)
+
+//Method is the costing method of the issued stock
+type Method string
+
+const (
+	//FIFO issues the stock at the cost of the oldest received units
+	FIFO Method = "fifo"
+	//WeightedAverage issues the stock at the moving average cost of the units in the warehouse
+	WeightedAverage Method = "average"
+)
+
+//ErrUnknownMethod is returned for the costing methods other than fifo and average
+var ErrUnknownMethod = errors.New("unknown costing method")
+
+//ParseMethod parses the costing method name, "weighted" and "avg" are the aliases of average
+func ParseMethod(value string) (Method, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "fifo":
+		return FIFO, nil
+	case "average", "avg", "weighted", "weighted-average":
+		return WeightedAverage, nil
+	}
+	return "", fmt.Errorf("%w %q, expected fifo or average", ErrUnknownMethod, value)
+}
+
+//Kind is the type of the document row which moves the stock
+type Kind string
+
+const (
+	Purchase       Kind = "PURCHASE"
+	PurchaseReturn Kind = "PURCHASE_RETURN"
+	Registration   Kind = "REGISTRATION"
+	WriteOff       Kind = "WRITEOFF"
+	Transfer       Kind = "TRANSFER"
+	Sale           Kind = "SALE"
+	SaleReturn     Kind = "SALE_RETURN"
+)
+
+//Inbound tells if the movement of the kind receives the stock, a transfer issues the stock and receives it
+//in the other warehouse
+func (k Kind) Inbound() bool {
+	return k == Purchase || k == Registration || k == SaleReturn
+}
+
+//saleTypes are the sales documents which issue the stock, the plain invoices don't move the stock
+//and the credit invoices return it
+var saleTypes = map[string]bool{
+	sales.SaleDocumentTypeInvWayBill:    true,
+	sales.SaleDocumentTypeCASHINVOICE:   true,
+	sales.SaleDocumentTypeWayBill:       true,
+	sales.SaleDocumentTypeExportInvoice: true,
+	sales.SaleDocumentTypeCreditInvoice: true,
+}
+
+//purchaseTypes are the purchase documents which receive the stock, the returns issue it
+var purchaseTypes = map[purchase.PurchaseOrderType]bool{
+	purchase.PurchaseInvoiceWaybill: true,
+	purchase.PurchaseReceipt:        true,
+	purchase.PurchaseWaybill:        true,
+	purchase.PurchaseReturn:         true,
+}
+
+//transferOrderType is the inventory transfer which is only requested and doesn't move the stock
+const transferOrderType = "TRANSFER_ORDER"
+
+//Movement is a document row which moves the stock of a product in a warehouse
+type Movement struct {
+	Kind Kind
+	Date time.Time
+	//Added orders the movements of the same day, it's the creation time of the document when the API returns it
+	Added      int64
+	DocumentID int
+	//DocumentType is the type of the API document, e.g. INVWAYBILL or PRCINVOICE, empty for the inventory documents
+	DocumentType string
+	Number       string
+	//Row is the position of the row in the document starting from 1
+	Row         int
+	ProductID   int
+	WarehouseID int
+	//ToWarehouseID is the receiving warehouse of a transfer
+	ToWarehouseID int
+	//Quantity is the positive quantity, the kind tells the direction
+	Quantity sharedCommon.Decimal
+	//UnitCost is the cost of a received unit in the base currency, the sales returns are received at the cost
+	//of the returned sale when it's known
+	UnitCost sharedCommon.Decimal
+	//Revenue is the net amount of a sales row in the base currency
+	Revenue sharedCommon.Decimal
+	//BaseDocumentIDs are the sales documents returned by a sales return
+	BaseDocumentIDs []int
+	//ReasonID is the reason code of a write-off
+	ReasonID int
+}
+
+//Collector collects the stock movements of the documents
+type Collector struct {
+	//Rates converts the documents into the base currency at the rates of the document dates when set,
+	//the currency rates of the documents are used otherwise
+	Rates     *currency.Table
+	movements []Movement
+	//recordedCosts are the costs of the sales documents stored in the ERP by the document ID
+	recordedCosts map[int]float64
+	seen          map[string]bool
+}
+
+//NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{
+		recordedCosts: map[int]float64{},
+		seen:          map[string]bool{},
+	}
+}
+
+//Valuator values the stock by the cost layers rebuilt from the collected movements
+type Valuator struct {
+	Method Method
+	*Collector
+}
+
+//NewValuator creates the valuator of the costing method
+func NewValuator(method Method) (*Valuator, error) {
+	if method != FIFO && method != WeightedAverage {
+		return nil, fmt.Errorf("%w %q, expected fifo or average", ErrUnknownMethod, method)
+	}
+	return &Valuator{Method: method, Collector: NewCollector()}, nil
+}
+
+//Movements returns the collected movements in the order of their replay, see Sort
+func (c *Collector) Movements() []Movement {
+	movements := make([]Movement, len(c.movements))
+	copy(movements, c.movements)
+	Sort(movements)
+	return movements
+}
+
+//Add adds the movements which don't come from the API documents, e.g. the opening balances as registrations
+func (c *Collector) Add(movements ...Movement) {
+	c.movements = append(c.movements, movements...)
+}
+
+//AddPurchases adds the rows of the confirmed purchase documents which receive or return the stock,
+//the received units cost the net price of the row in the base currency
+func (c *Collector) AddPurchases(docs ...purchase.PurchaseDocument) error {
+	for _, doc := range docs {
+		if !purchaseTypes[doc.Type] || doc.Confirmed == 0 || !c.first("purchase", doc.ID) {
+			continue
+		}
+		date, err := transactionDate(doc.InventoryTransactionDate, doc.Date)
+		if err != nil {
+			return fmt.Errorf("purchase document %d: %v", doc.ID, err)
+		}
+		rate, err := c.currencyRate(doc.CurrencyCode, date, doc.CurrencyRate.String())
+		if err != nil {
+			return fmt.Errorf("purchase document %d: %v", doc.ID, err)
+		}
+
+		kind := Purchase
+		if doc.Type == purchase.PurchaseReturn {
+			kind = PurchaseReturn
+		}
+		for i, row := range doc.Rows {
+			if row.ProductID == 0 {
+				continue
+			}
+			quantity, err := sharedCommon.ParseDecimal(row.Amount.String())
+			if err != nil {
+				return fmt.Errorf("purchase document %d row %d: amount: %v", doc.ID, i+1, err)
+			}
+			price, err := sharedCommon.ParseDecimal(row.Price.String())
+			if err != nil {
+				return fmt.Errorf("purchase document %d row %d: price: %v", doc.ID, i+1, err)
+			}
+			discount, err := sharedCommon.ParseDecimal(row.Discount.String())
+			if err != nil {
+				return fmt.Errorf("purchase document %d row %d: discount: %v", doc.ID, i+1, err)
+			}
+			if quantity.IsZero() {
+				continue
+			}
+			c.movements = append(c.movements, Movement{
+				Kind:         kind,
+				Date:         date,
+				DocumentID:   doc.ID,
+				DocumentType: string(doc.Type),
+				Number:       doc.Number,
+				Row:          i + 1,
+				ProductID:    row.ProductID,
+				WarehouseID:  doc.WarehouseID,
+				Quantity:     quantity.Abs(),
+				UnitCost:     netPrice(price, discount).Mul(rate),
+			})
+		}
+	}
+
+	return nil
+}
+
+//AddSales adds the rows of the confirmed sales documents which issue or return the stock.
+//The plain invoices are skipped as the stock is issued by their waybills
+func (c *Collector) AddSales(docs ...sales.SaleDocument) error {
+	for _, doc := range docs {
+		docType := strings.ToUpper(doc.Type)
+		if !saleTypes[docType] || doc.Confirmed == "0" || strings.EqualFold(doc.InvoiceState, "CANCELLED") {
+			continue
+		}
+		if !c.first("sale", doc.ID) {
+			continue
+		}
+		date, err := transactionDate(doc.InventoryTransactionDate, doc.Date)
+		if err != nil {
+			return fmt.Errorf("sales document %d: %v", doc.ID, err)
+		}
+		rate, err := c.currencyRate(doc.CurrencyCode, date, doc.CurrencyRate)
+		if err != nil {
+			return fmt.Errorf("sales document %d: %v", doc.ID, err)
+		}
+
+		kind := Sale
+		var baseIDs []int
+		if docType == sales.SaleDocumentTypeCreditInvoice {
+			kind = SaleReturn
+			for _, base := range doc.BaseDocuments {
+				baseIDs = append(baseIDs, base.ID)
+			}
+		}
+		c.recordedCosts[doc.ID] = doc.Cost
+		for i, row := range doc.InvoiceRows {
+			productID, err := parseID(row.ProductID)
+			if err != nil {
+				return fmt.Errorf("sales document %d row %d: invalid product ID %q", doc.ID, i+1, row.ProductID)
+			}
+			if productID == 0 {
+				continue
+			}
+			quantity, err := row.AmountDecimal()
+			if err != nil {
+				return fmt.Errorf("sales document %d row %d: amount: %v", doc.ID, i+1, err)
+			}
+			if quantity.IsZero() {
+				continue
+			}
+			c.movements = append(c.movements, Movement{
+				Kind:            kind,
+				Date:            date,
+				Added:           int64(doc.Added),
+				DocumentID:      doc.ID,
+				DocumentType:    docType,
+				Number:          doc.Number,
+				Row:             i + 1,
+				ProductID:       productID,
+				WarehouseID:     doc.WarehouseID,
+				Quantity:        quantity.Abs(),
+				Revenue:         row.RowNetTotalDecimal().Abs().Mul(rate),
+				BaseDocumentIDs: baseIDs,
+			})
+		}
+	}
+
+	return nil
+}
+
+//AddRegistrations adds the rows of the confirmed inventory registrations, the received units cost
+//the price of the row in the base currency
+func (c *Collector) AddRegistrations(registrations ...warehouse.InventoryRegistration) error {
+	for _, reg := range registrations {
+		if reg.Confirmed == 0 || !c.first("registration", reg.InventoryRegistrationID) {
+			continue
+		}
+		date, err := parseDate(reg.Date)
+		if err != nil {
+			return fmt.Errorf("inventory registration %d: %v", reg.InventoryRegistrationID, err)
+		}
+		rate, err := c.currencyRate(reg.CurrencyCode, date, reg.CurrencyRate.String())
+		if err != nil {
+			return fmt.Errorf("inventory registration %d: %v", reg.InventoryRegistrationID, err)
+		}
+		err = c.addInventoryRows(Registration, date, reg.Added, reg.InventoryRegistrationID, strconv.Itoa(reg.InventoryRegistrationID), reg.WarehouseID, 0, reg.ReasonID, &rate, reg.Rows)
+		if err != nil {
+			return fmt.Errorf("inventory registration %d %v", reg.InventoryRegistrationID, err)
+		}
+	}
+	return nil
+}
+
+//AddWriteOffs adds the rows of the confirmed inventory write-offs
+func (c *Collector) AddWriteOffs(writeOffs ...warehouse.InventoryWriteOff) error {
+	for _, writeOff := range writeOffs {
+		if writeOff.Confirmed == 0 || !c.first("writeoff", writeOff.InventoryWriteOffID) {
+			continue
+		}
+		date, err := parseDate(writeOff.Date)
+		if err != nil {
+			return fmt.Errorf("inventory write-off %d: %v", writeOff.InventoryWriteOffID, err)
+		}
+		number := strconv.Itoa(writeOff.InventoryWriteOffNo)
+		if writeOff.InventoryWriteOffNo == 0 {
+			number = strconv.Itoa(writeOff.InventoryWriteOffID)
+		}
+		err = c.addInventoryRows(WriteOff, date, writeOff.Added, writeOff.InventoryWriteOffID, number, writeOff.WarehouseID, 0, writeOff.ReasonID, nil, writeOff.Rows)
+		if err != nil {
+			return fmt.Errorf("inventory write-off %d %v", writeOff.InventoryWriteOffID, err)
+		}
+	}
+	return nil
+}
+
+//AddTransfers adds the rows of the confirmed inventory transfers, the transfer orders are skipped.
+//The transferred units keep their cost in the receiving warehouse
+func (c *Collector) AddTransfers(transfers ...warehouse.InventoryTransfer) error {
+	for _, transfer := range transfers {
+		if transfer.Confirmed == 0 || strings.EqualFold(transfer.Type, transferOrderType) {
+			continue
+		}
+		if !c.first("transfer", transfer.InventoryTransferID) {
+			continue
+		}
+		date, err := parseDate(transfer.Date)
+		if err != nil {
+			return fmt.Errorf("inventory transfer %d: %v", transfer.InventoryTransferID, err)
+		}
+		number := strconv.Itoa(transfer.InventoryTransferNo)
+		if transfer.InventoryTransferNo == 0 {
+			number = strconv.Itoa(transfer.InventoryTransferID)
+		}
+		err = c.addInventoryRows(Transfer, date, transfer.Added, transfer.InventoryTransferID, number, transfer.WarehouseFromID, transfer.WarehouseToID, 0, nil, transfer.Rows)
+		if err != nil {
+			return fmt.Errorf("inventory transfer %d %v", transfer.InventoryTransferID, err)
+		}
+	}
+	return nil
+}
+
+//addInventoryRows adds the rows of an inventory document, the rate prices the rows of the registrations
+func (c *Collector) addInventoryRows(
+	kind Kind,
+	date time.Time,
+	added int64,
+	id int,
+	number string,
+	warehouseID, toWarehouseID int,
+	reasonID int,
+	rate *sharedCommon.Decimal,
+	rows []warehouse.InventoryDocumentRow,
+) error {
+	for i, row := range rows {
+		if row.ProductID == 0 {
+			continue
+		}
+		quantity, err := row.AmountDecimal()
+		if err != nil {
+			return fmt.Errorf("row %d: amount: %v", i+1, err)
+		}
+		if quantity.IsZero() {
+			continue
+		}
+		movement := Movement{
+			Kind:          kind,
+			Date:          date,
+			Added:         added,
+			DocumentID:    id,
+			Number:        number,
+			Row:           i + 1,
+			ProductID:     row.ProductID,
+			WarehouseID:   warehouseID,
+			ToWarehouseID: toWarehouseID,
+			Quantity:      quantity.Abs(),
+			ReasonID:      reasonID,
+		}
+		if rate != nil {
+			price, err := row.PriceDecimal()
+			if err != nil {
+				return fmt.Errorf("row %d: price: %v", i+1, err)
+			}
+			movement.UnitCost = price.Mul(*rate)
+		}
+		c.movements = append(c.movements, movement)
+	}
+	return nil
+}
+
+//first tells if the document is added for the first time, the listings may return a document twice
+func (c *Collector) first(kind string, id int) bool {
+	key := kind + ":" + strconv.Itoa(id)
+	if c.seen[key] {
+		return false
+	}
+	c.seen[key] = true
+	return true
+}
+
+//currencyRate is the rate of the document currency on the document date from the rate table or the document
+func (c *Collector) currencyRate(code string, date time.Time, rate string) (sharedCommon.Decimal, error) {
+	if c.Rates != nil {
+		value, err := c.Rates.RecordRate(code, date, rate)
+		if err != nil {
+			return sharedCommon.Decimal{}, err
+		}
+		return sharedCommon.DecimalFromFloat(value), nil
+	}
+	value, err := sharedCommon.ParseDecimal(rate)
+	if err != nil {
+		return sharedCommon.Decimal{}, fmt.Errorf("invalid currency rate %q", rate)
+	}
+	if value.IsZero() {
+		return sharedCommon.DecimalFromInt(1), nil
+	}
+	return value, nil
+}
+
+//netPrice is the price after the discount percentage
+func netPrice(price, discount sharedCommon.Decimal) sharedCommon.Decimal {
+	if discount.IsZero() {
+		return price
+	}
+	hundred := sharedCommon.DecimalFromInt(100)
+	return price.Mul(hundred.Sub(discount)).Mul(sharedCommon.NewDecimal(1, 2))
+}
+
+//transactionDate is the date of the stock movement, the inventory transaction date when it's set
+func transactionDate(inventoryDate, date string) (time.Time, error) {
+	if inventoryDate != "" && inventoryDate != "0000-00-00" {
+		return parseDate(inventoryDate)
+	}
+	return parseDate(date)
+}
+
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(sharedCommon.DateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+func parseID(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
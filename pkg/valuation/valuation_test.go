@@ -0,0 +1,271 @@
+package valuation
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sharedCommon "github.com/bhojpur/erp/pkg/api/v1/common"
+	"github.com/bhojpur/erp/pkg/api/v1/purchase"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/bhojpur/erp/pkg/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestValuator(t *testing.T, method Method) *Valuator {
+	v, err := NewValuator(method)
+	assert.NoError(t, err)
+
+	assert.NoError(t, v.AddPurchases(
+		purchase.PurchaseDocument{ID: 1, Type: purchase.PurchaseInvoiceWaybill, Confirmed: 1, WarehouseID: 1, Number: "P1", Date: "2022-06-01",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "10", Price: "5"}}},
+		//the price is net of the discount and converted to the base currency
+		purchase.PurchaseDocument{ID: 2, Type: purchase.PurchaseWaybill, Confirmed: 1, WarehouseID: 1, Number: "P2", Date: "2022-06-03",
+			InventoryTransactionDate: "2022-06-05", CurrencyCode: "USD", CurrencyRate: "2",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "10", Price: "4", Discount: "12.5"}}},
+		purchase.PurchaseDocument{ID: 3, Type: purchase.PurchaseOrder, Confirmed: 1, WarehouseID: 1, Date: "2022-06-02",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "100", Price: "1"}}},
+	))
+	assert.NoError(t, v.AddSales(
+		sales.SaleDocument{ID: 20, Type: "INVWAYBILL", Confirmed: "1", WarehouseID: 1, Number: "S20", Date: "2022-06-10", Cost: 80,
+			InvoiceRows: []sales.InvoiceRow{
+				{ProductID: "5", Amount: "15", RowNetTotal: 150},
+				{ProductID: "0", ItemName: "Delivery", Amount: "1", RowNetTotal: 10},
+			}},
+		//the invoices without the waybill don't move the stock
+		sales.SaleDocument{ID: 22, Type: "INVOICE", Confirmed: "1", WarehouseID: 1, Date: "2022-06-10",
+			InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "1", RowNetTotal: 10}}},
+		sales.SaleDocument{ID: 21, Type: "CREDITINVOICE", Confirmed: "1", WarehouseID: 1, Number: "C21", Date: "2022-06-15",
+			BaseDocuments: []sales.BaseDocument{{ID: 20, Type: "INVWAYBILL"}},
+			InvoiceRows:   []sales.InvoiceRow{{ProductID: "5", Amount: "-2", RowNetTotal: -20}}},
+	))
+	assert.NoError(t, v.AddTransfers(
+		warehouse.InventoryTransfer{InventoryTransferID: 40, InventoryTransferNo: 4, Type: "TRANSFER", Confirmed: 1,
+			WarehouseFromID: 1, WarehouseToID: 2, Date: "2022-06-12", Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "3"}}},
+		warehouse.InventoryTransfer{InventoryTransferID: 41, Type: "TRANSFER_ORDER", Confirmed: 1,
+			WarehouseFromID: 1, WarehouseToID: 2, Date: "2022-06-12", Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "5"}}},
+	))
+	assert.NoError(t, v.AddWriteOffs(
+		warehouse.InventoryWriteOff{InventoryWriteOffID: 30, InventoryWriteOffNo: 3, Confirmed: 1, WarehouseID: 2, Date: "2022-06-20",
+			Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "5"}}},
+	))
+	assert.NoError(t, v.AddRegistrations(
+		warehouse.InventoryRegistration{InventoryRegistrationID: 50, Confirmed: 1, WarehouseID: 2, Date: "2022-06-25",
+			Rows: []warehouse.InventoryDocumentRow{{ProductID: 5, Amount: "4", Price: "4"}}},
+	))
+	return v
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name      string
+		method    Method
+		date      time.Time
+		stock     string
+		costs     string
+		shortages []string
+	}{
+		{
+			name:   "fifo before the sales",
+			method: FIFO,
+			date:   time.Date(2022, 6, 7, 0, 0, 0, 0, time.UTC),
+			stock: "date,warehouse_id,product_id,quantity,unit_cost,value\n" +
+				"2022-06-07,1,5,20,6.0000,120.00\n" +
+				"TOTAL,,,,,120.00\n",
+			costs: "date,document_id,number,kind,row,warehouse_id,product_id,quantity,cost,revenue,margin\n" +
+				"TOTAL,,,,,,,,0.00,0.00,0.00\n",
+			shortages: []string{},
+		},
+		{
+			//the transfer takes the newest layer at 7, the return comes back at the cost of the sale 85/15,
+			//the registration covers the shortage of the write-off first
+			name:   "fifo",
+			method: FIFO,
+			date:   time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC),
+			stock: "date,warehouse_id,product_id,quantity,unit_cost,value\n" +
+				"2022-06-30,1,5,4,6.3333,25.33\n" +
+				"2022-06-30,2,5,2,4.0000,8.00\n" +
+				"TOTAL,,,,,33.33\n",
+			costs: "date,document_id,number,kind,row,warehouse_id,product_id,quantity,cost,revenue,margin\n" +
+				"2022-06-10,20,S20,SALE,1,1,5,15,85.00,150.00,65.00\n" +
+				"2022-06-15,21,C21,SALE_RETURN,1,1,5,-2,-11.33,-20.00,-8.67\n" +
+				"TOTAL,,,,,,,,73.67,130.00,56.33\n",
+			shortages: []string{"WRITEOFF 3 warehouse 2 quantity 2"},
+		},
+		{
+			name:   "weighted average",
+			method: WeightedAverage,
+			date:   time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC),
+			stock: "date,warehouse_id,product_id,quantity,unit_cost,value\n" +
+				"2022-06-30,1,5,4,6.0000,24.00\n" +
+				"2022-06-30,2,5,2,4.0000,8.00\n" +
+				"TOTAL,,,,,32.00\n",
+			costs: "date,document_id,number,kind,row,warehouse_id,product_id,quantity,cost,revenue,margin\n" +
+				"2022-06-10,20,S20,SALE,1,1,5,15,90.00,150.00,60.00\n" +
+				"2022-06-15,21,C21,SALE_RETURN,1,1,5,-2,-12.00,-20.00,-8.00\n" +
+				"TOTAL,,,,,,,,78.00,130.00,52.00\n",
+			shortages: []string{"WRITEOFF 3 warehouse 2 quantity 2"},
+		},
+	}
+	for _, testCase := range testCases {
+		report := newTestValuator(t, testCase.method).Run(testCase.date)
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, WriteStockCSV(buf, report))
+		assert.Equal(t, testCase.stock, buf.String(), testCase.name)
+		buf.Reset()
+		assert.NoError(t, WriteCostsCSV(buf, report.Costs))
+		assert.Equal(t, testCase.costs, buf.String(), testCase.name)
+
+		shortages := []string{}
+		for _, shortage := range report.Shortages {
+			shortages = append(shortages, fmt.Sprintf("%s %s warehouse %d quantity %s", shortage.Kind, shortage.Number, shortage.WarehouseID, shortage.Quantity))
+		}
+		assert.Equal(t, testCase.shortages, shortages, testCase.name)
+	}
+}
+
+func TestReportPeriods(t *testing.T) {
+	report := newTestValuator(t, FIFO).Run(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	testCases := []struct {
+		name      string
+		from      time.Time
+		to        time.Time
+		costs     []int
+		documents []string
+	}{
+		{"whole report", time.Time{}, time.Time{}, []int{20, 21}, []string{"S20 85.00 80.00 5.00", "C21 -11.33 0.00 -11.33"}},
+		{"day of the sale", time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC), []int{20}, []string{"S20 85.00 80.00 5.00"}},
+		{"after the sale", time.Date(2022, 6, 11, 0, 0, 0, 0, time.UTC), time.Time{}, []int{21}, []string{"C21 -11.33 0.00 -11.33"}},
+	}
+	for _, testCase := range testCases {
+		costs := []int{}
+		for _, cost := range report.CostsBetween(testCase.from, testCase.to) {
+			costs = append(costs, cost.DocumentID)
+		}
+		assert.Equal(t, testCase.costs, costs, testCase.name)
+
+		documents := []string{}
+		for _, document := range report.DocumentCosts(testCase.from, testCase.to) {
+			documents = append(documents, fmt.Sprintf("%s %s %s %s", document.Number, document.Cost.StringFixed(2),
+				document.RecordedCost.StringFixed(2), document.Difference.StringFixed(2)))
+		}
+		assert.Equal(t, testCase.documents, documents, testCase.name)
+	}
+}
+
+func TestAverageLeavesNoResidue(t *testing.T) {
+	v, err := NewValuator(WeightedAverage)
+	assert.NoError(t, err)
+	assert.NoError(t, v.AddPurchases(purchase.PurchaseDocument{ID: 1, Type: purchase.PurchaseInvoiceWaybill, Confirmed: 1, WarehouseID: 1, Date: "2022-06-01",
+		Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "1", Price: "10"}, {ProductID: 5, Amount: "2", Price: "0"}, {ProductID: 5, Amount: "0", Price: "10"}}}))
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, v.AddSales(sales.SaleDocument{ID: i, Type: "CASHINVOICE", WarehouseID: 1, Date: "2022-06-02", Added: i,
+			InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "1"}}}))
+	}
+	one := sharedCommon.DecimalFromInt(1)
+	v.Add(Movement{Kind: Purchase, Date: time.Date(2022, 6, 3, 0, 0, 0, 0, time.UTC), ProductID: 5, WarehouseID: 1, Quantity: one, UnitCost: one})
+
+	report := v.Run(time.Date(2022, 6, 3, 0, 0, 0, 0, time.UTC))
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteCostsCSV(buf, report.Costs))
+	assert.Equal(t, "date,document_id,number,kind,row,warehouse_id,product_id,quantity,cost,revenue,margin\n"+
+		"2022-06-02,1,,SALE,1,1,5,1,3.33,0.00,-3.33\n"+
+		"2022-06-02,2,,SALE,1,1,5,1,3.33,0.00,-3.33\n"+
+		"2022-06-02,3,,SALE,1,1,5,1,3.33,0.00,-3.33\n"+
+		"TOTAL,,,,,,,,9.99,0.00,-9.99\n", buf.String())
+	//the last sale takes the remaining value so that the later receipts start from the empty stock
+	buf.Reset()
+	assert.NoError(t, WriteStockCSV(buf, report))
+	assert.Equal(t, "date,warehouse_id,product_id,quantity,unit_cost,value\n"+
+		"2022-06-03,1,5,1,1.0000,1.00\n"+
+		"TOTAL,,,,,1.00\n", buf.String())
+	assert.Empty(t, report.Shortages)
+}
+
+func TestRatesAndSkippedDocuments(t *testing.T) {
+	v, err := NewValuator(FIFO)
+	assert.NoError(t, err)
+	v.Rates = currency.NewTable("EUR")
+	assert.NoError(t, v.Rates.Add("USD", time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), 0.9))
+
+	assert.NoError(t, v.AddPurchases(
+		purchase.PurchaseDocument{ID: 1, Type: purchase.PurchaseReceipt, Confirmed: 1, WarehouseID: 1, Date: "2022-06-02", CurrencyCode: "USD", CurrencyRate: "0.5",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "10", Price: "10"}}},
+		//the documents which are repeated by the listing are added once
+		purchase.PurchaseDocument{ID: 1, Type: purchase.PurchaseReceipt, Confirmed: 1, WarehouseID: 1, Date: "2022-06-02",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "10", Price: "10"}}},
+		purchase.PurchaseDocument{ID: 2, Type: purchase.PurchaseReturn, Confirmed: 1, WarehouseID: 1, Date: "2022-06-03", CurrencyCode: "USD",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "-4", Price: "10"}}},
+		purchase.PurchaseDocument{ID: 3, Type: purchase.PurchaseReceipt, Confirmed: 0, WarehouseID: 1, Date: "2022-06-03",
+			Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "10", Price: "10"}}},
+	))
+	assert.NoError(t, v.AddSales(
+		sales.SaleDocument{ID: 1, Type: "WAYBILL", Confirmed: "0", WarehouseID: 1, Date: "2022-06-03", InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "1"}}},
+		sales.SaleDocument{ID: 2, Type: "WAYBILL", Confirmed: "1", InvoiceState: "CANCELLED", WarehouseID: 1, Date: "2022-06-03", InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "1"}}},
+	))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteStockCSV(buf, v.Run(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))))
+	assert.Equal(t, "date,warehouse_id,product_id,quantity,unit_cost,value\n"+
+		"2022-06-30,1,5,6,9.0000,54.00\n"+
+		"TOTAL,,,,,54.00\n", buf.String())
+
+	err = v.AddPurchases(purchase.PurchaseDocument{ID: 4, Type: purchase.PurchaseReceipt, Confirmed: 1, Date: "2022-05-01", CurrencyCode: "USD",
+		Rows: []purchase.PurchaseDocumentRow{{ProductID: 5, Amount: "1", Price: "1"}}})
+	assert.EqualError(t, err, "purchase document 4: no exchange rate of USD on 2022-05-01")
+	err = v.AddSales(sales.SaleDocument{ID: 5, Type: "WAYBILL", Date: "2022-06-01", InvoiceRows: []sales.InvoiceRow{{ProductID: "5", Amount: "x"}}})
+	assert.EqualError(t, err, `sales document 5 row 1: amount: invalid decimal "x"`)
+	err = v.AddRegistrations(warehouse.InventoryRegistration{InventoryRegistrationID: 6, Confirmed: 1, Date: "01.06.2022"})
+	assert.EqualError(t, err, `inventory registration 6: invalid date "01.06.2022"`)
+}
+
+func TestParseMethod(t *testing.T) {
+	for value, expected := range map[string]Method{"FIFO": FIFO, "average": WeightedAverage, " avg ": WeightedAverage, "weighted": WeightedAverage} {
+		method, err := ParseMethod(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, method)
+	}
+	_, err := ParseMethod("lifo")
+	assert.EqualError(t, err, `unknown costing method "lifo", expected fifo or average`)
+	_, err = NewValuator("lifo")
+	assert.True(t, errors.Is(err, ErrUnknownMethod))
+}
+
+func TestWriteCSV(t *testing.T) {
+	report := newTestValuator(t, FIFO).Run(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	//the stock and the cost rows are checked by TestRun
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteDocumentsCSV(buf, report.DocumentCosts(time.Time{}, time.Time{})))
+	assert.Equal(t, "date,document_id,number,cost,recorded_cost,difference\n"+
+		"2022-06-10,20,S20,85.00,80.00,5.00\n"+
+		"2022-06-15,21,C21,-11.33,0.00,-11.33\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteJSON(buf, report))
+	assert.Contains(t, buf.String(), `"value": 33.33`)
+}
@@ -0,0 +1,126 @@
+package webhook
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"sync"
+	"time"
+)
+
+//ClaimStatus is the outcome of claiming a delivery
+type ClaimStatus int
+
+const (
+	//Claimed means the delivery is new and the caller should process it
+	Claimed ClaimStatus = iota
+	//InProgress means another request is processing the delivery, it can still fail
+	InProgress
+	//Processed means the delivery was processed before
+	Processed
+)
+
+//DeliveryStore remembers the processed deliveries, so that repeated deliveries are not dispatched twice
+type DeliveryStore interface {
+	//Claim marks the delivery as being processed unless it was claimed before
+	Claim(deliveryID string) ClaimStatus
+	//Complete marks the claimed delivery as processed
+	Complete(deliveryID string)
+	//Release forgets the delivery after a failed processing, so that the repeated delivery is processed again
+	Release(deliveryID string)
+}
+
+//MemoryDeliveryStore keeps the delivery IDs in memory for TTL, it's enough for a single receiver instance
+type MemoryDeliveryStore struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	claimed map[string]claimState
+	//order of the claims used to drop the expired IDs without scanning the whole map
+	order []claim
+}
+
+type claim struct {
+	deliveryID string
+	at         time.Time
+}
+
+type claimState struct {
+	at        time.Time
+	processed bool
+}
+
+func NewMemoryDeliveryStore(ttl time.Duration) *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{
+		ttl:     ttl,
+		now:     time.Now,
+		claimed: map[string]claimState{},
+	}
+}
+
+func (ms *MemoryDeliveryStore) Claim(deliveryID string) ClaimStatus {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	now := ms.now()
+	ms.dropExpired(now)
+	if state, ok := ms.claimed[deliveryID]; ok {
+		if state.processed {
+			return Processed
+		}
+		return InProgress
+	}
+	ms.claimed[deliveryID] = claimState{at: now}
+	ms.order = append(ms.order, claim{deliveryID: deliveryID, at: now})
+
+	return Claimed
+}
+
+func (ms *MemoryDeliveryStore) Complete(deliveryID string) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if state, ok := ms.claimed[deliveryID]; ok {
+		state.processed = true
+		ms.claimed[deliveryID] = state
+	}
+}
+
+func (ms *MemoryDeliveryStore) Release(deliveryID string) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	delete(ms.claimed, deliveryID)
+}
+
+func (ms *MemoryDeliveryStore) dropExpired(now time.Time) {
+	expired := 0
+	for _, c := range ms.order {
+		if now.Sub(c.at) < ms.ttl {
+			break
+		}
+		//the ID could be released and claimed again later
+		if state, ok := ms.claimed[c.deliveryID]; ok && state.at.Equal(c.at) {
+			delete(ms.claimed, c.deliveryID)
+		}
+		expired++
+	}
+	ms.order = ms.order[expired:]
+}
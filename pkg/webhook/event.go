@@ -0,0 +1,62 @@
+package webhook
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+)
+
+//header names of the webhook deliveries
+const (
+	HeaderSignature  = "X-Erp-Signature"
+	HeaderSecret     = "X-Erp-Secret"
+	HeaderDeliveryID = "X-Erp-Delivery"
+	HeaderTimestamp  = "X-Erp-Timestamp"
+)
+
+//entities of the webhook events
+const (
+	EntityProducts               = "products"
+	EntitySaleDocuments          = "salesDocuments"
+	EntityCustomers              = "customers"
+	EntityPayments               = "payments"
+	EntityInventoryRegistrations = "inventoryRegistrations"
+	EntityInventoryWriteOffs     = "inventoryWriteOffs"
+	EntityInventoryTransfers     = "inventoryTransfers"
+)
+
+//actions of the webhook events
+const (
+	ActionInsert = "insert"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+//Event is the body of a webhook delivery, the records have the same format as the records of the get API calls
+type Event struct {
+	//DeliveryID is the same for the repeated deliveries of the event, the HeaderDeliveryID header is used if it's empty
+	DeliveryID string            `json:"deliveryID"`
+	ClientCode string            `json:"clientCode"`
+	Entity     string            `json:"entity"`
+	Action     string            `json:"action"`
+	Timestamp  int64             `json:"timestamp"`
+	Records    []json.RawMessage `json:"records"`
+}
@@ -0,0 +1,237 @@
+package webhook
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/customer"
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+)
+
+const DefaultMaxBodyBytes = 10 << 20
+const DefaultDeliveryTTL = 24 * time.Hour
+
+//EventHandler processes an event, an error makes the receiver respond with 500 so that the platform repeats the delivery
+type EventHandler func(ctx context.Context, event Event) error
+
+//Handler is the http.Handler receiving the webhook deliveries. The handlers should be registered before serving
+type Handler struct {
+	verifier Verifier
+	store    DeliveryStore
+	handlers map[string]EventHandler
+	//MaxBodyBytes limits the delivery size, DefaultMaxBodyBytes is used if it's zero
+	MaxBodyBytes int64
+	//ErrorLog gets the reasons of the rejected deliveries and the handler errors, optional
+	ErrorLog func(err error)
+}
+
+//NewHandler creates the receiver, all deliveries are rejected if the verifier is nil.
+//If the store is nil, the deliveries are deduplicated in memory for DefaultDeliveryTTL
+func NewHandler(verifier Verifier, store DeliveryStore) *Handler {
+	if store == nil {
+		store = NewMemoryDeliveryStore(DefaultDeliveryTTL)
+	}
+
+	return &Handler{
+		verifier: verifier,
+		store:    store,
+		handlers: map[string]EventHandler{},
+	}
+}
+
+//HandleEntity registers the handler of the raw events of the entity, it replaces the previous handler of the entity
+func (h *Handler) HandleEntity(entity string, handler EventHandler) {
+	h.handlers[entity] = handler
+}
+
+func (h *Handler) HandleProducts(handler func(ctx context.Context, event Event, products []product.Product) error) {
+	h.HandleEntity(EntityProducts, func(ctx context.Context, event Event) error {
+		products := make([]product.Product, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &products[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, products)
+	})
+}
+
+func (h *Handler) HandleSaleDocuments(handler func(ctx context.Context, event Event, documents []sales.SaleDocument) error) {
+	h.HandleEntity(EntitySaleDocuments, func(ctx context.Context, event Event) error {
+		documents := make([]sales.SaleDocument, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &documents[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, documents)
+	})
+}
+
+func (h *Handler) HandleCustomers(handler func(ctx context.Context, event Event, customers []customer.Customer) error) {
+	h.HandleEntity(EntityCustomers, func(ctx context.Context, event Event) error {
+		customers := make([]customer.Customer, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &customers[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, customers)
+	})
+}
+
+func (h *Handler) HandlePayments(handler func(ctx context.Context, event Event, payments []sales.PaymentInfo) error) {
+	h.HandleEntity(EntityPayments, func(ctx context.Context, event Event) error {
+		payments := make([]sales.PaymentInfo, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &payments[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, payments)
+	})
+}
+
+func (h *Handler) HandleInventoryRegistrations(handler func(ctx context.Context, event Event, registrations []warehouse.InventoryRegistration) error) {
+	h.HandleEntity(EntityInventoryRegistrations, func(ctx context.Context, event Event) error {
+		registrations := make([]warehouse.InventoryRegistration, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &registrations[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, registrations)
+	})
+}
+
+func (h *Handler) HandleInventoryWriteOffs(handler func(ctx context.Context, event Event, writeOffs []warehouse.InventoryWriteOff) error) {
+	h.HandleEntity(EntityInventoryWriteOffs, func(ctx context.Context, event Event) error {
+		writeOffs := make([]warehouse.InventoryWriteOff, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &writeOffs[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, writeOffs)
+	})
+}
+
+func (h *Handler) HandleInventoryTransfers(handler func(ctx context.Context, event Event, transfers []warehouse.InventoryTransfer) error) {
+	h.HandleEntity(EntityInventoryTransfers, func(ctx context.Context, event Event) error {
+		transfers := make([]warehouse.InventoryTransfer, len(event.Records))
+		if err := decodeRecords(event, func(i int) interface{} { return &transfers[i] }); err != nil {
+			return err
+		}
+		return handler(ctx, event, transfers)
+	})
+}
+
+//decodingError is a permanent failure, repeating the delivery won't help
+type decodingError struct {
+	error
+}
+
+//decodeRecords decodes the records of the event into the targets of their indexes
+func decodeRecords(event Event, target func(i int) interface{}) error {
+	for i, raw := range event.Records {
+		if err := json.Unmarshal(raw, target(i)); err != nil {
+			return decodingError{fmt.Errorf("failed to decode %s record %d: %v", event.Entity, i, err)}
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxBodyBytes := h.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		h.reject(w, fmt.Errorf("failed to read webhook body: %v", err), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if h.verifier == nil {
+		h.reject(w, ErrInvalidSignature, http.StatusUnauthorized)
+		return
+	}
+	if err := h.verifier.Verify(r, body); err != nil {
+		h.reject(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	event := Event{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.reject(w, fmt.Errorf("failed to decode webhook event: %v", err), http.StatusBadRequest)
+		return
+	}
+	if event.DeliveryID == "" {
+		event.DeliveryID = r.Header.Get(HeaderDeliveryID)
+	}
+	if event.DeliveryID == "" {
+		sum := sha256.Sum256(body)
+		event.DeliveryID = hex.EncodeToString(sum[:])
+	}
+
+	handler, ok := h.handlers[event.Entity]
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch h.store.Claim(event.DeliveryID) {
+	case Processed:
+		w.WriteHeader(http.StatusOK)
+		return
+	case InProgress:
+		//the running processing can still fail, so the duplicate is not acknowledged and the platform repeats it
+		w.Header().Set("Retry-After", "1")
+		h.reject(w, fmt.Errorf("%s %s delivery %s is being processed", event.Entity, event.Action, event.DeliveryID), http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := handler(r.Context(), event); err != nil {
+		h.store.Release(event.DeliveryID)
+		if errors.As(err, &decodingError{}) {
+			h.reject(w, err, http.StatusBadRequest)
+			return
+		}
+		h.reject(w, fmt.Errorf("failed to handle %s %s delivery %s: %v", event.Entity, event.Action, event.DeliveryID, err), http.StatusInternalServerError)
+		return
+	}
+	h.store.Complete(event.DeliveryID)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) reject(w http.ResponseWriter, err error, status int) {
+	if h.ErrorLog != nil {
+		h.ErrorLog(err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,279 @@
+package webhook
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/erp/pkg/api/v1/product"
+	"github.com/bhojpur/erp/pkg/api/v1/sales"
+	"github.com/bhojpur/erp/pkg/api/v1/warehouse"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSecret = []byte("some secret")
+
+type receivedEvents struct {
+	lock      sync.Mutex
+	products  []product.Product
+	documents []sales.SaleDocument
+	transfers []warehouse.InventoryTransfer
+	actions   []string
+}
+
+func newTestReceiver(t *testing.T, failProducts int) (*httptest.Server, *receivedEvents) {
+	received := &receivedEvents{}
+	handler := NewHandler(HMACVerifier{Secret: testSecret}, nil)
+	handler.HandleProducts(func(ctx context.Context, event Event, products []product.Product) error {
+		received.lock.Lock()
+		defer received.lock.Unlock()
+		if failProducts > 0 {
+			failProducts--
+			return errors.New("database is down")
+		}
+		received.products = append(received.products, products...)
+		received.actions = append(received.actions, event.Action)
+		return nil
+	})
+	handler.HandleSaleDocuments(func(ctx context.Context, event Event, documents []sales.SaleDocument) error {
+		received.lock.Lock()
+		defer received.lock.Unlock()
+		received.documents = append(received.documents, documents...)
+		return nil
+	})
+	handler.HandleInventoryTransfers(func(ctx context.Context, event Event, transfers []warehouse.InventoryTransfer) error {
+		received.lock.Lock()
+		defer received.lock.Unlock()
+		received.transfers = append(received.transfers, transfers...)
+		return nil
+	})
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return srv, received
+}
+
+func TestTypedDispatch(t *testing.T) {
+	srv, received := newTestReceiver(t, 0)
+	sender := Sender{URL: srv.URL, HMACSecret: testSecret}
+
+	productsEvent, err := NewEvent("123", EntityProducts, ActionUpdate, []product.Product{{ProductID: 1, Code: "A1"}, {ProductID: 2, Code: "A2"}})
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), productsEvent))
+
+	documentsEvent, err := NewEvent("123", EntitySaleDocuments, ActionInsert, []sales.SaleDocument{{ID: 7, Number: "INV-7"}})
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), documentsEvent))
+
+	transfersEvent, err := NewEvent("123", EntityInventoryTransfers, ActionInsert, []warehouse.InventoryTransfer{{
+		InventoryTransferID: 3,
+		WarehouseFromID:     1,
+		WarehouseToID:       2,
+		Rows:                []warehouse.InventoryDocumentRow{{ProductID: 1, Amount: "5"}},
+	}})
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), transfersEvent))
+
+	unknownEvent, err := NewEvent("123", EntityCustomers, ActionDelete, []map[string]int{{"customerID": 1}})
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), unknownEvent))
+
+	if assert.Len(t, received.products, 2) {
+		assert.Equal(t, "A2", received.products[1].Code)
+	}
+	assert.Equal(t, []string{ActionUpdate}, received.actions)
+	if assert.Len(t, received.documents, 1) {
+		assert.Equal(t, "INV-7", received.documents[0].Number)
+	}
+	if assert.Len(t, received.transfers, 1) {
+		assert.Equal(t, "5", received.transfers[0].Rows[0].Amount.String())
+	}
+}
+
+func TestRepeatedDeliveryIsDispatchedOnce(t *testing.T) {
+	srv, received := newTestReceiver(t, 0)
+	sender := Sender{URL: srv.URL, HMACSecret: testSecret}
+
+	event, err := NewEvent("123", EntityProducts, ActionInsert, []product.Product{{ProductID: 1}})
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), event))
+	assert.NoError(t, sender.Send(context.Background(), event))
+
+	assert.Len(t, received.products, 1)
+}
+
+func TestFailedDeliveryIsProcessedWhenRepeated(t *testing.T) {
+	srv, received := newTestReceiver(t, 1)
+	sender := Sender{URL: srv.URL, HMACSecret: testSecret}
+
+	event, err := NewEvent("123", EntityProducts, ActionInsert, []product.Product{{ProductID: 1}})
+	assert.NoError(t, err)
+
+	err = sender.Send(context.Background(), event)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 500")
+
+	assert.NoError(t, sender.Send(context.Background(), event))
+	assert.Len(t, received.products, 1)
+}
+
+func TestConcurrentDuplicateIsNotAcknowledged(t *testing.T) {
+	started := make(chan struct{})
+	proceed := make(chan error)
+	handled := 0
+	handler := NewHandler(HMACVerifier{Secret: testSecret}, nil)
+	handler.HandleProducts(func(ctx context.Context, event Event, products []product.Product) error {
+		handled++
+		if handled == 1 {
+			close(started)
+			return <-proceed
+		}
+		return nil
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	sender := Sender{URL: srv.URL, HMACSecret: testSecret}
+
+	event, err := NewEvent("123", EntityProducts, ActionInsert, []product.Product{{ProductID: 1}})
+	assert.NoError(t, err)
+
+	firstErr := make(chan error)
+	go func() {
+		firstErr <- sender.Send(context.Background(), event)
+	}()
+	<-started
+
+	err = sender.Send(context.Background(), event)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "status 503")
+	}
+
+	proceed <- errors.New("database is down")
+	assert.Error(t, <-firstErr)
+
+	assert.NoError(t, sender.Send(context.Background(), event))
+	assert.NoError(t, sender.Send(context.Background(), event))
+	assert.Equal(t, 2, handled)
+}
+
+func TestInvalidSignatureIsRejected(t *testing.T) {
+	srv, received := newTestReceiver(t, 0)
+
+	event, err := NewEvent("123", EntityProducts, ActionInsert, []product.Product{{ProductID: 1}})
+	assert.NoError(t, err)
+
+	err = Sender{URL: srv.URL, HMACSecret: []byte("wrong")}.Send(context.Background(), event)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+
+	err = Sender{URL: srv.URL}.Send(context.Background(), event)
+	assert.Error(t, err)
+
+	assert.Empty(t, received.products)
+}
+
+func TestUndecodableRecordsAreRejected(t *testing.T) {
+	srv, _ := newTestReceiver(t, 0)
+
+	event, err := NewEvent("123", EntityProducts, ActionInsert, []map[string]string{{"productID": "not a number"}})
+	assert.NoError(t, err)
+
+	err = Sender{URL: srv.URL, HMACSecret: testSecret}.Send(context.Background(), event)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 400")
+}
+
+func TestOnlyPostIsAllowed(t *testing.T) {
+	srv, _ := newTestReceiver(t, 0)
+
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHMACVerifierMaxAge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := HMACVerifier{Secret: testSecret, MaxAge: time.Minute, Now: func() time.Time { return now }}
+	body := []byte(`{"entity":"products"}`)
+
+	newRequest := func(timestamp string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(testSecret, timestamp, body))
+		return req
+	}
+
+	assert.NoError(t, verifier.Verify(newRequest("1699999990"), body))
+	assert.EqualError(t, verifier.Verify(newRequest("1699999000"), body), "webhook delivery is too old")
+
+	tampered := newRequest("1699999990")
+	assert.Equal(t, ErrInvalidSignature, verifier.Verify(tampered, []byte(`{"entity":"customers"}`)))
+}
+
+func TestHMACVerifierWithoutSecret(t *testing.T) {
+	body := []byte(`{"entity":"products"}`)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	req.Header.Set(HeaderSignature, Sign(nil, "", body))
+
+	assert.Equal(t, ErrMissingSecret, HMACVerifier{}.Verify(req, body))
+}
+
+func TestSharedSecretVerifier(t *testing.T) {
+	verifier := SharedSecretVerifier{Secret: "abc"}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	assert.Equal(t, ErrInvalidSignature, verifier.Verify(req, nil))
+
+	req.Header.Set(HeaderSecret, "abc")
+	assert.NoError(t, verifier.Verify(req, nil))
+
+	assert.Equal(t, ErrMissingSecret, SharedSecretVerifier{}.Verify(httptest.NewRequest(http.MethodPost, "/", nil), nil))
+}
+
+func TestMemoryDeliveryStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryDeliveryStore(time.Hour)
+	store.now = func() time.Time { return now }
+
+	assert.Equal(t, Claimed, store.Claim("a"))
+	assert.Equal(t, InProgress, store.Claim("a"))
+
+	store.Release("a")
+	assert.Equal(t, Claimed, store.Claim("a"))
+	store.Complete("a")
+	assert.Equal(t, Processed, store.Claim("a"))
+
+	now = now.Add(30 * time.Minute)
+	assert.Equal(t, Claimed, store.Claim("b"))
+	now = now.Add(31 * time.Minute)
+	assert.Equal(t, Claimed, store.Claim("a"))
+	assert.Equal(t, InProgress, store.Claim("b"))
+	assert.Len(t, store.claimed, 2)
+}
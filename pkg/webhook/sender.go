@@ -0,0 +1,119 @@
+package webhook
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//Sender delivers events the same way as the platform does, it's meant for simulating events locally and in tests
+type Sender struct {
+	URL string
+	//HMACSecret signs the deliveries if it's set
+	HMACSecret []byte
+	//SharedSecret is sent in the HeaderSecret header if it's set
+	SharedSecret string
+	HTTPClient   *http.Client
+	//Now gives the delivery timestamp, time.Now if nil
+	Now func() time.Time
+}
+
+//NewEvent creates an event with a random delivery ID, records should be a slice of the models e.g. []product.Product
+func NewEvent(clientCode, entity, action string, records interface{}) (Event, error) {
+	event := Event{
+		ClientCode: clientCode,
+		Entity:     entity,
+		Action:     action,
+		Timestamp:  time.Now().Unix(),
+	}
+
+	raw, err := json.Marshal(records)
+	if err != nil {
+		return event, err
+	}
+	if err := json.Unmarshal(raw, &event.Records); err != nil {
+		return event, fmt.Errorf("records should be a list: %v", err)
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return event, err
+	}
+	event.DeliveryID = hex.EncodeToString(idBytes)
+
+	return event, nil
+}
+
+//Send delivers the event, sending the same event again simulates a repeated delivery
+func (s Sender) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, event.DeliveryID)
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if len(s.HMACSecret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(s.HMACSecret, timestamp, body))
+	}
+	if s.SharedSecret != "" {
+		req.Header.Set(HeaderSecret, s.SharedSecret)
+	}
+
+	cli := s.HTTPClient
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook delivery %s failed with status %d: %s", event.DeliveryID, resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package webhook
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const signaturePrefix = "sha256="
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+//ErrMissingSecret is returned by the verifiers without a secret, so a missing configuration doesn't accept every delivery
+var ErrMissingSecret = errors.New("webhook secret is not set")
+
+//Verifier checks that the delivery comes from the platform, body is the raw request body
+type Verifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+//HMACVerifier checks the HeaderSignature header which should be "sha256=" followed by the hex encoded HMAC-SHA256
+//of the body or, if the HeaderTimestamp header is set, of the timestamp, a dot and the body
+type HMACVerifier struct {
+	Secret []byte
+	//MaxAge rejects the deliveries with a timestamp older than this, zero disables the check
+	MaxAge time.Duration
+	//Now is used for the MaxAge check, time.Now if nil
+	Now func() time.Time
+}
+
+func (hv HMACVerifier) Verify(r *http.Request, body []byte) error {
+	if len(hv.Secret) == 0 {
+		return ErrMissingSecret
+	}
+	signature := r.Header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	actualMAC, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	timestamp := r.Header.Get(HeaderTimestamp)
+	if !hmac.Equal(actualMAC, computeMAC(hv.Secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+
+	if hv.MaxAge > 0 {
+		unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return errors.New("webhook timestamp is missing")
+		}
+		now := time.Now
+		if hv.Now != nil {
+			now = hv.Now
+		}
+		if now().Sub(time.Unix(unixTime, 0)) > hv.MaxAge {
+			return errors.New("webhook delivery is too old")
+		}
+	}
+
+	return nil
+}
+
+//Sign gives the HeaderSignature value for the body, timestamp can be empty
+func Sign(secret []byte, timestamp string, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(computeMAC(secret, timestamp, body))
+}
+
+func computeMAC(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	if timestamp != "" {
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+
+	return mac.Sum(nil)
+}
+
+//SharedSecretVerifier compares the HeaderSecret header with the secret, it's meant for the setups where
+//the deliveries can't be signed, HMACVerifier should be preferred
+type SharedSecretVerifier struct {
+	Secret string
+}
+
+func (sv SharedSecretVerifier) Verify(r *http.Request, body []byte) error {
+	if sv.Secret == "" {
+		return ErrMissingSecret
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(HeaderSecret)), []byte(sv.Secret)) != 1 {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}